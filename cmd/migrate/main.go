@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ridhomain/proto-trading-service/internal/config"
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up          apply all pending migrations
+  down [n]    revert the last n applied migrations (default 1)
+  status      list migrations and when they were applied
+
+DATABASE_URL selects the database, as for the server.`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fail("Failed to load config: %v", err)
+	}
+	if err := logger.Init(cfg.Logger.Environment, cfg.Logger.Level); err != nil {
+		fail("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		fail("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch os.Args[1] {
+	case "up":
+		applied, err := db.MigrateUp(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied  %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fail("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				fail("down takes a positive number of steps")
+			}
+		}
+		reverted, err := db.MigrateDown(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fail("Migration failed: %v", err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no applied migrations to revert")
+		}
+
+	case "status":
+		states, err := db.MigrationStatus(ctx)
+		if err != nil {
+			fail("Failed to read migration status: %v", err)
+		}
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%03d_%-32s %s\n", s.Version, s.Name, applied)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func fail(format string, args ...interface{}) {
+	logger.Sync()
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ridhomain/proto-trading-service/internal/config"
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/refdata"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+)
+
+const usage = `Usage: refdata <command> [flags]
+
+Commands:
+  export [-o file]                  write exchanges, calendars, sources and symbols as a YAML bundle
+                                    (stdout by default)
+  import [-dry-run] [-prune] file   upsert a bundle in one transaction and report what changed;
+                                    -dry-run rolls back, -prune deletes holidays the bundle omits
+
+DATABASE_URL selects the database, as for the server. Exports are sorted and carry
+no timestamps, so bundles from two environments can be compared with diff.`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fail("Failed to load config: %v", err)
+	}
+	if err := logger.Init(cfg.Logger.Environment, cfg.Logger.Level); err != nil {
+		fail("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	ctx := context.Background()
+	switch os.Args[1] {
+	case "export":
+		flags := flag.NewFlagSet("export", flag.ExitOnError)
+		output := flags.String("o", "", "file to write (default stdout)")
+		flags.Parse(os.Args[2:])
+
+		db := connect(cfg)
+		defer db.Close()
+
+		bundle, err := refdata.Export(ctx, db)
+		if err != nil {
+			fail("Export failed: %v", err)
+		}
+
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				fail("Failed to create %s: %v", *output, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := refdata.Write(w, bundle); err != nil {
+			fail("Failed to write bundle: %v", err)
+		}
+		if *output != "" {
+			fmt.Fprintf(os.Stderr, "exported %d exchanges, %d sources and %d symbols to %s\n",
+				len(bundle.Exchanges), len(bundle.Sources), len(bundle.Symbols), *output)
+		}
+
+	case "import":
+		flags := flag.NewFlagSet("import", flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "report changes without applying them")
+		prune := flags.Bool("prune", false, "delete holidays of bundled exchanges that the bundle omits")
+		flags.Parse(os.Args[2:])
+		if flags.NArg() != 1 {
+			fail("import takes one bundle file")
+		}
+
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fail("Failed to open bundle: %v", err)
+		}
+		bundle, err := refdata.Read(f)
+		f.Close()
+		if err != nil {
+			fail("%v", err)
+		}
+
+		db := connect(cfg)
+		defer db.Close()
+
+		result, err := refdata.Import(ctx, db, bundle, refdata.ImportOptions{DryRun: *dryRun, Prune: *prune})
+		if err != nil {
+			fail("Import failed: %v", err)
+		}
+		for _, section := range []struct {
+			name   string
+			counts refdata.Counts
+		}{
+			{"exchanges", result.Exchanges},
+			{"holidays", result.Holidays},
+			{"sources", result.Sources},
+			{"symbols", result.Symbols},
+		} {
+			c := section.counts
+			fmt.Printf("%-10s %5d inserted %5d updated %5d unchanged %5d deleted\n",
+				section.name, c.Inserted, c.Updated, c.Unchanged, c.Deleted)
+		}
+		if *dryRun {
+			fmt.Println("dry run: nothing was changed")
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func connect(cfg *config.Config) *database.DB {
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		fail("Failed to connect to database: %v", err)
+	}
+	return db
+}
+
+func fail(format string, args ...interface{}) {
+	logger.Sync()
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
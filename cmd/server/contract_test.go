@@ -0,0 +1,775 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/cache"
+	"github.com/ridhomain/proto-trading-service/internal/changelog"
+	"github.com/ridhomain/proto-trading-service/internal/clients/alphavantage"
+	"github.com/ridhomain/proto-trading-service/internal/clients/binance"
+	"github.com/ridhomain/proto-trading-service/internal/clients/fundnav"
+	"github.com/ridhomain/proto-trading-service/internal/clients/fxfeed"
+	"github.com/ridhomain/proto-trading-service/internal/clients/yahoo"
+	"github.com/ridhomain/proto-trading-service/internal/config"
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/datasource"
+	"github.com/ridhomain/proto-trading-service/internal/handlers"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/provisioning"
+	"github.com/ridhomain/proto-trading-service/internal/scheduler"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+	"github.com/ridhomain/proto-trading-service/internal/storage"
+	"github.com/ridhomain/proto-trading-service/internal/stream"
+	"github.com/ridhomain/proto-trading-service/internal/wal"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Run with TEST_DATABASE_URL pointing at a disposable database. Its tables are
+// truncated and reseeded. Pass -update to rewrite the golden files after an
+// intended change to a response shape.
+var update = flag.Bool("update", false, "rewrite golden response files")
+
+const (
+	contractUserID = "00000000-0000-0000-0000-000000000001"
+	contractToken  = "contract-session"
+	goldenDir      = "testdata/golden"
+)
+
+// volatileKeys hold values that change between runs; they are replaced before comparing
+var volatileKeys = map[string]bool{
+	"created_at":         true,
+	"updated_at":         true,
+	"completed_at":       true,
+	"reviewed_at":        true,
+	"applied_at":         true,
+	"recorded_at":        true,
+	"expires_at":         true,
+	"reset_at":           true,
+	"last_ingest_at":     true,
+	"data_as_of":         true,
+	"timestamp":          true,
+	"took_ms":            true,
+	"token":              true,
+	"confirmation_token": true,
+	"download_url":       true,
+	"request_id":         true,
+	"first_seen_at":      true,
+	"last_seen_at":       true,
+}
+
+// contractCase is one request; cases run in order and may depend on earlier writes
+type contractCase struct {
+	name   string
+	method string
+	path   string
+	body   string
+	csv    string   // sent as a multipart file upload instead of body
+	mask   []string // keys masked in this case only, e.g. values that depend on the clock
+}
+
+var contractCases = []contractCase{
+	{name: "health", method: http.MethodGet, path: "/health"},
+	{name: "ready", method: http.MethodGet, path: "/ready"},
+
+	// Market data reads
+	{name: "market_data_page", method: http.MethodGet, path: "/api/v1/market-data?symbol=BBCA.JK&per_page=2"},
+	{name: "market_data_page_too_deep", method: http.MethodGet, path: "/api/v1/market-data?symbol=BBCA.JK&per_page=2&page=999999999999"},
+	{name: "market_data_range", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-02&end_date=2025-01-08"},
+	{name: "market_data_range_source", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-02&end_date=2025-01-08&source=mirae"},
+	{name: "market_data_range_merged", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-02&end_date=2025-01-08&source=merged"},
+	// A merged read ranks the user's default source first; the default is restored after
+	{name: "preferences_default_source_mirae", method: http.MethodPut, path: "/api/v1/preferences", body: `{"default_source":"mirae"}`},
+	{name: "market_data_range_merged_preferred", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-02&end_date=2025-01-08&source=merged"},
+	{name: "market_data_as_of_merged_preferred", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-02&end_date=2025-01-08&as_of=2030-01-01T00:00:00Z&source=merged"},
+	{name: "market_data_aggregate_merged_preferred", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/aggregate?interval=monthly&start_date=2025-01-01&end_date=2025-01-31&source=merged"},
+	{name: "preferences_default_source_any", method: http.MethodPut, path: "/api/v1/preferences", body: `{"default_source":"any"}`},
+	{name: "market_data_compare", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/compare?sources=yahoo,mirae&start_date=2025-01-02&end_date=2025-01-08"},
+	{name: "market_data_compare_discrepant_only", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/compare?sources=mirae,yahoo&start_date=2025-01-02&end_date=2025-01-08&tolerance=0.1&discrepant_only=true"},
+	{name: "market_data_compare_one_source", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/compare?sources=yahoo,yahoo"},
+	{name: "market_data_compare_unknown_source", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/compare?sources=yahoo,nope"},
+	{name: "market_data_as_of", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-02&end_date=2025-01-08&as_of=2030-01-01T00:00:00Z"},
+	{name: "market_data_ingested_before", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-02&end_date=2025-01-08&ingested_before=2030-01-01T00:00:00Z"},
+	{name: "market_data_ingested_before_empty", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-02&end_date=2025-01-08&ingested_before=2000-01-01T00:00:00Z"},
+	{name: "market_data_ingested_before_invalid", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?ingested_before=2025-01-08"},
+	{name: "market_data_ingested_before_with_as_of", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?as_of=2030-01-01T00:00:00Z&ingested_before=2030-01-01T00:00:00Z"},
+	{name: "market_data_debug_meta", method: http.MethodGet, path: "/api/v1/market-data?symbol=TLKM.JK&per_page=2&debug_meta=true"},
+	{name: "market_data_page_hourly", method: http.MethodGet, path: "/api/v1/market-data?symbol=BBCA.JK&per_page=2&interval=1h"},
+	{name: "market_data_range_hourly", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-07&end_date=2025-01-07&interval=1h"},
+	{name: "market_data_invalid_interval", method: http.MethodGet, path: "/api/v1/market-data?symbol=BBCA.JK&interval=2h"},
+	{name: "market_data_aggregate_weekly", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/aggregate?interval=weekly&start_date=2025-01-01&end_date=2025-01-31"},
+	{name: "market_data_aggregate_monthly", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/aggregate?interval=monthly&start_date=2025-01-01&end_date=2025-01-31&source=any"},
+	{name: "market_data_aggregate_invalid", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/aggregate?interval=daily"},
+	{name: "market_data_gaps", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/gaps"},
+	{name: "market_data_gaps_range", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/gaps?start_date=2025-01-01&end_date=2025-01-10&source=yahoo"},
+	{name: "market_data_profile", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/profile?start_date=2025-01-02&end_date=2025-01-08"},
+	{name: "market_data_export_json", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/export?format=json&start_date=2025-01-02&end_date=2025-01-08&source=yahoo"},
+	{name: "market_data_export_invalid_format", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/export?format=pdf"},
+	{name: "market_data_export_empty", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK/export?start_date=2024-01-01&end_date=2024-01-31"},
+	{name: "quote", method: http.MethodGet, path: "/api/v1/quote/BBCA.JK", mask: []string{"market_state", "as_of"}},
+	{name: "stream_sse_missing_symbols", method: http.MethodGet, path: "/api/v1/stream/sse"},
+	{name: "stream_unknown_channel", method: http.MethodGet, path: "/api/v1/stream/market-data?channels=alerts"},
+	{name: "stream_invalid_resume_after", method: http.MethodGet, path: "/api/v1/stream/market-data?resume_after=latest"},
+	{name: "sources", method: http.MethodGet, path: "/api/v1/sources"},
+	{name: "source_ingest_window", method: http.MethodPut, path: "/api/v1/sources/binance/ingest-window", body: `{"window_minutes":1440}`},
+	{name: "source_ingest_window_invalid", method: http.MethodPut, path: "/api/v1/sources/binance/ingest-window", body: `{"window_minutes":0}`},
+	{name: "source_ingest_window_missing", method: http.MethodPut, path: "/api/v1/sources/nope/ingest-window", body: `{"window_minutes":60}`},
+	{name: "source_transform_rules", method: http.MethodPut, path: "/api/v1/sources/mirae/transform-rules", body: `{"rules":[{"kind":"scale_prices","factor":1000,"symbol_suffix":".JK"}]}`},
+	{name: "source_transform_rules_invalid", method: http.MethodPut, path: "/api/v1/sources/mirae/transform-rules", body: `{"rules":[{"kind":"round"},{"kind":"scale_prices"}]}`},
+	{name: "source_transform_rules_missing", method: http.MethodPut, path: "/api/v1/sources/nope/transform-rules", body: `{"rules":[]}`},
+	{name: "source_transform_preview", method: http.MethodPost, path: "/api/v1/sources/yahoo/transform-rules/preview", body: `{"rules":[{"kind":"volume_to_lots","symbol_suffix":".JK"},{"kind":"strip_suffix","suffix":".JK"}],"data":[{"symbol":"BBCA.JK","date":"2025-01-07T00:00:00Z","open":8550,"high":8600,"low":8500,"close":8575,"volume":1234500}]}`},
+	{name: "anomalies", method: http.MethodGet, path: "/api/v1/anomalies"},
+	{name: "reconcile", method: http.MethodGet, path: "/api/v1/admin/reconcile?symbol=BBCA.JK&start=2025-01-02&end=2025-01-08"},
+
+	// Roles and permissions
+	{name: "role_set_permissions", method: http.MethodPut, path: "/api/v1/admin/roles/analyst", body: `{"permissions":["symbols:write","fx:*"]}`},
+	{name: "role_set_invalid_permission", method: http.MethodPut, path: "/api/v1/admin/roles/analyst", body: `{"permissions":["delete everything"]}`},
+	{name: "roles", method: http.MethodGet, path: "/api/v1/admin/roles"},
+	{name: "permissions_self", method: http.MethodGet, path: "/api/v1/admin/permissions"},
+	{name: "permissions_roles", method: http.MethodGet, path: "/api/v1/admin/permissions?roles=analyst,trader"},
+	{name: "market_data_range_exchange", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-02&end_date=2025-01-08&exchange=us"},
+	{name: "market_data_unknown_symbol", method: http.MethodGet, path: "/api/v1/market-data?symbol=NOPE.JK"},
+
+	// Symbol reference data
+	{name: "symbols", method: http.MethodGet, path: "/api/v1/symbols?exchange=IDX"},
+	{name: "symbol_create", method: http.MethodPost, path: "/api/v1/symbols",
+		body: `{"symbol":"GOTO.JK","name":"GoTo Gojek Tokopedia Tbk","sector":"Technology"}`},
+	{name: "symbol_update", method: http.MethodPut, path: "/api/v1/symbols/IDX/BBCA.JK", body: `{"name":"Bank Central Asia Tbk","sector":"Financials"}`},
+	{name: "symbol_get", method: http.MethodGet, path: "/api/v1/symbols/IDX/BBCA.JK"},
+	{name: "symbols_search", method: http.MethodGet, path: "/api/v1/symbols?search=bank"},
+	{name: "symbols_upload", method: http.MethodPost, path: "/api/v1/symbols/upload",
+		csv: "Exchange,Symbol,Name,Sector,Currency,LotSize\nUS,AAPL,Apple Inc.,Technology,,\n,ETH-USDT,Ether / Tether,,,\n"},
+	{name: "fundamentals_upsert", method: http.MethodPost, path: "/api/v1/symbols/fundamentals",
+		body: `{"data":[{"symbol":"BBCA.JK","date":"2025-01-07","shares_outstanding":123275050000,"free_float_pct":42.6}]}`},
+	{name: "fundamentals_latest", method: http.MethodGet, path: "/api/v1/symbols/BBCA.JK/fundamentals"},
+	{name: "fundamentals_history", method: http.MethodGet, path: "/api/v1/symbols/BBCA.JK/fundamentals?history=true&start_date=2025-01-02&end_date=2025-01-08"},
+	{name: "fundamentals_missing", method: http.MethodGet, path: "/api/v1/symbols/BTC-USDT/fundamentals"},
+	{name: "sectors", method: http.MethodGet, path: "/api/v1/symbols/sectors?exchange=IDX"},
+	{name: "financials_upsert", method: http.MethodPost, path: "/api/v1/symbols/financials",
+		body: `{"data":[{"symbol":"BBCA.JK","fiscal_year":2024,"fiscal_quarter":1,"period_end":"2024-03-31","revenue":23400000000000,"net_income":12900000000000,"eps":104.7,"book_value":240000000000000},{"symbol":"BBCA.JK","fiscal_year":2024,"fiscal_quarter":2,"period_end":"2024-06-30","net_income":13000000000000,"eps":105.5},{"symbol":"BBCA.JK","fiscal_year":2024,"fiscal_quarter":3,"period_end":"2024-09-30","net_income":13200000000000}]}`},
+	{name: "financials_upload", method: http.MethodPost, path: "/api/v1/symbols/financials/upload",
+		csv: "Symbol,FiscalYear,Quarter,PeriodEnd,Currency,Revenue,NetIncome,EPS,BookValue\nBBCA.JK,2024,4,2024-12-31,IDR,25100000000000,14100000000000,114.4,262000000000000\nBBCA.JK,2024,5,2024-12-31,,,,,\n"},
+	{name: "financials_get", method: http.MethodGet, path: "/api/v1/symbols/BBCA.JK/financials"},
+	{name: "financials_missing", method: http.MethodGet, path: "/api/v1/symbols/TLKM.JK/financials"},
+	{name: "note_create", method: http.MethodPost, path: "/api/v1/symbols/BBCA.JK/notes",
+		body: `{"title":"Q4 review","body":"Loan growth **above** guidance; watch net interest margin."}`},
+	{name: "note_create_shared_without_organization", method: http.MethodPost, path: "/api/v1/symbols/BBCA.JK/notes",
+		body: `{"body":"Shared note","shared":true}`},
+	{name: "note_update", method: http.MethodPut, path: "/api/v1/notes/1", body: `{"title":"Q4 2024 review"}`},
+	{name: "note_attachment", method: http.MethodPost, path: "/api/v1/notes/1/attachments", csv: "Quarter,NIM\n2024Q4,5.8\n"},
+	{name: "notes_symbol", method: http.MethodGet, path: "/api/v1/symbols/BBCA.JK/notes"},
+	{name: "notes_search", method: http.MethodGet, path: "/api/v1/notes?q=margin"},
+	{name: "notes_search_no_match", method: http.MethodGet, path: "/api/v1/notes?q=dividend"},
+	{name: "note_missing", method: http.MethodGet, path: "/api/v1/notes/99"},
+	{name: "search", method: http.MethodGet, path: "/api/v1/search?q=bank"},
+	{name: "search_ticker_prefix", method: http.MethodGet, path: "/api/v1/search?q=bbc"},
+	{name: "search_notes_only", method: http.MethodGet, path: "/api/v1/search?q=interest%20marg&types=note"},
+	{name: "search_invalid_type", method: http.MethodGet, path: "/api/v1/search?q=bank&types=news"},
+	{name: "search_empty", method: http.MethodGet, path: "/api/v1/search?q=%20%2B"},
+	{name: "symbol_delete_has_data", method: http.MethodDelete, path: "/api/v1/symbols/IDX/BBCA.JK"},
+	{name: "symbol_delete", method: http.MethodDelete, path: "/api/v1/symbols/IDX/GOTO.JK"},
+	{name: "watchlist_add_unknown", method: http.MethodPost, path: "/api/v1/preferences/watchlist/NOPE.JK"},
+
+	// FX rates
+	{name: "fx_rate", method: http.MethodGet, path: "/api/v1/fx/USD-IDR?date=2025-01-08"},
+	{name: "fx_rate_inverse", method: http.MethodGet, path: "/api/v1/fx/IDR-USD?date=2025-01-06"},
+	{name: "fx_rate_cross", method: http.MethodGet, path: "/api/v1/fx/EUR-IDR?date=2025-01-07"},
+	{name: "fx_rate_missing", method: http.MethodGet, path: "/api/v1/fx/USD-IDR?date=2024-12-31"},
+	{name: "fx_convert", method: http.MethodPost, path: "/api/v1/fx/convert", body: `{"amount":1000,"from":"USD","to":"IDR","date":"2025-01-07"}`},
+	{name: "fx_rates_upsert", method: http.MethodPost, path: "/api/v1/fx/rates", body: `{"rates":[{"base":"USD","quote":"SGD","date":"2025-01-07","rate":1.3662}]}`},
+	{name: "fx_fetch_not_configured", method: http.MethodPost, path: "/api/v1/fx/fetch"},
+	{name: "market_data_range_currency", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-06&end_date=2025-01-08&currency=USD"},
+	{name: "market_data_invalid_currency", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?currency=usdollar"},
+
+	// Exchanges
+	{name: "exchanges", method: http.MethodGet, path: "/api/v1/exchanges"},
+	{name: "exchange_get", method: http.MethodGet, path: "/api/v1/exchanges/IDX?at=2025-01-07T03:00:00Z"},
+	{name: "exchange_pre_open", method: http.MethodGet, path: "/api/v1/exchanges/IDX?at=2025-01-07T01:50:00Z"},
+	{name: "exchange_crypto", method: http.MethodGet, path: "/api/v1/exchanges/CRYPTO?at=2025-01-05T12:00:00Z"},
+	{name: "market_data_range_crypto", method: http.MethodGet, path: "/api/v1/market-data/BTC-USDT?start_date=2025-01-06&end_date=2025-01-07"},
+	// Pairs written without a separator name the same series
+	{name: "market_data_range_crypto_concatenated", method: http.MethodGet, path: "/api/v1/market-data/BTCUSDT?start_date=2025-01-06&end_date=2025-01-07&interval=1d"},
+	{name: "exchange_missing", method: http.MethodGet, path: "/api/v1/exchanges/LSE"},
+	{name: "exchange_calendar", method: http.MethodGet, path: "/api/v1/exchanges/IDX/calendar?start_date=2025-01-25&end_date=2025-01-31"},
+	{name: "exchange_tick_size", method: http.MethodGet, path: "/api/v1/exchanges/idx/tick-size?price=1233"},
+	{name: "exchange_tick_size_bands", method: http.MethodGet, path: "/api/v1/exchanges/IDX/tick-size"},
+	{name: "exchange_tick_size_none", method: http.MethodGet, path: "/api/v1/exchanges/US/tick-size"},
+	{name: "exchange_holiday_add", method: http.MethodPost, path: "/api/v1/exchanges/IDX/holidays", body: `{"date":"2025-03-31","name":"Eid al-Fitr"}`},
+	{name: "exchange_holidays_list", method: http.MethodGet, path: "/api/v1/exchanges/IDX/holidays?year=2025"},
+	{name: "exchange_holidays_upload", method: http.MethodPut, path: "/api/v1/exchanges/IDX/holidays?year=2025", body: `{"holidays":[{"date":"2025-01-01","name":"New Year's Day"},{"date":"2025-03-31","name":"Idul Fitri"}]}`},
+	{name: "exchange_holidays_upload_wrong_year", method: http.MethodPut, path: "/api/v1/exchanges/IDX/holidays?year=2025", body: `{"holidays":[{"date":"2024-12-25","name":"Christmas Day"}]}`},
+	{name: "exchange_holidays_upload_no_year", method: http.MethodPut, path: "/api/v1/exchanges/IDX/holidays", body: `{"holidays":[]}`},
+	{name: "exchange_holiday_delete", method: http.MethodDelete, path: "/api/v1/exchanges/IDX/holidays/2025-03-31"},
+
+	// Market data writes
+	{name: "market_data_create", method: http.MethodPost, path: "/api/v1/market-data",
+		body: `{"symbol":"BBRI.JK","date":"2025-01-06T00:00:00Z","open":4500,"high":4600,"low":4450,"close":4550,"volume":25000000,"source":"manual"}`},
+	{name: "market_data_bulk", method: http.MethodPost, path: "/api/v1/market-data/bulk?on_conflict=skip",
+		body: `{"data":[{"symbol":"BBRI.JK","date":"2025-01-06T00:00:00Z","open":4500,"high":4600,"low":4450,"close":4550,"volume":25000000,"source":"manual"},{"symbol":"BBRI.JK","date":"2025-01-07T00:00:00Z","open":4550,"high":4650,"low":4500,"close":4600,"volume":28000000,"source":"manual"}]}`},
+	{name: "market_data_bulk_intraday", method: http.MethodPost, path: "/api/v1/market-data/bulk?on_conflict=skip",
+		body: `{"data":[{"symbol":"BBRI.JK","interval":"5m","timestamp":"2025-01-07T02:05:00Z","open":4550,"high":4560,"low":4540,"close":4550,"volume":400000,"source":"manual"},{"symbol":"BBRI.JK","interval":"5m","timestamp":"2025-01-07T02:10:00Z","open":4550,"high":4570,"low":4550,"close":4570,"volume":380000,"source":"manual"}]}`},
+	{name: "market_data_create_off_tick", method: http.MethodPost, path: "/api/v1/market-data",
+		body: `{"symbol":"BBRI.JK","date":"2025-01-08T00:00:00Z","open":4600,"high":4655,"low":4580,"close":4630,"volume":21000000,"source":"manual"}`},
+	{name: "market_data_create_inconsistent", method: http.MethodPost, path: "/api/v1/market-data",
+		body: `{"symbol":"BBRI.JK","date":"2025-01-08T00:00:00Z","open":4600,"high":4550,"low":4580,"close":4700,"volume":21000000,"source":"manual"}`},
+	{name: "market_data_bulk_inconsistent", method: http.MethodPost, path: "/api/v1/market-data/bulk",
+		body: `{"data":[{"symbol":"BBRI.JK","date":"2025-01-08T00:00:00Z","open":4600,"high":4650,"low":4580,"close":4630,"volume":21000000,"source":"manual"},{"symbol":"BBRI.JK","date":"2025-01-09T00:00:00Z","open":4500,"high":4650,"low":4580,"close":4630,"volume":21000000,"source":"manual"}]}`},
+	{name: "market_data_bulk_duplicate", method: http.MethodPost, path: "/api/v1/market-data/bulk?on_conflict=error",
+		body: `{"data":[{"symbol":"BBRI.JK","date":"2025-01-07T00:00:00Z","open":4550,"high":4650,"low":4500,"close":4600,"volume":28000000,"source":"manual"}]}`},
+	{name: "market_data_create_without_open_volume", method: http.MethodPost, path: "/api/v1/market-data",
+		body: `{"symbol":"GOTO.JK","date":"2025-01-06T00:00:00Z","high":80,"low":78,"close":79,"source":"manual"}`},
+	{name: "market_data_range_without_open_volume", method: http.MethodGet, path: "/api/v1/market-data/GOTO.JK?start_date=2025-01-06&end_date=2025-01-06"},
+	{name: "market_data_fetch_not_configured", method: http.MethodPost, path: "/api/v1/market-data/fetch/IBM?source=alphavantage&days=30"},
+	{name: "market_data_fetch_unknown_source", method: http.MethodPost, path: "/api/v1/market-data/fetch/IBM?source=stooq"},
+	{name: "market_data_fetch_interval_unsupported", method: http.MethodPost, path: "/api/v1/market-data/fetch/IBM?source=yahoo&interval=1h"},
+	{name: "market_data_fetch_window_too_long", method: http.MethodPost, path: "/api/v1/market-data/binance/BTCUSDT?interval=1m&days=30"},
+	// The failed fetch above is counted against its provider
+	{name: "sources_after_fetch", method: http.MethodGet, path: "/api/v1/sources", mask: []string{"last_fetch_at"}},
+	{name: "upload_csv", method: http.MethodPost, path: "/api/v1/upload/csv",
+		csv: "Symbol,Date,Open,High,Low,Close,Volume\nASII.JK,2025-01-06,5000,5100,4950,5050,9000000\nASII.JK,2025-01-07,5050,5150,5000,5100,9500000\n"},
+	{name: "upload_csv_inconsistent", method: http.MethodPost, path: "/api/v1/upload/csv?on_conflict=skip",
+		csv: "Symbol,Date,Open,High,Low,Close,Volume\nASII.JK,2025-01-06,5000,5100,4950,5050,9000000\nASII.JK,2025-01-07,5050,5000,5100,5200,9500000\n"},
+	{name: "upload_csv_yahoo", method: http.MethodPost, path: "/api/v1/upload/csv?symbol=ASII.JK",
+		csv: "Date,Open,High,Low,Close,Adj Close,Volume\n2025-01-02,4900,5000,4880,4990,4990,8800000\n2025-01-03,null,null,null,null,null,null\n"},
+	{name: "upload_csv_tradingview", method: http.MethodPost, path: "/api/v1/upload/csv?format=tradingview&symbol=ASII.JK&interval=1h",
+		csv: "time,open,high,low,close,Volume\n1736128800,5000,5040,4990,5030,1200000\n"},
+	{name: "upload_csv_symbol_required", method: http.MethodPost, path: "/api/v1/upload/csv?format=tradingview",
+		csv: "time,open,high,low,close,Volume\n1736128800,5000,5040,4990,5030,1200000\n"},
+	{name: "upload_csv_unknown_format", method: http.MethodPost, path: "/api/v1/upload/csv?format=metastock",
+		csv: "Symbol,Date,Open,High,Low,Close,Volume\n"},
+	{name: "upload_csv_too_large", method: http.MethodPost, path: "/api/v1/upload/csv",
+		csv: "Symbol,Date,Open,High,Low,Close,Volume\n" + strings.Repeat("ASII.JK,2025-01-06,5000,5100,4950,5050,9000000\n", 200)},
+	{name: "upload_job_create", method: http.MethodPost, path: "/api/v1/upload/jobs?on_conflict=skip",
+		csv: "Symbol,Date,Open,High,Low,Close,Volume\nASII.JK,2025-01-08,5100,5200,5050,5150,8000000\n"},
+	{name: "upload_job_list", method: http.MethodGet, path: "/api/v1/upload/jobs"},
+	{name: "upload_job_get", method: http.MethodGet, path: "/api/v1/upload/jobs/1"},
+	{name: "upload_job_missing", method: http.MethodGet, path: "/api/v1/upload/jobs/99"},
+	{name: "market_data_delete_request", method: http.MethodDelete, path: "/api/v1/market-data/ASII.JK"},
+	{name: "data_coverage", method: http.MethodGet, path: "/api/v1/admin/data/coverage"},
+	{name: "data_coverage_source", method: http.MethodGet, path: "/api/v1/admin/data/coverage?exchange=idx&source=yahoo"},
+	{name: "market_data_range_delete_request", method: http.MethodDelete, path: "/api/v1/admin/market-data?source=yahoo&start_date=2025-01-02&end_date=2025-01-03&exchange=idx"},
+	{name: "market_data_range_delete_without_source", method: http.MethodDelete, path: "/api/v1/admin/market-data?start_date=2025-01-02&end_date=2025-01-03"},
+	{name: "market_data_range_delete_inverted", method: http.MethodDelete, path: "/api/v1/admin/market-data?source=yahoo&start_date=2025-01-03&end_date=2025-01-02"},
+	{name: "import_history", method: http.MethodGet, path: "/api/v1/admin/imports?status=completed"},
+	{name: "import_history_invalid_status", method: http.MethodGet, path: "/api/v1/admin/imports?status=done"},
+
+	// Preferences and watchlist
+	{name: "preferences", method: http.MethodGet, path: "/api/v1/preferences"},
+	{name: "preferences_update", method: http.MethodPut, path: "/api/v1/preferences", body: `{"default_limit":50,"default_window_days":90}`},
+	{name: "watchlist_add", method: http.MethodPost, path: "/api/v1/preferences/watchlist/BBCA.JK"},
+	{name: "watchlist_add_crypto", method: http.MethodPost, path: "/api/v1/preferences/watchlist/BTC-USDT"},
+	{name: "watchlist_add_fund", method: http.MethodPost, path: "/api/v1/preferences/watchlist/SCHPASIA"},
+	// The seeded candles are older than the 90-day window, so series are empty
+	{name: "offline_bundle", method: http.MethodGet, path: "/api/v1/offline/bundle", mask: []string{"generated_at", "window_start"}},
+	{name: "offline_bundle_unchanged", method: http.MethodGet, path: "/api/v1/offline/bundle?since=1"},
+	{name: "offline_bundle_invalid_since", method: http.MethodGet, path: "/api/v1/offline/bundle?since=latest"},
+	{name: "offline_key", method: http.MethodGet, path: "/api/v1/offline/key"},
+	{name: "fetch_status", method: http.MethodGet, path: "/api/v1/admin/fetch-status"},
+	{name: "outbox_status", method: http.MethodGet, path: "/api/v1/admin/outbox"},
+	{name: "deprecations", method: http.MethodGet, path: "/api/v1/admin/deprecations"},
+
+	// What's new: seen marks only move forward
+	{name: "changelog", method: http.MethodGet, path: "/api/v1/changelog"},
+	{name: "changelog_mark_seen_version", method: http.MethodPost, path: "/api/v1/changelog/seen", body: `{"version":"1.2.0"}`, mask: []string{"seen_at"}},
+	{name: "changelog_unseen", method: http.MethodGet, path: "/api/v1/changelog?unseen=true", mask: []string{"seen_at"}},
+	{name: "changelog_mark_seen_latest", method: http.MethodPost, path: "/api/v1/changelog/seen", mask: []string{"seen_at"}},
+	{name: "changelog_mark_seen_older", method: http.MethodPost, path: "/api/v1/changelog/seen", body: `{"version":"1.1.0"}`, mask: []string{"seen_at"}},
+	{name: "changelog_seen_unknown_version", method: http.MethodPost, path: "/api/v1/changelog/seen", body: `{"version":"0.9.0"}`},
+	{name: "archive_status", method: http.MethodGet, path: "/api/v1/admin/archive"},
+	{name: "archive_run_disabled", method: http.MethodPost, path: "/api/v1/admin/archive/run"},
+	{name: "ingest_buffer_status", method: http.MethodGet, path: "/api/v1/admin/ingest-buffer"},
+	{name: "provisioning_status", method: http.MethodGet, path: "/api/v1/admin/provisioning"},
+	{name: "refetch", method: http.MethodPost, path: "/api/v1/admin/refetch", body: `{"symbols":["bbca.jk"],"days":30}`},
+	{name: "refetch_pending", method: http.MethodPost, path: "/api/v1/admin/refetch", body: `{}`},
+	{name: "refetch_invalid", method: http.MethodPost, path: "/api/v1/admin/refetch", body: `{"days":0.5}`},
+	{name: "org_export_without_organization", method: http.MethodGet, path: "/api/v1/admin/org/export"},
+	{name: "org_export_invalid_format", method: http.MethodGet, path: "/api/v1/admin/org/export?format=xml"},
+	{name: "org_import_without_organization", method: http.MethodPost, path: "/api/v1/admin/org/import",
+		body: `{"members":[{"email":"member@example.com","watchlist":["BBCA.JK"]}]}`},
+	{name: "org_import_invalid", method: http.MethodPost, path: "/api/v1/admin/org/import", body: `{"members":[{"email":"not-an-email"}]}`},
+	{name: "outbox_retry_window", method: http.MethodPost, path: "/api/v1/admin/outbox/retry", body: `{"from":"2025-01-07T00:00:00Z","to":"2025-01-08T00:00:00Z","topic":"quota.warning"}`},
+	{name: "outbox_retry_window_inverted", method: http.MethodPost, path: "/api/v1/admin/outbox/retry", body: `{"from":"2025-01-08T00:00:00Z","to":"2025-01-07T00:00:00Z"}`},
+	{name: "outbox_retry_missing", method: http.MethodPost, path: "/api/v1/admin/outbox/999/retry"},
+	{name: "watchlist_performance", method: http.MethodGet, path: "/api/v1/preferences/watchlist/performance?days=30"},
+	{name: "watchlist_quotes", method: http.MethodGet, path: "/api/v1/watchlist/quotes"},
+	{name: "watchlist_remove", method: http.MethodDelete, path: "/api/v1/preferences/watchlist/BBCA.JK"},
+	{name: "offline_bundle_delta", method: http.MethodGet, path: "/api/v1/offline/bundle?since=1", mask: []string{"generated_at", "window_start"}},
+
+	// Mutual fund NAVs
+	{name: "nav_get", method: http.MethodGet, path: "/api/v1/nav/SCHPASIA?start_date=2025-01-01&end_date=2025-01-08"},
+	{name: "nav_upsert", method: http.MethodPost, path: "/api/v1/nav",
+		body: `{"data":[{"symbol":"SCHPASIA","date":"2025-01-07","nav":3141.0},{"symbol":"sucorinvest","date":"2025-01-07","nav":1620.55}]}`},
+	{name: "nav_unknown", method: http.MethodGet, path: "/api/v1/nav/BBCA.JK"},
+	{name: "nav_fetch_unconfigured", method: http.MethodPost, path: "/api/v1/nav/fetch/SCHPASIA"},
+
+	// Bonds
+	{name: "bond_create", method: http.MethodPost, path: "/api/v1/bonds",
+		body: `{"symbol":"ORI025T3","name":"ORI025T3","issuer":"Republic of Indonesia","coupon_rate":6.25,"coupon_frequency":12,"issue_date":"2024-03-25","maturity_date":"2027-03-15"}`},
+	{name: "bond_list", method: http.MethodGet, path: "/api/v1/bonds"},
+	{name: "bond_coupons", method: http.MethodGet, path: "/api/v1/bonds/ORI025T3/coupons"},
+	{name: "bond_quote_price", method: http.MethodPost, path: "/api/v1/bonds/ORI025T3/quotes", body: `{"date":"2025-01-06","clean_price":100.75}`},
+	{name: "bond_quote_yield", method: http.MethodPost, path: "/api/v1/bonds/ORI025T3/quotes", body: `{"date":"2025-01-07","yield":5.9}`},
+	{name: "bond_quote_both", method: http.MethodPost, path: "/api/v1/bonds/ORI025T3/quotes", body: `{"date":"2025-01-08","clean_price":100,"yield":6}`},
+	{name: "bond_quotes", method: http.MethodGet, path: "/api/v1/bonds/ORI025T3/quotes?start_date=2025-01-01&end_date=2025-01-08"},
+	{name: "bond_get", method: http.MethodGet, path: "/api/v1/bonds/ORI025T3?date=2025-01-08"},
+	{name: "bond_missing", method: http.MethodGet, path: "/api/v1/bonds/FR0100"},
+
+	// Fee settings
+	{name: "fees", method: http.MethodGet, path: "/api/v1/settings/fees"},
+	{name: "fees_estimate", method: http.MethodGet, path: "/api/v1/settings/fees/estimate?side=buy&price=8500&quantity=500"},
+	{name: "fees_reset", method: http.MethodDelete, path: "/api/v1/settings/fees"},
+
+	// Portfolios
+	{name: "portfolio_create", method: http.MethodPost, path: "/api/v1/portfolios", body: `{"name":"Core"}`},
+	{name: "portfolio_holding_set", method: http.MethodPut, path: "/api/v1/portfolios/1/holdings/BBCA.JK", body: `{"quantity":500,"avg_price":8400}`},
+	{name: "portfolio_holding_set_fund", method: http.MethodPut, path: "/api/v1/portfolios/1/holdings/SCHPASIA", body: `{"quantity":1000,"avg_price":3050}`},
+	{name: "portfolio_holding_set_bond", method: http.MethodPut, path: "/api/v1/portfolios/1/holdings/ORI025T3", body: `{"quantity":10000000,"avg_price":100}`},
+	{name: "portfolio_cash_deposit", method: http.MethodPost, path: "/api/v1/portfolios/1/cash",
+		body: `{"type":"deposit","amount":10000000,"at":"2025-01-02T00:00:00Z"}`},
+	{name: "portfolio_cash_future", method: http.MethodPost, path: "/api/v1/portfolios/1/cash",
+		body: `{"type":"withdrawal","amount":1000000,"at":"2099-01-02T00:00:00Z"}`},
+	{name: "portfolio_trade_buy", method: http.MethodPost, path: "/api/v1/portfolios/1/trades",
+		body: `{"symbol":"TLKM.JK","side":"buy","quantity":1000,"price":3200,"executed_at":"2025-01-06T03:00:00Z"}`},
+	{name: "portfolio_trade_buy_more", method: http.MethodPost, path: "/api/v1/portfolios/1/trades",
+		body: `{"symbol":"tlkm.jk","side":"buy","quantity":1000,"price":3260,"executed_at":"2025-01-07T03:00:00Z"}`},
+	{name: "portfolio_trade_sell_backdated", method: http.MethodPost, path: "/api/v1/portfolios/1/trades",
+		body: `{"symbol":"TLKM.JK","side":"sell","quantity":500,"price":3240,"executed_at":"2025-01-06T06:00:00Z"}`},
+	{name: "portfolio_trade_oversell", method: http.MethodPost, path: "/api/v1/portfolios/1/trades",
+		body: `{"symbol":"TLKM.JK","side":"sell","quantity":5000,"price":3260,"executed_at":"2025-01-07T06:00:00Z"}`},
+	{name: "portfolio_trade_invalid_side", method: http.MethodPost, path: "/api/v1/portfolios/1/trades",
+		body: `{"symbol":"TLKM.JK","side":"short","quantity":100,"price":3260}`},
+	{name: "portfolio_get_as_of", method: http.MethodGet, path: "/api/v1/portfolios/1?as_of=2025-01-06T23:59:59Z"},
+	{name: "portfolio_get_as_of_invalid", method: http.MethodGet, path: "/api/v1/portfolios/1?as_of=2025-01-06"},
+	{name: "portfolio_get_currency", method: http.MethodGet, path: "/api/v1/portfolios/1?as_of=2025-01-07T23:59:59Z&currency=USD"},
+	{name: "corporate_action_rights", method: http.MethodPost, path: "/api/v1/corporate-actions",
+		body: `{"symbol":"BBCA.JK","action_type":"rights","ex_date":"2025-01-08","ratio_old":10,"ratio_new":1,"exercise_price":7000,"entitlement_symbol":"BBCA-R"}`},
+	{name: "corporate_action_warrant_review", method: http.MethodPost, path: "/api/v1/corporate-actions",
+		body: `{"symbol":"BBRI.JK","action_type":"warrant","ex_date":"2025-01-08","ratio_old":5,"ratio_new":1,"exercise_price":5000}`},
+	{name: "corporate_action_queue", method: http.MethodGet, path: "/api/v1/corporate-actions?status=pending_review"},
+	{name: "corporate_action_approve", method: http.MethodPost, path: "/api/v1/corporate-actions/2/approve", body: `{"entitlement_symbol":"BBRI-W"}`},
+	{name: "corporate_action_reject_applied", method: http.MethodPost, path: "/api/v1/corporate-actions/1/reject", body: `{"note":"announced in error"}`},
+	{name: "corporate_action_get", method: http.MethodGet, path: "/api/v1/corporate-actions/1"},
+	{name: "corporate_action_missing", method: http.MethodGet, path: "/api/v1/corporate-actions/99"},
+	{name: "corporate_action_split", method: http.MethodPost, path: "/api/v1/corporate-actions",
+		body: `{"symbol":"BTC-USDT","action_type":"split","ex_date":"2025-01-07","ratio_old":1,"ratio_new":2}`},
+	{name: "corporate_action_dividend", method: http.MethodPost, path: "/api/v1/corporate-actions",
+		body: `{"symbol":"BBCA.JK","action_type":"dividend","ex_date":"2025-01-07","amount":85}`},
+	{name: "corporate_action_dividend_no_amount", method: http.MethodPost, path: "/api/v1/corporate-actions",
+		body: `{"symbol":"BBCA.JK","action_type":"dividend","ex_date":"2025-01-03"}`},
+	{name: "market_data_range_adjusted_splits", method: http.MethodGet, path: "/api/v1/market-data/BTC-USDT?start_date=2025-01-06&end_date=2025-01-07&adjust=splits"},
+	{name: "market_data_range_adjusted_all", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?start_date=2025-01-02&end_date=2025-01-07&source=yahoo&adjust=all"},
+	{name: "market_data_invalid_adjust", method: http.MethodGet, path: "/api/v1/market-data/BBCA.JK?adjust=dividends"},
+	{name: "portfolio_adjustments", method: http.MethodGet, path: "/api/v1/portfolios/1/adjustments"},
+	{name: "dividend_create", method: http.MethodPost, path: "/api/v1/dividends",
+		body: `{"symbol":"BBCA.JK","ex_date":"2025-03-20","record_date":"2025-03-21","payment_date":"2025-04-10","amount":250}`},
+	{name: "dividend_payment_before_ex", method: http.MethodPost, path: "/api/v1/dividends",
+		body: `{"symbol":"BBRI.JK","ex_date":"2025-03-20","payment_date":"2025-03-01","amount":200}`},
+	{name: "dividend_calendar", method: http.MethodGet, path: "/api/v1/dividends?from=2025-03-01&to=2025-03-31"},
+	{name: "dividend_missing", method: http.MethodGet, path: "/api/v1/dividends/99"},
+	// The projection window ends a year from today, so its bounds depend on the clock
+	{name: "portfolio_dividends", method: http.MethodGet, path: "/api/v1/portfolios/1/dividends?days=366", mask: []string{"from", "to"}},
+	{name: "portfolio_trade_before_action", method: http.MethodPost, path: "/api/v1/portfolios/1/trades",
+		body: `{"symbol":"BBCA.JK","side":"sell","quantity":100,"price":8550,"executed_at":"2025-01-06T03:00:00Z"}`},
+	{name: "portfolio_events", method: http.MethodGet, path: "/api/v1/portfolios/1/events", mask: []string{"occurred_at"}},
+	{name: "portfolio_list", method: http.MethodGet, path: "/api/v1/portfolios"},
+	{name: "portfolio_get", method: http.MethodGet, path: "/api/v1/portfolios/1"},
+	{name: "portfolio_rename", method: http.MethodPut, path: "/api/v1/portfolios/1", body: `{"name":"Long term"}`},
+	{name: "portfolio_holding_remove", method: http.MethodDelete, path: "/api/v1/portfolios/1/holdings/BBCA.JK"},
+	{name: "portfolio_delete", method: http.MethodDelete, path: "/api/v1/portfolios/1"},
+	{name: "portfolio_missing", method: http.MethodGet, path: "/api/v1/portfolios/1"},
+
+	// Exports
+	{name: "export_create", method: http.MethodPost, path: "/api/v1/exports",
+		body: `{"symbols":["BBCA.JK"],"start_date":"2025-01-02","end_date":"2025-01-08","format":"csv"}`},
+	{name: "export_create_intraday", method: http.MethodPost, path: "/api/v1/exports",
+		body: `{"symbols":["BBCA.JK"],"start_date":"2025-01-02","end_date":"2025-01-08","format":"json","interval":"1h","exchange":"idx"}`},
+	{name: "export_create_invalid_interval", method: http.MethodPost, path: "/api/v1/exports",
+		body: `{"symbols":["BBCA.JK"],"start_date":"2025-01-02","end_date":"2025-01-08","interval":"2h"}`},
+	{name: "export_list", method: http.MethodGet, path: "/api/v1/exports"},
+	{name: "export_get", method: http.MethodGet, path: "/api/v1/exports/1"},
+	{name: "export_quota", method: http.MethodGet, path: "/api/v1/exports/quota"},
+
+	// Strategies and accounts
+	{name: "strategy_validate", method: http.MethodPost, path: "/api/v1/strategies/validate",
+		body: `{"name":"Breakout","symbols":["BBCA.JK"],"entry":{"all":[]},"exit":{},"sizing":{}}`},
+	{name: "strategy_create", method: http.MethodPost, path: "/api/v1/strategies?public=true",
+		body: `{"name":"Golden cross","symbols":["BBCA.JK"],"entry":{"all":[{"left":{"indicator":"sma","period":20},"op":"crosses_above","right":{"indicator":"sma","period":50}}]},"exit":{"stop_loss_pct":5},"sizing":{"method":"percent_equity","value":10}}`},
+	{name: "strategy_create_invalid", method: http.MethodPost, path: "/api/v1/strategies",
+		body: `{"name":"Breakout","symbols":["BBCA.JK"],"entry":{"all":[]},"exit":{},"sizing":{}}`},
+	{name: "strategy_list", method: http.MethodGet, path: "/api/v1/strategies"},
+	{name: "strategy_list_public", method: http.MethodGet, path: "/api/v1/strategies?public=true"},
+	{name: "strategy_clone", method: http.MethodPost, path: "/api/v1/strategies/1/clone"},
+	{name: "strategy_update", method: http.MethodPut, path: "/api/v1/strategies/2?public=false",
+		body: `{"name":"Golden cross (tight stop)","symbols":["BBCA.JK"],"entry":{"all":[{"left":{"indicator":"sma","period":20},"op":"crosses_above","right":{"indicator":"sma","period":50}}]},"exit":{"stop_loss_pct":2},"sizing":{"method":"percent_equity","value":10}}`},
+	{name: "strategy_get", method: http.MethodGet, path: "/api/v1/strategies/2"},
+	{name: "strategy_delete", method: http.MethodDelete, path: "/api/v1/strategies/2"},
+	{name: "strategy_missing", method: http.MethodGet, path: "/api/v1/strategies/2"},
+	{name: "webhook_create", method: http.MethodPost, path: "/api/v1/webhooks",
+		body: `{"url":"https://hooks.example.com/trading","events":["import.completed","import.failed"]}`, mask: []string{"secret"}},
+	{name: "webhook_create_invalid_url", method: http.MethodPost, path: "/api/v1/webhooks", body: `{"url":"ftp://hooks.example.com/trading","events":["import.completed"]}`},
+	{name: "webhook_create_private_url", method: http.MethodPost, path: "/api/v1/webhooks", body: `{"url":"http://169.254.169.254/latest/meta-data","events":["import.completed"]}`},
+	{name: "webhook_create_invalid_event", method: http.MethodPost, path: "/api/v1/webhooks", body: `{"url":"https://hooks.example.com/trading","events":["alert.triggered"]}`},
+	{name: "webhook_list", method: http.MethodGet, path: "/api/v1/webhooks"},
+	{name: "webhook_update", method: http.MethodPut, path: "/api/v1/webhooks/1", body: `{"url":"https://hooks.example.com/v2","events":["quota.warning"],"active":false}`},
+	{name: "webhook_ping", method: http.MethodPost, path: "/api/v1/webhooks/1/ping", mask: []string{"next_attempt_at"}},
+	{name: "webhook_redeliver", method: http.MethodPost, path: "/api/v1/webhooks/1/deliveries/1/redeliver", mask: []string{"next_attempt_at"}},
+	{name: "webhook_redeliver_missing", method: http.MethodPost, path: "/api/v1/webhooks/1/deliveries/99/redeliver"},
+	{name: "webhook_deliveries", method: http.MethodGet, path: "/api/v1/webhooks/1/deliveries?status=pending", mask: []string{"next_attempt_at"}},
+	{name: "webhook_deliveries_invalid_status", method: http.MethodGet, path: "/api/v1/webhooks/1/deliveries?status=lost"},
+	{name: "webhook_delete", method: http.MethodDelete, path: "/api/v1/webhooks/1"},
+	{name: "webhook_missing", method: http.MethodGet, path: "/api/v1/webhooks/1"},
+	{name: "account_links", method: http.MethodGet, path: "/api/v1/account/links"},
+}
+
+// seedSQL resets the tables the API touches and loads a small, fixed data set
+const seedSQL = `
+	TRUNCATE market_data, market_data_history, market_data_anomalies, nav_data, symbol_fundamentals, financial_reports, bond_quotes, bond_coupons, bonds, symbols, exchange_holidays, fx_rates,
+		user_preferences, user_fee_settings, user_links, account_link_tokens, confirmation_tokens,
+		export_jobs, import_jobs, fetch_status, source_ingests, outbox_messages, role_permissions, symbol_notes, symbol_note_attachments, strategies, webhooks, webhook_deliveries, deprecated_calls, changelog_seen, offline_bundles, market_data_archives, corporate_actions, dividends, portfolio_adjustments, portfolio_snapshots, portfolio_events, portfolio_holdings, portfolios RESTART IDENTITY CASCADE;
+
+	UPDATE sources SET ingest_window_minutes = NULL, transform_rules = '[]';
+
+	INSERT INTO exchange_holidays (exchange, date, name) VALUES
+		('IDX', '2025-01-27', 'Isra Mi''raj'),
+		('IDX', '2025-01-29', 'Chinese New Year');
+
+	INSERT INTO fx_rates (base, quote, date, rate, source) VALUES
+		('USD', 'IDR', '2025-01-06', 16190.0, 'manual'),
+		('USD', 'IDR', '2025-01-07', 16205.5, 'manual'),
+		('EUR', 'USD', '2025-01-07', 1.0393, 'manual');
+
+	INSERT INTO market_data (exchange, symbol, interval, date, ts, open, high, low, close, volume, source) VALUES
+		('IDX', 'BBCA.JK', '1d', '2025-01-02', '2025-01-02', 8400, 8500, 8350, 8450, 11000000, 'yahoo'),
+		('IDX', 'BBCA.JK', '1d', '2025-01-03', '2025-01-03', 8450, 8550, 8400, 8500, 11500000, 'yahoo'),
+		('IDX', 'BBCA.JK', '1d', '2025-01-06', '2025-01-06', 8500, 8600, 8450, 8550, 12500000, 'yahoo'),
+		('IDX', 'BBCA.JK', '1d', '2025-01-07', '2025-01-07', 8550, 8650, 8500, 8600, 13000000, 'yahoo'),
+		('IDX', 'BBCA.JK', '1d', '2025-01-06', '2025-01-06', 8500, 8600, 8450, 8560, 12400000, 'mirae'),
+		('IDX', 'BBCA.JK', '1d', '2025-01-07', '2025-01-07', 8550, 8650, 8500, 8610, 12900000, 'mirae'),
+		('IDX', 'BBCA.JK', '1h', '2025-01-07', '2025-01-07 02:00', 8550, 8600, 8540, 8580, 3100000, 'yahoo'),
+		('IDX', 'BBCA.JK', '1h', '2025-01-07', '2025-01-07 03:00', 8580, 8650, 8570, 8600, 2900000, 'yahoo'),
+		('IDX', 'TLKM.JK', '1d', '2025-01-06', '2025-01-06', 3200, 3250, 3180, 3220, 18000000, 'yahoo'),
+		('IDX', 'TLKM.JK', '1d', '2025-01-07', '2025-01-07', 3220, 3280, 3200, 3260, 19000000, 'yahoo'),
+		('CRYPTO', 'BTC-USDT', '1d', '2025-01-06', '2025-01-06', 98314.95, 102480.00, 97900.00, 102078.09, 21032, 'binance'),
+		('CRYPTO', 'BTC-USDT', '1d', '2025-01-07', '2025-01-07', 102078.09, 102724.38, 96132.00, 96922.70, 32059, 'binance');
+
+	INSERT INTO symbols (exchange, symbol, name, currency) VALUES
+		('FUND', 'SCHPASIA', 'Schroder Dana Prestasi', 'IDR');
+
+	INSERT INTO nav_data (symbol, date, nav, source) VALUES
+		('SCHPASIA', '2025-01-03', 3125.4021, 'manual'),
+		('SCHPASIA', '2025-01-06', 3118.9375, 'manual'),
+		('SCHPASIA', '2025-01-07', 3140.2210, 'manual');
+
+	INSERT INTO symbol_fundamentals (symbol, date, shares_outstanding, market_cap, free_float_pct, source) VALUES
+		('BBCA.JK', '2024-12-31', 123275050000, NULL, 42.5, 'manual'),
+		('TLKM.JK', '2024-12-31', 99062216600, 320961581784000, 47.9, 'manual');
+`
+
+func TestContract(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set; skipping contract tests")
+	}
+
+	router := newContractRouter(t, dsn)
+
+	for _, tc := range contractCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.request(t)
+			req.Header.Set("X-Session-Token", contractToken)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			got := canonicalResponse(t, w, tc.mask)
+			path := filepath.Join(goldenDir, tc.name+".json")
+
+			if *update {
+				if err := os.MkdirAll(goldenDir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("missing golden file %s; run go test ./cmd/server -run TestContract -update: %v", path, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s %s changed shape.\n--- golden\n%s\n--- got\n%s", tc.method, tc.path, want, got)
+			}
+		})
+	}
+}
+
+func (tc contractCase) request(t *testing.T) *http.Request {
+	t.Helper()
+
+	if tc.csv == "" {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", tc.name+".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte(tc.csv)); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(tc.method, tc.path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+// newContractRouter seeds the database and builds the production router, with a
+// stand-in Kratos that accepts contractToken as an admin session
+func newContractRouter(t *testing.T, dsn string) *gin.Engine {
+	t.Helper()
+
+	if err := logger.Init("development", "error"); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+
+	kratos := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Session-Token") != contractToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":     "contract-session-id",
+			"active": true,
+			"identity": map[string]interface{}{
+				"id":     contractUserID,
+				"state":  "active",
+				"traits": map[string]interface{}{"email": "contract@example.com", "role": "admin"},
+			},
+			"expires_at": time.Now().Add(time.Hour),
+		})
+	}))
+	t.Cleanup(kratos.Close)
+	middleware.InitAuthConfig(kratos.URL, kratos.URL, middleware.SessionOptions{})
+
+	db, err := database.New(&config.DatabaseConfig{
+		URL:             dsn,
+		MaxOpenConns:    5,
+		MaxIdleConns:    1,
+		ConnMaxLifetime: time.Hour,
+		ConnMaxIdleTime: time.Minute,
+	})
+	if err != nil {
+		// A set but unreachable database is an environment problem, not a broken contract
+		t.Skipf("database at TEST_DATABASE_URL is unavailable; skipping contract tests: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	if _, err := db.MigrateUp(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(context.Background(), seedSQL); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	cfg := &config.Config{App: config.AppConfig{QuoteStepTimeout: 2 * time.Second, MaxUploadSize: 4096}}
+
+	hub := stream.NewHub()
+	marketService := services.NewMarketService(db, hub, cache.Noop{}, services.MarketOptions{
+		ImportLockTimeout: 5 * time.Second,
+		MaxRangeRows:      10000,
+	})
+	sourceService := services.NewSourceService(db, false)
+	accountService := services.NewAccountService(db)
+	anomalyService := services.NewAnomalyService(db, sourceService)
+	navService := services.NewNAVService(db)
+	bondService := services.NewBondService(db)
+	fundamentalsService := services.NewFundamentalsService(db, marketService)
+	userService := services.NewUserService(db)
+	rbacService := services.NewRBACService(db, map[string][]string{"admin": {"*"}, "analyst": {"market_data:*"}})
+	middleware.InitRBAC(rbacService.Permissions)
+	// The watchlist routes stand in for a deprecated endpoint; their calls above are reported
+	deprecationService := services.NewDeprecationService(db, []models.Deprecation{{
+		Method:       http.MethodPost,
+		Route:        "/api/v1/preferences/watchlist/:symbol",
+		DeprecatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Replacement:  "/api/v1/watchlists",
+	}})
+	middleware.InitDeprecations(deprecationService)
+	releases, err := changelog.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	yahooClient := yahoo.New("http://127.0.0.1:0", time.Second)
+	dataSources, err := datasource.NewRegistry(
+		yahooClient,
+		// No API key, so Alpha Vantage fetches report it is not configured
+		alphavantage.New("http://127.0.0.1:0", "", time.Second, 0),
+		binance.New("http://127.0.0.1:0", time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The seeded candles are long past, so each step is tried and the unreachable
+	// providers fail the live one
+	exchangeService := services.NewExchangeService(db)
+	quoteService := services.NewQuoteService(exchangeService,
+		services.QuoteStep{Name: "intraday", Timeout: cfg.App.QuoteStepTimeout, MaxAge: 15 * time.Minute, Fetch: marketService.LatestIntradayQuote},
+		services.QuoteStep{Name: "latest_daily", Timeout: cfg.App.QuoteStepTimeout, Fetch: marketService.LatestQuote},
+		services.QuoteStep{Name: "live", Timeout: cfg.App.QuoteStepTimeout, Fetch: services.LiveQuote(dataSources)},
+	)
+
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without a publisher the outbox is disabled and writes enqueue nothing
+	outboxService := services.NewOutboxService(db, nil, services.OutboxOptions{})
+	// The webhook relay is not started, so deliveries stay pending
+	webhookService := services.NewWebhookService(db, nil, 0)
+	fxService := services.NewFXService(db)
+	portfolioService := services.NewPortfolioService(db, marketService, navService, bondService, fxService, outboxService, hub)
+	// The database stays up, so ingests are written directly and the buffer stays empty
+	ingestLog, err := wal.Open(filepath.Join(t.TempDir(), "ingest.wal"), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ingestLog.Close()
+	ingestBuffer, err := services.NewIngestBuffer(ingestLog, marketService, anomalyService, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No provider is configured, so fetching fx rates reports it
+	fxFetcher, err := scheduler.NewFXFetcher(fxfeed.New("", "", time.Second), fxService, nil, []string{"USD-IDR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Export and import workers are not started, so jobs stay pending and responses are stable
+	exportService := services.NewExportService(db, marketService, sourceService, store, services.ExportOptions{
+		SigningKey: []byte("contract-signing-key"),
+		URLTTL:     time.Hour,
+		MaxRows:    500000,
+		DailyQuota: 10,
+		Outbox:     outboxService,
+		Webhooks:   webhookService,
+	})
+
+	h := handlers.NewHandler(
+		marketService,
+		userService,
+		services.NewFeeService(db),
+		sourceService,
+		quoteService,
+		accountService,
+		services.NewConfirmationService(db),
+		exportService,
+		anomalyService,
+		portfolioService,
+		exchangeService,
+		fxService,
+		services.NewSymbolService(db),
+		services.NewImportService(db, marketService, anomalyService, store, webhookService),
+		navService,
+		bondService,
+		services.NewCorporateActionService(db, marketService, outboxService),
+		services.NewDividendService(db, portfolioService),
+		fundamentalsService,
+		services.NewFinancialsService(db, marketService, fundamentalsService),
+		rbacService,
+		services.NewNoteService(db, store),
+		services.NewSearchService(db),
+		outboxService,
+		services.NewStrategyService(db),
+		webhookService,
+		deprecationService,
+		// The release notes shipped with the binary
+		services.NewChangelogService(db, releases),
+		// A fixed key keeps the published public key stable
+		services.NewOfflineService(db, marketService, userService, ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))),
+		// Only the database blocks readiness, as by default
+		services.NewReadinessService(db, cache.Noop{}, outboxService, services.ReadinessOptions{Blocking: []string{models.GateDB}}),
+		ingestBuffer,
+		dataSources,
+		fundnav.New("", "", time.Second),
+		// No schedule, so the fetcher never runs and only reports status
+		scheduler.NewWatchlistFetcher(db, nil, yahooClient, userService, anomalyService, marketService, exchangeService, scheduler.WatchlistOptions{}),
+		fxFetcher,
+		// Without ARCHIVE_AFTER_YEARS nothing is archived; status still reads the manifest
+		scheduler.NewArchiver(marketService, 0, 0),
+		// Nothing is provisioned, so status lists no files
+		provisioning.New("", userService),
+		hub,
+		nil,
+	)
+
+	return setupRouter(h, cfg, accountService.CanonicalID, nil)
+}
+
+// canonicalResponse renders status and body as indented JSON with volatile values masked
+func canonicalResponse(t *testing.T, w *httptest.ResponseRecorder, extra []string) []byte {
+	t.Helper()
+
+	var body interface{}
+	if w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("response is not JSON: %v\n%s", err, w.Body.String())
+		}
+	}
+
+	out, err := json.MarshalIndent(map[string]interface{}{
+		"status": w.Code,
+		"body":   mask(body, extra),
+	}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(out, '\n')
+}
+
+func mask(v interface{}, extra []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if (volatileKeys[key] || slices.Contains(extra, key)) && value != nil {
+				v[key] = fmt.Sprintf("<%s>", key)
+				continue
+			}
+			v[key] = mask(value, extra)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = mask(v[i], extra)
+		}
+	}
+	return v
+}
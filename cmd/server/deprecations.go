@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deprecations lists the endpoints slated for removal, by method and route template.
+// Callers get Deprecation and Sunset headers, and GET /api/v1/admin/deprecations
+// shows which clients still call each one. Nothing is deprecated yet; the
+// single-watchlist routes under /preferences/watchlist belong here once multiple
+// watchlists ship, for example:
+//
+//	{
+//		Method:       http.MethodPost,
+//		Route:        "/api/v1/preferences/watchlist/:symbol",
+//		DeprecatedAt: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+//		SunsetAt:     &sunset,
+//		Replacement:  "/api/v1/watchlists",
+//	}
+var deprecations = []models.Deprecation{}
+
+// checkDeprecations fails when a deprecation names no registered route, so a typo
+// does not leave an endpoint silently unflagged
+func checkDeprecations(r *gin.Engine, deprecations []models.Deprecation) error {
+	routes := make(map[string]bool)
+	for _, route := range r.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	for _, d := range deprecations {
+		if !routes[d.Method+" "+d.Route] {
+			return fmt.Errorf("%s %s is not a registered route", d.Method, d.Route)
+		}
+	}
+	return nil
+}
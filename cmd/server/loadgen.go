@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// loadgenOptions configure a soak test run against a live instance
+type loadgenOptions struct {
+	target     string
+	token      string
+	agents     int
+	readers    int
+	duration   time.Duration
+	batch      int
+	prefix     string
+	onConflict string
+	commit     string
+	timeout    time.Duration
+}
+
+// loadgenSample is the outcome of one request
+type loadgenSample struct {
+	op      string
+	status  int // 0 when the request failed before a response
+	latency time.Duration
+	rows    int
+}
+
+// runLoadgen implements `server loadgen`: N agents push bulk candles while M
+// readers page through them, then throughput and latency percentiles are printed
+func runLoadgen(args []string) int {
+	var opts loadgenOptions
+	fs := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	fs.StringVar(&opts.target, "target", "http://localhost:8080", "base URL of the instance under test")
+	fs.StringVar(&opts.token, "token", os.Getenv("LOADGEN_SESSION_TOKEN"), "Kratos session token (default $LOADGEN_SESSION_TOKEN)")
+	fs.IntVar(&opts.agents, "agents", 4, "concurrent ingestion agents")
+	fs.IntVar(&opts.readers, "readers", 8, "concurrent dashboard readers")
+	fs.DurationVar(&opts.duration, "duration", time.Minute, "how long to run")
+	fs.IntVar(&opts.batch, "batch", 500, "candles per bulk request")
+	fs.StringVar(&opts.prefix, "prefix", "LOADGEN", "symbol prefix; each agent writes its own symbol")
+	fs.StringVar(&opts.onConflict, "on-conflict", "update", "on_conflict policy for bulk writes")
+	fs.StringVar(&opts.commit, "commit", "all", "commit mode for bulk writes (all or chunk)")
+	fs.DurationVar(&opts.timeout, "timeout", 30*time.Second, "per-request timeout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: server loadgen [flags]")
+		fmt.Fprintln(fs.Output(), "Writes synthetic candles for <prefix>0..<prefix>N-1; point it at a disposable instance.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if opts.token == "" {
+		fmt.Fprintln(os.Stderr, "loadgen: a session token is required (-token or LOADGEN_SESSION_TOKEN)")
+		return 2
+	}
+	if opts.agents < 0 || opts.readers < 0 || opts.agents+opts.readers == 0 || opts.batch < 1 {
+		fmt.Fprintln(os.Stderr, "loadgen: need at least one agent or reader and a positive batch size")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, opts.duration)
+	defer cancel()
+
+	client := &http.Client{Timeout: opts.timeout}
+	samples := make(chan loadgenSample, 1024)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.agents; i++ {
+		wg.Add(1)
+		go func(agent int) {
+			defer wg.Done()
+			runAgent(ctx, client, opts, agent, samples)
+		}(i)
+	}
+	for i := 0; i < opts.readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runReader(ctx, client, opts, samples)
+		}()
+	}
+
+	fmt.Printf("loadgen: %d agents, %d readers against %s for %s\n", opts.agents, opts.readers, opts.target, opts.duration)
+	start := time.Now()
+	go func() {
+		wg.Wait()
+		close(samples)
+	}()
+
+	byOp := map[string][]loadgenSample{}
+	for s := range samples {
+		byOp[s.op] = append(byOp[s.op], s)
+	}
+
+	printLoadgenReport(os.Stdout, byOp, time.Since(start))
+	return 0
+}
+
+// runAgent bulk-writes consecutive days of candles for its own symbol, so agents
+// never wait on each other's import locks
+func runAgent(ctx context.Context, client *http.Client, opts loadgenOptions, agent int, samples chan<- loadgenSample) {
+	symbol := fmt.Sprintf("%s%d", opts.prefix, agent)
+	day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	price := 1000 + rand.Float64()*9000
+	url := fmt.Sprintf("%s/api/v1/market-data/bulk?on_conflict=%s&commit=%s", opts.target, opts.onConflict, opts.commit)
+
+	for ctx.Err() == nil {
+		req := models.BulkCreateRequest{Data: make([]models.MarketData, opts.batch)}
+		for i := range req.Data {
+			open := price
+			price = math.Max(1, price*(1+rand.NormFloat64()*0.01))
+			openPrice, volume := round2(open), rand.Int64N(50_000_000)
+			req.Data[i] = models.MarketData{
+				Symbol: symbol,
+				Date:   day,
+				Open:   &openPrice,
+				High:   round2(math.Max(open, price) * 1.005),
+				Low:    round2(math.Min(open, price) * 0.995),
+				Close:  round2(price),
+				Volume: &volume,
+				Source: "manual",
+			}
+			day = day.AddDate(0, 0, 1)
+		}
+
+		body, err := json.Marshal(req)
+		if err != nil {
+			return
+		}
+		sample := loadgenRequest(ctx, client, opts, http.MethodPost, url, body)
+		if ctx.Err() != nil && sample.status == 0 {
+			return
+		}
+		sample.op = "bulk_write"
+		sample.rows = opts.batch
+		samples <- sample
+	}
+}
+
+// runReader alternates between the newest page and a one-year range of a random
+// agent's symbol, like a dashboard refreshing charts
+func runReader(ctx context.Context, client *http.Client, opts loadgenOptions, samples chan<- loadgenSample) {
+	for n := 0; ctx.Err() == nil; n++ {
+		agents := opts.agents
+		if agents == 0 {
+			agents = 1
+		}
+		symbol := fmt.Sprintf("%s%d", opts.prefix, rand.IntN(agents))
+
+		op, url := "read_page", fmt.Sprintf("%s/api/v1/market-data?symbol=%s&per_page=100", opts.target, symbol)
+		if n%2 == 1 {
+			start := time.Date(2000+rand.IntN(5), 1, 1, 0, 0, 0, 0, time.UTC)
+			op, url = "read_range", fmt.Sprintf("%s/api/v1/market-data/%s?start_date=%s&end_date=%s",
+				opts.target, symbol, start.Format("2006-01-02"), start.AddDate(1, 0, -1).Format("2006-01-02"))
+		}
+
+		sample := loadgenRequest(ctx, client, opts, http.MethodGet, url, nil)
+		if ctx.Err() != nil && sample.status == 0 {
+			return
+		}
+		sample.op = op
+		samples <- sample
+	}
+}
+
+func loadgenRequest(ctx context.Context, client *http.Client, opts loadgenOptions, method, url string, body []byte) loadgenSample {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return loadgenSample{}
+	}
+	req.Header.Set("X-Session-Token", opts.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return loadgenSample{latency: time.Since(start)}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return loadgenSample{status: resp.StatusCode, latency: time.Since(start)}
+}
+
+func printLoadgenReport(w io.Writer, byOp map[string][]loadgenSample, elapsed time.Duration) {
+	ops := make([]string, 0, len(byOp))
+	for op := range byOp {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	var breakdown []string
+	fmt.Fprintln(tw, "op\trequests\treq/s\trows/s\terrors\tp50\tp90\tp99\tmax\t")
+	for _, op := range ops {
+		samples := byOp[op]
+		latencies := make([]time.Duration, 0, len(samples))
+		rows, errors := 0, 0
+		statuses := map[int]int{}
+		for _, s := range samples {
+			latencies = append(latencies, s.latency)
+			statuses[s.status]++
+			if s.status >= 200 && s.status < 300 {
+				rows += s.rows
+			} else {
+				errors++
+			}
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		secs := elapsed.Seconds()
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%.0f\t%d\t%s\t%s\t%s\t%s\t\n",
+			op, len(samples), float64(len(samples))/secs, float64(rows)/secs, errors,
+			percentile(latencies, 0.50), percentile(latencies, 0.90), percentile(latencies, 0.99),
+			percentile(latencies, 1),
+		)
+		if errors > 0 {
+			breakdown = append(breakdown, fmt.Sprintf("%s statuses: %s", op, formatStatuses(statuses)))
+		}
+	}
+	tw.Flush()
+
+	for _, line := range breakdown {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i].Round(time.Millisecond / 10)
+}
+
+func formatStatuses(statuses map[int]int) string {
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		label := fmt.Sprint(code)
+		if code == 0 {
+			label = "network"
+		}
+		parts[i] = fmt.Sprintf("%s=%d", label, statuses[code])
+	}
+	return strings.Join(parts, " ")
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
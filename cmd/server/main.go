@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"fmt"
 	"net/http"
 	"os"
@@ -9,11 +11,28 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ridhomain/proto-trading-service/internal/cache"
+	"github.com/ridhomain/proto-trading-service/internal/changelog"
+	"github.com/ridhomain/proto-trading-service/internal/chaos"
+	"github.com/ridhomain/proto-trading-service/internal/clients/alphavantage"
+	"github.com/ridhomain/proto-trading-service/internal/clients/binance"
+	"github.com/ridhomain/proto-trading-service/internal/clients/fundnav"
+	"github.com/ridhomain/proto-trading-service/internal/clients/fxfeed"
+	"github.com/ridhomain/proto-trading-service/internal/clients/nats"
+	"github.com/ridhomain/proto-trading-service/internal/clients/webhook"
+	"github.com/ridhomain/proto-trading-service/internal/clients/yahoo"
 	"github.com/ridhomain/proto-trading-service/internal/config"
 	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/datasource"
 	"github.com/ridhomain/proto-trading-service/internal/handlers"
+	"github.com/ridhomain/proto-trading-service/internal/metrics"
 	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/provisioning"
+	"github.com/ridhomain/proto-trading-service/internal/scheduler"
 	"github.com/ridhomain/proto-trading-service/internal/services"
+	"github.com/ridhomain/proto-trading-service/internal/storage"
+	"github.com/ridhomain/proto-trading-service/internal/stream"
+	"github.com/ridhomain/proto-trading-service/internal/wal"
 	"github.com/ridhomain/proto-trading-service/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -21,6 +40,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "loadgen" {
+		os.Exit(runLoadgen(os.Args[2:]))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -43,7 +66,11 @@ func main() {
 	)
 
 	// Initialize authentication configuration
-	middleware.InitAuthConfig(cfg.App.KratosPublicURL, cfg.App.KratosBrowserURL)
+	middleware.InitAuthConfig(cfg.App.KratosPublicURL, cfg.App.KratosBrowserURL, middleware.SessionOptions{
+		Timeout:          cfg.App.KratosTimeout,
+		FailureThreshold: cfg.App.KratosBreakerThreshold,
+		Cooldown:         cfg.App.KratosBreakerCooldown,
+	})
 
 	// Wait for dependencies to be ready
 	if err := waitForDependencies(cfg); err != nil {
@@ -56,25 +83,273 @@ func main() {
 		logger.Fatal("Failed to initialize database", zap.Error(err))
 	}
 	defer db.Close()
+	metrics.RegisterDB(db)
 
-	// Run migrations (in production, this should be done separately)
+	// Apply pending migrations (in production, run cmd/migrate separately)
 	if cfg.Logger.Environment == "development" {
 		logger.Info("Running database migrations...")
-		if err := runMigrations(db); err != nil {
+		if _, err := db.MigrateUp(context.Background()); err != nil {
 			logger.Warn("Migration warning", zap.Error(err))
 		}
 	}
 
-	// Initialize services
-	marketService := services.NewMarketService(db)
+	// Cache hot market data reads when Redis is configured
+	var readCache cache.Cache = cache.Noop{}
+	if cfg.App.RedisURL != "" {
+		pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		redisCache, err := cache.NewRedis(pingCtx, cfg.App.RedisURL, cfg.App.CacheTTL)
+		cancel()
+		if err != nil {
+			logger.Warn("Redis unavailable, market data reads will not be cached", zap.Error(err))
+		} else {
+			defer redisCache.Close()
+			readCache = redisCache
+		}
+	}
+
+	// Changes are published through the outbox when a webhook or NATS server is configured
+	var publisher services.Publisher
+	switch {
+	case cfg.App.OutboxWebhookURL != "" && cfg.App.OutboxNATSURL != "":
+		logger.Fatal("Set only one of OUTBOX_WEBHOOK_URL and OUTBOX_NATS_URL")
+	case cfg.App.OutboxWebhookURL != "":
+		publisher = webhook.New(cfg.App.OutboxWebhookURL, cfg.App.OutboxWebhookSecret, cfg.App.OutboxWebhookTimeout)
+	case cfg.App.OutboxNATSURL != "":
+		natsClient, err := nats.New(cfg.App.OutboxNATSURL, cfg.App.OutboxNATSPrefix, cfg.App.OutboxNATSTimeout)
+		if err != nil {
+			logger.Fatal("Invalid OUTBOX_NATS_URL", zap.Error(err))
+		}
+		publisher = natsClient
+	}
+	outboxService := services.NewOutboxService(db, publisher, services.OutboxOptions{
+		PollInterval: cfg.App.OutboxPollInterval,
+		MaxAttempts:  cfg.App.OutboxMaxAttempts,
+		Retention:    cfg.App.OutboxRetention,
+	})
+
+	// Users' own webhooks are told of their imports, exports and quota warnings
+	webhookService := services.NewWebhookService(db, webhook.NewPublicSender(cfg.App.WebhookTimeout), cfg.App.WebhookMaxAttempts)
+
+	// Candles older than ARCHIVE_AFTER_YEARS move to their own object store
+	var archiveStore storage.ObjectStore
+	if cfg.App.ArchiveAfterYears > 0 {
+		if archiveStore, err = storage.NewLocalStore(cfg.App.ArchiveDir); err != nil {
+			logger.Fatal("Failed to initialize archive storage", zap.Error(err))
+		}
+	}
+
+	// Initialize services; stored candles are pushed to stream subscribers
+	hub := stream.NewHub()
+	marketService := services.NewMarketService(db, hub, readCache, services.MarketOptions{
+		ImportLockTimeout: cfg.App.ImportLockTimeout,
+		MaxRangeRows:      int64(cfg.App.MaxRangeRows),
+		Outbox:            outboxService,
+		Archive:           archiveStore,
+	})
 	userService := services.NewUserService(db)
+	feeService := services.NewFeeService(db)
+	sourceService := services.NewSourceService(db, cfg.App.BlockRestrictedExports)
+
+	accountService := services.NewAccountService(db)
+	confirmationService := services.NewConfirmationService(db)
+	anomalyService := services.NewAnomalyService(db, sourceService)
+	navService := services.NewNAVService(db)
+	bondService := services.NewBondService(db)
+	fxService := services.NewFXService(db)
+	portfolioService := services.NewPortfolioService(db, marketService, navService, bondService, fxService, outboxService, hub)
+	exchangeService := services.NewExchangeService(db)
+	strategyService := services.NewStrategyService(db)
+	symbolService := services.NewSymbolService(db)
+	fundamentalsService := services.NewFundamentalsService(db, marketService)
+	financialsService := services.NewFinancialsService(db, marketService, fundamentalsService)
+
+	rolePermissions, err := services.ParseRolePermissions(cfg.App.RolePermissions)
+	if err != nil {
+		logger.Fatal("Invalid RBAC_ROLE_PERMISSIONS", zap.Error(err))
+	}
+	rbacService := services.NewRBACService(db, rolePermissions)
+	middleware.InitRBAC(rbacService.Permissions)
+
+	// Endpoints slated for removal are flagged to callers, who are counted per client
+	deprecationService := services.NewDeprecationService(db, deprecations)
+	middleware.InitDeprecations(deprecationService)
+
+	// Release notes ship with the binary; a malformed file stops startup
+	releases, err := changelog.Load()
+	if err != nil {
+		logger.Fatal("Invalid release notes", zap.Error(err))
+	}
+	changelogService := services.NewChangelogService(db, releases)
+
+	// /ready fails only on the gates named in READY_GATES; the rest are reported
+	readyGates, err := services.ParseReadinessGates(cfg.App.ReadyGates)
+	if err != nil {
+		logger.Fatal("Invalid READY_GATES", zap.Error(err))
+	}
+	readinessService := services.NewReadinessService(db, readCache, outboxService, services.ReadinessOptions{
+		Blocking:         readyGates,
+		MaxImportBacklog: cfg.App.ReadyMaxImportBacklog,
+		MaxOutboxLag:     cfg.App.ReadyMaxOutboxLag,
+	})
+	yahooClient := yahoo.New(cfg.App.YahooAPIBaseURL, cfg.App.YahooAPITimeout)
+	binanceClient := binance.New(cfg.App.BinanceAPIBaseURL, cfg.App.BinanceAPITimeout)
+	alphaVantageClient := alphavantage.New(cfg.App.AlphaVantageAPIBaseURL, cfg.App.AlphaVantageAPIKey, cfg.App.AlphaVantageAPITimeout, cfg.App.AlphaVantageRateLimit)
+	// Providers candles can be fetched from on demand; register new ones here
+	dataSources, err := datasource.NewRegistry(yahooClient, alphaVantageClient, binanceClient)
+	if err != nil {
+		logger.Fatal("Invalid data source registry", zap.Error(err))
+	}
+	// Quote fallback chain, freshest source first; quotes from before the exchange's
+	// latest session are stale
+	quoteService := services.NewQuoteService(exchangeService,
+		services.QuoteStep{
+			Name:    "intraday",
+			Timeout: cfg.App.QuoteStepTimeout,
+			MaxAge:  cfg.App.QuoteIntradayMaxAge,
+			Fetch:   marketService.LatestIntradayQuote,
+		},
+		services.QuoteStep{
+			Name:    "latest_daily",
+			Timeout: cfg.App.QuoteStepTimeout,
+			Fetch:   marketService.LatestQuote,
+		},
+		services.QuoteStep{
+			Name:    "live",
+			Timeout: cfg.App.QuoteStepTimeout,
+			Fetch:   services.LiveQuote(dataSources),
+		},
+	)
+	fundNAVClient := fundnav.New(cfg.App.FundNAVAPIBaseURL, cfg.App.FundNAVAPIKey, cfg.App.FundNAVAPITimeout)
+
+	// Export jobs run in the background and are stored outside the database
+	exportStore, err := storage.NewLocalStore(cfg.App.ExportDir)
+	if err != nil {
+		logger.Fatal("Failed to initialize export storage", zap.Error(err))
+	}
+	signingKey := []byte(cfg.App.ExportSigningKey)
+	if len(signingKey) == 0 {
+		logger.Warn("EXPORT_SIGNING_KEY not set, using a random key; download links will not survive restarts")
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			logger.Fatal("Failed to generate export signing key", zap.Error(err))
+		}
+	}
+	// Offline bundles are signed so the mobile app can trust what it cached
+	var offlineKey ed25519.PrivateKey
+	if cfg.App.OfflineSigningKey == "" {
+		logger.Warn("OFFLINE_SIGNING_KEY not set, using a random key; bundles will not verify after a restart")
+		if _, offlineKey, err = ed25519.GenerateKey(rand.Reader); err != nil {
+			logger.Fatal("Failed to generate offline signing key", zap.Error(err))
+		}
+	} else if offlineKey, err = services.ParseOfflineKey(cfg.App.OfflineSigningKey); err != nil {
+		logger.Fatal("Invalid OFFLINE_SIGNING_KEY", zap.Error(err))
+	}
+	offlineService := services.NewOfflineService(db, marketService, userService, offlineKey)
+
+	exportService := services.NewExportService(db, marketService, sourceService, exportStore, services.ExportOptions{
+		SigningKey: signingKey,
+		URLTTL:     cfg.App.ExportURLTTL,
+		MaxRows:    int64(cfg.App.ExportMaxRows),
+		DailyQuota: cfg.App.ExportDailyQuota,
+		Outbox:     outboxService,
+		Webhooks:   webhookService,
+	})
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go exportService.Start(workerCtx)
+	go outboxService.Start(workerCtx)
+	go webhookService.Start(workerCtx)
+	go deprecationService.Start(workerCtx)
+
+	// Large CSV uploads are imported in the background from the same object store
+	importService := services.NewImportService(db, marketService, anomalyService, exportStore, webhookService)
+	noteService := services.NewNoteService(db, exportStore)
+	searchService := services.NewSearchService(db)
+	go importService.Start(workerCtx)
+
+	// Rights issues and warrants adjust holdings as their ex-dates arrive
+	corporateActionService := services.NewCorporateActionService(db, marketService, outboxService)
+	go corporateActionService.Start(workerCtx)
+	dividendService := services.NewDividendService(db, portfolioService)
+
+	// Watchlisted equities are refreshed from Yahoo on FETCH_SCHEDULE
+	var fetchSchedule *scheduler.Schedule
+	if cfg.App.FetchSchedule != "" {
+		fetchSchedule, err = scheduler.Parse(cfg.App.FetchSchedule)
+		if err != nil {
+			logger.Fatal("Invalid FETCH_SCHEDULE", zap.Error(err))
+		}
+	}
+	watchlistFetcher := scheduler.NewWatchlistFetcher(db, fetchSchedule, yahooClient, userService, anomalyService, marketService, exchangeService, scheduler.WatchlistOptions{
+		LookbackDays: cfg.App.FetchLookbackDays,
+		MaxBackoff:   cfg.App.FetchMaxBackoff,
+		HeartbeatURL: cfg.App.FetchHeartbeatURL,
+	})
+	go watchlistFetcher.Start(workerCtx)
+
+	// Reference rates for FX_PAIRS are stored from FX_SOURCE_URL on FX_FETCH_SCHEDULE
+	var fxSchedule *scheduler.Schedule
+	if cfg.App.FXFetchSchedule != "" {
+		if fxSchedule, err = scheduler.Parse(cfg.App.FXFetchSchedule); err != nil {
+			logger.Fatal("Invalid FX_FETCH_SCHEDULE", zap.Error(err))
+		}
+	}
+	fxFeed := fxfeed.New(cfg.App.FXSourceURL, cfg.App.FXSourceAPIKey, cfg.App.FXSourceTimeout)
+	fxFetcher, err := scheduler.NewFXFetcher(fxFeed, fxService, fxSchedule, cfg.App.FXPairs)
+	if err != nil {
+		logger.Fatal("Invalid FX_PAIRS", zap.Error(err))
+	}
+	go fxFetcher.Start(workerCtx)
+
+	// Sources with an ingest window are reported when they stop delivering
+	ingestMonitor := scheduler.NewIngestMonitor(db, outboxService, cfg.App.IngestCheckInterval)
+	go ingestMonitor.Start(workerCtx)
+
+	// Old candles are archived daily once ARCHIVE_AFTER_YEARS is set
+	archiver := scheduler.NewArchiver(marketService, cfg.App.ArchiveAfterYears, cfg.App.ArchiveInterval)
+	go archiver.Start(workerCtx)
+
+	// Bulk ingests are held in INGEST_WAL_PATH while the database is unreachable
+	// and replayed once it is back
+	var ingestLog *wal.Log
+	if cfg.App.IngestWALPath != "" {
+		if ingestLog, err = wal.Open(cfg.App.IngestWALPath, cfg.App.IngestWALMaxBytes); err != nil {
+			logger.Fatal("Failed to open ingest write-ahead log", zap.Error(err))
+		}
+		defer ingestLog.Close()
+	}
+	ingestBuffer, err := services.NewIngestBuffer(ingestLog, marketService, anomalyService, cfg.App.IngestReplayInterval)
+	if err != nil {
+		logger.Fatal("Failed to initialize ingest buffer", zap.Error(err))
+	}
+	go ingestBuffer.Start(workerCtx)
+
+	// Organization settings declared under PROVISIONING_DIR are applied once per start
+	provisioner := provisioning.New(cfg.App.ProvisioningDir, userService)
+	if err := provisioner.Apply(workerCtx); err != nil {
+		logger.Error("Failed to apply provisioning", zap.Error(err))
+	}
 
 	// Initialize handlers
-	handler := handlers.NewHandler(marketService, userService)
+	// Fault injection for resilience testing, configured at runtime by admins
+	var injector *chaos.Injector
+	if cfg.App.ChaosEnabled {
+		if cfg.Logger.Environment == "production" {
+			logger.Warn("CHAOS_ENABLED is ignored in production")
+		} else {
+			logger.Warn("Fault injection enabled")
+			injector = chaos.NewInjector()
+		}
+	}
+
+	handler := handlers.NewHandler(marketService, userService, feeService, sourceService, quoteService, accountService, confirmationService, exportService, anomalyService, portfolioService, exchangeService, fxService, symbolService, importService, navService, bondService, corporateActionService, dividendService, fundamentalsService, financialsService, rbacService, noteService, searchService, outboxService, strategyService, webhookService, deprecationService, changelogService, offlineService, readinessService, ingestBuffer, dataSources, fundNAVClient, watchlistFetcher, fxFetcher, archiver, provisioner, hub, injector)
 
 	// Setup Gin
 	gin.SetMode(cfg.Server.Mode)
-	router := setupRouter(handler, cfg)
+	router := setupRouter(handler, cfg, accountService.CanonicalID, injector)
+	if err := checkDeprecations(router, deprecations); err != nil {
+		logger.Fatal("Invalid deprecation registry", zap.Error(err))
+	}
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -105,6 +380,7 @@ func main() {
 	<-quit
 
 	logger.Info("Shutting down server...")
+	stopWorkers()
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -117,28 +393,41 @@ func main() {
 	logger.Info("Server exited gracefully")
 }
 
-func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
+func setupRouter(h *handlers.Handler, cfg *config.Config, resolveIdentity middleware.IdentityResolver, injector *chaos.Injector) *gin.Engine {
 	r := gin.New()
 
 	// Global middleware
 	r.Use(middleware.Recovery())
+	r.Use(middleware.Metrics())
 	r.Use(middleware.Logger())
 	r.Use(middleware.RequestID())
+	r.Use(middleware.Deprecations())
+	r.Use(middleware.Deadline())
 	r.Use(middleware.SecurityHeaders())
 	r.Use(middleware.CORS())
 	r.Use(middleware.CORSPreflightHandler())
+	if injector != nil {
+		r.Use(injector.Middleware())
+	}
 
 	// Public endpoints (no auth required)
 	r.GET("/health", h.Health)
 	r.GET("/ready", h.Ready)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Signed export downloads; the signature stands in for the session
+	r.GET("/api/v1/exports/:id/download", h.DownloadExport)
 
 	// Add a public endpoint to check auth status
 	r.GET("/auth/status", middleware.OptionalAuth(), h.AuthStatus)
 
+	// Release notes are public; signed-in callers also see what they have not read
+	r.GET("/api/v1/changelog", middleware.OptionalAuth(), middleware.ResolveIdentity(resolveIdentity), h.GetChangelog)
+
 	// Auth endpoints
 	auth := r.Group("/auth")
 	{
-		auth.GET("/me", middleware.AuthRequired(), h.GetCurrentUser)
+		auth.GET("/me", middleware.AuthRequired(), middleware.ResolveIdentity(resolveIdentity), h.GetCurrentUser)
 		auth.POST("/logout", h.Logout)
 		auth.GET("/login-url", h.GetLoginURL)
 	}
@@ -146,6 +435,7 @@ func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
 	// API v1 routes (protected)
 	v1 := r.Group("/api/v1")
 	v1.Use(middleware.AuthRequired())
+	v1.Use(middleware.ResolveIdentity(resolveIdentity))
 	{
 		// Market data endpoints
 		market := v1.Group("/market-data")
@@ -153,15 +443,247 @@ func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
 			market.GET("", h.GetMarketData)
 			market.POST("", h.CreateMarketData)
 			market.GET("/:symbol", h.GetMarketDataBySymbol)
+			market.GET("/:symbol/profile", h.GetMarketDataProfile)
+			market.GET("/:symbol/gaps", h.GetMarketDataGaps)
+			market.GET("/:symbol/aggregate", h.GetMarketDataAggregate)
+			market.GET("/:symbol/compare", h.CompareMarketData)
+			market.GET("/:symbol/export", h.ExportMarketData)
 			market.POST("/yahoo/:symbol", h.FetchYahooData)
-			market.DELETE("/:symbol", middleware.RoleRequired("admin"), h.DeleteMarketData)
+			market.POST("/binance/:symbol", h.FetchBinanceData)
+			market.POST("/fetch/:symbol", h.FetchMarketData)
+			market.DELETE("/:symbol", middleware.PermissionRequired("market_data:delete"), h.DeleteMarketData)
 			market.POST("/bulk", h.BulkCreateMarketData)
 		}
 
+		// Quotes
+		v1.GET("/quote/:symbol", h.GetQuote)
+		v1.GET("/watchlist/quotes", h.GetWatchlistQuotes)
+
+		// Live market data over WebSocket, or Server-Sent Events where WebSockets are blocked
+		v1.GET("/stream/market-data", h.StreamMarketData)
+		v1.GET("/stream/sse", h.StreamMarketDataSSE)
+
+		// Data sources
+		v1.GET("/sources", h.ListSources)
+		v1.PUT("/sources/:name/anomaly-policy", middleware.PermissionRequired("sources:write"), h.UpdateAnomalyPolicy)
+		v1.PUT("/sources/:name/ingest-window", middleware.PermissionRequired("sources:write"), h.UpdateIngestWindow)
+		v1.PUT("/sources/:name/transform-rules", middleware.PermissionRequired("sources:write"), h.UpdateTransformRules)
+		v1.POST("/sources/:name/transform-rules/preview", middleware.PermissionRequired("sources:write"), h.PreviewTransformRules)
+
+		// Ingestion anomalies
+		v1.GET("/anomalies", middleware.PermissionRequired("anomalies:read"), h.ListAnomalies)
+
+		// Exchanges and trading calendars
+		exchanges := v1.Group("/exchanges")
+		{
+			exchanges.GET("", h.ListExchanges)
+			exchanges.GET("/:code", h.GetExchange)
+			exchanges.GET("/:code/calendar", h.GetExchangeCalendar)
+			exchanges.GET("/:code/tick-size", h.GetTickSize)
+			exchanges.GET("/:code/holidays", h.ListExchangeHolidays)
+			exchanges.POST("/:code/holidays", middleware.PermissionRequired("exchanges:write"), h.AddExchangeHoliday)
+			exchanges.PUT("/:code/holidays", middleware.PermissionRequired("exchanges:write"), h.UploadExchangeHolidays)
+			exchanges.DELETE("/:code/holidays/:date", middleware.PermissionRequired("exchanges:write"), h.DeleteExchangeHoliday)
+		}
+
+		// Symbol reference data
+		symbols := v1.Group("/symbols")
+		{
+			symbols.GET("", h.ListSymbols)
+			symbols.POST("", middleware.PermissionRequired("symbols:write"), h.CreateSymbol)
+			symbols.POST("/upload", middleware.PermissionRequired("symbols:write"), h.UploadSymbolsCSV)
+			symbols.POST("/fundamentals", middleware.PermissionRequired("fundamentals:write"), h.UpsertFundamentals)
+			symbols.POST("/financials", middleware.PermissionRequired("fundamentals:write"), h.UpsertFinancials)
+			symbols.POST("/financials/upload", middleware.PermissionRequired("fundamentals:write"), h.UploadFinancialsCSV)
+			symbols.GET("/sectors", h.GetSectors)
+			symbols.GET("/:exchange/fundamentals", h.GetFundamentals) // /symbols/:symbol/fundamentals
+			symbols.GET("/:exchange/financials", h.GetFinancials)     // /symbols/:symbol/financials
+			symbols.GET("/:exchange/notes", h.ListSymbolNotes)        // /symbols/:symbol/notes
+			symbols.POST("/:exchange/notes", h.CreateSymbolNote)
+			symbols.GET("/:exchange/:symbol", h.GetSymbol)
+			symbols.PUT("/:exchange/:symbol", middleware.PermissionRequired("symbols:write"), h.UpdateSymbol)
+			symbols.DELETE("/:exchange/:symbol", middleware.PermissionRequired("symbols:write"), h.DeleteSymbol)
+		}
+
+		// Search across symbols and notes
+		v1.GET("/search", h.Search)
+
+		// What's new: the changelog itself is public, marking it read needs a user
+		v1.POST("/changelog/seen", h.MarkChangelogSeen)
+
+		// Research notes
+		notes := v1.Group("/notes")
+		{
+			notes.GET("", h.SearchNotes)
+			notes.GET("/:id", h.GetNote)
+			notes.PUT("/:id", h.UpdateNote)
+			notes.DELETE("/:id", h.DeleteNote)
+			notes.POST("/:id/attachments", middleware.MaxBodySize(cfg.App.MaxUploadSize), h.AddNoteAttachment)
+			notes.GET("/:id/attachments/:attachment", h.DownloadNoteAttachment)
+			notes.DELETE("/:id/attachments/:attachment", h.DeleteNoteAttachment)
+		}
+
+		// Mutual fund NAVs
+		nav := v1.Group("/nav")
+		{
+			nav.POST("", h.UpsertNAV)
+			nav.GET("/:symbol", h.GetNAV)
+			nav.POST("/fetch/:symbol", h.FetchFundNAV)
+		}
+
+		// Bonds: terms, coupon schedules and price/yield quotes
+		bonds := v1.Group("/bonds")
+		{
+			bonds.GET("", h.ListBonds)
+			bonds.POST("", middleware.PermissionRequired("bonds:write"), h.CreateBond)
+			bonds.GET("/:symbol", h.GetBond)
+			bonds.GET("/:symbol/coupons", h.GetBondCoupons)
+			bonds.PUT("/:symbol/coupons", middleware.PermissionRequired("bonds:write"), h.UpdateBondCoupons)
+			bonds.GET("/:symbol/quotes", h.GetBondQuotes)
+			bonds.POST("/:symbol/quotes", h.RecordBondQuote)
+		}
+
+		// Corporate actions; ambiguous ones wait in the review queue (?status=pending_review)
+		actions := v1.Group("/corporate-actions")
+		{
+			actions.GET("", h.ListCorporateActions)
+			actions.GET("/:id", h.GetCorporateAction)
+			actions.POST("", middleware.PermissionRequired("corporate_actions:write"), h.CreateCorporateAction)
+			actions.POST("/:id/approve", middleware.PermissionRequired("corporate_actions:approve"), h.ApproveCorporateAction)
+			actions.POST("/:id/reject", middleware.PermissionRequired("corporate_actions:approve"), h.RejectCorporateAction)
+		}
+
+		// Dividend calendar (?from=&to=&symbol=); projected income is under /portfolios/:id/dividends
+		dividends := v1.Group("/dividends")
+		{
+			dividends.GET("", h.ListDividends)
+			dividends.GET("/:id", h.GetDividend)
+			dividends.POST("", middleware.PermissionRequired("dividends:write"), h.CreateDividend)
+			dividends.PUT("/:id", middleware.PermissionRequired("dividends:write"), h.UpdateDividend)
+			dividends.DELETE("/:id", middleware.PermissionRequired("dividends:write"), h.DeleteDividend)
+		}
+
+		// FX rates and conversion
+		fx := v1.Group("/fx")
+		{
+			fx.GET("/:pair", h.GetFXRate)
+			fx.POST("/convert", h.ConvertCurrency)
+			fx.POST("/rates", middleware.PermissionRequired("fx:write"), h.UpsertFXRates)
+			fx.POST("/fetch", middleware.PermissionRequired("fx:write"), h.FetchFXRates)
+		}
+
+		// Admin tools
+		admin := v1.Group("/admin")
+		{
+			admin.GET("/reconcile", middleware.PermissionRequired("sources:reconcile"), h.Reconcile)
+			admin.GET("/fetch-status", middleware.PermissionRequired("fetches:read"), h.GetFetchStatus)
+			admin.POST("/refetch", middleware.PermissionRequired("fetches:write"), h.TriggerRefetch)
+			admin.GET("/data/coverage", middleware.PermissionRequired("data:read"), h.GetDataCoverage)
+			admin.DELETE("/market-data", middleware.PermissionRequired("market_data:delete"), h.DeleteMarketDataRange)
+			admin.GET("/archive", middleware.PermissionRequired("data:read"), h.GetArchiveStatus)
+			admin.POST("/archive/run", middleware.PermissionRequired("data:archive"), h.TriggerArchive)
+			admin.GET("/ingest-buffer", middleware.PermissionRequired("data:read"), h.GetIngestBufferStatus)
+			admin.GET("/provisioning", middleware.PermissionRequired("provisioning:read"), h.GetProvisioningStatus)
+			admin.GET("/imports", middleware.PermissionRequired("imports:read"), h.ListImportHistory)
+			admin.GET("/outbox", middleware.PermissionRequired("outbox:read"), h.GetOutboxStatus)
+			admin.GET("/deprecations", middleware.PermissionRequired("deprecations:read"), h.GetDeprecations)
+			admin.GET("/org/export", middleware.PermissionRequired("org:read"), h.ExportOrganization)
+			admin.POST("/org/import", middleware.PermissionRequired("org:write"), h.ImportOrganization)
+			admin.POST("/outbox/retry", middleware.PermissionRequired("outbox:write"), h.RetryOutboxMessages)
+			admin.POST("/outbox/:id/retry", middleware.PermissionRequired("outbox:write"), h.RetryOutboxMessage)
+			admin.GET("/roles", middleware.PermissionRequired("rbac:read"), h.ListRoles)
+			admin.PUT("/roles/:role", middleware.PermissionRequired("rbac:write"), h.SetRolePermissions)
+			admin.GET("/permissions", middleware.PermissionRequired("rbac:read"), h.GetEffectivePermissions)
+			if injector != nil {
+				admin.GET("/chaos", middleware.PermissionRequired("chaos:read"), h.GetChaosRules)
+				admin.PUT("/chaos", middleware.PermissionRequired("chaos:write"), h.SetChaosRules)
+				admin.DELETE("/chaos", middleware.PermissionRequired("chaos:write"), h.ClearChaosRules)
+			}
+		}
+
+		// Export jobs
+		exports := v1.Group("/exports")
+		{
+			exports.POST("", h.CreateExport)
+			exports.GET("", h.ListExports)
+			exports.GET("/quota", h.GetExportQuota)
+			exports.GET("/:id", h.GetExport)
+		}
+
+		// Portfolios
+		portfolios := v1.Group("/portfolios")
+		{
+			portfolios.GET("", h.ListPortfolios)
+			portfolios.POST("", h.CreatePortfolio)
+			portfolios.GET("/:id", h.GetPortfolio)
+			portfolios.PUT("/:id", h.RenamePortfolio)
+			portfolios.DELETE("/:id", h.DeletePortfolio)
+			portfolios.PUT("/:id/holdings/:symbol", h.SetHolding)
+			portfolios.DELETE("/:id/holdings/:symbol", h.RemoveHolding)
+			portfolios.GET("/:id/adjustments", h.GetPortfolioAdjustments)
+			portfolios.GET("/:id/dividends", h.GetPortfolioDividends)
+			portfolios.GET("/:id/events", h.GetPortfolioEvents)
+			portfolios.POST("/:id/trades", h.RecordTrade)
+			portfolios.POST("/:id/cash", h.RecordCash)
+		}
+
 		// Upload endpoints
-		upload := v1.Group("/upload")
+		upload := v1.Group("/upload", middleware.MaxBodySize(cfg.App.MaxUploadSize))
 		{
 			upload.POST("/csv", h.UploadCSV)
+			upload.POST("/jobs", h.CreateImport)
+			upload.GET("/jobs", h.ListImports)
+			upload.GET("/jobs/:id", h.GetImport)
+		}
+
+		// Strategy definitions, saved and shared
+		strategies := v1.Group("/strategies")
+		{
+			strategies.POST("/validate", h.ValidateStrategy)
+			strategies.GET("", h.ListStrategies)
+			strategies.POST("", h.CreateStrategy)
+			strategies.GET("/:id", h.GetStrategy)
+			strategies.PUT("/:id", h.UpdateStrategy)
+			strategies.DELETE("/:id", h.DeleteStrategy)
+			strategies.POST("/:id/clone", h.CloneStrategy)
+		}
+
+		// Webhooks the user registers for their own events, with each one's delivery log
+		webhooks := v1.Group("/webhooks")
+		{
+			webhooks.GET("", h.ListWebhooks)
+			webhooks.POST("", h.CreateWebhook)
+			webhooks.GET("/:id", h.GetWebhook)
+			webhooks.PUT("/:id", h.UpdateWebhook)
+			webhooks.DELETE("/:id", h.DeleteWebhook)
+			webhooks.POST("/:id/ping", h.PingWebhook)
+			webhooks.GET("/:id/deliveries", h.ListWebhookDeliveries)
+			webhooks.POST("/:id/deliveries/:delivery/redeliver", h.RedeliverWebhookDelivery)
+		}
+
+		// Linked identities
+		account := v1.Group("/account")
+		{
+			account.GET("/links", h.ListLinks)
+			account.POST("/links", h.CreateLinkToken)
+			account.POST("/links/confirm", h.ConfirmLink)
+			account.DELETE("/links/:identity_id", h.Unlink)
+		}
+
+		// User settings
+		settings := v1.Group("/settings")
+		{
+			settings.GET("/fees", h.GetFeeSettings)
+			settings.PUT("/fees", h.UpdateFeeSettings)
+			settings.DELETE("/fees", h.ResetFeeSettings)
+			settings.GET("/fees/estimate", h.EstimateFees)
+		}
+
+		// Signed bundles the mobile app caches for offline use
+		offline := v1.Group("/offline")
+		{
+			offline.GET("/bundle", h.GetOfflineBundle)
+			offline.GET("/key", h.GetOfflineKey)
 		}
 
 		// User preferences
@@ -169,6 +691,7 @@ func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
 		{
 			prefs.GET("", h.GetUserPreferences)
 			prefs.PUT("", h.UpdateUserPreferences)
+			prefs.GET("/watchlist/performance", h.GetWatchlistPerformance)
 			prefs.POST("/watchlist/:symbol", h.AddToWatchlist)
 			prefs.DELETE("/watchlist/:symbol", h.RemoveFromWatchlist)
 		}
@@ -224,45 +747,3 @@ func waitForDependencies(cfg *config.Config) error {
 
 	return nil
 }
-
-func runMigrations(db *database.DB) error {
-	// In production, migrations should be run separately
-	// This is just for development convenience
-	ctx := context.Background()
-
-	migrations := []string{
-		`CREATE TABLE IF NOT EXISTS market_data (
-			id BIGSERIAL PRIMARY KEY,
-			symbol VARCHAR(20) NOT NULL,
-			date DATE NOT NULL,
-			open DECIMAL(10, 2),
-			high DECIMAL(10, 2),
-			low DECIMAL(10, 2),
-			close DECIMAL(10, 2),
-			volume BIGINT,
-			source VARCHAR(50) NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(symbol, date, source)
-		);`,
-		`CREATE INDEX IF NOT EXISTS idx_market_data_symbol_date ON market_data(symbol, date);`,
-		`CREATE TABLE IF NOT EXISTS user_preferences (
-			user_id VARCHAR(255) PRIMARY KEY,
-			email VARCHAR(255) NOT NULL,
-			default_source VARCHAR(50) DEFAULT 'yahoo',
-			selected_symbols TEXT[] DEFAULT '{}',
-			watchlist TEXT[] DEFAULT '{}', 
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		);`,
-		`CREATE INDEX IF NOT EXISTS idx_user_preferences_email ON user_preferences(email);`,
-	}
-
-	for _, migration := range migrations {
-		if _, err := db.Exec(ctx, migration); err != nil {
-			return err
-		}
-	}
-
-	logger.Info("Database migrations completed")
-	return nil
-}
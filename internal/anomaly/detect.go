@@ -0,0 +1,144 @@
+package anomaly
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// Policies a source can apply to anomalous candles
+const (
+	PolicyReject         = "reject"           // drop the row and report it
+	PolicyQuarantine     = "quarantine"       // hold the row for review instead of storing it
+	PolicyAcceptWithFlag = "accept_with_flag" // store the row and record the anomaly
+	PolicyAutoCorrect    = "auto_correct"     // fix what can be fixed, quarantine the rest
+)
+
+// Kinds of anomaly
+const (
+	KindNonPositivePrice = "non_positive_price"
+	KindNegativeVolume   = "negative_volume"
+	KindInconsistentOHLC = "inconsistent_ohlc"
+	KindDecimalShift     = "decimal_shift"
+	KindPriceJump        = "price_jump"
+)
+
+// MaxDailyMove is the largest close-to-close move accepted without a flag. IDX
+// auto-rejection caps daily moves at 35% for the cheapest tier.
+const MaxDailyMove = 0.35
+
+// decimalTolerance is how close a ratio must be to a power of ten to count as a shift
+const decimalTolerance = 0.2
+
+// Policies lists the valid policy names
+var Policies = []string{PolicyReject, PolicyQuarantine, PolicyAcceptWithFlag, PolicyAutoCorrect}
+
+// ValidPolicy reports whether policy is a known policy name
+func ValidPolicy(policy string) bool {
+	for _, p := range Policies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// Finding is one anomaly detected on a candle
+type Finding struct {
+	Kind   string  `json:"kind"`
+	Detail string  `json:"detail"`
+	Factor float64 `json:"-"` // for decimal shifts, what prices must be multiplied by
+}
+
+// Detect checks a candle on its own and against the previous close (0 when unknown)
+func Detect(md models.MarketData, prevClose float64) []Finding {
+	var findings []Finding
+
+	// A missing open or volume is not an anomaly; the open is bounded as the close
+	open := md.Close
+	if md.Open != nil {
+		open = *md.Open
+	}
+
+	if open <= 0 || md.High <= 0 || md.Low <= 0 || md.Close <= 0 {
+		findings = append(findings, Finding{
+			Kind:   KindNonPositivePrice,
+			Detail: "open, high, low and close must be positive",
+		})
+	}
+	if md.Volume != nil && *md.Volume < 0 {
+		findings = append(findings, Finding{
+			Kind:   KindNegativeVolume,
+			Detail: fmt.Sprintf("volume %d is negative", *md.Volume),
+		})
+	}
+	if md.High < md.Low || md.High < math.Max(open, md.Close) || md.Low > math.Min(open, md.Close) {
+		findings = append(findings, Finding{
+			Kind:   KindInconsistentOHLC,
+			Detail: fmt.Sprintf("high %g / low %g do not bound open %g / close %g", md.High, md.Low, open, md.Close),
+		})
+	}
+
+	if prevClose <= 0 || md.Close <= 0 {
+		return findings
+	}
+
+	ratio := md.Close / prevClose
+	if factor := decimalShift(ratio); factor != 0 {
+		findings = append(findings, Finding{
+			Kind:   KindDecimalShift,
+			Detail: fmt.Sprintf("close %g is %.4gx the previous close %g", md.Close, ratio, prevClose),
+			Factor: factor,
+		})
+	} else if math.Abs(ratio-1) > MaxDailyMove {
+		findings = append(findings, Finding{
+			Kind:   KindPriceJump,
+			Detail: fmt.Sprintf("close moved %.1f%% from the previous close %g", (ratio-1)*100, prevClose),
+		})
+	}
+
+	return findings
+}
+
+// Correct fixes findings in place where the intended value is unambiguous and
+// reports whether every finding was corrected
+func Correct(md *models.MarketData, findings []Finding) bool {
+	corrected := true
+	for _, f := range findings {
+		switch f.Kind {
+		case KindDecimalShift:
+			if md.Open != nil {
+				open := *md.Open * f.Factor
+				md.Open = &open
+			}
+			md.High *= f.Factor
+			md.Low *= f.Factor
+			md.Close *= f.Factor
+		case KindInconsistentOHLC:
+			open := md.Close
+			if md.Open != nil {
+				open = *md.Open
+			}
+			high := math.Max(math.Max(open, md.Close), math.Max(md.High, md.Low))
+			low := math.Min(math.Min(open, md.Close), math.Min(md.High, md.Low))
+			md.High, md.Low = high, low
+		default:
+			corrected = false
+		}
+	}
+	return corrected
+}
+
+// decimalShift returns the factor that undoes a misplaced decimal point, or 0
+func decimalShift(ratio float64) float64 {
+	for _, scale := range []float64{10, 100, 1000} {
+		if math.Abs(ratio/scale-1) < decimalTolerance {
+			return 1 / scale
+		}
+		if math.Abs(ratio*scale-1) < decimalTolerance {
+			return scale
+		}
+	}
+	return 0
+}
@@ -0,0 +1,175 @@
+package apierror
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDKey is the context key the RequestID middleware stores the request ID under
+const RequestIDKey = "request_id"
+
+// Code is a stable, machine-readable error code clients can branch on. Error and
+// Message are for people and may change wording; codes do not.
+type Code string
+
+// Codes answered for a status when a response does not name a more specific one
+const (
+	CodeBadRequest         Code = "BAD_REQUEST"
+	CodeUnauthenticated    Code = "UNAUTHENTICATED"
+	CodeForbidden          Code = "FORBIDDEN"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeConflict           Code = "CONFLICT"
+	CodePayloadTooLarge    Code = "PAYLOAD_TOO_LARGE"
+	CodeRateLimited        Code = "RATE_LIMITED"
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeUpstreamError      Code = "UPSTREAM_ERROR"
+	CodeServiceUnavailable Code = "SERVICE_UNAVAILABLE"
+	CodeDeadlineExceeded   Code = "DEADLINE_EXCEEDED"
+	CodeUnknown            Code = "ERROR"
+)
+
+// Request validation
+const (
+	CodeInvalidRequestBody Code = "INVALID_REQUEST_BODY"
+	CodeMissingParameter   Code = "MISSING_PARAMETER"
+	CodeInvalidParameter   Code = "INVALID_PARAMETER"
+	CodeInvalidID          Code = "INVALID_ID"
+	CodeInvalidDate        Code = "INVALID_DATE"
+	CodeInvalidDateRange   Code = "INVALID_DATE_RANGE"
+	CodeInvalidCursor      Code = "INVALID_CURSOR"
+	CodeInvalidHeader      Code = "INVALID_HEADER"
+	CodeFileRequired       Code = "FILE_REQUIRED"
+	CodeInvalidCSV         Code = "INVALID_CSV"
+	CodeValidationFailed   Code = "VALIDATION_FAILED"
+	CodeInvalidTickSize    Code = "INVALID_TICK_SIZE"
+	CodeInvalidOHLC        Code = "INVALID_OHLC"
+	CodeAnomalyRejected    Code = "ANOMALY_REJECTED"
+	CodeConfirmationFailed Code = "CONFIRMATION_INVALID"
+)
+
+// Authentication and authorization
+const (
+	CodeSessionInvalid          Code = "SESSION_INVALID"
+	CodeSessionExpired          Code = "SESSION_EXPIRED"
+	CodeLinkTokenInvalid        Code = "LINK_TOKEN_INVALID"
+	CodeAlreadyLinked           Code = "IDENTITY_ALREADY_LINKED"
+	CodeInsufficientPermissions Code = "INSUFFICIENT_PERMISSIONS"
+	CodeAuthUnavailable         Code = "AUTH_UNAVAILABLE"
+	CodeNotInOrganization       Code = "NOT_IN_ORGANIZATION"
+)
+
+// Missing resources
+const (
+	CodeMarketDataNotFound      Code = "MARKET_DATA_NOT_FOUND"
+	CodeSymbolNotFound          Code = "SYMBOL_NOT_FOUND"
+	CodeExchangeNotFound        Code = "EXCHANGE_NOT_FOUND"
+	CodeTickRulesNotFound       Code = "TICK_RULES_NOT_FOUND"
+	CodeHolidayNotFound         Code = "HOLIDAY_NOT_FOUND"
+	CodeQuoteNotFound           Code = "QUOTE_NOT_FOUND"
+	CodeSourceNotFound          Code = "SOURCE_NOT_FOUND"
+	CodePortfolioNotFound       Code = "PORTFOLIO_NOT_FOUND"
+	CodeHoldingNotFound         Code = "HOLDING_NOT_FOUND"
+	CodeFXRateNotFound          Code = "FX_RATE_NOT_FOUND"
+	CodeBondNotFound            Code = "BOND_NOT_FOUND"
+	CodeCouponNotFound          Code = "COUPON_NOT_FOUND"
+	CodeCorporateActionNotFound Code = "CORPORATE_ACTION_NOT_FOUND"
+	CodeDividendNotFound        Code = "DIVIDEND_NOT_FOUND"
+	CodeFundamentalsNotFound    Code = "FUNDAMENTALS_NOT_FOUND"
+	CodeFinancialsNotFound      Code = "FINANCIALS_NOT_FOUND"
+	CodeImportNotFound          Code = "IMPORT_NOT_FOUND"
+	CodeExportNotFound          Code = "EXPORT_NOT_FOUND"
+	CodeNoteNotFound            Code = "NOTE_NOT_FOUND"
+	CodeAttachmentNotFound      Code = "ATTACHMENT_NOT_FOUND"
+	CodeLinkNotFound            Code = "LINK_NOT_FOUND"
+	CodeOutboxMessageNotFound   Code = "OUTBOX_MESSAGE_NOT_FOUND"
+	CodeStrategyNotFound        Code = "STRATEGY_NOT_FOUND"
+	CodeWebhookNotFound         Code = "WEBHOOK_NOT_FOUND"
+	CodeDeliveryNotFound        Code = "WEBHOOK_DELIVERY_NOT_FOUND"
+	CodeReleaseNotFound         Code = "RELEASE_NOT_FOUND"
+)
+
+// Conflicts and limits
+const (
+	CodeDuplicateRow           Code = "DUPLICATE_ROW"
+	CodeImportInProgress       Code = "IMPORT_IN_PROGRESS"
+	CodePortfolioExists        Code = "PORTFOLIO_EXISTS"
+	CodeInsufficientHoldings   Code = "INSUFFICIENT_HOLDINGS"
+	CodeBeforeCorporateAction  Code = "BEFORE_CORPORATE_ACTION"
+	CodeSymbolInUse            Code = "SYMBOL_IN_USE"
+	CodeActionNotReviewable    Code = "CORPORATE_ACTION_NOT_REVIEWABLE"
+	CodeNoteReadOnly           Code = "NOTE_READ_ONLY"
+	CodeStrategyReadOnly       Code = "STRATEGY_READ_ONLY"
+	CodeExportNotReady         Code = "EXPORT_NOT_READY"
+	CodeExportQuotaExceeded    Code = "EXPORT_QUOTA_EXCEEDED"
+	CodeDownloadLinkInvalid    Code = "DOWNLOAD_LINK_INVALID"
+	CodeUpstreamRateLimited    Code = "UPSTREAM_RATE_LIMITED"
+	CodeUpstreamSymbolNotFound Code = "UPSTREAM_SYMBOL_NOT_FOUND"
+	CodeProviderNotConfigured  Code = "PROVIDER_NOT_CONFIGURED"
+	CodeRefetchPending         Code = "REFETCH_PENDING"
+	CodeArchivePending         Code = "ARCHIVE_PENDING"
+	CodeArchiveDisabled        Code = "ARCHIVE_DISABLED"
+	CodeWebhookLimit           Code = "WEBHOOK_LIMIT_REACHED"
+	CodeIngestBufferFull       Code = "INGEST_BUFFER_FULL"
+)
+
+// Response is the body of every error the API answers
+type Response struct {
+	Code      Code        `json:"code"`
+	Error     string      `json:"error"`
+	Message   string      `json:"message,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Respond writes resp with status, filling in the code for the status when resp has
+// none and the request ID the RequestID middleware assigned
+func Respond(c *gin.Context, status int, resp Response) {
+	if resp.Code == "" {
+		resp.Code = CodeForStatus(status)
+	}
+	if resp.Error == "" {
+		resp.Error = http.StatusText(status)
+	}
+	if resp.RequestID == "" {
+		resp.RequestID = c.GetString(RequestIDKey)
+	}
+	c.JSON(status, resp)
+}
+
+// Abort is Respond for middleware, stopping the handler chain
+func Abort(c *gin.Context, status int, resp Response) {
+	Respond(c, status, resp)
+	c.Abort()
+}
+
+// CodeForStatus is the generic code for an HTTP status
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusInternalServerError:
+		return CodeInternal
+	case http.StatusBadGateway:
+		return CodeUpstreamError
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeDeadlineExceeded
+	}
+	return CodeUnknown
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMiss is returned when nothing is cached under a key and field
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores serialized reads in groups. Every entry lives under a key and a
+// field within it, so all reads derived from the same data (e.g. one symbol) can
+// be dropped together when that data changes.
+type Cache interface {
+	Get(ctx context.Context, key, field string) ([]byte, error)
+	Set(ctx context.Context, key, field string, value []byte) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Pinger is a Cache whose backend can be checked for reachability
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Noop caches nothing; it is used when no cache backend is configured
+type Noop struct{}
+
+func (Noop) Get(ctx context.Context, key, field string) ([]byte, error) {
+	return nil, ErrMiss
+}
+
+func (Noop) Set(ctx context.Context, key, field string, value []byte) error {
+	return nil
+}
+
+func (Noop) Delete(ctx context.Context, keys ...string) error {
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package changelog holds the service's release notes, embedded in the binary so
+// they always match the version deployed.
+package changelog
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed releases.yaml
+var releasesYAML []byte
+
+// file is the layout of releases.yaml
+type file struct {
+	Releases []struct {
+		Version string `yaml:"version"`
+		Date    string `yaml:"date"` // YYYY-MM-DD
+		Title   string `yaml:"title"`
+		Changes []struct {
+			Kind     string   `yaml:"kind"`
+			Text     string   `yaml:"text"`
+			Endpoint string   `yaml:"endpoint"`
+			Tags     []string `yaml:"tags"`
+		} `yaml:"changes"`
+	} `yaml:"releases"`
+}
+
+// Load returns the embedded release notes, newest first
+func Load() ([]models.Release, error) {
+	return Parse(releasesYAML)
+}
+
+// Parse reads release notes in the releases.yaml layout, checking every release has
+// a unique version, a date and only known kinds of change
+func Parse(data []byte) ([]models.Release, error) {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid changelog: %w", err)
+	}
+
+	releases := make([]models.Release, 0, len(f.Releases))
+	versions := map[string]bool{}
+	for i, r := range f.Releases {
+		version := strings.TrimSpace(r.Version)
+		if version == "" {
+			return nil, fmt.Errorf("release %d: version is required", i)
+		}
+		if versions[version] {
+			return nil, fmt.Errorf("release %s is listed twice", version)
+		}
+		versions[version] = true
+
+		date, err := time.Parse("2006-01-02", r.Date)
+		if err != nil {
+			return nil, fmt.Errorf("release %s: date must be YYYY-MM-DD", version)
+		}
+		if len(r.Changes) == 0 {
+			return nil, fmt.Errorf("release %s: at least one change is required", version)
+		}
+
+		release := models.Release{
+			Version:    version,
+			ReleasedAt: date,
+			Title:      strings.TrimSpace(r.Title),
+			Changes:    make([]models.ReleaseChange, 0, len(r.Changes)),
+		}
+		for j, ch := range r.Changes {
+			if !models.ValidChangeKind(ch.Kind) {
+				return nil, fmt.Errorf("release %s change %d: unknown kind %q", version, j, ch.Kind)
+			}
+			if strings.TrimSpace(ch.Text) == "" {
+				return nil, fmt.Errorf("release %s change %d: text is required", version, j)
+			}
+			release.Changes = append(release.Changes, models.ReleaseChange{
+				Kind:     ch.Kind,
+				Text:     strings.TrimSpace(ch.Text),
+				Endpoint: ch.Endpoint,
+				Tags:     ch.Tags,
+			})
+		}
+		releases = append(releases, release)
+	}
+	if len(releases) == 0 {
+		return nil, errors.New("changelog lists no releases")
+	}
+
+	// Releases on one day keep the order they are listed in
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].ReleasedAt.After(releases[j].ReleasedAt)
+	})
+	return releases, nil
+}
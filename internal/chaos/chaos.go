@@ -0,0 +1,150 @@
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ControlPath is never faulted so injection can always be switched off again
+const ControlPath = "/api/v1/admin/chaos"
+
+// MaxLatency bounds the delay a single rule may inject
+const MaxLatency = 60 * time.Second
+
+// Rule injects faults into a share of the requests to one route
+type Rule struct {
+	Route       string  `json:"route"`            // route template, e.g. /api/v1/market-data/:symbol, or * for all
+	Method      string  `json:"method,omitempty"` // empty matches every method
+	Percent     float64 `json:"percent"`          // share of matching requests affected, 0-100
+	LatencyMs   int     `json:"latency_ms,omitempty"`
+	ErrorStatus int     `json:"error_status,omitempty"` // answer with this status instead of running the handler
+	DropDB      bool    `json:"drop_db,omitempty"`      // fail every database call the handler makes
+}
+
+// Validate reports the first problem with the rule
+func (r Rule) Validate() error {
+	switch {
+	case r.Route == "":
+		return errors.New("route is required")
+	case r.Percent < 0 || r.Percent > 100:
+		return errors.New("percent must be between 0 and 100")
+	case r.LatencyMs < 0 || time.Duration(r.LatencyMs)*time.Millisecond > MaxLatency:
+		return fmt.Errorf("latency_ms must be between 0 and %d", MaxLatency.Milliseconds())
+	case r.ErrorStatus != 0 && (r.ErrorStatus < 400 || r.ErrorStatus > 599):
+		return errors.New("error_status must be a 4xx or 5xx status")
+	case r.LatencyMs == 0 && r.ErrorStatus == 0 && !r.DropDB:
+		return errors.New("rule injects nothing; set latency_ms, error_status or drop_db")
+	}
+	return nil
+}
+
+func (r Rule) matches(method, route string) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	return r.Route == "*" || r.Route == route
+}
+
+// Injector holds the active rules and applies them to requests
+type Injector struct {
+	mu     sync.RWMutex
+	rules  []Rule
+	logger *zap.Logger
+}
+
+func NewInjector() *Injector {
+	return &Injector{
+		logger: logger.With(zap.String("component", "chaos")),
+	}
+}
+
+// Rules returns a copy of the active rules
+func (i *Injector) Rules() []Rule {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	rules := make([]Rule, len(i.rules))
+	copy(rules, i.rules)
+	return rules
+}
+
+// SetRules validates and replaces the active rules; an empty list disables injection
+func (i *Injector) SetRules(rules []Rule) error {
+	for n, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", n, err)
+		}
+	}
+
+	i.mu.Lock()
+	i.rules = append([]Rule(nil), rules...)
+	i.mu.Unlock()
+
+	i.logger.Warn("Fault injection rules replaced", zap.Int("rules", len(rules)))
+	return nil
+}
+
+// Middleware applies matching rules. Each rule rolls separately; latency is added
+// first, then an injected error ends the request, otherwise dropped database
+// connections are armed for the handler. Faults applied are listed in X-Chaos-Fault.
+func (i *Injector) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" || strings.HasPrefix(route, ControlPath) {
+			c.Next()
+			return
+		}
+
+		var latency time.Duration
+		var status int
+		var dropDB bool
+		for _, rule := range i.Rules() {
+			if !rule.matches(c.Request.Method, route) || rand.Float64()*100 >= rule.Percent {
+				continue
+			}
+			latency += time.Duration(rule.LatencyMs) * time.Millisecond
+			if status == 0 {
+				status = rule.ErrorStatus
+			}
+			dropDB = dropDB || rule.DropDB
+		}
+
+		var faults []string
+		if latency > 0 {
+			faults = append(faults, "latency")
+			select {
+			case <-time.After(latency):
+			case <-c.Request.Context().Done():
+			}
+		}
+
+		if status != 0 {
+			c.Header("X-Chaos-Fault", strings.Join(append(faults, "error"), ","))
+			apierror.Abort(c, status, apierror.Response{
+				Message: "injected fault",
+			})
+			return
+		}
+
+		if dropDB {
+			faults = append(faults, "drop_db")
+			c.Request = c.Request.WithContext(database.WithDroppedConnection(c.Request.Context()))
+		}
+		if len(faults) > 0 {
+			c.Header("X-Chaos-Fault", strings.Join(faults, ","))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,249 @@
+package alphavantage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/datasource"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSymbolNotFound = fmt.Errorf("%w on Alpha Vantage", datasource.ErrSymbolNotFound)
+	ErrRateLimited    = fmt.Errorf("%w by Alpha Vantage", datasource.ErrRateLimited)
+	ErrNotConfigured  = fmt.Errorf("%w: Alpha Vantage needs an API key", datasource.ErrNotConfigured)
+)
+
+const (
+	// defaultRequestsPerMinute is the free tier's allowance
+	defaultRequestsPerMinute = 5
+	// compactPoints is how many of the latest days outputsize=compact returns
+	compactPoints = 100
+)
+
+// Client calls the Alpha Vantage time series API, pacing requests to stay within
+// the key's per-minute allowance
+type Client struct {
+	baseURL     string
+	apiKey      string
+	httpClient  *http.Client
+	minInterval time.Duration
+
+	mu          sync.Mutex
+	lastRequest time.Time
+
+	logger *zap.Logger
+}
+
+// New creates a client for the API at baseURL, e.g. https://www.alphavantage.co.
+// Requests are spaced to at most requestsPerMinute (the free tier's 5 when not
+// positive); an empty apiKey leaves the client unconfigured.
+func New(baseURL, apiKey string, timeout time.Duration, requestsPerMinute int) *Client {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = defaultRequestsPerMinute
+	}
+	return &Client{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		apiKey:      apiKey,
+		httpClient:  &http.Client{Timeout: timeout},
+		minInterval: time.Minute / time.Duration(requestsPerMinute),
+		logger:      logger.With(zap.String("client", "alphavantage")),
+	}
+}
+
+// Name is the source fetched candles are stored under
+func (c *Client) Name() string {
+	return "alphavantage"
+}
+
+// DisplayName names Alpha Vantage in messages
+func (c *Client) DisplayName() string {
+	return "Alpha Vantage"
+}
+
+// Configured reports whether an API key is set
+func (c *Client) Configured() bool {
+	return c.apiKey != ""
+}
+
+// dailyResponse mirrors the parts of a TIME_SERIES_DAILY response we use. Failures
+// also answer 200, with one of the message fields set instead of the series.
+type dailyResponse struct {
+	Series       map[string]dailyBar `json:"Time Series (Daily)"`
+	ErrorMessage string              `json:"Error Message"`
+	Note         string              `json:"Note"`
+	Information  string              `json:"Information"`
+}
+
+type dailyBar struct {
+	Open   string `json:"1. open"`
+	High   string `json:"2. high"`
+	Low    string `json:"3. low"`
+	Close  string `json:"4. close"`
+	Volume string `json:"5. volume"`
+}
+
+// FetchDaily returns daily candles for symbol between start and end
+func (c *Client) FetchDaily(ctx context.Context, symbol string, start, end time.Time) ([]models.MarketData, error) {
+	if !c.Configured() {
+		return nil, ErrNotConfigured
+	}
+
+	// Compact covers the latest 100 trading days; older windows need the full history
+	outputSize := "compact"
+	if time.Since(start) > compactPoints*24*time.Hour {
+		outputSize = "full"
+	}
+	params := url.Values{}
+	params.Set("function", "TIME_SERIES_DAILY")
+	params.Set("symbol", symbol)
+	params.Set("outputsize", outputSize)
+	params.Set("apikey", c.apiKey)
+
+	body, err := c.get(ctx, fmt.Sprintf("%s/query?%s", c.baseURL, params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp dailyResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode time series response: %w", err)
+	}
+	switch {
+	case resp.ErrorMessage != "":
+		// Alpha Vantage answers an unknown symbol with an "invalid API call" message
+		if strings.Contains(strings.ToLower(resp.ErrorMessage), "invalid api call") {
+			return nil, ErrSymbolNotFound
+		}
+		return nil, fmt.Errorf("alpha vantage error: %s", resp.ErrorMessage)
+	case resp.Series == nil && (resp.Note != "" || resp.Information != ""):
+		// Both the per-minute and the daily allowance are reported this way
+		c.logger.Warn("Alpha Vantage refused the request",
+			zap.String("note", resp.Note+resp.Information),
+		)
+		return nil, ErrRateLimited
+	}
+
+	return candles(symbol, resp.Series, start, end)
+}
+
+// candles converts the series into market data dated between start and end, oldest first
+func candles(symbol string, series map[string]dailyBar, start, end time.Time) ([]models.MarketData, error) {
+	from := start.UTC().Truncate(24 * time.Hour)
+	data := make([]models.MarketData, 0, len(series))
+	for date, bar := range series {
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", date, err)
+		}
+		if day.Before(from) || day.After(end) {
+			continue
+		}
+
+		var prices [4]float64
+		for i, s := range []string{bar.Open, bar.High, bar.Low, bar.Close} {
+			if prices[i], err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, fmt.Errorf("%s: invalid price %q", date, s)
+			}
+		}
+		open := round4(prices[0])
+		md := models.MarketData{
+			Exchange:  models.ExchangeForSymbol(symbol),
+			Symbol:    symbol,
+			Interval:  models.IntervalDaily,
+			Date:      day,
+			Timestamp: day,
+			Open:      &open,
+			High:      round4(prices[1]),
+			Low:       round4(prices[2]),
+			Close:     round4(prices[3]),
+			Source:    "alphavantage",
+		}
+		if volume, err := strconv.ParseInt(bar.Volume, 10, 64); err == nil {
+			md.Volume = &volume
+		}
+		data = append(data, md)
+	}
+
+	sort.Slice(data, func(i, j int) bool { return data[i].Date.Before(data[j].Date) })
+	return data, nil
+}
+
+// get performs a GET once the pacing allows it
+func (c *Client) get(ctx context.Context, endpoint string) ([]byte, error) {
+	if err := c.pace(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// The URL carries the API key, so only the underlying cause is reported
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, ErrRateLimited
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("alpha vantage returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 50<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
+
+// pace spaces requests at least minInterval apart
+func (c *Client) pace(ctx context.Context) error {
+	c.mu.Lock()
+	wait := time.Until(c.lastRequest.Add(c.minInterval))
+	if wait < 0 {
+		wait = 0
+	}
+	c.lastRequest = time.Now().Add(wait)
+	c.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,257 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/datasource"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSymbolNotFound = fmt.Errorf("%w on Binance", datasource.ErrSymbolNotFound)
+	ErrRateLimited    = fmt.Errorf("%w by Binance", datasource.ErrRateLimited)
+)
+
+const (
+	defaultMaxRetries = 3
+	maxBackoff        = 30 * time.Second
+	// maxKlines is the most candles Binance returns per request
+	maxKlines = 1000
+	// codeInvalidSymbol is Binance's error code for an unknown trading pair
+	codeInvalidSymbol = -1121
+)
+
+// Client calls the Binance spot market data API, which needs no API key
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	logger     *zap.Logger
+}
+
+// New creates a client for the REST API below baseURL, e.g. https://api.binance.com/api/v3
+func New(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+		maxRetries: defaultMaxRetries,
+		logger:     logger.With(zap.String("client", "binance")),
+	}
+}
+
+// Name is the source fetched candles are stored under
+func (c *Client) Name() string {
+	return "binance"
+}
+
+// DisplayName names Binance in messages
+func (c *Client) DisplayName() string {
+	return "Binance"
+}
+
+// NormalizeSymbol writes a pair as BASE-QUOTE, the name it is stored under; BTCUSDT
+// becomes BTC-USDT
+func (c *Client) NormalizeSymbol(pair string) string {
+	return models.CanonicalSymbol(strings.ToUpper(pair))
+}
+
+// PairSymbol returns the Binance name of a pair written BASE-QUOTE or BASE/QUOTE,
+// e.g. BTC-USDT becomes BTCUSDT
+func PairSymbol(pair string) string {
+	return strings.NewReplacer("-", "", "/", "").Replace(strings.ToUpper(pair))
+}
+
+// FetchDaily returns UTC daily candles for pair between start and end
+func (c *Client) FetchDaily(ctx context.Context, pair string, start, end time.Time) ([]models.MarketData, error) {
+	return c.FetchInterval(ctx, pair, models.IntervalDaily, start, end)
+}
+
+// FetchInterval returns candles of interval (1m, 5m, 1h or 1d) for pair opening
+// between start and end, paging through Binance's 1000-kline limit. Candles keep the
+// BASE-QUOTE name so they sort next to other listings.
+func (c *Client) FetchInterval(ctx context.Context, pair, interval string, start, end time.Time) ([]models.MarketData, error) {
+	length := models.IntervalLength(interval)
+	if length == 0 {
+		return nil, fmt.Errorf("%w: %s", datasource.ErrIntervalNotSupported, interval)
+	}
+
+	symbol := models.CanonicalSymbol(strings.ReplaceAll(strings.ToUpper(pair), "/", "-"))
+	var data []models.MarketData
+	for from := start; !from.After(end); {
+		params := url.Values{}
+		params.Set("symbol", PairSymbol(symbol))
+		params.Set("interval", interval)
+		params.Set("startTime", strconv.FormatInt(from.UnixMilli(), 10))
+		params.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+		params.Set("limit", strconv.Itoa(maxKlines))
+
+		body, err := c.get(ctx, fmt.Sprintf("%s/klines?%s", c.baseURL, params.Encode()))
+		if err != nil {
+			return nil, err
+		}
+
+		var klines [][]json.RawMessage
+		if err := json.Unmarshal(body, &klines); err != nil {
+			return nil, fmt.Errorf("failed to decode klines response: %w", err)
+		}
+		for i, k := range klines {
+			md, err := candle(symbol, interval, length, k)
+			if err != nil {
+				return nil, fmt.Errorf("kline %d: %w", len(data)+i, err)
+			}
+			data = append(data, md)
+		}
+
+		// A short page is the last one
+		if len(klines) < maxKlines {
+			break
+		}
+		from = data[len(data)-1].Timestamp.Add(length)
+	}
+
+	return data, nil
+}
+
+// candle converts one kline: [open time, open, high, low, close, volume, ...]
+func candle(symbol, interval string, length time.Duration, k []json.RawMessage) (models.MarketData, error) {
+	if len(k) < 6 {
+		return models.MarketData{}, fmt.Errorf("expected at least 6 fields, got %d", len(k))
+	}
+
+	var openTime int64
+	if err := json.Unmarshal(k[0], &openTime); err != nil {
+		return models.MarketData{}, fmt.Errorf("open time: %w", err)
+	}
+
+	var prices [5]float64
+	for i := range prices {
+		var s string
+		if err := json.Unmarshal(k[i+1], &s); err != nil {
+			return models.MarketData{}, err
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return models.MarketData{}, err
+		}
+		prices[i] = v
+	}
+
+	ts := time.UnixMilli(openTime).UTC().Truncate(length)
+	open := round8(prices[0])
+	// Volume is counted in whole units of the base asset
+	volume := int64(math.Round(prices[4]))
+	return models.MarketData{
+		Exchange:  models.ExchangeCrypto,
+		Symbol:    symbol,
+		Interval:  interval,
+		Date:      ts.Truncate(24 * time.Hour),
+		Timestamp: ts,
+		Open:      &open,
+		High:      round8(prices[1]),
+		Low:       round8(prices[2]),
+		Close:     round8(prices[3]),
+		Volume:    &volume,
+		Source:    "binance",
+	}, nil
+}
+
+// apiError is the body Binance sends with 4xx responses
+type apiError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// get performs a GET, retrying on 429, 418 (temporary ban) and 5xx responses
+func (c *Client) get(ctx context.Context, endpoint string) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = fmt.Errorf("request failed: %w", err)
+			if err := c.backoff(ctx, attempt, ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		body, readErr := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			if readErr != nil {
+				return nil, fmt.Errorf("failed to read response: %w", readErr)
+			}
+			return body, nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot:
+			lastErr = ErrRateLimited
+		case resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("binance returned status %d", resp.StatusCode)
+		default:
+			var apiErr apiError
+			if json.Unmarshal(body, &apiErr) == nil && apiErr.Code == codeInvalidSymbol {
+				return nil, ErrSymbolNotFound
+			}
+			return nil, fmt.Errorf("binance returned status %d: %s", resp.StatusCode, apiErr.Msg)
+		}
+
+		c.logger.Warn("Retrying Binance request",
+			zap.Int("status", resp.StatusCode),
+			zap.Int("attempt", attempt+1),
+		)
+		if err := c.backoff(ctx, attempt, resp.Header.Get("Retry-After")); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+// backoff waits before the next attempt, honouring Retry-After when present
+func (c *Client) backoff(ctx context.Context, attempt int, retryAfter string) error {
+	if attempt >= c.maxRetries {
+		return nil
+	}
+
+	wait := time.Duration(math.Pow(2, float64(attempt))) * time.Second
+	if secs, err := strconv.Atoi(retryAfter); err == nil && secs > 0 {
+		wait = time.Duration(secs) * time.Second
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func round8(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
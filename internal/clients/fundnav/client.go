@@ -0,0 +1,123 @@
+package fundnav
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotConfigured = errors.New("fund NAV provider is not configured")
+	ErrFundNotFound  = errors.New("fund not found at the NAV provider")
+	ErrRateLimited   = errors.New("rate limited by the NAV provider")
+)
+
+// Source is the name NAVs fetched by this client are stored under
+const Source = "fundnav"
+
+// Client calls a fund data provider publishing daily NAVs as JSON at
+// GET {baseURL}/funds/{code}/nav?from=YYYY-MM-DD&to=YYYY-MM-DD
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// New creates a client for the provider below baseURL; an empty baseURL leaves it
+// unconfigured. apiKey, when set, is sent as a bearer token.
+func New(baseURL, apiKey string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: timeout},
+		logger:     logger.With(zap.String("client", "fundnav")),
+	}
+}
+
+// navResponse is the provider's reply: one value per publication day
+type navResponse struct {
+	NAV []struct {
+		Date string  `json:"date"`
+		NAV  float64 `json:"nav"`
+	} `json:"nav"`
+}
+
+// FetchDaily returns the NAVs a fund published between start and end
+func (c *Client) FetchDaily(ctx context.Context, code string, start, end time.Time) ([]models.NAV, error) {
+	if c.baseURL == "" {
+		return nil, ErrNotConfigured
+	}
+
+	symbol := strings.ToUpper(code)
+	params := url.Values{}
+	params.Set("from", start.Format("2006-01-02"))
+	params.Set("to", end.Format("2006-01-02"))
+	endpoint := fmt.Sprintf("%s/funds/%s/nav?%s", c.baseURL, url.PathEscape(symbol), params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrFundNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, ErrRateLimited
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("NAV provider returned status %d", resp.StatusCode)
+	}
+
+	var body navResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode NAV response: %w", err)
+	}
+
+	navs := make([]models.NAV, 0, len(body.NAV))
+	for i, p := range body.NAV {
+		date, err := time.Parse("2006-01-02", p.Date)
+		if err != nil {
+			return nil, fmt.Errorf("nav %d: invalid date %q", i, p.Date)
+		}
+		if p.NAV <= 0 {
+			c.logger.Warn("Skipping non-positive NAV",
+				zap.String("symbol", symbol),
+				zap.String("date", p.Date),
+			)
+			continue
+		}
+		navs = append(navs, models.NAV{
+			Symbol: symbol,
+			Date:   date,
+			NAV:    p.NAV,
+			Source: Source,
+		})
+	}
+
+	return navs, nil
+}
@@ -0,0 +1,129 @@
+package fxfeed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotConfigured = errors.New("fx rate provider is not configured")
+	ErrRateLimited   = errors.New("rate limited by the fx rate provider")
+)
+
+// Source is the name rates fetched by this client are stored under
+const Source = "fxfeed"
+
+// Client calls a provider publishing daily reference rates in the Frankfurter
+// format: GET {baseURL}/latest?base=USD&symbols=IDR,SGD answers
+// {"base":"USD","date":"2025-01-07","rates":{"IDR":16205.5,"SGD":1.3662}}
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// New creates a client for the provider below baseURL; an empty baseURL leaves it
+// unconfigured. apiKey, when set, is sent as a bearer token.
+func New(baseURL, apiKey string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: timeout},
+		logger:     logger.With(zap.String("client", "fxfeed")),
+	}
+}
+
+// Configured reports whether a provider is set
+func (c *Client) Configured() bool {
+	return c.baseURL != ""
+}
+
+// latestResponse is the provider's reply: the day's rate from base into each quote
+type latestResponse struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// FetchLatest returns the latest published rates from base into each of quotes
+func (c *Client) FetchLatest(ctx context.Context, base string, quotes []string) ([]models.FXRateInput, error) {
+	if c.baseURL == "" {
+		return nil, ErrNotConfigured
+	}
+
+	base = strings.ToUpper(base)
+	params := url.Values{}
+	params.Set("base", base)
+	params.Set("symbols", strings.ToUpper(strings.Join(quotes, ",")))
+	endpoint := fmt.Sprintf("%s/latest?%s", c.baseURL, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, ErrRateLimited
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("fx rate provider returned status %d", resp.StatusCode)
+	}
+
+	var body latestResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode fx rate response: %w", err)
+	}
+	if _, err := time.Parse("2006-01-02", body.Date); err != nil {
+		return nil, fmt.Errorf("fx rate response has invalid date %q", body.Date)
+	}
+	if body.Base != "" && !strings.EqualFold(body.Base, base) {
+		return nil, fmt.Errorf("fx rate response is for base %s, not %s", body.Base, base)
+	}
+
+	rates := make([]models.FXRateInput, 0, len(body.Rates))
+	for quote, rate := range body.Rates {
+		if rate <= 0 {
+			c.logger.Warn("Skipping non-positive fx rate",
+				zap.String("base", base),
+				zap.String("quote", quote),
+			)
+			continue
+		}
+		rates = append(rates, models.FXRateInput{
+			Base:   base,
+			Quote:  strings.ToUpper(quote),
+			Date:   body.Date,
+			Rate:   rate,
+			Source: Source,
+		})
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Quote < rates[j].Quote })
+	return rates, nil
+}
@@ -0,0 +1,228 @@
+package nats
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// defaultPort is used when the URL names none
+const defaultPort = "4222"
+
+// defaultTimeout bounds each exchange with the server when no timeout is given
+const defaultTimeout = 10 * time.Second
+
+// Client publishes outbox messages to a NATS server over its text protocol. Each
+// message goes to the subject prefix + topic with Nats-Msg-Id set to the outbox ID,
+// so a JetStream stream on those subjects drops redelivered copies. Connections run
+// in verbose mode: a message is accepted once the server answers +OK.
+type Client struct {
+	url     *url.URL
+	prefix  string
+	timeout time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// serverInfo is the part of the server's INFO message the client relies on
+type serverInfo struct {
+	Headers     bool `json:"headers"`
+	TLSRequired bool `json:"tls_required"`
+}
+
+// New creates a client for a nats:// or tls:// URL, which may carry user:password
+// or a token as its user info. It connects on the first publish.
+func New(rawURL, subjectPrefix string, timeout time.Duration) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL: %w", err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("invalid NATS URL: scheme must be nats or tls, got %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("invalid NATS URL: host is required")
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Client{url: u, prefix: subjectPrefix, timeout: timeout}, nil
+}
+
+// Name identifies the publisher in the outbox status
+func (c *Client) Name() string {
+	return "nats"
+}
+
+// Publish delivers msg, returning an error for anything the server did not accept.
+// The connection is dropped after any error and made again on the next publish.
+func (c *Client) Publish(ctx context.Context, msg models.OutboxMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.publish(ctx, msg, body); err != nil {
+		c.close()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Client) publish(ctx context.Context, msg models.OutboxMessage, body []byte) error {
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if err := c.conn.SetDeadline(c.deadline(ctx)); err != nil {
+		return err
+	}
+
+	headers := "NATS/1.0\r\n" +
+		"Nats-Msg-Id: " + strconv.FormatInt(msg.ID, 10) + "\r\n" +
+		"Outbox-Topic: " + msg.Topic + "\r\n" +
+		"Outbox-Key: " + msg.Key + "\r\n\r\n"
+	frame := fmt.Sprintf("HPUB %s %d %d\r\n%s%s\r\n", c.prefix+msg.Topic, len(headers), len(headers)+len(body), headers, body)
+	if _, err := c.conn.Write([]byte(frame)); err != nil {
+		return fmt.Errorf("failed to publish: %w", err)
+	}
+	return c.awaitOK()
+}
+
+// connect dials the server, upgrading to TLS when asked to, and authenticates
+func (c *Client) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.address())
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	if err := conn.SetDeadline(c.deadline(ctx)); err != nil {
+		conn.Close()
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	payload, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		return fmt.Errorf("unexpected greeting %q", line)
+	}
+	var info serverInfo
+	if err := json.Unmarshal([]byte(payload), &info); err != nil {
+		return fmt.Errorf("invalid server info: %w", err)
+	}
+	if !info.Headers {
+		return errors.New("server does not support message headers (NATS 2.2 or later is required)")
+	}
+
+	if info.TLSRequired || c.url.Scheme == "tls" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: c.url.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		c.conn, c.reader = tlsConn, bufio.NewReader(tlsConn)
+	}
+
+	options := map[string]any{
+		"verbose":  true,
+		"pedantic": false,
+		"headers":  true,
+		"lang":     "go",
+		"name":     "proto-trading-service",
+	}
+	if user := c.url.User; user != nil {
+		if pass, ok := user.Password(); ok {
+			options["user"], options["pass"] = user.Username(), pass
+		} else {
+			options["auth_token"] = user.Username()
+		}
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	if _, err := c.conn.Write([]byte("CONNECT " + string(connect) + "\r\n")); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	return c.awaitOK()
+}
+
+// awaitOK reads until the server acknowledges the last command, answering its pings
+// on the way
+func (c *Client) awaitOK() error {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "+OK":
+			return nil
+		case line == "PING":
+			if _, err := c.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server rejected message: %s", strings.Trim(strings.TrimPrefix(line, "-ERR"), " '"))
+		case line == "PONG", strings.HasPrefix(line, "INFO "):
+			// Cluster updates and keep-alives are not answers
+		default:
+			return fmt.Errorf("unexpected reply %q", line)
+		}
+	}
+}
+
+func (c *Client) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read reply: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// deadline bounds one exchange with the server by the timeout and ctx
+func (c *Client) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+func (c *Client) address() string {
+	port := c.url.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	return net.JoinHostPort(c.url.Hostname(), port)
+}
+
+func (c *Client) close() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn, c.reader = nil, nil
+	}
+}
@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// Client publishes outbox messages by POSTing each as JSON to one URL. Any 2xx
+// response accepts the message. With a secret, the body's HMAC-SHA256 is sent as
+// X-Signature-256: sha256=<hex>.
+type Client struct {
+	url    string
+	secret []byte
+	sender *Sender
+}
+
+// New creates a client posting to url
+func New(url, secret string, timeout time.Duration) *Client {
+	return &Client{
+		url:    url,
+		secret: []byte(secret),
+		sender: NewSender(timeout),
+	}
+}
+
+// Name identifies the publisher in the outbox status
+func (c *Client) Name() string {
+	return "webhook"
+}
+
+// Publish delivers msg, returning an error for anything the endpoint did not accept
+func (c *Client) Publish(ctx context.Context, msg models.OutboxMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	_, err = c.sender.Send(ctx, c.url, c.secret, map[string]string{
+		"X-Outbox-Id":    strconv.FormatInt(msg.ID, 10),
+		"X-Outbox-Topic": msg.Topic,
+	}, body)
+	return err
+}
+
+// ErrBlockedAddress is returned for a URL whose host is, or resolves to, an address
+// that is not publicly routable
+var ErrBlockedAddress = errors.New("address is not publicly routable")
+
+// Sender POSTs signed JSON bodies to a URL. It is shared by the outbox publisher and
+// the webhooks users register. Redirects are not followed; a 3xx is a failure.
+type Sender struct {
+	httpClient *http.Client
+}
+
+// NewSender creates a sender giving each request timeout to complete
+func NewSender(timeout time.Duration) *Sender {
+	return newSender(timeout, nil)
+}
+
+// NewPublicSender creates a sender that only connects to publicly routable
+// addresses. The check is made on the address actually dialed, so a host that
+// resolves differently at delivery than at registration is still refused. It does
+// not go through a proxy.
+func NewPublicSender(timeout time.Duration) *Sender {
+	return newSender(timeout, func(network, address string, _ syscall.RawConn) error {
+		addr, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+		}
+		if Blocked(addr.Addr()) {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, addr.Addr())
+		}
+		return nil
+	})
+}
+
+func newSender(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *Sender {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if control != nil {
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: control}).DialContext
+	}
+	return &Sender{httpClient: &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Blocked reports whether addr is one webhooks may not be sent to: loopback,
+// private, link-local, shared (carrier-grade NAT), multicast or unspecified
+func Blocked(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || sharedAddressSpace.Contains(addr) || thisNetwork.Contains(addr)
+}
+
+var (
+	sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+	thisNetwork        = netip.MustParsePrefix("0.0.0.0/8")
+)
+
+// CheckURL returns ErrBlockedAddress when rawURL's host is, or resolves to, an
+// address Blocked refuses. A host that does not resolve now is let through; the
+// public sender checks again when it connects.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if Blocked(addr) {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if Blocked(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrBlockedAddress, host, addr)
+		}
+	}
+	return nil
+}
+
+// Send POSTs body to url with headers, signed with secret when it is not empty. It
+// returns the response status, 0 when no response arrived, and an error for anything
+// but a 2xx. The response body is never read into the error.
+func (s *Sender) Send(ctx context.Context, url string, secret []byte, headers map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if len(secret) > 0 {
+		req.Header.Set("X-Signature-256", Sign(secret, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	// Drain so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Signature-256 value for body: sha256= and the hex HMAC-SHA256
+// of body under secret
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,122 @@
+package yahoo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// chartResponse mirrors the parts of the v8 chart API response we use
+type chartResponse struct {
+	Chart struct {
+		Result []chartResult `json:"result"`
+		Error  *chartError   `json:"error"`
+	} `json:"chart"`
+}
+
+type chartError struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+type chartResult struct {
+	Meta struct {
+		Symbol       string `json:"symbol"`
+		Currency     string `json:"currency"`
+		GMTOffset    int    `json:"gmtoffset"`
+		ExchangeName string `json:"exchangeName"`
+	} `json:"meta"`
+	Timestamp  []int64 `json:"timestamp"`
+	Indicators struct {
+		Quote []struct {
+			Open   []*float64 `json:"open"`
+			High   []*float64 `json:"high"`
+			Low    []*float64 `json:"low"`
+			Close  []*float64 `json:"close"`
+			Volume []*int64   `json:"volume"`
+		} `json:"quote"`
+		AdjClose []struct {
+			AdjClose []*float64 `json:"adjclose"`
+		} `json:"adjclose"`
+	} `json:"indicators"`
+}
+
+// candles converts the response into market data, skipping days Yahoo reports without prices
+func (r chartResponse) candles(symbol string) ([]models.MarketData, error) {
+	if r.Chart.Error != nil {
+		if strings.EqualFold(r.Chart.Error.Code, "Not Found") {
+			return nil, ErrSymbolNotFound
+		}
+		return nil, fmt.Errorf("yahoo chart error %s: %s", r.Chart.Error.Code, r.Chart.Error.Description)
+	}
+	if len(r.Chart.Result) == 0 {
+		return nil, ErrSymbolNotFound
+	}
+
+	result := r.Chart.Result[0]
+	if len(result.Timestamp) == 0 || len(result.Indicators.Quote) == 0 {
+		return []models.MarketData{}, nil
+	}
+
+	quote := result.Indicators.Quote[0]
+	loc := time.FixedZone(result.Meta.ExchangeName, result.Meta.GMTOffset)
+
+	data := make([]models.MarketData, 0, len(result.Timestamp))
+	for i, ts := range result.Timestamp {
+		open, high, low, close := at(quote.Open, i), at(quote.High, i), at(quote.Low, i), at(quote.Close, i)
+		if high == nil || low == nil || close == nil {
+			continue
+		}
+
+		// Yahoo leaves the open or volume out of some sessions; they are stored as null
+		if open != nil {
+			rounded := round2(*open)
+			open = &rounded
+		}
+		var volume *int64
+		if i < len(quote.Volume) {
+			volume = quote.Volume[i]
+		}
+		var adjClose *float64
+		if len(result.Indicators.AdjClose) > 0 {
+			if adj := at(result.Indicators.AdjClose[0].AdjClose, i); adj != nil {
+				rounded := round2(*adj)
+				adjClose = &rounded
+			}
+		}
+
+		local := time.Unix(ts, 0).In(loc)
+		day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+		data = append(data, models.MarketData{
+			Exchange:  models.ExchangeForSymbol(symbol),
+			Symbol:    symbol,
+			Interval:  models.IntervalDaily,
+			Date:      day,
+			Timestamp: day,
+			Open:      open,
+			High:      round2(*high),
+			Low:       round2(*low),
+			Close:     round2(*close),
+			AdjClose:  adjClose,
+			Volume:    volume,
+			Source:    "yahoo",
+		})
+	}
+
+	return data, nil
+}
+
+func at(values []*float64, i int) *float64 {
+	if i >= len(values) {
+		return nil
+	}
+	return values[i]
+}
+
+// round2 matches the DECIMAL(10, 2) storage and drops float noise such as 8549.999999
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,195 @@
+package yahoo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/datasource"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSymbolNotFound = fmt.Errorf("%w on Yahoo Finance", datasource.ErrSymbolNotFound)
+	ErrRateLimited    = fmt.Errorf("%w by Yahoo Finance", datasource.ErrRateLimited)
+)
+
+const (
+	// Yahoo rejects requests without a browser-like user agent
+	userAgent = "Mozilla/5.0 (compatible; proto-trading-service)"
+
+	defaultMaxRetries  = 3
+	defaultMinInterval = 500 * time.Millisecond
+	maxBackoff         = 30 * time.Second
+)
+
+// Client calls the Yahoo Finance chart API
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	maxRetries  int
+	minInterval time.Duration
+
+	mu          sync.Mutex
+	lastRequest time.Time
+
+	logger *zap.Logger
+}
+
+// New creates a client for the chart API below baseURL, e.g. https://query1.finance.yahoo.com/v8/finance
+func New(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		httpClient:  &http.Client{Timeout: timeout},
+		maxRetries:  defaultMaxRetries,
+		minInterval: defaultMinInterval,
+		logger:      logger.With(zap.String("client", "yahoo")),
+	}
+}
+
+// Name is the source fetched candles are stored under
+func (c *Client) Name() string {
+	return "yahoo"
+}
+
+// DisplayName names Yahoo Finance in messages
+func (c *Client) DisplayName() string {
+	return "Yahoo Finance"
+}
+
+// FetchDaily returns daily candles for symbol between start and end, dated in the exchange's timezone
+func (c *Client) FetchDaily(ctx context.Context, symbol string, start, end time.Time) ([]models.MarketData, error) {
+	params := url.Values{}
+	params.Set("period1", strconv.FormatInt(start.Unix(), 10))
+	params.Set("period2", strconv.FormatInt(end.Unix(), 10))
+	params.Set("interval", "1d")
+	params.Set("events", "history")
+
+	endpoint := fmt.Sprintf("%s/chart/%s?%s", c.baseURL, url.PathEscape(symbol), params.Encode())
+
+	body, err := c.get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp chartResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode chart response: %w", err)
+	}
+
+	return resp.candles(symbol)
+}
+
+// get performs a GET with client-side pacing and retries on 429 and 5xx responses
+func (c *Client) get(ctx context.Context, endpoint string) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if err := c.pace(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = fmt.Errorf("request failed: %w", err)
+			if err := c.backoff(ctx, attempt, ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		body, readErr := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			if readErr != nil {
+				return nil, fmt.Errorf("failed to read response: %w", readErr)
+			}
+			return body, nil
+		case resp.StatusCode == http.StatusNotFound:
+			return nil, ErrSymbolNotFound
+		case resp.StatusCode == http.StatusTooManyRequests:
+			lastErr = ErrRateLimited
+		case resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("yahoo returned status %d", resp.StatusCode)
+		default:
+			return nil, fmt.Errorf("yahoo returned status %d", resp.StatusCode)
+		}
+
+		c.logger.Warn("Retrying Yahoo request",
+			zap.Int("status", resp.StatusCode),
+			zap.Int("attempt", attempt+1),
+		)
+		if err := c.backoff(ctx, attempt, resp.Header.Get("Retry-After")); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+// pace spaces requests at least minInterval apart
+func (c *Client) pace(ctx context.Context) error {
+	c.mu.Lock()
+	wait := time.Until(c.lastRequest.Add(c.minInterval))
+	if wait < 0 {
+		wait = 0
+	}
+	c.lastRequest = time.Now().Add(wait)
+	c.mu.Unlock()
+
+	return sleep(ctx, wait)
+}
+
+// backoff waits before the next attempt, honouring Retry-After when present
+func (c *Client) backoff(ctx context.Context, attempt int, retryAfter string) error {
+	if attempt >= c.maxRetries {
+		return nil
+	}
+
+	wait := time.Duration(math.Pow(2, float64(attempt))) * time.Second
+	if secs, err := strconv.Atoi(retryAfter); err == nil && secs > 0 {
+		wait = time.Duration(secs) * time.Second
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+
+	return sleep(ctx, wait)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -35,17 +36,77 @@ type LoggerConfig struct {
 }
 
 type AppConfig struct {
-	Name             string
-	Version          string
-	YahooAPIBaseURL  string
-	YahooAPITimeout  time.Duration
-	DefaultDataLimit int
-	MaxDataLimit     int
-	CacheTTL         time.Duration
-	KratosPublicURL  string // Internal URL for service-to-service
-	KratosAdminURL   string
-	KratosBrowserURL string // External URL for browser redirects
-	FrontendURL      string // Frontend application URL
+	Name              string
+	Version           string
+	YahooAPIBaseURL   string
+	YahooAPITimeout   time.Duration
+	BinanceAPIBaseURL string
+	BinanceAPITimeout time.Duration
+	FundNAVAPIBaseURL string // fund data provider; NAV fetches are refused when empty
+	FundNAVAPIKey     string
+	FundNAVAPITimeout time.Duration
+	FXSourceURL       string // daily reference rate provider; scheduled fx fetches are off when empty
+	FXSourceAPIKey    string
+	FXSourceTimeout   time.Duration
+	FXFetchSchedule   string   // cron expression for fetching FX_PAIRS; off when empty
+	FXPairs           []string // pairs fetched, e.g. USD-IDR
+	DefaultDataLimit  int
+	MaxDataLimit      int
+	CacheTTL          time.Duration
+	RedisURL          string // Read cache backend; caching is off when empty
+	KratosPublicURL   string // Internal URL for service-to-service
+	KratosAdminURL    string
+	KratosBrowserURL  string // External URL for browser redirects
+	FrontendURL       string // Frontend application URL
+
+	KratosTimeout          time.Duration // Per session validation call
+	KratosBreakerThreshold int           // Consecutive Kratos failures that open the circuit breaker
+	KratosBreakerCooldown  time.Duration // How long the open breaker answers 503 before probing Kratos again
+
+	QuoteStepTimeout       time.Duration // Budget for each step of the quote fallback chain
+	QuoteIntradayMaxAge    time.Duration // Intraday quotes older than this are stale while the exchange is in session
+	BlockRestrictedExports bool          // Refuse exports containing sources that forbid redistribution
+	ExportDir              string        // Directory backing the export object store
+	ExportSigningKey       string        // HMAC key for signed export download URLs
+	ExportURLTTL           time.Duration // How long a finished export stays downloadable
+	ExportMaxRows          int           // Largest export a single job may produce
+	ExportDailyQuota       int           // Export jobs a user may start per 24 hours
+	OfflineSigningKey      string        // Base64 Ed25519 seed that signs offline bundles; random when empty
+	ImportLockTimeout      time.Duration // How long a bulk write waits for another import of the same symbol
+	MaxUploadSize          int64         // Largest CSV upload accepted, in bytes
+	MaxRangeRows           int           // Largest date-range read served synchronously
+	ChaosEnabled           bool          // Allow fault injection rules; refused in production
+	FetchSchedule          string        // Cron expression for refreshing watchlisted symbols; off when empty
+	FetchLookbackDays      int           // Days of candles each scheduled fetch requests
+	FetchMaxBackoff        time.Duration // Longest a failing symbol is skipped by scheduled fetches
+	FetchHeartbeatURL      string        // Pinged after each scheduled fetch that stores data; none when empty
+	AlphaVantageAPIBaseURL string        // Alpha Vantage API root
+	AlphaVantageAPIKey     string        // Alpha Vantage fetches are refused when empty
+	AlphaVantageAPITimeout time.Duration // Per request
+	AlphaVantageRateLimit  int           // Requests per minute the key allows; requests are paced to it
+	IngestCheckInterval    time.Duration // How often sources are checked against their ingest window; 0 disables
+	ArchiveAfterYears      int           // Candles dated before January 1st this many years ago are archived; 0 disables
+	ArchiveDir             string        // Directory backing the archive object store
+	ArchiveInterval        time.Duration // How often old candles are archived
+	IngestWALPath          string        // File buffering bulk ingests while the database is unreachable; off when empty
+	IngestWALMaxBytes      int64         // Largest the ingest buffer may grow before ingests are refused
+	IngestReplayInterval   time.Duration // How often buffered ingests are retried
+	ProvisioningDir        string        // Directory of YAML files applied to organizations at startup
+	RolePermissions        string        // Role to permission mapping, e.g. "admin=*;analyst=market_data:*"
+	OutboxWebhookURL       string        // Endpoint outbox messages are delivered to; the outbox is off when empty
+	OutboxWebhookSecret    string        // HMAC key signing webhook deliveries; unsigned when empty
+	OutboxWebhookTimeout   time.Duration // Per delivery
+	OutboxNATSURL          string        // NATS server outbox messages are published to instead of the webhook
+	OutboxNATSPrefix       string        // Prepended to each topic to form the NATS subject
+	OutboxNATSTimeout      time.Duration // Per delivery, including connecting
+	OutboxPollInterval     time.Duration // How often the relay looks for pending messages
+	OutboxMaxAttempts      int           // Failed deliveries before a message is dead-lettered
+	OutboxRetention        time.Duration // How long delivered messages are kept
+	WebhookTimeout         time.Duration // Per delivery to a user's webhook
+	WebhookMaxAttempts     int           // Failed deliveries to a user's webhook before it is given up on
+	ReadyGates             string        // Comma-separated readiness gates that fail /ready: db, cache, ingest, queue
+	ReadyMaxImportBacklog  time.Duration // Longest a queued import may wait before the ingest gate fails
+	ReadyMaxOutboxLag      time.Duration // Longest an outbox message may wait before the queue gate fails
 }
 
 type CORSConfig struct {
@@ -91,17 +152,77 @@ func Load() (*Config, error) {
 			Environment: viper.GetString("ENVIRONMENT"),
 		},
 		App: AppConfig{
-			Name:             "proto-trading-service",
-			Version:          viper.GetString("APP_VERSION"),
-			YahooAPIBaseURL:  viper.GetString("YAHOO_API_BASE_URL"),
-			YahooAPITimeout:  viper.GetDuration("YAHOO_API_TIMEOUT"),
-			DefaultDataLimit: viper.GetInt("DEFAULT_DATA_LIMIT"),
-			MaxDataLimit:     viper.GetInt("MAX_DATA_LIMIT"),
-			CacheTTL:         viper.GetDuration("CACHE_TTL"),
-			KratosPublicURL:  viper.GetString("KRATOS_PUBLIC_URL"),
-			KratosAdminURL:   viper.GetString("KRATOS_ADMIN_URL"),
-			KratosBrowserURL: viper.GetString("KRATOS_BROWSER_URL"),
-			FrontendURL:      viper.GetString("FRONTEND_URL"),
+			Name:              "proto-trading-service",
+			Version:           viper.GetString("APP_VERSION"),
+			YahooAPIBaseURL:   viper.GetString("YAHOO_API_BASE_URL"),
+			YahooAPITimeout:   viper.GetDuration("YAHOO_API_TIMEOUT"),
+			BinanceAPIBaseURL: viper.GetString("BINANCE_API_BASE_URL"),
+			BinanceAPITimeout: viper.GetDuration("BINANCE_API_TIMEOUT"),
+			FundNAVAPIBaseURL: viper.GetString("FUND_NAV_API_BASE_URL"),
+			FundNAVAPIKey:     viper.GetString("FUND_NAV_API_KEY"),
+			FundNAVAPITimeout: viper.GetDuration("FUND_NAV_API_TIMEOUT"),
+			FXSourceURL:       viper.GetString("FX_SOURCE_URL"),
+			FXSourceAPIKey:    viper.GetString("FX_SOURCE_API_KEY"),
+			FXSourceTimeout:   viper.GetDuration("FX_SOURCE_TIMEOUT"),
+			FXFetchSchedule:   viper.GetString("FX_FETCH_SCHEDULE"),
+			FXPairs:           listValue("FX_PAIRS"),
+			DefaultDataLimit:  viper.GetInt("DEFAULT_DATA_LIMIT"),
+			MaxDataLimit:      viper.GetInt("MAX_DATA_LIMIT"),
+			CacheTTL:          viper.GetDuration("CACHE_TTL"),
+			RedisURL:          viper.GetString("REDIS_URL"),
+			KratosPublicURL:   viper.GetString("KRATOS_PUBLIC_URL"),
+			KratosAdminURL:    viper.GetString("KRATOS_ADMIN_URL"),
+			KratosBrowserURL:  viper.GetString("KRATOS_BROWSER_URL"),
+			FrontendURL:       viper.GetString("FRONTEND_URL"),
+
+			KratosTimeout:          viper.GetDuration("KRATOS_TIMEOUT"),
+			KratosBreakerThreshold: viper.GetInt("KRATOS_BREAKER_THRESHOLD"),
+			KratosBreakerCooldown:  viper.GetDuration("KRATOS_BREAKER_COOLDOWN"),
+
+			QuoteStepTimeout:       viper.GetDuration("QUOTE_STEP_TIMEOUT"),
+			QuoteIntradayMaxAge:    viper.GetDuration("QUOTE_INTRADAY_MAX_AGE"),
+			BlockRestrictedExports: viper.GetBool("BLOCK_RESTRICTED_EXPORTS"),
+			ExportDir:              viper.GetString("EXPORT_DIR"),
+			ExportSigningKey:       viper.GetString("EXPORT_SIGNING_KEY"),
+			ExportURLTTL:           viper.GetDuration("EXPORT_URL_TTL"),
+			ExportMaxRows:          viper.GetInt("EXPORT_MAX_ROWS"),
+			ExportDailyQuota:       viper.GetInt("EXPORT_DAILY_QUOTA"),
+			OfflineSigningKey:      viper.GetString("OFFLINE_SIGNING_KEY"),
+			ImportLockTimeout:      viper.GetDuration("IMPORT_LOCK_TIMEOUT"),
+			MaxUploadSize:          int64(viper.GetSizeInBytes("MAX_UPLOAD_SIZE")),
+			MaxRangeRows:           viper.GetInt("MAX_RANGE_ROWS"),
+			ChaosEnabled:           viper.GetBool("CHAOS_ENABLED"),
+			FetchSchedule:          viper.GetString("FETCH_SCHEDULE"),
+			FetchLookbackDays:      viper.GetInt("FETCH_LOOKBACK_DAYS"),
+			FetchMaxBackoff:        viper.GetDuration("FETCH_MAX_BACKOFF"),
+			FetchHeartbeatURL:      viper.GetString("FETCH_HEARTBEAT_URL"),
+			AlphaVantageAPIBaseURL: viper.GetString("ALPHA_VANTAGE_API_BASE_URL"),
+			AlphaVantageAPIKey:     viper.GetString("ALPHA_VANTAGE_API_KEY"),
+			AlphaVantageAPITimeout: viper.GetDuration("ALPHA_VANTAGE_API_TIMEOUT"),
+			AlphaVantageRateLimit:  viper.GetInt("ALPHA_VANTAGE_RATE_LIMIT"),
+			IngestCheckInterval:    viper.GetDuration("INGEST_CHECK_INTERVAL"),
+			ArchiveAfterYears:      viper.GetInt("ARCHIVE_AFTER_YEARS"),
+			ArchiveDir:             viper.GetString("ARCHIVE_DIR"),
+			ArchiveInterval:        viper.GetDuration("ARCHIVE_INTERVAL"),
+			IngestWALPath:          viper.GetString("INGEST_WAL_PATH"),
+			IngestWALMaxBytes:      viper.GetInt64("INGEST_WAL_MAX_BYTES"),
+			IngestReplayInterval:   viper.GetDuration("INGEST_REPLAY_INTERVAL"),
+			ProvisioningDir:        viper.GetString("PROVISIONING_DIR"),
+			RolePermissions:        viper.GetString("RBAC_ROLE_PERMISSIONS"),
+			OutboxWebhookURL:       viper.GetString("OUTBOX_WEBHOOK_URL"),
+			OutboxWebhookSecret:    viper.GetString("OUTBOX_WEBHOOK_SECRET"),
+			OutboxWebhookTimeout:   viper.GetDuration("OUTBOX_WEBHOOK_TIMEOUT"),
+			OutboxNATSURL:          viper.GetString("OUTBOX_NATS_URL"),
+			OutboxNATSPrefix:       viper.GetString("OUTBOX_NATS_SUBJECT_PREFIX"),
+			OutboxNATSTimeout:      viper.GetDuration("OUTBOX_NATS_TIMEOUT"),
+			OutboxPollInterval:     viper.GetDuration("OUTBOX_POLL_INTERVAL"),
+			OutboxMaxAttempts:      viper.GetInt("OUTBOX_MAX_ATTEMPTS"),
+			OutboxRetention:        viper.GetDuration("OUTBOX_RETENTION"),
+			WebhookTimeout:         viper.GetDuration("WEBHOOK_TIMEOUT"),
+			WebhookMaxAttempts:     viper.GetInt("WEBHOOK_MAX_ATTEMPTS"),
+			ReadyGates:             viper.GetString("READY_GATES"),
+			ReadyMaxImportBacklog:  viper.GetDuration("READY_MAX_IMPORT_BACKLOG"),
+			ReadyMaxOutboxLag:      viper.GetDuration("READY_MAX_OUTBOX_LAG"),
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: viper.GetStringSlice("CORS_ORIGINS"),
@@ -112,6 +233,13 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
+// listValue reads a comma- or space-separated list
+func listValue(key string) []string {
+	return strings.FieldsFunc(viper.GetString(key), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
 func setDefaults() {
 	// Server defaults
 	viper.SetDefault("PORT", "8080")
@@ -134,15 +262,73 @@ func setDefaults() {
 	viper.SetDefault("APP_VERSION", "1.0.0")
 	viper.SetDefault("YAHOO_API_BASE_URL", "https://query1.finance.yahoo.com/v8/finance")
 	viper.SetDefault("YAHOO_API_TIMEOUT", 30*time.Second)
+	viper.SetDefault("BINANCE_API_BASE_URL", "https://api.binance.com/api/v3")
+	viper.SetDefault("BINANCE_API_TIMEOUT", 30*time.Second)
+	viper.SetDefault("ALPHA_VANTAGE_API_BASE_URL", "https://www.alphavantage.co")
+	viper.SetDefault("ALPHA_VANTAGE_API_KEY", "")
+	viper.SetDefault("ALPHA_VANTAGE_API_TIMEOUT", 30*time.Second)
+	viper.SetDefault("ALPHA_VANTAGE_RATE_LIMIT", 5)
+	viper.SetDefault("FUND_NAV_API_BASE_URL", "")
+	viper.SetDefault("FUND_NAV_API_KEY", "")
+	viper.SetDefault("FUND_NAV_API_TIMEOUT", 30*time.Second)
+	viper.SetDefault("FX_SOURCE_URL", "")
+	viper.SetDefault("FX_SOURCE_API_KEY", "")
+	viper.SetDefault("FX_SOURCE_TIMEOUT", 30*time.Second)
+	viper.SetDefault("FX_FETCH_SCHEDULE", "CRON_TZ=Asia/Jakarta 0 17 * * 1-5")
+	viper.SetDefault("FX_PAIRS", "USD-IDR")
 	viper.SetDefault("DEFAULT_DATA_LIMIT", 30)
 	viper.SetDefault("MAX_DATA_LIMIT", 1000)
 	viper.SetDefault("CACHE_TTL", 5*time.Minute)
+	viper.SetDefault("REDIS_URL", "")
+	viper.SetDefault("QUOTE_STEP_TIMEOUT", 2*time.Second)
+	viper.SetDefault("QUOTE_INTRADAY_MAX_AGE", 15*time.Minute)
+	viper.SetDefault("BLOCK_RESTRICTED_EXPORTS", false)
+	viper.SetDefault("EXPORT_DIR", "./exports")
+	viper.SetDefault("EXPORT_SIGNING_KEY", "")
+	viper.SetDefault("EXPORT_URL_TTL", 24*time.Hour)
+	viper.SetDefault("EXPORT_MAX_ROWS", 500000)
+	viper.SetDefault("EXPORT_DAILY_QUOTA", 10)
+	viper.SetDefault("OFFLINE_SIGNING_KEY", "")
+	viper.SetDefault("IMPORT_LOCK_TIMEOUT", 5*time.Second)
+	viper.SetDefault("MAX_UPLOAD_SIZE", "512MB")
+	viper.SetDefault("MAX_RANGE_ROWS", 10000)
+	viper.SetDefault("CHAOS_ENABLED", false)
+	viper.SetDefault("FETCH_SCHEDULE", "")
+	viper.SetDefault("FETCH_LOOKBACK_DAYS", 7)
+	viper.SetDefault("FETCH_MAX_BACKOFF", 24*time.Hour)
+	viper.SetDefault("FETCH_HEARTBEAT_URL", "")
+	viper.SetDefault("INGEST_CHECK_INTERVAL", 5*time.Minute)
+	viper.SetDefault("ARCHIVE_AFTER_YEARS", 0)
+	viper.SetDefault("ARCHIVE_DIR", "./archive")
+	viper.SetDefault("ARCHIVE_INTERVAL", 24*time.Hour)
+	viper.SetDefault("INGEST_WAL_PATH", "")
+	viper.SetDefault("INGEST_WAL_MAX_BYTES", 256<<20)
+	viper.SetDefault("INGEST_REPLAY_INTERVAL", 5*time.Second)
+	viper.SetDefault("PROVISIONING_DIR", "./provisioning")
+	viper.SetDefault("RBAC_ROLE_PERMISSIONS", "admin=*")
+	viper.SetDefault("OUTBOX_WEBHOOK_URL", "")
+	viper.SetDefault("OUTBOX_WEBHOOK_SECRET", "")
+	viper.SetDefault("OUTBOX_WEBHOOK_TIMEOUT", 10*time.Second)
+	viper.SetDefault("OUTBOX_NATS_URL", "")
+	viper.SetDefault("OUTBOX_NATS_SUBJECT_PREFIX", "trading.")
+	viper.SetDefault("OUTBOX_NATS_TIMEOUT", 10*time.Second)
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", time.Second)
+	viper.SetDefault("OUTBOX_MAX_ATTEMPTS", 20)
+	viper.SetDefault("OUTBOX_RETENTION", 7*24*time.Hour)
+	viper.SetDefault("WEBHOOK_TIMEOUT", 10*time.Second)
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 10)
+	viper.SetDefault("READY_GATES", "db")
+	viper.SetDefault("READY_MAX_IMPORT_BACKLOG", 10*time.Minute)
+	viper.SetDefault("READY_MAX_OUTBOX_LAG", 5*time.Minute)
 
 	// Kratos defaults - Internal vs External URLs
 	viper.SetDefault("KRATOS_PUBLIC_URL", "http://kratos:4433")     // Internal service-to-service
 	viper.SetDefault("KRATOS_ADMIN_URL", "http://kratos:4434")      // Internal service-to-service
 	viper.SetDefault("KRATOS_BROWSER_URL", "http://localhost:4433") // External browser access
 	viper.SetDefault("FRONTEND_URL", "http://localhost:8000")
+	viper.SetDefault("KRATOS_TIMEOUT", 3*time.Second)
+	viper.SetDefault("KRATOS_BREAKER_THRESHOLD", 5)
+	viper.SetDefault("KRATOS_BREAKER_COOLDOWN", 30*time.Second)
 
 	// CORS defaults
 	viper.SetDefault("CORS_ORIGINS", []string{
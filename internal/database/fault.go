@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrConnectionDropped is returned by every call made with a context from
+// WithDroppedConnection, standing in for a connection lost mid-request
+var ErrConnectionDropped = errors.New("database connection dropped (injected fault)")
+
+type droppedKey struct{}
+
+// WithDroppedConnection makes database calls using ctx fail as if the connection
+// had been lost; used by fault injection in non-production environments
+func WithDroppedConnection(ctx context.Context) context.Context {
+	return context.WithValue(ctx, droppedKey{}, true)
+}
+
+func connectionDropped(ctx context.Context) bool {
+	dropped, _ := ctx.Value(droppedKey{}).(bool)
+	return dropped
+}
+
+// errRow is a pgx.Row whose Scan reports err
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
+
+var _ pgx.Row = errRow{}
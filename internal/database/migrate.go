@@ -0,0 +1,240 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Migrations are NNN_name.up.sql files, each with a matching NNN_name.down.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock serializes runners started at the same time, e.g. by several replicas
+const migrationLock = `SELECT pg_advisory_xact_lock(hashtextextended('schema_migrations', 0))`
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState is a migration and when it was applied, if it has been
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// LoadMigrations returns the embedded migrations in version order
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		file := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: name must end in .up.sql or .down.sql", file)
+		}
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s: name must start with a version number", file)
+		}
+
+		body, err := migrationFiles.ReadFile(path.Join("migrations", file))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %03d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration in order, each in its own transaction,
+// and returns the ones applied
+func (db *DB) MigrateUp(ctx context.Context) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.ensureMigrationTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		ran := false
+		err := db.Transaction(ctx, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, migrationLock); err != nil {
+				return err
+			}
+			done, err := migrationApplied(ctx, tx, m.Version)
+			if err != nil || done {
+				return err
+			}
+
+			if _, err := tx.Exec(ctx, m.Up); err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+				m.Version, m.Name,
+			)
+			ran = err == nil
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, err)
+		}
+		if ran {
+			logger.Info("Applied migration", zap.Int("version", m.Version), zap.String("name", m.Name))
+			applied = append(applied, m)
+		}
+	}
+
+	return applied, nil
+}
+
+// MigrateDown reverts the latest steps applied migrations, newest first, and
+// returns the ones reverted
+func (db *DB) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.ensureMigrationTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		m := migrations[i]
+		ran := false
+		err := db.Transaction(ctx, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, migrationLock); err != nil {
+				return err
+			}
+			done, err := migrationApplied(ctx, tx, m.Version)
+			if err != nil || !done {
+				return err
+			}
+
+			if _, err := tx.Exec(ctx, m.Down); err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+			ran = err == nil
+			return err
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, err)
+		}
+		if ran {
+			logger.Info("Reverted migration", zap.Int("version", m.Version), zap.String("name", m.Name))
+			reverted = append(reverted, m)
+		}
+	}
+
+	return reverted, nil
+}
+
+// MigrationStatus lists every known migration with the time it was applied
+func (db *DB) MigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.ensureMigrationTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		appliedAt[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	states := make([]MigrationState, len(migrations))
+	for i, m := range migrations {
+		states[i] = MigrationState{Migration: m}
+		if at, ok := appliedAt[m.Version]; ok {
+			states[i].AppliedAt = &at
+		}
+	}
+	return states, nil
+}
+
+func (db *DB) ensureMigrationTable(ctx context.Context) error {
+	_, err := db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func migrationApplied(ctx context.Context, tx pgx.Tx, version int) (bool, error) {
+	var applied bool
+	err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version,
+	).Scan(&applied)
+	return applied, err
+}
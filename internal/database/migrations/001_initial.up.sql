@@ -13,4 +13,4 @@ CREATE TABLE IF NOT EXISTS market_data (
     UNIQUE(symbol, date, source)
 );
 
-CREATE INDEX idx_market_data_symbol_date ON market_data(symbol, date);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_market_data_symbol_date ON market_data(symbol, date);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_preferences;
+DROP FUNCTION IF EXISTS update_updated_at_column();
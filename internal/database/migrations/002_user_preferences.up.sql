@@ -18,10 +18,11 @@ BEGIN
 END;
 $$ language 'plpgsql';
 
+DROP TRIGGER IF EXISTS update_user_preferences_updated_at ON user_preferences;
 CREATE TRIGGER update_user_preferences_updated_at 
 BEFORE UPDATE ON user_preferences 
 FOR EACH ROW 
 EXECUTE FUNCTION update_updated_at_column();
 
 -- Index for faster lookups
-CREATE INDEX idx_user_preferences_email ON user_preferences(email);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_user_preferences_email ON user_preferences(email);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS archive_market_data_version ON market_data;
+DROP FUNCTION IF EXISTS archive_market_data_version();
+DROP TABLE IF EXISTS market_data_history;
+ALTER TABLE market_data DROP COLUMN IF EXISTS updated_at;
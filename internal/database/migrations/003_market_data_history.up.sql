@@ -0,0 +1,56 @@
+-- Track when the current version of a candle became valid
+ALTER TABLE market_data ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+-- Superseded candle versions (provider restatements and deletions)
+CREATE TABLE IF NOT EXISTS market_data_history (
+    id BIGSERIAL PRIMARY KEY,
+    market_data_id BIGINT NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    date DATE NOT NULL,
+    open DECIMAL(10, 2),
+    high DECIMAL(10, 2),
+    low DECIMAL(10, 2),
+    close DECIMAL(10, 2),
+    volume BIGINT,
+    source VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP,
+    valid_from TIMESTAMP NOT NULL,
+    valid_to TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_market_data_history_symbol_date ON market_data_history(symbol, date);
+CREATE INDEX IF NOT EXISTS idx_market_data_history_validity ON market_data_history(valid_from, valid_to);
+
+-- Archive the previous version whenever a candle is restated or deleted
+CREATE OR REPLACE FUNCTION archive_market_data_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.open, NEW.high, NEW.low, NEW.close, NEW.volume)
+        IS NOT DISTINCT FROM (OLD.open, OLD.high, OLD.low, OLD.close, OLD.volume) THEN
+        NEW.updated_at = OLD.updated_at;
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO market_data_history (
+        market_data_id, symbol, date, open, high, low, close, volume, source,
+        created_at, valid_from, valid_to
+    ) VALUES (
+        OLD.id, OLD.symbol, OLD.date, OLD.open, OLD.high, OLD.low, OLD.close, OLD.volume, OLD.source,
+        OLD.created_at, COALESCE(OLD.updated_at, OLD.created_at), CURRENT_TIMESTAMP
+    );
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS archive_market_data_version ON market_data;
+
+CREATE TRIGGER archive_market_data_version
+BEFORE UPDATE OR DELETE ON market_data
+FOR EACH ROW
+EXECUTE FUNCTION archive_market_data_version();
@@ -0,0 +1,7 @@
+-- Per-user commission, levy, tax and slippage assumptions
+CREATE TABLE IF NOT EXISTS user_fee_settings (
+    user_id VARCHAR(255) PRIMARY KEY,  -- Kratos identity ID
+    model JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,23 @@
+-- Market data providers with attribution and licensing terms
+CREATE TABLE IF NOT EXISTS sources (
+    name VARCHAR(50) PRIMARY KEY,
+    display_name VARCHAR(100) NOT NULL,
+    attribution TEXT NOT NULL DEFAULT '',
+    license TEXT NOT NULL DEFAULT '',
+    license_url TEXT NOT NULL DEFAULT '',
+    redistribution_allowed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO sources (name, display_name, attribution, license, license_url, redistribution_allowed) VALUES
+    ('yahoo', 'Yahoo Finance', 'Data provided by Yahoo Finance', 'Personal, non-commercial use only', 'https://legal.yahoo.com/us/en/yahoo/terms/otos/index.html', FALSE),
+    ('mirae', 'Mirae Asset Sekuritas', 'Data exported from Mirae Asset Sekuritas', 'Account holder use only', '', FALSE),
+    ('manual', 'Manual entry', 'Entered manually by users', '', '', TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+DROP TRIGGER IF EXISTS update_sources_updated_at ON sources;
+CREATE TRIGGER update_sources_updated_at
+BEFORE UPDATE ON sources
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS account_link_tokens;
+DROP TABLE IF EXISTS user_links;
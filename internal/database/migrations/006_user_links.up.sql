@@ -0,0 +1,18 @@
+-- Secondary Kratos identities resolved to one canonical profile
+CREATE TABLE IF NOT EXISTS user_links (
+    linked_user_id VARCHAR(255) PRIMARY KEY,      -- Kratos identity ID that was linked
+    canonical_user_id VARCHAR(255) NOT NULL,      -- Profile the identity resolves to
+    linked_email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (linked_user_id <> canonical_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_links_canonical ON user_links(canonical_user_id);
+
+-- Short-lived tokens proving control of the canonical identity during linking
+CREATE TABLE IF NOT EXISTS account_link_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
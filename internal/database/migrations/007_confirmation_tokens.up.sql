@@ -0,0 +1,10 @@
+-- Short-lived tokens confirming destructive admin operations
+CREATE TABLE IF NOT EXISTS confirmation_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,                  -- e.g. delete_market_data
+    target VARCHAR(255) NOT NULL,                 -- What the action applies to, e.g. the symbol
+    rows_affected BIGINT NOT NULL DEFAULT 0,      -- Impact reported when the token was issued
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
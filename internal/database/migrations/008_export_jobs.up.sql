@@ -0,0 +1,20 @@
+-- Asynchronous market data export jobs
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    symbols TEXT[] NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    format VARCHAR(10) NOT NULL,                  -- csv or json
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed, expired
+    row_count BIGINT NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    object_key VARCHAR(255) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_user ON export_jobs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_export_jobs_status ON export_jobs(status);
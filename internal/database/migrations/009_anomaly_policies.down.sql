@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS market_data_anomalies;
+
+ALTER TABLE sources DROP CONSTRAINT IF EXISTS sources_anomaly_policy_check;
+ALTER TABLE sources DROP COLUMN IF EXISTS anomaly_policy;
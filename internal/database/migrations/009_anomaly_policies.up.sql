@@ -0,0 +1,23 @@
+-- Per-source handling of ingestion anomalies
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS anomaly_policy VARCHAR(20) NOT NULL DEFAULT 'accept_with_flag';
+
+ALTER TABLE sources DROP CONSTRAINT IF EXISTS sources_anomaly_policy_check;
+ALTER TABLE sources ADD CONSTRAINT sources_anomaly_policy_check
+    CHECK (anomaly_policy IN ('reject', 'quarantine', 'accept_with_flag', 'auto_correct'));
+
+-- Candles that tripped an anomaly check, with the action taken
+CREATE TABLE IF NOT EXISTS market_data_anomalies (
+    id BIGSERIAL PRIMARY KEY,
+    symbol VARCHAR(20) NOT NULL,
+    date DATE NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    kinds TEXT[] NOT NULL,
+    details TEXT[] NOT NULL,
+    policy VARCHAR(20) NOT NULL,
+    action VARCHAR(20) NOT NULL,                  -- rejected, quarantined, flagged, corrected
+    raw JSONB NOT NULL,                           -- Candle as received, before correction
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_market_data_anomalies_symbol ON market_data_anomalies(symbol, date);
+CREATE INDEX IF NOT EXISTS idx_market_data_anomalies_action ON market_data_anomalies(action, created_at DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE user_preferences DROP CONSTRAINT IF EXISTS user_preferences_default_window_days_check;
+ALTER TABLE user_preferences DROP CONSTRAINT IF EXISTS user_preferences_default_limit_check;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS default_window_days;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS default_limit;
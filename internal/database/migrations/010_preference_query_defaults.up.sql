@@ -0,0 +1,11 @@
+-- Per-user defaults applied to market data reads
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS default_limit INTEGER NOT NULL DEFAULT 30;
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS default_window_days INTEGER NOT NULL DEFAULT 30;
+
+ALTER TABLE user_preferences DROP CONSTRAINT IF EXISTS user_preferences_default_limit_check;
+ALTER TABLE user_preferences ADD CONSTRAINT user_preferences_default_limit_check
+    CHECK (default_limit BETWEEN 1 AND 1000);
+
+ALTER TABLE user_preferences DROP CONSTRAINT IF EXISTS user_preferences_default_window_days_check;
+ALTER TABLE user_preferences ADD CONSTRAINT user_preferences_default_window_days_check
+    CHECK (default_window_days BETWEEN 1 AND 3650);
@@ -0,0 +1,3 @@
+ALTER TABLE export_jobs DROP COLUMN IF EXISTS source;
+ALTER TABLE user_preferences ALTER COLUMN default_source SET DEFAULT 'yahoo';
+ALTER TABLE sources DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,12 @@
+-- Source priority used when merging overlapping sources (source=any); lower wins
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 100;
+
+UPDATE sources SET priority = 10 WHERE name = 'manual' AND priority = 100;
+UPDATE sources SET priority = 20 WHERE name = 'mirae' AND priority = 100;
+UPDATE sources SET priority = 30 WHERE name = 'yahoo' AND priority = 100;
+
+-- New users read the merged series unless they pick a source
+ALTER TABLE user_preferences ALTER COLUMN default_source SET DEFAULT 'any';
+
+-- Source an export job reads from
+ALTER TABLE export_jobs ADD COLUMN IF NOT EXISTS source VARCHAR(50) NOT NULL DEFAULT 'any';
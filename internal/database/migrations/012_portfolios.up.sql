@@ -0,0 +1,34 @@
+-- User portfolios and their holdings
+CREATE TABLE IF NOT EXISTS portfolios (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS portfolio_holdings (
+    id BIGSERIAL PRIMARY KEY,
+    portfolio_id BIGINT NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    quantity DECIMAL(18, 4) NOT NULL CHECK (quantity > 0),
+    avg_price DECIMAL(12, 4) NOT NULL CHECK (avg_price >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(portfolio_id, symbol)
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolios_user ON portfolios(user_id);
+
+DROP TRIGGER IF EXISTS update_portfolios_updated_at ON portfolios;
+CREATE TRIGGER update_portfolios_updated_at
+BEFORE UPDATE ON portfolios
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_portfolio_holdings_updated_at ON portfolio_holdings;
+CREATE TRIGGER update_portfolio_holdings_updated_at
+BEFORE UPDATE ON portfolio_holdings
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,43 @@
+-- Only daily candles fit the old (symbol, date, source) key
+ALTER TABLE market_data DISABLE TRIGGER archive_market_data_version;
+DELETE FROM market_data WHERE interval <> '1d';
+ALTER TABLE market_data ENABLE TRIGGER archive_market_data_version;
+DELETE FROM market_data_history WHERE interval <> '1d';
+
+CREATE OR REPLACE FUNCTION archive_market_data_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.open, NEW.high, NEW.low, NEW.close, NEW.volume)
+        IS NOT DISTINCT FROM (OLD.open, OLD.high, OLD.low, OLD.close, OLD.volume) THEN
+        NEW.updated_at = OLD.updated_at;
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO market_data_history (
+        market_data_id, symbol, date, open, high, low, close, volume, source,
+        created_at, valid_from, valid_to
+    ) VALUES (
+        OLD.id, OLD.symbol, OLD.date, OLD.open, OLD.high, OLD.low, OLD.close, OLD.volume, OLD.source,
+        OLD.created_at, COALESCE(OLD.updated_at, OLD.created_at), CURRENT_TIMESTAMP
+    );
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+ALTER TABLE market_data_history DROP COLUMN IF EXISTS ts;
+ALTER TABLE market_data_history DROP COLUMN IF EXISTS interval;
+
+DROP INDEX IF EXISTS idx_market_data_symbol_interval_date;
+CREATE INDEX IF NOT EXISTS idx_market_data_symbol_date ON market_data(symbol, date);
+
+ALTER TABLE market_data DROP CONSTRAINT IF EXISTS market_data_symbol_interval_ts_source_key;
+ALTER TABLE market_data ADD CONSTRAINT market_data_symbol_date_source_key UNIQUE (symbol, date, source);
+
+ALTER TABLE market_data DROP COLUMN IF EXISTS ts;
+ALTER TABLE market_data DROP COLUMN IF EXISTS interval;
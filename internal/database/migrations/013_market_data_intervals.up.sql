@@ -0,0 +1,45 @@
+-- Candles of any interval: ts is when the candle opens and date stays its trading day
+ALTER TABLE market_data ADD COLUMN IF NOT EXISTS interval VARCHAR(3) NOT NULL DEFAULT '1d';
+ALTER TABLE market_data ADD COLUMN IF NOT EXISTS ts TIMESTAMP;
+UPDATE market_data SET ts = date::timestamp WHERE ts IS NULL;
+ALTER TABLE market_data ALTER COLUMN ts SET NOT NULL;
+
+ALTER TABLE market_data DROP CONSTRAINT IF EXISTS market_data_symbol_date_source_key;
+ALTER TABLE market_data DROP CONSTRAINT IF EXISTS market_data_symbol_interval_ts_source_key;
+ALTER TABLE market_data ADD CONSTRAINT market_data_symbol_interval_ts_source_key
+    UNIQUE (symbol, interval, ts, source);
+
+DROP INDEX IF EXISTS idx_market_data_symbol_date;
+CREATE INDEX IF NOT EXISTS idx_market_data_symbol_interval_date ON market_data(symbol, interval, date);
+
+ALTER TABLE market_data_history ADD COLUMN IF NOT EXISTS interval VARCHAR(3) NOT NULL DEFAULT '1d';
+ALTER TABLE market_data_history ADD COLUMN IF NOT EXISTS ts TIMESTAMP;
+UPDATE market_data_history SET ts = date::timestamp WHERE ts IS NULL;
+ALTER TABLE market_data_history ALTER COLUMN ts SET NOT NULL;
+
+-- Archive the interval and timestamp along with each superseded version
+CREATE OR REPLACE FUNCTION archive_market_data_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.open, NEW.high, NEW.low, NEW.close, NEW.volume)
+        IS NOT DISTINCT FROM (OLD.open, OLD.high, OLD.low, OLD.close, OLD.volume) THEN
+        NEW.updated_at = OLD.updated_at;
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO market_data_history (
+        market_data_id, symbol, interval, date, ts, open, high, low, close, volume, source,
+        created_at, valid_from, valid_to
+    ) VALUES (
+        OLD.id, OLD.symbol, OLD.interval, OLD.date, OLD.ts, OLD.open, OLD.high, OLD.low, OLD.close,
+        OLD.volume, OLD.source, OLD.created_at, COALESCE(OLD.updated_at, OLD.created_at), CURRENT_TIMESTAMP
+    );
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
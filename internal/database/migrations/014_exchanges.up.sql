@@ -0,0 +1,98 @@
+-- Exchanges with their regular session and trading week
+CREATE TABLE IF NOT EXISTS exchanges (
+    code VARCHAR(10) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    timezone VARCHAR(64) NOT NULL,             -- IANA zone, e.g. Asia/Jakarta
+    opens_at TIME NOT NULL,                    -- local session open
+    closes_at TIME NOT NULL,                   -- local session close
+    trading_days INTEGER[] NOT NULL DEFAULT '{1,2,3,4,5}', -- ISO weekdays, 1 = Monday
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO exchanges (code, name, timezone, opens_at, closes_at) VALUES
+    ('IDX', 'Indonesia Stock Exchange', 'Asia/Jakarta', '09:00', '16:00'),
+    ('US', 'US equities (NYSE, Nasdaq)', 'America/New_York', '09:30', '16:00')
+ON CONFLICT (code) DO NOTHING;
+
+-- Full-day closures outside the regular trading week
+CREATE TABLE IF NOT EXISTS exchange_holidays (
+    exchange VARCHAR(10) NOT NULL REFERENCES exchanges(code) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    PRIMARY KEY (exchange, date)
+);
+
+-- Every candle belongs to a listing on one exchange; existing symbols are inferred
+-- from their Yahoo suffix
+ALTER TABLE market_data ADD COLUMN IF NOT EXISTS exchange VARCHAR(10);
+UPDATE market_data SET exchange = CASE WHEN symbol LIKE '%.JK' THEN 'IDX' ELSE 'US' END
+WHERE exchange IS NULL;
+ALTER TABLE market_data ALTER COLUMN exchange SET NOT NULL;
+
+ALTER TABLE market_data DROP CONSTRAINT IF EXISTS market_data_symbol_interval_ts_source_key;
+ALTER TABLE market_data DROP CONSTRAINT IF EXISTS market_data_exchange_symbol_interval_ts_source_key;
+ALTER TABLE market_data ADD CONSTRAINT market_data_exchange_symbol_interval_ts_source_key
+    UNIQUE (exchange, symbol, interval, ts, source);
+
+ALTER TABLE market_data_history ADD COLUMN IF NOT EXISTS exchange VARCHAR(10);
+UPDATE market_data_history SET exchange = CASE WHEN symbol LIKE '%.JK' THEN 'IDX' ELSE 'US' END
+WHERE exchange IS NULL;
+ALTER TABLE market_data_history ALTER COLUMN exchange SET NOT NULL;
+
+-- Listings seen in market data, kept up to date by the trigger below
+CREATE TABLE IF NOT EXISTS symbols (
+    exchange VARCHAR(10) NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (exchange, symbol)
+);
+
+INSERT INTO symbols (exchange, symbol)
+SELECT DISTINCT exchange, symbol FROM market_data
+ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION register_market_data_symbols()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO symbols (exchange, symbol)
+    SELECT DISTINCT exchange, symbol FROM inserted
+    ON CONFLICT DO NOTHING;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS register_market_data_symbols ON market_data;
+
+CREATE TRIGGER register_market_data_symbols
+AFTER INSERT ON market_data
+REFERENCING NEW TABLE AS inserted
+FOR EACH STATEMENT
+EXECUTE FUNCTION register_market_data_symbols();
+
+-- Archive the exchange along with each superseded version
+CREATE OR REPLACE FUNCTION archive_market_data_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.open, NEW.high, NEW.low, NEW.close, NEW.volume)
+        IS NOT DISTINCT FROM (OLD.open, OLD.high, OLD.low, OLD.close, OLD.volume) THEN
+        NEW.updated_at = OLD.updated_at;
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO market_data_history (
+        market_data_id, exchange, symbol, interval, date, ts, open, high, low, close, volume, source,
+        created_at, valid_from, valid_to
+    ) VALUES (
+        OLD.id, OLD.exchange, OLD.symbol, OLD.interval, OLD.date, OLD.ts, OLD.open, OLD.high, OLD.low,
+        OLD.close, OLD.volume, OLD.source, OLD.created_at, COALESCE(OLD.updated_at, OLD.created_at),
+        CURRENT_TIMESTAMP
+    );
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
@@ -0,0 +1,21 @@
+ALTER TABLE market_data DISABLE TRIGGER archive_market_data_version;
+DELETE FROM market_data WHERE exchange = 'CRYPTO' OR source = 'binance';
+ALTER TABLE market_data ENABLE TRIGGER archive_market_data_version;
+DELETE FROM market_data_history WHERE exchange = 'CRYPTO' OR source = 'binance';
+DELETE FROM symbols WHERE exchange = 'CRYPTO';
+
+DELETE FROM sources WHERE name = 'binance';
+DELETE FROM exchanges WHERE code = 'CRYPTO';
+ALTER TABLE exchanges DROP COLUMN IF EXISTS asset_class;
+
+ALTER TABLE market_data_history
+    ALTER COLUMN open TYPE DECIMAL(10, 2),
+    ALTER COLUMN high TYPE DECIMAL(10, 2),
+    ALTER COLUMN low TYPE DECIMAL(10, 2),
+    ALTER COLUMN close TYPE DECIMAL(10, 2);
+
+ALTER TABLE market_data
+    ALTER COLUMN open TYPE DECIMAL(10, 2),
+    ALTER COLUMN high TYPE DECIMAL(10, 2),
+    ALTER COLUMN low TYPE DECIMAL(10, 2),
+    ALTER COLUMN close TYPE DECIMAL(10, 2);
@@ -0,0 +1,24 @@
+-- Crypto pairs trade at prices far below a cent; keep eight decimals
+ALTER TABLE market_data
+    ALTER COLUMN open TYPE NUMERIC(24, 8),
+    ALTER COLUMN high TYPE NUMERIC(24, 8),
+    ALTER COLUMN low TYPE NUMERIC(24, 8),
+    ALTER COLUMN close TYPE NUMERIC(24, 8);
+
+ALTER TABLE market_data_history
+    ALTER COLUMN open TYPE NUMERIC(24, 8),
+    ALTER COLUMN high TYPE NUMERIC(24, 8),
+    ALTER COLUMN low TYPE NUMERIC(24, 8),
+    ALTER COLUMN close TYPE NUMERIC(24, 8);
+
+-- What an exchange lists, so equities and crypto can be told apart
+ALTER TABLE exchanges ADD COLUMN IF NOT EXISTS asset_class VARCHAR(20) NOT NULL DEFAULT 'equity';
+
+-- Crypto trades around the clock: a session from midnight to midnight UTC, every day
+INSERT INTO exchanges (code, name, timezone, opens_at, closes_at, trading_days, asset_class) VALUES
+    ('CRYPTO', 'Cryptocurrency (24/7)', 'UTC', '00:00', '00:00', '{1,2,3,4,5,6,7}', 'crypto')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO sources (name, display_name, attribution, license, license_url, redistribution_allowed, priority) VALUES
+    ('binance', 'Binance', 'Data provided by Binance', 'Binance API terms of use', 'https://www.binance.com/en/terms', FALSE, 40)
+ON CONFLICT (name) DO NOTHING;
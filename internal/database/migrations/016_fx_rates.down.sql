@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_fx_rates_updated_at ON fx_rates;
+DROP TABLE IF EXISTS fx_rates;
@@ -0,0 +1,17 @@
+-- Daily FX rates: 1 unit of base buys rate units of quote
+CREATE TABLE IF NOT EXISTS fx_rates (
+    base CHAR(3) NOT NULL,
+    quote CHAR(3) NOT NULL,
+    date DATE NOT NULL,
+    rate NUMERIC(24, 10) NOT NULL CHECK (rate > 0),
+    source VARCHAR(50) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base, quote, date)
+);
+
+DROP TRIGGER IF EXISTS update_fx_rates_updated_at ON fx_rates;
+CREATE TRIGGER update_fx_rates_updated_at
+BEFORE UPDATE ON fx_rates
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,22 @@
+CREATE OR REPLACE FUNCTION register_market_data_symbols()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO symbols (exchange, symbol)
+    SELECT DISTINCT exchange, symbol FROM inserted
+    ON CONFLICT DO NOTHING;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS update_symbols_updated_at ON symbols;
+DROP INDEX IF EXISTS idx_symbols_name;
+DROP INDEX IF EXISTS idx_symbols_symbol;
+
+ALTER TABLE symbols DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE symbols DROP COLUMN IF EXISTS lot_size;
+ALTER TABLE symbols DROP COLUMN IF EXISTS currency;
+ALTER TABLE symbols DROP COLUMN IF EXISTS sector;
+ALTER TABLE symbols DROP COLUMN IF EXISTS name;
+
+ALTER TABLE exchanges DROP COLUMN IF EXISTS lot_size;
+ALTER TABLE exchanges DROP COLUMN IF EXISTS currency;
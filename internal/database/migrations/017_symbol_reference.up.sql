@@ -0,0 +1,42 @@
+-- Default quote currency and board lot of each exchange; NULL currency means the
+-- quote asset of a BASE-QUOTE pair
+ALTER TABLE exchanges ADD COLUMN IF NOT EXISTS currency CHAR(3);
+ALTER TABLE exchanges ADD COLUMN IF NOT EXISTS lot_size INTEGER NOT NULL DEFAULT 1;
+
+UPDATE exchanges SET currency = 'IDR', lot_size = 100 WHERE code = 'IDX';
+UPDATE exchanges SET currency = 'USD' WHERE code = 'US';
+
+-- Reference data for each listing
+ALTER TABLE symbols ADD COLUMN IF NOT EXISTS name VARCHAR(200) NOT NULL DEFAULT '';
+ALTER TABLE symbols ADD COLUMN IF NOT EXISTS sector VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE symbols ADD COLUMN IF NOT EXISTS currency VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE symbols ADD COLUMN IF NOT EXISTS lot_size INTEGER NOT NULL DEFAULT 1 CHECK (lot_size > 0);
+ALTER TABLE symbols ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE symbols s
+SET currency = COALESCE(e.currency, split_part(s.symbol, '-', 2)), lot_size = e.lot_size
+FROM exchanges e
+WHERE e.code = s.exchange AND s.currency = '';
+
+CREATE INDEX IF NOT EXISTS idx_symbols_symbol ON symbols(symbol);
+CREATE INDEX IF NOT EXISTS idx_symbols_name ON symbols(lower(name) text_pattern_ops);
+
+DROP TRIGGER IF EXISTS update_symbols_updated_at ON symbols;
+CREATE TRIGGER update_symbols_updated_at
+BEFORE UPDATE ON symbols
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Listings first seen in market data get their exchange's currency and lot size
+CREATE OR REPLACE FUNCTION register_market_data_symbols()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO symbols (exchange, symbol, currency, lot_size)
+    SELECT DISTINCT i.exchange, i.symbol,
+        COALESCE(e.currency, split_part(i.symbol, '-', 2), ''), COALESCE(e.lot_size, 1)
+    FROM inserted i
+    LEFT JOIN exchanges e ON e.code = i.exchange
+    ON CONFLICT DO NOTHING;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
@@ -0,0 +1,24 @@
+-- Asynchronous CSV import jobs; the uploaded file is kept in the object store until the job finishes
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    filename VARCHAR(255) NOT NULL DEFAULT '',
+    object_key VARCHAR(255) NOT NULL DEFAULT '',
+    on_conflict VARCHAR(10) NOT NULL DEFAULT 'update', -- update, skip or error
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',     -- pending, running, completed, failed
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    bytes_read BIGINT NOT NULL DEFAULT 0,
+    rows_processed BIGINT NOT NULL DEFAULT 0,
+    rows_inserted BIGINT NOT NULL DEFAULT 0,
+    rows_updated BIGINT NOT NULL DEFAULT 0,
+    rows_skipped BIGINT NOT NULL DEFAULT 0,  -- existing rows kept under on_conflict=skip
+    rows_rejected BIGINT NOT NULL DEFAULT 0, -- unparseable rows and rows refused by anomaly screening
+    errors JSONB NOT NULL DEFAULT '[]',      -- per-row errors, capped
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_user ON import_jobs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_import_jobs_status ON import_jobs(status);
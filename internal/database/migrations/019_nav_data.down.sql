@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS nav_data;
+DELETE FROM symbols WHERE exchange = 'FUND';
+DELETE FROM sources WHERE name = 'fundnav';
+DELETE FROM exchanges WHERE code = 'FUND';
@@ -0,0 +1,28 @@
+-- Mutual funds are quoted by one net asset value per day, without OHLC or volume.
+-- They are listed under a pseudo-exchange publishing on weekdays.
+INSERT INTO exchanges (code, name, timezone, opens_at, closes_at, trading_days, asset_class) VALUES
+    ('FUND', 'Mutual funds (daily NAV)', 'UTC', '00:00', '00:00', '{1,2,3,4,5}', 'fund')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO sources (name, display_name, attribution, license, license_url, redistribution_allowed, priority) VALUES
+    ('fundnav', 'Fund NAV provider', 'NAV data provided by the configured fund data provider', '', '', FALSE, 50)
+ON CONFLICT (name) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS nav_data (
+    id BIGSERIAL PRIMARY KEY,
+    symbol VARCHAR(20) NOT NULL,
+    date DATE NOT NULL,
+    nav NUMERIC(24, 8) NOT NULL CHECK (nav > 0),
+    source VARCHAR(50) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (symbol, date, source)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nav_data_symbol_date ON nav_data(symbol, date DESC);
+
+DROP TRIGGER IF EXISTS update_nav_data_updated_at ON nav_data;
+CREATE TRIGGER update_nav_data_updated_at
+BEFORE UPDATE ON nav_data
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
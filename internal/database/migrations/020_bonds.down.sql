@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS bond_quotes;
+DROP TABLE IF EXISTS bond_coupons;
+DROP TABLE IF EXISTS bonds;
+DELETE FROM symbols WHERE exchange = 'BOND';
+DELETE FROM exchanges WHERE code = 'BOND';
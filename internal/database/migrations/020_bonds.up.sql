@@ -0,0 +1,66 @@
+-- Fixed-income instruments such as Indonesian government retail bonds (ORI, SBR).
+-- Bonds are listed under a pseudo-exchange so they can be watched and held.
+INSERT INTO exchanges (code, name, timezone, opens_at, closes_at, trading_days, asset_class, currency, lot_size) VALUES
+    ('BOND', 'Bonds (quoted as percent of par)', 'Asia/Jakarta', '09:00', '16:00', '{1,2,3,4,5}', 'bond', 'IDR', 1)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS bonds (
+    symbol VARCHAR(20) PRIMARY KEY,
+    name VARCHAR(200) NOT NULL DEFAULT '',
+    issuer VARCHAR(200) NOT NULL DEFAULT '',
+    currency CHAR(3) NOT NULL DEFAULT 'IDR',
+    coupon_type VARCHAR(10) NOT NULL DEFAULT 'fixed',        -- fixed or floating
+    coupon_rate NUMERIC(10, 6) NOT NULL CHECK (coupon_rate >= 0), -- annual percent; the floor for floating coupons
+    coupon_frequency SMALLINT NOT NULL CHECK (coupon_frequency IN (1, 2, 4, 12)),
+    day_count VARCHAR(10) NOT NULL DEFAULT 'ACT/ACT',         -- ACT/ACT, ACT/365 or 30/360
+    issue_date DATE NOT NULL,
+    maturity_date DATE NOT NULL CHECK (maturity_date > issue_date),
+    tradable BOOLEAN NOT NULL DEFAULT TRUE,                   -- SBR cannot be traded and is valued at par
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Coupon schedule, one row per payment like other corporate actions: the period it
+-- accrues over, who is entitled (record date) and when it is paid
+CREATE TABLE IF NOT EXISTS bond_coupons (
+    symbol VARCHAR(20) NOT NULL REFERENCES bonds(symbol) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL CHECK (period_end > period_start),
+    record_date DATE,
+    pay_date DATE NOT NULL,
+    rate NUMERIC(10, 6) NOT NULL CHECK (rate >= 0), -- annual percent in force for the period
+    amount NUMERIC(24, 10) NOT NULL,                -- paid per 100 of face value
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (symbol, period_end)
+);
+
+-- Daily secondary-market quotes; clean price in percent of par with its yield to maturity
+CREATE TABLE IF NOT EXISTS bond_quotes (
+    symbol VARCHAR(20) NOT NULL REFERENCES bonds(symbol) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    clean_price NUMERIC(12, 6) NOT NULL CHECK (clean_price > 0),
+    yield NUMERIC(12, 6) NOT NULL,
+    source VARCHAR(50) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (symbol, date, source)
+);
+
+DROP TRIGGER IF EXISTS update_bonds_updated_at ON bonds;
+CREATE TRIGGER update_bonds_updated_at
+BEFORE UPDATE ON bonds
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_bond_coupons_updated_at ON bond_coupons;
+CREATE TRIGGER update_bond_coupons_updated_at
+BEFORE UPDATE ON bond_coupons
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_bond_quotes_updated_at ON bond_quotes;
+CREATE TRIGGER update_bond_quotes_updated_at
+BEFORE UPDATE ON bond_quotes
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,5 @@
+DELETE FROM sources WHERE name = 'tradingview';
+
+ALTER TABLE import_jobs DROP COLUMN IF EXISTS interval;
+ALTER TABLE import_jobs DROP COLUMN IF EXISTS symbol;
+ALTER TABLE import_jobs DROP COLUMN IF EXISTS format;
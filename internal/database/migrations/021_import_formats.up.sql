@@ -0,0 +1,8 @@
+-- CSV imports record the export format they were read as
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS format VARCHAR(30) NOT NULL DEFAULT '';   -- empty to detect from the header
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS symbol VARCHAR(20) NOT NULL DEFAULT '';   -- for exports without a symbol column
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS interval VARCHAR(3) NOT NULL DEFAULT '';  -- for exports without an interval column
+
+INSERT INTO sources (name, display_name, attribution, license, license_url, redistribution_allowed, priority) VALUES
+    ('tradingview', 'TradingView', 'Data exported from TradingView charts', 'TradingView terms of use', 'https://www.tradingview.com/policies/', FALSE, 35)
+ON CONFLICT (name) DO NOTHING;
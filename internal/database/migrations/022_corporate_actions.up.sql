@@ -0,0 +1,48 @@
+-- Corporate actions that change holdings. Rights issues (HMETD) and warrants credit
+-- holders of the parent symbol with ratio_new entitlements per ratio_old shares.
+CREATE TABLE IF NOT EXISTS corporate_actions (
+    id BIGSERIAL PRIMARY KEY,
+    symbol VARCHAR(20) NOT NULL,
+    action_type VARCHAR(20) NOT NULL CHECK (action_type IN ('rights', 'warrant')),
+    ex_date DATE NOT NULL,
+    ratio_old INTEGER NOT NULL CHECK (ratio_old > 0),
+    ratio_new INTEGER NOT NULL CHECK (ratio_new > 0),
+    exercise_price DECIMAL(12, 4) NOT NULL DEFAULT 0 CHECK (exercise_price >= 0),
+    entitlement_symbol VARCHAR(20) NOT NULL DEFAULT '', -- the rights or warrant ticker credited to holders
+    cum_price DECIMAL(12, 4),                           -- parent close before the ex-date, for cost allocation
+    status VARCHAR(20) NOT NULL DEFAULT 'pending_review', -- pending_review, scheduled, applied or rejected
+    review_reasons TEXT[] NOT NULL DEFAULT '{}',          -- why the action needs review
+    note TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    reviewed_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP,
+    applied_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_corporate_actions_symbol ON corporate_actions(symbol, ex_date);
+CREATE INDEX IF NOT EXISTS idx_corporate_actions_status ON corporate_actions(status, ex_date);
+
+DROP TRIGGER IF EXISTS update_corporate_actions_updated_at ON corporate_actions;
+CREATE TRIGGER update_corporate_actions_updated_at
+BEFORE UPDATE ON corporate_actions
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- What applying a corporate action did to each holding; quantity_before is 0 for a
+-- holding the action created
+CREATE TABLE IF NOT EXISTS portfolio_adjustments (
+    id BIGSERIAL PRIMARY KEY,
+    action_id BIGINT NOT NULL REFERENCES corporate_actions(id) ON DELETE CASCADE,
+    portfolio_id BIGINT NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    quantity_before DECIMAL(18, 4) NOT NULL,
+    quantity_after DECIMAL(18, 4) NOT NULL,
+    avg_price_before DECIMAL(12, 4) NOT NULL,
+    avg_price_after DECIMAL(12, 4) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(action_id, portfolio_id, symbol)
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_adjustments_portfolio ON portfolio_adjustments(portfolio_id, created_at DESC);
@@ -0,0 +1,23 @@
+-- Fundamentals-lite: share count and free float as reported on a date. A snapshot holds
+-- until the next one, so market cap on any day is that day's close times the shares of
+-- the latest snapshot on or before it. market_cap keeps the figure the source reported.
+CREATE TABLE IF NOT EXISTS symbol_fundamentals (
+    id BIGSERIAL PRIMARY KEY,
+    symbol VARCHAR(20) NOT NULL,
+    date DATE NOT NULL,
+    shares_outstanding BIGINT NOT NULL CHECK (shares_outstanding > 0),
+    market_cap NUMERIC(24, 2) CHECK (market_cap >= 0),
+    free_float_pct NUMERIC(7, 4) CHECK (free_float_pct >= 0 AND free_float_pct <= 100),
+    source VARCHAR(50) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (symbol, date, source)
+);
+
+CREATE INDEX IF NOT EXISTS idx_symbol_fundamentals_symbol_date ON symbol_fundamentals(symbol, date DESC);
+
+DROP TRIGGER IF EXISTS update_symbol_fundamentals_updated_at ON symbol_fundamentals;
+CREATE TRIGGER update_symbol_fundamentals_updated_at
+BEFORE UPDATE ON symbol_fundamentals
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,26 @@
+-- Quarterly income statement and balance sheet summaries. Income figures cover the
+-- quarter alone, not the year to date; book_value is total equity at period_end.
+CREATE TABLE IF NOT EXISTS financial_reports (
+    id BIGSERIAL PRIMARY KEY,
+    symbol VARCHAR(20) NOT NULL,
+    fiscal_year SMALLINT NOT NULL,
+    fiscal_quarter SMALLINT NOT NULL CHECK (fiscal_quarter BETWEEN 1 AND 4),
+    period_end DATE NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'IDR',
+    revenue NUMERIC(24, 2),
+    net_income NUMERIC(24, 2),
+    eps NUMERIC(18, 6),
+    book_value NUMERIC(24, 2),
+    source VARCHAR(50) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (symbol, fiscal_year, fiscal_quarter, source)
+);
+
+CREATE INDEX IF NOT EXISTS idx_financial_reports_symbol_period ON financial_reports(symbol, period_end DESC);
+
+DROP TRIGGER IF EXISTS update_financial_reports_updated_at ON financial_reports;
+CREATE TRIGGER update_financial_reports_updated_at
+BEFORE UPDATE ON financial_reports
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,13 @@
+-- Outcome of the scheduled fetch of each watchlisted symbol; next_attempt_at holds a
+-- symbol back after consecutive failures
+CREATE TABLE IF NOT EXISTS fetch_status (
+    symbol VARCHAR(20) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    last_attempt_at TIMESTAMP,
+    last_success_at TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    failures INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    rows_stored INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (symbol, source)
+);
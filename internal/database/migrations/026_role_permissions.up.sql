@@ -0,0 +1,8 @@
+-- Permissions granted to each role, on top of those configured in RBAC_ROLE_PERMISSIONS.
+-- A permission is resource:action; resource:* and * grant more broadly.
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(50) NOT NULL,
+    permission VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (role, permission)
+);
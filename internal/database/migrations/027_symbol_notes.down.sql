@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS symbol_note_attachments;
+DROP TABLE IF EXISTS symbol_notes;
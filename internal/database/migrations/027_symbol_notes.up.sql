@@ -0,0 +1,37 @@
+-- Research notes on symbols, written in markdown. A shared note is readable by every
+-- user of the author's organization; only the author can change it.
+CREATE TABLE IF NOT EXISTS symbol_notes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    organization VARCHAR(100) NOT NULL DEFAULT '', -- the author's, when the note was written
+    symbol VARCHAR(20) NOT NULL,
+    title VARCHAR(200) NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    shared BOOLEAN NOT NULL DEFAULT FALSE,
+    search TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', title || ' ' || body)) STORED,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_symbol_notes_user ON symbol_notes(user_id, symbol, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_symbol_notes_shared ON symbol_notes(organization, symbol, created_at DESC) WHERE shared;
+CREATE INDEX IF NOT EXISTS idx_symbol_notes_search ON symbol_notes USING GIN (search);
+
+DROP TRIGGER IF EXISTS update_symbol_notes_updated_at ON symbol_notes;
+CREATE TRIGGER update_symbol_notes_updated_at
+BEFORE UPDATE ON symbol_notes
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Files attached to a note; the content is kept in the object store
+CREATE TABLE IF NOT EXISTS symbol_note_attachments (
+    id BIGSERIAL PRIMARY KEY,
+    note_id BIGINT NOT NULL REFERENCES symbol_notes(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL DEFAULT 'application/octet-stream',
+    object_key VARCHAR(255) NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_symbol_note_attachments_note ON symbol_note_attachments(note_id);
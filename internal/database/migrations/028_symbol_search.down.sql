@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_symbols_search;
+ALTER TABLE symbols DROP COLUMN IF EXISTS search;
@@ -0,0 +1,7 @@
+-- Full-text search over listings for GET /api/v1/search. Ticker punctuation is split
+-- so BBCA.JK matches "bbca" and BTC-USDT matches "btc".
+ALTER TABLE symbols ADD COLUMN IF NOT EXISTS search TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('simple', translate(symbol, '.-', '  ') || ' ' || name || ' ' || sector)
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_symbols_search ON symbols USING GIN (search);
@@ -0,0 +1 @@
+ALTER TABLE exchanges DROP COLUMN IF EXISTS pre_opens_at;
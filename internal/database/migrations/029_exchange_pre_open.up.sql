@@ -0,0 +1,5 @@
+-- Local start of the pre-opening session before the regular open, when the venue has one
+ALTER TABLE exchanges ADD COLUMN IF NOT EXISTS pre_opens_at TIME;
+
+UPDATE exchanges SET pre_opens_at = '08:45' WHERE code = 'IDX';
+UPDATE exchanges SET pre_opens_at = '04:00' WHERE code = 'US';
@@ -0,0 +1,5 @@
+ALTER TABLE portfolios DROP COLUMN IF EXISTS cash;
+DROP TABLE IF EXISTS portfolio_snapshots;
+DROP TRIGGER IF EXISTS reject_portfolio_event_update ON portfolio_events;
+DROP FUNCTION IF EXISTS reject_portfolio_event_update();
+DROP TABLE IF EXISTS portfolio_events;
@@ -0,0 +1,50 @@
+-- Append-only log of everything that changed a portfolio. portfolio_holdings and
+-- portfolios.cash are a projection of it, rebuilt by replaying events in order.
+CREATE TABLE IF NOT EXISTS portfolio_events (
+    id BIGSERIAL PRIMARY KEY,
+    portfolio_id BIGINT NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    event_type VARCHAR(20) NOT NULL CHECK (event_type IN
+        ('holding_set', 'holding_removed', 'trade', 'deposit', 'withdrawal', 'corporate_action')),
+    symbol VARCHAR(20) NOT NULL DEFAULT '',
+    side VARCHAR(4) NOT NULL DEFAULT '',     -- buy or sell, for trades
+    quantity DECIMAL(18, 4),
+    price DECIMAL(12, 4),                    -- trade price, or the average price a holding was set to
+    amount DECIMAL(18, 4),                   -- deposits and withdrawals
+    action_id BIGINT,                        -- the corporate action that made the change
+    occurred_at TIMESTAMP NOT NULL,          -- when the change took effect; may be backdated
+    recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_events_replay ON portfolio_events(portfolio_id, occurred_at, id);
+
+CREATE OR REPLACE FUNCTION reject_portfolio_event_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'portfolio_events is append-only';
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS reject_portfolio_event_update ON portfolio_events;
+CREATE TRIGGER reject_portfolio_event_update
+BEFORE UPDATE ON portfolio_events
+FOR EACH ROW
+EXECUTE FUNCTION reject_portfolio_event_update();
+
+-- Replayed state after event_id, so reconstruction need not start from the first
+-- event. A backdated event drops the snapshots it lands before.
+CREATE TABLE IF NOT EXISTS portfolio_snapshots (
+    portfolio_id BIGINT NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES portfolio_events(id) ON DELETE CASCADE,
+    at TIMESTAMP NOT NULL,                   -- occurred_at of event_id
+    state JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (portfolio_id, event_id)
+);
+
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS cash DECIMAL(18, 4) NOT NULL DEFAULT 0;
+
+-- History starts from the holdings as they stand
+INSERT INTO portfolio_events (portfolio_id, event_type, symbol, quantity, price, occurred_at)
+SELECT h.portfolio_id, 'holding_set', h.symbol, h.quantity, h.avg_price, COALESCE(h.updated_at, h.created_at, CURRENT_TIMESTAMP)
+FROM portfolio_holdings h
+WHERE NOT EXISTS (SELECT 1 FROM portfolio_events e WHERE e.portfolio_id = h.portfolio_id);
@@ -0,0 +1,19 @@
+-- Transactional outbox: messages written in the same transaction as the change they
+-- describe, relayed to the configured publisher after commit, at least once
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id BIGSERIAL PRIMARY KEY,
+    topic VARCHAR(100) NOT NULL,
+    key VARCHAR(255) NOT NULL DEFAULT '',  -- messages sharing a key are delivered in order
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    available_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- earliest next delivery attempt
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    dead_at TIMESTAMP                      -- given up on after the last allowed attempt
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_pending ON outbox_messages(id)
+    WHERE delivered_at IS NULL AND dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_delivered ON outbox_messages(delivered_at)
+    WHERE delivered_at IS NOT NULL;
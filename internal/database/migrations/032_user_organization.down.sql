@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_user_preferences_organization;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS organization;
@@ -0,0 +1,6 @@
+-- Organization trait of each user as of their last sign-in, so org admins can find
+-- their members' settings
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS organization VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_user_preferences_organization ON user_preferences(organization, email)
+    WHERE organization <> '';
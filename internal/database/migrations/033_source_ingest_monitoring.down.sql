@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS source_ingests;
+ALTER TABLE sources DROP CONSTRAINT IF EXISTS sources_ingest_window_check;
+ALTER TABLE sources DROP COLUMN IF EXISTS ingest_window_minutes;
@@ -0,0 +1,18 @@
+-- How often each source is expected to deliver candles; NULL leaves it unmonitored
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS ingest_window_minutes INTEGER;
+
+ALTER TABLE sources DROP CONSTRAINT IF EXISTS sources_ingest_window_check;
+ALTER TABLE sources ADD CONSTRAINT sources_ingest_window_check
+    CHECK (ingest_window_minutes IS NULL OR ingest_window_minutes > 0);
+
+-- When each source last delivered candles, and since when it has been reported
+-- overdue. Kept apart from sources so ingests do not touch its updated_at.
+CREATE TABLE IF NOT EXISTS source_ingests (
+    source VARCHAR(50) PRIMARY KEY,
+    last_ingest_at TIMESTAMP,
+    stale_since TIMESTAMP
+);
+
+INSERT INTO source_ingests (source, last_ingest_at)
+SELECT source, MAX(COALESCE(updated_at, created_at)) FROM market_data GROUP BY source
+ON CONFLICT DO NOTHING;
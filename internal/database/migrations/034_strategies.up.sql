@@ -0,0 +1,21 @@
+-- Saved strategy definitions. A public strategy is readable, and can be cloned, by
+-- every user; only its owner can change it.
+CREATE TABLE IF NOT EXISTS strategies (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    definition JSONB NOT NULL,
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    cloned_from BIGINT REFERENCES strategies(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_strategies_user ON strategies(user_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_strategies_public ON strategies(updated_at DESC) WHERE public;
+
+DROP TRIGGER IF EXISTS update_strategies_updated_at ON strategies;
+CREATE TRIGGER update_strategies_updated_at
+BEFORE UPDATE ON strategies
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
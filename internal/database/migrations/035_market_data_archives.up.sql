@@ -0,0 +1,17 @@
+-- Candles moved out of market_data into the archive object store: one gzip-compressed
+-- JSON Lines object per listing, interval, source and year. Reads of archived years
+-- load the object and merge it with whatever live rows the year has since gained.
+CREATE TABLE IF NOT EXISTS market_data_archives (
+    exchange VARCHAR(10) NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    interval VARCHAR(3) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    year INT NOT NULL,
+    rows INT NOT NULL,
+    first_ts TIMESTAMP NOT NULL,
+    last_ts TIMESTAMP NOT NULL,
+    object_key VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (symbol, interval, year, exchange, source)
+);
@@ -0,0 +1,3 @@
+-- Rules each source's candles are rewritten with on ingest, applied in order before
+-- anomaly screening
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS transform_rules JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,12 @@
+-- Calls to endpoints slated for removal, counted per client so admins can see who
+-- still has to migrate. Counts are buffered in memory and added here periodically.
+CREATE TABLE IF NOT EXISTS deprecated_calls (
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,           -- the route template, not the raw path
+    user_id VARCHAR(255) NOT NULL,         -- empty for unauthenticated calls
+    user_agent VARCHAR(255) NOT NULL,
+    calls BIGINT NOT NULL DEFAULT 0,
+    first_seen_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (method, route, user_id, user_agent)
+);
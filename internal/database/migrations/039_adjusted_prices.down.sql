@@ -0,0 +1,35 @@
+DELETE FROM corporate_actions WHERE action_type IN ('split', 'dividend');
+ALTER TABLE corporate_actions DROP COLUMN IF EXISTS amount;
+ALTER TABLE corporate_actions DROP CONSTRAINT IF EXISTS corporate_actions_action_type_check;
+ALTER TABLE corporate_actions ADD CONSTRAINT corporate_actions_action_type_check
+    CHECK (action_type IN ('rights', 'warrant'));
+
+CREATE OR REPLACE FUNCTION archive_market_data_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.open, NEW.high, NEW.low, NEW.close, NEW.volume)
+        IS NOT DISTINCT FROM (OLD.open, OLD.high, OLD.low, OLD.close, OLD.volume) THEN
+        NEW.updated_at = OLD.updated_at;
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO market_data_history (
+        market_data_id, exchange, symbol, interval, date, ts, open, high, low, close, volume, source,
+        created_at, valid_from, valid_to
+    ) VALUES (
+        OLD.id, OLD.exchange, OLD.symbol, OLD.interval, OLD.date, OLD.ts, OLD.open, OLD.high, OLD.low,
+        OLD.close, OLD.volume, OLD.source, OLD.created_at, COALESCE(OLD.updated_at, OLD.created_at),
+        CURRENT_TIMESTAMP
+    );
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+ALTER TABLE market_data_history DROP COLUMN IF EXISTS adj_close;
+ALTER TABLE market_data DROP COLUMN IF EXISTS adj_close;
@@ -0,0 +1,37 @@
+-- The source's split and dividend adjusted close, where it publishes one
+ALTER TABLE market_data ADD COLUMN IF NOT EXISTS adj_close NUMERIC(24, 8);
+ALTER TABLE market_data_history ADD COLUMN IF NOT EXISTS adj_close NUMERIC(24, 8);
+
+-- Archive the adjusted close along with each superseded version
+CREATE OR REPLACE FUNCTION archive_market_data_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.open, NEW.high, NEW.low, NEW.close, NEW.adj_close, NEW.volume)
+        IS NOT DISTINCT FROM (OLD.open, OLD.high, OLD.low, OLD.close, OLD.adj_close, OLD.volume) THEN
+        NEW.updated_at = OLD.updated_at;
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO market_data_history (
+        market_data_id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source,
+        created_at, valid_from, valid_to
+    ) VALUES (
+        OLD.id, OLD.exchange, OLD.symbol, OLD.interval, OLD.date, OLD.ts, OLD.open, OLD.high, OLD.low,
+        OLD.close, OLD.adj_close, OLD.volume, OLD.source, OLD.created_at, COALESCE(OLD.updated_at, OLD.created_at),
+        CURRENT_TIMESTAMP
+    );
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+-- Splits give ratio_new shares for every ratio_old held; dividends pay amount per share
+ALTER TABLE corporate_actions DROP CONSTRAINT IF EXISTS corporate_actions_action_type_check;
+ALTER TABLE corporate_actions ADD CONSTRAINT corporate_actions_action_type_check
+    CHECK (action_type IN ('rights', 'warrant', 'split', 'dividend'));
+ALTER TABLE corporate_actions ADD COLUMN IF NOT EXISTS amount DECIMAL(18, 6) NOT NULL DEFAULT 0 CHECK (amount >= 0);
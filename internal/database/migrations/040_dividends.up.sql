@@ -0,0 +1,25 @@
+-- Dividend calendar: announced cash dividends per symbol with the dates that decide
+-- who is paid and when. One announcement per symbol and ex-date.
+CREATE TABLE IF NOT EXISTS dividends (
+    id BIGSERIAL PRIMARY KEY,
+    symbol VARCHAR(20) NOT NULL,
+    ex_date DATE NOT NULL,
+    record_date DATE,
+    payment_date DATE,
+    amount NUMERIC(18, 6) NOT NULL CHECK (amount > 0), -- per share
+    currency VARCHAR(10) NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (symbol, ex_date),
+    CHECK (payment_date IS NULL OR payment_date >= ex_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dividends_ex_date ON dividends (ex_date);
+
+DROP TRIGGER IF EXISTS update_dividends_updated_at ON dividends;
+CREATE TRIGGER update_dividends_updated_at
+BEFORE UPDATE ON dividends
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
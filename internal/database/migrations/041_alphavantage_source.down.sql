@@ -0,0 +1,6 @@
+ALTER TABLE market_data DISABLE TRIGGER archive_market_data_version;
+DELETE FROM market_data WHERE source = 'alphavantage';
+ALTER TABLE market_data ENABLE TRIGGER archive_market_data_version;
+DELETE FROM market_data_history WHERE source = 'alphavantage';
+
+DELETE FROM sources WHERE name = 'alphavantage';
@@ -0,0 +1,4 @@
+-- Daily candles fetched from the Alpha Vantage time series API
+INSERT INTO sources (name, display_name, attribution, license, license_url, redistribution_allowed, priority) VALUES
+    ('alphavantage', 'Alpha Vantage', 'Data provided by Alpha Vantage', 'Alpha Vantage terms of service', 'https://www.alphavantage.co/terms_of_service/', FALSE, 45)
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,6 @@
+-- The newest release each user has marked seen in the changelog
+CREATE TABLE IF NOT EXISTS changelog_seen (
+    user_id VARCHAR(255) PRIMARY KEY,
+    version VARCHAR(50) NOT NULL,
+    seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
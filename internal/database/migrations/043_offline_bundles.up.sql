@@ -0,0 +1,13 @@
+-- What each offline bundle handed to a user covered, so a later request can send
+-- only what changed since it
+CREATE TABLE IF NOT EXISTS offline_bundles (
+    version BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    symbols TEXT[] NOT NULL,
+    source VARCHAR(100) NOT NULL,
+    window_start DATE NOT NULL,
+    preferences_hash VARCHAR(64) NOT NULL,
+    generated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_offline_bundles_user ON offline_bundles(user_id, generated_at);
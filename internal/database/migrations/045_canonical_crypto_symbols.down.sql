@@ -0,0 +1 @@
+-- Crypto symbols are not split back into the spellings they were stored under
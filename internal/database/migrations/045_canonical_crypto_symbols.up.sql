@@ -0,0 +1,98 @@
+-- Crypto pairs are stored as BASE-QUOTE on CRYPTO. Pairs stored before that as
+-- BTCUSDT or BTC/USDT, inferred to be US listings, are rewritten; where both
+-- spellings hold a candle for the same time and source, the later update is kept.
+-- The patterns match models.CryptoPair.
+CREATE OR REPLACE FUNCTION canonical_crypto_symbol(symbol TEXT)
+RETURNS TEXT AS $$
+    SELECT CASE
+        WHEN upper(symbol) ~ '^[A-Z0-9]{2,10}[-/](USDT|USDC|FDUSD|BTC|ETH)$'
+            THEN regexp_replace(upper(symbol), '^([A-Z0-9]{2,10})[-/]', '\1-')
+        WHEN upper(symbol) ~ '^[A-Z0-9]{2,10}(FDUSD|USDT|USDC)$'
+            THEN regexp_replace(upper(symbol), '^([A-Z0-9]{2,10})(FDUSD|USDT|USDC)$', '\1-\2')
+        ELSE symbol
+    END
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION crypto_renamed(symbol TEXT, exchange TEXT)
+RETURNS BOOLEAN AS $$
+    SELECT canonical_crypto_symbol(symbol) ~ '^[A-Z0-9]{2,10}-(USDT|USDC|FDUSD|BTC|ETH)$'
+        AND (canonical_crypto_symbol(symbol) <> symbol OR exchange = 'US')
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE TEMP TABLE crypto_renames ON COMMIT DROP AS
+SELECT id, canonical_crypto_symbol(symbol) AS symbol,
+    CASE WHEN exchange = 'US' THEN 'CRYPTO' ELSE exchange END AS exchange
+FROM market_data
+WHERE crypto_renamed(symbol, exchange);
+
+DELETE FROM market_data md
+USING (
+    SELECT id, row_number() OVER (
+        PARTITION BY exchange, symbol, interval, ts, source ORDER BY at DESC, id DESC
+    ) AS rank
+    FROM (
+        SELECT m.id, COALESCE(r.exchange, m.exchange) AS exchange, COALESCE(r.symbol, m.symbol) AS symbol,
+            m.interval, m.ts, m.source, COALESCE(m.updated_at, m.created_at) AS at
+        FROM market_data m
+        LEFT JOIN crypto_renames r ON r.id = m.id
+        WHERE r.id IS NOT NULL OR (m.exchange, m.symbol) IN (SELECT exchange, symbol FROM crypto_renames)
+    ) keyed
+) ranked
+WHERE md.id = ranked.id AND ranked.rank > 1;
+
+UPDATE market_data md SET symbol = r.symbol, exchange = r.exchange
+FROM crypto_renames r
+WHERE md.id = r.id;
+
+UPDATE market_data_history
+SET symbol = canonical_crypto_symbol(symbol), exchange = CASE WHEN exchange = 'US' THEN 'CRYPTO' ELSE exchange END
+WHERE crypto_renamed(symbol, exchange);
+
+-- Listings follow their candles; one already listed under the new spelling is kept
+DELETE FROM symbols s
+WHERE crypto_renamed(s.symbol, s.exchange) AND EXISTS (
+    SELECT 1 FROM symbols t
+    WHERE t.symbol = canonical_crypto_symbol(s.symbol)
+        AND t.exchange = CASE WHEN s.exchange = 'US' THEN 'CRYPTO' ELSE s.exchange END
+);
+
+UPDATE symbols
+SET currency = CASE WHEN exchange = 'US' THEN split_part(canonical_crypto_symbol(symbol), '-', 2) ELSE currency END,
+    lot_size = CASE WHEN exchange = 'US' THEN 1 ELSE lot_size END,
+    symbol = canonical_crypto_symbol(symbol),
+    exchange = CASE WHEN exchange = 'US' THEN 'CRYPTO' ELSE exchange END
+WHERE crypto_renamed(symbol, exchange);
+
+-- Archived segments are renamed unless the new spelling already has one
+UPDATE market_data_archives a
+SET symbol = canonical_crypto_symbol(a.symbol), exchange = CASE WHEN a.exchange = 'US' THEN 'CRYPTO' ELSE a.exchange END
+WHERE crypto_renamed(a.symbol, a.exchange) AND NOT EXISTS (
+    SELECT 1 FROM market_data_archives t
+    WHERE t.symbol = canonical_crypto_symbol(a.symbol)
+        AND t.exchange = CASE WHEN a.exchange = 'US' THEN 'CRYPTO' ELSE a.exchange END
+        AND t.interval = a.interval AND t.year = a.year AND t.source = a.source
+);
+
+-- Watchlists keep their order; a pair listed under two spellings is listed once
+UPDATE user_preferences SET watchlist = ARRAY(
+    SELECT symbol FROM (
+        SELECT canonical_crypto_symbol(w.symbol) AS symbol, min(w.position) AS position
+        FROM unnest(watchlist) WITH ORDINALITY AS w(symbol, position)
+        GROUP BY 1
+    ) canonical
+    ORDER BY position
+)
+WHERE EXISTS (SELECT 1 FROM unnest(watchlist) AS w(symbol) WHERE canonical_crypto_symbol(w.symbol) <> w.symbol);
+
+UPDATE user_preferences SET selected_symbols = ARRAY(
+    SELECT symbol FROM (
+        SELECT canonical_crypto_symbol(w.symbol) AS symbol, min(w.position) AS position
+        FROM unnest(selected_symbols) WITH ORDINALITY AS w(symbol, position)
+        GROUP BY 1
+    ) canonical
+    ORDER BY position
+)
+WHERE EXISTS (SELECT 1 FROM unnest(selected_symbols) AS w(symbol) WHERE canonical_crypto_symbol(w.symbol) <> w.symbol);
+
+DROP FUNCTION crypto_renamed(TEXT, TEXT);
+DROP FUNCTION canonical_crypto_symbol(TEXT);
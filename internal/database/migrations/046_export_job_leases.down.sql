@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_export_jobs_claimable;
+ALTER TABLE export_jobs DROP COLUMN IF EXISTS lease_expires_at;
+ALTER TABLE export_jobs DROP COLUMN IF EXISTS claimed_by;
@@ -0,0 +1,8 @@
+-- Export jobs are claimed by one instance at a time. The claim is a lease the worker
+-- renews while it runs; a running job whose lease lapses, because its instance
+-- stopped, is claimed again by another.
+ALTER TABLE export_jobs ADD COLUMN IF NOT EXISTS claimed_by VARCHAR(64);
+ALTER TABLE export_jobs ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_claimable ON export_jobs(created_at, id)
+    WHERE status IN ('pending', 'running');
@@ -0,0 +1,2 @@
+ALTER TABLE export_jobs DROP COLUMN IF EXISTS exchange;
+ALTER TABLE export_jobs DROP COLUMN IF EXISTS interval;
@@ -0,0 +1,4 @@
+-- Export jobs read one interval, and optionally one exchange's listings; jobs queued
+-- before either was chosen read daily candles on every exchange.
+ALTER TABLE export_jobs ADD COLUMN IF NOT EXISTS interval VARCHAR(3) NOT NULL DEFAULT '1d';
+ALTER TABLE export_jobs ADD COLUMN IF NOT EXISTS exchange VARCHAR(10) NOT NULL DEFAULT '';
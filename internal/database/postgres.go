@@ -2,7 +2,11 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/ridhomain/proto-trading-service/internal/config"
@@ -72,7 +76,7 @@ func (db *DB) Close() {
 // HealthCheck performs a simple health check
 func (db *DB) HealthCheck(ctx context.Context) error {
 	var result int
-	err := db.pool.QueryRow(ctx, "SELECT 1").Scan(&result)
+	err := db.QueryRow(ctx, "SELECT 1").Scan(&result)
 	return err
 }
 
@@ -83,11 +87,18 @@ func (db *DB) Stats() *pgxpool.Stat {
 
 // Acquire gets a connection from the pool
 func (db *DB) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	if connectionDropped(ctx) {
+		return nil, ErrConnectionDropped
+	}
 	return db.pool.Acquire(ctx)
 }
 
 // Transaction helper for handling transactions
 func (db *DB) Transaction(ctx context.Context, fn func(pgx.Tx) error) error {
+	if connectionDropped(ctx) {
+		return fmt.Errorf("failed to begin transaction: %w", ErrConnectionDropped)
+	}
+
 	tx, err := db.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -112,20 +123,57 @@ func (db *DB) Transaction(ctx context.Context, fn func(pgx.Tx) error) error {
 
 // QueryRow is a helper method that acquires a connection and executes a query returning a single row
 func (db *DB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if connectionDropped(ctx) {
+		return errRow{err: ErrConnectionDropped}
+	}
 	return db.pool.QueryRow(ctx, sql, args...)
 }
 
 // Query is a helper method that acquires a connection and executes a query returning multiple rows
 func (db *DB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	if connectionDropped(ctx) {
+		return nil, ErrConnectionDropped
+	}
 	return db.pool.Query(ctx, sql, args...)
 }
 
 // Exec is a helper method that acquires a connection and executes a query without returning rows
 func (db *DB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if connectionDropped(ctx) {
+		return pgconn.CommandTag{}, ErrConnectionDropped
+	}
 	return db.pool.Exec(ctx, sql, args...)
 }
 
 // CopyFrom performs a bulk insert using PostgreSQL COPY protocol - very fast for bulk data
 func (db *DB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	if connectionDropped(ctx) {
+		return 0, ErrConnectionDropped
+	}
 	return db.pool.CopyFrom(ctx, tableName, columnNames, rowSrc)
 }
+
+// IsUnavailable reports whether err means the database could not be reached, as
+// opposed to a query it rejected: a failed connection, a dropped one, or a server
+// shutting down or not yet accepting connections
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrConnectionDropped) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is connection exceptions; 57P01-57P03 are admin shutdown, crash
+		// shutdown and cannot connect now
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,36 @@
+// Package datasource describes the upstream providers candles are fetched from, so
+// callers can fetch from any of them the same way.
+package datasource
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// Errors every data source reports its failures as, wrapped with the provider's name
+var (
+	ErrSymbolNotFound = errors.New("symbol not found")
+	ErrRateLimited    = errors.New("rate limited")
+	ErrNotConfigured  = errors.New("data source is not configured")
+	// ErrIntervalNotSupported is returned for a candle interval the source does not offer
+	ErrIntervalNotSupported = errors.New("interval not supported")
+)
+
+// DataSource is an upstream provider of daily candles
+type DataSource interface {
+	// Name is the source fetched candles are stored under, e.g. yahoo
+	Name() string
+	// DisplayName names the provider in messages, e.g. Yahoo Finance
+	DisplayName() string
+	// FetchDaily returns the daily candles of symbol between start and end
+	FetchDaily(ctx context.Context, symbol string, start, end time.Time) ([]models.MarketData, error)
+}
+
+// IntervalFetcher is implemented by data sources that also offer intraday candles
+type IntervalFetcher interface {
+	// FetchInterval returns the candles of interval for symbol opening between start and end
+	FetchInterval(ctx context.Context, symbol, interval string, start, end time.Time) ([]models.MarketData, error)
+}
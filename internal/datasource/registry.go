@@ -0,0 +1,174 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// ErrUnknownSource is returned when fetching from a provider that is not registered
+var ErrUnknownSource = errors.New("unknown data source")
+
+// Configurable is implemented by data sources that need settings, such as an API
+// key, before they can fetch
+type Configurable interface {
+	Configured() bool
+}
+
+// SymbolNormalizer is implemented by data sources that expect symbols written a
+// particular way, e.g. upper case
+type SymbolNormalizer interface {
+	NormalizeSymbol(symbol string) string
+}
+
+// Registry holds the data sources candles can be fetched from, by name, and keeps
+// track of how each one's fetches go. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	sources []DataSource // in registration order
+	byName  map[string]DataSource
+	stats   map[string]*fetchStats
+}
+
+// fetchStats is how a source's fetches have gone since startup
+type fetchStats struct {
+	fetches       int
+	failures      int
+	lastFetchAt   *time.Time
+	lastSuccessAt *time.Time
+	lastError     string
+}
+
+// NewRegistry registers sources in the order given
+func NewRegistry(sources ...DataSource) (*Registry, error) {
+	r := &Registry{byName: map[string]DataSource{}, stats: map[string]*fetchStats{}}
+	for _, s := range sources {
+		if err := r.Register(s); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds a data source under its name, which must not be taken
+func (r *Registry) Register(source DataSource) error {
+	name := strings.ToLower(source.Name())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byName[name]; ok {
+		return fmt.Errorf("data source %s is already registered", name)
+	}
+	r.sources = append(r.sources, source)
+	r.byName[name] = source
+	r.stats[name] = &fetchStats{}
+	return nil
+}
+
+// Get returns the data source registered under name, ignoring case
+func (r *Registry) Get(name string) (DataSource, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	source, ok := r.byName[strings.ToLower(name)]
+	return source, ok
+}
+
+// Names lists the registered sources in registration order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, len(r.sources))
+	for i, s := range r.sources {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// Fetch fetches the candles of interval for symbol between start and end from the
+// source registered under name, normalizing the symbol the way the source expects.
+// Daily candles are assumed when interval is "". It returns the symbol as fetched.
+func (r *Registry) Fetch(ctx context.Context, name, symbol, interval string, start, end time.Time) ([]models.MarketData, string, error) {
+	source, ok := r.Get(name)
+	if !ok {
+		return nil, symbol, fmt.Errorf("%w: %s", ErrUnknownSource, name)
+	}
+	if n, ok := source.(SymbolNormalizer); ok {
+		symbol = n.NormalizeSymbol(symbol)
+	}
+
+	var data []models.MarketData
+	var err error
+	switch f, ok := source.(IntervalFetcher); {
+	case interval == "" || interval == models.IntervalDaily:
+		data, err = source.FetchDaily(ctx, symbol, start, end)
+	case ok:
+		data, err = f.FetchInterval(ctx, symbol, interval, start, end)
+	default:
+		return nil, symbol, fmt.Errorf("%w by %s: %s", ErrIntervalNotSupported, source.DisplayName(), interval)
+	}
+	if ctx.Err() == nil {
+		r.record(source.Name(), err)
+	}
+	return data, symbol, err
+}
+
+// record counts a fetch. A symbol the source does not know is the caller's mistake,
+// not a failure of the source.
+func (r *Registry) record(name string, err error) {
+	now := time.Now().UTC()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.stats[strings.ToLower(name)]
+	stats.fetches++
+	stats.lastFetchAt = &now
+	if err != nil && !errors.Is(err, ErrSymbolNotFound) {
+		stats.failures++
+		stats.lastError = err.Error()
+		return
+	}
+	stats.lastSuccessAt = &now
+	stats.lastError = ""
+}
+
+// Status reports every registered source in registration order
+func (r *Registry) Status() []models.ProviderStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	statuses := make([]models.ProviderStatus, 0, len(r.sources))
+	for _, s := range r.sources {
+		stats := r.stats[strings.ToLower(s.Name())]
+		status := models.ProviderStatus{
+			Name:          s.Name(),
+			DisplayName:   s.DisplayName(),
+			Configured:    true,
+			Fetches:       stats.fetches,
+			Failures:      stats.failures,
+			LastFetchAt:   stats.lastFetchAt,
+			LastSuccessAt: stats.lastSuccessAt,
+			LastError:     stats.lastError,
+		}
+		if c, ok := s.(Configurable); ok {
+			status.Configured = c.Configured()
+		}
+		status.Intervals = []string{models.IntervalDaily}
+		if _, ok := s.(IntervalFetcher); ok {
+			status.Intervals = []string{models.Interval1m, models.Interval5m, models.Interval1h, models.IntervalDaily}
+		}
+		switch {
+		case !status.Configured:
+			status.Status = models.ProviderUnconfigured
+		case stats.lastFetchAt == nil:
+			status.Status = models.ProviderIdle
+		case stats.lastError != "":
+			status.Status = models.ProviderFailing
+		default:
+			status.Status = models.ProviderOK
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,179 @@
+package fees
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Side of a trade
+type Side string
+
+const (
+	Buy  Side = "buy"
+	Sell Side = "sell"
+)
+
+// Tier applies a broker commission rate from a minimum trade value upward
+type Tier struct {
+	MinValue float64 `json:"min_value"`
+	BuyPct   float64 `json:"buy_pct"`
+	SellPct  float64 `json:"sell_pct"`
+}
+
+// Model describes trading costs, shared by the backtester, rebalancer and paper-trading fills
+type Model struct {
+	BrokerTiers []Tier  `json:"broker_tiers"`
+	LevyPct     float64 `json:"levy_pct"`     // IDX levy plus clearing and settlement fees
+	SellTaxPct  float64 `json:"sell_tax_pct"` // final income tax on sales
+	MinFee      float64 `json:"min_fee"`      // minimum broker commission per order
+	SlippageBps float64 `json:"slippage_bps"` // assumed adverse price movement per fill
+	LotSize     int64   `json:"lot_size"`
+}
+
+// Breakdown itemizes the cost of a single fill
+type Breakdown struct {
+	Side       Side    `json:"side"`
+	Quantity   int64   `json:"quantity"`
+	Price      float64 `json:"price"`
+	FillPrice  float64 `json:"fill_price"`
+	GrossValue float64 `json:"gross_value"`
+	BrokerFee  float64 `json:"broker_fee"`
+	Levy       float64 `json:"levy"`
+	Tax        float64 `json:"tax"`
+	TotalFees  float64 `json:"total_fees"`
+	NetValue   float64 `json:"net_value"` // cash paid on buys, cash received on sells
+}
+
+// Default returns typical IDX retail costs
+func Default() Model {
+	return Model{
+		BrokerTiers: []Tier{
+			{MinValue: 0, BuyPct: 0.10, SellPct: 0.10},
+		},
+		LevyPct:     0.043,
+		SellTaxPct:  0.10,
+		MinFee:      0,
+		SlippageBps: 5,
+		LotSize:     100,
+	}
+}
+
+// Validate returns a list of problems with the model
+func (m Model) Validate() []string {
+	var problems []string
+
+	if len(m.BrokerTiers) == 0 {
+		problems = append(problems, "broker_tiers: at least one tier is required")
+	}
+	for i, tier := range m.BrokerTiers {
+		if tier.MinValue < 0 {
+			problems = append(problems, fmt.Sprintf("broker_tiers[%d].min_value: must not be negative", i))
+		}
+		if tier.BuyPct < 0 || tier.BuyPct > 10 || tier.SellPct < 0 || tier.SellPct > 10 {
+			problems = append(problems, fmt.Sprintf("broker_tiers[%d]: rates must be between 0 and 10 percent", i))
+		}
+		if i > 0 && tier.MinValue <= m.BrokerTiers[i-1].MinValue {
+			problems = append(problems, fmt.Sprintf("broker_tiers[%d].min_value: tiers must be in ascending order", i))
+		}
+	}
+	if len(m.BrokerTiers) > 0 && m.BrokerTiers[0].MinValue != 0 {
+		problems = append(problems, "broker_tiers[0].min_value: first tier must start at 0")
+	}
+	if m.LevyPct < 0 || m.LevyPct > 10 {
+		problems = append(problems, "levy_pct: must be between 0 and 10 percent")
+	}
+	if m.SellTaxPct < 0 || m.SellTaxPct > 10 {
+		problems = append(problems, "sell_tax_pct: must be between 0 and 10 percent")
+	}
+	if m.MinFee < 0 {
+		problems = append(problems, "min_fee: must not be negative")
+	}
+	if m.SlippageBps < 0 || m.SlippageBps > 1000 {
+		problems = append(problems, "slippage_bps: must be between 0 and 1000")
+	}
+	if m.LotSize <= 0 {
+		problems = append(problems, "lot_size: must be greater than 0")
+	}
+
+	return problems
+}
+
+// Apply computes the cost of filling quantity shares at price
+func (m Model) Apply(side Side, price float64, quantity int64) Breakdown {
+	// Slippage always moves the fill against the trader
+	slip := price * m.SlippageBps / 10000
+	fillPrice := price + slip
+	if side == Sell {
+		fillPrice = price - slip
+	}
+
+	gross := fillPrice * float64(quantity)
+	tier := m.tierFor(gross)
+
+	brokerPct := tier.BuyPct
+	if side == Sell {
+		brokerPct = tier.SellPct
+	}
+	brokerFee := math.Max(gross*brokerPct/100, m.MinFee)
+	levy := gross * m.LevyPct / 100
+
+	var tax float64
+	if side == Sell {
+		tax = gross * m.SellTaxPct / 100
+	}
+
+	total := brokerFee + levy + tax
+	net := gross + total
+	if side == Sell {
+		net = gross - total
+	}
+
+	return Breakdown{
+		Side:       side,
+		Quantity:   quantity,
+		Price:      price,
+		FillPrice:  round2(fillPrice),
+		GrossValue: round2(gross),
+		BrokerFee:  round2(brokerFee),
+		Levy:       round2(levy),
+		Tax:        round2(tax),
+		TotalFees:  round2(total),
+		NetValue:   round2(net),
+	}
+}
+
+// Quantity sizes a buy: the largest whole-lot quantity whose total cost fits within amount
+func (m Model) Quantity(amount, price float64) int64 {
+	if price <= 0 || amount <= 0 || m.LotSize <= 0 {
+		return 0
+	}
+
+	lots := int64(amount / (price * float64(m.LotSize)))
+	for lots > 0 {
+		qty := lots * m.LotSize
+		if m.Apply(Buy, price, qty).NetValue <= amount {
+			return qty
+		}
+		lots--
+	}
+	return 0
+}
+
+// tierFor picks the highest tier whose minimum value the trade reaches
+func (m Model) tierFor(value float64) Tier {
+	if len(m.BrokerTiers) == 0 {
+		return Tier{}
+	}
+	idx := sort.Search(len(m.BrokerTiers), func(i int) bool {
+		return m.BrokerTiers[i].MinValue > value
+	})
+	if idx == 0 {
+		return m.BrokerTiers[0]
+	}
+	return m.BrokerTiers[idx-1]
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ConfirmLinkRequest carries the token issued to the canonical identity
+type ConfirmLinkRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// CreateLinkToken issues a token that another identity redeems to link itself to this profile
+func (h *Handler) CreateLinkToken(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	token, err := h.accountService.CreateLinkToken(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to create link token",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to create link token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":      token.Token,
+		"expires_at": token.ExpiresAt,
+		"message":    "Sign in with the other account and confirm the link with this token",
+	})
+}
+
+// ConfirmLink links the current identity to the profile that issued the token
+func (h *Handler) ConfirmLink(c *gin.Context) {
+	identityID := middleware.GetIdentityID(c)
+	email := middleware.GetUserEmail(c)
+	ctx := c.Request.Context()
+
+	var req ConfirmLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.accountService.Link(ctx, req.Token, identityID, email)
+	if err != nil {
+		h.serviceError(c, "Failed to link identity", err, zap.String("identity_id", identityID))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListLinks returns the identities linked to the current profile
+func (h *Handler) ListLinks(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	links, err := h.accountService.ListLinks(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to list links",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list links",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"canonical_user_id": userID,
+		"identity_id":       middleware.GetIdentityID(c),
+		"links":             links,
+	})
+}
+
+// Unlink detaches a linked identity from the current profile
+func (h *Handler) Unlink(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	linkedID := c.Param("identity_id")
+	ctx := c.Request.Context()
+
+	err := h.accountService.Unlink(ctx, userID, linkedID)
+	if err != nil {
+		h.serviceError(c, "Failed to unlink identity", err,
+			zap.String("user_id", userID),
+			zap.String("linked_user_id", linkedID),
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Identity unlinked",
+		"linked_user_id": linkedID,
+	})
+}
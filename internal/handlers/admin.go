@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetDataCoverage lists every listing with its stored row count, the dates it
+// covers and the intervals and sources present (admin)
+func (h *Handler) GetDataCoverage(c *gin.Context) {
+	coverage, err := h.marketService.Coverage(c.Request.Context(), exchangeParam(c), c.Query("source"))
+	if err != nil {
+		h.serviceError(c, "Failed to get data coverage", err)
+		return
+	}
+
+	var rows int64
+	for _, s := range coverage {
+		rows += s.Rows
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(coverage),
+		"rows":    rows,
+		"symbols": coverage,
+	})
+}
+
+// DeleteMarketDataRange deletes one source's candles between two dates, optionally
+// narrowed by exchange, symbol and interval. Like DeleteMarketData, the first
+// request reports the impact and issues a confirmation token (admin).
+func (h *Handler) DeleteMarketDataRange(c *gin.Context) {
+	r, ok := dataRangeParams(c)
+	if !ok {
+		return
+	}
+	userID := middleware.GetUserID(c)
+	token := c.Query("confirm")
+	target := r.Target()
+
+	ctx := c.Request.Context()
+	if token == "" {
+		count, err := h.marketService.CountRange(ctx, r)
+		if err != nil {
+			h.serviceError(c, "Failed to estimate delete impact", err)
+			return
+		}
+		if count == 0 {
+			respondError(c, http.StatusNotFound, ErrorResponse{
+				Code:  apierror.CodeMarketDataNotFound,
+				Error: "No data found in range",
+			})
+			return
+		}
+
+		confirmation, err := h.confirmationService.Issue(ctx, userID, services.ActionDeleteMarketDataRange, target, count)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to issue confirmation token",
+			})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":      "Confirmation required: repeat the request with ?confirm=<confirmation_token>",
+			"confirmation": confirmation,
+		})
+		return
+	}
+
+	if err := h.confirmationService.Consume(ctx, token, userID, services.ActionDeleteMarketDataRange, target); err != nil {
+		h.serviceError(c, "Failed to verify confirmation token", err)
+		return
+	}
+
+	deleted, err := h.marketService.DeleteRange(ctx, r)
+	if err != nil {
+		h.serviceError(c, "Failed to delete data", err, zap.String("range", target))
+		return
+	}
+
+	h.logger.Info("Confirmed market data range delete",
+		zap.String("range", target),
+		zap.String("user_id", userID),
+		zap.Int64("rows_affected", deleted),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Data deleted successfully",
+		"source":        r.Source,
+		"start_date":    r.StartDate.Format("2006-01-02"),
+		"end_date":      r.EndDate.Format("2006-01-02"),
+		"rows_affected": deleted,
+	})
+}
+
+// dataRangeParams reads the range a delete covers: source, start_date and end_date
+// are required, exchange, symbol and interval narrow it
+func dataRangeParams(c *gin.Context) (services.DataRange, bool) {
+	r := services.DataRange{
+		Source:   strings.TrimSpace(c.Query("source")),
+		Exchange: exchangeParam(c),
+		Symbol:   strings.ToUpper(strings.TrimSpace(c.Query("symbol"))),
+		Interval: c.Query("interval"),
+	}
+	if r.Source == "" || c.Query("start_date") == "" || c.Query("end_date") == "" {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeMissingParameter,
+			Error:   "source, start_date and end_date parameters are required",
+			Message: "Deleting a whole source at once is not supported; give the dates it should cover",
+		})
+		return r, false
+	}
+	if r.Interval != "" && !models.ValidInterval(r.Interval) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid interval",
+			Message: "interval must be 1m, 5m, 1h or 1d",
+		})
+		return r, false
+	}
+
+	var err error
+	if r.StartDate, err = time.Parse("2006-01-02", c.Query("start_date")); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDate,
+			Error: "Invalid start_date format. Use YYYY-MM-DD",
+		})
+		return r, false
+	}
+	if r.EndDate, err = time.Parse("2006-01-02", c.Query("end_date")); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDate,
+			Error: "Invalid end_date format. Use YYYY-MM-DD",
+		})
+		return r, false
+	}
+	if r.EndDate.Before(r.StartDate) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDateRange,
+			Error: "end_date is before start_date",
+		})
+		return r, false
+	}
+	return r, true
+}
+
+// ListImportHistory lists recent import jobs across all users, newest first,
+// filtered by ?status= and ?user_id= (admin)
+func (h *Handler) ListImportHistory(c *gin.Context) {
+	filter := services.ImportFilter{
+		UserID: c.Query("user_id"),
+		Status: c.Query("status"),
+		Limit:  100,
+	}
+	switch filter.Status {
+	case "", models.ImportPending, models.ImportRunning, models.ImportCompleted, models.ImportFailed:
+	default:
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid status",
+			Message: "status must be pending, running, completed or failed",
+		})
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 1000 {
+			filter.Limit = l
+		}
+	}
+
+	jobs, err := h.importService.History(c.Request.Context(), filter)
+	if err != nil {
+		h.serviceError(c, "Failed to list imports", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(jobs),
+		"imports": jobs,
+	})
+}
+
+// GetArchiveStatus reports what the market data archive holds, the current cutoff and
+// the latest run (admin)
+func (h *Handler) GetArchiveStatus(c *gin.Context) {
+	status, err := h.archiver.Status(c.Request.Context())
+	if err != nil {
+		h.serviceError(c, "Failed to get archive status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// TriggerArchive queues an archive run now rather than at the next interval. Progress
+// shows in GET /admin/archive (admin).
+func (h *Handler) TriggerArchive(c *gin.Context) {
+	if err := h.archiver.Trigger(); err != nil {
+		h.serviceError(c, "Failed to queue archive run", err)
+		return
+	}
+
+	before := h.archiver.Before(time.Now())
+	h.logger.Info("Archive run queued",
+		zap.String("user_id", middleware.GetUserID(c)),
+		zap.Time("before", before),
+	)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Archive run queued",
+		"before":  before,
+	})
+}
+
+// GetIngestBufferStatus reports the bulk ingests buffered while the database was
+// unavailable and how their replay is going (admin)
+func (h *Handler) GetIngestBufferStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.ingestBuffer.Status())
+}
+
+// TriggerRefetch queues a fetch from Yahoo of the given symbols, or of every
+// watchlisted equity, ignoring backoff. Progress shows in GET /admin/fetch-status
+// (admin).
+func (h *Handler) TriggerRefetch(c *gin.Context) {
+	var req models.RefetchRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidRequestBody,
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+	symbols := make([]string, 0, len(req.Symbols))
+	seen := map[string]bool{}
+	for _, s := range req.Symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s != "" && !seen[s] {
+			seen[s] = true
+			symbols = append(symbols, s)
+		}
+	}
+
+	if err := h.watchlistFetcher.Refetch(symbols, req.Days); err != nil {
+		h.serviceError(c, "Failed to queue refetch", err)
+		return
+	}
+
+	h.logger.Info("Refetch queued",
+		zap.String("user_id", middleware.GetUserID(c)),
+		zap.Strings("symbols", symbols),
+		zap.Int("days", req.Days),
+	)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Refetch queued",
+		"symbols": symbols,
+		"days":    req.Days,
+	})
+}
+
+// GetProvisioningStatus reports the provisioning files applied at startup and where
+// the stored settings have drifted from them (admin)
+func (h *Handler) GetProvisioningStatus(c *gin.Context) {
+	status, err := h.provisioner.Status(c.Request.Context())
+	if err != nil {
+		h.serviceError(c, "Failed to get provisioning status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
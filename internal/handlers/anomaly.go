@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListAnomalies returns recorded ingestion anomalies, e.g. ?action=quarantined for the review queue
+func (h *Handler) ListAnomalies(c *gin.Context) {
+	action := c.Query("action")
+	symbol := c.Query("symbol")
+
+	switch action {
+	case "", models.AnomalyRejected, models.AnomalyQuarantined, models.AnomalyFlagged, models.AnomalyCorrected:
+	default:
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid action",
+			Message: "action must be rejected, quarantined, flagged or corrected",
+		})
+		return
+	}
+
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	ctx := c.Request.Context()
+	anomalies, err := h.anomalyService.List(ctx, action, symbol, limit)
+	if err != nil {
+		h.logger.Error("Failed to list anomalies", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list anomalies",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":     len(anomalies),
+		"anomalies": anomalies,
+	})
+}
+
+// screen applies the sources' anomaly policies to an ingest batch. On failure it
+// answers the request itself and returns ok=false.
+func (h *Handler) screen(c *gin.Context, data []models.MarketData) ([]models.MarketData, *models.ScreenReport, bool) {
+	accepted, report, err := h.anomalyService.Screen(c.Request.Context(), data)
+	if err != nil {
+		h.screenFailed(c, err, len(data))
+		return nil, nil, false
+	}
+	return accepted, report, true
+}
+
+// screenFailed answers a screening error
+func (h *Handler) screenFailed(c *gin.Context, err error, count int) {
+	if h.deadlineExceeded(c, err, nil) {
+		return
+	}
+	h.logger.Error("Failed to screen market data",
+		zap.Int("count", count),
+		zap.Error(err),
+	)
+	respondError(c, http.StatusInternalServerError, ErrorResponse{
+		Error: "Failed to screen data",
+	})
+}
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
 	"github.com/ridhomain/proto-trading-service/internal/middleware"
 	"go.uber.org/zap"
 )
@@ -46,13 +50,13 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 
 	// Get or create user preferences
 	ctx := c.Request.Context()
-	prefs, err := h.userService.GetOrCreatePreferences(ctx, userID, email)
+	prefs, err := h.userService.GetOrCreatePreferences(ctx, userID, email, middleware.GetUserOrganization(c))
 	if err != nil {
 		h.logger.Error("Failed to get user preferences",
 			zap.String("user_id", userID),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to get user preferences",
 		})
 		return
@@ -60,9 +64,11 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
-			"id":    userID,
-			"email": email,
-			"role":  role,
+			"id":          userID,
+			"identity_id": middleware.GetIdentityID(c),
+			"email":       email,
+			"role":        role,
+			"roles":       middleware.GetUserRoles(c),
 		},
 		"session_id":    sessionID,
 		"preferences":   prefs,
@@ -112,7 +118,7 @@ func (h *Handler) GetUserPreferences(c *gin.Context) {
 			zap.String("user_id", userID),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to get preferences",
 		})
 		return
@@ -128,7 +134,8 @@ func (h *Handler) UpdateUserPreferences(c *gin.Context) {
 
 	var updates map[string]interface{}
 	if err := c.ShouldBindJSON(&updates); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
 			Error:   "Invalid request body",
 			Message: err.Error(),
 		})
@@ -137,19 +144,40 @@ func (h *Handler) UpdateUserPreferences(c *gin.Context) {
 
 	// Validate allowed fields
 	allowedFields := map[string]bool{
-		"default_source":   true,
-		"selected_symbols": true,
-		"watchlist":        true,
+		"default_source":      true,
+		"selected_symbols":    true,
+		"watchlist":           true,
+		"default_limit":       true,
+		"default_window_days": true,
 	}
 
-	for field := range updates {
+	// Numeric defaults and their accepted ranges
+	numericFields := map[string][2]float64{
+		"default_limit":       {1, 1000},
+		"default_window_days": {1, 3650},
+	}
+
+	for field, value := range updates {
 		if !allowedFields[field] {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidParameter,
 				Error:   "Invalid field",
 				Message: "Field '" + field + "' is not allowed",
 			})
 			return
 		}
+		if bounds, ok := numericFields[field]; ok {
+			n, isNumber := value.(float64)
+			if !isNumber || n != float64(int(n)) || n < bounds[0] || n > bounds[1] {
+				respondError(c, http.StatusBadRequest, ErrorResponse{
+					Code:    apierror.CodeInvalidParameter,
+					Error:   "Invalid value",
+					Message: fmt.Sprintf("Field '%s' must be a whole number between %.0f and %.0f", field, bounds[0], bounds[1]),
+				})
+				return
+			}
+			updates[field] = int(n)
+		}
 	}
 
 	err := h.userService.UpdatePreferences(ctx, userID, updates)
@@ -158,7 +186,7 @@ func (h *Handler) UpdateUserPreferences(c *gin.Context) {
 			zap.String("user_id", userID),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to update preferences",
 		})
 		return
@@ -172,15 +200,19 @@ func (h *Handler) UpdateUserPreferences(c *gin.Context) {
 // AddToWatchlist adds a symbol to user's watchlist
 func (h *Handler) AddToWatchlist(c *gin.Context) {
 	userID := middleware.GetUserID(c)
-	symbol := c.Param("symbol")
+	symbol := symbolParam(c)
 	ctx := c.Request.Context()
 
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeMissingParameter,
 			Error: "Symbol is required",
 		})
 		return
 	}
+	if !h.requireSymbol(c, symbol, "") {
+		return
+	}
 
 	err := h.userService.AddToWatchlist(ctx, userID, symbol)
 	if err != nil {
@@ -189,7 +221,7 @@ func (h *Handler) AddToWatchlist(c *gin.Context) {
 			zap.String("symbol", symbol),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to add to watchlist",
 		})
 		return
@@ -201,14 +233,149 @@ func (h *Handler) AddToWatchlist(c *gin.Context) {
 	})
 }
 
+// GetWatchlistPerformance returns how each watchlist symbol moved over the user's
+// default window, or the last ?days days
+func (h *Handler) GetWatchlistPerformance(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	prefs, err := h.userService.GetPreferences(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get user preferences",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get preferences",
+		})
+		return
+	}
+
+	defaults := h.queryDefaults(c)
+	days := defaults.WindowDays
+	if d := c.Query("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 1 || parsed > 3650 {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidParameter,
+				Error: "days must be between 1 and 3650",
+			})
+			return
+		}
+		days = parsed
+	}
+
+	var watchlist []string
+	if prefs != nil {
+		watchlist = prefs.Watchlist
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -days)
+	performance, err := h.marketService.Performance(ctx, watchlist, defaults.Source, startDate, endDate)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		h.logger.Error("Failed to compute watchlist performance",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to compute watchlist performance",
+		})
+		return
+	}
+	// Funds have no candles; summarize them from their NAVs instead
+	if err := h.navService.FillPerformance(ctx, performance, startDate, endDate); err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		h.logger.Error("Failed to compute fund performance",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to compute watchlist performance",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"source":      defaults.Source,
+		"start_date":  startDate.Format("2006-01-02"),
+		"end_date":    endDate.Format("2006-01-02"),
+		"performance": performance,
+	})
+}
+
+// GetWatchlistQuotes returns the latest daily candle and change for every symbol on
+// the user's watchlist in one request
+func (h *Handler) GetWatchlistQuotes(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	prefs, err := h.userService.GetPreferences(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get user preferences",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get preferences",
+		})
+		return
+	}
+
+	var watchlist []string
+	if prefs != nil {
+		watchlist = prefs.Watchlist
+	}
+
+	defaults := h.queryDefaults(c)
+	quotes, err := h.marketService.LatestQuotes(ctx, watchlist, defaults.Source)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		h.logger.Error("Failed to get watchlist quotes",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get watchlist quotes",
+		})
+		return
+	}
+
+	// Symbols without candles, such as mutual funds, are listed rather than dropped
+	quoted := make(map[string]bool, len(quotes))
+	for _, q := range quotes {
+		quoted[q.Symbol] = true
+	}
+	missing := []string{}
+	for _, symbol := range watchlist {
+		if !quoted[symbol] {
+			missing = append(missing, symbol)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"source":  defaults.Source,
+		"quotes":  quotes,
+		"missing": missing,
+	})
+}
+
 // RemoveFromWatchlist removes a symbol from user's watchlist
 func (h *Handler) RemoveFromWatchlist(c *gin.Context) {
 	userID := middleware.GetUserID(c)
-	symbol := c.Param("symbol")
+	symbol := symbolParam(c)
 	ctx := c.Request.Context()
 
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeMissingParameter,
 			Error: "Symbol is required",
 		})
 		return
@@ -221,7 +388,7 @@ func (h *Handler) RemoveFromWatchlist(c *gin.Context) {
 			zap.String("symbol", symbol),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to remove from watchlist",
 		})
 		return
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListBonds returns every bond's terms, soonest maturity first
+func (h *Handler) ListBonds(c *gin.Context) {
+	bonds, err := h.bondService.List(c.Request.Context())
+	if err != nil {
+		h.serviceError(c, "Failed to list bonds", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(bonds),
+		"bonds": bonds,
+	})
+}
+
+// GetBond returns a bond's terms and its valuation on ?date= (default today)
+func (h *Handler) GetBond(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+
+	date := time.Now().UTC().Truncate(24 * time.Hour)
+	if s := c.Query("date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		date = d
+	}
+
+	ctx := c.Request.Context()
+	bond, err := h.bondService.Get(ctx, symbol)
+	if err != nil {
+		h.serviceError(c, "Failed to get bond", err, zap.String("symbol", symbol))
+		return
+	}
+	valuations, err := h.bondService.Value(ctx, []string{symbol}, date)
+	if err != nil {
+		h.serviceError(c, "Failed to value bond", err, zap.String("symbol", symbol))
+		return
+	}
+	valuation := valuations[symbol]
+
+	c.JSON(http.StatusOK, gin.H{
+		"bond":      bond,
+		"valuation": valuation,
+	})
+}
+
+// CreateBond creates or replaces a bond and regenerates its coupon schedule
+func (h *Handler) CreateBond(c *gin.Context) {
+	var req models.BondRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	bond, err := h.bondService.Upsert(c.Request.Context(), req)
+	if err != nil {
+		h.serviceError(c, "Failed to store bond", err, zap.String("symbol", req.Symbol))
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Bond stored",
+		Data:    bond,
+	})
+}
+
+// GetBondCoupons returns a bond's coupon schedule
+func (h *Handler) GetBondCoupons(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+
+	coupons, err := h.bondService.Coupons(c.Request.Context(), symbol)
+	if err != nil {
+		h.serviceError(c, "Failed to get coupons", err, zap.String("symbol", symbol))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":  symbol,
+		"count":   len(coupons),
+		"coupons": coupons,
+	})
+}
+
+// UpdateBondCoupons resets the rate or dates of coupon periods, e.g. a floating SBR coupon
+func (h *Handler) UpdateBondCoupons(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+
+	var req models.UpdateCouponsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	coupons, err := h.bondService.UpdateCoupons(c.Request.Context(), symbol, req.Coupons)
+	if err != nil {
+		h.serviceError(c, "Failed to update coupons", err, zap.String("symbol", symbol))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":  symbol,
+		"count":   len(coupons),
+		"coupons": coupons,
+	})
+}
+
+// GetBondQuotes returns a bond's quotes over start_date..end_date, defaulting to the
+// user's window ending today
+func (h *Handler) GetBondQuotes(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+
+	endDate := time.Now().UTC().Truncate(24 * time.Hour)
+	startDate := endDate.AddDate(0, 0, -h.queryDefaults(c).WindowDays)
+	if s := c.Query("start_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid start_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		startDate = d
+	}
+	if s := c.Query("end_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid end_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = d
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.bondService.Get(ctx, symbol); err != nil {
+		h.serviceError(c, "Failed to get bond", err, zap.String("symbol", symbol))
+		return
+	}
+	quotes, err := h.bondService.Quotes(ctx, symbol, startDate, endDate)
+	if err != nil {
+		h.serviceError(c, "Failed to get bond quotes", err, zap.String("symbol", symbol))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":     symbol,
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"count":      len(quotes),
+		"quotes":     quotes,
+	})
+}
+
+// RecordBondQuote stores a day's quote given as a clean price or a yield
+func (h *Handler) RecordBondQuote(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+
+	var req models.BondQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	quote, err := h.bondService.RecordQuote(c.Request.Context(), symbol, req)
+	if err != nil {
+		h.serviceError(c, "Failed to store bond quote", err, zap.String("symbol", symbol))
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Bond quote stored",
+		Data:    quote,
+	})
+}
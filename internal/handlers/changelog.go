@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetChangelog returns the release notes, newest first. Signed-in callers also see
+// which releases they have seen; ?unseen=true keeps only the ones they have not.
+func (h *Handler) GetChangelog(c *gin.Context) {
+	changelog, err := h.changelogService.Get(c.Request.Context(), middleware.GetUserID(c), c.Query("unseen") == "true")
+	if err != nil {
+		h.serviceError(c, "Failed to load changelog", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, changelog)
+}
+
+// MarkChangelogSeen records that the caller has seen the releases up to a version,
+// the latest when the body is empty
+func (h *Handler) MarkChangelogSeen(c *gin.Context) {
+	var req models.ChangelogSeenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidRequestBody,
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	changelog, err := h.changelogService.MarkSeen(c.Request.Context(), middleware.GetUserID(c), req.Version)
+	if err != nil {
+		h.serviceError(c, "Failed to mark changelog seen", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, changelog)
+}
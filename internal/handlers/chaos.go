@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/chaos"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// chaosRulesRequest replaces the active fault injection rules
+type chaosRulesRequest struct {
+	Rules []chaos.Rule `json:"rules"`
+}
+
+// GetChaosRules lists the active fault injection rules
+func (h *Handler) GetChaosRules(c *gin.Context) {
+	rules := h.chaos.Rules()
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// SetChaosRules replaces the fault injection rules
+func (h *Handler) SetChaosRules(c *gin.Context) {
+	var req chaosRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.chaos.SetRules(req.Rules); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid rule",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.logger.Warn("Fault injection rules updated",
+		zap.String("user_id", middleware.GetUserID(c)),
+		zap.Int("rules", len(req.Rules)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": h.chaos.Rules(),
+		"count": len(req.Rules),
+	})
+}
+
+// ClearChaosRules stops all fault injection
+func (h *Handler) ClearChaosRules(c *gin.Context) {
+	_ = h.chaos.SetRules(nil)
+
+	h.logger.Warn("Fault injection rules cleared",
+		zap.String("user_id", middleware.GetUserID(c)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Fault injection disabled",
+	})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListCorporateActions returns corporate actions, newest ex-date first. ?status=pending_review
+// is the review queue; ?symbol= narrows to one parent symbol.
+func (h *Handler) ListCorporateActions(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.ActionPendingReview, models.ActionScheduled, models.ActionApplied, models.ActionRejected:
+	default:
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid status",
+			Message: "status must be pending_review, scheduled, applied or rejected",
+		})
+		return
+	}
+	symbol := strings.ToUpper(c.Query("symbol"))
+
+	actions, err := h.corporateActionService.List(c.Request.Context(), status, symbol)
+	if err != nil {
+		h.serviceError(c, "Failed to list corporate actions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(actions),
+		"actions": actions,
+	})
+}
+
+// GetCorporateAction returns an action and, once applied, the holdings it adjusted
+func (h *Handler) GetCorporateAction(c *gin.Context) {
+	id, ok := corporateActionID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	action, err := h.corporateActionService.Get(ctx, id)
+	if err != nil {
+		h.serviceError(c, "Failed to get corporate action", err)
+		return
+	}
+	adjustments, err := h.corporateActionService.Adjustments(ctx, id)
+	if err != nil {
+		h.serviceError(c, "Failed to get adjustments", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"action":      action,
+		"adjustments": adjustments,
+	})
+}
+
+// CreateCorporateAction records a rights issue or warrant distribution. It is applied
+// to holdings on its ex-date, or held for review when it is ambiguous.
+func (h *Handler) CreateCorporateAction(c *gin.Context) {
+	var req models.CorporateActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	action, err := h.corporateActionService.Create(c.Request.Context(), middleware.GetUserID(c), req)
+	if err != nil {
+		h.serviceError(c, "Failed to create corporate action", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, action)
+}
+
+// ApproveCorporateAction releases an action from the review queue
+func (h *Handler) ApproveCorporateAction(c *gin.Context) {
+	id, ok := corporateActionID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReviewActionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidRequestBody,
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	action, err := h.corporateActionService.Approve(c.Request.Context(), middleware.GetUserID(c), id, req)
+	if err != nil {
+		h.serviceError(c, "Failed to approve corporate action", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+// RejectCorporateAction discards an action before it is applied
+func (h *Handler) RejectCorporateAction(c *gin.Context) {
+	id, ok := corporateActionID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RejectActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	action, err := h.corporateActionService.Reject(c.Request.Context(), middleware.GetUserID(c), id, req.Note)
+	if err != nil {
+		h.serviceError(c, "Failed to reject corporate action", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+func corporateActionID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid corporate action id",
+		})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDeprecations reports the endpoints slated for removal and which clients still
+// call them (admin)
+func (h *Handler) GetDeprecations(c *gin.Context) {
+	usage, err := h.deprecationService.Report(c.Request.Context())
+	if err != nil {
+		h.serviceError(c, "Failed to report deprecated endpoints", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":        len(usage),
+		"deprecations": usage,
+	})
+}
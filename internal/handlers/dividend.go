@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// dividendWindowDays is how far ahead the dividend calendar and income projection
+// look by default
+const dividendWindowDays = 90
+
+// ListDividends returns the dividend calendar: dividends going ex between from and to,
+// defaulting to the next 90 days. ?symbol= narrows to one symbol.
+func (h *Handler) ListDividends(c *gin.Context) {
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	to := from.AddDate(0, 0, dividendWindowDays)
+	if s := c.Query("from"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid from format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		from = d
+	}
+	if s := c.Query("to"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid to format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		to = d
+	}
+	if to.Before(from) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDateRange,
+			Error: "to must not be before from",
+		})
+		return
+	}
+	symbol := strings.ToUpper(c.Query("symbol"))
+
+	dividends, err := h.dividendService.List(c.Request.Context(), symbol, from, to)
+	if err != nil {
+		h.serviceError(c, "Failed to list dividends", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":      from.Format("2006-01-02"),
+		"to":        to.Format("2006-01-02"),
+		"count":     len(dividends),
+		"dividends": dividends,
+	})
+}
+
+// GetDividend returns one dividend
+func (h *Handler) GetDividend(c *gin.Context) {
+	id, ok := dividendID(c)
+	if !ok {
+		return
+	}
+
+	dividend, err := h.dividendService.Get(c.Request.Context(), id)
+	if err != nil {
+		h.serviceError(c, "Failed to get dividend", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dividend)
+}
+
+// CreateDividend records an announced dividend, replacing any already recorded for
+// the same symbol and ex-date
+func (h *Handler) CreateDividend(c *gin.Context) {
+	var req models.DividendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	dividend, err := h.dividendService.Upsert(c.Request.Context(), middleware.GetUserID(c), req)
+	if err != nil {
+		h.serviceError(c, "Failed to create dividend", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dividend)
+}
+
+// UpdateDividend corrects a recorded dividend
+func (h *Handler) UpdateDividend(c *gin.Context) {
+	id, ok := dividendID(c)
+	if !ok {
+		return
+	}
+
+	var req models.DividendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	dividend, err := h.dividendService.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.serviceError(c, "Failed to update dividend", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dividend)
+}
+
+// DeleteDividend removes a dividend announced in error
+func (h *Handler) DeleteDividend(c *gin.Context) {
+	id, ok := dividendID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.dividendService.Delete(c.Request.Context(), id); err != nil {
+		h.serviceError(c, "Failed to delete dividend", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Dividend deleted",
+	})
+}
+
+// GetPortfolioDividends projects a portfolio's dividend income from its current
+// holdings over the next ?days days, 90 by default
+func (h *Handler) GetPortfolioDividends(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, ok := portfolioID(c)
+	if !ok {
+		return
+	}
+
+	days := dividendWindowDays
+	if s := c.Query("days"); s != "" {
+		d, err := strconv.Atoi(s)
+		if err != nil || d < 1 || d > 366 {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidParameter,
+				Error:   "Invalid days",
+				Message: "days must be between 1 and 366",
+			})
+			return
+		}
+		days = d
+	}
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	to := from.AddDate(0, 0, days)
+
+	projection, err := h.dividendService.Projected(c.Request.Context(), userID, id, from, to)
+	if err != nil {
+		h.serviceError(c, "Failed to project dividends", err, zap.String("user_id", userID))
+		return
+	}
+
+	c.JSON(http.StatusOK, projection)
+}
+
+func dividendID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid dividend id",
+		})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/scheduler"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// serviceErrorMapping is how a service error is answered. An empty title uses the
+// caller's message, and an empty message the error's text.
+type serviceErrorMapping struct {
+	err     error
+	status  int
+	code    apierror.Code
+	title   string
+	message string
+}
+
+// serviceErrors maps service sentinel errors to responses; anything unmatched is a 500
+var serviceErrors = []serviceErrorMapping{
+	{err: services.ErrSymbolNotFound, status: http.StatusNotFound, code: apierror.CodeSymbolNotFound, title: "Symbol not found"},
+	{err: services.ErrSymbolHasData, status: http.StatusConflict, code: apierror.CodeSymbolInUse, title: "Symbol still has market data",
+		message: "Delete its market data first"},
+	{err: services.ErrExchangeNotFound, status: http.StatusNotFound, code: apierror.CodeExchangeNotFound, title: "Exchange not found"},
+	{err: services.ErrHolidayNotFound, status: http.StatusNotFound, code: apierror.CodeHolidayNotFound, title: "Holiday not found"},
+	{err: services.ErrCalendarTooLong, status: http.StatusBadRequest, code: apierror.CodeInvalidDateRange, title: "Date range too large"},
+	{err: services.ErrHolidayOutsideYear, status: http.StatusBadRequest, code: apierror.CodeValidationFailed, title: "Invalid holiday calendar"},
+	{err: services.ErrDuplicateHoliday, status: http.StatusBadRequest, code: apierror.CodeValidationFailed, title: "Invalid holiday calendar"},
+	{err: services.ErrRangeTooLarge, status: http.StatusUnprocessableEntity, code: apierror.CodeInvalidDateRange, title: "Date range too large"},
+	{err: services.ErrInvalidCursor, status: http.StatusBadRequest, code: apierror.CodeInvalidCursor, title: "Invalid cursor"},
+	{err: services.ErrDuplicateRow, status: http.StatusConflict, code: apierror.CodeDuplicateRow, title: "Row already exists"},
+	{err: services.ErrImportInProgress, status: http.StatusConflict, code: apierror.CodeImportInProgress,
+		title: "Another import for this symbol is in progress"},
+	{err: services.ErrConfirmationInvalid, status: http.StatusConflict, code: apierror.CodeConfirmationFailed, title: "Invalid confirmation token"},
+	{err: services.ErrQuoteNotFound, status: http.StatusNotFound, code: apierror.CodeQuoteNotFound, title: "No quote available"},
+	{err: services.ErrSourceNotFound, status: http.StatusNotFound, code: apierror.CodeSourceNotFound, title: "Source not found"},
+	{err: services.ErrImportNotFound, status: http.StatusNotFound, code: apierror.CodeImportNotFound, title: "Import not found"},
+	{err: services.ErrExportNotFound, status: http.StatusNotFound, code: apierror.CodeExportNotFound, title: "Export not found"},
+	{err: services.ErrExportNotReady, status: http.StatusNotFound, code: apierror.CodeExportNotReady, title: "Export not ready"},
+	{err: services.ErrExportSignatureInvalid, status: http.StatusForbidden, code: apierror.CodeDownloadLinkInvalid, title: "Invalid download link"},
+	{err: services.ErrExportQuotaExceeded, status: http.StatusTooManyRequests, code: apierror.CodeExportQuotaExceeded, title: "Export quota exceeded"},
+	{err: services.ErrExportTooLarge, status: http.StatusRequestEntityTooLarge, code: apierror.CodePayloadTooLarge, title: "Export too large"},
+	{err: services.ErrLinkTokenInvalid, status: http.StatusBadRequest, code: apierror.CodeLinkTokenInvalid},
+	{err: services.ErrLinkSelf, status: http.StatusConflict, code: apierror.CodeAlreadyLinked},
+	{err: services.ErrAlreadyLinked, status: http.StatusConflict, code: apierror.CodeAlreadyLinked},
+	{err: services.ErrLinkNotFound, status: http.StatusNotFound, code: apierror.CodeLinkNotFound, title: "Link not found"},
+	{err: services.ErrPortfolioNotFound, status: http.StatusNotFound, code: apierror.CodePortfolioNotFound, title: "Portfolio not found"},
+	{err: services.ErrHoldingNotFound, status: http.StatusNotFound, code: apierror.CodeHoldingNotFound, title: "Holding not found"},
+	{err: services.ErrPortfolioExists, status: http.StatusConflict, code: apierror.CodePortfolioExists},
+	{err: services.ErrInsufficientHoldings, status: http.StatusConflict, code: apierror.CodeInsufficientHoldings, title: "Not enough held to sell"},
+	{err: services.ErrBeforeCorporateAction, status: http.StatusConflict, code: apierror.CodeBeforeCorporateAction,
+		title: "Holding was adjusted by a later corporate action"},
+	{err: services.ErrFutureEvent, status: http.StatusBadRequest, code: apierror.CodeInvalidDate, title: "Date is in the future"},
+	{err: services.ErrFXRateNotFound, status: http.StatusNotFound, code: apierror.CodeFXRateNotFound, title: "FX rate not found"},
+	{err: services.ErrInvalidFXRate, status: http.StatusBadRequest, code: apierror.CodeValidationFailed, title: "Invalid fx rate"},
+	{err: services.ErrBondNotFound, status: http.StatusNotFound, code: apierror.CodeBondNotFound, title: "Bond not found"},
+	{err: services.ErrCouponNotFound, status: http.StatusNotFound, code: apierror.CodeCouponNotFound, title: "Coupon period not found"},
+	{err: services.ErrInvalidBond, status: http.StatusBadRequest, code: apierror.CodeValidationFailed},
+	{err: services.ErrInvalidBondQuote, status: http.StatusBadRequest, code: apierror.CodeValidationFailed},
+	{err: services.ErrBondMatured, status: http.StatusBadRequest, code: apierror.CodeValidationFailed},
+	{err: services.ErrActionNotFound, status: http.StatusNotFound, code: apierror.CodeCorporateActionNotFound, title: "Corporate action not found"},
+	{err: services.ErrInvalidAction, status: http.StatusBadRequest, code: apierror.CodeValidationFailed},
+	{err: services.ErrActionNotReviewable, status: http.StatusConflict, code: apierror.CodeActionNotReviewable},
+	{err: services.ErrDividendNotFound, status: http.StatusNotFound, code: apierror.CodeDividendNotFound, title: "Dividend not found"},
+	{err: services.ErrInvalidDividend, status: http.StatusBadRequest, code: apierror.CodeValidationFailed},
+	{err: services.ErrFundamentalsNotFound, status: http.StatusNotFound, code: apierror.CodeFundamentalsNotFound, title: "Fundamentals not found",
+		message: "store snapshots with POST /api/v1/symbols/fundamentals"},
+	{err: services.ErrInvalidFundamentals, status: http.StatusBadRequest, code: apierror.CodeValidationFailed},
+	{err: services.ErrInvalidFinancials, status: http.StatusBadRequest, code: apierror.CodeValidationFailed},
+	{err: services.ErrInvalidRole, status: http.StatusBadRequest, code: apierror.CodeValidationFailed},
+	{err: services.ErrInvalidPermission, status: http.StatusBadRequest, code: apierror.CodeValidationFailed},
+	{err: services.ErrNoteNotFound, status: http.StatusNotFound, code: apierror.CodeNoteNotFound},
+	{err: services.ErrAttachmentNotFound, status: http.StatusNotFound, code: apierror.CodeAttachmentNotFound},
+	{err: services.ErrNoteReadOnly, status: http.StatusForbidden, code: apierror.CodeNoteReadOnly},
+	{err: services.ErrStrategyNotFound, status: http.StatusNotFound, code: apierror.CodeStrategyNotFound},
+	{err: services.ErrStrategyReadOnly, status: http.StatusForbidden, code: apierror.CodeStrategyReadOnly},
+	{err: services.ErrWebhookNotFound, status: http.StatusNotFound, code: apierror.CodeWebhookNotFound, title: "Webhook not found"},
+	{err: services.ErrDeliveryNotFound, status: http.StatusNotFound, code: apierror.CodeDeliveryNotFound, title: "Webhook delivery not found"},
+	{err: services.ErrReleaseNotFound, status: http.StatusNotFound, code: apierror.CodeReleaseNotFound, title: "Release not found",
+		message: "The changelog does not list that version"},
+	{err: services.ErrWebhookLimit, status: http.StatusConflict, code: apierror.CodeWebhookLimit, title: "Too many webhooks",
+		message: "Delete a webhook to register another"},
+	{err: services.ErrNoteNotShareable, status: http.StatusBadRequest, code: apierror.CodeValidationFailed},
+	{err: services.ErrNoOrganization, status: http.StatusForbidden, code: apierror.CodeNotInOrganization,
+		title: "Not in an organization"},
+	{err: services.ErrOutboxMessageNotFound, status: http.StatusNotFound, code: apierror.CodeOutboxMessageNotFound,
+		title: "Outbox message not found"},
+	{err: scheduler.ErrArchivePending, status: http.StatusConflict, code: apierror.CodeArchivePending,
+		title: "An archive run is already queued", message: "Wait for it to finish and try again"},
+	{err: services.ErrArchiveDisabled, status: http.StatusServiceUnavailable, code: apierror.CodeArchiveDisabled,
+		title: "Archiving is disabled", message: "Set ARCHIVE_AFTER_YEARS to archive old candles"},
+	{err: services.ErrIngestBufferFull, status: http.StatusServiceUnavailable, code: apierror.CodeIngestBufferFull,
+		title: "Database unavailable and ingest buffer full", message: "Retry once the database is back"},
+	{err: scheduler.ErrRefetchPending, status: http.StatusConflict, code: apierror.CodeRefetchPending,
+		title: "A refetch is already queued", message: "Wait for it to start and try again"},
+	{err: services.ErrEmptySearch, status: http.StatusBadRequest, code: apierror.CodeMissingParameter, title: "q is required"},
+}
+
+// respondError writes an error response carrying the request ID
+func respondError(c *gin.Context, status int, resp ErrorResponse) {
+	apierror.Respond(c, status, resp)
+}
+
+// mappedError answers err from serviceErrors, returning false when it has no mapping
+func mappedError(c *gin.Context, message string, err error) bool {
+	for _, m := range serviceErrors {
+		if !errors.Is(err, m.err) {
+			continue
+		}
+		resp := ErrorResponse{Code: m.code, Error: m.title, Message: m.message}
+		if resp.Error == "" {
+			resp.Error = message
+		}
+		if resp.Message == "" {
+			resp.Message = err.Error()
+		}
+		respondError(c, m.status, resp)
+		return true
+	}
+	return false
+}
+
+// serviceError answers a service error: its mapped response, 504 for an exhausted
+// request deadline, or a logged 500 with message
+func (h *Handler) serviceError(c *gin.Context, message string, err error, fields ...zap.Field) {
+	if mappedError(c, message, err) || h.deadlineExceeded(c, err, nil) {
+		return
+	}
+	h.logger.Error(message, append(fields, zap.Error(err))...)
+	respondError(c, http.StatusInternalServerError, ErrorResponse{
+		Error: message,
+	})
+}
@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListExchanges returns the configured exchanges with their trading hours
+func (h *Handler) ListExchanges(c *gin.Context) {
+	exchanges, err := h.exchangeService.List(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list exchanges", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list exchanges",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":     len(exchanges),
+		"exchanges": exchanges,
+	})
+}
+
+// GetExchange returns one exchange and whether it is in session at ?at= (default now)
+func (h *Handler) GetExchange(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+
+	at := time.Now()
+	if s := c.Query("at"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid at format",
+				Message: "Use RFC3339 (e.g. 2025-01-07T03:00:00Z)",
+			})
+			return
+		}
+		at = t
+	}
+
+	ctx := c.Request.Context()
+	exchange, err := h.exchangeService.Get(ctx, code)
+	if err != nil {
+		h.serviceError(c, "Failed to get exchange", err, zap.String("exchange", code))
+		return
+	}
+	status, err := h.exchangeService.Status(ctx, code, at)
+	if err != nil {
+		h.serviceError(c, "Failed to get exchange status", err, zap.String("exchange", code))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange": exchange,
+		"status":   status,
+	})
+}
+
+// GetExchangeCalendar lists trading days, holidays and session times for a date range,
+// defaulting to the next 30 days
+func (h *Handler) GetExchangeCalendar(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+
+	now := time.Now().UTC()
+	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 30)
+	if s := c.Query("start_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid start_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		startDate = d
+	}
+	if s := c.Query("end_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid end_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = d
+	}
+	if endDate.Before(startDate) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDateRange,
+			Error: "end_date must not be before start_date",
+		})
+		return
+	}
+
+	days, err := h.exchangeService.Calendar(c.Request.Context(), code, startDate, endDate)
+	if err != nil {
+		h.serviceError(c, "Failed to build calendar", err, zap.String("exchange", code))
+		return
+	}
+
+	trading := 0
+	for _, day := range days {
+		if day.Open {
+			trading++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange":     code,
+		"start_date":   startDate.Format("2006-01-02"),
+		"end_date":     endDate.Format("2006-01-02"),
+		"trading_days": trading,
+		"days":         days,
+	})
+}
+
+// AddExchangeHoliday closes an exchange for a day
+func (h *Handler) AddExchangeHoliday(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+
+	var req models.CreateHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidDate,
+			Error:   "Invalid date format",
+			Message: "Use format YYYY-MM-DD",
+		})
+		return
+	}
+
+	holiday, err := h.exchangeService.AddHoliday(c.Request.Context(), code, date, req.Name)
+	if err != nil {
+		h.serviceError(c, "Failed to add holiday", err, zap.String("exchange", code))
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Holiday added",
+		Data:    holiday,
+	})
+}
+
+// ListExchangeHolidays lists an exchange's holidays in ?year= (default this year)
+func (h *Handler) ListExchangeHolidays(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+
+	year := time.Now().UTC().Year()
+	if s := c.Query("year"); s != "" {
+		y, ok := holidayYear(c, s)
+		if !ok {
+			return
+		}
+		year = y
+	}
+
+	holidays, err := h.exchangeService.Holidays(c.Request.Context(), code, year)
+	if err != nil {
+		h.serviceError(c, "Failed to list holidays", err, zap.String("exchange", code))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange": code,
+		"year":     year,
+		"count":    len(holidays),
+		"holidays": holidays,
+	})
+}
+
+// UploadExchangeHolidays replaces an exchange's holidays for ?year= with a published
+// calendar, sent as JSON ({"holidays": [{"date", "name"}]}) or as a CSV file with
+// Date and Name columns. Closures the calendar leaves out reopen.
+func (h *Handler) UploadExchangeHolidays(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+
+	s := c.Query("year")
+	if s == "" {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeMissingParameter,
+			Error: "year is required",
+		})
+		return
+	}
+	year, ok := holidayYear(c, s)
+	if !ok {
+		return
+	}
+
+	var req models.UploadHolidaysRequest
+	if c.ContentType() == "multipart/form-data" {
+		if req, ok = holidaysFromCSV(c); !ok {
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	holidays := make([]models.ExchangeHoliday, 0, len(req.Holidays))
+	for i, r := range req.Holidays {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(r.Date))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid date format",
+				Message: fmt.Sprintf("Holiday %d: use format YYYY-MM-DD", i+1),
+			})
+			return
+		}
+		holidays = append(holidays, models.ExchangeHoliday{Exchange: code, Date: date, Name: strings.TrimSpace(r.Name)})
+	}
+
+	result, err := h.exchangeService.ReplaceHolidays(c.Request.Context(), code, year, holidays)
+	if err != nil {
+		h.serviceError(c, "Failed to upload holidays", err, zap.String("exchange", code), zap.Int("year", year))
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Holiday calendar replaced",
+		Data:    result,
+	})
+}
+
+// holidayYear parses a ?year= value, answering 400 and returning false when invalid
+func holidayYear(c *gin.Context, s string) (int, bool) {
+	year, err := strconv.Atoi(s)
+	if err != nil || year < 1900 || year > 2200 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid year",
+			Message: "year must be a four-digit year",
+		})
+		return 0, false
+	}
+	return year, true
+}
+
+// holidaysFromCSV reads an uploaded calendar with Date and Name columns after a header
+// row, answering 400 and returning false when it cannot be read
+func holidaysFromCSV(c *gin.Context) (models.UploadHolidaysRequest, bool) {
+	var req models.UploadHolidaysRequest
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeFileRequired,
+			Error: "No file uploaded",
+		})
+		return req, false
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidCSV,
+			Error:   "Failed to parse CSV",
+			Message: err.Error(),
+		})
+		return req, false
+	}
+	if len(records) == 0 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidCSV,
+			Error: "CSV file is empty",
+		})
+		return req, false
+	}
+
+	// An empty calendar (header only) reopens every day of the year
+	req.Holidays = []models.CreateHolidayRequest{}
+	for i, record := range records[1:] {
+		if len(record) < 2 || strings.TrimSpace(record[1]) == "" || len(strings.TrimSpace(record[1])) > 100 {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidCSV,
+				Error:   "Invalid holiday row",
+				Message: fmt.Sprintf("Row %d: date and a name of at most 100 characters are required", i+2),
+			})
+			return req, false
+		}
+		req.Holidays = append(req.Holidays, models.CreateHolidayRequest{Date: record[0], Name: record[1]})
+	}
+	return req, true
+}
+
+// DeleteExchangeHoliday reopens a day previously marked as a holiday
+func (h *Handler) DeleteExchangeHoliday(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+
+	date, err := time.Parse("2006-01-02", c.Param("date"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidDate,
+			Error:   "Invalid date format",
+			Message: "Use format YYYY-MM-DD",
+		})
+		return
+	}
+
+	if err := h.exchangeService.DeleteHoliday(c.Request.Context(), code, date); err != nil {
+		h.serviceError(c, "Failed to delete holiday", err, zap.String("exchange", code))
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Holiday deleted",
+	})
+}
+
+// GetTickSize returns an exchange's tick size bands and, for ?price=, the tick at
+// that price, whether the price is on it and the nearest valid prices either side
+func (h *Handler) GetTickSize(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+	bands := models.TickBands(code)
+	if bands == nil {
+		respondError(c, http.StatusNotFound, ErrorResponse{
+			Code:    apierror.CodeTickRulesNotFound,
+			Error:   "No tick size rules",
+			Message: fmt.Sprintf("%s prices are not restricted to ticks", code),
+		})
+		return
+	}
+
+	result := gin.H{
+		"exchange": code,
+		"bands":    bands,
+	}
+	if v := c.Query("price"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil || price <= 0 {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidParameter,
+				Error:   "Invalid price",
+				Message: "price must be a positive number",
+			})
+			return
+		}
+		tick, _ := models.TickSize(code, price)
+		down, up := models.RoundToTick(code, price)
+		result["price"] = price
+		result["tick_size"] = tick
+		result["valid"] = models.ValidateTick(code, price) == nil
+		result["round_down"] = down
+		result["round_up"] = up
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateExport queues an asynchronous export of market data
+func (h *Handler) CreateExport(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.CreateExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = services.ExportCSV
+	}
+	if !services.ValidExportFormat(format) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid format",
+			Message: "format must be csv, json or xlsx",
+		})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidDate,
+			Error:   "Invalid start_date",
+			Message: "Use format YYYY-MM-DD",
+		})
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidDate,
+			Error:   "Invalid end_date",
+			Message: "Use format YYYY-MM-DD",
+		})
+		return
+	}
+	if endDate.Before(startDate) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDateRange,
+			Error: "end_date must not be before start_date",
+		})
+		return
+	}
+
+	interval := req.Interval
+	if interval == "" {
+		interval = models.IntervalDaily
+	}
+	if !models.ValidInterval(interval) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid interval",
+			Message: "interval must be 1m, 5m, 1h or 1d",
+		})
+		return
+	}
+	exchange := strings.ToUpper(strings.TrimSpace(req.Exchange))
+
+	symbols := make([]string, len(req.Symbols))
+	for i, symbol := range req.Symbols {
+		symbols[i] = strings.ToUpper(strings.TrimSpace(symbol))
+	}
+
+	source := req.Source
+	switch source {
+	case "":
+		source = h.queryDefaults(c).Source
+	case services.SourceMerged:
+		source = services.MergedPreferring(h.queryDefaults(c).Preferred)
+	}
+
+	ctx := c.Request.Context()
+	job, usage, err := h.exportService.Create(ctx, userID, symbols, source, interval, exchange, startDate, endDate, format)
+	setQuotaHeaders(c, usage)
+	if err != nil {
+		h.serviceError(c, "Failed to create export", err, zap.String("user_id", userID))
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/exports/%d", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// setQuotaHeaders reports quota usage in X-RateLimit-* headers, adding
+// X-RateLimit-Warning once the warning threshold is reached and Retry-After once
+// nothing remains. A nil usage sends nothing.
+func setQuotaHeaders(c *gin.Context, usage *models.QuotaUsage) {
+	if usage == nil {
+		return
+	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(usage.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(usage.Remaining))
+	if usage.ResetAt != nil {
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(usage.ResetAt.Unix(), 10))
+		if usage.Remaining == 0 {
+			c.Header("Retry-After", strconv.Itoa(max(int(time.Until(*usage.ResetAt).Seconds())+1, 1)))
+		}
+	}
+	if usage.Warning {
+		c.Header("X-RateLimit-Warning", fmt.Sprintf("%d of %d daily %s used", usage.Used, usage.Limit, usage.Quota))
+	}
+}
+
+// GetExportQuota reports the user's daily export quota usage
+func (h *Handler) GetExportQuota(c *gin.Context) {
+	usage, err := h.exportService.Usage(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		h.serviceError(c, "Failed to get export quota", err)
+		return
+	}
+
+	setQuotaHeaders(c, usage)
+	c.JSON(http.StatusOK, usage)
+}
+
+// ListExports returns the user's recent export jobs
+func (h *Handler) ListExports(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	jobs, err := h.exportService.List(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to list exports",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list exports",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(jobs),
+		"exports": jobs,
+	})
+}
+
+// GetExport reports a job's status and, once completed, its signed download URL
+func (h *Handler) GetExport(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid export id",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	job, err := h.exportService.Get(ctx, userID, id)
+	if err != nil {
+		h.serviceError(c, "Failed to get export", err, zap.Int64("id", id))
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadExport streams a finished export; access is granted by the URL signature, not the session
+func (h *Handler) DownloadExport(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid export id",
+		})
+		return
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		mappedError(c, "Failed to open export", services.ErrExportSignatureInvalid)
+		return
+	}
+
+	ctx := c.Request.Context()
+	job, body, err := h.exportService.OpenSigned(ctx, id, expires, c.Query("signature"))
+	if err != nil {
+		h.serviceError(c, "Failed to open export", err, zap.Int64("id", id))
+		return
+	}
+	defer body.Close()
+
+	c.DataFromReader(http.StatusOK, job.SizeBytes, services.ExportContentType(job.Format), body, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="export-%d.%s"`, job.ID, job.Format),
+	})
+}
+
+// ExportMarketData streams a symbol's stored market data over start_date..end_date as a
+// csv, json or xlsx download (?format=, csv by default). The range defaults to the
+// user's window ending today and is not capped, since nothing is held in memory.
+func (h *Handler) ExportMarketData(c *gin.Context) {
+	symbol := strings.ToUpper(symbolParam(c))
+	format := strings.ToLower(c.DefaultQuery("format", services.ExportCSV))
+	if !services.ValidExportFormat(format) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid format",
+			Message: "format must be csv, json or xlsx",
+		})
+		return
+	}
+	interval, ok := intervalParam(c)
+	if !ok {
+		return
+	}
+	exchange := exchangeParam(c)
+	if !h.requireSymbol(c, symbol, exchange) {
+		return
+	}
+	ingestedBefore, ok := ingestedBeforeParam(c)
+	if !ok {
+		return
+	}
+
+	defaults := h.queryDefaults(c)
+	endDate := time.Now().UTC().Truncate(24 * time.Hour)
+	startDate := endDate.AddDate(0, 0, -defaults.WindowDays)
+	if s := c.Query("start_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid start_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		startDate = d
+	}
+	if s := c.Query("end_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid end_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = d
+	}
+	if endDate.Before(startDate) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDateRange,
+			Error: "end_date must not be before start_date",
+		})
+		return
+	}
+
+	// Headers go out with the first candle so an empty range can still answer 404
+	var w services.MarketDataWriter
+	filename := fmt.Sprintf("%s_%s_%s_%s.%s", symbol, interval,
+		startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), format)
+	count, err := h.marketService.StreamBySymbolAndDateRange(c.Request.Context(), symbol, defaults.Source, interval, exchange, startDate, endDate, ingestedBefore,
+		func(md models.MarketData) error {
+			if w == nil {
+				c.Header("Content-Type", services.ExportContentType(format))
+				c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+				c.Status(http.StatusOK)
+				mw, err := services.NewMarketDataWriter(c.Writer, format)
+				if err != nil {
+					return err
+				}
+				w = mw
+			}
+			return w.Write(md)
+		})
+	if w != nil {
+		if err == nil {
+			err = w.Close()
+		}
+		if err != nil {
+			// The status is already sent; a truncated file is all the client can get
+			h.logger.Error("Market data export interrupted",
+				zap.String("symbol", symbol),
+				zap.Int64("rows", count),
+				zap.Error(err),
+			)
+			c.Abort()
+		}
+		return
+	}
+
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) || h.cancelled(c, err, nil) {
+			return
+		}
+		h.logger.Error("Failed to export market data",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to export data",
+		})
+		return
+	}
+	respondError(c, http.StatusNotFound, ErrorResponse{
+		Code:  apierror.CodeMarketDataNotFound,
+		Error: "No data found for symbol",
+	})
+}
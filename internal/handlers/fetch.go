@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetFetchStatus reports the scheduled watchlist fetch: its schedule, when it next
+// runs and how each watchlisted equity last fared (admin)
+func (h *Handler) GetFetchStatus(c *gin.Context) {
+	statuses, err := h.watchlistFetcher.Status(c.Request.Context())
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		h.logger.Error("Failed to get fetch status", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get fetch status",
+		})
+		return
+	}
+
+	var schedule string
+	var nextRun *time.Time
+	if s := h.watchlistFetcher.Schedule(); s != nil {
+		schedule = s.String()
+		if next := s.Next(time.Now()); !next.IsZero() {
+			nextRun = &next
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  schedule != "",
+		"schedule": schedule,
+		"next_run": nextRun,
+		"count":    len(statuses),
+		"symbols":  statuses,
+	})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetFinancials returns a symbol's latest ?quarters= reports (default 8), newest
+// first, with PER and PBV at the latest close
+func (h *Handler) GetFinancials(c *gin.Context) {
+	// The route shares its first segment with /symbols/:exchange/:symbol
+	symbol := strings.ToUpper(c.Param("exchange"))
+	if !h.requireSymbol(c, symbol, exchangeParam(c)) {
+		return
+	}
+
+	quarters := 8
+	if v := c.Query("quarters"); v != "" {
+		if q, err := strconv.Atoi(v); err == nil && q > 0 && q <= 40 {
+			quarters = q
+		}
+	}
+
+	ctx := c.Request.Context()
+	reports, err := h.financialsService.List(ctx, symbol, quarters)
+	if err != nil {
+		h.serviceError(c, "Failed to get financials", err)
+		return
+	}
+	if len(reports) == 0 {
+		respondError(c, http.StatusNotFound, ErrorResponse{
+			Code:    apierror.CodeFinancialsNotFound,
+			Error:   "Financials not found",
+			Message: "store reports with POST /api/v1/symbols/financials",
+		})
+		return
+	}
+	ratios, err := h.financialsService.Ratios(ctx, []string{symbol})
+	if err != nil {
+		h.serviceError(c, "Failed to get financials", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":  symbol,
+		"ratios":  ratios[symbol],
+		"count":   len(reports),
+		"reports": reports,
+	})
+}
+
+// UpsertFinancials stores or replaces quarterly reports (admin)
+func (h *Handler) UpsertFinancials(c *gin.Context) {
+	var req models.UpsertFinancialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	reports, err := services.ParseFinancialInputs(req.Data)
+	if err != nil {
+		h.serviceError(c, "Invalid financial report", err)
+		return
+	}
+
+	inserted, updated, err := h.financialsService.Upsert(c.Request.Context(), reports)
+	if err != nil {
+		h.serviceError(c, "Failed to store financials", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Financials stored",
+		"inserted": inserted,
+		"updated":  updated,
+	})
+}
+
+// UploadFinancialsCSV imports quarterly reports (admin). Columns: Symbol, FiscalYear,
+// Quarter, PeriodEnd, Currency, Revenue, NetIncome, EPS, BookValue, Source; empty
+// figures are stored as not reported.
+func (h *Handler) UploadFinancialsCSV(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeFileRequired,
+			Error: "No file uploaded",
+		})
+		return
+	}
+	defer file.Close()
+
+	h.logger.Info("Processing financials CSV upload",
+		zap.String("filename", header.Filename),
+		zap.Int64("size", header.Size),
+	)
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidCSV,
+			Error:   "Failed to parse CSV",
+			Message: err.Error(),
+		})
+		return
+	}
+	if len(records) < 2 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidCSV,
+			Error: "CSV file is empty or has no data rows",
+		})
+		return
+	}
+
+	var reports []models.FinancialReport
+	var errs []string
+	for i, record := range records[1:] {
+		in, err := financialRecord(record)
+		if err == nil {
+			var parsed []models.FinancialReport
+			parsed, err = services.ParseFinancialInputs([]models.FinancialReportInput{in})
+			if err == nil {
+				reports = append(reports, parsed[0])
+				continue
+			}
+		}
+		errs = append(errs, fmt.Sprintf("Row %d: %v", i+2, err))
+	}
+
+	if len(reports) > 0 {
+		if _, _, err := h.financialsService.Upsert(c.Request.Context(), reports); err != nil {
+			h.serviceError(c, "Failed to import financials", err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.SymbolImportResponse{
+		Message:      "Financials imported",
+		RowsImported: len(reports),
+		RowsSkipped:  len(errs),
+		Errors:       errs,
+	})
+}
+
+// financialRecord reads one CSV row of UploadFinancialsCSV
+func financialRecord(record []string) (models.FinancialReportInput, error) {
+	field := func(i int) string {
+		if i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	amount := func(i int, name string) (*float64, error) {
+		if field(i) == "" {
+			return nil, nil
+		}
+		v, err := strconv.ParseFloat(field(i), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", name, field(i))
+		}
+		return &v, nil
+	}
+
+	in := models.FinancialReportInput{
+		Symbol:    field(0),
+		PeriodEnd: field(3),
+		Currency:  field(4),
+		Source:    field(9),
+	}
+	if in.Symbol == "" || in.PeriodEnd == "" {
+		return in, errors.New("symbol and period end columns are required")
+	}
+	var err error
+	if in.FiscalYear, err = strconv.Atoi(field(1)); err != nil || in.FiscalYear < 1900 || in.FiscalYear > 2200 {
+		return in, fmt.Errorf("invalid fiscal year %q", field(1))
+	}
+	if in.FiscalQuarter, err = strconv.Atoi(field(2)); err != nil || in.FiscalQuarter < 1 || in.FiscalQuarter > 4 {
+		return in, fmt.Errorf("invalid quarter %q", field(2))
+	}
+	if in.Currency != "" && len(in.Currency) != 3 {
+		return in, fmt.Errorf("invalid currency %q", in.Currency)
+	}
+	if in.Revenue, err = amount(5, "revenue"); err != nil {
+		return in, err
+	}
+	if in.NetIncome, err = amount(6, "net income"); err != nil {
+		return in, err
+	}
+	if in.EPS, err = amount(7, "EPS"); err != nil {
+		return in, err
+	}
+	if in.BookValue, err = amount(8, "book value"); err != nil {
+		return in, err
+	}
+	return in, nil
+}
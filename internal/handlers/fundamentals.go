@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFundamentals returns a symbol's latest shares outstanding and free float valued
+// at its latest close. With ?history=true it returns the daily market cap over
+// start_date..end_date, defaulting to the user's window ending today, along with the
+// snapshots in force.
+func (h *Handler) GetFundamentals(c *gin.Context) {
+	// The route shares its first segment with /symbols/:exchange/:symbol, so gin
+	// names the ticker "exchange" here
+	symbol := strings.ToUpper(c.Param("exchange"))
+	if !h.requireSymbol(c, symbol, exchangeParam(c)) {
+		return
+	}
+
+	if c.Query("history") != "true" {
+		h.latestFundamentals(c, symbol)
+		return
+	}
+
+	endDate := time.Now().UTC().Truncate(24 * time.Hour)
+	startDate := endDate.AddDate(0, 0, -h.queryDefaults(c).WindowDays)
+	if s := c.Query("start_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid start_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		startDate = d
+	}
+	if s := c.Query("end_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid end_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = d
+	}
+	if endDate.Before(startDate) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDateRange,
+			Error: "end_date must not be before start_date",
+		})
+		return
+	}
+
+	history, snapshots, err := h.fundamentalsService.MarketCapHistory(c.Request.Context(), symbol, startDate, endDate)
+	if err != nil {
+		h.serviceError(c, "Failed to get fundamentals history", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":     symbol,
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"snapshots":  snapshots,
+		"count":      len(history),
+		"data":       history,
+	})
+}
+
+func (h *Handler) latestFundamentals(c *gin.Context, symbol string) {
+	caps, err := h.fundamentalsService.LatestMarketCaps(c.Request.Context(), []string{symbol})
+	if err != nil {
+		h.serviceError(c, "Failed to get fundamentals", err)
+		return
+	}
+	latest, ok := caps[symbol]
+	if !ok {
+		h.serviceError(c, "Failed to get fundamentals", services.ErrFundamentalsNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, latest)
+}
+
+// UpsertFundamentals stores or replaces shares outstanding and free float snapshots
+// (admin)
+func (h *Handler) UpsertFundamentals(c *gin.Context) {
+	var req models.UpsertFundamentalsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	snapshots, err := services.ParseFundamentalsInputs(req.Data)
+	if err != nil {
+		h.serviceError(c, "Invalid fundamentals", err)
+		return
+	}
+
+	inserted, updated, err := h.fundamentalsService.Upsert(c.Request.Context(), snapshots)
+	if err != nil {
+		h.serviceError(c, "Failed to store fundamentals", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Fundamentals stored",
+		"inserted": inserted,
+		"updated":  updated,
+	})
+}
+
+// GetSectors sums the latest market caps of listings by sector, optionally on one
+// ?exchange=, with each sector's weight in the total
+func (h *Handler) GetSectors(c *gin.Context) {
+	sectors, err := h.fundamentalsService.Sectors(c.Request.Context(), exchangeParam(c))
+	if err != nil {
+		h.serviceError(c, "Failed to aggregate sectors", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(sectors),
+		"sectors": sectors,
+	})
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/clients/fxfeed"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFXRate returns the rate for a pair (e.g. USD-IDR) in force on ?date= (default today)
+func (h *Handler) GetFXRate(c *gin.Context) {
+	base, quote, err := services.ParsePair(c.Param("pair"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid currency pair",
+			Message: "Use BASE-QUOTE, e.g. USD-IDR",
+		})
+		return
+	}
+	date, ok := fxDate(c, c.Query("date"))
+	if !ok {
+		return
+	}
+
+	rate, err := h.fxService.Rate(c.Request.Context(), base, quote, date)
+	if err != nil {
+		h.serviceError(c, "Failed to get fx rate", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// ConvertCurrency converts an amount at the historical rate for its date
+func (h *Handler) ConvertCurrency(c *gin.Context) {
+	var req models.ConvertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	date, ok := fxDate(c, req.Date)
+	if !ok {
+		return
+	}
+
+	conversion, err := h.fxService.Convert(c.Request.Context(), req.Amount, req.From, req.To, date)
+	if err != nil {
+		h.serviceError(c, "Failed to convert amount", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conversion)
+}
+
+// UpsertFXRates stores or replaces rates (admin)
+func (h *Handler) UpsertFXRates(c *gin.Context) {
+	var req models.UpsertFXRatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	stored, err := h.fxService.Upsert(c.Request.Context(), req.Rates)
+	if err != nil {
+		h.serviceError(c, "Failed to store fx rates", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "FX rates stored",
+		"stored":  stored,
+	})
+}
+
+// FetchFXRates stores the latest rates of the configured pairs from the provider now
+// rather than at the next scheduled fetch (admin)
+func (h *Handler) FetchFXRates(c *gin.Context) {
+	stored, err := h.fxFetcher.Run(c.Request.Context())
+	if err != nil && len(stored) == 0 {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		switch {
+		case errors.Is(err, fxfeed.ErrNotConfigured):
+			respondError(c, http.StatusServiceUnavailable, ErrorResponse{
+				Code:    apierror.CodeProviderNotConfigured,
+				Error:   "FX rate provider is not configured",
+				Message: "Set FX_SOURCE_URL or store rates with POST /api/v1/fx/rates",
+			})
+		case errors.Is(err, fxfeed.ErrRateLimited):
+			c.Header("Retry-After", "60")
+			respondError(c, http.StatusServiceUnavailable, ErrorResponse{
+				Code:  apierror.CodeUpstreamRateLimited,
+				Error: "FX rate provider rate limit reached, try again later",
+			})
+		default:
+			respondError(c, http.StatusBadGateway, ErrorResponse{
+				Error:   "Failed to fetch fx rates from the provider",
+				Message: err.Error(),
+			})
+		}
+		return
+	}
+
+	response := gin.H{
+		"message": "FX rates fetched",
+		"stored":  len(stored),
+		"rates":   stored,
+		"source":  fxfeed.Source,
+	}
+	if err != nil {
+		// Some bases were stored before another failed
+		response["error"] = err.Error()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// fxDate parses an optional YYYY-MM-DD date, defaulting to today
+func fxDate(c *gin.Context, s string) (time.Time, bool) {
+	if s == "" {
+		return time.Now().UTC().Truncate(24 * time.Hour), true
+	}
+	date, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidDate,
+			Error:   "Invalid date format",
+			Message: "Use format YYYY-MM-DD",
+		})
+		return time.Time{}, false
+	}
+	return date, true
+}
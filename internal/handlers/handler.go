@@ -1,35 +1,153 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/chaos"
+	"github.com/ridhomain/proto-trading-service/internal/clients/fundnav"
+	"github.com/ridhomain/proto-trading-service/internal/datasource"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/provisioning"
+	"github.com/ridhomain/proto-trading-service/internal/scheduler"
 	"github.com/ridhomain/proto-trading-service/internal/services"
+	"github.com/ridhomain/proto-trading-service/internal/stream"
 	"github.com/ridhomain/proto-trading-service/pkg/logger"
 
+	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // Handler holds all handler dependencies
 type Handler struct {
-	marketService *services.MarketService
-	userService   *services.UserService
-	logger        *zap.Logger
+	marketService          *services.MarketService
+	userService            *services.UserService
+	feeService             *services.FeeService
+	sourceService          *services.SourceService
+	quoteService           *services.QuoteService
+	accountService         *services.AccountService
+	confirmationService    *services.ConfirmationService
+	exportService          *services.ExportService
+	anomalyService         *services.AnomalyService
+	portfolioService       *services.PortfolioService
+	exchangeService        *services.ExchangeService
+	fxService              *services.FXService
+	symbolService          *services.SymbolService
+	importService          *services.ImportService
+	navService             *services.NAVService
+	bondService            *services.BondService
+	corporateActionService *services.CorporateActionService
+	dividendService        *services.DividendService
+	fundamentalsService    *services.FundamentalsService
+	financialsService      *services.FinancialsService
+	rbacService            *services.RBACService
+	noteService            *services.NoteService
+	searchService          *services.SearchService
+	outboxService          *services.OutboxService
+	strategyService        *services.StrategyService
+	webhookService         *services.WebhookService
+	deprecationService     *services.DeprecationService
+	changelogService       *services.ChangelogService
+	offlineService         *services.OfflineService
+	readinessService       *services.ReadinessService
+	ingestBuffer           *services.IngestBuffer
+	dataSources            *datasource.Registry
+	fundNAVClient          *fundnav.Client
+	watchlistFetcher       *scheduler.WatchlistFetcher
+	fxFetcher              *scheduler.FXFetcher
+	archiver               *scheduler.Archiver
+	provisioner            *provisioning.Provisioner
+	hub                    *stream.Hub
+	chaos                  *chaos.Injector // nil unless fault injection is enabled
+	logger                 *zap.Logger
 }
 
 // NewHandler creates a new handler with all dependencies
-func NewHandler(marketService *services.MarketService, userService *services.UserService) *Handler {
+func NewHandler(marketService *services.MarketService, userService *services.UserService, feeService *services.FeeService, sourceService *services.SourceService, quoteService *services.QuoteService, accountService *services.AccountService, confirmationService *services.ConfirmationService, exportService *services.ExportService, anomalyService *services.AnomalyService, portfolioService *services.PortfolioService, exchangeService *services.ExchangeService, fxService *services.FXService, symbolService *services.SymbolService, importService *services.ImportService, navService *services.NAVService, bondService *services.BondService, corporateActionService *services.CorporateActionService, dividendService *services.DividendService, fundamentalsService *services.FundamentalsService, financialsService *services.FinancialsService, rbacService *services.RBACService, noteService *services.NoteService, searchService *services.SearchService, outboxService *services.OutboxService, strategyService *services.StrategyService, webhookService *services.WebhookService, deprecationService *services.DeprecationService, changelogService *services.ChangelogService, offlineService *services.OfflineService, readinessService *services.ReadinessService, ingestBuffer *services.IngestBuffer, dataSources *datasource.Registry, fundNAVClient *fundnav.Client, watchlistFetcher *scheduler.WatchlistFetcher, fxFetcher *scheduler.FXFetcher, archiver *scheduler.Archiver, provisioner *provisioning.Provisioner, hub *stream.Hub, injector *chaos.Injector) *Handler {
 	return &Handler{
-		marketService: marketService,
-		userService:   userService,
-		logger:        logger.With(zap.String("component", "handler")),
+		marketService:          marketService,
+		userService:            userService,
+		feeService:             feeService,
+		sourceService:          sourceService,
+		quoteService:           quoteService,
+		accountService:         accountService,
+		confirmationService:    confirmationService,
+		exportService:          exportService,
+		anomalyService:         anomalyService,
+		portfolioService:       portfolioService,
+		exchangeService:        exchangeService,
+		fxService:              fxService,
+		symbolService:          symbolService,
+		importService:          importService,
+		navService:             navService,
+		bondService:            bondService,
+		corporateActionService: corporateActionService,
+		dividendService:        dividendService,
+		fundamentalsService:    fundamentalsService,
+		financialsService:      financialsService,
+		rbacService:            rbacService,
+		noteService:            noteService,
+		searchService:          searchService,
+		outboxService:          outboxService,
+		strategyService:        strategyService,
+		webhookService:         webhookService,
+		deprecationService:     deprecationService,
+		changelogService:       changelogService,
+		offlineService:         offlineService,
+		readinessService:       readinessService,
+		ingestBuffer:           ingestBuffer,
+		dataSources:            dataSources,
+		fundNAVClient:          fundNAVClient,
+		watchlistFetcher:       watchlistFetcher,
+		fxFetcher:              fxFetcher,
+		archiver:               archiver,
+		provisioner:            provisioner,
+		hub:                    hub,
+		chaos:                  injector,
+		logger:                 logger.With(zap.String("component", "handler")),
 	}
 }
 
 // Common response types
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-}
+type ErrorResponse = apierror.Response
 
 type SuccessResponse struct {
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 }
+
+// deadlineExceeded answers 504 when err stems from an exhausted request budget,
+// reporting any partial progress made before the deadline
+func (h *Handler) deadlineExceeded(c *gin.Context, err error, partial gin.H) bool {
+	if !middleware.IsDeadlineExceeded(c, err) {
+		return false
+	}
+	middleware.RespondDeadlineExceeded(c, partial)
+	return true
+}
+
+// tooLarge answers 413 when err stems from an upload over the size limit, reporting
+// any rows committed before the limit was reached
+func (h *Handler) tooLarge(c *gin.Context, err error, partial gin.H) bool {
+	limit, ok := middleware.TooLarge(err)
+	if !ok {
+		return false
+	}
+	middleware.RespondTooLarge(c, limit, partial)
+	return true
+}
+
+// cancelled stops work for a client that has gone away. Nobody reads the
+// response, so it only logs the partial progress and records 499.
+func (h *Handler) cancelled(c *gin.Context, err error, partial gin.H) bool {
+	if !errors.Is(err, context.Canceled) {
+		return false
+	}
+	h.logger.Warn("Request cancelled by client",
+		zap.String("path", c.FullPath()),
+		zap.Any("completed", partial),
+	)
+	c.AbortWithStatus(499)
+	return true
+}
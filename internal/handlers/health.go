@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
 )
 
 // Health check endpoint
@@ -16,18 +17,20 @@ func (h *Handler) Health(c *gin.Context) {
 	})
 }
 
-// Ready check endpoint - checks database connection
+// Ready reports each readiness gate and answers 503 when a blocking one fails. Gates
+// not named in READY_GATES are reported without failing readiness, so reads stay in
+// rotation while, say, imports back up. The Kratos circuit breaker is reported too but
+// never fails readiness: public endpoints keep working during an outage.
 func (h *Handler) Ready(c *gin.Context) {
-	ctx := c.Request.Context()
-	if err := h.marketService.HealthCheck(ctx); err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error: "Database not ready",
-		})
-		return
-	}
+	readiness := h.readinessService.Check(c.Request.Context())
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":   "ready",
-		"database": "connected",
+	status, state := http.StatusOK, "ready"
+	if !readiness.Ready {
+		status, state = http.StatusServiceUnavailable, "not_ready"
+	}
+	c.JSON(status, gin.H{
+		"status": state,
+		"gates":  readiness.Gates,
+		"auth":   middleware.AuthBreakerStatus(),
 	})
 }
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateImport stores an uploaded CSV and queues it for import in the background;
+// it reads the same layouts and query options as UploadCSV, and each batch of rows
+// is committed on its own
+func (h *Handler) CreateImport(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	opts, ok := bulkOptions(c)
+	if !ok {
+		return
+	}
+	format, importOpts, ok := importOptions(c)
+	if !ok {
+		return
+	}
+
+	file, ok := h.uploadedFile(c)
+	if !ok {
+		return
+	}
+
+	h.logger.Info("Queueing CSV import",
+		zap.String("user_id", userID),
+		zap.String("filename", file.FileName()),
+	)
+
+	ctx := c.Request.Context()
+	job, err := h.importService.Create(ctx, userID, file.FileName(), file, opts.OnConflict, format, importOpts)
+	if err != nil {
+		if h.tooLarge(c, err, nil) || h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		h.logger.Error("Failed to create import",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to create import",
+		})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/upload/jobs/%d", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListImports returns the user's recent import jobs
+func (h *Handler) ListImports(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	jobs, err := h.importService.List(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to list imports",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list imports",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(jobs),
+		"imports": jobs,
+	})
+}
+
+// GetImport reports a job's progress, row counts and per-row errors
+func (h *Handler) GetImport(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid import id",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	job, err := h.importService.Get(ctx, userID, id)
+	if err != nil {
+		h.serviceError(c, "Failed to get import", err, zap.Int64("id", id))
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
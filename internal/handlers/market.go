@@ -2,13 +2,23 @@ package handlers
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/datasource"
+	"github.com/ridhomain/proto-trading-service/internal/importers"
 	"github.com/ridhomain/proto-trading-service/internal/middleware"
 	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -16,126 +26,525 @@ import (
 
 // MarketDataResponse represents the response for market data queries
 type MarketDataResponse struct {
-	Symbol string              `json:"symbol"`
-	Count  int                 `json:"count"`
-	Data   []models.MarketData `json:"data"`
+	Symbol      string                     `json:"symbol"`
+	Count       int                        `json:"count"`
+	Data        []models.MarketData        `json:"data"`
+	Sources     []string                   `json:"sources,omitempty"`
+	DataAsOf    *time.Time                 `json:"data_as_of,omitempty"`
+	Attribution []models.SourceAttribution `json:"attribution,omitempty"`
+	Adjusted    string                     `json:"adjusted,omitempty"` // splits or all when back-adjusted for corporate actions
+	Currency    string                     `json:"currency,omitempty"` // prices were converted into it
+
+	// Pagination, set by GetMarketData
+	Total      *int64 `json:"total,omitempty"`
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	Meta *ResponseMeta `json:"meta,omitempty"`
+}
+
+// ResponseMeta explains how a read was served; only sent with debug_meta=true
+type ResponseMeta struct {
+	TookMs       int64          `json:"took_ms"`
+	Cache        string         `json:"cache"` // hit, miss or bypass
+	SourceCounts map[string]int `json:"source_counts"`
+}
+
+// traceReads starts counting cache use for a read when the client asked for debug_meta
+func traceReads(c *gin.Context) {
+	if c.Query("debug_meta") != "true" {
+		return
+	}
+	ctx, trace := services.WithCacheTrace(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	c.Set("cache_trace", trace)
+}
+
+// responseMeta reports timing, cache status and rows per source when traceReads ran
+func responseMeta(c *gin.Context, data []models.MarketData) *ResponseMeta {
+	trace, exists := c.Get("cache_trace")
+	if !exists {
+		return nil
+	}
+
+	counts := map[string]int{}
+	for _, md := range data {
+		counts[md.Source]++
+	}
+
+	meta := &ResponseMeta{
+		Cache:        trace.(*services.CacheTrace).Status(),
+		SourceCounts: counts,
+	}
+	if start := middleware.GetRequestStart(c); !start.IsZero() {
+		meta.TookMs = time.Since(start).Milliseconds()
+	}
+	return meta
+}
+
+// marketDataResponse builds a MarketDataResponse with freshness headers and attribution for the sources served
+func (h *Handler) marketDataResponse(c *gin.Context, symbol string, data []models.MarketData) MarketDataResponse {
+	sources, asOf := setFreshnessHeaders(c, data)
+
+	attribution, err := h.sourceService.Attributions(c.Request.Context(), data)
+	if err != nil {
+		// Attribution is best-effort; never fail a read because of it
+		h.logger.Warn("Failed to load source attribution",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+	}
+
+	return MarketDataResponse{
+		Symbol:      symbol,
+		Count:       len(data),
+		Data:        data,
+		Sources:     sources,
+		DataAsOf:    asOf,
+		Attribution: attribution,
+		Meta:        responseMeta(c, data),
+	}
+}
+
+// setFreshnessHeaders sets X-Data-Source and X-Data-As-Of so clients can warn about stale data
+func setFreshnessHeaders(c *gin.Context, data []models.MarketData) ([]string, *time.Time) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	seen := map[string]bool{}
+	var sources []string
+	var asOf time.Time
+	for _, md := range data {
+		if !seen[md.Source] {
+			seen[md.Source] = true
+			sources = append(sources, md.Source)
+		}
+		if md.UpdatedAt.After(asOf) {
+			asOf = md.UpdatedAt
+		}
+	}
+	sort.Strings(sources)
+
+	c.Header("X-Data-Source", strings.Join(sources, ","))
+	c.Header("X-Data-As-Of", asOf.UTC().Format(time.RFC3339))
+
+	return sources, &asOf
+}
+
+// readDefaults are the source, page size and date window applied to a market data read
+type readDefaults struct {
+	Source     string // a source name, or services.SourceAny or a merged value to merge them
+	Preferred  string // the user's default source, which source=merged ranks first
+	Limit      int
+	WindowDays int
+}
+
+// queryDefaults resolves read defaults from explicit query parameters first, then the
+// user's preferences, then the service defaults. source=any merges every source by
+// priority, and source=merged does so ranking the user's default source first.
+func (h *Handler) queryDefaults(c *gin.Context) readDefaults {
+	defaults := readDefaults{Source: services.SourceAny, Limit: 30, WindowDays: 30}
+
+	userID := middleware.GetUserID(c)
+	if userID != "" {
+		prefs, err := h.userService.GetPreferences(c.Request.Context(), userID)
+		if err == nil && prefs != nil {
+			if prefs.DefaultSource != "" {
+				defaults.Source = prefs.DefaultSource
+				defaults.Preferred = prefs.DefaultSource
+			}
+			if prefs.DefaultLimit > 0 {
+				defaults.Limit = prefs.DefaultLimit
+			}
+			if prefs.DefaultWindow > 0 {
+				defaults.WindowDays = prefs.DefaultWindow
+			}
+		}
+	}
+
+	if source := c.Query("source"); source != "" {
+		defaults.Source = source
+	}
+	if defaults.Source == services.SourceMerged {
+		defaults.Source = services.MergedPreferring(defaults.Preferred)
+	}
+
+	return defaults
+}
+
+// intervalParam reads ?interval=1m|5m|1h|1d, defaulting to daily candles
+func intervalParam(c *gin.Context) (string, bool) {
+	interval := c.DefaultQuery("interval", models.IntervalDaily)
+	if !models.ValidInterval(interval) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid interval",
+			Message: "interval must be 1m, 5m, 1h or 1d",
+		})
+		return "", false
+	}
+	return interval, true
+}
+
+// ingestedBeforeParam reads ?ingested_before=, an RFC3339 timestamp limiting a read to
+// candles first stored before it; zero when absent
+func ingestedBeforeParam(c *gin.Context) (time.Time, bool) {
+	s := c.Query("ingested_before")
+	if s == "" {
+		return time.Time{}, true
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDate,
+			Error: "Invalid ingested_before format. Use RFC3339 (e.g. 2025-01-31T00:00:00Z)",
+		})
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// adjustParam reads ?adjust=none|splits|all, defaulting to prices as stored
+func adjustParam(c *gin.Context) (string, bool) {
+	mode := c.DefaultQuery("adjust", models.AdjustNone)
+	if !models.ValidAdjust(mode) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid adjust",
+			Message: "adjust must be none, splits or all",
+		})
+		return "", false
+	}
+	return mode, true
+}
+
+// currencyParam reads ?currency=, an ISO 4217 code amounts are converted into; ""
+// leaves them in their own currency
+func currencyParam(c *gin.Context) (string, bool) {
+	currency := strings.ToUpper(strings.TrimSpace(c.Query("currency")))
+	if currency != "" && !services.ValidCurrency(currency) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid currency",
+			Message: "currency must be a 3-letter code such as USD",
+		})
+		return "", false
+	}
+	return currency, true
+}
+
+// adjustedResponse builds the response for data back-adjusted as mode asks and,
+// with ?currency=, converted at each candle's date
+func (h *Handler) adjustedResponse(c *gin.Context, symbol, mode string, data []models.MarketData) (MarketDataResponse, bool) {
+	currency, ok := currencyParam(c)
+	if !ok {
+		return MarketDataResponse{}, false
+	}
+
+	ctx := c.Request.Context()
+	data, err := h.marketService.Adjust(ctx, symbol, mode, data)
+	if err != nil {
+		h.serviceError(c, "Failed to adjust data", err, zap.String("symbol", symbol))
+		return MarketDataResponse{}, false
+	}
+	if currency != "" {
+		if data, err = h.fxService.ConvertCandles(ctx, data, currency); err != nil {
+			h.serviceError(c, "Failed to convert data", err, zap.String("symbol", symbol))
+			return MarketDataResponse{}, false
+		}
+	}
+
+	response := h.marketDataResponse(c, symbol, data)
+	if mode != models.AdjustNone {
+		response.Adjusted = mode
+	}
+	response.Currency = currency
+	return response, true
+}
+
+// exchangeParam reads ?exchange=; "" reads every exchange listing the symbol
+func exchangeParam(c *gin.Context) string {
+	return strings.ToUpper(strings.TrimSpace(c.Query("exchange")))
 }
 
 // GetMarketData retrieves market data with query parameters
 func (h *Handler) GetMarketData(c *gin.Context) {
-	symbol := c.Query("symbol")
+	symbol := models.CanonicalSymbol(c.Query("symbol"))
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeMissingParameter,
 			Error: "symbol parameter is required",
 		})
 		return
 	}
 
-	// Parse limit with default
-	limit := 30
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
-			limit = l
+	interval, ok := intervalParam(c)
+	if !ok {
+		return
+	}
+	exchange := exchangeParam(c)
+	if !h.requireSymbol(c, symbol, exchange) {
+		return
+	}
+	adjust, ok := adjustParam(c)
+	if !ok {
+		return
+	}
+
+	traceReads(c)
+	defaults := h.queryDefaults(c)
+
+	// Parse page size with default; limit is kept as an alias of per_page
+	perPage := defaults.Limit
+	for _, param := range []string{"limit", "per_page"} {
+		if v := c.Query(param); v != "" {
+			if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 1000 {
+				perPage = l
+			}
+		}
+	}
+
+	page := 1
+	if v := c.Query("page"); v != "" {
+		if p, err := strconv.Atoi(v); err == nil && p > 0 {
+			page = p
 		}
 	}
 
-	// Get user preferences for default source
-	userID := middleware.GetUserID(c)
+	// A cursor from next_cursor continues keyset pagination and ignores page
+	cursor := c.Query("cursor")
+	if cursor != "" {
+		page = 0
+	}
+	if pageTooDeep(page, perPage) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Page too deep",
+			Message: fmt.Sprintf("page may skip at most %d rows; follow next_cursor with cursor= to read further", maxPageOffset),
+		})
+		return
+	}
+
 	ctx := c.Request.Context()
+	pageQuery := services.Page{Limit: perPage, Cursor: cursor, Source: defaults.Source, Interval: interval, Exchange: exchange}
+	if page > 0 {
+		pageQuery.Offset = (page - 1) * perPage
+	}
 
-	// Get user's preferred data source
-	source := c.Query("source")
-	if source == "" && userID != "" {
-		prefs, err := h.userService.GetPreferences(ctx, userID)
-		if err == nil && prefs != nil {
-			source = prefs.DefaultSource
-		}
+	data, next, err := h.marketService.GetBySymbol(ctx, symbol, pageQuery)
+	if err != nil {
+		h.serviceError(c, "Failed to fetch data", err, zap.String("symbol", symbol))
+		return
 	}
 
-	data, err := h.marketService.GetBySymbol(ctx, symbol, limit)
+	total, err := h.marketService.CountBySymbol(ctx, symbol, defaults.Source, interval, exchange)
 	if err != nil {
-		h.logger.Error("Failed to fetch market data",
-			zap.String("symbol", symbol),
-			zap.Error(err),
-		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: "Failed to fetch data",
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to count data",
 		})
 		return
 	}
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	setLinkHeader(c, offsetPageLinks(page, perPage, total, next))
 
-	c.JSON(http.StatusOK, MarketDataResponse{
-		Symbol: symbol,
-		Count:  len(data),
-		Data:   data,
-	})
+	response, ok := h.adjustedResponse(c, symbol, adjust, data)
+	if !ok {
+		return
+	}
+	response.Total = &total
+	response.Page = page
+	response.PerPage = perPage
+	response.NextCursor = next
+
+	c.JSON(http.StatusOK, response)
 }
 
 // GetMarketDataBySymbol retrieves market data for a specific symbol
 func (h *Handler) GetMarketDataBySymbol(c *gin.Context) {
-	symbol := c.Param("symbol")
+	symbol := symbolParam(c)
 
 	// Parse date range if provided
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
+	asOfStr := c.Query("as_of")
 
+	interval, ok := intervalParam(c)
+	if !ok {
+		return
+	}
+	exchange := exchangeParam(c)
+	if !h.requireSymbol(c, symbol, exchange) {
+		return
+	}
+	ingestedBefore, ok := ingestedBeforeParam(c)
+	if !ok {
+		return
+	}
+	adjust, ok := adjustParam(c)
+	if !ok {
+		return
+	}
+	if !ingestedBefore.IsZero() && asOfStr != "" {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "as_of and ingested_before cannot be combined",
+			Message: "as_of already leaves out candles stored after it",
+		})
+		return
+	}
+
+	traceReads(c)
 	ctx := c.Request.Context()
+	defaults := h.queryDefaults(c)
 
-	if startDateStr != "" && endDateStr != "" {
-		startDate, err := time.Parse("2006-01-02", startDateStr)
+	var startDate, endDate time.Time
+	hasRange := startDateStr != "" && endDateStr != ""
+	if hasRange {
+		var err error
+		startDate, err = time.Parse("2006-01-02", startDateStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidDate,
 				Error: "Invalid start_date format. Use YYYY-MM-DD",
 			})
 			return
 		}
 
-		endDate, err := time.Parse("2006-01-02", endDateStr)
+		endDate, err = time.Parse("2006-01-02", endDateStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidDate,
 				Error: "Invalid end_date format. Use YYYY-MM-DD",
 			})
 			return
 		}
+	}
 
-		data, err := h.marketService.GetBySymbolAndDateRange(ctx, symbol, startDate, endDate)
+	// Reconstruct the data as it was stored at a point in time
+	if asOfStr != "" {
+		asOf, err := time.Parse(time.RFC3339, asOfStr)
 		if err != nil {
-			h.logger.Error("Failed to fetch market data by date range",
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidDate,
+				Error: "Invalid as_of format. Use RFC3339 (e.g. 2025-01-31T00:00:00Z)",
+			})
+			return
+		}
+
+		// Default: the window leading up to as_of
+		if !hasRange {
+			endDate = asOf
+			startDate = asOf.AddDate(0, 0, -defaults.WindowDays)
+		}
+
+		if !h.checkRange(c, symbol, defaults.Source, interval, exchange, startDate, endDate) {
+			return
+		}
+
+		data, err := h.marketService.GetBySymbolAsOf(ctx, symbol, defaults.Source, interval, exchange, startDate, endDate, asOf)
+		if err != nil {
+			if h.deadlineExceeded(c, err, nil) {
+				return
+			}
+			h.logger.Error("Failed to fetch market data as of timestamp",
 				zap.String("symbol", symbol),
+				zap.Time("as_of", asOf),
 				zap.Error(err),
 			)
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
+			respondError(c, http.StatusInternalServerError, ErrorResponse{
 				Error: "Failed to fetch data",
 			})
 			return
 		}
 
-		c.JSON(http.StatusOK, MarketDataResponse{
-			Symbol: symbol,
-			Count:  len(data),
-			Data:   data,
-		})
+		if response, ok := h.adjustedResponse(c, symbol, adjust, data); ok {
+			c.JSON(http.StatusOK, response)
+		}
+		return
+	}
+
+	// Default: the user's date window ending today
+	if !hasRange {
+		endDate = time.Now()
+		startDate = endDate.AddDate(0, 0, -defaults.WindowDays)
+	}
+
+	if !h.checkRange(c, symbol, defaults.Source, interval, exchange, startDate, endDate) {
 		return
 	}
 
-	// Default: get latest 30 days
-	data, err := h.marketService.GetBySymbol(ctx, symbol, 30)
+	data, err := h.marketService.GetBySymbolAndDateRange(ctx, symbol, defaults.Source, interval, exchange, startDate, endDate, ingestedBefore)
 	if err != nil {
-		h.logger.Error("Failed to fetch market data",
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		h.logger.Error("Failed to fetch market data by date range",
 			zap.String("symbol", symbol),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to fetch data",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, MarketDataResponse{
-		Symbol: symbol,
-		Count:  len(data),
-		Data:   data,
+	if response, ok := h.adjustedResponse(c, symbol, adjust, data); ok {
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// checkRange refuses date ranges estimated to return more rows than are served
+// synchronously, answering 422 with a smaller span and a pointer to an export: the
+// export API for daily candles, the streamed export for intraday ones
+func (h *Handler) checkRange(c *gin.Context, symbol, source, interval, exchange string, startDate, endDate time.Time) bool {
+	estimate, err := h.marketService.CheckRange(c.Request.Context(), []string{symbol}, source, interval, exchange, startDate, endDate)
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, services.ErrRangeTooLarge) {
+		exportURL := "/api/v1/exports"
+		via := "POST " + exportURL
+		if interval != models.IntervalDaily {
+			query := url.Values{
+				"interval":   {interval},
+				"start_date": {startDate.Format("2006-01-02")},
+				"end_date":   {endDate.Format("2006-01-02")},
+			}
+			exportURL = "/api/v1/market-data/" + url.PathEscape(symbol) + "/export?" + query.Encode()
+			via = "GET " + exportURL
+		}
+		respondError(c, http.StatusUnprocessableEntity, ErrorResponse{
+			Code:    apierror.CodeInvalidDateRange,
+			Error:   "Date range too large",
+			Message: fmt.Sprintf("%s; request at most %d days at a time or use %s", err.Error(), estimate.ChunkDays, via),
+			Details: gin.H{
+				"estimated_rows": estimate.EstimatedRows,
+				"max_rows":       estimate.MaxRows,
+				"chunk_days":     estimate.ChunkDays,
+				"chunks":         estimate.Chunks,
+				"export_url":     exportURL,
+			},
+		})
+		return false
+	}
+
+	if h.deadlineExceeded(c, err, nil) {
+		return false
+	}
+	h.logger.Error("Failed to estimate date range",
+		zap.String("symbol", symbol),
+		zap.Error(err),
+	)
+	respondError(c, http.StatusInternalServerError, ErrorResponse{
+		Error: "Failed to fetch data",
 	})
+	return false
 }
 
 // CreateMarketData creates a new market data entry
@@ -143,21 +552,49 @@ func (h *Handler) CreateMarketData(c *gin.Context) {
 	var data models.MarketData
 
 	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
 			Error:   "Invalid request body",
 			Message: err.Error(),
 		})
 		return
 	}
+	if err := data.Normalize(); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !validOHLC(c, []models.MarketData{data}) || !validManualTicks(c, []models.MarketData{data}) {
+		return
+	}
+
+	accepted, report, ok := h.screen(c, []models.MarketData{data})
+	if !ok {
+		return
+	}
+	if len(accepted) == 0 {
+		respondError(c, http.StatusUnprocessableEntity, ErrorResponse{
+			Code:    apierror.CodeAnomalyRejected,
+			Error:   "Data rejected by anomaly policy",
+			Details: gin.H{"screening": report},
+		})
+		return
+	}
 
 	ctx := c.Request.Context()
-	result, err := h.marketService.Create(ctx, data)
+	result, err := h.marketService.Create(ctx, accepted[0])
 	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
 		h.logger.Error("Failed to create market data",
 			zap.String("symbol", data.Symbol),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to create data",
 		})
 		return
@@ -171,203 +608,927 @@ func (h *Handler) BulkCreateMarketData(c *gin.Context) {
 	var req models.BulkCreateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
 			Error:   "Invalid request body",
 			Message: err.Error(),
 		})
 		return
 	}
+	if err := models.NormalizeAll(req.Data); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !validOHLC(c, req.Data) || !validManualTicks(c, req.Data) {
+		return
+	}
+
+	opts, ok := bulkOptions(c)
+	if !ok {
+		return
+	}
+
+	// Symbols with buffered ingests take new data behind them, in order
+	if h.ingestBuffer.Holds(req.Data) {
+		h.bufferIngest(c, req.Data, opts, false, gin.H{"reason": "earlier ingests of these symbols await replay"})
+		return
+	}
 
 	ctx := c.Request.Context()
-	err := h.marketService.BulkCreateWithConflict(ctx, req.Data)
+	accepted, report, err := h.anomalyService.Screen(ctx, req.Data)
 	if err != nil {
+		if h.ingestBuffer.Absorbs(err) {
+			h.bufferIngest(c, req.Data, opts, false, gin.H{"reason": "database unavailable"})
+			return
+		}
+		h.screenFailed(c, err, len(req.Data))
+		return
+	}
+
+	result, err := h.marketService.BulkCreateWithConflict(ctx, accepted, opts)
+	if err != nil {
+		partial := gin.H{"rows_received": len(req.Data), "chunks": result.Chunks,
+			"chunks_committed": result.ChunksCommitted, "rows_committed": result.RowsCommitted}
+		if h.deadlineExceeded(c, err, partial) || h.cancelled(c, err, partial) {
+			return
+		}
+		if writeConflict(c, err, result) {
+			return
+		}
+		// Chunks committed before the database went away are kept; the rest wait
+		if h.ingestBuffer.Absorbs(err) {
+			partial["reason"] = "database unavailable"
+			h.bufferIngest(c, accepted[result.RowsCommitted:], opts, true, partial)
+			return
+		}
 		h.logger.Error("Failed to bulk create market data",
 			zap.Int("count", len(req.Data)),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to bulk create data",
+			Details: gin.H{
+				"chunks":           result.Chunks,
+				"chunks_committed": result.ChunksCommitted,
+				"rows_committed":   result.RowsCommitted,
+			},
 		})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Data created successfully",
-		"count":   len(req.Data),
+		"message":   "Data created successfully",
+		"count":     len(accepted),
+		"inserted":  result.Inserted,
+		"updated":   result.Updated,
+		"skipped":   result.Skipped,
+		"chunks":    result.Chunks,
+		"screening": report,
 	})
 }
 
-// FetchYahooData fetches data from Yahoo Finance (mock for now)
+// bufferIngest holds a bulk ingest in the write-ahead log for replay and answers 202,
+// or 503 when the log is full
+func (h *Handler) bufferIngest(c *gin.Context, data []models.MarketData, opts services.BulkOptions, screened bool, details gin.H) {
+	seq, pending, err := h.ingestBuffer.Buffer(data, opts.OnConflict, screened)
+	if err != nil {
+		h.serviceError(c, "Failed to buffer ingest", err, zap.Int("count", len(data)))
+		return
+	}
+	h.logger.Warn("Bulk ingest buffered for replay",
+		zap.Uint64("seq", seq),
+		zap.Int("count", len(data)),
+		zap.Any("reason", details["reason"]),
+	)
+
+	body := gin.H{
+		"message":  "Data buffered for replay",
+		"count":    len(data),
+		"buffered": true,
+		"sequence": seq,
+		"pending":  pending,
+	}
+	for k, v := range details {
+		body[k] = v
+	}
+	c.JSON(http.StatusAccepted, body)
+}
+
+// validOHLC answers 400 listing every candle whose high and low do not bound it
+func validOHLC(c *gin.Context, data []models.MarketData) bool {
+	var invalid []gin.H
+	for i := range data {
+		if err := data[i].ValidateOHLC(); err != nil {
+			invalid = append(invalid, gin.H{"item": i, "symbol": data[i].Symbol, "timestamp": data[i].Timestamp, "error": err.Error()})
+		}
+	}
+	if len(invalid) == 0 {
+		return true
+	}
+
+	message := invalid[0]["error"].(string)
+	if len(data) > 1 {
+		message = fmt.Sprintf("%d of %d items are inconsistent; first is item %d: %s", len(invalid), len(data), invalid[0]["item"], message)
+	}
+	respondError(c, http.StatusBadRequest, ErrorResponse{
+		Code:    apierror.CodeInvalidOHLC,
+		Error:   "Inconsistent OHLC",
+		Message: message,
+		Details: gin.H{"invalid": invalid},
+	})
+	return false
+}
+
+// validManualTicks answers 400 when a manually entered candle has a price off its
+// exchange's tick size. Fetched and imported prices are not checked, since adjusted
+// history need not fall on today's ticks.
+func validManualTicks(c *gin.Context, data []models.MarketData) bool {
+	for i := range data {
+		if data[i].Source != "manual" {
+			continue
+		}
+		if err := data[i].ValidateTicks(); err != nil {
+			message := err.Error()
+			if len(data) > 1 {
+				message = fmt.Sprintf("item %d: %s", i, message)
+			}
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidTickSize,
+				Error:   "Invalid tick size",
+				Message: message,
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// bulkOptions reads ?commit=chunk, which commits each chunk of a bulk write on its own
+// so a failure or cancellation keeps the chunks already written, and
+// ?on_conflict=update|skip|error for rows that already exist
+func bulkOptions(c *gin.Context) (services.BulkOptions, bool) {
+	opts := services.BulkOptions{OnConflict: services.ConflictUpdate}
+	if policy := c.Query("on_conflict"); policy != "" {
+		if !services.ValidConflictPolicy(policy) {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidParameter,
+				Error:   "Invalid on_conflict",
+				Message: "on_conflict must be update, skip or error",
+			})
+			return opts, false
+		}
+		opts.OnConflict = policy
+	}
+
+	switch c.Query("commit") {
+	case "", "all":
+	case "chunk":
+		opts.CommitPerChunk = true
+	default:
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid commit mode",
+			Message: "commit must be all or chunk",
+		})
+		return opts, false
+	}
+	return opts, true
+}
+
+// importOptions reads how an uploaded CSV is laid out: ?format= names a registered
+// export format (detected from the header when omitted), and ?symbol= and ?interval=
+// fill in what single-instrument exports leave out
+func importOptions(c *gin.Context) (string, importers.Options, bool) {
+	format := strings.ToLower(c.Query("format"))
+	if !importers.Valid(format) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid format",
+			Message: "format must be auto or one of " + strings.Join(importers.Formats(), ", "),
+		})
+		return "", importers.Options{}, false
+	}
+	if format == "auto" {
+		format = ""
+	}
+
+	interval, ok := intervalParam(c)
+	if !ok {
+		return "", importers.Options{}, false
+	}
+	return format, importers.Options{
+		Symbol:   strings.ToUpper(strings.TrimSpace(c.Query("symbol"))),
+		Interval: interval,
+	}, true
+}
+
+// writeConflict answers 409 when on_conflict=error hit an existing row or another
+// import of the same symbol held its lock past the timeout
+func writeConflict(c *gin.Context, err error, result models.BulkResult) bool {
+	var message string
+	var code apierror.Code
+	switch {
+	case errors.Is(err, services.ErrDuplicateRow):
+		message, code = "Row already exists", apierror.CodeDuplicateRow
+	case errors.Is(err, services.ErrImportInProgress):
+		message, code = "Another import for this symbol is in progress", apierror.CodeImportInProgress
+		c.Header("Retry-After", "5")
+	default:
+		return false
+	}
+	respondError(c, http.StatusConflict, ErrorResponse{
+		Code:    code,
+		Error:   message,
+		Message: err.Error(),
+		Details: gin.H{
+			"chunks":           result.Chunks,
+			"chunks_committed": result.ChunksCommitted,
+			"rows_committed":   result.RowsCommitted,
+		},
+	})
+	return true
+}
+
+// FetchYahooData fetches daily candles from Yahoo Finance
 func (h *Handler) FetchYahooData(c *gin.Context) {
-	symbol := c.Param("symbol")
+	h.fetchFrom(c, "yahoo", c.Param("symbol"))
+}
+
+// FetchBinanceData fetches candles for a crypto pair, written BTC-USDT or BTCUSDT, from
+// Binance; ?interval= fetches 1m, 5m or 1h candles instead of daily ones
+func (h *Handler) FetchBinanceData(c *gin.Context) {
+	h.fetchFrom(c, "binance", c.Param("symbol"))
+}
 
-	// Optional query parameters
+// FetchMarketData fetches candles from the registered data source ?source= names
+// (yahoo by default)
+func (h *Handler) FetchMarketData(c *gin.Context) {
+	name := c.DefaultQuery("source", "yahoo")
+	if _, ok := h.dataSources.Get(name); !ok {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Unknown data source",
+			Message: "source must be one of " + strings.Join(h.dataSources.Names(), ", "),
+		})
+		return
+	}
+	h.fetchFrom(c, name, c.Param("symbol"))
+}
+
+// maxFetchDays bounds how far back one fetch reaches per interval, keeping an
+// intraday fetch to about 10,000 candles a symbol
+var maxFetchDays = map[string]int{
+	models.Interval1m:    7,
+	models.Interval5m:    30,
+	models.Interval1h:    365,
+	models.IntervalDaily: 365,
+}
+
+// fetchFrom fetches the last ?days days (1-365, default 7) of ?interval= candles
+// (daily by default) for symbol from the registered source name and stores them.
+// Intraday fetches reach back at most 7 days of 1m and 30 days of 5m candles.
+func (h *Handler) fetchFrom(c *gin.Context, name, symbol string) {
+	source, ok := h.dataSources.Get(name)
+	if !ok {
+		h.logger.Error("Data source not registered", zap.String("source", name))
+		respondError(c, http.StatusServiceUnavailable, ErrorResponse{
+			Code:  apierror.CodeProviderNotConfigured,
+			Error: name + " is not available",
+		})
+		return
+	}
+
+	interval, ok := intervalParam(c)
+	if !ok {
+		return
+	}
 	days := 7
 	if daysStr := c.Query("days"); daysStr != "" {
 		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 && d <= 365 {
 			days = d
 		}
 	}
+	if days > maxFetchDays[interval] {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Fetch window too long",
+			Message: fmt.Sprintf("%s candles can be fetched for at most %d days at a time", interval, maxFetchDays[interval]),
+		})
+		return
+	}
 
-	h.logger.Info("Fetching Yahoo Finance data",
+	h.logger.Info("Fetching market data",
+		zap.String("source", source.Name()),
 		zap.String("symbol", symbol),
+		zap.String("interval", interval),
 		zap.Int("days", days),
 	)
 
-	// TODO: Implement actual Yahoo Finance API call
-	// For now, generate mock data
 	endDate := time.Now()
-	mockData := make([]models.MarketData, days)
-
-	for i := 0; i < days; i++ {
-		date := endDate.AddDate(0, 0, -i)
-		mockData[i] = models.MarketData{
-			Symbol: symbol,
-			Date:   date,
-			Open:   8500 + float64(i*10),
-			High:   8600 + float64(i*10),
-			Low:    8400 + float64(i*10),
-			Close:  8550 + float64(i*10),
-			Volume: 12500000 + int64(i*100000),
-			Source: "yahoo",
+	data, symbol, err := h.dataSources.Fetch(c.Request.Context(), name, symbol, interval, endDate.AddDate(0, 0, -days), endDate)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
 		}
+		switch {
+		case errors.Is(err, datasource.ErrIntervalNotSupported):
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidParameter,
+				Error:   source.DisplayName() + " does not offer " + interval + " candles",
+				Message: err.Error(),
+			})
+		case errors.Is(err, datasource.ErrSymbolNotFound):
+			respondError(c, http.StatusNotFound, ErrorResponse{
+				Code:  apierror.CodeUpstreamSymbolNotFound,
+				Error: "Symbol not found on " + source.DisplayName(),
+			})
+		case errors.Is(err, datasource.ErrRateLimited):
+			c.Header("Retry-After", "60")
+			respondError(c, http.StatusServiceUnavailable, ErrorResponse{
+				Code:  apierror.CodeUpstreamRateLimited,
+				Error: source.DisplayName() + " rate limit reached, try again later",
+			})
+		case errors.Is(err, datasource.ErrNotConfigured):
+			respondError(c, http.StatusServiceUnavailable, ErrorResponse{
+				Code:    apierror.CodeProviderNotConfigured,
+				Error:   source.DisplayName() + " is not configured",
+				Message: err.Error(),
+			})
+		default:
+			h.logger.Error("Failed to fetch market data",
+				zap.String("source", source.Name()),
+				zap.String("symbol", symbol),
+				zap.Error(err),
+			)
+			respondError(c, http.StatusBadGateway, ErrorResponse{
+				Error:   "Failed to fetch data from " + source.DisplayName(),
+				Message: err.Error(),
+			})
+		}
+		return
 	}
 
-	ctx := c.Request.Context()
-	err := h.marketService.BulkCreate(ctx, mockData)
+	h.storeFetched(c, symbol, source.Name(), data)
+}
+
+// storeFetched screens and upserts candles pulled from an upstream source
+func (h *Handler) storeFetched(c *gin.Context, symbol, source string, data []models.MarketData) {
+	accepted, report, ok := h.screen(c, data)
+	if !ok {
+		return
+	}
+
+	result, err := h.marketService.BulkCreateWithConflict(c.Request.Context(), accepted, services.BulkOptions{})
 	if err != nil {
-		h.logger.Error("Failed to save Yahoo data",
+		partial := gin.H{"rows_fetched": len(data), "rows_saved": result.RowsCommitted}
+		if h.deadlineExceeded(c, err, partial) || h.cancelled(c, err, partial) {
+			return
+		}
+		if writeConflict(c, err, result) {
+			return
+		}
+		h.logger.Error("Failed to save fetched data",
 			zap.String("symbol", symbol),
+			zap.String("source", source),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to save data",
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Data fetched successfully",
-		"symbol":  symbol,
-		"count":   len(mockData),
-		"source":  "yahoo",
+		"message":   "Data fetched successfully",
+		"symbol":    symbol,
+		"count":     len(accepted),
+		"inserted":  result.Inserted,
+		"updated":   result.Updated,
+		"source":    source,
+		"screening": report,
 	})
 }
 
-// DeleteMarketData deletes market data for a symbol
+// DeleteMarketData deletes market data for a symbol in two steps: without a
+// confirm token it only reports the impact and issues a token; with it, it deletes
 func (h *Handler) DeleteMarketData(c *gin.Context) {
-	symbol := c.Param("symbol")
+	symbol := symbolParam(c)
+	userID := middleware.GetUserID(c)
+	token := c.Query("confirm")
 
 	ctx := c.Request.Context()
-	err := h.marketService.Delete(ctx, symbol)
+	if token == "" {
+		count, err := h.marketService.CountBySymbol(ctx, symbol, "", "", "")
+		if err != nil {
+			if h.deadlineExceeded(c, err, nil) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to estimate delete impact",
+			})
+			return
+		}
+		if count == 0 {
+			respondError(c, http.StatusNotFound, ErrorResponse{
+				Code:  apierror.CodeMarketDataNotFound,
+				Error: "No data found for symbol",
+			})
+			return
+		}
+
+		confirmation, err := h.confirmationService.Issue(ctx, userID, services.ActionDeleteMarketData, symbol, count)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to issue confirmation token",
+			})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":      "Confirmation required: repeat the request with ?confirm=<confirmation_token>",
+			"confirmation": confirmation,
+		})
+		return
+	}
+
+	err := h.confirmationService.Consume(ctx, token, userID, services.ActionDeleteMarketData, symbol)
 	if err != nil {
+		h.serviceError(c, "Failed to verify confirmation token", err)
+		return
+	}
+
+	deleted, err := h.marketService.Delete(ctx, symbol)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
 		h.logger.Error("Failed to delete market data",
 			zap.String("symbol", symbol),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to delete data",
 		})
 		return
 	}
 
+	h.logger.Info("Confirmed market data delete",
+		zap.String("symbol", symbol),
+		zap.String("user_id", userID),
+		zap.Int64("rows_affected", deleted),
+	)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Data deleted successfully",
-		"symbol":  symbol,
+		"message":       "Data deleted successfully",
+		"symbol":        symbol,
+		"rows_affected": deleted,
 	})
 }
 
-// UploadCSV handles CSV file uploads
+const (
+	// uploadBatchSize is how many parsed CSV rows are screened and stored at a time
+	uploadBatchSize = 5000
+	// maxUploadRowErrors caps the per-row errors reported for an upload
+	maxUploadRowErrors = 1000
+)
+
+// UploadCSV handles CSV file uploads in any registered export format (see importOptions).
+// The file is streamed: every uploadBatchSize rows are screened and stored on their own,
+// so a failure keeps the batches before it.
 func (h *Handler) UploadCSV(c *gin.Context) {
-	file, header, err := c.Request.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "No file uploaded",
-		})
+	opts, ok := bulkOptions(c)
+	if !ok {
+		return
+	}
+
+	format, importOpts, ok := importOptions(c)
+	if !ok {
+		return
+	}
+
+	file, ok := h.uploadedFile(c)
+	if !ok {
 		return
 	}
-	defer file.Close()
 
 	h.logger.Info("Processing CSV upload",
-		zap.String("filename", header.Filename),
-		zap.Int64("size", header.Size),
+		zap.String("filename", file.FileName()),
 	)
 
-	// Parse CSV
 	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+		if h.tooLarge(c, err, nil) {
+			return
+		}
+		if errors.Is(err, io.EOF) {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidCSV,
+				Error: "CSV file is empty or has no data rows",
+			})
+			return
+		}
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidCSV,
 			Error:   "Failed to parse CSV",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	if len(records) < 2 {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "CSV file is empty or has no data rows",
+	parse, format, err := importers.Open(format, header, importOpts)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidCSV,
+			Error:   "Unsupported CSV layout",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	// Process records (skip header)
-	var marketData []models.MarketData
-	var errors []string
+	ctx := c.Request.Context()
+	var (
+		rows     int
+		accepted int
+		result   models.BulkResult
+		report   = &models.ScreenReport{Anomalies: []models.Anomaly{}}
+		rowErrs  []string
+		batch    = make([]models.MarketData, 0, uploadBatchSize)
+	)
+
+	// flush screens and stores the pending batch, answering the request itself on failure
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		screened, batchReport, ok := h.screen(c, batch)
+		if !ok {
+			return false
+		}
+		report.Merge(batchReport)
+		batch = batch[:0]
+		if len(screened) == 0 {
+			return true
+		}
+
+		batchResult, err := h.marketService.BulkCreateWithConflict(ctx, screened, opts)
+		result.Chunks += batchResult.Chunks
+		result.ChunksCommitted += batchResult.ChunksCommitted
+		result.RowsCommitted += batchResult.RowsCommitted
+		result.Add(batchResult)
+		if err == nil {
+			accepted += len(screened)
+			return true
+		}
+
+		partial := gin.H{"rows_parsed": rows, "rows_imported": result.RowsCommitted,
+			"chunks": result.Chunks, "chunks_committed": result.ChunksCommitted, "errors": rowErrs}
+		if h.deadlineExceeded(c, err, partial) || h.cancelled(c, err, partial) {
+			return false
+		}
+		if writeConflict(c, err, result) {
+			return false
+		}
+		h.logger.Error("Failed to import CSV data",
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to import data",
+			Details: gin.H{
+				"chunks":           result.Chunks,
+				"chunks_committed": result.ChunksCommitted,
+				"rows_imported":    result.RowsCommitted,
+			},
+		})
+		return false
+	}
 
-	for i, record := range records[1:] {
-		if len(record) < 7 {
-			errors = append(errors, fmt.Sprintf("Row %d: insufficient columns", i+2))
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				partial := gin.H{"rows_parsed": rows, "rows_imported": result.RowsCommitted,
+					"chunks": result.Chunks, "chunks_committed": result.ChunksCommitted, "errors": rowErrs}
+				if h.tooLarge(c, err, partial) || h.deadlineExceeded(c, err, partial) || h.cancelled(c, err, partial) {
+					return
+				}
+				respondError(c, http.StatusBadRequest, ErrorResponse{
+					Error:   "Failed to read upload",
+					Message: err.Error(),
+				})
+				return
+			}
+			// A malformed row does not stop the upload
+			rows++
+			if len(rowErrs) < maxUploadRowErrors {
+				rowErrs = append(rowErrs, fmt.Sprintf("Row %d: %v", rows+1, err))
+			}
 			continue
 		}
 
-		// Parse date
-		date, err := time.Parse("2006-01-02", record[1])
+		rows++
+		md, err := parse(record)
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("Row %d: invalid date format", i+2))
+			if len(rowErrs) < maxUploadRowErrors {
+				rowErrs = append(rowErrs, fmt.Sprintf("Row %d: %v", rows+1, err))
+			}
 			continue
 		}
+		batch = append(batch, md)
+
+		if len(batch) == uploadBatchSize && !flush() {
+			return
+		}
+	}
+	if !flush() {
+		return
+	}
 
-		// Parse numeric values
-		open, _ := strconv.ParseFloat(record[2], 64)
-		high, _ := strconv.ParseFloat(record[3], 64)
-		low, _ := strconv.ParseFloat(record[4], 64)
-		close, _ := strconv.ParseFloat(record[5], 64)
-		volume, _ := strconv.ParseInt(record[6], 10, 64)
+	if rows == 0 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidCSV,
+			Error: "CSV file is empty or has no data rows",
+		})
+		return
+	}
+
+	response := models.CSVUploadResponse{
+		Message:          "CSV processed successfully",
+		Format:           format,
+		RowsImported:     result.Inserted + result.Updated,
+		RowsSkipped:      rows - accepted,
+		Inserted:         result.Inserted,
+		Updated:          result.Updated,
+		ConflictsSkipped: result.Skipped,
+		Errors:           rowErrs,
+		Screening:        report,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
 
-		marketData = append(marketData, models.MarketData{
-			Symbol: record[0],
-			Date:   date,
-			Open:   open,
-			High:   high,
-			Low:    low,
-			Close:  close,
-			Volume: volume,
-			Source: "mirae",
+// uploadedFile returns the "file" part of a multipart upload as a stream, so large
+// files are neither held in memory nor spooled to temporary files
+func (h *Handler) uploadedFile(c *gin.Context) (*multipart.Part, bool) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeFileRequired,
+			Error: "No file uploaded",
 		})
+		return nil, false
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeFileRequired,
+				Error: "No file uploaded",
+			})
+			return nil, false
+		}
+		if err != nil {
+			if h.tooLarge(c, err, nil) {
+				return nil, false
+			}
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidRequestBody,
+				Error:   "Invalid multipart body",
+				Message: err.Error(),
+			})
+			return nil, false
+		}
+		if part.FormName() == "file" {
+			return part, true
+		}
+	}
+}
+
+// GetMarketDataProfile returns per-column statistics so data quality can be
+// assessed before pulling a full export
+func (h *Handler) GetMarketDataProfile(c *gin.Context) {
+	symbol := symbolParam(c)
+	source := h.queryDefaults(c).Source
+	interval, ok := intervalParam(c)
+	if !ok {
+		return
+	}
+	exchange := exchangeParam(c)
+	if !h.requireSymbol(c, symbol, exchange) {
+		return
+	}
+
+	var startDate, endDate *time.Time
+	if s := c.Query("start_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid start_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		startDate = &d
+	}
+	if s := c.Query("end_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid end_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = &d
 	}
 
-	// Bulk insert
 	ctx := c.Request.Context()
-	if len(marketData) > 0 {
-		err = h.marketService.BulkCreateWithConflict(ctx, marketData)
+	profile, err := h.marketService.Profile(ctx, symbol, startDate, endDate, source, interval, exchange)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		h.logger.Error("Failed to profile market data",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to profile data",
+		})
+		return
+	}
+	if profile.Rows == 0 {
+		respondError(c, http.StatusNotFound, ErrorResponse{
+			Code:  apierror.CodeMarketDataNotFound,
+			Error: "No data found for symbol",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// GetMarketDataGaps lists the trading days without a daily candle between a symbol's
+// first and last stored ones, or within start_date..end_date, skipping weekends and
+// holidays of its exchange
+func (h *Handler) GetMarketDataGaps(c *gin.Context) {
+	symbol := symbolParam(c)
+	source := h.queryDefaults(c).Source
+	exchange := exchangeParam(c)
+	if exchange == "" {
+		exchange = models.ExchangeForSymbol(strings.ToUpper(symbol))
+	}
+	if !h.requireSymbol(c, symbol, exchange) {
+		return
+	}
+
+	var startDate, endDate *time.Time
+	if s := c.Query("start_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
 		if err != nil {
-			h.logger.Error("Failed to import CSV data",
-				zap.Error(err),
-			)
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error: "Failed to import data",
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid start_date format",
+				Message: "Use format YYYY-MM-DD",
 			})
 			return
 		}
+		startDate = &d
+	}
+	if s := c.Query("end_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid end_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = &d
 	}
 
-	response := models.CSVUploadResponse{
-		Message:      "CSV processed successfully",
-		RowsImported: len(marketData),
-		RowsSkipped:  len(records) - 1 - len(marketData),
-		Errors:       errors,
+	ctx := c.Request.Context()
+	stored, err := h.marketService.StoredDates(ctx, symbol, exchange, source, startDate, endDate)
+	if err != nil {
+		h.serviceError(c, "Failed to find gaps", err, zap.String("symbol", symbol))
+		return
+	}
+	if len(stored) == 0 {
+		respondError(c, http.StatusNotFound, ErrorResponse{
+			Code:  apierror.CodeMarketDataNotFound,
+			Error: "No daily data found for symbol",
+		})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	from, to := stored[0], stored[len(stored)-1]
+	if startDate != nil {
+		from = *startDate
+	}
+	if endDate != nil {
+		to = *endDate
+	}
+	openDays, err := h.exchangeService.OpenDays(ctx, exchange, from, to)
+	if err != nil {
+		h.serviceError(c, "Failed to load trading calendar", err, zap.String("exchange", exchange))
+		return
+	}
+	gaps, missing := services.FindGaps(openDays, stored)
+
+	c.JSON(http.StatusOK, models.GapReport{
+		Symbol:      symbol,
+		Exchange:    exchange,
+		Source:      source,
+		StartDate:   from.Format("2006-01-02"),
+		EndDate:     to.Format("2006-01-02"),
+		TradingDays: len(openDays),
+		StoredDays:  len(openDays) - missing,
+		MissingDays: missing,
+		Gaps:        gaps,
+	})
+}
+
+// GetMarketDataAggregate serves weekly or monthly candles built from daily ones, over
+// start_date..end_date or the user's default window ending today
+func (h *Handler) GetMarketDataAggregate(c *gin.Context) {
+	symbol := symbolParam(c)
+	period := c.Query("interval")
+	if !models.ValidPeriod(period) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid interval",
+			Message: "interval must be weekly or monthly",
+		})
+		return
+	}
+	exchange := exchangeParam(c)
+	if !h.requireSymbol(c, symbol, exchange) {
+		return
+	}
+	defaults := h.queryDefaults(c)
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -defaults.WindowDays)
+	if s := c.Query("start_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid start_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		startDate = d
+	}
+	if s := c.Query("end_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid end_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = d
+	}
+
+	candles, err := h.marketService.Aggregate(c.Request.Context(), symbol, defaults.Source, exchange, period, startDate, endDate)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		h.logger.Error("Failed to aggregate market data",
+			zap.String("symbol", symbol),
+			zap.String("interval", period),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to aggregate data",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   symbol,
+		"interval": period,
+		"count":    len(candles),
+		"data":     candles,
+	})
 }
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/clients/fundnav"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetNAV returns a fund's daily NAVs over start_date..end_date, defaulting to the
+// user's window ending today. ?source= picks one source; by default each day comes
+// from the highest-priority source.
+func (h *Handler) GetNAV(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	if !h.requireSymbol(c, symbol, models.ExchangeFund) {
+		return
+	}
+
+	endDate := time.Now().UTC().Truncate(24 * time.Hour)
+	startDate := endDate.AddDate(0, 0, -h.queryDefaults(c).WindowDays)
+	if s := c.Query("start_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid start_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		startDate = d
+	}
+	if s := c.Query("end_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid end_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = d
+	}
+	if endDate.Before(startDate) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDateRange,
+			Error: "end_date must not be before start_date",
+		})
+		return
+	}
+
+	source := c.Query("source")
+	if source == services.SourceMerged {
+		source = services.MergedPreferring(h.queryDefaults(c).Preferred)
+	}
+	navs, err := h.navService.GetBySymbolsAndDateRange(c.Request.Context(), []string{symbol}, source, startDate, endDate)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get NAVs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":     symbol,
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"count":      len(navs),
+		"data":       navs,
+	})
+}
+
+// UpsertNAV stores or replaces fund NAVs, listing new funds on the FUND exchange
+func (h *Handler) UpsertNAV(c *gin.Context) {
+	var req models.UpsertNAVRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	navs, err := services.ParseNAVInputs(req.Data)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid NAV",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	inserted, updated, err := h.navService.Upsert(c.Request.Context(), navs)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to store NAVs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "NAVs stored",
+		"inserted": inserted,
+		"updated":  updated,
+	})
+}
+
+// FetchFundNAV pulls the last ?days days (default 30) of a fund's NAVs from the
+// configured fund data provider
+func (h *Handler) FetchFundNAV(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+
+	days := 30
+	if daysStr := c.Query("days"); daysStr != "" {
+		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 && d <= 365 {
+			days = d
+		}
+	}
+
+	h.logger.Info("Fetching fund NAVs",
+		zap.String("symbol", symbol),
+		zap.Int("days", days),
+	)
+
+	ctx := c.Request.Context()
+	endDate := time.Now()
+	navs, err := h.fundNAVClient.FetchDaily(ctx, symbol, endDate.AddDate(0, 0, -days), endDate)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		switch {
+		case errors.Is(err, fundnav.ErrNotConfigured):
+			respondError(c, http.StatusServiceUnavailable, ErrorResponse{
+				Code:    apierror.CodeProviderNotConfigured,
+				Error:   "Fund NAV provider is not configured",
+				Message: "Set FUND_NAV_API_BASE_URL or store NAVs with POST /api/v1/nav",
+			})
+		case errors.Is(err, fundnav.ErrFundNotFound):
+			respondError(c, http.StatusNotFound, ErrorResponse{
+				Code:  apierror.CodeUpstreamSymbolNotFound,
+				Error: "Fund not found at the NAV provider",
+			})
+		case errors.Is(err, fundnav.ErrRateLimited):
+			c.Header("Retry-After", "60")
+			respondError(c, http.StatusServiceUnavailable, ErrorResponse{
+				Code:  apierror.CodeUpstreamRateLimited,
+				Error: "NAV provider rate limit reached, try again later",
+			})
+		default:
+			h.logger.Error("Failed to fetch fund NAVs",
+				zap.String("symbol", symbol),
+				zap.Error(err),
+			)
+			respondError(c, http.StatusBadGateway, ErrorResponse{
+				Error:   "Failed to fetch NAVs from the provider",
+				Message: err.Error(),
+			})
+		}
+		return
+	}
+
+	var inserted, updated int
+	if len(navs) > 0 {
+		inserted, updated, err = h.navService.Upsert(ctx, navs)
+		if err != nil {
+			if h.deadlineExceeded(c, err, nil) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to save NAVs",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "NAVs fetched successfully",
+		"symbol":   symbol,
+		"count":    len(navs),
+		"inserted": inserted,
+		"updated":  updated,
+		"source":   fundnav.Source,
+	})
+}
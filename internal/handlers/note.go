@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSymbolNotes returns the notes on a symbol the user wrote or that were shared
+// within their organization; ?q= searches titles and bodies
+func (h *Handler) ListSymbolNotes(c *gin.Context) {
+	// The route shares its first segment with /symbols/:exchange/:symbol
+	symbol := strings.ToUpper(c.Param("exchange"))
+	if !h.requireSymbol(c, symbol, exchangeParam(c)) {
+		return
+	}
+	h.listNotes(c, symbol)
+}
+
+// SearchNotes searches every note the user can read (?q=), optionally on one ?symbol=
+func (h *Handler) SearchNotes(c *gin.Context) {
+	h.listNotes(c, strings.ToUpper(c.Query("symbol")))
+}
+
+func (h *Handler) listNotes(c *gin.Context, symbol string) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	notes, err := h.noteService.List(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserOrganization(c), services.NoteFilter{
+		Symbol: symbol,
+		Query:  strings.TrimSpace(c.Query("q")),
+		Limit:  limit,
+	})
+	if err != nil {
+		h.serviceError(c, "Failed to list notes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(notes),
+		"notes": notes,
+	})
+}
+
+// CreateSymbolNote writes a markdown note on a symbol
+func (h *Handler) CreateSymbolNote(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("exchange"))
+	if !h.requireSymbol(c, symbol, exchangeParam(c)) {
+		return
+	}
+
+	var req models.CreateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	note, err := h.noteService.Create(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserOrganization(c), symbol, req)
+	if err != nil {
+		h.serviceError(c, "Failed to create note", err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/notes/%d", note.ID))
+	c.JSON(http.StatusCreated, note)
+}
+
+// GetNote returns a note with its attachments
+func (h *Handler) GetNote(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+
+	note, err := h.noteService.Get(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserOrganization(c), id)
+	if err != nil {
+		h.serviceError(c, "Failed to get note", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
+// UpdateNote changes a note's title, body or sharing; only its author can
+func (h *Handler) UpdateNote(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	note, err := h.noteService.Update(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserOrganization(c), id, req)
+	if err != nil {
+		h.serviceError(c, "Failed to update note", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
+// DeleteNote removes a note and its attachments; only its author can
+func (h *Handler) DeleteNote(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.noteService.Delete(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserOrganization(c), id); err != nil {
+		h.serviceError(c, "Failed to delete note", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Note deleted",
+	})
+}
+
+// AddNoteAttachment stores the multipart "file" on a note
+func (h *Handler) AddNoteAttachment(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+
+	file, ok := h.uploadedFile(c)
+	if !ok {
+		return
+	}
+
+	attachment, err := h.noteService.AddAttachment(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserOrganization(c),
+		id, file.FileName(), file.Header.Get("Content-Type"), file)
+	if err != nil {
+		if h.tooLarge(c, err, nil) {
+			return
+		}
+		h.serviceError(c, "Failed to store attachment", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// DownloadNoteAttachment streams an attachment of a note the user can read
+func (h *Handler) DownloadNoteAttachment(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+	fileID, ok := attachmentID(c)
+	if !ok {
+		return
+	}
+
+	attachment, body, err := h.noteService.OpenAttachment(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserOrganization(c), id, fileID)
+	if err != nil {
+		h.serviceError(c, "Failed to open attachment", err)
+		return
+	}
+	defer body.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.SizeBytes, attachment.ContentType, body, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename=%q`, attachment.Filename),
+	})
+}
+
+// DeleteNoteAttachment removes an attachment; only the note's author can
+func (h *Handler) DeleteNoteAttachment(c *gin.Context) {
+	id, ok := noteID(c)
+	if !ok {
+		return
+	}
+	fileID, ok := attachmentID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.noteService.DeleteAttachment(c.Request.Context(), middleware.GetUserID(c), middleware.GetUserOrganization(c), id, fileID); err != nil {
+		h.serviceError(c, "Failed to delete attachment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Attachment deleted",
+	})
+}
+
+func noteID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid note id",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func attachmentID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("attachment"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid attachment id",
+		})
+		return 0, false
+	}
+	return id, true
+}
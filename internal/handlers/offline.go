@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetOfflineBundle returns the caller's signed offline bundle: their preferences and
+// the last 90 days of their watchlist. With ?since= set to the version the app holds,
+// only what changed is sent, or 304 when nothing did. The body's Ed25519 signature is
+// in X-Bundle-Signature.
+func (h *Handler) GetOfflineBundle(c *gin.Context) {
+	var since int64
+	if s := c.Query("since"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v < 1 {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidParameter,
+				Error:   "Invalid since",
+				Message: "since must be the version of a bundle you hold",
+			})
+			return
+		}
+		since = v
+	}
+
+	userID := middleware.GetUserID(c)
+	bundle, changed, err := h.offlineService.Bundle(c.Request.Context(), userID, since)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		h.serviceError(c, "Failed to build offline bundle", err, zap.String("user_id", userID))
+		return
+	}
+	if !changed {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		h.serviceError(c, "Failed to build offline bundle", err, zap.String("user_id", userID))
+		return
+	}
+	signature, keyID := h.offlineService.Sign(body)
+	c.Header("X-Bundle-Version", strconv.FormatInt(bundle.Version, 10))
+	c.Header("X-Bundle-Signature", signature)
+	c.Header("X-Bundle-Key-Id", keyID)
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// GetOfflineKey returns the public key offline bundles are signed with
+func (h *Handler) GetOfflineKey(c *gin.Context) {
+	c.JSON(http.StatusOK, h.offlineService.Key())
+}
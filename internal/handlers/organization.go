@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// memberCSVHeader is the column order of organization exports and CSV imports; list
+// columns are separated by semicolons
+var memberCSVHeader = []string{"email", "default_source", "default_limit", "default_window_days", "watchlist", "selected_symbols"}
+
+// ExportOrganization returns every member's watchlist and preferences in the admin's
+// organization as JSON, or as CSV with ?format=csv (org admin)
+func (h *Handler) ExportOrganization(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid format",
+			Message: "format must be json or csv",
+		})
+		return
+	}
+
+	export, err := h.userService.ExportOrganization(c.Request.Context(), middleware.GetUserOrganization(c))
+	if err != nil {
+		h.serviceError(c, "Failed to export organization", err)
+		return
+	}
+
+	filename := fmt.Sprintf("org-%s-%s.%s", export.Organization, export.ExportedAt.Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == "json" {
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Status(http.StatusOK)
+	if err := writeMembersCSV(c.Writer, export.Members); err != nil {
+		h.logger.Error("Failed to write organization CSV", zap.Error(err))
+	}
+}
+
+// ImportOrganization applies an organization export to the members of the admin's
+// organization with the same emails. The body is an export as JSON, or a CSV export
+// uploaded as file (org admin).
+func (h *Handler) ImportOrganization(c *gin.Context) {
+	var members []models.MemberSettings
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		var ok bool
+		if members, ok = membersFromCSV(c); !ok {
+			return
+		}
+	} else {
+		var req models.OrganizationExport
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidRequestBody,
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+		members = req.Members
+	}
+
+	result, err := h.userService.ImportOrganization(c.Request.Context(), middleware.GetUserOrganization(c), members)
+	if err != nil {
+		h.serviceError(c, "Failed to import organization", err)
+		return
+	}
+
+	h.logger.Info("Organization settings imported",
+		zap.String("organization", result.Organization),
+		zap.String("user_id", middleware.GetUserID(c)),
+		zap.Int("updated", result.Updated),
+		zap.Int("unmatched", len(result.Unmatched)),
+	)
+	c.JSON(http.StatusOK, result)
+}
+
+func writeMembersCSV(w http.ResponseWriter, members []models.MemberSettings) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(memberCSVHeader); err != nil {
+		return err
+	}
+	for _, m := range members {
+		record := []string{
+			m.Email,
+			m.DefaultSource,
+			strconv.Itoa(m.DefaultLimit),
+			strconv.Itoa(m.DefaultWindow),
+			strings.Join(m.Watchlist, ";"),
+			strings.Join(m.SelectedSymbols, ";"),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// membersFromCSV reads an uploaded organization CSV, answering 400 and returning
+// false when it is missing or malformed
+func membersFromCSV(c *gin.Context) ([]models.MemberSettings, bool) {
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeFileRequired,
+			Error: "No file uploaded",
+		})
+		return nil, false
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = len(memberCSVHeader)
+	records, err := reader.ReadAll()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidCSV,
+			Error:   "Failed to parse CSV",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	if len(records) < 2 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidCSV,
+			Error: "CSV file is empty or has no data rows",
+		})
+		return nil, false
+	}
+
+	members := make([]models.MemberSettings, 0, len(records)-1)
+	var errs []string
+	for i, record := range records[1:] {
+		m := models.MemberSettings{
+			Email:           strings.TrimSpace(record[0]),
+			DefaultSource:   strings.TrimSpace(record[1]),
+			Watchlist:       splitSymbols(record[4]),
+			SelectedSymbols: splitSymbols(record[5]),
+		}
+		if m.Email == "" {
+			errs = append(errs, fmt.Sprintf("Row %d: email is required", i+2))
+			continue
+		}
+		limit, limitErr := optionalInt(record[2], 1000)
+		window, windowErr := optionalInt(record[3], 3650)
+		if limitErr != nil || windowErr != nil {
+			errs = append(errs, fmt.Sprintf("Row %d: default_limit must be 1-1000 and default_window_days 1-3650", i+2))
+			continue
+		}
+		m.DefaultLimit, m.DefaultWindow = limit, window
+		members = append(members, m)
+	}
+	// Settings are applied all or none, so a bad row refuses the whole file
+	if len(errs) > 0 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidCSV,
+			Error:   "Invalid rows",
+			Details: gin.H{"errors": errs},
+		})
+		return nil, false
+	}
+	return members, true
+}
+
+// splitSymbols reads a semicolon-separated list column
+func splitSymbols(field string) []string {
+	symbols := []string{}
+	for _, s := range strings.Split(field, ";") {
+		if s = strings.TrimSpace(s); s != "" {
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+// optionalInt parses a column between 1 and max, with empty meaning zero
+func optionalInt(field string, max int) (int, error) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(field)
+	if err != nil || v < 1 || v > max {
+		return 0, fmt.Errorf("out of range")
+	}
+	return v, nil
+}
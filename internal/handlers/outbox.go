@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetOutboxStatus reports the outbox backlog: pending, retrying and dead messages and
+// the most recent delivery failures (admin)
+func (h *Handler) GetOutboxStatus(c *gin.Context) {
+	status, err := h.outboxService.Status(c.Request.Context())
+	if err != nil {
+		h.serviceError(c, "Failed to get outbox status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// RetryOutboxMessage returns a dead message to the queue (admin)
+func (h *Handler) RetryOutboxMessage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid outbox message id",
+		})
+		return
+	}
+
+	if err := h.outboxService.Retry(c.Request.Context(), id); err != nil {
+		h.serviceError(c, "Failed to retry outbox message", err, zap.Int64("id", id))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "status": "pending"})
+}
+
+// RetryOutboxMessages requeues every dead message created in a time window, optionally
+// of one topic (admin)
+func (h *Handler) RetryOutboxMessages(c *gin.Context) {
+	var req models.RetryOutboxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	from, err := time.Parse(time.RFC3339, req.From)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidDate,
+			Error:   "Invalid from format",
+			Message: "Use RFC3339 (e.g. 2025-01-07T00:00:00Z)",
+		})
+		return
+	}
+	to, err := time.Parse(time.RFC3339, req.To)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidDate,
+			Error:   "Invalid to format",
+			Message: "Use RFC3339 (e.g. 2025-01-08T00:00:00Z)",
+		})
+		return
+	}
+	if !to.After(from) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDateRange,
+			Error: "to must be after from",
+		})
+		return
+	}
+
+	requeued, err := h.outboxService.RetryDead(c.Request.Context(), from, to, req.Topic)
+	if err != nil {
+		h.serviceError(c, "Failed to retry outbox messages", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from.UTC(),
+		"to":       to.UTC(),
+		"topic":    req.Topic,
+		"requeued": requeued,
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageLinks are the pages a paginated response links to in its Link header (RFC
+// 5988). Each is a set of query changes applied to the request's own query: a ""
+// value removes the parameter. Unset pages are not linked.
+type pageLinks struct {
+	Next, Prev, First, Last map[string]string
+}
+
+// setLinkHeader sends links relative to the request path, keeping every other query
+// parameter so the linked pages use the same filters
+func setLinkHeader(c *gin.Context, links pageLinks) {
+	var parts []string
+	for _, l := range []struct {
+		rel     string
+		changes map[string]string
+	}{
+		{"next", links.Next},
+		{"prev", links.Prev},
+		{"first", links.First},
+		{"last", links.Last},
+	} {
+		if l.changes == nil {
+			continue
+		}
+		query := c.Request.URL.Query()
+		for k, v := range l.changes {
+			if v == "" {
+				query.Del(k)
+			} else {
+				query.Set(k, v)
+			}
+		}
+		target := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+		parts = append(parts, fmt.Sprintf(`<%s>; rel="%s"`, target.String(), l.rel))
+	}
+	if len(parts) > 0 {
+		c.Header("Link", strings.Join(parts, ", "))
+	}
+}
+
+// maxPageOffset is the most rows page/per_page pagination skips. Deeper pages are
+// refused rather than scanned past; a cursor reaches them without the scan.
+const maxPageOffset = 10000
+
+// pageTooDeep reports whether page of perPage rows starts past maxPageOffset
+func pageTooDeep(page, perPage int) bool {
+	return page > 1 && page-1 > maxPageOffset/perPage
+}
+
+// offsetPageLinks links the pages around page of a page/per_page listing of total
+// items, continuing from nextCursor when there is one. Keyset pages (page 0) can
+// only move forward, so they link no prev or last page; nor is a last page past
+// maxPageOffset linked.
+func offsetPageLinks(page, perPage int, total int64, nextCursor string) pageLinks {
+	links := pageLinks{
+		First: map[string]string{"page": "1", "cursor": ""},
+	}
+	if nextCursor != "" {
+		links.Next = map[string]string{"cursor": nextCursor, "page": ""}
+	}
+	if page == 0 {
+		return links
+	}
+	if page > 1 {
+		links.Prev = map[string]string{"page": strconv.Itoa(page - 1), "cursor": ""}
+	}
+	last := int((total + int64(perPage) - 1) / int64(perPage))
+	if last < 1 {
+		last = 1
+	}
+	if !pageTooDeep(last, perPage) {
+		links.Last = map[string]string{"page": strconv.Itoa(last), "cursor": ""}
+	}
+	return links
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListPortfolios returns the user's portfolios and their holdings
+func (h *Handler) ListPortfolios(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	portfolios, err := h.portfolioService.List(ctx, userID)
+	if err != nil {
+		h.serviceError(c, "Failed to list portfolios", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"portfolios": portfolios,
+		"count":      len(portfolios),
+	})
+}
+
+// CreatePortfolio adds an empty portfolio
+func (h *Handler) CreatePortfolio(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.PortfolioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	portfolio, err := h.portfolioService.Create(ctx, userID, strings.TrimSpace(req.Name))
+	if err != nil {
+		h.serviceError(c, "Failed to create portfolio", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusCreated, portfolio)
+}
+
+// GetPortfolio returns a portfolio valued at the latest close of each holding, or
+// the latest NAV of a fund. With as_of, the holdings and cash then are rebuilt from
+// the portfolio's events and priced at the closes of that day. ?currency= converts
+// every amount into one currency.
+func (h *Handler) GetPortfolio(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, ok := portfolioID(c)
+	if !ok {
+		return
+	}
+	asOf, ok := portfolioAsOf(c)
+	if !ok {
+		return
+	}
+	currency, ok := currencyParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	valuation, err := h.portfolioService.Value(ctx, userID, id, h.queryDefaults(c).Source, currency, asOf)
+	if err != nil {
+		h.serviceError(c, "Failed to value portfolio", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusOK, valuation)
+}
+
+// RenamePortfolio changes a portfolio's name
+func (h *Handler) RenamePortfolio(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, ok := portfolioID(c)
+	if !ok {
+		return
+	}
+
+	var req models.PortfolioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.portfolioService.Rename(ctx, userID, id, strings.TrimSpace(req.Name)); err != nil {
+		h.serviceError(c, "Failed to rename portfolio", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Portfolio renamed",
+		"id":      id,
+		"name":    strings.TrimSpace(req.Name),
+	})
+}
+
+// DeletePortfolio removes a portfolio and its holdings
+func (h *Handler) DeletePortfolio(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, ok := portfolioID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.portfolioService.Delete(ctx, userID, id); err != nil {
+		h.serviceError(c, "Failed to delete portfolio", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Portfolio deleted",
+		"id":      id,
+	})
+}
+
+// SetHolding records the quantity and average price held in a symbol, from at when
+// given
+func (h *Handler) SetHolding(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, ok := portfolioID(c)
+	if !ok {
+		return
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(c.Param("symbol")))
+
+	var req models.HoldingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var at time.Time
+	if req.At != nil {
+		at = *req.At
+	}
+
+	ctx := c.Request.Context()
+	holding, err := h.portfolioService.SetHolding(ctx, userID, id, symbol, req.Quantity, req.AvgPrice, at)
+	if err != nil {
+		h.serviceError(c, "Failed to save holding", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusOK, holding)
+}
+
+// RemoveHolding deletes the position in a symbol
+func (h *Handler) RemoveHolding(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, ok := portfolioID(c)
+	if !ok {
+		return
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(c.Param("symbol")))
+
+	ctx := c.Request.Context()
+	if err := h.portfolioService.RemoveHolding(ctx, userID, id, symbol); err != nil {
+		h.serviceError(c, "Failed to remove holding", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Holding removed",
+		"symbol":  symbol,
+	})
+}
+
+// GetPortfolioAdjustments lists how corporate actions such as rights issues and
+// warrants changed the portfolio's holdings
+func (h *Handler) GetPortfolioAdjustments(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, ok := portfolioID(c)
+	if !ok {
+		return
+	}
+
+	adjustments, err := h.portfolioService.Adjustments(c.Request.Context(), userID, id)
+	if err != nil {
+		h.serviceError(c, "Failed to get adjustments", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"portfolio_id": id,
+		"count":        len(adjustments),
+		"adjustments":  adjustments,
+	})
+}
+
+// RecordTrade buys or sells a symbol, averaging a buy into the holding and moving
+// the trade's value out of or into cash
+func (h *Handler) RecordTrade(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, ok := portfolioID(c)
+	if !ok {
+		return
+	}
+
+	var req models.TradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+
+	ctx := c.Request.Context()
+	event, holding, err := h.portfolioService.RecordTrade(ctx, userID, id, req)
+	if err != nil {
+		h.serviceError(c, "Failed to record trade", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"event":   event,
+		"holding": holding,
+	})
+}
+
+// RecordCash deposits cash into or withdraws it from a portfolio
+func (h *Handler) RecordCash(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, ok := portfolioID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	event, cash, err := h.portfolioService.RecordCash(ctx, userID, id, req)
+	if err != nil {
+		h.serviceError(c, "Failed to record cash", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"event": event,
+		"cash":  cash,
+	})
+}
+
+// GetPortfolioEvents lists the changes recorded against a portfolio, most recently
+// effective first
+func (h *Handler) GetPortfolioEvents(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	id, ok := portfolioID(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.portfolioService.Events(c.Request.Context(), userID, id)
+	if err != nil {
+		h.serviceError(c, "Failed to get portfolio events", err, zap.String("user_id", middleware.GetUserID(c)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"portfolio_id": id,
+		"count":        len(events),
+		"events":       events,
+	})
+}
+
+// portfolioAsOf parses the optional as_of query parameter
+func portfolioAsOf(c *gin.Context) (*time.Time, bool) {
+	s := c.Query("as_of")
+	if s == "" {
+		return nil, true
+	}
+	asOf, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDate,
+			Error: "Invalid as_of format. Use RFC3339 (e.g. 2025-01-31T00:00:00Z)",
+		})
+		return nil, false
+	}
+	return &asOf, true
+}
+
+func portfolioID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid portfolio id",
+		})
+		return 0, false
+	}
+	return id, true
+}
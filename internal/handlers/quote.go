@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetQuote returns the freshest available quote for a symbol and the fallback path used
+func (h *Handler) GetQuote(c *gin.Context) {
+	symbol := symbolParam(c)
+	ctx := c.Request.Context()
+
+	result, err := h.quoteService.GetQuote(ctx, symbol)
+	if err != nil {
+		if h.deadlineExceeded(c, err, gin.H{"attempts": result.Attempts}) {
+			return
+		}
+		if errors.Is(err, services.ErrQuoteNotFound) {
+			respondError(c, http.StatusNotFound, ErrorResponse{
+				Code:    apierror.CodeQuoteNotFound,
+				Error:   "No quote available",
+				Details: gin.H{"symbol": symbol, "attempts": result.Attempts},
+			})
+			return
+		}
+		h.logger.Error("Failed to get quote",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get quote",
+		})
+		return
+	}
+
+	h.setMarketState(c, symbol, result)
+
+	c.Header("X-Data-Source", result.Quote.Source)
+	c.Header("X-Data-As-Of", result.Quote.AsOf.UTC().Format(time.RFC3339))
+	c.Header("X-Quote-Path", result.Path)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// setMarketState adds the state of the symbol's exchange to a quote. A quote is still
+// served when the state cannot be determined.
+func (h *Handler) setMarketState(c *gin.Context, symbol string, result *models.QuoteResult) {
+	exchange := models.ExchangeForSymbol(strings.ToUpper(symbol))
+	status, err := h.exchangeService.Status(c.Request.Context(), exchange, time.Now())
+	if err != nil {
+		if !errors.Is(err, services.ErrExchangeNotFound) {
+			h.logger.Warn("Failed to get market state",
+				zap.String("exchange", exchange),
+				zap.Error(err),
+			)
+		}
+		return
+	}
+	result.Exchange = exchange
+	result.MarketState = status.MarketState
+	c.Header("X-Market-State", status.MarketState)
+}
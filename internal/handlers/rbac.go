@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRoles returns every role and what it grants (admin)
+func (h *Handler) ListRoles(c *gin.Context) {
+	roles, err := h.rbacService.Roles(c.Request.Context())
+	if err != nil {
+		h.serviceError(c, "Failed to list roles", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(roles),
+		"roles": roles,
+	})
+}
+
+// SetRolePermissions replaces the permissions a role is granted in the database;
+// permissions from RBAC_ROLE_PERMISSIONS stay (admin)
+func (h *Handler) SetRolePermissions(c *gin.Context) {
+	var req models.SetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	role := c.Param("role")
+	ctx := c.Request.Context()
+	if err := h.rbacService.SetRolePermissions(ctx, role, req.Permissions); err != nil {
+		h.serviceError(c, "Failed to set role permissions", err)
+		return
+	}
+	permissions, err := h.rbacService.Permissions(ctx, []string{role})
+	if err != nil {
+		h.serviceError(c, "Failed to set role permissions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Role permissions updated",
+		"role":        role,
+		"permissions": permissions,
+	})
+}
+
+// GetEffectivePermissions returns what ?roles= (comma separated) grant together,
+// or the caller's own roles when omitted (admin)
+func (h *Handler) GetEffectivePermissions(c *gin.Context) {
+	result := models.EffectivePermissions{}
+	if v := c.Query("roles"); v != "" {
+		for _, role := range strings.Split(v, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				result.Roles = append(result.Roles, role)
+			}
+		}
+	} else {
+		result.UserID = middleware.GetUserID(c)
+		result.Roles = middleware.GetUserRoles(c)
+	}
+
+	permissions, err := h.rbacService.Permissions(c.Request.Context(), result.Roles)
+	if err != nil {
+		h.serviceError(c, "Failed to get permissions", err)
+		return
+	}
+	result.Permissions = permissions
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Reconcile compares a symbol's candles across sources, highlighting discrepancies above a tolerance
+func (h *Handler) Reconcile(c *gin.Context) {
+	symbol := c.Query("symbol")
+	if symbol == "" {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeMissingParameter,
+			Error: "symbol is required",
+		})
+		return
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -90)
+	if s := c.Query("start"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid start format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		startDate = d
+	}
+	if s := c.Query("end"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid end format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = d
+	}
+
+	opts, ok := reconcileOptions(c)
+	if !ok {
+		return
+	}
+	opts.All = c.Query("all") == "true"
+
+	ctx := c.Request.Context()
+	report, err := h.marketService.Reconcile(ctx, symbol, startDate, endDate, opts)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		h.logger.Error("Failed to reconcile sources",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to reconcile sources",
+		})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		filename := fmt.Sprintf("reconcile-%s-%s-%s.csv", symbol, startDate.Format("20060102"), endDate.Format("20060102"))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Header("Content-Type", "text/csv")
+		c.Status(http.StatusOK)
+		if err := writeReconcileCSV(c.Writer, report); err != nil {
+			h.logger.Error("Failed to write reconcile CSV", zap.Error(err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// reconcileOptions reads ?tolerance= (default 0.5%) and ?volume_tolerance= (default
+// 5%), answering 400 and returning false when either is not a non-negative number
+func reconcileOptions(c *gin.Context) (services.ReconcileOptions, bool) {
+	opts := services.ReconcileOptions{
+		TolerancePct:       0.5,
+		VolumeTolerancePct: 5,
+	}
+	if t := c.Query("tolerance"); t != "" {
+		parsed, err := strconv.ParseFloat(t, 64)
+		if err != nil || parsed < 0 {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidParameter,
+				Error: "tolerance must be a non-negative percentage",
+			})
+			return opts, false
+		}
+		opts.TolerancePct = parsed
+	}
+	if t := c.Query("volume_tolerance"); t != "" {
+		parsed, err := strconv.ParseFloat(t, 64)
+		if err != nil || parsed < 0 {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidParameter,
+				Error: "volume_tolerance must be a non-negative percentage",
+			})
+			return opts, false
+		}
+		opts.VolumeTolerancePct = parsed
+	}
+	return opts, true
+}
+
+// maxCompareSources bounds how many sources one comparison sets side by side
+const maxCompareSources = 5
+
+// CompareMarketData sets a symbol's candles from the sources in ?sources= side by side
+// per date, diffing each against the first and flagging differences beyond the
+// tolerances. The range is start_date..end_date or the user's default window ending
+// today; ?discrepant_only=true leaves out dates where every source agrees.
+func (h *Handler) CompareMarketData(c *gin.Context) {
+	symbol := symbolParam(c)
+	interval, ok := intervalParam(c)
+	if !ok {
+		return
+	}
+	exchange := exchangeParam(c)
+	if !h.requireSymbol(c, symbol, exchange) {
+		return
+	}
+	sources, ok := h.compareSources(c)
+	if !ok {
+		return
+	}
+	opts, ok := reconcileOptions(c)
+	if !ok {
+		return
+	}
+	opts.All = c.Query("discrepant_only") != "true"
+
+	endDate := time.Now().UTC().Truncate(24 * time.Hour)
+	startDate := endDate.AddDate(0, 0, -h.queryDefaults(c).WindowDays)
+	if s := c.Query("start_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid start_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		startDate = d
+	}
+	if s := c.Query("end_date"); s != "" {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidDate,
+				Error:   "Invalid end_date format",
+				Message: "Use format YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = d
+	}
+	if endDate.Before(startDate) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidDateRange,
+			Error: "end_date must not be before start_date",
+		})
+		return
+	}
+	if !h.checkRange(c, symbol, "", interval, exchange, startDate, endDate) {
+		return
+	}
+
+	report, err := h.marketService.Compare(c.Request.Context(), symbol, interval, exchange, sources, startDate, endDate, opts)
+	if err != nil {
+		h.serviceError(c, "Failed to compare sources", err, zap.String("symbol", symbol))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// compareSources reads ?sources=, two to maxCompareSources distinct configured sources,
+// answering and returning false otherwise
+func (h *Handler) compareSources(c *gin.Context) ([]string, bool) {
+	var sources []string
+	for _, name := range strings.Split(c.Query("sources"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !slices.Contains(sources, name) {
+			sources = append(sources, name)
+		}
+	}
+	if len(sources) < 2 || len(sources) > maxCompareSources {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid sources",
+			Message: fmt.Sprintf("sources must list 2 to %d sources, e.g. sources=yahoo,mirae", maxCompareSources),
+		})
+		return nil, false
+	}
+
+	for _, name := range sources {
+		source, err := h.sourceService.Get(c.Request.Context(), name)
+		if err != nil {
+			h.serviceError(c, "Failed to look up source", err, zap.String("source", name))
+			return nil, false
+		}
+		if source == nil {
+			respondError(c, http.StatusNotFound, ErrorResponse{
+				Code:    apierror.CodeSourceNotFound,
+				Error:   "Source not found",
+				Message: fmt.Sprintf("%s is not a configured source; see GET /api/v1/sources", name),
+			})
+			return nil, false
+		}
+	}
+	return sources, true
+}
+
+func writeReconcileCSV(w http.ResponseWriter, report *models.ReconcileReport) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"date", "type", "source_a", "source_b", "close_a", "close_b",
+		"open_diff_pct", "high_diff_pct", "low_diff_pct", "close_diff_pct", "volume_diff_pct", "fields",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		kind := "match"
+		if row.Discrepant {
+			kind = "discrepancy"
+		}
+		record := []string{
+			row.Date.Format("2006-01-02"),
+			kind,
+			row.SourceA,
+			row.SourceB,
+			strconv.FormatFloat(row.CloseA, 'f', -1, 64),
+			strconv.FormatFloat(row.CloseB, 'f', -1, 64),
+			strconv.FormatFloat(row.OpenDiffPct, 'f', -1, 64),
+			strconv.FormatFloat(row.HighDiffPct, 'f', -1, 64),
+			strconv.FormatFloat(row.LowDiffPct, 'f', -1, 64),
+			strconv.FormatFloat(row.CloseDiffPct, 'f', -1, 64),
+			strconv.FormatFloat(row.VolumeDiffPct, 'f', -1, 64),
+			strings.Join(row.Fields, ";"),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	// Missing dates list the sources that have the candle under source_a and those lacking it under source_b
+	for _, m := range report.Missing {
+		record := []string{
+			m.Date.Format("2006-01-02"),
+			"missing",
+			strings.Join(m.Present, ";"),
+			strings.Join(m.Missing, ";"),
+			"", "", "", "", "", "", "", "",
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
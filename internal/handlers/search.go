@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Search matches ?q= against symbols and the notes the user can read, best first.
+// ?types= (comma separated: symbol, note) narrows the result types.
+func (h *Handler) Search(c *gin.Context) {
+	q := services.SearchQuery{
+		Text:         c.Query("q"),
+		UserID:       middleware.GetUserID(c),
+		Organization: middleware.GetUserOrganization(c),
+		Limit:        20,
+	}
+	if v := c.Query("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 100 {
+			q.Limit = l
+		}
+	}
+	if v := c.Query("types"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if !slices.Contains(services.SearchTypes, t) {
+				respondError(c, http.StatusBadRequest, ErrorResponse{
+					Code:    apierror.CodeInvalidParameter,
+					Error:   "Invalid type",
+					Message: "types must be among: " + strings.Join(services.SearchTypes, ", "),
+				})
+				return
+			}
+			q.Types = append(q.Types, t)
+		}
+	}
+
+	results, err := h.searchService.Search(c.Request.Context(), q)
+	if err != nil {
+		h.serviceError(c, "Failed to search", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   q.Text,
+		"count":   len(results),
+		"results": results,
+	})
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/fees"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// FeeSettingsResponse wraps the fee model with whether it was customized
+type FeeSettingsResponse struct {
+	Custom bool       `json:"custom"`
+	Model  fees.Model `json:"model"`
+}
+
+// GetFeeSettings returns the user's fee model
+func (h *Handler) GetFeeSettings(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	model, custom, err := h.feeService.GetModel(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get fee settings",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get fee settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, FeeSettingsResponse{
+		Custom: custom,
+		Model:  model,
+	})
+}
+
+// UpdateFeeSettings replaces the user's fee model
+func (h *Handler) UpdateFeeSettings(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	var model fees.Model
+	if err := c.ShouldBindJSON(&model); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if problems := model.Validate(); len(problems) > 0 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid fee model",
+			Message: strings.Join(problems, "; "),
+		})
+		return
+	}
+
+	if err := h.feeService.SaveModel(ctx, userID, model); err != nil {
+		h.logger.Error("Failed to save fee settings",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to save fee settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, FeeSettingsResponse{
+		Custom: true,
+		Model:  model,
+	})
+}
+
+// ResetFeeSettings restores the default fee model
+func (h *Handler) ResetFeeSettings(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	if err := h.feeService.ResetModel(ctx, userID); err != nil {
+		h.logger.Error("Failed to reset fee settings",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to reset fee settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, FeeSettingsResponse{
+		Custom: false,
+		Model:  fees.Default(),
+	})
+}
+
+// EstimateFees applies the user's fee model to a hypothetical fill
+func (h *Handler) EstimateFees(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	side := fees.Side(c.DefaultQuery("side", string(fees.Buy)))
+	if side != fees.Buy && side != fees.Sell {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidParameter,
+			Error: "side must be buy or sell",
+		})
+		return
+	}
+
+	price, err := strconv.ParseFloat(c.Query("price"), 64)
+	if err != nil || price <= 0 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidParameter,
+			Error: "price must be a positive number",
+		})
+		return
+	}
+
+	model, _, err := h.feeService.GetModel(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get fee settings",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get fee settings",
+		})
+		return
+	}
+
+	// Size either by explicit quantity or by a cash amount rounded down to whole lots
+	var quantity int64
+	switch {
+	case c.Query("quantity") != "":
+		quantity, err = strconv.ParseInt(c.Query("quantity"), 10, 64)
+		if err != nil || quantity <= 0 {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidParameter,
+				Error: "quantity must be a positive integer",
+			})
+			return
+		}
+	case c.Query("amount") != "" && side == fees.Buy:
+		amount, err := strconv.ParseFloat(c.Query("amount"), 64)
+		if err != nil || amount <= 0 {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidParameter,
+				Error: "amount must be a positive number",
+			})
+			return
+		}
+		quantity = model.Quantity(amount, price)
+	default:
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeMissingParameter,
+			Error: "quantity (or amount for buys) is required",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Apply(side, price, quantity))
+}
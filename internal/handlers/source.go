@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/anomaly"
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/transform"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListSources returns the configured data sources with their attribution and licensing
+// terms, and the upstream providers candles can be fetched from with their status
+func (h *Handler) ListSources(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sources, err := h.sourceService.List(ctx)
+	if err != nil {
+		h.logger.Error("Failed to list sources", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list sources",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":     len(sources),
+		"sources":   sources,
+		"providers": h.dataSources.Status(),
+	})
+}
+
+// UpdateAnomalyPolicy sets how ingestion anomalies from a source are handled
+func (h *Handler) UpdateAnomalyPolicy(c *gin.Context) {
+	name := c.Param("name")
+
+	var req models.UpdateAnomalyPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !anomaly.ValidPolicy(req.Policy) {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid policy",
+			Message: "policy must be one of: " + strings.Join(anomaly.Policies, ", "),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.sourceService.SetAnomalyPolicy(ctx, name, req.Policy); err != nil {
+		h.serviceError(c, "Failed to update anomaly policy", err, zap.String("source", name))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Anomaly policy updated",
+		"source":  name,
+		"policy":  req.Policy,
+	})
+}
+
+// UpdateIngestWindow sets how long a source may go without delivering candles before
+// the ingest monitor reports it stale (admin)
+func (h *Handler) UpdateIngestWindow(c *gin.Context) {
+	name := c.Param("name")
+
+	var req models.UpdateIngestWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.sourceService.SetIngestWindow(c.Request.Context(), name, req.WindowMinutes); err != nil {
+		h.serviceError(c, "Failed to update ingest window", err, zap.String("source", name))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Ingest window updated",
+		"source":         name,
+		"window_minutes": req.WindowMinutes,
+	})
+}
+
+// UpdateTransformRules replaces the rules a source's candles are rewritten with on
+// ingest, before anomaly screening (admin)
+func (h *Handler) UpdateTransformRules(c *gin.Context) {
+	name := c.Param("name")
+
+	var req models.UpdateTransformRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !validTransformRules(c, req.Rules) {
+		return
+	}
+
+	if err := h.sourceService.SetTransformRules(c.Request.Context(), name, req.Rules); err != nil {
+		h.serviceError(c, "Failed to update transform rules", err, zap.String("source", name))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Transform rules updated",
+		"source":  name,
+		"rules":   req.Rules,
+	})
+}
+
+// PreviewTransformRules shows what a source's rules, or the rules in the body, would
+// make of a sample batch, without storing anything (admin)
+func (h *Handler) PreviewTransformRules(c *gin.Context) {
+	name := c.Param("name")
+
+	var req models.PreviewTransformRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !validTransformRules(c, req.Rules) {
+		return
+	}
+	for i := range req.Data {
+		if err := req.Data[i].Normalize(); err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeValidationFailed,
+				Error:   "Invalid sample candle",
+				Message: fmt.Sprintf("data[%d]: %v", i, err),
+			})
+			return
+		}
+	}
+
+	preview, err := h.sourceService.PreviewTransform(c.Request.Context(), name, req.Rules, req.Data)
+	if err != nil {
+		h.serviceError(c, "Failed to preview transform rules", err, zap.String("source", name))
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// validTransformRules answers 400 listing every invalid rule
+func validTransformRules(c *gin.Context, rules []models.TransformRule) bool {
+	var errs []string
+	for i, rule := range rules {
+		if err := transform.Validate(rule); err != nil {
+			errs = append(errs, fmt.Sprintf("rules[%d]: %v", i, err))
+		}
+	}
+	if len(errs) > 0 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeValidationFailed,
+			Error:   "Invalid transform rules",
+			Details: gin.H{"errors": errs},
+		})
+		return false
+	}
+	return true
+}
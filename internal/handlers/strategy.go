@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+	"github.com/ridhomain/proto-trading-service/internal/strategy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStrategySize bounds strategy definitions accepted for validation or saving
+const maxStrategySize = 64 << 10
+
+// StrategyValidationResponse reports parse/validation errors and data requirements
+type StrategyValidationResponse struct {
+	Valid        bool                       `json:"valid"`
+	Errors       []strategy.ValidationError `json:"errors"`
+	Requirements *strategy.Requirements     `json:"requirements,omitempty"`
+}
+
+// ValidateStrategy parses a JSON or YAML strategy definition and validates it
+func (h *Handler) ValidateStrategy(c *gin.Context) {
+	body, ok := strategyBody(c)
+	if !ok {
+		return
+	}
+
+	def, err := strategy.Parse(body, strategyFormat(c))
+	if err != nil {
+		c.JSON(http.StatusOK, StrategyValidationResponse{
+			Valid: false,
+			Errors: []strategy.ValidationError{
+				{Field: "", Message: err.Error()},
+			},
+		})
+		return
+	}
+
+	errs, requirements := strategy.Validate(def)
+	if errs == nil {
+		errs = []strategy.ValidationError{}
+	}
+
+	c.JSON(http.StatusOK, StrategyValidationResponse{
+		Valid:        len(errs) == 0,
+		Errors:       errs,
+		Requirements: requirements,
+	})
+}
+
+// ListStrategies returns the user's saved strategies, or with ?public=true every
+// user's public ones, most recently changed first
+func (h *Handler) ListStrategies(c *gin.Context) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	strategies, err := h.strategyService.List(c.Request.Context(), middleware.GetUserID(c), services.StrategyFilter{
+		Public: c.Query("public") == "true",
+		Limit:  limit,
+	})
+	if err != nil {
+		h.serviceError(c, "Failed to list strategies", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":      len(strategies),
+		"strategies": strategies,
+	})
+}
+
+// CreateStrategy saves a valid JSON or YAML strategy definition, public with
+// ?public=true
+func (h *Handler) CreateStrategy(c *gin.Context) {
+	def, ok := validStrategy(c)
+	if !ok {
+		return
+	}
+
+	saved, err := h.strategyService.Create(c.Request.Context(), middleware.GetUserID(c), def, c.Query("public") == "true")
+	if err != nil {
+		h.serviceError(c, "Failed to save strategy", err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/strategies/%d", saved.ID))
+	c.JSON(http.StatusCreated, saved)
+}
+
+// GetStrategy returns a strategy the user owns or that is public
+func (h *Handler) GetStrategy(c *gin.Context) {
+	id, ok := strategyID(c)
+	if !ok {
+		return
+	}
+
+	saved, err := h.strategyService.Get(c.Request.Context(), middleware.GetUserID(c), id)
+	if err != nil {
+		h.serviceError(c, "Failed to get strategy", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// UpdateStrategy replaces a strategy's definition, and its visibility when ?public=
+// is given; only its owner can
+func (h *Handler) UpdateStrategy(c *gin.Context) {
+	id, ok := strategyID(c)
+	if !ok {
+		return
+	}
+	def, ok := validStrategy(c)
+	if !ok {
+		return
+	}
+	var public *bool
+	if v := c.Query("public"); v != "" {
+		p := v == "true"
+		public = &p
+	}
+
+	saved, err := h.strategyService.Update(c.Request.Context(), middleware.GetUserID(c), id, def, public)
+	if err != nil {
+		h.serviceError(c, "Failed to update strategy", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteStrategy removes a strategy; only its owner can
+func (h *Handler) DeleteStrategy(c *gin.Context) {
+	id, ok := strategyID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.strategyService.Delete(c.Request.Context(), middleware.GetUserID(c), id); err != nil {
+		h.serviceError(c, "Failed to delete strategy", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Strategy deleted",
+	})
+}
+
+// CloneStrategy copies a strategy the user owns or that is public into a new private
+// strategy of theirs
+func (h *Handler) CloneStrategy(c *gin.Context) {
+	id, ok := strategyID(c)
+	if !ok {
+		return
+	}
+
+	saved, err := h.strategyService.Clone(c.Request.Context(), middleware.GetUserID(c), id)
+	if err != nil {
+		h.serviceError(c, "Failed to clone strategy", err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/strategies/%d", saved.ID))
+	c.JSON(http.StatusCreated, saved)
+}
+
+// strategyBody reads a definition from the request body, answering and returning
+// false when it cannot be read or is too large
+func strategyBody(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxStrategySize+1))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Failed to read request body",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	if len(body) > maxStrategySize {
+		respondError(c, http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error: "Strategy definition too large",
+		})
+		return nil, false
+	}
+	return body, true
+}
+
+// validStrategy parses and validates the definition in the request body, answering
+// 400 with every validation error and returning false when it is not valid
+func validStrategy(c *gin.Context) (*strategy.Definition, bool) {
+	body, ok := strategyBody(c)
+	if !ok {
+		return nil, false
+	}
+
+	def, err := strategy.Parse(body, strategyFormat(c))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid strategy definition",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	if errs, _ := strategy.Validate(def); len(errs) > 0 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeValidationFailed,
+			Error:   "Invalid strategy definition",
+			Details: gin.H{"errors": errs},
+		})
+		return nil, false
+	}
+	return def, true
+}
+
+func strategyID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid strategy id",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// strategyFormat picks the definition format from ?format= or the Content-Type header
+func strategyFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	if strings.Contains(c.ContentType(), "yaml") {
+		return strategy.FormatYAML
+	}
+	return strategy.FormatJSON
+}
@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/stream"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	streamWriteTimeout    = 10 * time.Second
+	streamSnapshotTimeout = 10 * time.Second
+	streamHeartbeat       = 30 * time.Second
+	sseRetry              = 5 * time.Second // how long EventSource clients wait before reconnecting
+)
+
+// streamRequest is a message sent by a stream client. ID, when set, is echoed as
+// reply_to on every message answering it.
+type streamRequest struct {
+	ID       string   `json:"id"`
+	Action   string   `json:"action"` // subscribe, unsubscribe, snapshot, resume or ping
+	Symbols  []string `json:"symbols"`
+	Channels []string `json:"channels"`
+	After    *uint64  `json:"after"` // resume: the seq of the last message received
+}
+
+// streamMessage is a message sent to a stream client. Seq is the hub event a message
+// carries or, for snapshot, resumed, resync and pong, the latest event it reflects.
+type streamMessage struct {
+	Type     string                  `json:"type"` // subscribed, market_data, portfolio, snapshot, resumed, pong, heartbeat, resync or error
+	Seq      uint64                  `json:"seq,omitempty"`
+	ReplyTo  string                  `json:"reply_to,omitempty"`
+	Symbols  []string                `json:"symbols,omitempty"`
+	Channels []string                `json:"channels,omitempty"`
+	Data     []models.MarketData     `json:"data,omitempty"`
+	Quotes   []models.Quote          `json:"quotes,omitempty"`
+	Events   []models.PortfolioEvent `json:"events,omitempty"`
+	Message  string                  `json:"message,omitempty"`
+}
+
+// StreamMarketData upgrades to a WebSocket and pushes newly stored candles for the
+// symbols the client subscribes to, and the user's portfolio events once it follows
+// the portfolio channel. The client can also ask for snapshots of the latest quotes
+// and, after reconnecting, resume from the last seq it received. Disallowed origins
+// are rejected by the CORS middleware before the upgrade.
+func (h *Handler) StreamMarketData(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	// ?symbols=, ?channels= and ?resume_after= act as the client's first requests
+	var opening []streamRequest
+	symbols := normalizeSymbols(strings.Split(c.Query("symbols"), ","))
+	channels := normalizeChannels(strings.Split(c.Query("channels"), ","))
+	for _, channel := range channels {
+		if channel != stream.ChannelPortfolio {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:    apierror.CodeInvalidParameter,
+				Error:   "Invalid channels parameter",
+				Message: fmt.Sprintf("Unknown channel %q; use %s", channel, stream.ChannelPortfolio),
+			})
+			return
+		}
+	}
+	if len(symbols) > 0 || len(channels) > 0 {
+		opening = append(opening, streamRequest{Action: "subscribe", Symbols: symbols, Channels: channels})
+	}
+	if v := c.Query("resume_after"); v != "" {
+		after, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidParameter,
+				Error: "Invalid resume_after parameter",
+			})
+			return
+		}
+		opening = append(opening, streamRequest{Action: "resume", After: &after})
+	}
+	source := h.queryDefaults(c).Source
+
+	server := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			h.serveStream(ws, userID, source, opening)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *Handler) serveStream(ws *websocket.Conn, userID, source string, opening []streamRequest) {
+	defer ws.Close()
+
+	// The hijacked connection keeps the HTTP server's timeouts; streams manage their own
+	if err := ws.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+
+	sub := h.hub.Register(userID)
+	defer h.hub.Unregister(sub)
+
+	h.logger.Info("Stream opened", zap.String("user_id", userID))
+	defer h.logger.Info("Stream closed", zap.String("user_id", userID))
+
+	session := &streamSession{h: h, ctx: ws.Request().Context(), sub: sub, source: source}
+
+	// Requests are handled by the loop below, so only one goroutine writes and replayed
+	// events are ordered with live ones
+	requests := make(chan streamRequest, 8)
+	for _, req := range opening {
+		requests <- req
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			var req streamRequest
+			if err := websocket.JSON.Receive(ws, &req); err != nil {
+				return
+			}
+
+			select {
+			case requests <- req:
+			case <-sub.Done():
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var msgs []streamMessage
+		select {
+		case <-closed:
+			return
+		case <-sub.Done():
+			h.sendStream(ws, streamMessage{Type: "error", Message: "connection too slow, updates were dropped"})
+			return
+		case event := <-sub.Updates():
+			if event.ID <= session.replayed {
+				continue
+			}
+			msgs = []streamMessage{eventMessage(event)}
+		case req := <-requests:
+			msgs = session.handle(req)
+		case <-heartbeat.C:
+			msgs = []streamMessage{{Type: "heartbeat"}}
+		}
+
+		for _, msg := range msgs {
+			if err := h.sendStream(ws, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *Handler) sendStream(ws *websocket.Conn, msg streamMessage) error {
+	if err := ws.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		return err
+	}
+	return websocket.JSON.Send(ws, msg)
+}
+
+// streamSession answers one WebSocket client's requests
+type streamSession struct {
+	h        *Handler
+	ctx      context.Context
+	sub      *stream.Subscriber
+	source   string // snapshots read the latest quotes from it
+	replayed uint64 // the last event replayed on resume; live copies up to it are skipped
+}
+
+func (s *streamSession) handle(req streamRequest) []streamMessage {
+	var msgs []streamMessage
+	switch req.Action {
+	case "subscribe", "unsubscribe":
+		msgs = []streamMessage{s.subscribe(req)}
+	case "snapshot":
+		msgs = []streamMessage{s.snapshot(req)}
+	case "resume":
+		msgs = s.resume(req)
+	case "ping":
+		msgs = []streamMessage{{Type: "pong", Seq: s.h.hub.LastID()}}
+	default:
+		msgs = []streamMessage{{Type: "error", Message: "action must be subscribe, unsubscribe, snapshot, resume or ping"}}
+	}
+
+	for i := range msgs {
+		msgs[i].ReplyTo = req.ID
+	}
+	return msgs
+}
+
+// subscribe follows or stops following symbols and channels, answering with all
+// those followed
+func (s *streamSession) subscribe(req streamRequest) streamMessage {
+	follow := req.Action == "subscribe"
+	for _, channel := range normalizeChannels(req.Channels) {
+		if !s.sub.Follow(channel, follow) {
+			return streamMessage{Type: "error", Message: fmt.Sprintf("unknown channel %q; use %s", channel, stream.ChannelPortfolio)}
+		}
+	}
+
+	symbols := normalizeSymbols(req.Symbols)
+	if !follow {
+		return streamMessage{Type: "subscribed", Symbols: s.sub.Unsubscribe(symbols), Channels: s.sub.Channels()}
+	}
+	followed, ok := s.sub.Subscribe(symbols)
+	if !ok {
+		return streamMessage{
+			Type:     "error",
+			Symbols:  followed,
+			Channels: s.sub.Channels(),
+			Message:  fmt.Sprintf("too many symbols; at most %d may be followed", stream.MaxSymbols),
+		}
+	}
+	return streamMessage{Type: "subscribed", Symbols: followed, Channels: s.sub.Channels()}
+}
+
+// snapshot answers with the latest quote of the symbols asked for, or of those
+// followed. Its seq is taken before the quotes are read, so later events may repeat
+// what it shows but none are missed.
+func (s *streamSession) snapshot(req streamRequest) streamMessage {
+	symbols := normalizeSymbols(req.Symbols)
+	if len(symbols) == 0 {
+		symbols = s.sub.Symbols()
+	}
+	if len(symbols) == 0 {
+		return streamMessage{Type: "error", Message: "no symbols to snapshot; name some or subscribe first"}
+	}
+	if len(symbols) > stream.MaxSymbols {
+		return streamMessage{Type: "error", Message: fmt.Sprintf("too many symbols; at most %d may be in a snapshot", stream.MaxSymbols)}
+	}
+
+	seq := s.h.hub.LastID()
+	ctx, cancel := context.WithTimeout(s.ctx, streamSnapshotTimeout)
+	defer cancel()
+
+	quotes, err := s.h.marketService.LatestQuotes(ctx, symbols, s.source)
+	if err != nil {
+		s.h.logger.Error("Failed to load stream snapshot",
+			zap.Strings("symbols", symbols),
+			zap.Error(err),
+		)
+		return streamMessage{Type: "error", Message: "failed to load snapshot"}
+	}
+	return streamMessage{Type: "snapshot", Seq: seq, Symbols: symbols, Quotes: quotes}
+}
+
+// resume replays the events after req.After for what the client now follows, then
+// answers resumed. When they are no longer buffered it answers resync instead, and
+// the client should reload what it shows, for instance with a snapshot.
+func (s *streamSession) resume(req streamRequest) []streamMessage {
+	if req.After == nil {
+		return []streamMessage{{Type: "error", Message: "after is required to resume"}}
+	}
+
+	events, complete := s.h.hub.Since(s.sub, *req.After)
+	if !complete {
+		return []streamMessage{{Type: "resync", Seq: s.h.hub.LastID(), Symbols: s.sub.Symbols(), Channels: s.sub.Channels(),
+			Message: "updates since after are no longer available; reload what you show"}}
+	}
+
+	last := *req.After
+	msgs := make([]streamMessage, 0, len(events)+1)
+	for _, event := range events {
+		msgs = append(msgs, eventMessage(event))
+		last = event.ID
+	}
+	s.replayed = max(s.replayed, last)
+	return append(msgs, streamMessage{Type: "resumed", Seq: last})
+}
+
+// eventMessage is the message carrying a hub event
+func eventMessage(event stream.Event) streamMessage {
+	if len(event.Portfolio) > 0 {
+		return streamMessage{Type: "portfolio", Seq: event.ID, Events: event.Portfolio}
+	}
+	return streamMessage{Type: "market_data", Seq: event.ID, Data: event.Data}
+}
+
+// StreamMarketDataSSE pushes newly stored candles for ?symbols= as Server-Sent Events,
+// for clients that cannot use the WebSocket. Each event carries the hub's event ID, so
+// a reconnecting client resumes after its Last-Event-ID header; when events since then
+// are no longer buffered it is sent a resync event instead.
+func (h *Handler) StreamMarketDataSSE(c *gin.Context) {
+	symbols := normalizeSymbols(strings.Split(c.Query("symbols"), ","))
+	if len(symbols) == 0 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeMissingParameter,
+			Error: "symbols parameter is required",
+		})
+		return
+	}
+
+	var resumeFrom uint64
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorResponse{
+				Code:  apierror.CodeInvalidHeader,
+				Error: "Invalid Last-Event-ID header",
+			})
+			return
+		}
+		resumeFrom = id
+	}
+
+	userID := middleware.GetUserID(c)
+	sub := h.hub.Register(userID)
+	defer h.hub.Unregister(sub)
+
+	followed, ok := sub.Subscribe(symbols)
+	if !ok {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeValidationFailed,
+			Error:   "Too many symbols",
+			Message: fmt.Sprintf("At most %d symbols may be followed", stream.MaxSymbols),
+		})
+		return
+	}
+
+	h.logger.Info("Event stream opened", zap.String("user_id", userID), zap.Bool("resumed", lastEventID != ""))
+	defer h.logger.Info("Event stream closed", zap.String("user_id", userID))
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // keep nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	w := &sseWriter{c: c, rc: http.NewResponseController(c.Writer)}
+	w.line(fmt.Sprintf("retry: %d", sseRetry.Milliseconds()))
+
+	// Replay what a resuming client missed; live events it was also sent are skipped
+	var replayed uint64
+	if lastEventID != "" {
+		events, complete := h.hub.Since(sub, resumeFrom)
+		if complete {
+			replayed = resumeFrom
+		} else {
+			w.event(h.hub.LastID(), streamMessage{Type: "resync", Symbols: followed,
+				Message: "updates since Last-Event-ID are no longer available; reload the history"})
+		}
+		for _, event := range events {
+			w.event(event.ID, eventMessage(event))
+			replayed = event.ID
+		}
+	} else {
+		w.event(h.hub.LastID(), streamMessage{Type: "subscribed", Symbols: followed})
+	}
+	if w.err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for w.err == nil {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-sub.Done():
+			// Dropped for falling behind; the client reconnects and resumes
+			return
+		case event := <-sub.Updates():
+			if event.ID > replayed {
+				w.event(event.ID, eventMessage(event))
+			}
+		case <-heartbeat.C:
+			w.line(": heartbeat")
+		}
+	}
+}
+
+// sseWriter writes Server-Sent Events, flushing each one; the first error ends the
+// stream
+type sseWriter struct {
+	c   *gin.Context
+	rc  *http.ResponseController
+	err error
+}
+
+// event writes msg as an event named after its type
+func (w *sseWriter) event(id uint64, msg streamMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		w.err = err
+		return
+	}
+	w.write(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", id, msg.Type, data))
+}
+
+// line writes a field or comment on its own, such as the retry delay or a heartbeat
+func (w *sseWriter) line(line string) {
+	w.write(line + "\n\n")
+}
+
+func (w *sseWriter) write(s string) {
+	if w.err != nil {
+		return
+	}
+	// The HTTP server's write timeout would end the stream; each write gets its own.
+	// Writers that cannot take a deadline keep the server's, and the client reconnects.
+	_ = w.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if _, w.err = w.c.Writer.WriteString(s); w.err != nil {
+		return
+	}
+	w.c.Writer.Flush()
+}
+
+// normalizeSymbols upper-cases symbols, writes crypto pairs as BASE-QUOTE and drops blanks
+func normalizeSymbols(symbols []string) []string {
+	result := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = models.CanonicalSymbol(strings.ToUpper(strings.TrimSpace(symbol)))
+		if symbol != "" {
+			result = append(result, symbol)
+		}
+	}
+	return result
+}
+
+// normalizeChannels lower-cases channel names and drops blanks
+func normalizeChannels(channels []string) []string {
+	result := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if channel != "" {
+			result = append(result, channel)
+		}
+	}
+	return result
+}
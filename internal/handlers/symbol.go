@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListSymbols returns reference data for listings, optionally on one ?exchange= and
+// matching a typeahead ?search= on ticker or name
+func (h *Handler) ListSymbols(c *gin.Context) {
+	query := services.SymbolQuery{
+		Exchange: exchangeParam(c),
+		Search:   c.Query("search"),
+		Limit:    100,
+	}
+	if query.Search != "" {
+		query.Limit = 20
+	}
+	if v := c.Query("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 1000 {
+			query.Limit = l
+		}
+	}
+
+	symbols, err := h.symbolService.List(c.Request.Context(), query)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list symbols",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(symbols),
+		"symbols": symbols,
+	})
+}
+
+// GetSymbol returns the reference data of one listing
+func (h *Handler) GetSymbol(c *gin.Context) {
+	exchange, symbol := symbolKey(c)
+
+	result, err := h.symbolService.Get(c.Request.Context(), exchange, symbol)
+	if err != nil {
+		h.serviceError(c, "Failed to get symbol", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateSymbol creates or replaces a listing (admin)
+func (h *Handler) CreateSymbol(c *gin.Context) {
+	var req models.SymbolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.symbolService.Upsert(ctx, []models.SymbolRequest{req}); err != nil {
+		h.serviceError(c, "Failed to save symbol", err)
+		return
+	}
+
+	exchange := strings.ToUpper(req.Exchange)
+	if exchange == "" {
+		exchange = models.ExchangeForSymbol(req.Symbol)
+	}
+	result, err := h.symbolService.Get(ctx, exchange, strings.ToUpper(req.Symbol))
+	if err != nil {
+		h.serviceError(c, "Failed to get symbol", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Symbol saved",
+		Data:    result,
+	})
+}
+
+// UpdateSymbol changes the given fields of a listing (admin)
+func (h *Handler) UpdateSymbol(c *gin.Context) {
+	exchange, symbol := symbolKey(c)
+
+	var req models.UpdateSymbolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.symbolService.Update(c.Request.Context(), exchange, symbol, req)
+	if err != nil {
+		h.serviceError(c, "Failed to update symbol", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Symbol updated",
+		Data:    result,
+	})
+}
+
+// DeleteSymbol removes a listing without market data (admin)
+func (h *Handler) DeleteSymbol(c *gin.Context) {
+	exchange, symbol := symbolKey(c)
+
+	if err := h.symbolService.Delete(c.Request.Context(), exchange, symbol); err != nil {
+		h.serviceError(c, "Failed to delete symbol", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Symbol deleted",
+	})
+}
+
+// UploadSymbolsCSV imports reference data (admin). Columns: Exchange, Symbol, Name,
+// Sector, Currency, LotSize; empty exchange, currency and lot size take defaults.
+func (h *Handler) UploadSymbolsCSV(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeFileRequired,
+			Error: "No file uploaded",
+		})
+		return
+	}
+	defer file.Close()
+
+	h.logger.Info("Processing symbol CSV upload",
+		zap.String("filename", header.Filename),
+		zap.Int64("size", header.Size),
+	)
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidCSV,
+			Error:   "Failed to parse CSV",
+			Message: err.Error(),
+		})
+		return
+	}
+	if len(records) < 2 {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidCSV,
+			Error: "CSV file is empty or has no data rows",
+		})
+		return
+	}
+
+	var reqs []models.SymbolRequest
+	var errs []string
+	for i, record := range records[1:] {
+		if len(record) < 2 || strings.TrimSpace(record[1]) == "" {
+			errs = append(errs, fmt.Sprintf("Row %d: exchange and symbol columns are required", i+2))
+			continue
+		}
+		req := models.SymbolRequest{Exchange: record[0], Symbol: record[1]}
+		if len(record) > 2 {
+			req.Name = record[2]
+		}
+		if len(record) > 3 {
+			req.Sector = record[3]
+		}
+		if len(record) > 4 {
+			req.Currency = record[4]
+		}
+		if len(record) > 5 && strings.TrimSpace(record[5]) != "" {
+			lot, err := strconv.Atoi(strings.TrimSpace(record[5]))
+			if err != nil || lot < 1 {
+				errs = append(errs, fmt.Sprintf("Row %d: invalid lot size", i+2))
+				continue
+			}
+			req.LotSize = lot
+		}
+		reqs = append(reqs, req)
+	}
+
+	if len(reqs) > 0 {
+		if err := h.symbolService.Upsert(c.Request.Context(), reqs); err != nil {
+			h.serviceError(c, "Failed to import symbols", err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.SymbolImportResponse{
+		Message:      "Symbols imported",
+		RowsImported: len(reqs),
+		RowsSkipped:  len(errs),
+		Errors:       errs,
+	})
+}
+
+// requireSymbol answers 404 and returns false when symbol is not a known listing
+// (on exchange, when it is not "")
+func (h *Handler) requireSymbol(c *gin.Context, symbol, exchange string) bool {
+	known, err := h.symbolService.Exists(c.Request.Context(), symbol, exchange)
+	if err != nil {
+		if h.deadlineExceeded(c, err, nil) {
+			return false
+		}
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to look up symbol",
+		})
+		return false
+	}
+	if !known {
+		respondError(c, http.StatusNotFound, ErrorResponse{
+			Code:    apierror.CodeSymbolNotFound,
+			Error:   "Unknown symbol",
+			Message: fmt.Sprintf("%s is not a listed symbol; see GET /api/v1/symbols", symbol),
+		})
+		return false
+	}
+	return true
+}
+
+func symbolKey(c *gin.Context) (string, string) {
+	return strings.ToUpper(c.Param("exchange")), models.CanonicalSymbol(strings.ToUpper(c.Param("symbol")))
+}
+
+// symbolParam reads the :symbol path parameter, writing crypto pairs as BASE-QUOTE so
+// BTCUSDT finds the BTC-USDT series
+func symbolParam(c *gin.Context) string {
+	return models.CanonicalSymbol(c.Param("symbol"))
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	webhookclient "github.com/ridhomain/proto-trading-service/internal/clients/webhook"
+	"github.com/ridhomain/proto-trading-service/internal/middleware"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListWebhooks returns the user's webhooks, without their secrets
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookService.List(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		h.serviceError(c, "Failed to list webhooks", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":    len(webhooks),
+		"webhooks": webhooks,
+		"events":   models.WebhookEvents,
+	})
+}
+
+// CreateWebhook registers an endpoint for some of the user's events. The response
+// carries the secret that signs its deliveries; it is not shown again.
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	req, ok := webhookRequest(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Create(c.Request.Context(), middleware.GetUserID(c), req)
+	if err != nil {
+		h.serviceError(c, "Failed to create webhook", err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/webhooks/%d", webhook.ID))
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// GetWebhook returns one of the user's webhooks
+func (h *Handler) GetWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Get(c.Request.Context(), middleware.GetUserID(c), id)
+	if err != nil {
+		h.serviceError(c, "Failed to get webhook", err, zap.Int64("id", id))
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook replaces a webhook's URL, events and active flag, keeping its secret
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	req, ok := webhookRequest(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Update(c.Request.Context(), middleware.GetUserID(c), id, req)
+	if err != nil {
+		h.serviceError(c, "Failed to update webhook", err, zap.Int64("id", id))
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Delete(c.Request.Context(), middleware.GetUserID(c), id); err != nil {
+		h.serviceError(c, "Failed to delete webhook", err, zap.Int64("id", id))
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Webhook deleted",
+	})
+}
+
+// PingWebhook queues a ping delivery to test the endpoint
+func (h *Handler) PingWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Ping(c.Request.Context(), middleware.GetUserID(c), id)
+	if err != nil {
+		h.serviceError(c, "Failed to ping webhook", err, zap.Int64("id", id))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// ListWebhookDeliveries returns a webhook's most recent deliveries with their
+// payloads and outcomes, optionally only those with ?status=
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryFailed:
+	default:
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidParameter,
+			Error:   "Invalid status",
+			Message: "Use pending, delivered or failed",
+		})
+		return
+	}
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	deliveries, err := h.webhookService.Deliveries(c.Request.Context(), middleware.GetUserID(c), id, status, limit)
+	if err != nil {
+		h.serviceError(c, "Failed to list webhook deliveries", err, zap.Int64("id", id))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":      len(deliveries),
+		"deliveries": deliveries,
+	})
+}
+
+// RedeliverWebhookDelivery queues a new delivery of an earlier one's event and payload
+func (h *Handler) RedeliverWebhookDelivery(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("delivery"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid delivery id",
+		})
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), middleware.GetUserID(c), id, deliveryID)
+	if err != nil {
+		h.serviceError(c, "Failed to redeliver webhook delivery", err, zap.Int64("id", deliveryID))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// webhookRequest binds a webhook's settings, answering 400 and returning false when
+// they are invalid. Only http and https URLs are accepted, and not ones whose host is
+// or resolves to a loopback, private or link-local address.
+func webhookRequest(c *gin.Context) (models.WebhookRequest, bool) {
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeInvalidRequestBody,
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return req, false
+	}
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeValidationFailed,
+			Error:   "Invalid webhook URL",
+			Message: "Use an absolute http or https URL",
+		})
+		return req, false
+	}
+	if err := webhookclient.CheckURL(c.Request.Context(), req.URL); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:    apierror.CodeValidationFailed,
+			Error:   "Invalid webhook URL",
+			Message: "Webhooks can only be sent to publicly routable addresses",
+		})
+		return req, false
+	}
+	return req, true
+}
+
+func webhookID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorResponse{
+			Code:  apierror.CodeInvalidID,
+			Error: "Invalid webhook id",
+		})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,292 @@
+package importers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// Built-in formats
+const (
+	FormatMirae       = "mirae"       // Symbol, Date, Open, High, Low, Close, Volume[, Interval] by position
+	FormatYahoo       = "yahoo"       // Yahoo Finance history download, one symbol per file
+	FormatTradingView = "tradingview" // TradingView chart export, one symbol per file
+)
+
+// layoutUnix marks a date column holding Unix seconds
+const layoutUnix = "unix"
+
+func init() {
+	// Header-driven formats are detected first; unrecognised headers fall back to mirae
+	Register(FormatYahoo, &Profile{
+		Source:      "yahoo",
+		Date:        []string{"date"},
+		Open:        []string{"open"},
+		High:        []string{"high"},
+		Low:         []string{"low"},
+		Close:       []string{"close"},
+		AdjClose:    []string{"adj close"},
+		Volume:      []string{"volume"},
+		Markers:     []string{"adj close"},
+		DateLayouts: []string{"2006-01-02"},
+	})
+	Register(FormatTradingView, &Profile{
+		Source:      "tradingview",
+		Symbol:      []string{"symbol", "ticker"},
+		Date:        []string{"time"},
+		Open:        []string{"open"},
+		High:        []string{"high"},
+		Low:         []string{"low"},
+		Close:       []string{"close"},
+		Volume:      []string{"volume", "vol"},
+		DateLayouts: []string{layoutUnix, time.RFC3339, "2006-01-02"},
+	})
+	Register(FormatMirae, mirae{})
+}
+
+// Profile maps named header columns onto candle fields, so a new broker export can
+// be supported by describing its columns. Header names match case-insensitively.
+type Profile struct {
+	Source      string
+	Symbol      []string // optional; Options.Symbol is used when absent
+	Date        []string
+	Open        []string
+	High        []string
+	Low         []string
+	Close       []string
+	AdjClose    []string // optional
+	Volume      []string // optional
+	Interval    []string // optional; Options.Interval is used when absent
+	Markers     []string // further headers that must be present for detection
+	DateLayouts []string // tried in order; "unix" for Unix seconds, layouts with a time give intraday rows
+}
+
+// Detect reports whether the header has every required column and marker
+func (p *Profile) Detect(header []string) bool {
+	index := headerIndex(header)
+	for _, names := range [][]string{p.Date, p.Open, p.High, p.Low, p.Close} {
+		if column(index, names) < 0 {
+			return false
+		}
+	}
+	for _, marker := range p.Markers {
+		if _, ok := index[marker]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Bind locates the profile's columns in header
+func (p *Profile) Bind(header []string, opts Options) (RowFunc, error) {
+	index := headerIndex(header)
+
+	cols := map[string]int{}
+	for _, c := range []struct {
+		field string
+		names []string
+	}{
+		{"date", p.Date}, {"open", p.Open}, {"high", p.High}, {"low", p.Low}, {"close", p.Close},
+	} {
+		i := column(index, c.names)
+		if i < 0 {
+			return nil, fmt.Errorf("%w %s", ErrMissingColumn, c.field)
+		}
+		cols[c.field] = i
+	}
+	symbolCol := column(index, p.Symbol)
+	adjCloseCol := column(index, p.AdjClose)
+	volumeCol := column(index, p.Volume)
+	intervalCol := column(index, p.Interval)
+
+	symbol := strings.ToUpper(strings.TrimSpace(opts.Symbol))
+	if symbolCol < 0 && symbol == "" {
+		return nil, ErrSymbolRequired
+	}
+
+	return func(record []string) (models.MarketData, error) {
+		md := models.MarketData{
+			Symbol:   symbol,
+			Interval: opts.Interval,
+			Source:   p.Source,
+		}
+		if symbolCol >= 0 {
+			if s := strings.TrimSpace(field(record, symbolCol)); s != "" {
+				md.Symbol = s
+			}
+		}
+		if intervalCol >= 0 {
+			if s := strings.TrimSpace(field(record, intervalCol)); s != "" {
+				md.Interval = s
+			}
+		}
+
+		var err error
+		if md.Date, md.Timestamp, err = parseDate(field(record, cols["date"]), p.DateLayouts); err != nil {
+			return models.MarketData{}, err
+		}
+		for _, f := range []struct {
+			name string
+			dst  *float64
+		}{
+			{"high", &md.High}, {"low", &md.Low}, {"close", &md.Close},
+		} {
+			if *f.dst, err = parseNumber(f.name, field(record, cols[f.name])); err != nil {
+				return models.MarketData{}, err
+			}
+		}
+		// An empty open or volume is stored as null
+		if s := field(record, cols["open"]); strings.TrimSpace(s) != "" {
+			open, err := parseNumber("open", s)
+			if err != nil {
+				return models.MarketData{}, err
+			}
+			md.Open = &open
+		}
+		if adjCloseCol >= 0 && strings.TrimSpace(field(record, adjCloseCol)) != "" {
+			adjClose, err := parseNumber("adj close", field(record, adjCloseCol))
+			if err != nil {
+				return models.MarketData{}, err
+			}
+			md.AdjClose = &adjClose
+		}
+		if volumeCol >= 0 && strings.TrimSpace(field(record, volumeCol)) != "" {
+			volume, err := parseNumber("volume", field(record, volumeCol))
+			if err != nil {
+				return models.MarketData{}, err
+			}
+			v := int64(volume)
+			md.Volume = &v
+		}
+
+		if err := md.Normalize(); err != nil {
+			return models.MarketData{}, err
+		}
+		return md, nil
+	}, nil
+}
+
+// mirae reads the original upload layout by position, whatever the header says
+type mirae struct{}
+
+func (mirae) Detect(header []string) bool {
+	return len(header) >= 7 &&
+		strings.EqualFold(strings.TrimSpace(header[0]), "symbol") &&
+		strings.EqualFold(strings.TrimSpace(header[1]), "date")
+}
+
+func (mirae) Bind(_ []string, opts Options) (RowFunc, error) {
+	return func(record []string) (models.MarketData, error) {
+		if len(record) < 7 {
+			return models.MarketData{}, errors.New("insufficient columns")
+		}
+
+		var date, ts time.Time
+		date, err := time.Parse("2006-01-02", record[1])
+		if err != nil {
+			ts, err = time.Parse(time.RFC3339, record[1])
+			if err != nil {
+				return models.MarketData{}, errors.New("invalid date format")
+			}
+		}
+
+		high, _ := strconv.ParseFloat(record[3], 64)
+		low, _ := strconv.ParseFloat(record[4], 64)
+		close, _ := strconv.ParseFloat(record[5], 64)
+
+		md := models.MarketData{
+			Symbol:    record[0],
+			Date:      date,
+			Timestamp: ts,
+			High:      high,
+			Low:       low,
+			Close:     close,
+			Interval:  opts.Interval,
+			Source:    "mirae",
+		}
+		// Empty open and volume cells are stored as null
+		if s := strings.TrimSpace(record[2]); s != "" {
+			open, _ := strconv.ParseFloat(s, 64)
+			md.Open = &open
+		}
+		if s := strings.TrimSpace(record[6]); s != "" {
+			volume, _ := strconv.ParseInt(s, 10, 64)
+			md.Volume = &volume
+		}
+		if len(record) > 7 {
+			md.Interval = strings.TrimSpace(record[7])
+		}
+		if err := md.Normalize(); err != nil {
+			return models.MarketData{}, err
+		}
+		return md, nil
+	}, nil
+}
+
+// headerIndex maps lower-cased header names to their column, keeping the first of
+// any duplicates
+func headerIndex(header []string) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, dup := index[name]; !dup {
+			index[name] = i
+		}
+	}
+	return index
+}
+
+// column returns the position of the first of names present in index, or -1
+func column(index map[string]int, names []string) int {
+	for _, name := range names {
+		if i, ok := index[name]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func field(record []string, i int) string {
+	if i < len(record) {
+		return record[i]
+	}
+	return ""
+}
+
+// parseDate tries each layout, returning a date for date-only layouts and a
+// timestamp for those with a time of day
+func parseDate(s string, layouts []string) (date, ts time.Time, err error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range layouts {
+		if layout == layoutUnix {
+			secs, err := strconv.ParseInt(s, 10, 64)
+			if err == nil {
+				return time.Time{}, time.Unix(secs, 0).UTC(), nil
+			}
+			continue
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			return t, time.Time{}, nil
+		}
+		return time.Time{}, t, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// parseNumber reads a price or volume, rejecting blanks and placeholders such as
+// Yahoo's "null"
+func parseNumber(name, s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, s)
+	}
+	return v, nil
+}
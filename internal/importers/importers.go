@@ -0,0 +1,124 @@
+package importers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+var (
+	ErrUnknownFormat  = errors.New("unknown CSV format")
+	ErrMissingColumn  = errors.New("missing column")
+	ErrSymbolRequired = errors.New("symbol is required for exports without a symbol column")
+)
+
+// Options fills in what a single-instrument export leaves out
+type Options struct {
+	Symbol   string // used when the file has no symbol column
+	Interval string // used when the file has no interval column; defaults to 1d
+}
+
+// RowFunc turns one data row into a candle
+type RowFunc func(record []string) (models.MarketData, error)
+
+// Parser reads one broker or charting tool's CSV export
+type Parser interface {
+	// Detect reports whether a header row looks like this format
+	Detect(header []string) bool
+	// Bind prepares to read the rows that follow header
+	Bind(header []string, opts Options) (RowFunc, error)
+}
+
+// Default is the format used when no registered parser recognises a header
+const Default = FormatMirae
+
+var (
+	mu      sync.RWMutex
+	parsers = map[string]Parser{}
+	order   []string // detection order
+)
+
+// Register adds a parser under name. Parsers are tried for detection in the order
+// they were registered; registering a name twice panics.
+func Register(name string, p Parser) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	name = strings.ToLower(name)
+	if name == "" || p == nil {
+		panic("importers: Register needs a name and a parser")
+	}
+	if _, dup := parsers[name]; dup {
+		panic("importers: Register called twice for " + name)
+	}
+	parsers[name] = p
+	order = append(order, name)
+}
+
+// Formats lists the registered format names
+func Formats() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Valid reports whether format names a registered parser, or asks for detection
+// when empty or "auto"
+func Valid(format string) bool {
+	format = strings.ToLower(format)
+	if format == "" || format == "auto" {
+		return true
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := parsers[format]
+	return ok
+}
+
+// Open binds the parser for format to a file's header row, returning the format
+// used. An empty or "auto" format is detected from the header, falling back to Default.
+// Whatever the format, a row whose high and low do not bound it is an error.
+func Open(format string, header []string, opts Options) (RowFunc, string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	format = strings.ToLower(format)
+	if format == "" || format == "auto" {
+		format = Default
+		for _, name := range order {
+			if parsers[name].Detect(header) {
+				format = name
+				break
+			}
+		}
+	}
+
+	p, ok := parsers[format]
+	if !ok {
+		return nil, "", fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+	parse, err := p.Bind(header, opts)
+	if err != nil {
+		return nil, format, err
+	}
+	return func(record []string) (models.MarketData, error) {
+		md, err := parse(record)
+		if err != nil {
+			return models.MarketData{}, err
+		}
+		if err := md.ValidateOHLC(); err != nil {
+			return models.MarketData{}, err
+		}
+		return md, nil
+	}, format, nil
+}
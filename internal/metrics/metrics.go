@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "trading"
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency, by method and route.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "route"})
+
+	httpDeprecated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "deprecated_requests_total",
+		Help:      "Requests to endpoints slated for removal, by method and route.",
+	}, []string{"method", "route"})
+
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "HTTP requests currently being handled.",
+	})
+
+	sourceLastIngest = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ingest",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time a monitored source last delivered candles, by source.",
+	}, []string{"source"})
+
+	sourceStale = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ingest",
+		Name:      "source_stale",
+		Help:      "1 while a monitored source has delivered nothing within its window, by source.",
+	}, []string{"source"})
+)
+
+// registry holds the service's collectors alongside Go runtime and process metrics
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		httpDeprecated,
+		httpInFlight,
+		sourceLastIngest,
+		sourceStale,
+	)
+}
+
+// RequestStarted marks a request as in flight until the returned func is called
+func RequestStarted() func() {
+	httpInFlight.Inc()
+	return httpInFlight.Dec
+}
+
+// ObserveRequest records one handled request. route is the route template, not
+// the raw path, so label cardinality stays bounded.
+func ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// ObserveDeprecated records one request to a deprecated endpoint
+func ObserveDeprecated(method, route string) {
+	httpDeprecated.WithLabelValues(method, route).Inc()
+}
+
+// ObserveIngest records the ingest state of a monitored source; a zero lastIngest
+// means it never delivered
+func ObserveIngest(source string, lastIngest time.Time, stale bool) {
+	if !lastIngest.IsZero() {
+		sourceLastIngest.WithLabelValues(source).Set(float64(lastIngest.Unix()))
+	}
+	value := 0.0
+	if stale {
+		value = 1
+	}
+	sourceStale.WithLabelValues(source).Set(value)
+}
+
+// RegisterDB exports the connection pool figures from db.Stats() at scrape time
+func RegisterDB(db *database.DB) {
+	registry.MustRegister(newPoolCollector(db))
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
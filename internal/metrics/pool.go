@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"github.com/ridhomain/proto-trading-service/internal/database"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads pgxpool statistics on every scrape
+type poolCollector struct {
+	db *database.DB
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	lifetimeDestroyCount *prometheus.Desc
+	idleDestroyCount     *prometheus.Desc
+}
+
+func newPoolCollector(db *database.DB) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		db:                   db,
+		acquiredConns:        desc("acquired_conns", "Connections currently checked out of the pool."),
+		idleConns:            desc("idle_conns", "Idle connections in the pool."),
+		constructingConns:    desc("constructing_conns", "Connections being established."),
+		totalConns:           desc("total_conns", "Connections in the pool, in any state."),
+		maxConns:             desc("max_conns", "Maximum size of the pool."),
+		acquireCount:         desc("acquires_total", "Successful connection acquires."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Time spent waiting for successful acquires."),
+		canceledAcquireCount: desc("canceled_acquires_total", "Acquires cancelled by their context."),
+		emptyAcquireCount:    desc("empty_acquires_total", "Acquires that had to wait because the pool was empty."),
+		newConnsCount:        desc("new_conns_total", "Connections opened."),
+		lifetimeDestroyCount: desc("max_lifetime_destroys_total", "Connections closed for exceeding their maximum lifetime."),
+		idleDestroyCount:     desc("max_idle_destroys_total", "Connections closed for exceeding the maximum idle time."),
+	}
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(p, ch)
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := p.db.Stats()
+
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+
+	gauge(p.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(p.idleConns, float64(stat.IdleConns()))
+	gauge(p.constructingConns, float64(stat.ConstructingConns()))
+	gauge(p.totalConns, float64(stat.TotalConns()))
+	gauge(p.maxConns, float64(stat.MaxConns()))
+	counter(p.acquireCount, float64(stat.AcquireCount()))
+	counter(p.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(p.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	counter(p.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	counter(p.newConnsCount, float64(stat.NewConnsCount()))
+	counter(p.lifetimeDestroyCount, float64(stat.MaxLifetimeDestroyCount()))
+	counter(p.idleDestroyCount, float64(stat.MaxIdleDestroyCount()))
+}
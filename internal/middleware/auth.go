@@ -1,13 +1,17 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
 	"github.com/ridhomain/proto-trading-service/pkg/logger"
 	"go.uber.org/zap"
 )
@@ -27,16 +31,49 @@ type KratosSession struct {
 type AuthConfig struct {
 	KratosInternalURL string // For service-to-service calls (http://kratos:4433)
 	KratosBrowserURL  string // For browser redirects (http://localhost:4433)
+
+	client  *http.Client
+	breaker *breaker
+}
+
+// SessionOptions bounds session validation so a Kratos outage fails fast. Zero
+// values take the defaults.
+type SessionOptions struct {
+	Timeout          time.Duration // per whoami call; default 3s
+	FailureThreshold int           // consecutive outages that open the breaker; default 5
+	Cooldown         time.Duration // how long an open breaker refuses calls; default 30s
 }
 
+// ErrAuthUnavailable is returned when Kratos cannot be reached or the breaker is open
+var ErrAuthUnavailable = errors.New("authentication service unavailable")
+
 var authConfig *AuthConfig
 
 // InitAuthConfig initializes the authentication configuration
-func InitAuthConfig(internalURL, browserURL string) {
+func InitAuthConfig(internalURL, browserURL string, opts SessionOptions) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 3 * time.Second
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 30 * time.Second
+	}
 	authConfig = &AuthConfig{
 		KratosInternalURL: internalURL,
 		KratosBrowserURL:  browserURL,
+		client:            &http.Client{Timeout: opts.Timeout},
+		breaker:           newBreaker(opts.FailureThreshold, opts.Cooldown),
+	}
+}
+
+// AuthBreakerStatus reports the state of the breaker around session validation
+func AuthBreakerStatus() BreakerStatus {
+	if authConfig == nil {
+		return BreakerStatus{State: BreakerClosed}
 	}
+	return authConfig.breaker.status()
 }
 
 // AuthRequired validates the session with Ory Kratos
@@ -44,10 +81,9 @@ func AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if authConfig == nil {
 			logger.Error("Auth config not initialized")
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Authentication service not configured",
+			apierror.Abort(c, http.StatusInternalServerError, apierror.Response{
+				Error: "Authentication service not configured",
 			})
-			c.Abort()
 			return
 		}
 
@@ -60,30 +96,44 @@ func AuthRequired() gin.HandlerFunc {
 				zap.String("user_agent", c.Request.UserAgent()),
 			)
 
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":     "Authentication required",
-				"login_url": authConfig.KratosBrowserURL + "/self-service/login/browser",
-				"kratos_ui": "http://localhost:4455/login",
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Response{
+				Code:  apierror.CodeUnauthenticated,
+				Error: "Authentication required",
+				Details: gin.H{
+					"login_url": authConfig.KratosBrowserURL + "/self-service/login/browser",
+					"kratos_ui": "http://localhost:4455/login",
+				},
 			})
-			c.Abort()
 			return
 		}
 
 		// Validate session with Kratos
-		session, err := validateSession(sessionToken)
+		session, err := validateSession(c.Request.Context(), sessionToken)
 		if err != nil {
+			if IsDeadlineExceeded(c, err) {
+				RespondDeadlineExceeded(c, nil)
+				c.Abort()
+				return
+			}
+			if errors.Is(err, ErrAuthUnavailable) {
+				respondAuthUnavailable(c, err)
+				return
+			}
+
 			logger.Error("Session validation failed",
 				zap.Error(err),
 				zap.String("token_hint", maskToken(sessionToken)),
 				zap.String("path", c.Request.URL.Path),
 			)
 
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":     "Invalid or expired session",
-				"login_url": authConfig.KratosBrowserURL + "/self-service/login/browser",
-				"kratos_ui": "http://localhost:4455/login",
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Response{
+				Code:  apierror.CodeSessionInvalid,
+				Error: "Invalid or expired session",
+				Details: gin.H{
+					"login_url": authConfig.KratosBrowserURL + "/self-service/login/browser",
+					"kratos_ui": "http://localhost:4455/login",
+				},
 			})
-			c.Abort()
 			return
 		}
 
@@ -93,12 +143,14 @@ func AuthRequired() gin.HandlerFunc {
 				zap.String("identity_id", session.Identity.ID),
 			)
 
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":     "Session inactive",
-				"login_url": authConfig.KratosBrowserURL + "/self-service/login/browser",
-				"kratos_ui": "http://localhost:4455/login",
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Response{
+				Code:  apierror.CodeSessionInvalid,
+				Error: "Session inactive",
+				Details: gin.H{
+					"login_url": authConfig.KratosBrowserURL + "/self-service/login/browser",
+					"kratos_ui": "http://localhost:4455/login",
+				},
 			})
-			c.Abort()
 			return
 		}
 
@@ -109,12 +161,14 @@ func AuthRequired() gin.HandlerFunc {
 				zap.Time("expires_at", session.ExpiresAt),
 			)
 
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":     "Session expired",
-				"login_url": authConfig.KratosBrowserURL + "/self-service/login/browser",
-				"kratos_ui": "http://localhost:4455/login",
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Response{
+				Code:  apierror.CodeSessionExpired,
+				Error: "Session expired",
+				Details: gin.H{
+					"login_url": authConfig.KratosBrowserURL + "/self-service/login/browser",
+					"kratos_ui": "http://localhost:4455/login",
+				},
 			})
-			c.Abort()
 			return
 		}
 
@@ -138,53 +192,42 @@ func AuthRequired() gin.HandlerFunc {
 	}
 }
 
-// RoleRequired checks if user has required role
+// RoleRequired checks if any of the user's roles is requiredRole. Prefer
+// PermissionRequired, which follows the configured role mapping.
 func RoleRequired(requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		traits, exists := c.Get("user_traits")
-		if !exists {
+		if _, exists := c.Get("user_traits"); !exists {
 			logger.Error("No user traits found in context")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied - no user context",
+			apierror.Abort(c, http.StatusForbidden, apierror.Response{
+				Error: "Access denied - no user context",
 			})
-			c.Abort()
 			return
 		}
 
-		traitsMap, ok := traits.(map[string]interface{})
-		if !ok {
-			logger.Error("Invalid user traits format")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied - invalid user data",
-			})
-			c.Abort()
-			return
-		}
-
-		role, hasRole := traitsMap["role"].(string)
-		if !hasRole {
-			role = "trader" // Default role
+		roles := GetUserRoles(c)
+		for _, role := range roles {
+			if role == requiredRole {
+				c.Next()
+				return
+			}
 		}
 
-		if role != requiredRole {
-			userID := GetUserID(c)
-			logger.Warn("Insufficient permissions",
-				zap.String("user_id", userID),
-				zap.String("user_role", role),
-				zap.String("required_role", requiredRole),
-				zap.String("path", c.Request.URL.Path),
-			)
+		userID := GetUserID(c)
+		logger.Warn("Insufficient permissions",
+			zap.String("user_id", userID),
+			zap.Strings("user_roles", roles),
+			zap.String("required_role", requiredRole),
+			zap.String("path", c.Request.URL.Path),
+		)
 
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":         "Insufficient permissions",
+		apierror.Abort(c, http.StatusForbidden, apierror.Response{
+			Code:  apierror.CodeInsufficientPermissions,
+			Error: "Insufficient permissions",
+			Details: gin.H{
 				"required_role": requiredRole,
-				"user_role":     role,
-			})
-			c.Abort()
-			return
-		}
-
-		c.Next()
+				"user_roles":    roles,
+			},
+		})
 	}
 }
 
@@ -215,12 +258,36 @@ func extractSessionToken(c *gin.Context) string {
 	return ""
 }
 
-// validateSession checks the session with Kratos internal API
-func validateSession(sessionToken string) (*KratosSession, error) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// validateSession checks the session with Kratos internal API. Outages count
+// towards the breaker and are returned as ErrAuthUnavailable; while the breaker is
+// open Kratos is not called at all.
+func validateSession(ctx context.Context, sessionToken string) (*KratosSession, error) {
+	allowed, _ := authConfig.breaker.allow()
+	if !allowed {
+		return nil, fmt.Errorf("%w: circuit breaker open", ErrAuthUnavailable)
 	}
 
+	session, err := whoami(ctx, sessionToken)
+	switch {
+	case err == nil, errors.Is(err, errSessionRejected):
+		authConfig.breaker.success()
+	case ctx.Err() != nil:
+		// The caller gave up; that says nothing about Kratos
+		authConfig.breaker.release()
+	default:
+		if authConfig.breaker.failure() {
+			logger.Error("Kratos unavailable, opening circuit breaker", zap.Error(err))
+		}
+		err = fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+	return session, err
+}
+
+// errSessionRejected marks Kratos answering that a session is not valid
+var errSessionRejected = errors.New("session rejected")
+
+// whoami asks Kratos for the session behind sessionToken
+func whoami(ctx context.Context, sessionToken string) (*KratosSession, error) {
 	// Use internal Kratos URL for service-to-service communication
 	url := authConfig.KratosInternalURL + "/sessions/whoami"
 
@@ -232,7 +299,7 @@ func validateSession(sessionToken string) (*KratosSession, error) {
 		zap.String("token_hint", maskToken(sessionToken)),
 	)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -248,7 +315,7 @@ func validateSession(sessionToken string) (*KratosSession, error) {
 	// Add user agent
 	req.Header.Set("User-Agent", "proto-trading-service/1.0")
 
-	resp, err := client.Do(req)
+	resp, err := authConfig.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("network error contacting Kratos: %w", err)
 	}
@@ -270,16 +337,39 @@ func validateSession(sessionToken string) (*KratosSession, error) {
 		return &session, nil
 
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized: invalid or expired session")
+		return nil, fmt.Errorf("%w: invalid or expired session", errSessionRejected)
 
 	case http.StatusForbidden:
-		return nil, fmt.Errorf("forbidden: session validation failed")
+		return nil, fmt.Errorf("%w: session validation failed", errSessionRejected)
 
 	default:
 		return nil, fmt.Errorf("unexpected response from Kratos: %d", resp.StatusCode)
 	}
 }
 
+// respondAuthUnavailable answers 503 during a Kratos outage, with Retry-After
+// set to when the breaker next lets a call through
+func respondAuthUnavailable(c *gin.Context, err error) {
+	status := AuthBreakerStatus()
+	retryAfter := status.RetryAfter
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	logger.Warn("Authentication unavailable",
+		zap.String("path", c.Request.URL.Path),
+		zap.String("breaker", status.State),
+		zap.Error(err),
+	)
+
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	apierror.Abort(c, http.StatusServiceUnavailable, apierror.Response{
+		Code:    apierror.CodeAuthUnavailable,
+		Error:   "Authentication service unavailable",
+		Details: gin.H{"retry_after": retryAfter},
+	})
+}
+
 // GetUserID extracts user ID from context
 func GetUserID(c *gin.Context) string {
 	if userID, exists := c.Get("user_id"); exists {
@@ -288,6 +378,15 @@ func GetUserID(c *gin.Context) string {
 	return ""
 }
 
+// GetIdentityID extracts the Kratos identity ID from context, which differs from
+// GetUserID when the identity is linked to another profile
+func GetIdentityID(c *gin.Context) string {
+	if identityID, exists := c.Get("identity_id"); exists {
+		return identityID.(string)
+	}
+	return GetUserID(c)
+}
+
 // GetUserEmail extracts user email from context
 func GetUserEmail(c *gin.Context) string {
 	if traits, exists := c.Get("user_traits"); exists {
@@ -300,6 +399,19 @@ func GetUserEmail(c *gin.Context) string {
 	return ""
 }
 
+// GetUserOrganization extracts the organization trait from context, empty for
+// users outside any organization
+func GetUserOrganization(c *gin.Context) string {
+	if traits, exists := c.Get("user_traits"); exists {
+		if traitsMap, ok := traits.(map[string]interface{}); ok {
+			if organization, ok := traitsMap["organization"].(string); ok {
+				return organization
+			}
+		}
+	}
+	return ""
+}
+
 // GetUserRole extracts user role from context
 func GetUserRole(c *gin.Context) string {
 	if traits, exists := c.Get("user_traits"); exists {
@@ -309,7 +421,7 @@ func GetUserRole(c *gin.Context) string {
 			}
 		}
 	}
-	return "trader" // Default role
+	return DefaultRole
 }
 
 // GetSessionID extracts session ID from context
@@ -342,7 +454,7 @@ func OptionalAuth() gin.HandlerFunc {
 			return
 		}
 
-		session, err := validateSession(sessionToken)
+		session, err := validateSession(c.Request.Context(), sessionToken)
 		if err != nil || !session.Active {
 			// Don't fail, just continue without user context
 			c.Next()
@@ -358,3 +470,41 @@ func OptionalAuth() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// IdentityResolver maps a Kratos identity ID to the canonical profile ID it is linked to
+type IdentityResolver func(ctx context.Context, identityID string) (string, error)
+
+// ResolveIdentity replaces user_id with the canonical profile ID for linked identities,
+// keeping the original Kratos identity ID as identity_id
+func ResolveIdentity(resolve IdentityResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identityID := GetUserID(c)
+		if identityID == "" {
+			c.Next()
+			return
+		}
+
+		c.Set("identity_id", identityID)
+
+		canonicalID, err := resolve(c.Request.Context(), identityID)
+		if err != nil {
+			// Fall back to the identity itself rather than failing the request
+			logger.Warn("Failed to resolve canonical user",
+				zap.String("identity_id", identityID),
+				zap.Error(err),
+			)
+			c.Next()
+			return
+		}
+
+		if canonicalID != identityID {
+			c.Set("user_id", canonicalID)
+			logger.Debug("Resolved linked identity",
+				zap.String("identity_id", identityID),
+				zap.String("user_id", canonicalID),
+			)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // calls go through
+	BreakerOpen     = "open"      // calls are refused until the cool-down ends
+	BreakerHalfOpen = "half_open" // one probe call decides whether to close again
+)
+
+// BreakerStatus is a snapshot of a circuit breaker
+type BreakerStatus struct {
+	State      string     `json:"state"`
+	Failures   int        `json:"consecutive_failures"`
+	OpenedAt   *time.Time `json:"opened_at,omitempty"`
+	RetryAfter int        `json:"retry_after_seconds,omitempty"` // while open
+}
+
+// breaker opens after threshold consecutive failures and refuses calls for
+// cooldown, then lets a single probe through
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may go ahead, and if not how long until the next
+// probe. A caller that is allowed must report the outcome with success or failure.
+func (b *breaker) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return true, 0
+	}
+	if wait := time.Until(b.openedAt.Add(b.cooldown)); wait > 0 {
+		return false, wait
+	}
+	if b.probing {
+		return false, time.Second
+	}
+	b.probing = true
+	return true, 0
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.openedAt = time.Time{}
+	b.probing = false
+}
+
+// failure counts a failed call, returning true when it opened the breaker
+func (b *breaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.probing || (b.openedAt.IsZero() && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.probing = false
+		return true
+	}
+	return false
+}
+
+// release ends a call that neither succeeded nor failed, e.g. one the client
+// abandoned, so a probe slot is not held forever
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *breaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := BreakerStatus{State: BreakerClosed, Failures: b.failures}
+	if b.openedAt.IsZero() {
+		return s
+	}
+	openedAt := b.openedAt
+	s.OpenedAt = &openedAt
+	if wait := time.Until(b.openedAt.Add(b.cooldown)); wait > 0 {
+		s.State = BreakerOpen
+		s.RetryAfter = int((wait + time.Second - 1) / time.Second)
+	} else {
+		s.State = BreakerHalfOpen
+	}
+	return s
+}
@@ -67,6 +67,7 @@ func CORS() gin.HandlerFunc {
 			"X-Request-ID",
 			"X-User-ID",
 			"X-API-Key",
+			"X-Request-Deadline",
 
 			// Content negotiation
 			"Accept-Language",
@@ -86,7 +87,15 @@ func CORS() gin.HandlerFunc {
 			"X-Session-ID",
 			"Location",
 			"X-Total-Count", // For pagination
+			"Link",
 			"X-Rate-Limit",  // For rate limiting info
+			"X-Data-Source", // Data freshness
+			"X-Data-As-Of",
+			"X-RateLimit-Limit", // Quota usage
+			"X-RateLimit-Remaining",
+			"X-RateLimit-Reset",
+			"X-RateLimit-Warning",
+			"Retry-After",
 		},
 		AllowCredentials: true, // Essential for cookie-based auth
 		MaxAge:           12 * time.Hour,
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Deadline propagates the gateway's X-Request-Deadline into the request context so
+// Kratos calls and DB queries stop once the client has given up. The header is either
+// an absolute RFC3339 timestamp or a remaining budget in milliseconds.
+func Deadline() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("X-Request-Deadline")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		deadline, err := parseDeadline(header, time.Now())
+		if err != nil {
+			apierror.Abort(c, http.StatusBadRequest, apierror.Response{
+				Code:    apierror.CodeInvalidHeader,
+				Error:   "Invalid X-Request-Deadline header",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.Set("request_deadline", deadline)
+
+		if !time.Now().Before(deadline) {
+			RespondDeadlineExceeded(c, nil)
+			c.Abort()
+			return
+		}
+
+		ctx, cancel := context.WithDeadline(c.Request.Context(), deadline)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		// Handlers that bailed out without responding still owe the client an answer
+		if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
+			RespondDeadlineExceeded(c, nil)
+			c.Abort()
+		}
+	}
+}
+
+// IsDeadlineExceeded reports whether err or the request context ran out of budget
+func IsDeadlineExceeded(c *gin.Context, err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) ||
+		c.Request.Context().Err() == context.DeadlineExceeded
+}
+
+// RespondDeadlineExceeded writes a 504 including any partial progress the handler made
+func RespondDeadlineExceeded(c *gin.Context, partial gin.H) {
+	details := gin.H{
+		"partial": len(partial) > 0,
+	}
+	if deadline, ok := c.Get("request_deadline"); ok {
+		details["deadline"] = deadline
+	}
+	if len(partial) > 0 {
+		details["completed"] = partial
+	}
+
+	logger.Warn("Request deadline exceeded",
+		zap.String("path", c.Request.URL.Path),
+		zap.String("method", c.Request.Method),
+		zap.Bool("partial", len(partial) > 0),
+	)
+
+	apierror.Respond(c, http.StatusGatewayTimeout, apierror.Response{
+		Error:   "Request deadline exceeded",
+		Details: details,
+	})
+}
+
+func parseDeadline(value string, now time.Time) (time.Time, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if ms < 0 {
+			return time.Time{}, fmt.Errorf("budget must not be negative")
+		}
+		return now.Add(time.Duration(ms) * time.Millisecond), nil
+	}
+
+	deadline, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC3339 timestamp or milliseconds budget")
+	}
+	return deadline, nil
+}
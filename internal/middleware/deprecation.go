@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ridhomain/proto-trading-service/internal/metrics"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecationRegistry knows the endpoints slated for removal and counts who still
+// calls them
+type DeprecationRegistry interface {
+	Lookup(method, route string) (models.Deprecation, bool)
+	Record(method, route, userID, userAgent string)
+}
+
+var deprecationRegistry DeprecationRegistry
+
+// InitDeprecations sets the registry Deprecations consults
+func InitDeprecations(registry DeprecationRegistry) {
+	deprecationRegistry = registry
+}
+
+// Deprecations answers calls to deprecated endpoints with a Deprecation header
+// (RFC 9745), a Sunset header (RFC 8594) when a removal date is set and a Link to the
+// replacement, then counts the call against its client. Other requests pass through.
+func Deprecations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if deprecationRegistry == nil || route == "" {
+			c.Next()
+			return
+		}
+		d, ok := deprecationRegistry.Lookup(c.Request.Method, route)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		c.Header("Deprecation", fmt.Sprintf("@%d", d.DeprecatedAt.Unix()))
+		if d.SunsetAt != nil {
+			c.Header("Sunset", d.SunsetAt.UTC().Format(http.TimeFormat))
+		}
+		if d.Replacement != "" {
+			c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, d.Replacement))
+		}
+
+		c.Next()
+
+		// The user is known once the route's own middleware has authenticated the call
+		metrics.ObserveDeprecated(c.Request.Method, route)
+		deprecationRegistry.Record(c.Request.Method, route, GetUserID(c), c.Request.UserAgent())
+	}
+}
@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
 	"github.com/ridhomain/proto-trading-service/pkg/logger"
 	"go.uber.org/zap"
 )
@@ -15,6 +17,7 @@ func Logger() gin.HandlerFunc {
 		start := time.Now()
 		path := c.Request.URL.Path
 		raw := c.Request.URL.RawQuery
+		c.Set("request_start", start)
 
 		// Process request
 		c.Next()
@@ -74,10 +77,9 @@ func Recovery() gin.HandlerFunc {
 					zap.String("method", c.Request.Method),
 				)
 
-				c.JSON(500, gin.H{
-					"error": "Internal server error",
+				apierror.Abort(c, http.StatusInternalServerError, apierror.Response{
+					Error: "Internal server error",
 				})
-				c.Abort()
 			}
 		}()
 		c.Next()
@@ -92,12 +94,20 @@ func RequestID() gin.HandlerFunc {
 			requestID = generateRequestID()
 		}
 
-		c.Set("request_id", requestID)
+		c.Set(apierror.RequestIDKey, requestID)
 		c.Header("X-Request-ID", requestID)
 		c.Next()
 	}
 }
 
+// GetRequestStart returns when the request reached the Logger middleware, or the zero time
+func GetRequestStart(c *gin.Context) time.Time {
+	if start, exists := c.Get("request_start"); exists {
+		return start.(time.Time)
+	}
+	return time.Time{}
+}
+
 func generateRequestID() string {
 	// Simple implementation, consider using UUID in production
 	return fmt.Sprintf("%d", time.Now().UnixNano())
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records request counts, latencies and status codes per route
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		done := metrics.RequestStarted()
+		defer done()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultRole is assigned to identities without role traits
+const DefaultRole = "trader"
+
+// PermissionResolver returns everything a set of roles grants
+type PermissionResolver func(ctx context.Context, roles []string) ([]string, error)
+
+var permissionResolver PermissionResolver
+
+// InitRBAC sets how PermissionRequired resolves roles to permissions
+func InitRBAC(resolve PermissionResolver) {
+	permissionResolver = resolve
+}
+
+// PermissionRequired lets the request through when any of the user's roles grants
+// permission, directly or through resource:* or *
+func PermissionRequired(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if permissionResolver == nil {
+			logger.Error("RBAC not initialized")
+			apierror.Abort(c, http.StatusInternalServerError, apierror.Response{
+				Error: "Authorization service not configured",
+			})
+			return
+		}
+
+		roles := GetUserRoles(c)
+		granted, err := GetPermissions(c)
+		if err != nil {
+			if IsDeadlineExceeded(c, err) {
+				RespondDeadlineExceeded(c, nil)
+				c.Abort()
+				return
+			}
+			logger.Error("Failed to resolve permissions",
+				zap.Strings("roles", roles),
+				zap.Error(err),
+			)
+			apierror.Abort(c, http.StatusInternalServerError, apierror.Response{
+				Error: "Failed to resolve permissions",
+			})
+			return
+		}
+
+		if !HasPermission(granted, permission) {
+			logger.Warn("Insufficient permissions",
+				zap.String("user_id", GetUserID(c)),
+				zap.Strings("user_roles", roles),
+				zap.String("required_permission", permission),
+				zap.String("path", c.Request.URL.Path),
+			)
+
+			apierror.Abort(c, http.StatusForbidden, apierror.Response{
+				Code:  apierror.CodeInsufficientPermissions,
+				Error: "Insufficient permissions",
+				Details: gin.H{
+					"required_permission": permission,
+					"user_roles":          roles,
+				},
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetPermissions resolves the user's permissions once per request
+func GetPermissions(c *gin.Context) ([]string, error) {
+	if permissions, exists := c.Get("permissions"); exists {
+		return permissions.([]string), nil
+	}
+	permissions, err := permissionResolver(c.Request.Context(), GetUserRoles(c))
+	if err != nil {
+		return nil, err
+	}
+	c.Set("permissions", permissions)
+	return permissions, nil
+}
+
+// HasPermission reports whether granted includes permission, directly or through
+// resource:* or *
+func HasPermission(granted []string, permission string) bool {
+	resource, _, _ := strings.Cut(permission, ":")
+	for _, g := range granted {
+		if g == "*" || g == permission || g == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUserRoles extracts the user's roles from the "roles" trait list and the
+// single "role" trait, defaulting to DefaultRole
+func GetUserRoles(c *gin.Context) []string {
+	var roles []string
+	seen := map[string]bool{}
+	add := func(role string) {
+		if role != "" && !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+
+	if traits, exists := c.Get("user_traits"); exists {
+		if traitsMap, ok := traits.(map[string]interface{}); ok {
+			if role, ok := traitsMap["role"].(string); ok {
+				add(role)
+			}
+			if list, ok := traitsMap["roles"].([]interface{}); ok {
+				for _, r := range list {
+					if role, ok := r.(string); ok {
+						add(role)
+					}
+				}
+			}
+		}
+	}
+	if len(roles) == 0 {
+		roles = []string{DefaultRole}
+	}
+	return roles
+}
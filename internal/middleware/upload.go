@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ridhomain/proto-trading-service/internal/apierror"
+)
+
+// MaxBodySize refuses request bodies larger than limit bytes. Bodies that declare
+// their length are refused up front; others fail with an *http.MaxBytesError once
+// the handler reads past the limit. A limit of zero or less disables the check.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			RespondTooLarge(c, limit, nil)
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		c.Next()
+	}
+}
+
+// TooLarge returns the limit err was refused under when it came from reading past
+// MaxBodySize's limit
+func TooLarge(err error) (int64, bool) {
+	var maxErr *http.MaxBytesError
+	if !errors.As(err, &maxErr) {
+		return 0, false
+	}
+	return maxErr.Limit, true
+}
+
+// RespondTooLarge writes a 413 including any partial progress the handler made
+func RespondTooLarge(c *gin.Context, limit int64, partial gin.H) {
+	resp := apierror.Response{
+		Error:   "Upload too large",
+		Message: fmt.Sprintf("uploads are limited to %d bytes", limit),
+	}
+	if len(partial) > 0 {
+		resp.Details = gin.H{"completed": partial}
+	}
+	apierror.Respond(c, http.StatusRequestEntityTooLarge, resp)
+}
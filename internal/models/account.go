@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// UserLink maps a secondary Kratos identity to a canonical profile
+type UserLink struct {
+	LinkedUserID    string    `json:"linked_user_id" db:"linked_user_id"`
+	CanonicalUserID string    `json:"canonical_user_id" db:"canonical_user_id"`
+	LinkedEmail     string    `json:"linked_email" db:"linked_email"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// LinkToken is handed to the canonical identity and redeemed by the identity being linked
+type LinkToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MergeConflict records a value that could not be merged and which side was kept. For
+// a portfolio_name conflict, Kept is the name the canonical profile's portfolio keeps
+// and Discarded the name the linked portfolio was moved under instead.
+type MergeConflict struct {
+	Field     string `json:"field"`
+	Kept      string `json:"kept"`
+	Discarded string `json:"discarded"`
+}
+
+// LinkResult summarizes what was merged into the canonical profile
+type LinkResult struct {
+	CanonicalUserID string          `json:"canonical_user_id"`
+	LinkedUserID    string          `json:"linked_user_id"`
+	WatchlistAdded  []string        `json:"watchlist_added"`
+	SymbolsAdded    []string        `json:"selected_symbols_added"`
+	PortfoliosAdded []string        `json:"portfolios_added"` // under their names in the canonical profile
+	Conflicts       []MergeConflict `json:"conflicts"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Actions taken on an anomalous candle during ingestion
+const (
+	AnomalyRejected    = "rejected"
+	AnomalyQuarantined = "quarantined"
+	AnomalyFlagged     = "flagged"
+	AnomalyCorrected   = "corrected"
+)
+
+// Anomaly records a candle that tripped a check during ingestion and what was done with it
+type Anomaly struct {
+	ID        int64      `json:"id,omitempty"`
+	Symbol    string     `json:"symbol"`
+	Date      time.Time  `json:"date"`
+	Source    string     `json:"source"`
+	Kinds     []string   `json:"kinds"`
+	Details   []string   `json:"details"`
+	Policy    string     `json:"policy"`
+	Action    string     `json:"action"`
+	Raw       MarketData `json:"raw"` // the candle as received and transformed, before any correction
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+}
+
+// ScreenReport summarizes anomaly screening of an ingest batch
+type ScreenReport struct {
+	Checked     int       `json:"checked"`
+	Transformed int       `json:"transformed,omitempty"` // candles rewritten by their source's transform rules
+	Accepted    int       `json:"accepted"`
+	Flagged     int       `json:"flagged"`
+	Corrected   int       `json:"corrected"`
+	Quarantined int       `json:"quarantined"`
+	Rejected    int       `json:"rejected"`
+	Anomalies   []Anomaly `json:"anomalies"`
+}
+
+// Merge accumulates the report of another batch from the same ingest
+func (r *ScreenReport) Merge(other *ScreenReport) {
+	if other == nil {
+		return
+	}
+	r.Checked += other.Checked
+	r.Transformed += other.Transformed
+	r.Accepted += other.Accepted
+	r.Flagged += other.Flagged
+	r.Corrected += other.Corrected
+	r.Quarantined += other.Quarantined
+	r.Rejected += other.Rejected
+	r.Anomalies = append(r.Anomalies, other.Anomalies...)
+}
+
+// UpdateAnomalyPolicyRequest sets how a source's anomalies are handled
+type UpdateAnomalyPolicyRequest struct {
+	Policy string `json:"policy" binding:"required"`
+}
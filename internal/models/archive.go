@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ArchiveRun reports one pass moving candles dated before a cutoff to the archive
+// store
+type ArchiveRun struct {
+	Before     time.Time  `json:"before"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Segments   int        `json:"segments"` // listing, interval, source and year objects written
+	Rows       int64      `json:"rows"`     // candles moved out of the database
+	Bytes      int64      `json:"bytes"`    // compressed size of the objects written
+	Failed     int        `json:"failed"`   // segments left in the database after an error
+}
+
+// ArchiveStatus summarizes what the archive store holds and the latest run
+type ArchiveStatus struct {
+	Enabled    bool        `json:"enabled"`
+	AfterYears int         `json:"after_years,omitempty"`
+	Before     *time.Time  `json:"before,omitempty"` // candles dated earlier are archived
+	Running    bool        `json:"running"`
+	Segments   int64       `json:"segments"`
+	Rows       int64       `json:"rows"`
+	Bytes      int64       `json:"bytes"`
+	OldestYear *int        `json:"oldest_year,omitempty"`
+	NewestYear *int        `json:"newest_year,omitempty"`
+	LastRun    *ArchiveRun `json:"last_run,omitempty"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import "time"
+
+// Coupon types
+const (
+	CouponFixed    = "fixed"
+	CouponFloating = "floating" // e.g. SBR, whose rate is reset with a floor
+)
+
+// Day-count conventions used to accrue interest within a coupon period
+const (
+	DayCountActAct = "ACT/ACT"
+	DayCountAct365 = "ACT/365"
+	DayCount30360  = "30/360"
+)
+
+// Bond holds the terms of a fixed-income instrument. Prices are percent of par and
+// a holding's quantity is its face value.
+type Bond struct {
+	Symbol          string    `json:"symbol" db:"symbol"`
+	Name            string    `json:"name" db:"name"`
+	Issuer          string    `json:"issuer" db:"issuer"`
+	Currency        string    `json:"currency" db:"currency"`
+	CouponType      string    `json:"coupon_type" db:"coupon_type"`
+	CouponRate      float64   `json:"coupon_rate" db:"coupon_rate"`           // annual percent
+	CouponFrequency int       `json:"coupon_frequency" db:"coupon_frequency"` // payments per year
+	DayCount        string    `json:"day_count" db:"day_count"`
+	IssueDate       time.Time `json:"issue_date" db:"issue_date"`
+	MaturityDate    time.Time `json:"maturity_date" db:"maturity_date"`
+	Tradable        bool      `json:"tradable" db:"tradable"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// BondRequest creates or replaces a bond; its coupon schedule is generated from the
+// terms unless coupons are stored separately
+type BondRequest struct {
+	Symbol          string  `json:"symbol" binding:"required,max=20"`
+	Name            string  `json:"name" binding:"max=200"`
+	Issuer          string  `json:"issuer" binding:"max=200"`
+	Currency        string  `json:"currency" binding:"omitempty,len=3"`
+	CouponType      string  `json:"coupon_type" binding:"omitempty,oneof=fixed floating"`
+	CouponRate      float64 `json:"coupon_rate" binding:"min=0"`
+	CouponFrequency int     `json:"coupon_frequency" binding:"required,oneof=1 2 4 12"`
+	DayCount        string  `json:"day_count" binding:"omitempty,oneof=ACT/ACT ACT/365 30/360"`
+	IssueDate       string  `json:"issue_date" binding:"required"`    // YYYY-MM-DD
+	MaturityDate    string  `json:"maturity_date" binding:"required"` // YYYY-MM-DD
+	Tradable        *bool   `json:"tradable"`                         // defaults to true
+}
+
+// Coupon is one scheduled payment, accruing from PeriodStart to PeriodEnd. Amount is
+// paid per 100 of face value to holders on RecordDate.
+type Coupon struct {
+	Symbol      string     `json:"symbol" db:"symbol"`
+	PeriodStart time.Time  `json:"period_start" db:"period_start"`
+	PeriodEnd   time.Time  `json:"period_end" db:"period_end"`
+	RecordDate  *time.Time `json:"record_date,omitempty" db:"record_date"`
+	PayDate     time.Time  `json:"pay_date" db:"pay_date"`
+	Rate        float64    `json:"rate" db:"rate"` // annual percent
+	Amount      float64    `json:"amount" db:"amount"`
+}
+
+// CouponInput sets the rate of one coupon period, e.g. after a floating-rate reset
+type CouponInput struct {
+	PeriodEnd  string  `json:"period_end" binding:"required"` // YYYY-MM-DD, identifies the period
+	Rate       float64 `json:"rate" binding:"min=0"`
+	RecordDate string  `json:"record_date"` // YYYY-MM-DD
+	PayDate    string  `json:"pay_date"`    // YYYY-MM-DD, defaults to period_end
+}
+
+// UpdateCouponsRequest changes coupon periods of a bond
+type UpdateCouponsRequest struct {
+	Coupons []CouponInput `json:"coupons" binding:"required,min=1,max=500,dive"`
+}
+
+// BondQuote is a day's clean price (percent of par) and the yield to maturity it implies
+type BondQuote struct {
+	Symbol     string    `json:"symbol" db:"symbol"`
+	Date       time.Time `json:"date" db:"date"`
+	CleanPrice float64   `json:"clean_price" db:"clean_price"`
+	Yield      float64   `json:"yield" db:"yield"` // annual percent, compounded at the coupon frequency
+	Source     string    `json:"source" db:"source"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// BondQuoteRequest records a quote given as either a clean price or a yield; the
+// other is derived
+type BondQuoteRequest struct {
+	Date       string   `json:"date" binding:"required"` // YYYY-MM-DD
+	CleanPrice *float64 `json:"clean_price" binding:"omitempty,gt=0"`
+	Yield      *float64 `json:"yield"`
+	Source     string   `json:"source" binding:"omitempty,max=50"`
+}
+
+// BondValuation prices a bond on a date: dirty price is clean price plus accrued
+// interest, both per 100 of face value
+type BondValuation struct {
+	Symbol          string    `json:"symbol"`
+	Date            time.Time `json:"date"`
+	CleanPrice      float64   `json:"clean_price"`
+	Yield           *float64  `json:"yield,omitempty"`
+	AccruedInterest float64   `json:"accrued_interest"`
+	DirtyPrice      float64   `json:"dirty_price"`
+	PriceSource     string    `json:"price_source"` // a quote source, or par for bonds without quotes
+	NextCoupon      *Coupon   `json:"next_coupon,omitempty"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Kinds of change a release note lists
+const (
+	ChangeAdded      = "added"
+	ChangeChanged    = "changed"
+	ChangeFixed      = "fixed"
+	ChangeDeprecated = "deprecated"
+	ChangeRemoved    = "removed"
+)
+
+// ValidChangeKind reports whether kind is a supported kind of change
+func ValidChangeKind(kind string) bool {
+	switch kind {
+	case ChangeAdded, ChangeChanged, ChangeFixed, ChangeDeprecated, ChangeRemoved:
+		return true
+	}
+	return false
+}
+
+// Release is the notes of one deployed version
+type Release struct {
+	Version    string          `json:"version"`
+	ReleasedAt time.Time       `json:"released_at"`
+	Title      string          `json:"title"`
+	Changes    []ReleaseChange `json:"changes"`
+}
+
+// ReleaseChange is one entry in a release's notes
+type ReleaseChange struct {
+	Kind     string   `json:"kind"` // added, changed, fixed, deprecated or removed
+	Text     string   `json:"text"`
+	Endpoint string   `json:"endpoint,omitempty"` // e.g. GET /api/v1/changelog
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// ChangelogEntry is a release with whether the user has seen it; Seen is left out
+// for anonymous callers
+type ChangelogEntry struct {
+	Release
+	Seen *bool `json:"seen,omitempty"`
+}
+
+// Changelog is the release notes, newest first. SeenVersion is the newest release
+// the user has marked seen, and Unseen counts the releases after it.
+type Changelog struct {
+	LatestVersion string           `json:"latest_version"`
+	SeenVersion   string           `json:"seen_version,omitempty"`
+	SeenAt        *time.Time       `json:"seen_at,omitempty"`
+	Unseen        int              `json:"unseen"`
+	Releases      []ChangelogEntry `json:"releases"`
+}
+
+// ChangelogSeenRequest marks releases up to and including Version seen; the latest
+// when empty
+type ChangelogSeenRequest struct {
+	Version string `json:"version"`
+}
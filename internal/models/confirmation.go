@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Confirmation is issued by the first call of a destructive operation and
+// must be passed back to the second call to execute it
+type Confirmation struct {
+	Token        string    `json:"confirmation_token"`
+	Action       string    `json:"action"`
+	Target       string    `json:"target"`
+	RowsAffected int64     `json:"rows_affected"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Corporate action types
+const (
+	ActionRights   = "rights"   // rights issue (HMETD): entitlements to buy new shares at the exercise price
+	ActionWarrant  = "warrant"  // warrants issued free to holders
+	ActionSplit    = "split"    // ratio_new shares for every ratio_old held; a reverse split when fewer
+	ActionDividend = "dividend" // cash paid per share to holders on the ex-date
+)
+
+// Corporate action statuses
+const (
+	ActionPendingReview = "pending_review" // ambiguous; held until an admin approves or rejects it
+	ActionScheduled     = "scheduled"      // approved, applied on its ex-date
+	ActionApplied       = "applied"
+	ActionRejected      = "rejected"
+)
+
+// CorporateAction credits holders of Symbol with RatioNew entitlements per RatioOld
+// shares held on the ex-date, splits their shares RatioOld into RatioNew, or pays
+// them Amount per share
+type CorporateAction struct {
+	ID                int64      `json:"id" db:"id"`
+	Symbol            string     `json:"symbol" db:"symbol"`
+	ActionType        string     `json:"action_type" db:"action_type"`
+	ExDate            time.Time  `json:"ex_date" db:"ex_date"`
+	RatioOld          int        `json:"ratio_old" db:"ratio_old"`
+	RatioNew          int        `json:"ratio_new" db:"ratio_new"`
+	ExercisePrice     float64    `json:"exercise_price" db:"exercise_price"`
+	EntitlementSymbol string     `json:"entitlement_symbol" db:"entitlement_symbol"`
+	Amount            float64    `json:"amount" db:"amount"`       // dividend per share
+	CumPrice          *float64   `json:"cum_price" db:"cum_price"` // parent close before the ex-date
+	Status            string     `json:"status" db:"status"`
+	ReviewReasons     []string   `json:"review_reasons" db:"review_reasons"`
+	Note              string     `json:"note,omitempty" db:"note"`
+	CreatedBy         string     `json:"created_by" db:"created_by"`
+	ReviewedBy        string     `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+	ReviewedAt        *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	AppliedAt         *time.Time `json:"applied_at,omitempty" db:"applied_at"`
+}
+
+// CorporateActionRequest announces a rights issue, warrant distribution, split or
+// dividend. Dividends take an amount instead of a ratio.
+type CorporateActionRequest struct {
+	Symbol            string   `json:"symbol" binding:"required,max=20"`
+	ActionType        string   `json:"action_type" binding:"required,oneof=rights warrant split dividend"`
+	ExDate            string   `json:"ex_date" binding:"required"` // YYYY-MM-DD
+	RatioOld          int      `json:"ratio_old" binding:"omitempty,gt=0"`
+	RatioNew          int      `json:"ratio_new" binding:"omitempty,gt=0"`
+	ExercisePrice     float64  `json:"exercise_price" binding:"min=0"`
+	EntitlementSymbol string   `json:"entitlement_symbol" binding:"max=20"`
+	Amount            float64  `json:"amount" binding:"min=0"`
+	CumPrice          *float64 `json:"cum_price" binding:"omitempty,gt=0"` // looked up from market data when omitted
+	Note              string   `json:"note" binding:"max=1000"`
+}
+
+// ReviewActionRequest approves an action held for review, filling in what it lacked
+type ReviewActionRequest struct {
+	EntitlementSymbol string   `json:"entitlement_symbol" binding:"max=20"`
+	CumPrice          *float64 `json:"cum_price" binding:"omitempty,gt=0"`
+	Note              string   `json:"note" binding:"max=1000"`
+}
+
+// RejectActionRequest rejects an action held for review
+type RejectActionRequest struct {
+	Note string `json:"note" binding:"required,max=1000"`
+}
+
+// PortfolioAdjustment records how applying a corporate action changed one holding
+type PortfolioAdjustment struct {
+	ID             int64     `json:"id" db:"id"`
+	ActionID       int64     `json:"action_id" db:"action_id"`
+	ActionType     string    `json:"action_type" db:"action_type"`
+	PortfolioID    int64     `json:"portfolio_id" db:"portfolio_id"`
+	Symbol         string    `json:"symbol" db:"symbol"`
+	QuantityBefore float64   `json:"quantity_before" db:"quantity_before"`
+	QuantityAfter  float64   `json:"quantity_after" db:"quantity_after"`
+	AvgPriceBefore float64   `json:"avg_price_before" db:"avg_price_before"`
+	AvgPriceAfter  float64   `json:"avg_price_after" db:"avg_price_after"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
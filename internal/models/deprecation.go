@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Deprecation marks an endpoint slated for removal. Calls to it are answered with
+// Deprecation and Sunset headers and counted per client.
+type Deprecation struct {
+	Method       string     `json:"method"`
+	Route        string     `json:"route"` // the route template, e.g. /api/v1/preferences/watchlist/:symbol
+	DeprecatedAt time.Time  `json:"deprecated_at"`
+	SunsetAt     *time.Time `json:"sunset_at,omitempty"`   // when the endpoint is removed
+	Replacement  string     `json:"replacement,omitempty"` // path of the endpoint to move to
+	Note         string     `json:"note,omitempty"`
+}
+
+// DeprecatedCaller is one client still calling a deprecated endpoint. Clients are
+// told apart by user and User-Agent.
+type DeprecatedCaller struct {
+	UserID      string    `json:"user_id"`
+	UserAgent   string    `json:"user_agent"`
+	Calls       int64     `json:"calls"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// DeprecationUsage is a deprecated endpoint with the clients that called it, most
+// recent first
+type DeprecationUsage struct {
+	Deprecation
+	Calls   int64              `json:"calls"`
+	Callers []DeprecatedCaller `json:"callers"`
+}
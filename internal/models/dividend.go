@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Dividend is an announced cash dividend. Holders on the ex-date are paid Amount
+// per share on the payment date.
+type Dividend struct {
+	ID          int64      `json:"id" db:"id"`
+	Symbol      string     `json:"symbol" db:"symbol"`
+	ExDate      time.Time  `json:"ex_date" db:"ex_date"`
+	RecordDate  *time.Time `json:"record_date" db:"record_date"`
+	PaymentDate *time.Time `json:"payment_date" db:"payment_date"`
+	Amount      float64    `json:"amount" db:"amount"` // per share
+	Currency    string     `json:"currency" db:"currency"`
+	Note        string     `json:"note,omitempty" db:"note"`
+	CreatedBy   string     `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// DividendRequest announces a dividend or replaces the one on the same symbol and
+// ex-date. Currency defaults to the symbol's quote currency.
+type DividendRequest struct {
+	Symbol      string  `json:"symbol" binding:"required,max=20"`
+	ExDate      string  `json:"ex_date" binding:"required"` // YYYY-MM-DD
+	RecordDate  string  `json:"record_date"`                // YYYY-MM-DD, optional
+	PaymentDate string  `json:"payment_date"`               // YYYY-MM-DD, optional
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Currency    string  `json:"currency" binding:"omitempty,len=3"`
+	Note        string  `json:"note" binding:"max=1000"`
+}
+
+// ProjectedDividend is the income one holding is expected to receive from a dividend
+type ProjectedDividend struct {
+	DividendID  int64      `json:"dividend_id"`
+	Symbol      string     `json:"symbol"`
+	ExDate      time.Time  `json:"ex_date"`
+	PaymentDate *time.Time `json:"payment_date"`
+	Amount      float64    `json:"amount"`
+	Currency    string     `json:"currency"`
+	Quantity    float64    `json:"quantity"`
+	Income      float64    `json:"income"`
+}
+
+// DividendProjection is a portfolio's expected dividend income from dividends going
+// ex between From and To, assuming current holdings are kept until then
+type DividendProjection struct {
+	PortfolioID int64               `json:"portfolio_id"`
+	From        time.Time           `json:"from"`
+	To          time.Time           `json:"to"`
+	Dividends   []ProjectedDividend `json:"dividends"`
+	Totals      map[string]float64  `json:"totals"` // income per currency
+}
@@ -0,0 +1,149 @@
+package models
+
+import "time"
+
+// Exchanges seeded by the schema; others can be added to the exchanges table
+const (
+	ExchangeIDX    = "IDX"
+	ExchangeUS     = "US"
+	ExchangeCrypto = "CRYPTO"
+	ExchangeFund   = "FUND" // mutual funds quoted by daily NAV
+	ExchangeBond   = "BOND" // bonds quoted in percent of par
+)
+
+// Asset classes an exchange lists
+const (
+	AssetClassEquity = "equity"
+	AssetClassCrypto = "crypto"
+	AssetClassFund   = "fund"
+	AssetClassBond   = "bond"
+)
+
+// AssetClassForExchange returns what a seeded exchange lists; unknown ones are taken
+// to list equities
+func AssetClassForExchange(code string) string {
+	switch code {
+	case ExchangeCrypto:
+		return AssetClassCrypto
+	case ExchangeFund:
+		return AssetClassFund
+	case ExchangeBond:
+		return AssetClassBond
+	}
+	return AssetClassEquity
+}
+
+// Exchange is a venue with its own regular session and trading week. A session that
+// closes at or before its open time runs past midnight; 00:00 to 00:00 trades all day.
+type Exchange struct {
+	Code        string    `json:"code" db:"code"`
+	Name        string    `json:"name" db:"name"`
+	Timezone    string    `json:"timezone" db:"timezone"`
+	OpensAt     string    `json:"opens_at" db:"opens_at"`                   // HH:MM local time
+	ClosesAt    string    `json:"closes_at" db:"closes_at"`                 // HH:MM local time
+	PreOpensAt  string    `json:"pre_opens_at,omitempty" db:"pre_opens_at"` // HH:MM local time; "" without a pre-opening session
+	TradingDays []int     `json:"trading_days" db:"trading_days"`           // ISO weekdays, 1 = Monday
+	AssetClass  string    `json:"asset_class" db:"asset_class"`
+	Currency    string    `json:"currency" db:"currency"` // "" when it is the quote asset of each pair
+	LotSize     int       `json:"lot_size" db:"lot_size"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// ExchangeHoliday is a full-day closure
+type ExchangeHoliday struct {
+	Exchange string    `json:"exchange" db:"exchange"`
+	Date     time.Time `json:"date" db:"date"`
+	Name     string    `json:"name" db:"name"`
+}
+
+// CreateHolidayRequest adds a closure to an exchange calendar
+type CreateHolidayRequest struct {
+	Date string `json:"date" binding:"required"` // YYYY-MM-DD
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// UploadHolidaysRequest replaces an exchange's holidays for one year
+type UploadHolidaysRequest struct {
+	Holidays []CreateHolidayRequest `json:"holidays" binding:"required,dive"` // [] reopens every day of the year
+}
+
+// HolidayUploadResult counts what replacing a year of holidays changed
+type HolidayUploadResult struct {
+	Exchange  string `json:"exchange"`
+	Year      int    `json:"year"`
+	Holidays  int    `json:"holidays"`
+	Added     int    `json:"added"`
+	Renamed   int    `json:"renamed"`
+	Removed   int    `json:"removed"`
+	Unchanged int    `json:"unchanged"`
+}
+
+// TradingDay is one calendar day of an exchange; session times are only set when it trades
+type TradingDay struct {
+	Date       string     `json:"date"`
+	Open       bool       `json:"open"`
+	Holiday    string     `json:"holiday,omitempty"`
+	PreOpensAt *time.Time `json:"pre_opens_at,omitempty"`
+	OpensAt    *time.Time `json:"opens_at,omitempty"`
+	ClosesAt   *time.Time `json:"closes_at,omitempty"`
+}
+
+// Market states of an exchange at a moment
+const (
+	MarketPreOpen = "pre_open" // pre-opening session before the regular open
+	MarketOpen    = "open"
+	MarketClosed  = "closed"  // outside the session, including weekends
+	MarketHoliday = "holiday" // a trading weekday closed for a holiday
+)
+
+// ExchangeStatus reports whether an exchange is in session at a moment and when that changes
+type ExchangeStatus struct {
+	Exchange    string     `json:"exchange"`
+	At          time.Time  `json:"at"`
+	Open        bool       `json:"open"`
+	MarketState string     `json:"market_state"`
+	Holiday     string     `json:"holiday,omitempty"`
+	NextOpen    *time.Time `json:"next_open,omitempty"`
+	NextClose   *time.Time `json:"next_close,omitempty"`
+}
+
+// Symbol is the reference data of a listing. Listings seen in market data are
+// registered automatically with their exchange's currency and lot size.
+type Symbol struct {
+	Exchange   string    `json:"exchange" db:"exchange"`
+	Symbol     string    `json:"symbol" db:"symbol"`
+	Name       string    `json:"name" db:"name"`
+	Sector     string    `json:"sector" db:"sector"`
+	Currency   string    `json:"currency" db:"currency"`
+	LotSize    int       `json:"lot_size" db:"lot_size"`
+	AssetClass string    `json:"asset_class" db:"asset_class"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SymbolRequest creates or replaces a listing; an empty exchange is inferred from the
+// ticker and empty currency and lot size default to the exchange's
+type SymbolRequest struct {
+	Exchange string `json:"exchange" binding:"omitempty,max=10"`
+	Symbol   string `json:"symbol" binding:"required,max=20"`
+	Name     string `json:"name" binding:"max=200"`
+	Sector   string `json:"sector" binding:"max=100"`
+	Currency string `json:"currency" binding:"omitempty,max=10"`
+	LotSize  int    `json:"lot_size" binding:"min=0"`
+}
+
+// UpdateSymbolRequest changes the given fields of a listing
+type UpdateSymbolRequest struct {
+	Name     *string `json:"name" binding:"omitempty,max=200"`
+	Sector   *string `json:"sector" binding:"omitempty,max=100"`
+	Currency *string `json:"currency" binding:"omitempty,min=1,max=10"`
+	LotSize  *int    `json:"lot_size" binding:"omitempty,min=1"`
+}
+
+// SymbolImportResponse reports a reference data CSV upload
+type SymbolImportResponse struct {
+	Message      string   `json:"message"`
+	RowsImported int      `json:"rows_imported"`
+	RowsSkipped  int      `json:"rows_skipped"`
+	Errors       []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Export job statuses
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+	ExportExpired   = "expired"
+)
+
+// ExportJob is an asynchronous market data export
+type ExportJob struct {
+	ID          int64      `json:"id" db:"id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	Symbols     []string   `json:"symbols" db:"symbols"`
+	Source      string     `json:"source" db:"source"`
+	Interval    string     `json:"interval" db:"interval"`
+	Exchange    string     `json:"exchange,omitempty" db:"exchange"`
+	StartDate   time.Time  `json:"start_date" db:"start_date"`
+	EndDate     time.Time  `json:"end_date" db:"end_date"`
+	Format      string     `json:"format" db:"format"`
+	Status      string     `json:"status" db:"status"`
+	RowCount    int64      `json:"row_count" db:"row_count"`
+	SizeBytes   int64      `json:"size_bytes" db:"size_bytes"`
+	ObjectKey   string     `json:"-" db:"object_key"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+// CreateExportRequest describes the data to export
+type CreateExportRequest struct {
+	Symbols   []string `json:"symbols" binding:"required,min=1,max=50"`
+	StartDate string   `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string   `json:"end_date" binding:"required"`   // YYYY-MM-DD
+	Format    string   `json:"format"`                        // csv (default) or json
+	Source    string   `json:"source"`                        // a source name, any or merged; defaults to the user's preference
+	Interval  string   `json:"interval"`                      // 1m, 5m, 1h or 1d (default)
+	Exchange  string   `json:"exchange"`                      // one exchange's listings; every exchange when empty
+}
+
+// QuotaUsage is how much of a rolling quota a user has used. Warning is set once
+// usage reaches the warning threshold, before requests start being refused.
+type QuotaUsage struct {
+	Quota     string     `json:"quota"`
+	Limit     int        `json:"limit"`
+	Used      int        `json:"used"`
+	Remaining int        `json:"remaining"`
+	ResetAt   *time.Time `json:"reset_at"` // when the oldest counted use leaves the window; nil while unused
+	Warning   bool       `json:"warning"`
+}
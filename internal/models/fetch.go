@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// FetchStatus is how the scheduled fetch of a watchlisted symbol last went. Times
+// are nil until the first attempt.
+type FetchStatus struct {
+	Symbol        string     `json:"symbol" db:"symbol"`
+	Source        string     `json:"source" db:"source"`
+	LastAttemptAt *time.Time `json:"last_attempt_at" db:"last_attempt_at"`
+	LastSuccessAt *time.Time `json:"last_success_at" db:"last_success_at"`
+	LastError     string     `json:"last_error,omitempty" db:"last_error"`
+	Failures      int        `json:"failures" db:"failures"`               // consecutive, reset by a success
+	NextAttemptAt *time.Time `json:"next_attempt_at" db:"next_attempt_at"` // set while backing off
+	RowsStored    int        `json:"rows_stored" db:"rows_stored"`         // by the last successful fetch
+}
+
+// RefetchRequest queues a manual fetch; no symbols refetches every watchlisted
+// equity and no days the configured lookback
+type RefetchRequest struct {
+	Symbols []string `json:"symbols" binding:"max=500,dive,required,max=20"`
+	Days    int      `json:"days" binding:"omitempty,min=1,max=3650"`
+}
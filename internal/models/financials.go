@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// FinancialReport summarizes a listing's income statement for one fiscal quarter
+// and its balance sheet at the quarter's end. Figures a source did not report are nil.
+type FinancialReport struct {
+	ID            int64     `json:"id" db:"id"`
+	Symbol        string    `json:"symbol" db:"symbol"`
+	FiscalYear    int       `json:"fiscal_year" db:"fiscal_year"`
+	FiscalQuarter int       `json:"fiscal_quarter" db:"fiscal_quarter"`
+	PeriodEnd     time.Time `json:"period_end" db:"period_end"`
+	Currency      string    `json:"currency" db:"currency"`
+	Revenue       *float64  `json:"revenue" db:"revenue"`
+	NetIncome     *float64  `json:"net_income" db:"net_income"`
+	EPS           *float64  `json:"eps" db:"eps"`               // for the quarter alone
+	BookValue     *float64  `json:"book_value" db:"book_value"` // total equity at period end
+	Source        string    `json:"source" db:"source"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// FinancialReportInput is one stored report in an upsert
+type FinancialReportInput struct {
+	Symbol        string   `json:"symbol" binding:"required,max=20"`
+	FiscalYear    int      `json:"fiscal_year" binding:"required,min=1900,max=2200"`
+	FiscalQuarter int      `json:"fiscal_quarter" binding:"required,min=1,max=4"`
+	PeriodEnd     string   `json:"period_end" binding:"required"` // YYYY-MM-DD
+	Currency      string   `json:"currency" binding:"omitempty,len=3"`
+	Revenue       *float64 `json:"revenue"`
+	NetIncome     *float64 `json:"net_income"`
+	EPS           *float64 `json:"eps"`
+	BookValue     *float64 `json:"book_value"`
+	Source        string   `json:"source" binding:"omitempty,max=50"`
+}
+
+// UpsertFinancialsRequest stores or replaces quarterly reports
+type UpsertFinancialsRequest struct {
+	Data []FinancialReportInput `json:"data" binding:"required,min=1,max=5000,dive"`
+}
+
+// FinancialRatios values a listing's latest reports at its latest close. Ratios are
+// nil when an input is missing, and PER is nil for trailing losses.
+type FinancialRatios struct {
+	Symbol            string     `json:"symbol"`
+	Price             *float64   `json:"price"`
+	PriceDate         *time.Time `json:"price_date"`
+	PeriodEnd         *time.Time `json:"period_end"` // of the latest report
+	EPSTTM            *float64   `json:"eps_ttm"`    // sum of the last four quarters
+	BookValuePerShare *float64   `json:"book_value_per_share"`
+	PER               *float64   `json:"per"`
+	PBV               *float64   `json:"pbv"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Fundamentals is a listing's share count and free float as reported on Date. It
+// holds until the next snapshot of the symbol.
+type Fundamentals struct {
+	ID                int64     `json:"id" db:"id"`
+	Symbol            string    `json:"symbol" db:"symbol"`
+	Date              time.Time `json:"date" db:"date"`
+	SharesOutstanding int64     `json:"shares_outstanding" db:"shares_outstanding"`
+	MarketCap         *float64  `json:"market_cap" db:"market_cap"`         // as reported by the source
+	FreeFloatPct      *float64  `json:"free_float_pct" db:"free_float_pct"` // percent of shares outstanding
+	Source            string    `json:"source" db:"source"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// FundamentalsInput is one stored snapshot in an upsert
+type FundamentalsInput struct {
+	Symbol            string   `json:"symbol" binding:"required,max=20"`
+	Date              string   `json:"date" binding:"required"` // YYYY-MM-DD
+	SharesOutstanding int64    `json:"shares_outstanding" binding:"required,gt=0"`
+	MarketCap         *float64 `json:"market_cap" binding:"omitempty,min=0"`
+	FreeFloatPct      *float64 `json:"free_float_pct" binding:"omitempty,min=0,max=100"`
+	Source            string   `json:"source" binding:"omitempty,max=50"`
+}
+
+// UpsertFundamentalsRequest stores or replaces fundamentals snapshots
+type UpsertFundamentalsRequest struct {
+	Data []FundamentalsInput `json:"data" binding:"required,min=1,max=5000,dive"`
+}
+
+// MarketCap values a listing on Date at that day's close. Without a close it falls
+// back to the market cap the source reported, and Close is nil.
+type MarketCap struct {
+	Symbol             string    `json:"symbol"`
+	Date               time.Time `json:"date"`
+	Close              *float64  `json:"close"`
+	SharesOutstanding  int64     `json:"shares_outstanding"`
+	FreeFloatPct       *float64  `json:"free_float_pct"`
+	MarketCap          *float64  `json:"market_cap"`
+	FreeFloatMarketCap *float64  `json:"free_float_market_cap"`
+	AsOf               time.Time `json:"as_of"` // date of the snapshot the share count comes from
+	Source             string    `json:"source"`
+}
+
+// SectorAggregate sums the latest market caps of the listings in a sector
+type SectorAggregate struct {
+	Sector             string  `json:"sector"`
+	Symbols            int     `json:"symbols"`
+	MarketCap          float64 `json:"market_cap"`
+	FreeFloatMarketCap float64 `json:"free_float_market_cap"`
+	WeightPct          float64 `json:"weight_pct"` // share of the total market cap across sectors
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// FXRate is what 1 unit of Base buys in Quote on Date. Derived says how a rate that
+// is not stored for the pair was computed: "inverse" of the opposite pair, or "cross"
+// through USD.
+type FXRate struct {
+	Base    string    `json:"base"`
+	Quote   string    `json:"quote"`
+	Date    time.Time `json:"date"`
+	Rate    float64   `json:"rate"`
+	Source  string    `json:"source"`
+	Derived string    `json:"derived,omitempty"`
+}
+
+// FXRateInput is one stored rate in an upsert
+type FXRateInput struct {
+	Base   string  `json:"base" binding:"required,len=3"`
+	Quote  string  `json:"quote" binding:"required,len=3"`
+	Date   string  `json:"date" binding:"required"` // YYYY-MM-DD
+	Rate   float64 `json:"rate" binding:"required,gt=0"`
+	Source string  `json:"source" binding:"omitempty,max=50"`
+}
+
+// UpsertFXRatesRequest stores or replaces rates
+type UpsertFXRatesRequest struct {
+	Rates []FXRateInput `json:"rates" binding:"required,min=1,max=1000,dive"`
+}
+
+// ConvertRequest converts an amount at the rate in force on Date (default today)
+type ConvertRequest struct {
+	Amount float64 `json:"amount" binding:"required"`
+	From   string  `json:"from" binding:"required,len=3"`
+	To     string  `json:"to" binding:"required,len=3"`
+	Date   string  `json:"date"` // YYYY-MM-DD
+}
+
+// Conversion is an amount converted at a historical rate
+type Conversion struct {
+	Amount    float64 `json:"amount"`
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Converted float64 `json:"converted"`
+	Rate      FXRate  `json:"rate"`
+}
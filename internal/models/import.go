@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Import job statuses
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// ImportRowError is a CSV row that could not be imported; Row counts the header as row 1
+type ImportRowError struct {
+	Row   int64  `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportJob is an asynchronous CSV import of market data
+type ImportJob struct {
+	ID            int64            `json:"id" db:"id"`
+	UserID        string           `json:"user_id" db:"user_id"`
+	Filename      string           `json:"filename" db:"filename"`
+	ObjectKey     string           `json:"-" db:"object_key"`
+	OnConflict    string           `json:"on_conflict" db:"on_conflict"`
+	Format        string           `json:"format" db:"format"`               // export format; empty until detected
+	Symbol        string           `json:"symbol,omitempty" db:"symbol"`     // for exports without a symbol column
+	Interval      string           `json:"interval,omitempty" db:"interval"` // for exports without an interval column
+	Status        string           `json:"status" db:"status"`
+	SizeBytes     int64            `json:"size_bytes" db:"size_bytes"`
+	BytesRead     int64            `json:"bytes_read" db:"bytes_read"`
+	RowsProcessed int64            `json:"rows_processed" db:"rows_processed"`
+	RowsInserted  int64            `json:"rows_inserted" db:"rows_inserted"`
+	RowsUpdated   int64            `json:"rows_updated" db:"rows_updated"`
+	RowsSkipped   int64            `json:"rows_skipped" db:"rows_skipped"`   // existing rows kept under on_conflict=skip
+	RowsRejected  int64            `json:"rows_rejected" db:"rows_rejected"` // unparseable or refused by screening
+	Errors        []ImportRowError `json:"errors" db:"errors"`
+	Error         string           `json:"error,omitempty" db:"error"`
+	Progress      float64          `json:"progress"` // percent of the file read
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+	StartedAt     *time.Time       `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
+}
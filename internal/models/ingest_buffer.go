@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// BufferedIngest is a bulk ingest held in the write-ahead log while the database is
+// unavailable, replayed as it would have been written
+type BufferedIngest struct {
+	ReceivedAt time.Time    `json:"received_at"`
+	OnConflict string       `json:"on_conflict"`
+	Screened   bool         `json:"screened"` // already passed anomaly screening; replayed as is
+	Data       []MarketData `json:"data"`
+}
+
+// IngestBufferStatus summarizes the write-ahead log and its replay
+type IngestBufferStatus struct {
+	Enabled      bool           `json:"enabled"`
+	Bytes        int64          `json:"bytes"`
+	MaxBytes     int64          `json:"max_bytes"`
+	Pending      int            `json:"pending"` // buffered ingests not yet replayed
+	Symbols      map[string]int `json:"symbols"` // pending ingests per symbol
+	Replaying    bool           `json:"replaying"`
+	Replayed     int            `json:"replayed"` // ingests replayed since startup
+	RowsReplayed int            `json:"rows_replayed"`
+	Rejected     int            `json:"rejected"` // ingests the database refused on replay; kept in the rejected log
+	LastReplayAt *time.Time     `json:"last_replay_at,omitempty"`
+	LastError    string         `json:"last_error,omitempty"`
+}
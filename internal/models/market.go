@@ -1,19 +1,191 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
 
-// MarketData represents stock market data
+// Candle intervals; daily is assumed wherever an interval is not given
+const (
+	Interval1m    = "1m"
+	Interval5m    = "5m"
+	Interval1h    = "1h"
+	IntervalDaily = "1d"
+)
+
+var intervalLengths = map[string]time.Duration{
+	Interval1m:    time.Minute,
+	Interval5m:    5 * time.Minute,
+	Interval1h:    time.Hour,
+	IntervalDaily: 24 * time.Hour,
+}
+
+// ValidInterval reports whether interval is a supported candle interval
+func ValidInterval(interval string) bool {
+	_, ok := intervalLengths[interval]
+	return ok
+}
+
+// IntervalLength returns how long a candle of interval spans, 0 when unsupported
+func IntervalLength(interval string) time.Duration {
+	return intervalLengths[interval]
+}
+
+// symbolSuffixes map Yahoo-style ticker suffixes to the exchange listing them
+var symbolSuffixes = map[string]string{
+	".JK": ExchangeIDX,
+}
+
+// cryptoQuotes are the quote assets of crypto pairs written BASE-QUOTE, e.g. BTC-USDT
+var cryptoQuotes = map[string]bool{
+	"USDT":  true,
+	"USDC":  true,
+	"FDUSD": true,
+	"BTC":   true,
+	"ETH":   true,
+}
+
+// stableQuotes are the quote assets recognised in pairs written without a separator,
+// e.g. BTCUSDT. BTC and ETH quotes need one, since tickers such as an equity's could
+// end in them.
+var stableQuotes = []string{"FDUSD", "USDT", "USDC"}
+
+// cryptoBase is the shape of a crypto pair's base asset
+var cryptoBase = regexp.MustCompile(`^[A-Z0-9]{2,10}$`)
+
+// CryptoPair splits a crypto pair written BASE-QUOTE, BASE/QUOTE or, for stablecoin
+// quotes, BASEQUOTE into its assets, reporting whether symbol is one
+func CryptoPair(symbol string) (base, quote string, ok bool) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if i := strings.LastIndexAny(symbol, "-/"); i > 0 {
+		base, quote = symbol[:i], symbol[i+1:]
+		if !cryptoQuotes[quote] || !cryptoBase.MatchString(base) {
+			return "", "", false
+		}
+		return base, quote, true
+	}
+	for _, q := range stableQuotes {
+		if b, found := strings.CutSuffix(symbol, q); found && cryptoBase.MatchString(b) {
+			return b, q, true
+		}
+	}
+	return "", "", false
+}
+
+// CanonicalSymbol writes crypto pairs as BASE-QUOTE, so BTCUSDT and btc/usdt name
+// the same series as BTC-USDT. Other symbols are returned unchanged.
+func CanonicalSymbol(symbol string) string {
+	if base, quote, ok := CryptoPair(symbol); ok {
+		return base + "-" + quote
+	}
+	return symbol
+}
+
+// ExchangeForSymbol infers the exchange of a ticker from its suffix; tickers
+// without a known suffix are taken to be US listings
+func ExchangeForSymbol(symbol string) string {
+	if _, _, ok := CryptoPair(symbol); ok {
+		return ExchangeCrypto
+	}
+	if i := strings.LastIndex(symbol, "."); i >= 0 {
+		if exchange, ok := symbolSuffixes[strings.ToUpper(symbol[i:])]; ok {
+			return exchange
+		}
+	}
+	return ExchangeUS
+}
+
+// ErrMissingTimestamp is returned by Normalize for a candle with neither date nor timestamp
+var ErrMissingTimestamp = errors.New("date or timestamp is required")
+
+// ErrInconsistentOHLC is returned for a candle whose high and low do not bound it
+var ErrInconsistentOHLC = errors.New("inconsistent OHLC")
+
+// MarketData represents stock market data for one listing. Timestamp is when the
+// candle opens and Date is its trading day; daily candles open at midnight of their date.
 type MarketData struct {
 	ID        int64     `json:"id" db:"id"`
+	Exchange  string    `json:"exchange" db:"exchange" binding:"omitempty,max=10"`
 	Symbol    string    `json:"symbol" db:"symbol" binding:"required"`
-	Date      time.Time `json:"date" db:"date" binding:"required"`
-	Open      float64   `json:"open" db:"open" binding:"required,min=0"`
+	Interval  string    `json:"interval" db:"interval" binding:"omitempty,oneof=1m 5m 1h 1d"`
+	Date      time.Time `json:"date" db:"date"`
+	Timestamp time.Time `json:"timestamp" db:"ts"`
+	Open      *float64  `json:"open" db:"open" binding:"omitempty,min=0"` // null when the source does not publish it
 	High      float64   `json:"high" db:"high" binding:"required,min=0"`
 	Low       float64   `json:"low" db:"low" binding:"required,min=0"`
 	Close     float64   `json:"close" db:"close" binding:"required,min=0"`
-	Volume    int64     `json:"volume" db:"volume" binding:"required,min=0"`
-	Source    string    `json:"source" db:"source" binding:"required,oneof=yahoo mirae manual"`
+	AdjClose  *float64  `json:"adj_close" db:"adj_close" binding:"omitempty,min=0"` // split and dividend adjusted, as the source publishes it
+	Volume    *int64    `json:"volume" db:"volume" binding:"omitempty,min=0"`       // null when unknown, e.g. before a listing trades
+	Source    string    `json:"source" db:"source" binding:"required,oneof=yahoo mirae binance alphavantage manual"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Normalize writes crypto pairs as BASE-QUOTE, fills whichever of Date and Timestamp
+// is missing, defaults the interval to daily and the exchange to the one the symbol's
+// suffix names, and aligns the timestamp to the start of its interval
+func (md *MarketData) Normalize() error {
+	md.Symbol = CanonicalSymbol(md.Symbol)
+	if md.Exchange == "" {
+		md.Exchange = ExchangeForSymbol(md.Symbol)
+	}
+	md.Exchange = strings.ToUpper(md.Exchange)
+
+	if md.Interval == "" {
+		md.Interval = IntervalDaily
+	}
+	length, ok := intervalLengths[md.Interval]
+	if !ok {
+		return errors.New("interval must be one of 1m, 5m, 1h or 1d")
+	}
+
+	switch {
+	case !md.Timestamp.IsZero():
+		md.Timestamp = md.Timestamp.UTC().Truncate(length)
+	case !md.Date.IsZero():
+		md.Timestamp = time.Date(md.Date.Year(), md.Date.Month(), md.Date.Day(), 0, 0, 0, 0, time.UTC)
+	default:
+		return ErrMissingTimestamp
+	}
+	md.Date = md.Timestamp.Truncate(24 * time.Hour)
+	return nil
+}
+
+// NormalizeAll normalizes every candle in data, stopping at the first invalid one
+func NormalizeAll(data []MarketData) error {
+	for i := range data {
+		if err := data[i].Normalize(); err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ValidateOHLC checks low is at most high and open and close lie between them,
+// naming every violation. A missing open is not checked.
+func (md *MarketData) ValidateOHLC() error {
+	var problems []string
+	if md.Low > md.High {
+		problems = append(problems, fmt.Sprintf("low %g is above high %g", md.Low, md.High))
+	}
+	for _, p := range []struct {
+		name  string
+		price *float64
+	}{{"open", md.Open}, {"close", &md.Close}} {
+		if p.price == nil {
+			continue
+		}
+		if *p.price < md.Low || *p.price > md.High {
+			problems = append(problems, fmt.Sprintf("%s %g is outside low %g and high %g", p.name, *p.price, md.Low, md.High))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInconsistentOHLC, strings.Join(problems, "; "))
 }
 
 // BulkCreateRequest represents a request to create multiple market data records
@@ -21,6 +193,24 @@ type BulkCreateRequest struct {
 	Data []MarketData `json:"data" binding:"required,dive"`
 }
 
+// BulkResult reports how much of a chunked bulk upsert was committed and what
+// happened to each committed row
+type BulkResult struct {
+	Chunks          int `json:"chunks"`
+	ChunksCommitted int `json:"chunks_committed"`
+	RowsCommitted   int `json:"rows_committed"`
+	Inserted        int `json:"inserted"`
+	Updated         int `json:"updated"`
+	Skipped         int `json:"skipped"`
+}
+
+// Add accumulates another result's row outcomes
+func (r *BulkResult) Add(other BulkResult) {
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Skipped += other.Skipped
+}
+
 // YahooQuote represents data from Yahoo Finance API
 type YahooQuote struct {
 	Symbol   string    `json:"symbol"`
@@ -35,8 +225,87 @@ type YahooQuote struct {
 
 // CSVUploadResponse represents the response for CSV upload
 type CSVUploadResponse struct {
-	Message      string   `json:"message"`
-	RowsImported int      `json:"rows_imported"`
-	RowsSkipped  int      `json:"rows_skipped"`
-	Errors       []string `json:"errors,omitempty"`
+	Message          string        `json:"message"`
+	Format           string        `json:"format"` // export format the file was read as
+	RowsImported     int           `json:"rows_imported"`
+	RowsSkipped      int           `json:"rows_skipped"`
+	Inserted         int           `json:"inserted"`
+	Updated          int           `json:"updated"`
+	ConflictsSkipped int           `json:"conflicts_skipped"` // existing rows kept under on_conflict=skip
+	Errors           []string      `json:"errors,omitempty"`
+	Screening        *ScreenReport `json:"screening,omitempty"`
+}
+
+// SymbolPerformance summarizes how a symbol moved over a date window
+type SymbolPerformance struct {
+	Symbol     string     `json:"symbol"`
+	AssetClass string     `json:"asset_class"`
+	StartDate  *time.Time `json:"start_date,omitempty"`
+	EndDate    *time.Time `json:"end_date,omitempty"`
+	StartClose float64    `json:"start_close"`
+	EndClose   float64    `json:"end_close"`
+	Change     float64    `json:"change"`
+	ChangePct  float64    `json:"change_pct"`
+	High       float64    `json:"high"`
+	Low        float64    `json:"low"`
+	Points     int        `json:"points"`
+}
+
+// RangeEstimate sizes a date-range read before it runs
+type RangeEstimate struct {
+	EstimatedRows int64 `json:"estimated_rows"`
+	MaxRows       int64 `json:"max_rows"`
+	ChunkDays     int   `json:"chunk_days,omitempty"` // days each smaller read may span
+	Chunks        int   `json:"chunks,omitempty"`     // reads of ChunkDays that cover the range
+}
+
+// Periods daily candles can be aggregated into
+const (
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+)
+
+// ValidPeriod reports whether period is a supported aggregation period
+func ValidPeriod(period string) bool {
+	return period == PeriodWeekly || period == PeriodMonthly
+}
+
+// How read endpoints adjust prices for corporate actions
+const (
+	AdjustNone   = "none"
+	AdjustSplits = "splits" // back-adjusted for splits
+	AdjustAll    = "all"    // back-adjusted for splits and dividends
+)
+
+// ValidAdjust reports whether mode is a supported price adjustment
+func ValidAdjust(mode string) bool {
+	return mode == AdjustNone || mode == AdjustSplits || mode == AdjustAll
+}
+
+// AggregateCandle is OHLCV over a week (starting Monday) or calendar month of daily
+// candles: open of the first day, close of the last, extremes and total volume. Volume
+// is null when no day in the period has one.
+type AggregateCandle struct {
+	Exchange    string    `json:"exchange"`
+	Source      string    `json:"source"`
+	PeriodStart time.Time `json:"period_start"`
+	FirstDate   time.Time `json:"first_date"`
+	LastDate    time.Time `json:"last_date"`
+	Open        *float64  `json:"open"` // null when the first day has no open
+	High        float64   `json:"high"`
+	Low         float64   `json:"low"`
+	Close       float64   `json:"close"`
+	Volume      *int64    `json:"volume"`
+	Days        int       `json:"days"` // daily candles in the period
+}
+
+// SymbolCoverage is how much market data is stored for a listing
+type SymbolCoverage struct {
+	Exchange  string     `json:"exchange"`
+	Symbol    string     `json:"symbol"`
+	Rows      int64      `json:"rows"`
+	FirstDate *time.Time `json:"first_date"`
+	LastDate  *time.Time `json:"last_date"`
+	Intervals []string   `json:"intervals"`
+	Sources   []string   `json:"sources"`
 }
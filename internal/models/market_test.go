@@ -0,0 +1,83 @@
+package models
+
+import "testing"
+
+func TestCryptoPair(t *testing.T) {
+	cases := []struct {
+		symbol      string
+		base, quote string
+		ok          bool
+	}{
+		{symbol: "BTC-USDT", base: "BTC", quote: "USDT", ok: true},
+		{symbol: "BTCUSDT", base: "BTC", quote: "USDT", ok: true},
+		{symbol: "btc/usdt", base: "BTC", quote: "USDT", ok: true},
+		{symbol: " ethusdc ", base: "ETH", quote: "USDC", ok: true},
+		{symbol: "BTCFDUSD", base: "BTC", quote: "FDUSD", ok: true},
+		{symbol: "FDUSDUSDT", base: "FDUSD", quote: "USDT", ok: true},
+		{symbol: "1INCHUSDT", base: "1INCH", quote: "USDT", ok: true},
+		{symbol: "SOL/ETH", base: "SOL", quote: "ETH", ok: true},
+		{symbol: "ETH-BTC", base: "ETH", quote: "BTC", ok: true},
+
+		// BTC and ETH quotes need a separator; XETH could be an equity ticker
+		{symbol: "XETH"},
+		{symbol: "SOLETH"},
+		{symbol: "ETHBTC"},
+		// Bases are two to ten letters or digits
+		{symbol: "X/ETH"},
+		{symbol: "AUSDT"},
+		{symbol: "AUSDC"},
+		{symbol: "USDT"},
+		{symbol: "ABCDEFGHIJKUSDT"},
+		{symbol: "BTC--USDT"},
+		// Equities, including ones with a dash or dot
+		{symbol: "BRK-B"},
+		{symbol: "BRK.B"},
+		{symbol: "BBCA.JK"},
+		{symbol: "USDT.JK"},
+		{symbol: "AAPL"},
+		{symbol: "BTC-EUR"},
+		{symbol: ""},
+	}
+	for _, tc := range cases {
+		base, quote, ok := CryptoPair(tc.symbol)
+		if base != tc.base || quote != tc.quote || ok != tc.ok {
+			t.Errorf("CryptoPair(%q) = %q, %q, %v; want %q, %q, %v", tc.symbol, base, quote, ok, tc.base, tc.quote, tc.ok)
+		}
+	}
+}
+
+func TestCanonicalSymbol(t *testing.T) {
+	cases := map[string]string{
+		"BTCUSDT":  "BTC-USDT",
+		"btc/usdt": "BTC-USDT",
+		"BTC-usdt": "BTC-USDT",
+		"BTC-USDT": "BTC-USDT",
+		"SOL/ETH":  "SOL-ETH",
+		"X/ETH":    "X/ETH",
+		"XETH":     "XETH",
+		"BRK-B":    "BRK-B",
+		"BBCA.JK":  "BBCA.JK",
+		"aapl":     "aapl",
+	}
+	for symbol, want := range cases {
+		if got := CanonicalSymbol(symbol); got != want {
+			t.Errorf("CanonicalSymbol(%q) = %q, want %q", symbol, got, want)
+		}
+	}
+}
+
+func TestExchangeForSymbol(t *testing.T) {
+	cases := map[string]string{
+		"BTCUSDT":  ExchangeCrypto,
+		"BTC-USDT": ExchangeCrypto,
+		"XETH":     ExchangeUS,
+		"BRK-B":    ExchangeUS,
+		"BBCA.JK":  ExchangeIDX,
+		"AAPL":     ExchangeUS,
+	}
+	for symbol, want := range cases {
+		if got := ExchangeForSymbol(symbol); got != want {
+			t.Errorf("ExchangeForSymbol(%q) = %q, want %q", symbol, got, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// NAV is a fund's net asset value per unit on Date. Funds have no intraday prices
+// or volume, so this is all that is stored for them.
+type NAV struct {
+	ID        int64     `json:"id" db:"id"`
+	Symbol    string    `json:"symbol" db:"symbol"`
+	Date      time.Time `json:"date" db:"date"`
+	NAV       float64   `json:"nav" db:"nav"`
+	Source    string    `json:"source" db:"source"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NAVInput is one stored NAV in an upsert
+type NAVInput struct {
+	Symbol string  `json:"symbol" binding:"required,max=20"`
+	Date   string  `json:"date" binding:"required"` // YYYY-MM-DD
+	NAV    float64 `json:"nav" binding:"required,gt=0"`
+	Source string  `json:"source" binding:"omitempty,max=50"`
+}
+
+// UpsertNAVRequest stores or replaces fund NAVs
+type UpsertNAVRequest struct {
+	Data []NAVInput `json:"data" binding:"required,min=1,max=5000,dive"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// SymbolNote is a user's markdown note on a symbol. Shared notes are readable by
+// the author's organization.
+type SymbolNote struct {
+	ID           int64            `json:"id" db:"id"`
+	UserID       string           `json:"user_id" db:"user_id"`
+	Organization string           `json:"organization,omitempty" db:"organization"`
+	Symbol       string           `json:"symbol" db:"symbol"`
+	Title        string           `json:"title" db:"title"`
+	Body         string           `json:"body" db:"body"` // markdown
+	Shared       bool             `json:"shared" db:"shared"`
+	Attachments  []NoteAttachment `json:"attachments"`
+	CreatedAt    time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// NoteAttachment is a file attached to a note
+type NoteAttachment struct {
+	ID          int64     `json:"id" db:"id"`
+	NoteID      int64     `json:"note_id" db:"note_id"`
+	Filename    string    `json:"filename" db:"filename"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	ObjectKey   string    `json:"-" db:"object_key"`
+	SizeBytes   int64     `json:"size_bytes" db:"size_bytes"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateNoteRequest struct {
+	Title  string `json:"title" binding:"max=200"`
+	Body   string `json:"body" binding:"required"`
+	Shared bool   `json:"shared"`
+}
+
+// UpdateNoteRequest changes the fields given
+type UpdateNoteRequest struct {
+	Title  *string `json:"title" binding:"omitempty,max=200"`
+	Body   *string `json:"body" binding:"omitempty,min=1"`
+	Shared *bool   `json:"shared"`
+}
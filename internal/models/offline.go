@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OfflineBundle is what the mobile app caches to work without a connection: the
+// user's preferences and the recent daily candles of their watchlist. A delta
+// (Full false) holds only what changed since BaseVersion and is applied on top of it.
+type OfflineBundle struct {
+	Version     int64     `json:"version"`
+	BaseVersion int64     `json:"base_version,omitempty"` // the bundle a delta applies to
+	Full        bool      `json:"full"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Source      string    `json:"source"`       // how candles were merged across sources
+	WindowStart string    `json:"window_start"` // candles dated before it may be dropped
+
+	Preferences OfflinePreferences `json:"preferences"`
+	Series      []OfflineSeries    `json:"series"`
+	Removed     []string           `json:"removed,omitempty"` // symbols no longer watched
+}
+
+// OfflinePreferences is the part of the user's preferences the app needs offline
+type OfflinePreferences struct {
+	DefaultSource   string   `json:"default_source"`
+	Watchlist       []string `json:"watchlist"`
+	SelectedSymbols []string `json:"selected_symbols"`
+	DefaultLimit    int      `json:"default_limit"`
+	DefaultWindow   int      `json:"default_window_days"`
+}
+
+// OfflineSeries is one symbol's daily candles, oldest first. In a delta they replace
+// the cached candles of the same dates.
+type OfflineSeries struct {
+	Symbol  string          `json:"symbol"`
+	Candles []OfflineCandle `json:"candles"`
+}
+
+// OfflineCandle is a daily candle written compactly as
+// [date, open, high, low, close, volume], with a missing open or volume as null
+type OfflineCandle struct {
+	Date   string
+	Open   *float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume *int64
+}
+
+// MarshalJSON writes the candle as an array
+func (c OfflineCandle) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{c.Date, c.Open, c.High, c.Low, c.Close, c.Volume})
+}
+
+// OfflineKey is the public half of the key offline bundles are signed with
+type OfflineKey struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"` // base64
+}
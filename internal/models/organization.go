@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// MemberSettings are one organization member's portable settings. Members are
+// matched by email on import, since identity IDs differ between environments.
+type MemberSettings struct {
+	Email           string   `json:"email" yaml:"email" binding:"required,email"`
+	DefaultSource   string   `json:"default_source" yaml:"default_source,omitempty"`
+	DefaultLimit    int      `json:"default_limit" yaml:"default_limit,omitempty" binding:"omitempty,min=1,max=1000"`
+	DefaultWindow   int      `json:"default_window_days" yaml:"default_window_days,omitempty" binding:"omitempty,min=1,max=3650"`
+	Watchlist       []string `json:"watchlist" yaml:"watchlist,omitempty,flow"`
+	SelectedSymbols []string `json:"selected_symbols" yaml:"selected_symbols,omitempty,flow"`
+}
+
+// OrganizationExport is the settings of every member of an organization, in the
+// shape accepted by the import
+type OrganizationExport struct {
+	Organization string           `json:"organization"`
+	ExportedAt   time.Time        `json:"exported_at"`
+	Members      []MemberSettings `json:"members" binding:"required,dive"`
+}
+
+// OrganizationImportResult reports which imported members were applied. Members not
+// yet signed in to this environment, or in another organization, are unmatched.
+type OrganizationImportResult struct {
+	Organization string   `json:"organization"`
+	Updated      int      `json:"updated"`
+	Unmatched    []string `json:"unmatched"`
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Outbox topics
+const (
+	TopicMarketDataWritten      = "market_data.written"
+	TopicPortfolioEvent         = "portfolio.event_recorded"
+	TopicCorporateActionApplied = "corporate_action.applied"
+	TopicQuotaWarning           = "quota.warning"
+	TopicSourceStale            = "source.ingest_stale"
+	TopicSourceRecovered        = "source.ingest_recovered"
+)
+
+// OutboxMessage is a change published once the write that made it has committed.
+// Delivery is at least once, so consumers deduplicate on ID; messages sharing a Key
+// are delivered in order.
+type OutboxMessage struct {
+	ID        int64           `json:"id"`
+	Topic     string          `json:"topic"`
+	Key       string          `json:"key"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// MarketDataWritten is the payload of TopicMarketDataWritten: the candles of one
+// listing, interval and source a write inserted or updated
+type MarketDataWritten struct {
+	Exchange string    `json:"exchange"`
+	Symbol   string    `json:"symbol"`
+	Interval string    `json:"interval"`
+	Source   string    `json:"source"`
+	Rows     int       `json:"rows"`
+	From     time.Time `json:"from"` // earliest candle timestamp
+	To       time.Time `json:"to"`   // latest candle timestamp
+}
+
+// CorporateActionApplied is the payload of TopicCorporateActionApplied
+type CorporateActionApplied struct {
+	Action      CorporateAction `json:"action"`
+	Adjustments int             `json:"adjustments"`
+}
+
+// QuotaWarning is the payload of TopicQuotaWarning, sent once when a user's usage of
+// a quota first crosses the warning threshold in its window
+type QuotaWarning struct {
+	UserID string `json:"user_id"`
+	QuotaUsage
+}
+
+// SourceIngestAlert is the payload of TopicSourceStale, sent when a monitored source
+// has delivered nothing for its window, and of TopicSourceRecovered, sent when it
+// delivers again
+type SourceIngestAlert struct {
+	Source        string     `json:"source"`
+	WindowMinutes int        `json:"window_minutes"`
+	LastIngestAt  *time.Time `json:"last_ingest_at"` // nil if it never delivered
+	StaleSince    time.Time  `json:"stale_since"`
+}
+
+// OutboxFailure is a message whose delivery has failed at least once
+type OutboxFailure struct {
+	ID            int64      `json:"id"`
+	Topic         string     `json:"topic"`
+	Key           string     `json:"key"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // nil once given up on
+	DeadAt        *time.Time `json:"dead_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// OutboxStatus is the relay's backlog
+type OutboxStatus struct {
+	Enabled         bool            `json:"enabled"`
+	Publisher       string          `json:"publisher,omitempty"`
+	Pending         int64           `json:"pending"`
+	Retrying        int64           `json:"retrying"` // pending messages that have failed before
+	Dead            int64           `json:"dead"`
+	OldestPendingAt *time.Time      `json:"oldest_pending_at,omitempty"`
+	Failures        []OutboxFailure `json:"failures"` // most recent first
+}
+
+// RetryOutboxRequest requeues the dead messages created in [from, to), as after an
+// outage of the receiving endpoint
+type RetryOutboxRequest struct {
+	From  string `json:"from" binding:"required"` // RFC3339
+	To    string `json:"to" binding:"required"`   // RFC3339
+	Topic string `json:"topic"`                   // only this topic; all when empty
+}
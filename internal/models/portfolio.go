@@ -0,0 +1,121 @@
+package models
+
+import "time"
+
+// Portfolio is a named set of holdings owned by a user
+type Portfolio struct {
+	ID        int64      `json:"id" db:"id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	Name      string     `json:"name" db:"name"`
+	Cash      float64    `json:"cash" db:"cash"`
+	Holdings  []Holding  `json:"holdings"`
+	AsOf      *time.Time `json:"as_of,omitempty"` // set when reconstructed from events
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Holding is a position in one symbol
+type Holding struct {
+	Symbol    string    `json:"symbol" db:"symbol"`
+	Quantity  float64   `json:"quantity" db:"quantity"`
+	AvgPrice  float64   `json:"avg_price" db:"avg_price"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// HoldingValuation values a holding at the latest close, or latest NAV for a fund.
+// A bond's quantity is face value and its prices are percent of par; its market value
+// includes accrued interest.
+type HoldingValuation struct {
+	Symbol          string     `json:"symbol"`
+	Quantity        float64    `json:"quantity"`
+	AvgPrice        float64    `json:"avg_price"`
+	CostBasis       float64    `json:"cost_basis"`
+	LastPrice       *float64   `json:"last_price"`
+	PriceDate       *time.Time `json:"price_date,omitempty"`
+	PriceSource     string     `json:"price_source,omitempty"`
+	AccruedInterest *float64   `json:"accrued_interest,omitempty"`
+	MarketValue     *float64   `json:"market_value"`
+	UnrealizedPnL   *float64   `json:"unrealized_pnl"`
+	PnLPct          *float64   `json:"pnl_pct"`
+	Currency        string     `json:"currency,omitempty"` // the symbol's own, when converted
+	FXRate          *float64   `json:"fx_rate,omitempty"`  // from Currency into the valuation's
+}
+
+// PortfolioValuation is a portfolio priced at the latest closes, or for AsOf the
+// holdings then priced at the closes on or before it. Holdings without a price are
+// listed but left out of the totals, as is cash.
+type PortfolioValuation struct {
+	PortfolioID   int64              `json:"portfolio_id"`
+	Name          string             `json:"name"`
+	AsOf          *time.Time         `json:"as_of,omitempty"`
+	Currency      string             `json:"currency,omitempty"` // every amount is converted into it when set
+	Cash          float64            `json:"cash"`
+	Holdings      []HoldingValuation `json:"holdings"`
+	CostBasis     float64            `json:"cost_basis"`
+	MarketValue   float64            `json:"market_value"`
+	UnrealizedPnL float64            `json:"unrealized_pnl"`
+	PnLPct        float64            `json:"pnl_pct"`
+	Unpriced      []string           `json:"unpriced,omitempty"`
+}
+
+// PortfolioRequest creates or renames a portfolio
+type PortfolioRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// HoldingRequest sets a holding's position, from At when backfilling history
+type HoldingRequest struct {
+	Quantity float64    `json:"quantity" binding:"required,gt=0"`
+	AvgPrice float64    `json:"avg_price" binding:"min=0"`
+	At       *time.Time `json:"at"`
+}
+
+// Portfolio event types
+const (
+	EventHoldingSet      = "holding_set"
+	EventHoldingRemoved  = "holding_removed"
+	EventTrade           = "trade"
+	EventDeposit         = "deposit"
+	EventWithdrawal      = "withdrawal"
+	EventCorporateAction = "corporate_action"
+)
+
+// Trade sides
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// PortfolioEvent is one change to a portfolio. Events are never edited; replaying
+// them in OccurredAt order gives the holdings and cash at any time. A corporate
+// action event records the position the action left.
+type PortfolioEvent struct {
+	ID          int64     `json:"id"`
+	PortfolioID int64     `json:"portfolio_id"`
+	EventType   string    `json:"event_type"`
+	Symbol      string    `json:"symbol,omitempty"`
+	Side        string    `json:"side,omitempty"`
+	Quantity    *float64  `json:"quantity,omitempty"`
+	Price       *float64  `json:"price,omitempty"`
+	Amount      *float64  `json:"amount,omitempty"`
+	ActionID    *int64    `json:"action_id,omitempty"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+// TradeRequest records a buy or sell, executed now unless ExecutedAt says otherwise
+type TradeRequest struct {
+	Symbol     string     `json:"symbol" binding:"required,max=20"`
+	Side       string     `json:"side" binding:"required,oneof=buy sell"`
+	Quantity   float64    `json:"quantity" binding:"required,gt=0"`
+	Price      float64    `json:"price" binding:"min=0"`
+	ExecutedAt *time.Time `json:"executed_at"`
+}
+
+// CashRequest records a deposit or withdrawal
+type CashRequest struct {
+	Type   string     `json:"type" binding:"required,oneof=deposit withdrawal"`
+	Amount float64    `json:"amount" binding:"required,gt=0"`
+	At     *time.Time `json:"at"`
+}
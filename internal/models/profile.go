@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// ColumnStats summarizes one numeric column
+type ColumnStats struct {
+	Nulls int64    `json:"nulls"`
+	Min   *float64 `json:"min"`
+	Max   *float64 `json:"max"`
+	Mean  *float64 `json:"mean"`
+}
+
+// Distribution describes a series by its moments and quantiles (keys p01 … p99)
+type Distribution struct {
+	Count     int64              `json:"count"`
+	Mean      *float64           `json:"mean"`
+	StdDev    *float64           `json:"stddev"`
+	Min       *float64           `json:"min"`
+	Max       *float64           `json:"max"`
+	Quantiles map[string]float64 `json:"quantiles"`
+}
+
+// DataProfile reports data quality statistics for a symbol
+type DataProfile struct {
+	Symbol           string                 `json:"symbol"`
+	Source           string                 `json:"source,omitempty"`
+	Rows             int64                  `json:"rows"`
+	FirstDate        *time.Time             `json:"first_date"`
+	LastDate         *time.Time             `json:"last_date"`
+	Columns          map[string]ColumnStats `json:"columns"`
+	ZeroVolumeRows   int64                  `json:"zero_volume_rows"`
+	InconsistentRows int64                  `json:"inconsistent_ohlc_rows"` // high/low not bounding open/close
+	Returns          Distribution           `json:"daily_returns"`          // close-to-close, as fractions
+	Volume           Distribution           `json:"volume"`
+}
+
+// DataGap is a run of consecutive trading days with no stored candle
+type DataGap struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Days      int    `json:"trading_days"`
+}
+
+// GapReport lists the trading days missing between a symbol's first and last stored
+// daily candles, by its exchange's calendar
+type GapReport struct {
+	Symbol      string    `json:"symbol"`
+	Exchange    string    `json:"exchange"`
+	Source      string    `json:"source"`
+	StartDate   string    `json:"start_date"`
+	EndDate     string    `json:"end_date"`
+	TradingDays int       `json:"trading_days"`
+	StoredDays  int       `json:"stored_days"`
+	MissingDays int       `json:"missing_days"`
+	Gaps        []DataGap `json:"gaps"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ProvisionedFile is one file of the provisioning directory: what it declares, how
+// it was applied at startup and where the stored settings have drifted from it since
+type ProvisionedFile struct {
+	Path         string        `json:"path"` // relative to the provisioning directory
+	Organization string        `json:"organization,omitempty"`
+	Checksum     string        `json:"checksum"` // SHA-256 of the file as applied
+	Members      int           `json:"members"`
+	AppliedAt    *time.Time    `json:"applied_at,omitempty"`
+	Updated      int           `json:"updated"`
+	Unmatched    []string      `json:"unmatched"` // members not signed in to this environment
+	Error        string        `json:"error,omitempty"`
+	Changed      bool          `json:"changed"` // the file on disk differs from the one applied
+	Drift        []MemberDrift `json:"drift"`
+}
+
+// MemberDrift lists the provisioned settings a member no longer has
+type MemberDrift struct {
+	Email  string   `json:"email"`
+	Fields []string `json:"fields"`
+}
+
+// ProvisioningStatus is the applied provisioning state of this instance
+type ProvisioningStatus struct {
+	Dir     string            `json:"dir,omitempty"`
+	Files   []ProvisionedFile `json:"files"`
+	Drifted int               `json:"drifted"` // members with at least one drifted setting
+}
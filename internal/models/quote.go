@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Quote is the most recent price known for a symbol
+type Quote struct {
+	Symbol        string    `json:"symbol"`
+	Price         float64   `json:"price"`
+	Open          *float64  `json:"open"`
+	High          float64   `json:"high"`
+	Low           float64   `json:"low"`
+	Volume        *int64    `json:"volume"`
+	PreviousClose float64   `json:"previous_close,omitempty"`
+	Change        float64   `json:"change"`
+	ChangePct     float64   `json:"change_pct"`
+	Source        string    `json:"source"`
+	AsOf          time.Time `json:"as_of"`
+}
+
+// QuoteAttempt records the outcome of one step of the quote fallback chain
+type QuoteAttempt struct {
+	Step    string `json:"step"`
+	Outcome string `json:"outcome"` // ok, stale, not_found, timeout, error
+	TookMS  int64  `json:"took_ms"`
+	Error   string `json:"error,omitempty"` // reason an errored step failed: rate_limited, not_configured or unavailable
+}
+
+// QuoteResult is a quote together with the path taken to obtain it. MarketState is
+// the listing exchange's state when the quote was served; outside MarketOpen the price
+// is from an earlier session and should not be read as a live move.
+type QuoteResult struct {
+	Quote       *Quote         `json:"quote"`
+	Path        string         `json:"path"`
+	Exchange    string         `json:"exchange,omitempty"`
+	MarketState string         `json:"market_state,omitempty"`
+	Attempts    []QuoteAttempt `json:"attempts"`
+}
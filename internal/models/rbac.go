@@ -0,0 +1,20 @@
+package models
+
+// Role is what a role grants, merged from configuration and the database
+type Role struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+	Configured  []string `json:"configured"` // from RBAC_ROLE_PERMISSIONS, read-only at runtime
+	Stored      []string `json:"stored"`     // from the database, replaced by PUT /admin/roles/:role
+}
+
+type SetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+// EffectivePermissions is what a set of roles grants together
+type EffectivePermissions struct {
+	UserID      string   `json:"user_id,omitempty"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// Readiness gates, one per subsystem /ready can check
+const (
+	GateDB     = "db"     // the database answers
+	GateCache  = "cache"  // the read cache answers, when one is configured
+	GateIngest = "ingest" // queued imports are picked up in time
+	GateQueue  = "queue"  // outbox messages are delivered in time, when a publisher is configured
+)
+
+// Gate states
+const (
+	GateOK       = "ok"
+	GateFailing  = "failing"
+	GateDisabled = "disabled" // the subsystem is not configured; never fails readiness
+)
+
+// GateStatus is one gate's last check
+type GateStatus struct {
+	Status   string `json:"status"`
+	Blocking bool   `json:"blocking"` // whether failing makes /ready answer 503
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Readiness reports every gate; Ready is false when a blocking gate fails
+type Readiness struct {
+	Ready bool                  `json:"ready"`
+	Gates map[string]GateStatus `json:"gates"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// ReconcileRow compares one date's candle between two sources; diffs are percentages of source B
+type ReconcileRow struct {
+	Date          time.Time `json:"date"`
+	SourceA       string    `json:"source_a"`
+	SourceB       string    `json:"source_b"`
+	CloseA        float64   `json:"close_a"`
+	CloseB        float64   `json:"close_b"`
+	OpenDiffPct   float64   `json:"open_diff_pct"`
+	HighDiffPct   float64   `json:"high_diff_pct"`
+	LowDiffPct    float64   `json:"low_diff_pct"`
+	CloseDiffPct  float64   `json:"close_diff_pct"`
+	VolumeDiffPct float64   `json:"volume_diff_pct"`
+	Discrepant    bool      `json:"discrepant"`
+	Fields        []string  `json:"fields,omitempty"` // fields whose diff exceeds the tolerance
+}
+
+// MissingCandle is a date some sources have and others lack
+type MissingCandle struct {
+	Date    time.Time `json:"date"`
+	Present []string  `json:"present"`
+	Missing []string  `json:"missing"`
+}
+
+// ReconcileReport compares a symbol's candles across sources
+type ReconcileReport struct {
+	Symbol             string          `json:"symbol"`
+	StartDate          time.Time       `json:"start_date"`
+	EndDate            time.Time       `json:"end_date"`
+	TolerancePct       float64         `json:"tolerance_pct"`
+	VolumeTolerancePct float64         `json:"volume_tolerance_pct"`
+	Sources            []string        `json:"sources"`
+	DatesCompared      int             `json:"dates_compared"`
+	Discrepancies      int             `json:"discrepancies"`
+	Rows               []ReconcileRow  `json:"rows"`
+	Missing            []MissingCandle `json:"missing"`
+}
+
+// CompareCandle is one source's candle in a comparison
+type CompareCandle struct {
+	Open   *float64 `json:"open"`
+	High   float64  `json:"high"`
+	Low    float64  `json:"low"`
+	Close  float64  `json:"close"`
+	Volume *int64   `json:"volume"`
+}
+
+// CompareDiff is how one source's candle differs from the reference source's, in
+// percentages of the reference
+type CompareDiff struct {
+	OpenDiffPct   float64  `json:"open_diff_pct"`
+	HighDiffPct   float64  `json:"high_diff_pct"`
+	LowDiffPct    float64  `json:"low_diff_pct"`
+	CloseDiffPct  float64  `json:"close_diff_pct"`
+	VolumeDiffPct float64  `json:"volume_diff_pct"`
+	Discrepant    bool     `json:"discrepant"`
+	Fields        []string `json:"fields,omitempty"` // fields whose diff exceeds the tolerance
+}
+
+// CompareRow is one date's candles side by side, keyed by source
+type CompareRow struct {
+	Date       time.Time                 `json:"date"`
+	Candles    map[string]*CompareCandle `json:"candles"`         // null for a source without a candle
+	Diffs      map[string]CompareDiff    `json:"diffs,omitempty"` // each other source against the reference
+	Discrepant bool                      `json:"discrepant"`
+	Missing    []string                  `json:"missing,omitempty"` // sources without a candle
+}
+
+// CompareReport sets a symbol's candles from chosen sources side by side. Diffs are
+// taken against the reference, the first source asked for; dates the reference lacks
+// have no diffs.
+type CompareReport struct {
+	Symbol             string       `json:"symbol"`
+	Interval           string       `json:"interval"`
+	StartDate          time.Time    `json:"start_date"`
+	EndDate            time.Time    `json:"end_date"`
+	Sources            []string     `json:"sources"`
+	Reference          string       `json:"reference"`
+	TolerancePct       float64      `json:"tolerance_pct"`
+	VolumeTolerancePct float64      `json:"volume_tolerance_pct"`
+	Dates              int          `json:"dates"`            // dates any source has a candle for
+	DiscrepantDates    int          `json:"discrepant_dates"` // dates some source is beyond tolerance
+	IncompleteDates    int          `json:"incomplete_dates"` // dates some source has no candle for
+	Rows               []CompareRow `json:"rows"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// Search result types
+const (
+	SearchSymbol = "symbol"
+	SearchNote   = "note"
+)
+
+// SearchResult is one match of GET /api/v1/search, tagged with what it is
+type SearchResult struct {
+	Type     string  `json:"type"`
+	ID       string  `json:"id"` // EXCHANGE:SYMBOL for symbols, the note ID for notes
+	Symbol   string  `json:"symbol"`
+	Exchange string  `json:"exchange,omitempty"`
+	Title    string  `json:"title"`
+	Snippet  string  `json:"snippet"` // matches marked **like this** in note bodies
+	Rank     float64 `json:"rank"`
+	Path     string  `json:"path"` // API resource the result links to
+}
@@ -0,0 +1,102 @@
+package models
+
+import "time"
+
+// Source describes a market data provider and the terms its data is used under
+type Source struct {
+	Name                  string    `json:"name" db:"name"`
+	DisplayName           string    `json:"display_name" db:"display_name"`
+	Attribution           string    `json:"attribution" db:"attribution"`
+	License               string    `json:"license" db:"license"`
+	LicenseURL            string    `json:"license_url,omitempty" db:"license_url"`
+	RedistributionAllowed bool      `json:"redistribution_allowed" db:"redistribution_allowed"`
+	AnomalyPolicy         string    `json:"anomaly_policy" db:"anomaly_policy"`
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
+
+	// Ingest monitoring: a source with a window is reported stale when it delivers
+	// no candles for that long
+	IngestWindowMinutes *int       `json:"ingest_window_minutes" db:"ingest_window_minutes"`
+	LastIngestAt        *time.Time `json:"last_ingest_at" db:"last_ingest_at"`
+	StaleSince          *time.Time `json:"stale_since,omitempty" db:"stale_since"`
+
+	TransformRules []TransformRule `json:"transform_rules" db:"transform_rules"`
+}
+
+// TransformRule rewrites the candles of a source that match it on ingest. Only the
+// fields of its kind apply; the match fields narrow it to some candles.
+type TransformRule struct {
+	Kind   string  `json:"kind"`
+	Factor float64 `json:"factor,omitempty"` // scale_prices, scale_volume
+	Suffix string  `json:"suffix,omitempty"` // strip_suffix
+
+	SymbolSuffix string `json:"symbol_suffix,omitempty"` // only symbols ending in it, e.g. .JK
+	Exchange     string `json:"exchange,omitempty"`
+	Interval     string `json:"interval,omitempty"`
+}
+
+// UpdateTransformRulesRequest replaces a source's transform rules; an empty list
+// removes them
+type UpdateTransformRulesRequest struct {
+	Rules []TransformRule `json:"rules" binding:"required,max=20"`
+}
+
+// PreviewTransformRequest applies rules to a sample batch without storing it. Without
+// rules the source's stored rules are previewed.
+type PreviewTransformRequest struct {
+	Rules []TransformRule `json:"rules" binding:"omitempty,max=20"`
+	Data  []MarketData    `json:"data" binding:"required,min=1,max=1000"`
+}
+
+// TransformPreview is the sample batch before and after the rules
+type TransformPreview struct {
+	Source  string              `json:"source"`
+	Rules   []TransformRule     `json:"rules"`
+	Changed int                 `json:"changed"`
+	Rows    []TransformedCandle `json:"rows"`
+}
+
+// TransformedCandle is one candle of a preview
+type TransformedCandle struct {
+	Before  MarketData `json:"before"`
+	After   MarketData `json:"after"`
+	Changed bool       `json:"changed"`
+}
+
+// UpdateIngestWindowRequest sets how long a source may go without delivering candles
+// before it is reported stale; null stops monitoring it
+type UpdateIngestWindowRequest struct {
+	WindowMinutes *int `json:"window_minutes" binding:"omitempty,min=1"`
+}
+
+// SourceAttribution is the attribution block included alongside served data
+type SourceAttribution struct {
+	Source                string `json:"source"`
+	Attribution           string `json:"attribution"`
+	License               string `json:"license,omitempty"`
+	LicenseURL            string `json:"license_url,omitempty"`
+	RedistributionAllowed bool   `json:"redistribution_allowed"`
+}
+
+// ProviderStatus reports an upstream provider candles can be fetched from and how its
+// fetches since startup went
+type ProviderStatus struct {
+	Name          string     `json:"name"`
+	DisplayName   string     `json:"display_name"`
+	Configured    bool       `json:"configured"`
+	Intervals     []string   `json:"intervals"` // candle intervals it can fetch
+	Status        string     `json:"status"`    // ok, failing, unconfigured or idle before the first fetch
+	Fetches       int        `json:"fetches"`
+	Failures      int        `json:"failures"`
+	LastFetchAt   *time.Time `json:"last_fetch_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// Provider statuses
+const (
+	ProviderOK           = "ok"
+	ProviderFailing      = "failing"
+	ProviderUnconfigured = "unconfigured"
+	ProviderIdle         = "idle"
+)
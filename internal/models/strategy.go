@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/strategy"
+)
+
+// Strategy is a saved strategy definition. Public strategies are readable, and can
+// be cloned, by every user.
+type Strategy struct {
+	ID         int64               `json:"id" db:"id"`
+	UserID     string              `json:"user_id" db:"user_id"`
+	Name       string              `json:"name" db:"name"`
+	Definition strategy.Definition `json:"definition" db:"definition"`
+	Public     bool                `json:"public" db:"public"`
+	ClonedFrom *int64              `json:"cloned_from,omitempty" db:"cloned_from"`
+	CreatedAt  time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrOffTick is returned for a price that is not a multiple of its tick size
+var ErrOffTick = errors.New("price is not on a valid tick")
+
+// TickBand is the tick size of prices from MinPrice up to the next band's MinPrice
+type TickBand struct {
+	MinPrice float64 `json:"min_price"`
+	TickSize float64 `json:"tick_size"`
+}
+
+// tickBands are the price fractions of exchanges that have them, lowest band first
+var tickBands = map[string][]TickBand{
+	// IDX equities (fraksi harga)
+	ExchangeIDX: {
+		{MinPrice: 0, TickSize: 1},
+		{MinPrice: 200, TickSize: 2},
+		{MinPrice: 500, TickSize: 5},
+		{MinPrice: 2000, TickSize: 10},
+		{MinPrice: 5000, TickSize: 25},
+	},
+}
+
+// TickBands returns an exchange's tick size bands, or nil when it has none
+func TickBands(exchange string) []TickBand {
+	return tickBands[exchange]
+}
+
+// TickSize returns the tick size at price on exchange; ok is false when the
+// exchange has no tick size rules
+func TickSize(exchange string, price float64) (tick float64, ok bool) {
+	bands := tickBands[exchange]
+	if len(bands) == 0 {
+		return 0, false
+	}
+	tick = bands[0].TickSize
+	for _, b := range bands {
+		if price < b.MinPrice {
+			break
+		}
+		tick = b.TickSize
+	}
+	return tick, true
+}
+
+// RoundToTick returns the nearest valid prices at or below and at or above price.
+// Both equal price when it is on a tick or the exchange has no rules.
+func RoundToTick(exchange string, price float64) (down, up float64) {
+	tick, ok := TickSize(exchange, price)
+	if !ok || onTick(price, tick) {
+		return price, price
+	}
+	down = math.Floor(price/tick) * tick
+	up = math.Ceil(price/tick) * tick
+	// A price just below a band boundary rounds up to the boundary, which is on
+	// the coarser tick of the band above
+	return down, up
+}
+
+// ValidateTick checks price is a multiple of its tick size on exchange. Exchanges
+// without rules accept any price.
+func ValidateTick(exchange string, price float64) error {
+	tick, ok := TickSize(exchange, price)
+	if !ok || onTick(price, tick) {
+		return nil
+	}
+	down, up := RoundToTick(exchange, price)
+	return fmt.Errorf("%w: %s price %g must be a multiple of %g; nearest valid prices are %g and %g",
+		ErrOffTick, exchange, price, tick, down, up)
+}
+
+// ValidateTicks checks the candle's open, high, low and close are on valid ticks
+func (md *MarketData) ValidateTicks() error {
+	for _, p := range []struct {
+		name  string
+		price *float64
+	}{{"open", md.Open}, {"high", &md.High}, {"low", &md.Low}, {"close", &md.Close}} {
+		if p.price == nil {
+			continue
+		}
+		if err := ValidateTick(md.Exchange, *p.price); err != nil {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+	}
+	return nil
+}
+
+// onTick allows for float error in prices parsed from JSON
+func onTick(price, tick float64) bool {
+	steps := price / tick
+	return math.Abs(steps-math.Round(steps)) < 1e-9
+}
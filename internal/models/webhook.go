@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook events a user can subscribe to. The service has no price alerts, so there
+// is no alert event.
+const (
+	EventImportCompleted = "import.completed"
+	EventImportFailed    = "import.failed"
+	EventExportCompleted = "export.completed"
+	EventExportFailed    = "export.failed"
+	EventQuotaWarning    = "quota.warning"
+	EventPing            = "ping" // sent on request to test an endpoint; not subscribable
+)
+
+// WebhookEvents are the events a webhook can subscribe to
+var WebhookEvents = []string{EventImportCompleted, EventImportFailed, EventExportCompleted, EventExportFailed, EventQuotaWarning}
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is an endpoint a user registered for some of their events. Its secret signs
+// every delivery and is only shown when the webhook is created.
+type Webhook struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	Events    []string  `json:"events" db:"events"`
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookRequest registers a webhook or replaces one's settings
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"required,min=1,max=10,dive,oneof=import.completed import.failed export.completed export.failed quota.warning"`
+	Active *bool    `json:"active"` // true when omitted
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook
+type WebhookDelivery struct {
+	ID             int64           `json:"id" db:"id"`
+	WebhookID      int64           `json:"webhook_id" db:"webhook_id"`
+	Event          string          `json:"event" db:"event"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	ResponseStatus *int            `json:"response_status,omitempty" db:"response_status"` // of the last attempt
+	LastError      string          `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty" db:"next_attempt_at"` // while pending
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	CompletedAt    *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// WebhookEnvelope is the body POSTed to a webhook
+type WebhookEnvelope struct {
+	ID        int64           `json:"id"` // the delivery; redeliveries get a new one
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// ImportFinished is the data of EventImportCompleted and EventImportFailed
+type ImportFinished struct {
+	ID            int64  `json:"id"`
+	Filename      string `json:"filename"`
+	Status        string `json:"status"`
+	RowsProcessed int64  `json:"rows_processed"`
+	RowsInserted  int64  `json:"rows_inserted"`
+	RowsUpdated   int64  `json:"rows_updated"`
+	RowsSkipped   int64  `json:"rows_skipped"`
+	RowsRejected  int64  `json:"rows_rejected"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ExportFinished is the data of EventExportCompleted and EventExportFailed. A completed
+// export carries its signed download URL, valid until ExpiresAt.
+type ExportFinished struct {
+	ID          int64      `json:"id"`
+	Status      string     `json:"status"`
+	Format      string     `json:"format"`
+	RowCount    int64      `json:"row_count"`
+	SizeBytes   int64      `json:"size_bytes"`
+	DownloadURL string     `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
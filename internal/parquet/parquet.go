@@ -0,0 +1,620 @@
+// Package parquet writes and reads flat tables as Apache Parquet files: row groups of
+// one PLAIN-encoded, gzip-compressed data page per column. Columns are required or
+// optional; nested and repeated columns, dictionary pages and other encodings are
+// not supported.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ErrUnsupported is returned when reading a file that uses a feature this package
+// does not implement
+var ErrUnsupported = errors.New("unsupported parquet feature")
+
+var magic = []byte("PAR1")
+
+// Kind is the type of a column's values
+type Kind int
+
+const (
+	String    Kind = iota // UTF-8 BYTE_ARRAY; values are string
+	Int64                 // INT64; values are int64
+	Double                // DOUBLE; values are float64
+	Date                  // INT32 DATE; values are time.Time, at midnight UTC
+	Timestamp             // INT64 TIMESTAMP(MICROS, UTC); values are time.Time
+)
+
+// Column is one column of a table. A nil value is a null, which only an optional
+// column may hold.
+type Column struct {
+	Name     string
+	Kind     Kind
+	Optional bool
+	Values   []interface{}
+}
+
+// Physical types, repetition types, converted types, encodings, codecs and page
+// types as numbered in the Parquet format
+const (
+	typeInt32     = 1
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedUTF8            = 0
+	convertedDate            = 6
+	convertedTimestampMicros = 10
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+	codecGzip         = 2
+
+	pageData = 0
+)
+
+func (k Kind) physical() int32 {
+	switch k {
+	case String:
+		return typeByteArray
+	case Date:
+		return typeInt32
+	case Double:
+		return typeDouble
+	default:
+		return typeInt64
+	}
+}
+
+// Write encodes columns, which must all hold as many values, as a Parquet file of
+// one row group
+func Write(w io.Writer, columns []Column) error {
+	pw, err := NewWriter(w, columns)
+	if err != nil {
+		return err
+	}
+	if err := pw.WriteRowGroup(columns); err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+// Writer writes a Parquet file to an io.Writer a row group at a time, so no more
+// than the group being written is held in memory. The file is incomplete until Close
+// writes its footer.
+type Writer struct {
+	w      *countingWriter
+	schema []Column // names, kinds and optionality; no values
+	groups []rowGroup
+	rows   int64
+}
+
+type rowGroup struct {
+	rows   int64
+	chunks []columnChunk
+}
+
+type columnChunk struct {
+	offset, uncompressed, compressed int64
+}
+
+// countingWriter tracks the offset of everything written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// NewWriter starts a Parquet file with the names, kinds and optionality of schema;
+// the schema's values are ignored
+func NewWriter(w io.Writer, schema []Column) (*Writer, error) {
+	if len(schema) == 0 {
+		return nil, errors.New("parquet: no columns")
+	}
+	pw := &Writer{w: &countingWriter{w: w}, schema: make([]Column, len(schema))}
+	for i, col := range schema {
+		pw.schema[i] = Column{Name: col.Name, Kind: col.Kind, Optional: col.Optional}
+	}
+	if _, err := pw.w.Write(magic); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// WriteRowGroup writes columns, which must match the schema and all hold as many
+// values, as one row group
+func (pw *Writer) WriteRowGroup(columns []Column) error {
+	if len(columns) != len(pw.schema) {
+		return fmt.Errorf("parquet: row group has %d columns, schema %d", len(columns), len(pw.schema))
+	}
+	rows := len(columns[0].Values)
+	for i, col := range columns {
+		want := pw.schema[i]
+		if col.Name != want.Name || col.Kind != want.Kind || col.Optional != want.Optional {
+			return fmt.Errorf("parquet: column %d is %s, schema has %s", i, col.Name, want.Name)
+		}
+		if len(col.Values) != rows {
+			return fmt.Errorf("parquet: column %s has %d values, want %d", col.Name, len(col.Values), rows)
+		}
+	}
+
+	group := rowGroup{rows: int64(rows), chunks: make([]columnChunk, len(columns))}
+	for i, col := range columns {
+		payload, err := encodePage(col)
+		if err != nil {
+			return err
+		}
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(payload); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		header := newThriftWriter()
+		header.i32(1, pageData)
+		header.i32(2, int32(len(payload)))
+		header.i32(3, int32(compressed.Len()))
+		header.beginStruct(5)
+		header.i32(1, int32(rows))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.endStruct()
+		headerBytes := header.bytes()
+
+		group.chunks[i] = columnChunk{
+			offset:       pw.w.n,
+			uncompressed: int64(len(headerBytes) + len(payload)),
+			compressed:   int64(len(headerBytes) + compressed.Len()),
+		}
+		if _, err := pw.w.Write(headerBytes); err != nil {
+			return err
+		}
+		if _, err := pw.w.Write(compressed.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	pw.groups = append(pw.groups, group)
+	pw.rows += int64(rows)
+	return nil
+}
+
+// Close writes the file's footer. It does not close the underlying writer.
+func (pw *Writer) Close() error {
+	meta := newThriftWriter()
+	meta.i32(1, 1)
+	meta.beginList(2, ctStruct, len(pw.schema)+1)
+	meta.beginStruct(0)
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(pw.schema)))
+	meta.endStruct()
+	for _, col := range pw.schema {
+		writeSchemaElement(meta, col)
+	}
+	meta.i64(3, pw.rows)
+	meta.beginList(4, ctStruct, len(pw.groups))
+	for _, group := range pw.groups {
+		meta.beginStruct(0)
+		meta.beginList(1, ctStruct, len(pw.schema))
+		var total int64
+		for i, col := range pw.schema {
+			c := group.chunks[i]
+			total += c.uncompressed
+			meta.beginStruct(0)
+			meta.i64(2, c.offset)
+			meta.beginStruct(3)
+			meta.i32(1, col.Kind.physical())
+			meta.beginList(2, ctI32, 2)
+			meta.i32Elem(encodingPlain)
+			meta.i32Elem(encodingRLE)
+			meta.beginList(3, ctBinary, 1)
+			meta.binaryElem(col.Name)
+			meta.i32(4, codecGzip)
+			meta.i64(5, group.rows)
+			meta.i64(6, c.uncompressed)
+			meta.i64(7, c.compressed)
+			meta.i64(9, c.offset)
+			meta.endStruct()
+			meta.endStruct()
+		}
+		meta.i64(2, total)
+		meta.i64(3, group.rows)
+		meta.endStruct()
+	}
+	meta.binary(6, "proto-trading-service")
+	metaBytes := meta.bytes()
+
+	var trailer [8]byte
+	binary.LittleEndian.PutUint32(trailer[:4], uint32(len(metaBytes)))
+	copy(trailer[4:], magic)
+	if _, err := pw.w.Write(metaBytes); err != nil {
+		return err
+	}
+	_, err := pw.w.Write(trailer[:])
+	return err
+}
+
+func writeSchemaElement(t *thriftWriter, col Column) {
+	t.beginStruct(0)
+	t.i32(1, col.Kind.physical())
+	repetition := int32(repetitionRequired)
+	if col.Optional {
+		repetition = repetitionOptional
+	}
+	t.i32(3, repetition)
+	t.binary(4, col.Name)
+	switch col.Kind {
+	case String:
+		t.i32(6, convertedUTF8)
+		t.beginStruct(10)
+		t.emptyStruct(1)
+		t.endStruct()
+	case Date:
+		t.i32(6, convertedDate)
+		t.beginStruct(10)
+		t.emptyStruct(6)
+		t.endStruct()
+	case Timestamp:
+		t.i32(6, convertedTimestampMicros)
+		t.beginStruct(10)
+		t.beginStruct(8)
+		t.bool(1, true)
+		t.beginStruct(2)
+		t.emptyStruct(2)
+		t.endStruct()
+		t.endStruct()
+		t.endStruct()
+	}
+	t.endStruct()
+}
+
+// encodePage returns a column's uncompressed page: the definition levels of an
+// optional column, then its non-null values
+func encodePage(col Column) ([]byte, error) {
+	var page bytes.Buffer
+	if col.Optional {
+		levels := encodeLevels(col.Values)
+		_ = binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+	}
+
+	var b [8]byte
+	for i, v := range col.Values {
+		if v == nil {
+			if !col.Optional {
+				return nil, fmt.Errorf("parquet: null in required column %s", col.Name)
+			}
+			continue
+		}
+		ok := true
+		switch col.Kind {
+		case String:
+			var s string
+			if s, ok = v.(string); ok {
+				binary.LittleEndian.PutUint32(b[:4], uint32(len(s)))
+				page.Write(b[:4])
+				page.WriteString(s)
+			}
+		case Int64:
+			var n int64
+			if n, ok = v.(int64); ok {
+				binary.LittleEndian.PutUint64(b[:], uint64(n))
+				page.Write(b[:])
+			}
+		case Double:
+			var f float64
+			if f, ok = v.(float64); ok {
+				binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+				page.Write(b[:])
+			}
+		case Date:
+			var t time.Time
+			if t, ok = v.(time.Time); ok {
+				days := math.Floor(float64(t.Unix()) / 86400)
+				binary.LittleEndian.PutUint32(b[:4], uint32(int32(days)))
+				page.Write(b[:4])
+			}
+		case Timestamp:
+			var t time.Time
+			if t, ok = v.(time.Time); ok {
+				binary.LittleEndian.PutUint64(b[:], uint64(t.UnixMicro()))
+				page.Write(b[:])
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("parquet: value %d of column %s is %T", i, col.Name, v)
+		}
+	}
+	return page.Bytes(), nil
+}
+
+// encodeLevels writes the definition levels of values, 1 for present and 0 for
+// null, as RLE runs with a bit width of 1
+func encodeLevels(values []interface{}) []byte {
+	var out []byte
+	for i := 0; i < len(values); {
+		level := values[i] != nil
+		run := 1
+		for i+run < len(values) && (values[i+run] != nil) == level {
+			run++
+		}
+		out = binary.AppendUvarint(out, uint64(run)<<1)
+		if level {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i += run
+	}
+	return out
+}
+
+// Read decodes a Parquet file written with flat columns and PLAIN encoding
+func Read(data []byte) ([]Column, error) {
+	if len(data) < 12 || !bytes.Equal(data[:4], magic) || !bytes.Equal(data[len(data)-4:], magic) {
+		return nil, errors.New("parquet: not a parquet file")
+	}
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if metaLen > len(data)-12 {
+		return nil, fmt.Errorf("parquet: %w", errMalformed)
+	}
+	r := &thriftReader{b: data[len(data)-8-metaLen : len(data)-8]}
+	meta, err := r.readStruct(0)
+	if err != nil {
+		return nil, fmt.Errorf("parquet: failed to read metadata: %w", err)
+	}
+
+	schema := meta.list(2)
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("parquet: %w: no schema", errMalformed)
+	}
+	var columns []Column
+	for _, e := range schema[1:] {
+		element, _ := e.(tstruct)
+		if n, _ := element.int(5); n > 0 {
+			return nil, fmt.Errorf("%w: nested column %s", ErrUnsupported, element.string(4))
+		}
+		col, err := schemaColumn(element)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, col)
+	}
+
+	for _, g := range meta.list(4) {
+		group, _ := g.(tstruct)
+		chunks := group.list(1)
+		if len(chunks) != len(columns) {
+			return nil, fmt.Errorf("parquet: %w: row group has %d columns, schema %d", errMalformed, len(chunks), len(columns))
+		}
+		for i, c := range chunks {
+			chunk, _ := c.(tstruct)
+			if err := readChunk(data, chunk.sub(3), &columns[i]); err != nil {
+				return nil, fmt.Errorf("parquet: column %s: %w", columns[i].Name, err)
+			}
+		}
+	}
+
+	rows, _ := meta.int(3)
+	for _, col := range columns {
+		if int64(len(col.Values)) != rows {
+			return nil, fmt.Errorf("parquet: %w: column %s has %d values, want %d", errMalformed, col.Name, len(col.Values), rows)
+		}
+	}
+	return columns, nil
+}
+
+func schemaColumn(element tstruct) (Column, error) {
+	col := Column{Name: element.string(4)}
+	repetition, _ := element.int(3)
+	switch repetition {
+	case repetitionRequired:
+	case repetitionOptional:
+		col.Optional = true
+	default:
+		return col, fmt.Errorf("%w: repeated column %s", ErrUnsupported, col.Name)
+	}
+
+	physical, _ := element.int(1)
+	converted, hasConverted := element.int(6)
+	switch {
+	case physical == typeByteArray:
+		col.Kind = String
+	case physical == typeDouble:
+		col.Kind = Double
+	case physical == typeInt32 && hasConverted && converted == convertedDate:
+		col.Kind = Date
+	case physical == typeInt64 && hasConverted && converted == convertedTimestampMicros:
+		col.Kind = Timestamp
+	case physical == typeInt64 && !hasConverted:
+		col.Kind = Int64
+	default:
+		return col, fmt.Errorf("%w: type of column %s", ErrUnsupported, col.Name)
+	}
+	return col, nil
+}
+
+// readChunk appends the values of one column chunk to col
+func readChunk(data []byte, meta tstruct, col *Column) error {
+	codec, _ := meta.int(4)
+	if codec != codecUncompressed && codec != codecGzip {
+		return fmt.Errorf("%w: codec %d", ErrUnsupported, codec)
+	}
+	if _, ok := meta.int(11); ok {
+		return fmt.Errorf("%w: dictionary pages", ErrUnsupported)
+	}
+	want, _ := meta.int(5)
+	offset, _ := meta.int(9)
+
+	var read int64
+	for read < want {
+		if offset < 0 || offset >= int64(len(data)) {
+			return errMalformed
+		}
+		r := &thriftReader{b: data[offset:]}
+		header, err := r.readStruct(0)
+		if err != nil {
+			return err
+		}
+		size, _ := header.int(3)
+		start := offset + int64(r.pos)
+		if size < 0 || start+size > int64(len(data)) {
+			return errMalformed
+		}
+		payload := data[start : start+size]
+		offset = start + size
+
+		if typ, _ := header.int(1); typ != pageData {
+			return fmt.Errorf("%w: page type %d", ErrUnsupported, typ)
+		}
+		if codec == codecGzip {
+			zr, err := gzip.NewReader(bytes.NewReader(payload))
+			if err != nil {
+				return err
+			}
+			if payload, err = io.ReadAll(zr); err != nil {
+				return err
+			}
+		}
+
+		page := header.sub(5)
+		n, _ := page.int(1)
+		if encoding, _ := page.int(2); encoding != encodingPlain {
+			return fmt.Errorf("%w: encoding %d", ErrUnsupported, encoding)
+		}
+		if err := decodePage(payload, int(n), col); err != nil {
+			return err
+		}
+		read += n
+	}
+	return nil
+}
+
+// decodePage appends the n values of a page to col
+func decodePage(page []byte, n int, col *Column) error {
+	present := make([]bool, n)
+	for i := range present {
+		present[i] = true
+	}
+	if col.Optional {
+		if len(page) < 4 {
+			return errMalformed
+		}
+		size := int(binary.LittleEndian.Uint32(page))
+		if size > len(page)-4 {
+			return errMalformed
+		}
+		if err := decodeLevels(page[4:4+size], present); err != nil {
+			return err
+		}
+		page = page[4+size:]
+	}
+
+	pos := 0
+	take := func(k int) ([]byte, error) {
+		if k < 0 || pos+k > len(page) {
+			return nil, errMalformed
+		}
+		b := page[pos : pos+k]
+		pos += k
+		return b, nil
+	}
+	for _, ok := range present {
+		if !ok {
+			col.Values = append(col.Values, nil)
+			continue
+		}
+		var v interface{}
+		switch col.Kind {
+		case String:
+			b, err := take(4)
+			if err != nil {
+				return err
+			}
+			if b, err = take(int(binary.LittleEndian.Uint32(b))); err != nil {
+				return err
+			}
+			v = string(b)
+		case Date:
+			b, err := take(4)
+			if err != nil {
+				return err
+			}
+			v = time.Unix(int64(int32(binary.LittleEndian.Uint32(b)))*86400, 0).UTC()
+		default:
+			b, err := take(8)
+			if err != nil {
+				return err
+			}
+			bits := binary.LittleEndian.Uint64(b)
+			switch col.Kind {
+			case Double:
+				v = math.Float64frombits(bits)
+			case Timestamp:
+				v = time.UnixMicro(int64(bits)).UTC()
+			default:
+				v = int64(bits)
+			}
+		}
+		col.Values = append(col.Values, v)
+	}
+	return nil
+}
+
+// decodeLevels reads definition levels of bit width 1, in RLE or bit-packed runs,
+// into present
+func decodeLevels(b []byte, present []bool) error {
+	i := 0
+	for i < len(present) {
+		header, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errMalformed
+		}
+		b = b[n:]
+		if header&1 == 0 {
+			if len(b) < 1 {
+				return errMalformed
+			}
+			run, level := int(header>>1), b[0] != 0
+			b = b[1:]
+			for ; run > 0 && i < len(present); run-- {
+				present[i] = level
+				i++
+			}
+			continue
+		}
+		groups := int(header >> 1)
+		if groups > len(b) {
+			return errMalformed
+		}
+		for _, packed := range b[:groups] {
+			for bit := 0; bit < 8 && i < len(present); bit++ {
+				present[i] = packed>>bit&1 != 0
+				i++
+			}
+		}
+		b = b[groups:]
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package parquet
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// allKinds returns one required and one optional column of every kind, with rows
+// values each. Optional columns hold nulls at the start, the end and in runs between.
+func allKinds(rows, seed int) []Column {
+	day := func(i int) time.Time {
+		return time.Date(1969, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i+seed)
+	}
+	micro := func(i int) time.Time {
+		return time.Date(1969, 12, 31, 23, 59, 59, 999999000, time.UTC).Add(time.Duration(i+seed) * (25*time.Hour + 61*time.Microsecond))
+	}
+	value := func(kind Kind, i int) interface{} {
+		switch kind {
+		case String:
+			return []string{"", "BBCA.JK", "BTC-USDT", "ünïcødé ✓", strings.Repeat("x", 300)}[(i+seed)%5]
+		case Int64:
+			return []int64{0, -1, math.MaxInt64, math.MinInt64, int64(i + seed)}[(i+seed)%5]
+		case Double:
+			return []float64{0, -1.5, math.MaxFloat64, math.SmallestNonzeroFloat64, math.Inf(-1), float64(i+seed) / 3}[(i+seed)%6]
+		case Date:
+			return day(i)
+		default:
+			return micro(i)
+		}
+	}
+
+	var columns []Column
+	for _, kind := range []Kind{String, Int64, Double, Date, Timestamp} {
+		required := Column{Name: "required_" + kind.name(), Kind: kind}
+		optional := Column{Name: "optional_" + kind.name(), Kind: kind, Optional: true}
+		for i := 0; i < rows; i++ {
+			required.Values = append(required.Values, value(kind, i))
+			if i == 0 || i == rows-1 || (i+seed)%7 < 3 {
+				optional.Values = append(optional.Values, nil)
+			} else {
+				optional.Values = append(optional.Values, value(kind, i))
+			}
+		}
+		columns = append(columns, required, optional)
+	}
+	return columns
+}
+
+func (k Kind) name() string {
+	return []string{"string", "int64", "double", "date", "timestamp"}[k]
+}
+
+// compareColumns reports every column, and every value, that differs
+func compareColumns(t *testing.T, got, want []Column) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("read %d columns, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Name != w.Name || g.Kind != w.Kind || g.Optional != w.Optional {
+			t.Errorf("column %d is %s (kind %d, optional %v), want %s (kind %d, optional %v)",
+				i, g.Name, g.Kind, g.Optional, w.Name, w.Kind, w.Optional)
+			continue
+		}
+		if len(g.Values) != len(w.Values) {
+			t.Errorf("column %s has %d values, want %d", w.Name, len(g.Values), len(w.Values))
+			continue
+		}
+		for r := range w.Values {
+			if !reflect.DeepEqual(g.Values[r], w.Values[r]) {
+				t.Errorf("column %s row %d = %#v, want %#v", w.Name, r, g.Values[r], w.Values[r])
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, rows := range []int{1, 2, 9, 1000} {
+		columns := allKinds(rows, 0)
+		var buf bytes.Buffer
+		if err := Write(&buf, columns); err != nil {
+			t.Fatalf("%d rows: Write: %v", rows, err)
+		}
+		got, err := Read(buf.Bytes())
+		if err != nil {
+			t.Fatalf("%d rows: Read: %v", rows, err)
+		}
+		compareColumns(t, got, columns)
+	}
+}
+
+func TestRoundTripRowGroups(t *testing.T) {
+	groups := [][]Column{allKinds(5, 0), allKinds(0, 0), allKinds(17, 5), allKinds(1, 11)}
+
+	var buf bytes.Buffer
+	pw, err := NewWriter(&buf, groups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := allKinds(0, 0)
+	for _, group := range groups {
+		if err := pw.WriteRowGroup(group); err != nil {
+			t.Fatal(err)
+		}
+		for i := range want {
+			want[i].Values = append(want[i].Values, group[i].Values...)
+		}
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareColumns(t, got, want)
+}
+
+func TestRoundTripEmpty(t *testing.T) {
+	columns := allKinds(0, 0)
+	var buf bytes.Buffer
+	if err := Write(&buf, columns); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareColumns(t, got, columns)
+
+	// A file closed before any row group is written has the schema alone
+	buf.Reset()
+	pw, err := NewWriter(&buf, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = Read(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	compareColumns(t, got, columns)
+}
+
+func TestRoundTripAllNull(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Kind: Int64, Values: []interface{}{int64(1), int64(2), int64(3)}},
+		{Name: "open", Kind: Double, Optional: true, Values: []interface{}{nil, nil, nil}},
+		{Name: "note", Kind: String, Optional: true, Values: []interface{}{nil, nil, nil}},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, columns); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareColumns(t, got, columns)
+}
+
+// recordingWriter counts what reaches it, standing in for an object store
+type recordingWriter struct{ n int }
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
+
+func TestWriterStreamsRowGroups(t *testing.T) {
+	w := &recordingWriter{}
+	pw, err := NewWriter(w, allKinds(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.n != len(magic) {
+		t.Fatalf("NewWriter wrote %d bytes, want %d", w.n, len(magic))
+	}
+
+	before := w.n
+	if err := pw.WriteRowGroup(allKinds(100, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if w.n <= before {
+		t.Fatal("WriteRowGroup held the row group back instead of writing it")
+	}
+	before = w.n
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.n <= before {
+		t.Fatal("Close wrote no footer")
+	}
+}
+
+func TestWriteRejects(t *testing.T) {
+	cases := map[string][]Column{
+		"no columns":            nil,
+		"null in required":      {{Name: "a", Kind: Int64, Values: []interface{}{int64(1), nil}}},
+		"int instead of int64":  {{Name: "a", Kind: Int64, Values: []interface{}{1}}},
+		"string in date column": {{Name: "a", Kind: Date, Values: []interface{}{"2024-01-02"}}},
+		"uneven columns": {
+			{Name: "a", Kind: Int64, Values: []interface{}{int64(1)}},
+			{Name: "b", Kind: Int64, Values: []interface{}{int64(1), int64(2)}},
+		},
+	}
+	for name, columns := range cases {
+		if err := Write(&bytes.Buffer{}, columns); err == nil {
+			t.Errorf("%s: Write succeeded", name)
+		}
+	}
+
+	pw, err := NewWriter(&bytes.Buffer{}, []Column{{Name: "a", Kind: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mismatched := map[string][]Column{
+		"renamed":      {{Name: "b", Kind: Int64}},
+		"retyped":      {{Name: "a", Kind: Double}},
+		"made nulls":   {{Name: "a", Kind: Int64, Optional: true}},
+		"extra column": {{Name: "a", Kind: Int64}, {Name: "b", Kind: Int64}},
+	}
+	for name, columns := range mismatched {
+		if err := pw.WriteRowGroup(columns); err == nil {
+			t.Errorf("%s: WriteRowGroup succeeded", name)
+		}
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, allKinds(10, 0)); err != nil {
+		t.Fatal(err)
+	}
+	file := buf.Bytes()
+
+	cases := map[string][]byte{
+		"empty":         nil,
+		"not parquet":   []byte("date,close\n2024-01-02,100\n"),
+		"no footer":     file[:len(file)-8],
+		"footer cut":    append(append([]byte{}, file[:4]...), file[len(file)-12:]...),
+		"footer length": append(append([]byte{}, file[:len(file)-8]...), 0xff, 0xff, 0xff, 0x7f, 'P', 'A', 'R', '1'),
+	}
+	for name, data := range cases {
+		if _, err := Read(data); err == nil {
+			t.Errorf("%s: Read succeeded", name)
+		}
+	}
+}
+
+// Other writers, parquet-mr and Arrow among them, encode definition levels as
+// bit-packed runs as well as RLE ones
+func TestDecodeLevels(t *testing.T) {
+	levels := []byte{
+		0x03, 0b10110101, // one bit-packed group of eight levels, least significant bit first
+		0x06, 0x01, // an RLE run of three present values
+		0x04, 0x00, // an RLE run of two nulls
+	}
+	want := []bool{true, false, true, false, true, true, false, true, true, true, true, false, false}
+
+	got := make([]bool, len(want))
+	if err := decodeLevels(levels, got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeLevels = %v, want %v", got, want)
+	}
+
+	if err := decodeLevels([]byte{0x05, 0xff}, make([]bool, 16)); !errors.Is(err, errMalformed) {
+		t.Errorf("decodeLevels with a missing bit-packed group = %v, want %v", err, errMalformed)
+	}
+}
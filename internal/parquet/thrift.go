@@ -0,0 +1,304 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Thrift compact protocol types, as they appear in field and list headers
+const (
+	ctBoolTrue  = 1
+	ctBoolFalse = 2
+	ctByte      = 3
+	ctI16       = 4
+	ctI32       = 5
+	ctI64       = 6
+	ctDouble    = 7
+	ctBinary    = 8
+	ctList      = 9
+	ctSet       = 10
+	ctMap       = 11
+	ctStruct    = 12
+)
+
+// maxDepth bounds how deeply structs may nest in metadata being read
+const maxDepth = 16
+
+var errMalformed = errors.New("malformed thrift data")
+
+// thriftWriter encodes the Parquet metadata structs in the Thrift compact protocol.
+// Fields are written one at a time; a struct is closed with endStruct.
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // ID of the previous field of each open struct
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{last: []int16{0}}
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, ctI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, ctI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) bool(id int16, v bool) {
+	if v {
+		t.field(id, ctBoolTrue)
+	} else {
+		t.field(id, ctBoolFalse)
+	}
+}
+
+func (t *thriftWriter) binary(id int16, v string) {
+	t.field(id, ctBinary)
+	t.varint(uint64(len(v)))
+	t.buf.WriteString(v)
+}
+
+// beginStruct opens a struct-valued field; 0 opens a struct that is a list element
+func (t *thriftWriter) beginStruct(id int16) {
+	if id != 0 {
+		t.field(id, ctStruct)
+	}
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf.WriteByte(0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+// emptyStruct writes a struct-valued field with no fields of its own
+func (t *thriftWriter) emptyStruct(id int16) {
+	t.beginStruct(id)
+	t.endStruct()
+}
+
+// beginList writes the header of a list field of n elements of type elem; the
+// elements follow
+func (t *thriftWriter) beginList(id int16, elem byte, n int) {
+	t.field(id, ctList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elem)
+	} else {
+		t.buf.WriteByte(0xf0 | elem)
+		t.varint(uint64(n))
+	}
+}
+
+func (t *thriftWriter) i32Elem(v int32) {
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) binaryElem(v string) {
+	t.varint(uint64(len(v)))
+	t.buf.WriteString(v)
+}
+
+// bytes returns the encoded top-level struct, closing it
+func (t *thriftWriter) bytes() []byte {
+	t.buf.WriteByte(0)
+	return t.buf.Bytes()
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// tstruct is a decoded struct: field values by ID. Integers decode to int64, binary
+// to []byte, lists and sets to []interface{}, structs to tstruct; maps are skipped.
+type tstruct map[int16]interface{}
+
+func (s tstruct) int(id int16) (int64, bool) {
+	v, ok := s[id].(int64)
+	return v, ok
+}
+
+func (s tstruct) string(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+func (s tstruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+func (s tstruct) sub(id int16) tstruct {
+	v, _ := s[id].(tstruct)
+	return v
+}
+
+// thriftReader decodes Thrift compact structs from a buffer
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.b) {
+		return 0, errMalformed
+	}
+	c := r.b[r.pos]
+	r.pos++
+	return c, nil
+}
+
+func (r *thriftReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	if n <= 0 {
+		return 0, errMalformed
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) readStruct(depth int) (tstruct, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: nested too deeply", errMalformed)
+	}
+	s := tstruct{}
+	var last int16
+	for {
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return s, nil
+		}
+		typ := header & 0x0f
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(unzigzag(v))
+		}
+		last = id
+
+		var value interface{}
+		switch typ {
+		case ctBoolTrue:
+			value = true
+		case ctBoolFalse:
+			value = false
+		default:
+			if value, err = r.readValue(typ, depth); err != nil {
+				return nil, err
+			}
+		}
+		s[id] = value
+	}
+}
+
+func (r *thriftReader) readValue(typ byte, depth int) (interface{}, error) {
+	switch typ {
+	case ctBoolTrue, ctBoolFalse:
+		// Only list elements get here; each is a byte of its own
+		c, err := r.byte()
+		return c == ctBoolTrue, err
+	case ctByte:
+		c, err := r.byte()
+		return int64(int8(c)), err
+	case ctI16, ctI32, ctI64:
+		v, err := r.varint()
+		return unzigzag(v), err
+	case ctDouble:
+		if r.pos+8 > len(r.b) {
+			return nil, errMalformed
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.b[r.pos:]))
+		r.pos += 8
+		return v, nil
+	case ctBinary:
+		n, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.b)-r.pos) {
+			return nil, errMalformed
+		}
+		v := r.b[r.pos : r.pos+int(n)]
+		r.pos += int(n)
+		return v, nil
+	case ctList, ctSet:
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		n, elem := uint64(header>>4), header&0x0f
+		if n == 15 {
+			if n, err = r.varint(); err != nil {
+				return nil, err
+			}
+		}
+		if n > uint64(len(r.b)-r.pos) {
+			return nil, errMalformed
+		}
+		list := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			v, err := r.readValue(elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case ctMap:
+		n, err := r.varint()
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		if n > uint64(len(r.b)-r.pos) {
+			return nil, errMalformed
+		}
+		types, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < n; i++ {
+			if _, err := r.readValue(types>>4, depth+1); err != nil {
+				return nil, err
+			}
+			if _, err := r.readValue(types&0x0f, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case ctStruct:
+		return r.readStruct(depth + 1)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %d", errMalformed, typ)
+	}
+}
@@ -0,0 +1,257 @@
+package provisioning
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// File declares the settings of an organization's members, in the shape of the
+// organization export:
+//
+//	organization: acme
+//	members:
+//	  - email: analyst@acme.example
+//	    watchlist: [BBCA.JK, TLKM.JK]
+//	    default_source: yahoo
+type File struct {
+	Organization string                  `yaml:"organization"`
+	Members      []models.MemberSettings `yaml:"members"`
+}
+
+// Read decodes a provisioning file, refusing unknown fields
+func Read(data []byte) (*File, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var file File
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid provisioning file: %w", err)
+	}
+	if strings.TrimSpace(file.Organization) == "" {
+		return nil, errors.New("organization is required")
+	}
+	seen := make(map[string]bool, len(file.Members))
+	for i, m := range file.Members {
+		email := strings.ToLower(strings.TrimSpace(m.Email))
+		switch {
+		case !strings.Contains(email, "@"):
+			return nil, fmt.Errorf("members[%d]: email is required", i)
+		case seen[email]:
+			return nil, fmt.Errorf("members[%d]: %s is listed twice", i, m.Email)
+		case m.DefaultLimit < 0 || m.DefaultLimit > 1000:
+			return nil, fmt.Errorf("members[%d]: default_limit must be between 1 and 1000", i)
+		case m.DefaultWindow < 0 || m.DefaultWindow > 3650:
+			return nil, fmt.Errorf("members[%d]: default_window_days must be between 1 and 3650", i)
+		}
+		seen[email] = true
+	}
+	return &file, nil
+}
+
+// Provisioner applies the files of a directory at startup and reports how the stored
+// settings have drifted from them since. Drift is reported, not corrected; it is
+// undone by the next restart.
+type Provisioner struct {
+	dir   string // empty turns provisioning off
+	users *services.UserService
+
+	mu    sync.Mutex
+	files []provisioned
+
+	logger *zap.Logger
+}
+
+// provisioned is a file as it was applied
+type provisioned struct {
+	file   *File
+	status models.ProvisionedFile
+}
+
+func New(dir string, users *services.UserService) *Provisioner {
+	return &Provisioner{
+		dir:    dir,
+		users:  users,
+		logger: logger.With(zap.String("component", "provisioning")),
+	}
+}
+
+// Apply reads every .yaml and .yml file of the directory, in name order, and applies
+// each in its own transaction. A file that cannot be read or applied is recorded with
+// its error and skipped; a missing directory provisions nothing.
+func (p *Provisioner) Apply(ctx context.Context) error {
+	if p.dir == "" {
+		return nil
+	}
+	paths, err := p.paths()
+	if err != nil {
+		return err
+	}
+
+	files := make([]provisioned, 0, len(paths))
+	owners := make(map[string]string) // organization -> file that provisioned it
+	for _, path := range paths {
+		applied := provisioned{status: models.ProvisionedFile{Path: path, Unmatched: []string{}, Drift: []models.MemberDrift{}}}
+		if err := p.apply(ctx, &applied, owners); err != nil {
+			applied.status.Error = err.Error()
+			p.logger.Error("Failed to apply provisioning file", zap.String("path", path), zap.Error(err))
+		}
+		files = append(files, applied)
+	}
+
+	p.mu.Lock()
+	p.files = files
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *Provisioner) apply(ctx context.Context, applied *provisioned, owners map[string]string) error {
+	data, err := os.ReadFile(filepath.Join(p.dir, applied.status.Path))
+	if err != nil {
+		return fmt.Errorf("failed to read: %w", err)
+	}
+	applied.status.Checksum = checksum(data)
+
+	file, err := Read(data)
+	if err != nil {
+		return err
+	}
+	applied.status.Organization = file.Organization
+	applied.status.Members = len(file.Members)
+	if owner, ok := owners[file.Organization]; ok {
+		return fmt.Errorf("organization %s is already provisioned by %s", file.Organization, owner)
+	}
+	owners[file.Organization] = applied.status.Path
+
+	result, err := p.users.ImportOrganization(ctx, file.Organization, file.Members)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	applied.file = file
+	applied.status.AppliedAt = &now
+	applied.status.Updated = result.Updated
+	applied.status.Unmatched = result.Unmatched
+
+	p.logger.Info("Provisioning file applied",
+		zap.String("path", applied.status.Path),
+		zap.String("organization", file.Organization),
+		zap.Int("updated", result.Updated),
+		zap.Int("unmatched", len(result.Unmatched)),
+	)
+	return nil
+}
+
+// paths lists the provisioning files relative to the directory, sorted
+func (p *Provisioner) paths() ([]string, error) {
+	entries, err := os.ReadDir(p.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		p.logger.Debug("Provisioning directory not found", zap.String("dir", p.dir))
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provisioning directory: %w", err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		paths = append(paths, entry.Name())
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Status reports each applied file, whether it has changed on disk since, and which
+// members' settings no longer match it
+func (p *Provisioner) Status(ctx context.Context) (*models.ProvisioningStatus, error) {
+	p.mu.Lock()
+	files := slices.Clone(p.files)
+	p.mu.Unlock()
+
+	status := &models.ProvisioningStatus{Dir: p.dir, Files: make([]models.ProvisionedFile, 0, len(files))}
+	for _, applied := range files {
+		file := applied.status
+		if data, err := os.ReadFile(filepath.Join(p.dir, file.Path)); err != nil || checksum(data) != file.Checksum {
+			file.Changed = true
+		}
+		if applied.file != nil {
+			drift, err := p.drift(ctx, applied.file)
+			if err != nil {
+				return nil, err
+			}
+			file.Drift = drift
+			status.Drifted += len(drift)
+		}
+		status.Files = append(status.Files, file)
+	}
+	return status, nil
+}
+
+// drift compares the settings a file declares with the stored ones. As on import,
+// only the settings a member entry sets are compared; members not signed in are left
+// to Unmatched.
+func (p *Provisioner) drift(ctx context.Context, file *File) ([]models.MemberDrift, error) {
+	export, err := p.users.ExportOrganization(ctx, file.Organization)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]models.MemberSettings, len(export.Members))
+	for _, m := range export.Members {
+		stored[strings.ToLower(m.Email)] = m
+	}
+
+	drift := []models.MemberDrift{}
+	for _, want := range file.Members {
+		got, ok := stored[strings.ToLower(strings.TrimSpace(want.Email))]
+		if !ok {
+			continue
+		}
+		var fields []string
+		if want.DefaultSource != "" && want.DefaultSource != got.DefaultSource {
+			fields = append(fields, "default_source")
+		}
+		if want.DefaultLimit != 0 && want.DefaultLimit != got.DefaultLimit {
+			fields = append(fields, "default_limit")
+		}
+		if want.DefaultWindow != 0 && want.DefaultWindow != got.DefaultWindow {
+			fields = append(fields, "default_window_days")
+		}
+		if want.Watchlist != nil && !slices.Equal(want.Watchlist, got.Watchlist) {
+			fields = append(fields, "watchlist")
+		}
+		if want.SelectedSymbols != nil && !slices.Equal(want.SelectedSymbols, got.SelectedSymbols) {
+			fields = append(fields, "selected_symbols")
+		}
+		if len(fields) > 0 {
+			drift = append(drift, models.MemberDrift{Email: want.Email, Fields: fields})
+		}
+	}
+	return drift, nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
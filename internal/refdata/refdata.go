@@ -0,0 +1,361 @@
+package refdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+
+	"github.com/jackc/pgx/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the bundle format written by Export and read by Import
+const Version = 1
+
+// errDryRun rolls back an import that was only checked
+var errDryRun = errors.New("dry run")
+
+// Bundle is the reference data of one environment. Sections are sorted by key and
+// carry no timestamps, so exporting an unchanged database gives identical bytes and
+// two environments' bundles can be diffed line by line.
+type Bundle struct {
+	Version   int        `yaml:"version"`
+	Schema    int        `yaml:"schema"` // latest migration applied where it was exported
+	Exchanges []Exchange `yaml:"exchanges"`
+	Sources   []Source   `yaml:"sources"`
+	Symbols   []Symbol   `yaml:"symbols"`
+}
+
+// Exchange is a venue's session, trading week and holiday calendar
+type Exchange struct {
+	Code        string    `yaml:"code"`
+	Name        string    `yaml:"name"`
+	Timezone    string    `yaml:"timezone"`
+	PreOpensAt  string    `yaml:"pre_opens_at,omitempty"` // HH:MM local
+	OpensAt     string    `yaml:"opens_at"`
+	ClosesAt    string    `yaml:"closes_at"`
+	TradingDays []int     `yaml:"trading_days,flow"`
+	Currency    string    `yaml:"currency,omitempty"`
+	LotSize     int       `yaml:"lot_size"`
+	AssetClass  string    `yaml:"asset_class"`
+	Holidays    []Holiday `yaml:"holidays,omitempty"`
+}
+
+// Holiday is a full-day closure
+type Holiday struct {
+	Date string `yaml:"date"` // YYYY-MM-DD
+	Name string `yaml:"name"`
+}
+
+// Source is a data provider's licensing, merge priority and anomaly policy
+type Source struct {
+	Name                  string `yaml:"name"`
+	DisplayName           string `yaml:"display_name"`
+	Attribution           string `yaml:"attribution,omitempty"`
+	License               string `yaml:"license,omitempty"`
+	LicenseURL            string `yaml:"license_url,omitempty"`
+	RedistributionAllowed bool   `yaml:"redistribution_allowed"`
+	Priority              int    `yaml:"priority"`
+	AnomalyPolicy         string `yaml:"anomaly_policy"`
+	IngestWindowMinutes   *int   `yaml:"ingest_window_minutes,omitempty"`
+}
+
+// Symbol is a listing's reference data
+type Symbol struct {
+	Exchange string `yaml:"exchange"`
+	Symbol   string `yaml:"symbol"`
+	Name     string `yaml:"name,omitempty"`
+	Sector   string `yaml:"sector,omitempty"`
+	Currency string `yaml:"currency,omitempty"`
+	LotSize  int    `yaml:"lot_size"`
+}
+
+// Counts is how an import changed one section
+type Counts struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+	Deleted   int
+}
+
+// Result is how an import changed each section
+type Result struct {
+	Exchanges Counts
+	Holidays  Counts
+	Sources   Counts
+	Symbols   Counts
+}
+
+// ImportOptions controls what an import may change
+type ImportOptions struct {
+	DryRun bool // report the changes, then roll them back
+	Prune  bool // delete holidays of bundled exchanges that the bundle does not list
+}
+
+// Export reads the reference data of db into a bundle
+func Export(ctx context.Context, db *database.DB) (*Bundle, error) {
+	schema, err := schemaVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &Bundle{Version: Version, Schema: schema}
+
+	rows, err := db.Query(ctx, `
+		SELECT code, name, timezone, COALESCE(to_char(pre_opens_at, 'HH24:MI'), ''), to_char(opens_at, 'HH24:MI'),
+			to_char(closes_at, 'HH24:MI'), trading_days, COALESCE(currency, ''), lot_size, asset_class
+		FROM exchanges
+		ORDER BY code
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchanges: %w", err)
+	}
+	bundle.Exchanges, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Exchange, error) {
+		var e Exchange
+		err := row.Scan(&e.Code, &e.Name, &e.Timezone, &e.PreOpensAt, &e.OpensAt, &e.ClosesAt, &e.TradingDays,
+			&e.Currency, &e.LotSize, &e.AssetClass)
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchanges: %w", err)
+	}
+
+	rows, err = db.Query(ctx, `
+		SELECT exchange, to_char(date, 'YYYY-MM-DD'), name FROM exchange_holidays ORDER BY exchange, date
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read holidays: %w", err)
+	}
+	holidays := map[string][]Holiday{}
+	for rows.Next() {
+		var exchange string
+		var h Holiday
+		if err := rows.Scan(&exchange, &h.Date, &h.Name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read holidays: %w", err)
+		}
+		holidays[exchange] = append(holidays[exchange], h)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read holidays: %w", err)
+	}
+	for i := range bundle.Exchanges {
+		bundle.Exchanges[i].Holidays = holidays[bundle.Exchanges[i].Code]
+	}
+
+	rows, err = db.Query(ctx, `
+		SELECT name, display_name, attribution, license, license_url, redistribution_allowed, priority, anomaly_policy,
+			ingest_window_minutes
+		FROM sources
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sources: %w", err)
+	}
+	bundle.Sources, err = pgx.CollectRows(rows, pgx.RowToStructByPos[Source])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sources: %w", err)
+	}
+
+	rows, err = db.Query(ctx, `
+		SELECT exchange, symbol, name, sector, currency, lot_size FROM symbols ORDER BY exchange, symbol
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbols: %w", err)
+	}
+	bundle.Symbols, err = pgx.CollectRows(rows, pgx.RowToStructByPos[Symbol])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbols: %w", err)
+	}
+
+	return bundle, nil
+}
+
+// Write encodes a bundle as YAML
+func Write(w io.Writer, bundle *Bundle) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(bundle); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Read decodes a YAML bundle, refusing other format versions and unknown fields
+func Read(r io.Reader) (*Bundle, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	var bundle Bundle
+	if err := dec.Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if bundle.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %d (expected %d)", bundle.Version, Version)
+	}
+	return &bundle, nil
+}
+
+// Import upserts a bundle into db in one transaction. Rows the bundle does not list
+// are kept, except holidays of bundled exchanges with opts.Prune. A bundle exported
+// from a newer schema is refused until db is migrated.
+func Import(ctx context.Context, db *database.DB, bundle *Bundle, opts ImportOptions) (*Result, error) {
+	schema, err := schemaVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if bundle.Schema > schema {
+		return nil, fmt.Errorf("bundle was exported at schema %d but the database is at %d; migrate first", bundle.Schema, schema)
+	}
+
+	var result Result
+	err = db.Transaction(ctx, func(tx pgx.Tx) error {
+		result = Result{}
+		if err := importExchanges(ctx, tx, bundle.Exchanges, opts, &result); err != nil {
+			return err
+		}
+		if err := importSources(ctx, tx, bundle.Sources, &result.Sources); err != nil {
+			return err
+		}
+		if err := importSymbols(ctx, tx, bundle.Symbols, &result.Symbols); err != nil {
+			return err
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// count records the outcome of an upsert that returns (xmax = 0) only for rows it
+// inserted or changed
+func count(row pgx.Row, c *Counts) error {
+	var inserted bool
+	if err := row.Scan(&inserted); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.Unchanged++
+			return nil
+		}
+		return err
+	}
+	if inserted {
+		c.Inserted++
+	} else {
+		c.Updated++
+	}
+	return nil
+}
+
+func importExchanges(ctx context.Context, tx pgx.Tx, exchanges []Exchange, opts ImportOptions, result *Result) error {
+	for _, e := range exchanges {
+		row := tx.QueryRow(ctx, `
+			INSERT INTO exchanges (code, name, timezone, pre_opens_at, opens_at, closes_at, trading_days, currency,
+				lot_size, asset_class)
+			VALUES ($1, $2, $3, NULLIF($4, '')::time, $5::time, $6::time, $7, NULLIF($8, ''), $9, $10)
+			ON CONFLICT (code) DO UPDATE SET
+				name = EXCLUDED.name, timezone = EXCLUDED.timezone, pre_opens_at = EXCLUDED.pre_opens_at,
+				opens_at = EXCLUDED.opens_at, closes_at = EXCLUDED.closes_at, trading_days = EXCLUDED.trading_days,
+				currency = EXCLUDED.currency, lot_size = EXCLUDED.lot_size, asset_class = EXCLUDED.asset_class
+			WHERE (exchanges.name, exchanges.timezone, exchanges.pre_opens_at, exchanges.opens_at, exchanges.closes_at,
+				exchanges.trading_days, exchanges.currency, exchanges.lot_size, exchanges.asset_class)
+				IS DISTINCT FROM (EXCLUDED.name, EXCLUDED.timezone, EXCLUDED.pre_opens_at, EXCLUDED.opens_at,
+				EXCLUDED.closes_at, EXCLUDED.trading_days, EXCLUDED.currency, EXCLUDED.lot_size, EXCLUDED.asset_class)
+			RETURNING (xmax = 0)
+		`, e.Code, e.Name, e.Timezone, e.PreOpensAt, e.OpensAt, e.ClosesAt, e.TradingDays, e.Currency, e.LotSize, e.AssetClass)
+		if err := count(row, &result.Exchanges); err != nil {
+			return fmt.Errorf("failed to import exchange %s: %w", e.Code, err)
+		}
+
+		dates := make([]string, 0, len(e.Holidays))
+		for _, h := range e.Holidays {
+			row := tx.QueryRow(ctx, `
+				INSERT INTO exchange_holidays (exchange, date, name) VALUES ($1, $2::date, $3)
+				ON CONFLICT (exchange, date) DO UPDATE SET name = EXCLUDED.name
+				WHERE exchange_holidays.name IS DISTINCT FROM EXCLUDED.name
+				RETURNING (xmax = 0)
+			`, e.Code, h.Date, h.Name)
+			if err := count(row, &result.Holidays); err != nil {
+				return fmt.Errorf("failed to import holiday %s %s: %w", e.Code, h.Date, err)
+			}
+			dates = append(dates, h.Date)
+		}
+
+		if opts.Prune {
+			tag, err := tx.Exec(ctx, `
+				DELETE FROM exchange_holidays WHERE exchange = $1 AND NOT (date = ANY($2::date[]))
+			`, e.Code, dates)
+			if err != nil {
+				return fmt.Errorf("failed to prune holidays of %s: %w", e.Code, err)
+			}
+			result.Holidays.Deleted += int(tag.RowsAffected())
+		}
+	}
+	return nil
+}
+
+func importSources(ctx context.Context, tx pgx.Tx, sources []Source, c *Counts) error {
+	for _, s := range sources {
+		row := tx.QueryRow(ctx, `
+			INSERT INTO sources (name, display_name, attribution, license, license_url, redistribution_allowed, priority,
+				anomaly_policy, ingest_window_minutes)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (name) DO UPDATE SET
+				display_name = EXCLUDED.display_name, attribution = EXCLUDED.attribution, license = EXCLUDED.license,
+				license_url = EXCLUDED.license_url, redistribution_allowed = EXCLUDED.redistribution_allowed,
+				priority = EXCLUDED.priority, anomaly_policy = EXCLUDED.anomaly_policy,
+				ingest_window_minutes = EXCLUDED.ingest_window_minutes
+			WHERE (sources.display_name, sources.attribution, sources.license, sources.license_url,
+				sources.redistribution_allowed, sources.priority, sources.anomaly_policy, sources.ingest_window_minutes)
+				IS DISTINCT FROM (EXCLUDED.display_name, EXCLUDED.attribution, EXCLUDED.license, EXCLUDED.license_url,
+				EXCLUDED.redistribution_allowed, EXCLUDED.priority, EXCLUDED.anomaly_policy, EXCLUDED.ingest_window_minutes)
+			RETURNING (xmax = 0)
+		`, s.Name, s.DisplayName, s.Attribution, s.License, s.LicenseURL, s.RedistributionAllowed, s.Priority, s.AnomalyPolicy,
+			s.IngestWindowMinutes)
+		if err := count(row, c); err != nil {
+			return fmt.Errorf("failed to import source %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+func importSymbols(ctx context.Context, tx pgx.Tx, symbols []Symbol, c *Counts) error {
+	for _, s := range symbols {
+		row := tx.QueryRow(ctx, `
+			INSERT INTO symbols (exchange, symbol, name, sector, currency, lot_size)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (exchange, symbol) DO UPDATE SET
+				name = EXCLUDED.name, sector = EXCLUDED.sector, currency = EXCLUDED.currency, lot_size = EXCLUDED.lot_size
+			WHERE (symbols.name, symbols.sector, symbols.currency, symbols.lot_size)
+				IS DISTINCT FROM (EXCLUDED.name, EXCLUDED.sector, EXCLUDED.currency, EXCLUDED.lot_size)
+			RETURNING (xmax = 0)
+		`, s.Exchange, s.Symbol, s.Name, s.Sector, s.Currency, s.LotSize)
+		if err := count(row, c); err != nil {
+			return fmt.Errorf("failed to import symbol %s:%s: %w", s.Exchange, s.Symbol, err)
+		}
+	}
+	return nil
+}
+
+// schemaVersion is the latest migration applied to db
+func schemaVersion(ctx context.Context, db *database.DB) (int, error) {
+	states, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migration status: %w", err)
+	}
+	versions := []int{0}
+	for _, s := range states {
+		if s.AppliedAt != nil {
+			versions = append(versions, s.Version)
+		}
+	}
+	sort.Ints(versions)
+	return versions[len(versions)-1], nil
+}
@@ -0,0 +1,142 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ErrArchivePending is returned when an archive run is requested while another is
+// waiting to start or running
+var ErrArchivePending = errors.New("an archive run is already pending")
+
+// Archiver moves candles older than a number of whole years to the archive store,
+// every interval and on request
+type Archiver struct {
+	market     *services.MarketService
+	afterYears int           // candles dated before January 1st this many years ago are archived; 0 turns it off
+	interval   time.Duration // how often archiving runs
+	runs       chan struct{}
+
+	mu      sync.Mutex
+	running bool
+	lastRun *models.ArchiveRun
+
+	logger *zap.Logger
+}
+
+// defaultArchiveInterval is used when no positive interval is configured
+const defaultArchiveInterval = 24 * time.Hour
+
+func NewArchiver(market *services.MarketService, afterYears int, interval time.Duration) *Archiver {
+	if interval <= 0 {
+		interval = defaultArchiveInterval
+	}
+	return &Archiver{
+		market:     market,
+		afterYears: afterYears,
+		interval:   interval,
+		runs:       make(chan struct{}, 1),
+		logger:     logger.With(zap.String("component", "archiver")),
+	}
+}
+
+// Enabled reports whether candles are archived
+func (a *Archiver) Enabled() bool {
+	return a.afterYears > 0 && a.market.ArchiveEnabled()
+}
+
+// Before returns the cutoff at now: candles dated earlier are archived
+func (a *Archiver) Before(now time.Time) time.Time {
+	return time.Date(now.Year()-a.afterYears, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Start archives every interval, and on each request, until ctx is cancelled. It
+// returns at once when archiving is off.
+func (a *Archiver) Start(ctx context.Context) {
+	if !a.Enabled() {
+		return
+	}
+	a.logger.Info("Market data archiving enabled",
+		zap.Int("after_years", a.afterYears),
+		zap.Duration("interval", a.interval),
+	)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-a.runs:
+		}
+		a.run(ctx)
+	}
+}
+
+// Trigger queues an archive run. It returns services.ErrArchiveDisabled when
+// archiving is off and ErrArchivePending while another run is pending or running.
+func (a *Archiver) Trigger() error {
+	if !a.Enabled() {
+		return services.ErrArchiveDisabled
+	}
+	a.mu.Lock()
+	running := a.running
+	a.mu.Unlock()
+	if running {
+		return ErrArchivePending
+	}
+	select {
+	case a.runs <- struct{}{}:
+		return nil
+	default:
+		return ErrArchivePending
+	}
+}
+
+// Status reports what the archive holds, the cutoff and the latest run
+func (a *Archiver) Status(ctx context.Context) (*models.ArchiveStatus, error) {
+	status, err := a.market.ArchiveStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.Enabled = a.Enabled()
+	if status.Enabled {
+		status.AfterYears = a.afterYears
+		before := a.Before(time.Now())
+		status.Before = &before
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	status.Running = a.running
+	status.LastRun = a.lastRun
+	return status, nil
+}
+
+func (a *Archiver) run(ctx context.Context) {
+	a.mu.Lock()
+	a.running = true
+	a.mu.Unlock()
+
+	run, err := a.market.Archive(ctx, a.Before(time.Now()))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.running = false
+	if err != nil {
+		if ctx.Err() == nil {
+			a.logger.Error("Failed to archive market data", zap.Error(err))
+		}
+		return
+	}
+	a.lastRun = run
+}
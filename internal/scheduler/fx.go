@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/clients/fxfeed"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// FXFetcher stores the day's reference rates for a set of currency pairs from the
+// configured provider each time its schedule fires
+type FXFetcher struct {
+	feed     *fxfeed.Client
+	fx       *services.FXService
+	schedule *Schedule           // nil when scheduled fetches are off
+	pairs    map[string][]string // quotes fetched per base currency
+	logger   *zap.Logger
+}
+
+// NewFXFetcher fetches pairs written BASE-QUOTE, e.g. USD-IDR
+func NewFXFetcher(feed *fxfeed.Client, fx *services.FXService, schedule *Schedule, pairs []string) (*FXFetcher, error) {
+	byBase := map[string][]string{}
+	for _, pair := range pairs {
+		base, quote, err := services.ParsePair(pair)
+		if err != nil {
+			return nil, err
+		}
+		if base == quote {
+			return nil, fmt.Errorf("%w: %s converts to itself", services.ErrInvalidFXPair, pair)
+		}
+		byBase[base] = append(byBase[base], quote)
+	}
+	return &FXFetcher{
+		feed:     feed,
+		fx:       fx,
+		schedule: schedule,
+		pairs:    byBase,
+		logger:   logger.With(zap.String("component", "fx_fetcher")),
+	}, nil
+}
+
+// Start fetches rates each time the schedule fires until ctx is cancelled. It
+// returns at once when there is no schedule, provider or pair.
+func (f *FXFetcher) Start(ctx context.Context) {
+	if f.schedule == nil || !f.feed.Configured() || len(f.pairs) == 0 {
+		return
+	}
+	f.logger.Info("Scheduled fx rate fetches enabled", zap.String("schedule", f.schedule.String()))
+
+	for {
+		next := f.schedule.Next(time.Now())
+		if next.IsZero() {
+			f.logger.Warn("FX fetch schedule never fires", zap.String("schedule", f.schedule.String()))
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if _, err := f.Run(ctx); err != nil && ctx.Err() == nil {
+				f.logger.Error("Failed to fetch fx rates", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Run fetches and stores the latest rate of every configured pair, returning what
+// was stored. Bases are fetched one after another; a failing base does not stop the
+// rest, and the first failure is returned.
+func (f *FXFetcher) Run(ctx context.Context) ([]models.FXRateInput, error) {
+	if !f.feed.Configured() {
+		return nil, fxfeed.ErrNotConfigured
+	}
+
+	bases := make([]string, 0, len(f.pairs))
+	for base := range f.pairs {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+
+	stored := []models.FXRateInput{}
+	var firstErr error
+	for _, base := range bases {
+		rates, err := f.feed.FetchLatest(ctx, base, f.pairs[base])
+		if err == nil && len(rates) > 0 {
+			_, err = f.fx.Upsert(ctx, rates)
+		}
+		if err != nil {
+			f.logger.Warn("Failed to fetch fx rates", zap.String("base", base), zap.Error(err))
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", base, err)
+			}
+			continue
+		}
+		stored = append(stored, rates...)
+	}
+
+	f.logger.Info("FX rates fetched", zap.Int("stored", len(stored)))
+	return stored, firstErr
+}
@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/metrics"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// IngestMonitor is a dead man's switch over sources: a source with an ingest window
+// that delivers no candles for that long is reported stale once, and recovered once
+// it delivers again. Reports are logged, exported as metrics and published through
+// the outbox.
+type IngestMonitor struct {
+	db       *database.DB
+	outbox   *services.OutboxService
+	interval time.Duration // how often sources are checked; 0 turns the monitor off
+	logger   *zap.Logger
+}
+
+func NewIngestMonitor(db *database.DB, outbox *services.OutboxService, interval time.Duration) *IngestMonitor {
+	return &IngestMonitor{
+		db:       db,
+		outbox:   outbox,
+		interval: interval,
+		logger:   logger.With(zap.String("component", "ingest_monitor")),
+	}
+}
+
+// Start checks sources every interval until ctx is cancelled. It returns at once
+// when the monitor is off.
+func (m *IngestMonitor) Start(ctx context.Context) {
+	if m.interval <= 0 {
+		return
+	}
+	m.logger.Info("Ingest monitor started", zap.Duration("interval", m.interval))
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// monitoredSource is a source with an ingest window and whether it is overdue now
+type monitoredSource struct {
+	name          string
+	windowMinutes int
+	lastIngestAt  *time.Time
+	staleSince    *time.Time
+	overdue       bool
+}
+
+// Check reports every monitored source whose state changed since the last check
+func (m *IngestMonitor) Check(ctx context.Context) {
+	rows, err := m.db.Query(ctx, `
+		SELECT s.name, s.ingest_window_minutes, i.last_ingest_at, i.stale_since,
+			i.last_ingest_at IS NULL
+				OR i.last_ingest_at < CURRENT_TIMESTAMP - make_interval(mins => s.ingest_window_minutes)
+		FROM sources s
+		LEFT JOIN source_ingests i ON i.source = s.name
+		WHERE s.ingest_window_minutes IS NOT NULL
+		ORDER BY s.name
+	`)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.Error("Failed to check source ingests", zap.Error(err))
+		}
+		return
+	}
+	var sources []monitoredSource
+	for rows.Next() {
+		var src monitoredSource
+		if err := rows.Scan(&src.name, &src.windowMinutes, &src.lastIngestAt, &src.staleSince, &src.overdue); err != nil {
+			rows.Close()
+			m.logger.Error("Failed to scan source ingest", zap.Error(err))
+			return
+		}
+		sources = append(sources, src)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		m.logger.Error("Failed to check source ingests", zap.Error(err))
+		return
+	}
+
+	for _, src := range sources {
+		stale := src.staleSince != nil
+		switch {
+		case src.overdue && !stale:
+			if err := m.markStale(ctx, src); err != nil {
+				m.logger.Error("Failed to report stale source", zap.String("source", src.name), zap.Error(err))
+				continue
+			}
+			stale = true
+		case !src.overdue && stale:
+			if err := m.markRecovered(ctx, src); err != nil {
+				m.logger.Error("Failed to report recovered source", zap.String("source", src.name), zap.Error(err))
+				continue
+			}
+			stale = false
+		}
+
+		var last time.Time
+		if src.lastIngestAt != nil {
+			last = *src.lastIngestAt
+		}
+		metrics.ObserveIngest(src.name, last, stale)
+	}
+}
+
+// markStale records when a source went stale and announces it. The conditional
+// update lets only one instance announce it.
+func (m *IngestMonitor) markStale(ctx context.Context, src monitoredSource) error {
+	return m.db.Transaction(ctx, func(tx pgx.Tx) error {
+		var since time.Time
+		err := tx.QueryRow(ctx, `
+			INSERT INTO source_ingests (source, stale_since) VALUES ($1, CURRENT_TIMESTAMP)
+			ON CONFLICT (source) DO UPDATE SET stale_since = EXCLUDED.stale_since
+			WHERE source_ingests.stale_since IS NULL
+			RETURNING stale_since
+		`, src.name).Scan(&since)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to mark stale: %w", err)
+		}
+
+		m.logger.Error("Source has not delivered within its ingest window",
+			zap.String("source", src.name),
+			zap.Int("window_minutes", src.windowMinutes),
+			zap.Timep("last_ingest_at", src.lastIngestAt),
+		)
+		return m.outbox.Enqueue(ctx, tx, models.TopicSourceStale, "source:"+src.name, models.SourceIngestAlert{
+			Source:        src.name,
+			WindowMinutes: src.windowMinutes,
+			LastIngestAt:  src.lastIngestAt,
+			StaleSince:    since,
+		})
+	})
+}
+
+// markRecovered clears a stale source that has delivered again and announces it
+func (m *IngestMonitor) markRecovered(ctx context.Context, src monitoredSource) error {
+	return m.db.Transaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE source_ingests SET stale_since = NULL WHERE source = $1 AND stale_since IS NOT NULL
+		`, src.name)
+		if err != nil {
+			return fmt.Errorf("failed to clear stale: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+
+		m.logger.Info("Source is delivering again",
+			zap.String("source", src.name),
+			zap.Timep("last_ingest_at", src.lastIngestAt),
+		)
+		return m.outbox.Enqueue(ctx, tx, models.TopicSourceRecovered, "source:"+src.name, models.SourceIngestAlert{
+			Source:        src.name,
+			WindowMinutes: src.windowMinutes,
+			LastIngestAt:  src.lastIngestAt,
+			StaleSince:    *src.staleSince,
+		})
+	})
+}
@@ -0,0 +1,396 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/clients/yahoo"
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/services"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	fetchSource = "yahoo"
+	// backoffBase holds a symbol back after its first consecutive failure; each
+	// further failure doubles it up to the configured maximum
+	backoffBase = 15 * time.Minute
+	// heartbeatTimeout bounds a heartbeat ping, so a slow monitor cannot delay fetches
+	heartbeatTimeout = 10 * time.Second
+)
+
+// ErrRefetchPending is returned when a refetch is requested while another is still
+// waiting to start
+var ErrRefetchPending = errors.New("a refetch is already pending")
+
+type refetchRequest struct {
+	symbols []string
+	days    int
+}
+
+// WatchlistOptions tunes the scheduled watchlist fetch
+type WatchlistOptions struct {
+	LookbackDays int           // days of daily candles fetched per symbol
+	MaxBackoff   time.Duration // longest a failing symbol is held back
+	HeartbeatURL string        // pinged after each scheduled fetch that stores data; "" for none
+}
+
+// WatchlistFetcher refreshes daily candles from Yahoo for every equity on any user's
+// watchlist, screening them for anomalies like any other ingest
+type WatchlistFetcher struct {
+	db        *database.DB
+	schedule  *Schedule // nil when scheduled fetches are off
+	yahoo     *yahoo.Client
+	users     *services.UserService
+	anomalies *services.AnomalyService
+	market    *services.MarketService
+	exchanges *services.ExchangeService
+	opts      WatchlistOptions
+	refetches chan refetchRequest
+	logger    *zap.Logger
+}
+
+func NewWatchlistFetcher(db *database.DB, schedule *Schedule, yahooClient *yahoo.Client, users *services.UserService, anomalies *services.AnomalyService, market *services.MarketService, exchanges *services.ExchangeService, opts WatchlistOptions) *WatchlistFetcher {
+	return &WatchlistFetcher{
+		db:        db,
+		schedule:  schedule,
+		yahoo:     yahooClient,
+		users:     users,
+		anomalies: anomalies,
+		market:    market,
+		exchanges: exchanges,
+		opts:      opts,
+		refetches: make(chan refetchRequest, 1),
+		logger:    logger.With(zap.String("component", "watchlist_fetcher")),
+	}
+}
+
+// Schedule returns the fetch schedule, nil when scheduled fetches are off
+func (f *WatchlistFetcher) Schedule() *Schedule {
+	return f.schedule
+}
+
+// Start runs a fetch each time the schedule fires, and each refetch requested,
+// until ctx is cancelled. With scheduled fetches off it only serves refetches.
+func (f *WatchlistFetcher) Start(ctx context.Context) {
+	schedule := f.schedule
+	if schedule != nil {
+		f.logger.Info("Scheduled watchlist fetches enabled", zap.String("schedule", schedule.String()))
+	}
+
+	for {
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if schedule != nil {
+			next := schedule.Next(time.Now())
+			if next.IsZero() {
+				f.logger.Warn("Fetch schedule never fires", zap.String("schedule", schedule.String()))
+				schedule = nil
+				continue
+			}
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-fire:
+			f.Run(ctx)
+		case req := <-f.refetches:
+			if timer != nil {
+				timer.Stop()
+			}
+			f.refetch(ctx, req)
+		}
+	}
+}
+
+// Refetch queues a fetch of symbols, or of every watchlisted equity when empty,
+// covering the last days days, or LookbackDays when zero. Symbols backing off are
+// fetched too. It returns ErrRefetchPending while an earlier refetch is still
+// waiting to start.
+func (f *WatchlistFetcher) Refetch(symbols []string, days int) error {
+	select {
+	case f.refetches <- refetchRequest{symbols: symbols, days: days}:
+		return nil
+	default:
+		return ErrRefetchPending
+	}
+}
+
+// Run fetches every watchlisted equity not backing off whose exchange trades today.
+// A Yahoo rate limit ends the run early without counting against the symbols left.
+func (f *WatchlistFetcher) Run(ctx context.Context) {
+	symbols, err := f.users.WatchedSymbols(ctx, models.AssetClassEquity)
+	if err != nil {
+		f.logger.Error("Failed to list watchlisted symbols", zap.Error(err))
+		return
+	}
+	trading := f.trading(ctx, symbols, time.Now())
+	if len(trading) == 0 {
+		// Nothing trades today, so there was nothing to miss
+		if len(symbols) > 0 {
+			f.heartbeat(ctx)
+		}
+		return
+	}
+	if f.runFetch(ctx, "Scheduled watchlist fetch", trading, f.opts.LookbackDays, true) > 0 {
+		f.heartbeat(ctx)
+	}
+}
+
+// trading keeps the symbols whose exchange has a session on its local date at now.
+// A closed day has no candle to fetch; the lookback picks up anything missed. Symbols
+// of exchanges that are not configured, or whose calendar cannot be read, are kept.
+func (f *WatchlistFetcher) trading(ctx context.Context, symbols []string, now time.Time) []string {
+	open := map[string]bool{}
+	var kept []string
+	closed := map[string]int{}
+	for _, symbol := range symbols {
+		exchange := models.ExchangeForSymbol(symbol)
+		trades, ok := open[exchange]
+		if !ok {
+			var err error
+			trades, err = f.exchanges.TradesOn(ctx, exchange, now)
+			if err != nil {
+				if !errors.Is(err, services.ErrExchangeNotFound) {
+					f.logger.Warn("Failed to check exchange calendar", zap.String("exchange", exchange), zap.Error(err))
+				}
+				trades = true
+			}
+			open[exchange] = trades
+		}
+		if !trades {
+			closed[exchange]++
+			continue
+		}
+		kept = append(kept, symbol)
+	}
+
+	for exchange, n := range closed {
+		f.logger.Info("Exchange closed today, skipping its symbols",
+			zap.String("exchange", exchange),
+			zap.Int("symbols", n),
+		)
+	}
+	return kept
+}
+
+// heartbeat pings HeartbeatURL, so an external dead man's switch (healthchecks.io
+// style) raises an alarm when scheduled fetches stop succeeding
+func (f *WatchlistFetcher) heartbeat(ctx context.Context) {
+	if f.opts.HeartbeatURL == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, heartbeatTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.opts.HeartbeatURL, nil)
+	if err != nil {
+		f.logger.Warn("Invalid heartbeat URL", zap.Error(err))
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		f.logger.Warn("Heartbeat ping failed", zap.Error(err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		f.logger.Warn("Heartbeat ping rejected", zap.Int("status", resp.StatusCode))
+	}
+}
+
+func (f *WatchlistFetcher) refetch(ctx context.Context, req refetchRequest) {
+	symbols := req.symbols
+	if len(symbols) == 0 {
+		var err error
+		symbols, err = f.users.WatchedSymbols(ctx, models.AssetClassEquity)
+		if err != nil {
+			f.logger.Error("Failed to list watchlisted symbols", zap.Error(err))
+			return
+		}
+	}
+	days := req.days
+	if days <= 0 {
+		days = f.opts.LookbackDays
+	}
+	f.runFetch(ctx, "Manual refetch", symbols, days, false)
+}
+
+// runFetch fetches the last days days of each symbol, skipping those backing off
+// when honorBackoff is set, and returns how many symbols it fetched
+func (f *WatchlistFetcher) runFetch(ctx context.Context, name string, symbols []string, days int, honorBackoff bool) int {
+	statuses, err := f.statuses(ctx, symbols)
+	if err != nil {
+		f.logger.Error("Failed to load fetch status", zap.Error(err))
+		return 0
+	}
+
+	var fetched, failed, held int
+	for _, symbol := range symbols {
+		if ctx.Err() != nil {
+			return fetched
+		}
+		status := statuses[symbol]
+		if honorBackoff && status.NextAttemptAt != nil && time.Now().Before(*status.NextAttemptAt) {
+			held++
+			continue
+		}
+
+		rows, fetchErr := f.fetch(ctx, symbol, days)
+		if errors.Is(fetchErr, yahoo.ErrRateLimited) {
+			f.logger.Warn("Yahoo rate limit reached, ending fetch early",
+				zap.String("run", name),
+				zap.String("symbol", symbol),
+				zap.Int("fetched", fetched),
+			)
+			break
+		}
+		if ctx.Err() != nil {
+			return fetched
+		}
+		if err := f.record(ctx, status, rows, fetchErr); err != nil {
+			f.logger.Error("Failed to record fetch status", zap.String("symbol", symbol), zap.Error(err))
+		}
+		if fetchErr != nil {
+			failed++
+			continue
+		}
+		fetched++
+	}
+
+	f.logger.Info(name+" finished",
+		zap.Int("symbols", len(symbols)),
+		zap.Int("days", days),
+		zap.Int("fetched", fetched),
+		zap.Int("failed", failed),
+		zap.Int("backing_off", held),
+	)
+	return fetched
+}
+
+// fetch stores the last days days of a symbol's daily candles, returning how many
+// rows were written
+func (f *WatchlistFetcher) fetch(ctx context.Context, symbol string, days int) (int, error) {
+	endDate := time.Now()
+	data, err := f.yahoo.FetchDaily(ctx, symbol, endDate.AddDate(0, 0, -days), endDate)
+	if err != nil {
+		return 0, err
+	}
+	accepted, _, err := f.anomalies.Screen(ctx, data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to screen: %w", err)
+	}
+	result, err := f.market.BulkCreateWithConflict(ctx, accepted, services.BulkOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to store: %w", err)
+	}
+	return result.Inserted + result.Updated, nil
+}
+
+// record saves the outcome of a fetch, backing the symbol off after a failure
+func (f *WatchlistFetcher) record(ctx context.Context, status models.FetchStatus, rows int, fetchErr error) error {
+	now := time.Now().UTC()
+	if fetchErr == nil {
+		_, err := f.db.Exec(ctx, `
+			INSERT INTO fetch_status (symbol, source, last_attempt_at, last_success_at, last_error, failures, next_attempt_at, rows_stored)
+			VALUES ($1, $2, $3, $3, '', 0, NULL, $4)
+			ON CONFLICT (symbol, source) DO UPDATE SET
+				last_attempt_at = EXCLUDED.last_attempt_at,
+				last_success_at = EXCLUDED.last_success_at,
+				last_error = '',
+				failures = 0,
+				next_attempt_at = NULL,
+				rows_stored = EXCLUDED.rows_stored
+		`, status.Symbol, fetchSource, now, rows)
+		return err
+	}
+
+	failures := status.Failures + 1
+	f.logger.Warn("Watchlist fetch failed",
+		zap.String("symbol", status.Symbol),
+		zap.Int("failures", failures),
+		zap.Error(fetchErr),
+	)
+	_, err := f.db.Exec(ctx, `
+		INSERT INTO fetch_status (symbol, source, last_attempt_at, last_error, failures, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (symbol, source) DO UPDATE SET
+			last_attempt_at = EXCLUDED.last_attempt_at,
+			last_error = EXCLUDED.last_error,
+			failures = EXCLUDED.failures,
+			next_attempt_at = EXCLUDED.next_attempt_at
+	`, status.Symbol, fetchSource, now, fetchErr.Error(), failures, now.Add(f.backoff(failures)))
+	return err
+}
+
+// backoff is how long a symbol is held back after consecutive failures
+func (f *WatchlistFetcher) backoff(failures int) time.Duration {
+	d := backoffBase
+	for i := 1; i < failures && d < f.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if f.opts.MaxBackoff > 0 && d > f.opts.MaxBackoff {
+		d = f.opts.MaxBackoff
+	}
+	return d
+}
+
+// Status returns the fetch status of every watchlisted equity, in ticker order;
+// symbols not fetched yet have no times
+func (f *WatchlistFetcher) Status(ctx context.Context) ([]models.FetchStatus, error) {
+	symbols, err := f.users.WatchedSymbols(ctx, models.AssetClassEquity)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := f.statuses(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.FetchStatus, len(symbols))
+	for i, symbol := range symbols {
+		result[i] = statuses[symbol]
+	}
+	return result, nil
+}
+
+// statuses returns the stored status of each symbol, keyed by symbol, with an empty
+// status for symbols never attempted
+func (f *WatchlistFetcher) statuses(ctx context.Context, symbols []string) (map[string]models.FetchStatus, error) {
+	rows, err := f.db.Query(ctx, `
+		SELECT symbol, source, last_attempt_at, last_success_at, last_error, failures, next_attempt_at, rows_stored
+		FROM fetch_status
+		WHERE symbol = ANY($1) AND source = $2
+	`, symbols, fetchSource)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.FetchStatus])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	statuses := make(map[string]models.FetchStatus, len(symbols))
+	for _, symbol := range symbols {
+		statuses[symbol] = models.FetchStatus{Symbol: symbol, Source: fetchSource}
+	}
+	for _, s := range stored {
+		statuses[s.Symbol] = s
+	}
+	return statuses, nil
+}
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrLinkTokenInvalid = errors.New("link token is invalid or expired")
+	ErrLinkSelf         = errors.New("cannot link an identity to itself")
+	ErrAlreadyLinked    = errors.New("identity is already linked to another profile")
+	ErrLinkNotFound     = errors.New("link not found")
+)
+
+const (
+	linkTokenTTL = 15 * time.Minute
+	// maxPortfolioName is the length of portfolios.name
+	maxPortfolioName = 100
+)
+
+type AccountService struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewAccountService(db *database.DB) *AccountService {
+	return &AccountService{
+		db:     db,
+		logger: logger.With(zap.String("service", "account")),
+	}
+}
+
+// CanonicalID resolves a Kratos identity ID to the profile it is linked to
+func (s *AccountService) CanonicalID(ctx context.Context, identityID string) (string, error) {
+	query := `SELECT canonical_user_id FROM user_links WHERE linked_user_id = $1`
+
+	var canonicalID string
+	err := s.db.QueryRow(ctx, query, identityID).Scan(&canonicalID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return identityID, nil
+		}
+		return identityID, err
+	}
+
+	return canonicalID, nil
+}
+
+// CreateLinkToken issues a single-use token the other identity redeems to link itself to userID
+func (s *AccountService) CreateLinkToken(ctx context.Context, userID string) (*models.LinkToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+	expiresAt := time.Now().Add(linkTokenTTL)
+
+	query := `
+		INSERT INTO account_link_tokens (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+	`
+
+	if _, err := s.db.Exec(ctx, query, hashToken(token), userID, expiresAt); err != nil {
+		s.logger.Error("Failed to create link token",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return &models.LinkToken{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// ListLinks returns the identities linked to a canonical profile
+func (s *AccountService) ListLinks(ctx context.Context, canonicalID string) ([]models.UserLink, error) {
+	query := `
+		SELECT linked_user_id, canonical_user_id, linked_email, created_at
+		FROM user_links
+		WHERE canonical_user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := s.db.Query(ctx, query, canonicalID)
+	if err != nil {
+		s.logger.Error("Failed to list user links",
+			zap.String("user_id", canonicalID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	links, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.UserLink])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return links, nil
+}
+
+// Link redeems a token, linking identityID to the token owner's profile and merging its data
+func (s *AccountService) Link(ctx context.Context, token, identityID, email string) (*models.LinkResult, error) {
+	result := &models.LinkResult{
+		LinkedUserID:    identityID,
+		WatchlistAdded:  []string{},
+		SymbolsAdded:    []string{},
+		PortfoliosAdded: []string{},
+		Conflicts:       []models.MergeConflict{},
+	}
+
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		// Tokens are single use
+		var ownerID string
+		err := tx.QueryRow(ctx, `
+			DELETE FROM account_link_tokens
+			WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP
+			RETURNING user_id
+		`, hashToken(token)).Scan(&ownerID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrLinkTokenInvalid
+			}
+			return err
+		}
+
+		// The owner may itself have been linked since the token was issued
+		canonicalID := ownerID
+		err = tx.QueryRow(ctx, `SELECT canonical_user_id FROM user_links WHERE linked_user_id = $1`, ownerID).Scan(&canonicalID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if canonicalID == identityID {
+			return ErrLinkSelf
+		}
+		result.CanonicalUserID = canonicalID
+
+		var existing string
+		err = tx.QueryRow(ctx, `SELECT canonical_user_id FROM user_links WHERE linked_user_id = $1`, identityID).Scan(&existing)
+		if err == nil {
+			return ErrAlreadyLinked
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		// Identities previously linked to this one follow it to the new profile
+		if _, err := tx.Exec(ctx, `
+			UPDATE user_links SET canonical_user_id = $1 WHERE canonical_user_id = $2
+		`, canonicalID, identityID); err != nil {
+			return fmt.Errorf("failed to re-point links: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO user_links (linked_user_id, canonical_user_id, linked_email)
+			VALUES ($1, $2, $3)
+		`, identityID, canonicalID, email); err != nil {
+			return fmt.Errorf("failed to create link: %w", err)
+		}
+
+		if err := mergePreferences(ctx, tx, canonicalID, identityID, result); err != nil {
+			return fmt.Errorf("failed to merge preferences: %w", err)
+		}
+		if err := mergeFeeSettings(ctx, tx, canonicalID, identityID, result); err != nil {
+			return fmt.Errorf("failed to merge fee settings: %w", err)
+		}
+		if err := mergePortfolios(ctx, tx, canonicalID, identityID, result); err != nil {
+			return fmt.Errorf("failed to merge portfolios: %w", err)
+		}
+		if err := mergeUserRows(ctx, tx, canonicalID, identityID); err != nil {
+			return fmt.Errorf("failed to merge user data: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrLinkTokenInvalid) && !errors.Is(err, ErrLinkSelf) && !errors.Is(err, ErrAlreadyLinked) {
+			s.logger.Error("Failed to link identity",
+				zap.String("identity_id", identityID),
+				zap.Error(err),
+			)
+		}
+		return nil, err
+	}
+
+	s.logger.Info("Linked identity",
+		zap.String("identity_id", identityID),
+		zap.String("canonical_user_id", result.CanonicalUserID),
+		zap.Int("conflicts", len(result.Conflicts)),
+	)
+
+	return result, nil
+}
+
+// Unlink detaches a linked identity; data already merged stays with the canonical profile
+func (s *AccountService) Unlink(ctx context.Context, canonicalID, linkedID string) error {
+	query := `DELETE FROM user_links WHERE linked_user_id = $1 AND canonical_user_id = $2`
+
+	cmdTag, err := s.db.Exec(ctx, query, linkedID, canonicalID)
+	if err != nil {
+		s.logger.Error("Failed to unlink identity",
+			zap.String("user_id", canonicalID),
+			zap.String("linked_user_id", linkedID),
+			zap.Error(err),
+		)
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrLinkNotFound
+	}
+
+	return nil
+}
+
+type linkPrefs struct {
+	defaultSource   string
+	selectedSymbols []string
+	watchlist       []string
+}
+
+func loadLinkPrefs(ctx context.Context, tx pgx.Tx, userID string) (*linkPrefs, error) {
+	var p linkPrefs
+	err := tx.QueryRow(ctx, `
+		SELECT default_source, selected_symbols, watchlist
+		FROM user_preferences
+		WHERE user_id = $1
+		FOR UPDATE
+	`, userID).Scan(&p.defaultSource, pq.Array(&p.selectedSymbols), pq.Array(&p.watchlist))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// mergePreferences folds the linked identity's preferences into the canonical profile
+func mergePreferences(ctx context.Context, tx pgx.Tx, canonicalID, linkedID string, result *models.LinkResult) error {
+	linked, err := loadLinkPrefs(ctx, tx, linkedID)
+	if err != nil || linked == nil {
+		return err
+	}
+
+	canonical, err := loadLinkPrefs(ctx, tx, canonicalID)
+	if err != nil {
+		return err
+	}
+
+	// Canonical profile never set up preferences: adopt the linked ones wholesale
+	if canonical == nil {
+		_, err := tx.Exec(ctx, `UPDATE user_preferences SET user_id = $1 WHERE user_id = $2`, canonicalID, linkedID)
+		_, result.WatchlistAdded = unionSymbols(nil, linked.watchlist)
+		_, result.SymbolsAdded = unionSymbols(nil, linked.selectedSymbols)
+		return err
+	}
+
+	watchlist, watchlistAdded := unionSymbols(canonical.watchlist, linked.watchlist)
+	selected, selectedAdded := unionSymbols(canonical.selectedSymbols, linked.selectedSymbols)
+	result.WatchlistAdded = watchlistAdded
+	result.SymbolsAdded = selectedAdded
+
+	if linked.defaultSource != "" && linked.defaultSource != canonical.defaultSource {
+		result.Conflicts = append(result.Conflicts, models.MergeConflict{
+			Field:     "default_source",
+			Kept:      canonical.defaultSource,
+			Discarded: linked.defaultSource,
+		})
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE user_preferences SET watchlist = $1, selected_symbols = $2 WHERE user_id = $3
+	`, pq.Array(watchlist), pq.Array(selected), canonicalID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM user_preferences WHERE user_id = $1`, linkedID)
+	return err
+}
+
+// mergeFeeSettings keeps the canonical fee model, adopting the linked one only if none exists
+func mergeFeeSettings(ctx context.Context, tx pgx.Tx, canonicalID, linkedID string, result *models.LinkResult) error {
+	var linkedModel, canonicalModel []byte
+	err := tx.QueryRow(ctx, `SELECT model FROM user_fee_settings WHERE user_id = $1`, linkedID).Scan(&linkedModel)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx, `SELECT model FROM user_fee_settings WHERE user_id = $1`, canonicalID).Scan(&canonicalModel)
+	if errors.Is(err, pgx.ErrNoRows) {
+		_, err := tx.Exec(ctx, `UPDATE user_fee_settings SET user_id = $1 WHERE user_id = $2`, canonicalID, linkedID)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(linkedModel, canonicalModel) {
+		result.Conflicts = append(result.Conflicts, models.MergeConflict{
+			Field:     "fee_model",
+			Kept:      "canonical",
+			Discarded: "linked",
+		})
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM user_fee_settings WHERE user_id = $1`, linkedID)
+	return err
+}
+
+// mergePortfolios moves the linked identity's portfolios, with their holdings and
+// event history, to the canonical profile. A portfolio whose name the canonical
+// profile already uses is renamed with a numbered suffix and reported as a conflict.
+func mergePortfolios(ctx context.Context, tx pgx.Tx, canonicalID, linkedID string, result *models.LinkResult) error {
+	rows, err := tx.Query(ctx, `SELECT name FROM portfolios WHERE user_id = $1 FOR UPDATE`, canonicalID)
+	if err != nil {
+		return err
+	}
+	taken, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(taken))
+	for _, name := range taken {
+		names[name] = true
+	}
+
+	rows, err = tx.Query(ctx, `SELECT id, name FROM portfolios WHERE user_id = $1 ORDER BY id FOR UPDATE`, linkedID)
+	if err != nil {
+		return err
+	}
+	type portfolio struct {
+		ID   int64
+		Name string
+	}
+	linked, err := pgx.CollectRows(rows, pgx.RowToStructByPos[portfolio])
+	if err != nil {
+		return err
+	}
+
+	for _, p := range linked {
+		name := p.Name
+		for n := 2; names[name]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			name = truncateRunes(p.Name, maxPortfolioName-len(suffix)) + suffix
+		}
+		names[name] = true
+		if name != p.Name {
+			result.Conflicts = append(result.Conflicts, models.MergeConflict{
+				Field:     "portfolio_name",
+				Kept:      p.Name,
+				Discarded: name,
+			})
+		}
+
+		// Holdings, events and snapshots reference the portfolio, so they move with it
+		if _, err := tx.Exec(ctx, `UPDATE portfolios SET user_id = $1, name = $2 WHERE id = $3`, canonicalID, name, p.ID); err != nil {
+			return err
+		}
+		result.PortfoliosAdded = append(result.PortfoliosAdded, name)
+	}
+	return nil
+}
+
+// linkedUserTables are the tables whose rows belong to one user and simply change
+// owner when the user's identity is linked
+var linkedUserTables = []string{"export_jobs", "import_jobs", "symbol_notes", "strategies", "webhooks", "offline_bundles"}
+
+// mergeUserRows moves the linked identity's remaining data to the canonical profile.
+// The canonical profile's changelog mark is kept when both have one.
+func mergeUserRows(ctx context.Context, tx pgx.Tx, canonicalID, linkedID string) error {
+	for _, table := range linkedUserTables {
+		if _, err := tx.Exec(ctx, `UPDATE `+table+` SET user_id = $1 WHERE user_id = $2`, canonicalID, linkedID); err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO changelog_seen (user_id, version, seen_at)
+		SELECT $1, version, seen_at FROM changelog_seen WHERE user_id = $2
+		ON CONFLICT (user_id) DO NOTHING
+	`, canonicalID, linkedID); err != nil {
+		return fmt.Errorf("changelog_seen: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM changelog_seen WHERE user_id = $1`, linkedID); err != nil {
+		return fmt.Errorf("changelog_seen: %w", err)
+	}
+	return nil
+}
+
+// unionSymbols appends symbols from extra not already in base, preserving base order
+func unionSymbols(base, extra []string) ([]string, []string) {
+	seen := make(map[string]bool, len(base))
+	merged := append([]string{}, base...)
+	for _, symbol := range base {
+		seen[symbol] = true
+	}
+
+	added := []string{}
+	for _, symbol := range extra {
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		merged = append(merged, symbol)
+		added = append(added, symbol)
+	}
+
+	return merged, added
+}
+
+// truncateRunes cuts s to at most n characters
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// priceAdjustment scales candles dated before an ex-date
+type priceAdjustment struct {
+	exDate time.Time
+	price  float64 // multiplies prices
+	volume float64 // multiplies volume
+}
+
+// Adjust back-adjusts candles of symbol for the splits, and with AdjustAll the
+// dividends, that went ex after them, so the series reads continuously at today's
+// share count. Only approved actions whose ex-date has arrived count. data is left
+// untouched; AdjustNone returns it as is.
+func (s *MarketService) Adjust(ctx context.Context, symbol, mode string, data []models.MarketData) ([]models.MarketData, error) {
+	if mode == models.AdjustNone || len(data) == 0 {
+		return data, nil
+	}
+
+	types := []string{models.ActionSplit}
+	if mode == models.AdjustAll {
+		types = append(types, models.ActionDividend)
+	}
+	first := data[0].Date
+	for _, md := range data {
+		if md.Date.Before(first) {
+			first = md.Date
+		}
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT action_type, ex_date, ratio_old, ratio_new, amount::float8, cum_price::float8
+		FROM corporate_actions
+		WHERE symbol = $1 AND action_type = ANY($2) AND status IN ($3, $4)
+			AND ex_date > $5 AND ex_date <= CURRENT_DATE
+		ORDER BY ex_date, id
+	`, symbol, types, models.ActionScheduled, models.ActionApplied, first)
+	if err != nil {
+		s.logger.Error("Failed to load corporate actions for adjustment",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var adjustments []priceAdjustment
+	for rows.Next() {
+		var actionType string
+		var exDate time.Time
+		var ratioOld, ratioNew int
+		var amount float64
+		var cumPrice *float64
+		if err := rows.Scan(&actionType, &exDate, &ratioOld, &ratioNew, &amount, &cumPrice); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if actionType == models.ActionSplit {
+			ratio := float64(ratioNew) / float64(ratioOld)
+			adjustments = append(adjustments, priceAdjustment{exDate: exDate, price: 1 / ratio, volume: ratio})
+			continue
+		}
+		// A dividend scales earlier prices by how much of the cum price it paid out
+		cum := cumPrice
+		if cum == nil {
+			cum = closeBefore(data, exDate)
+		}
+		if cum == nil || *cum <= amount {
+			continue
+		}
+		adjustments = append(adjustments, priceAdjustment{exDate: exDate, price: 1 - amount/(*cum), volume: 1})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	if len(adjustments) == 0 {
+		return data, nil
+	}
+
+	adjusted := make([]models.MarketData, len(data))
+	for i, md := range data {
+		price, volume := 1.0, 1.0
+		for _, a := range adjustments {
+			if md.Date.Before(a.exDate) {
+				price *= a.price
+				volume *= a.volume
+			}
+		}
+		if price != 1 || volume != 1 {
+			if md.Open != nil {
+				open := roundAdjusted(*md.Open * price)
+				md.Open = &open
+			}
+			md.High = roundAdjusted(md.High * price)
+			md.Low = roundAdjusted(md.Low * price)
+			md.Close = roundAdjusted(md.Close * price)
+			if md.Volume != nil {
+				v := int64(math.Round(float64(*md.Volume) * volume))
+				md.Volume = &v
+			}
+		}
+		adjusted[i] = md
+	}
+	return adjusted, nil
+}
+
+// closeBefore returns the close of the latest candle in data dated before exDate
+func closeBefore(data []models.MarketData, exDate time.Time) *float64 {
+	var latest *models.MarketData
+	for i := range data {
+		md := &data[i]
+		if md.Date.Before(exDate) && (latest == nil || md.Timestamp.After(latest.Timestamp)) {
+			latest = md
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+	return &latest.Close
+}
+
+// roundAdjusted rounds an adjusted price to the eight decimals prices are stored with
+func roundAdjusted(price float64) float64 {
+	return math.Round(price*1e8) / 1e8
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// aggregateUnits map aggregation periods to date_trunc units; weeks start on Monday
+var aggregateUnits = map[string]string{
+	models.PeriodWeekly:  "week",
+	models.PeriodMonthly: "month",
+}
+
+// Aggregate rolls a symbol's daily candles between startDate and endDate up into
+// weekly or monthly candles, one series per exchange and source (or per exchange
+// in a merge, which aggregates the merged series)
+func (s *MarketService) Aggregate(ctx context.Context, symbol, source, exchange, period string, startDate, endDate time.Time) ([]models.AggregateCandle, error) {
+	unit, ok := aggregateUnits[period]
+	if !ok {
+		return nil, fmt.Errorf("unsupported aggregation period %q", period)
+	}
+
+	from, filter := sourceScope(source)
+	sourceCol := "source"
+	if isMerged(source) {
+		// Merged values are validated and safe to quote
+		sourceCol = "'" + mergedLabel(source) + "'"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT exchange, %s AS series, date_trunc('%s', date::timestamp)::date AS period_start,
+			MIN(date), MAX(date),
+			(array_agg(open ORDER BY ts))[1]::float8,
+			MAX(high)::float8,
+			MIN(low)::float8,
+			(array_agg(close ORDER BY ts DESC))[1]::float8,
+			SUM(volume)::bigint,
+			COUNT(*)::int
+		FROM %s
+		WHERE symbol = $1 AND interval = '1d' AND date >= $2 AND date <= $3
+			AND ($4 = '' OR source = $4) AND ($5 = '' OR exchange = $5)
+		GROUP BY exchange, series, period_start
+		ORDER BY exchange, series, period_start
+	`, sourceCol, unit, from)
+
+	rows, err := s.db.Query(ctx, query, symbol, startDate, endDate, filter, exchange)
+	if err != nil {
+		s.logger.Error("Failed to aggregate market data",
+			zap.String("symbol", symbol),
+			zap.String("period", period),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AggregateCandle])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/anomaly"
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type AnomalyService struct {
+	db      *database.DB
+	sources *SourceService
+	logger  *zap.Logger
+}
+
+func NewAnomalyService(db *database.DB, sources *SourceService) *AnomalyService {
+	return &AnomalyService{
+		db:      db,
+		sources: sources,
+		logger:  logger.With(zap.String("service", "anomaly")),
+	}
+}
+
+// Screen rewrites an ingest batch with each source's transform rules, then checks it
+// and applies each source's anomaly policy. It returns the rows to store (possibly
+// corrected) and records every anomaly.
+func (s *AnomalyService) Screen(ctx context.Context, data []models.MarketData) ([]models.MarketData, *models.ScreenReport, error) {
+	report := &models.ScreenReport{Checked: len(data), Anomalies: []models.Anomaly{}}
+	if len(data) == 0 {
+		return data, report, nil
+	}
+
+	// Transform a copy, leaving the caller's batch as received
+	ordered := append([]models.MarketData{}, data...)
+	transformed, err := s.sources.Transform(ctx, ordered)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.Transformed = transformed
+
+	policies, err := s.sources.AnomalyPolicies(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Walk each listing/source/interval series in time order so every candle is compared
+	// with its predecessor
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if a.Exchange != b.Exchange {
+			return a.Exchange < b.Exchange
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Interval != b.Interval {
+			return a.Interval < b.Interval
+		}
+		return a.Timestamp.Before(b.Timestamp)
+	})
+
+	accepted := make([]models.MarketData, 0, len(ordered))
+	prevClose := map[string]float64{}
+	for _, md := range ordered {
+		key := md.Exchange + "|" + md.Symbol + "|" + md.Source + "|" + md.Interval
+		prev, ok := prevClose[key]
+		if !ok {
+			prev, err = s.previousClose(ctx, md.Exchange, md.Symbol, md.Source, md.Interval, md.Timestamp)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		findings := anomaly.Detect(md, prev)
+		if len(findings) == 0 {
+			accepted = append(accepted, md)
+			prevClose[key] = md.Close
+			continue
+		}
+
+		policy := policies[md.Source]
+		if policy == "" {
+			policy = anomaly.PolicyAcceptWithFlag
+		}
+
+		record := newAnomaly(md, policy, findings)
+		switch policy {
+		case anomaly.PolicyReject:
+			record.Action = models.AnomalyRejected
+			report.Rejected++
+			prevClose[key] = prev
+		case anomaly.PolicyQuarantine:
+			record.Action = models.AnomalyQuarantined
+			report.Quarantined++
+			prevClose[key] = prev
+		case anomaly.PolicyAutoCorrect:
+			fixed := md
+			if anomaly.Correct(&fixed, findings) {
+				record.Action = models.AnomalyCorrected
+				report.Corrected++
+				accepted = append(accepted, fixed)
+				prevClose[key] = fixed.Close
+			} else {
+				record.Action = models.AnomalyQuarantined
+				report.Quarantined++
+				prevClose[key] = prev
+			}
+		default:
+			record.Action = models.AnomalyFlagged
+			report.Flagged++
+			accepted = append(accepted, md)
+			prevClose[key] = md.Close
+		}
+		report.Anomalies = append(report.Anomalies, record)
+	}
+	report.Accepted = len(accepted)
+
+	if err := s.record(ctx, report.Anomalies); err != nil {
+		return nil, nil, err
+	}
+
+	if len(report.Anomalies) > 0 {
+		s.logger.Warn("Ingestion anomalies detected",
+			zap.Int("checked", report.Checked),
+			zap.Int("flagged", report.Flagged),
+			zap.Int("corrected", report.Corrected),
+			zap.Int("quarantined", report.Quarantined),
+			zap.Int("rejected", report.Rejected),
+		)
+	}
+
+	return accepted, report, nil
+}
+
+// List returns recorded anomalies, newest first, optionally filtered by action and symbol
+func (s *AnomalyService) List(ctx context.Context, action, symbol string, limit int) ([]models.Anomaly, error) {
+	query := `
+		SELECT id, symbol, date, source, kinds, details, policy, action, raw, created_at
+		FROM market_data_anomalies
+		WHERE ($1 = '' OR action = $1) AND ($2 = '' OR symbol = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`
+
+	rows, err := s.db.Query(ctx, query, action, symbol, limit)
+	if err != nil {
+		s.logger.Error("Failed to list anomalies",
+			zap.String("action", action),
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.Anomaly{}
+	for rows.Next() {
+		var a models.Anomaly
+		var raw []byte
+		err := rows.Scan(
+			&a.ID, &a.Symbol, &a.Date, &a.Source, pq.Array(&a.Kinds), pq.Array(&a.Details),
+			&a.Policy, &a.Action, &raw, &a.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := json.Unmarshal(raw, &a.Raw); err != nil {
+			return nil, fmt.Errorf("failed to decode raw candle: %w", err)
+		}
+		results = append(results, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *AnomalyService) previousClose(ctx context.Context, exchange, symbol, source, interval string, before time.Time) (float64, error) {
+	query := `
+		SELECT close FROM market_data
+		WHERE exchange = $5 AND symbol = $1 AND source = $2 AND interval = $4 AND ts < $3 AND close IS NOT NULL
+		ORDER BY ts DESC
+		LIMIT 1
+	`
+
+	var close float64
+	err := s.db.QueryRow(ctx, query, symbol, source, before, interval, exchange).Scan(&close)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		s.logger.Error("Failed to get previous close",
+			zap.String("symbol", symbol),
+			zap.String("source", source),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	return close, nil
+}
+
+func (s *AnomalyService) record(ctx context.Context, anomalies []models.Anomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	query := `
+		INSERT INTO market_data_anomalies (symbol, date, source, kinds, details, policy, action, raw)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	for _, a := range anomalies {
+		raw, err := json.Marshal(a.Raw)
+		if err != nil {
+			return fmt.Errorf("failed to encode raw candle: %w", err)
+		}
+		batch.Queue(query, a.Symbol, a.Date, a.Source, pq.Array(a.Kinds), pq.Array(a.Details), a.Policy, a.Action, raw)
+	}
+
+	br := s.db.Pool().SendBatch(ctx, batch)
+	defer br.Close()
+
+	for i := 0; i < batch.Len(); i++ {
+		if _, err := br.Exec(); err != nil {
+			s.logger.Error("Failed to record anomaly", zap.Int("item", i), zap.Error(err))
+			return fmt.Errorf("failed to record anomaly %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func newAnomaly(md models.MarketData, policy string, findings []anomaly.Finding) models.Anomaly {
+	a := models.Anomaly{
+		Symbol:  md.Symbol,
+		Date:    md.Date,
+		Source:  md.Source,
+		Kinds:   make([]string, len(findings)),
+		Details: make([]string, len(findings)),
+		Policy:  policy,
+		Raw:     md,
+	}
+	for i, f := range findings {
+		a.Kinds[i] = f.Kind
+		a.Details[i] = f.Detail
+	}
+	return a
+}
@@ -0,0 +1,693 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/parquet"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ErrArchiveDisabled is returned when archiving is asked for without an archive store
+var ErrArchiveDisabled = errors.New("market data archiving is disabled")
+
+// archiveSegment is the unit candles are archived in: one listing, interval, source
+// and year
+type archiveSegment struct {
+	exchange string
+	symbol   string
+	interval string
+	source   string
+	year     int
+}
+
+// objectKey names a new Parquet object for the segment; each rewrite gets its own key,
+// so the previous object stays readable until the manifest points away from it
+func (seg archiveSegment) objectKey(version int64) string {
+	return fmt.Sprintf("market_data/%s/%s/%s/%s/%d-%d.parquet",
+		url.PathEscape(seg.exchange), url.PathEscape(seg.symbol), seg.interval, url.PathEscape(seg.source), seg.year, version)
+}
+
+// archiveObject is a manifest entry found for a read. The manifest names the listing
+// its candles belong to, even if they were archived under another spelling.
+type archiveObject struct {
+	exchange string
+	symbol   string
+	year     int
+	key      string
+}
+
+// ArchiveEnabled reports whether candles can be moved to an archive store
+func (s *MarketService) ArchiveEnabled() bool {
+	return s.opts.Archive != nil
+}
+
+// Archive moves every candle dated before the cutoff out of market_data into the
+// archive store, one segment at a time. Candles of an already archived segment, as
+// from a late correction, are merged into its object. A segment that fails stays in
+// the database and is retried by the next run.
+func (s *MarketService) Archive(ctx context.Context, before time.Time) (*models.ArchiveRun, error) {
+	if !s.ArchiveEnabled() {
+		return nil, ErrArchiveDisabled
+	}
+	run := &models.ArchiveRun{Before: before, StartedAt: time.Now().UTC()}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT DISTINCT exchange, symbol, interval, source, EXTRACT(YEAR FROM date)::int
+		FROM market_data
+		WHERE date < $1
+		ORDER BY symbol, interval, 5, exchange, source
+	`, before)
+	if err != nil {
+		s.logger.Error("Failed to find candles to archive", zap.Time("before", before), zap.Error(err))
+		return nil, err
+	}
+	var segments []archiveSegment
+	for rows.Next() {
+		var seg archiveSegment
+		if err := rows.Scan(&seg.exchange, &seg.symbol, &seg.interval, &seg.source, &seg.year); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		segments = append(segments, seg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	for _, seg := range segments {
+		if ctx.Err() != nil {
+			break
+		}
+		moved, size, err := s.archiveSegment(ctx, seg)
+		if err != nil {
+			run.Failed++
+			s.logger.Error("Failed to archive candles",
+				zap.String("exchange", seg.exchange),
+				zap.String("symbol", seg.symbol),
+				zap.String("interval", seg.interval),
+				zap.String("source", seg.source),
+				zap.Int("year", seg.year),
+				zap.Error(err),
+			)
+			continue
+		}
+		if moved > 0 {
+			run.Segments++
+			run.Rows += moved
+			run.Bytes += size
+		}
+	}
+
+	finished := time.Now().UTC()
+	run.FinishedAt = &finished
+	s.logger.Info("Archived market data",
+		zap.Time("before", before),
+		zap.Int("segments", run.Segments),
+		zap.Int64("rows", run.Rows),
+		zap.Int64("bytes", run.Bytes),
+		zap.Int("failed", run.Failed),
+	)
+	return run, nil
+}
+
+// archiveSegment writes a segment's live candles, merged with its existing object, to
+// a new object and removes them from market_data, returning how many candles moved
+// and the size of the object written. Archived candles a live one replaces are kept
+// in market_data_history as superseded versions.
+func (s *MarketService) archiveSegment(ctx context.Context, seg archiveSegment) (int64, int64, error) {
+	startDate, endDate := yearBounds(seg.year)
+
+	var moved, size int64
+	var oldKey, newKey string
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		// Hold off imports of the symbol so nothing lands in the segment meanwhile
+		if err := s.lockSymbols(ctx, tx, []string{seg.symbol}, xactLockQuery); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, `
+			SELECT id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+				COALESCE(updated_at, created_at)
+			FROM market_data
+			WHERE symbol = $1 AND interval = $2 AND exchange = $3 AND source = $4 AND date >= $5 AND date <= $6
+			ORDER BY ts
+		`, seg.symbol, seg.interval, seg.exchange, seg.source, startDate, endDate)
+		if err != nil {
+			return fmt.Errorf("failed to load candles: %w", err)
+		}
+		live, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.MarketData])
+		if err != nil {
+			return fmt.Errorf("failed to collect rows: %w", err)
+		}
+		if len(live) == 0 {
+			return nil
+		}
+
+		err = tx.QueryRow(ctx, `
+			SELECT object_key FROM market_data_archives
+			WHERE symbol = $1 AND interval = $2 AND year = $3 AND exchange = $4 AND source = $5
+			FOR UPDATE
+		`, seg.symbol, seg.interval, seg.year, seg.exchange, seg.source).Scan(&oldKey)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to load manifest: %w", err)
+		}
+		candles := live
+		if oldKey != "" {
+			archived, err := s.readArchive(ctx, oldKey)
+			if err != nil {
+				return err
+			}
+			for i := range archived {
+				archived[i].Exchange, archived[i].Symbol = seg.exchange, seg.symbol
+			}
+			if err := supersedeArchived(ctx, tx, archived, live); err != nil {
+				return err
+			}
+			candles = mergeArchived(archived, live)
+		}
+
+		// The file is encoded as the store reads it, a row group at a time
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(encodeArchive(pw, candles))
+		}()
+		newKey = seg.objectKey(time.Now().UnixNano())
+		size, err = s.opts.Archive.Put(ctx, newKey, pr)
+		// Unblock the encoder if the store stopped reading early
+		pr.CloseWithError(err)
+		if err != nil {
+			return fmt.Errorf("failed to store archive: %w", err)
+		}
+
+		ids := make([]int64, len(live))
+		for i, md := range live {
+			ids[i] = md.ID
+		}
+		// Archived candles are moved, not superseded: the delete trigger skips them, and
+		// their earlier versions stay in market_data_history
+		if _, err := tx.Exec(ctx, `SET LOCAL market_data.archiving = 'on'`); err != nil {
+			return fmt.Errorf("failed to mark archiving: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM market_data WHERE id = ANY($1)`, ids); err != nil {
+			return fmt.Errorf("failed to remove archived candles: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO market_data_archives (exchange, symbol, interval, source, year, rows, first_ts, last_ts, object_key, size_bytes)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (symbol, interval, year, exchange, source) DO UPDATE SET
+				rows = EXCLUDED.rows,
+				first_ts = EXCLUDED.first_ts,
+				last_ts = EXCLUDED.last_ts,
+				object_key = EXCLUDED.object_key,
+				size_bytes = EXCLUDED.size_bytes,
+				archived_at = CURRENT_TIMESTAMP
+		`, seg.exchange, seg.symbol, seg.interval, seg.source, seg.year, len(candles),
+			candles[0].Timestamp, candles[len(candles)-1].Timestamp, newKey, size)
+		if err != nil {
+			return fmt.Errorf("failed to record archive: %w", err)
+		}
+		moved = int64(len(live))
+		return nil
+	})
+	if err != nil {
+		if newKey != "" {
+			s.discardArchive(ctx, newKey)
+		}
+		return 0, 0, err
+	}
+	if moved == 0 {
+		return 0, 0, nil
+	}
+	if oldKey != "" {
+		s.discardArchive(ctx, oldKey)
+	}
+	s.invalidate(ctx, []string{seg.symbol})
+	return moved, size, nil
+}
+
+// ArchiveStatus summarizes the archived segments; the caller adds the schedule
+func (s *MarketService) ArchiveStatus(ctx context.Context) (*models.ArchiveStatus, error) {
+	status := &models.ArchiveStatus{Enabled: s.ArchiveEnabled()}
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(rows), 0), COALESCE(SUM(size_bytes), 0), MIN(year), MAX(year)
+		FROM market_data_archives
+	`).Scan(&status.Segments, &status.Rows, &status.Bytes, &status.OldestYear, &status.NewestYear)
+	if err != nil {
+		s.logger.Error("Failed to summarize archive", zap.Error(err))
+		return nil, err
+	}
+	return status, nil
+}
+
+// archiveObjects lists the archived objects that may hold a symbol's candles within a
+// date range, filtered like a live read
+func (s *MarketService) archiveObjects(ctx context.Context, symbol, source, interval, exchange string, startDate, endDate time.Time) ([]archiveObject, error) {
+	if !s.ArchiveEnabled() {
+		return nil, nil
+	}
+	if isMerged(source) {
+		source = ""
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT exchange, symbol, year, object_key FROM market_data_archives
+		WHERE symbol = $1 AND interval = $2 AND year >= $3 AND year <= $4
+			AND ($5 = '' OR exchange = $5) AND ($6 = '' OR source = $6)
+		ORDER BY year, exchange, source
+	`, symbol, intervalOrDaily(interval), startDate.Year(), endDate.Year(), exchange, source)
+	if err != nil {
+		s.logger.Error("Failed to list archived candles", zap.String("symbol", symbol), zap.Error(err))
+		return nil, err
+	}
+	var objects []archiveObject
+	for rows.Next() {
+		var obj archiveObject
+		if err := rows.Scan(&obj.exchange, &obj.symbol, &obj.year, &obj.key); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		objects = append(objects, obj)
+	}
+	rows.Close()
+	return objects, rows.Err()
+}
+
+// archiveSpanEnd is the last date of a range the archived objects may hold candles
+// for; every candle after it is live
+func archiveSpanEnd(objects []archiveObject, endDate time.Time) time.Time {
+	_, spanEnd := yearBounds(objects[len(objects)-1].year)
+	if end := dateOf(endDate); spanEnd.After(end) {
+		return end
+	}
+	return spanEnd
+}
+
+// withArchived stitches the archived candles of a date range into live, the candles
+// read for it from market_data. Live candles replace archived ones for the same
+// listing, interval, source and time.
+func (s *MarketService) withArchived(ctx context.Context, live []models.MarketData, objects []archiveObject, symbol, source, interval, exchange string, startDate, endDate, ingestedBefore time.Time) ([]models.MarketData, error) {
+	first, spanEnd := dateOf(startDate), archiveSpanEnd(objects, endDate)
+
+	var candles []models.MarketData
+	for _, obj := range objects {
+		archived, err := s.readArchive(ctx, obj.key)
+		if err != nil {
+			return nil, err
+		}
+		for _, md := range archived {
+			if !md.Date.Before(first) && !md.Date.After(spanEnd) &&
+				(ingestedBefore.IsZero() || md.CreatedAt.Before(ingestedBefore)) {
+				md.Exchange, md.Symbol = obj.exchange, obj.symbol
+				candles = append(candles, md)
+			}
+		}
+	}
+
+	var early, later []models.MarketData
+	for _, md := range live {
+		if md.Date.After(spanEnd) {
+			later = append(later, md)
+		} else {
+			early = append(early, md)
+		}
+	}
+	// Merged live candles hide the sources they beat; redo the merge over every source
+	if isMerged(source) {
+		var err error
+		if early, err = s.getBySymbolAndDateRange(ctx, symbol, "", interval, exchange, startDate, spanEnd, ingestedBefore); err != nil {
+			return nil, err
+		}
+	}
+
+	candles = mergeArchived(candles, early)
+	if isMerged(source) {
+		priorities, err := s.sourcePriorities(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if preferred := preferredSource(source); preferred != "" {
+			priorities[preferred] = math.MinInt
+		}
+		candles = preferSources(candles, priorities)
+	}
+	sort.SliceStable(candles, func(i, j int) bool {
+		return candles[i].Timestamp.Before(candles[j].Timestamp)
+	})
+	return append(candles, later...), nil
+}
+
+// withArchivedAsOf stitches the archived candles of a date range that were current at
+// asOf into live, the candles read for it as of asOf. An archived candle was current
+// from its last update until a live candle with the same key replaced it, and the
+// live read returns that candle or a version of it from then on.
+func (s *MarketService) withArchivedAsOf(ctx context.Context, live []models.MarketData, objects []archiveObject, source string, startDate, endDate, asOf time.Time) ([]models.MarketData, error) {
+	first, spanEnd := dateOf(startDate), archiveSpanEnd(objects, endDate)
+
+	var candles []models.MarketData
+	for _, obj := range objects {
+		archived, err := s.readArchive(ctx, obj.key)
+		if err != nil {
+			return nil, err
+		}
+		for _, md := range archived {
+			if !md.Date.Before(first) && !md.Date.After(spanEnd) && !md.UpdatedAt.After(asOf) {
+				md.Exchange, md.Symbol = obj.exchange, obj.symbol
+				candles = append(candles, md)
+			}
+		}
+	}
+
+	candles = mergeArchived(candles, live)
+	if isMerged(source) {
+		priorities, err := s.sourcePriorities(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if preferred := preferredSource(source); preferred != "" {
+			priorities[preferred] = math.MinInt
+		}
+		candles = preferSources(candles, priorities)
+	}
+	sort.SliceStable(candles, func(i, j int) bool {
+		return candles[i].Timestamp.Before(candles[j].Timestamp)
+	})
+	return candles, nil
+}
+
+// archivedPage continues a page of a symbol's candles, newest first, into the span of
+// archived years ending at spanEnd, reading it a year at a time. It passes over the
+// candles up to a non-zero cursor, then skip more, and returns at most want.
+func (s *MarketService) archivedPage(ctx context.Context, symbol string, page Page, objects []archiveObject, spanEnd, cursorTS time.Time, cursorID int64, skip, want int) ([]models.MarketData, error) {
+	var results []models.MarketData
+	oldest := objects[0].year
+	for year := spanEnd.Year(); year >= oldest-1 && len(results) < want; year-- {
+		start, end := yearBounds(year)
+		if year < oldest {
+			// Whatever precedes the oldest archived year is live
+			start = time.Time{}
+		}
+		if end.After(spanEnd) {
+			end = spanEnd
+		}
+		data, err := s.GetBySymbolAndDateRange(ctx, symbol, page.Source, page.Interval, page.Exchange, start, end, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(data, func(i, j int) bool {
+			if !data[i].Timestamp.Equal(data[j].Timestamp) {
+				return data[i].Timestamp.After(data[j].Timestamp)
+			}
+			return data[i].ID > data[j].ID
+		})
+		for _, md := range data {
+			if !cursorTS.IsZero() && (md.Timestamp.After(cursorTS) || md.Timestamp.Equal(cursorTS) && md.ID >= cursorID) {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			results = append(results, md)
+			if len(results) == want {
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
+// supersedeArchived records the archived candles that live candles replace as
+// versions in market_data_history, valid until their replacement was first stored
+func supersedeArchived(ctx context.Context, tx pgx.Tx, archived, live []models.MarketData) error {
+	replacedAt := make(map[candleKey]time.Time, len(live))
+	for _, md := range live {
+		replacedAt[candleKey{md.Exchange, md.Interval, md.Source, md.Timestamp.UTC()}] = md.CreatedAt
+	}
+	versions := &pgx.Batch{}
+	for _, md := range archived {
+		at, ok := replacedAt[candleKey{md.Exchange, md.Interval, md.Source, md.Timestamp.UTC()}]
+		if !ok {
+			continue
+		}
+		versions.Queue(`
+			INSERT INTO market_data_history (
+				market_data_id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source,
+				created_at, valid_from, valid_to
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		`, md.ID, md.Exchange, md.Symbol, md.Interval, md.Date, md.Timestamp, md.Open, md.High, md.Low, md.Close,
+			md.AdjClose, md.Volume, md.Source, md.CreatedAt, md.UpdatedAt, at)
+	}
+	if versions.Len() == 0 {
+		return nil
+	}
+	if err := tx.SendBatch(ctx, versions).Close(); err != nil {
+		return fmt.Errorf("failed to record superseded archived candles: %w", err)
+	}
+	return nil
+}
+
+// sourcePriorities returns each source's merge priority, lowest preferred
+func (s *MarketService) sourcePriorities(ctx context.Context) (map[string]int, error) {
+	rows, err := s.db.Query(ctx, `SELECT name, COALESCE(priority, 1000) FROM sources`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	priorities := map[string]int{}
+	for rows.Next() {
+		var name string
+		var priority int
+		if err := rows.Scan(&name, &priority); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		priorities[name] = priority
+	}
+	return priorities, rows.Err()
+}
+
+// candleKey identifies a candle of one symbol
+type candleKey struct {
+	exchange string
+	interval string
+	source   string
+	ts       time.Time
+}
+
+// mergeArchived combines archived and live candles of one symbol, live ones
+// replacing archived ones with the same key
+func mergeArchived(archived, live []models.MarketData) []models.MarketData {
+	replaced := make(map[candleKey]bool, len(live))
+	for _, md := range live {
+		replaced[candleKey{md.Exchange, md.Interval, md.Source, md.Timestamp.UTC()}] = true
+	}
+	merged := make([]models.MarketData, 0, len(archived)+len(live))
+	for _, md := range archived {
+		if !replaced[candleKey{md.Exchange, md.Interval, md.Source, md.Timestamp.UTC()}] {
+			merged = append(merged, md)
+		}
+	}
+	return append(merged, live...)
+}
+
+// preferSources keeps one candle per listing, interval and time, from the source with
+// the lowest priority as mergedMarketData does
+func preferSources(candles []models.MarketData, priorities map[string]int) []models.MarketData {
+	rank := func(source string) int {
+		if p, ok := priorities[source]; ok {
+			return p
+		}
+		return 1000
+	}
+	best := map[candleKey]int{}
+	var kept []models.MarketData
+	for _, md := range candles {
+		key := candleKey{md.Exchange, md.Interval, "", md.Timestamp.UTC()}
+		i, ok := best[key]
+		if !ok {
+			best[key] = len(kept)
+			kept = append(kept, md)
+			continue
+		}
+		cur := kept[i]
+		if rank(md.Source) < rank(cur.Source) || (rank(md.Source) == rank(cur.Source) && md.Source < cur.Source) {
+			kept[i] = md
+		}
+	}
+	return kept
+}
+
+// archiveRowGroup is how many candles go in each row group of an archive
+const archiveRowGroup = 10000
+
+// encodeArchive writes candles as a Parquet file, archiveRowGroup candles at a time
+func encodeArchive(w io.Writer, candles []models.MarketData) error {
+	columns := []parquet.Column{
+		{Name: "id", Kind: parquet.Int64},
+		{Name: "exchange", Kind: parquet.String},
+		{Name: "symbol", Kind: parquet.String},
+		{Name: "interval", Kind: parquet.String},
+		{Name: "date", Kind: parquet.Date},
+		{Name: "ts", Kind: parquet.Timestamp},
+		{Name: "open", Kind: parquet.Double, Optional: true},
+		{Name: "high", Kind: parquet.Double},
+		{Name: "low", Kind: parquet.Double},
+		{Name: "close", Kind: parquet.Double},
+		{Name: "adj_close", Kind: parquet.Double, Optional: true},
+		{Name: "volume", Kind: parquet.Int64, Optional: true},
+		{Name: "source", Kind: parquet.String},
+		{Name: "created_at", Kind: parquet.Timestamp},
+		{Name: "updated_at", Kind: parquet.Timestamp},
+	}
+	pw, err := parquet.NewWriter(w, columns)
+	if err != nil {
+		return fmt.Errorf("failed to encode archive: %w", err)
+	}
+
+	for start := 0; start < len(candles); start += archiveRowGroup {
+		group := candles[start:min(start+archiveRowGroup, len(candles))]
+		for i := range columns {
+			columns[i].Values = make([]interface{}, len(group))
+		}
+		for r, md := range group {
+			row := []interface{}{md.ID, md.Exchange, md.Symbol, md.Interval, md.Date, md.Timestamp, nil, md.High, md.Low,
+				md.Close, nil, nil, md.Source, md.CreatedAt, md.UpdatedAt}
+			if md.Open != nil {
+				row[6] = *md.Open
+			}
+			if md.AdjClose != nil {
+				row[10] = *md.AdjClose
+			}
+			if md.Volume != nil {
+				row[11] = *md.Volume
+			}
+			for i, v := range row {
+				columns[i].Values[r] = v
+			}
+		}
+		if err := pw.WriteRowGroup(columns); err != nil {
+			return fmt.Errorf("failed to encode archive: %w", err)
+		}
+	}
+	if err := pw.Close(); err != nil {
+		return fmt.Errorf("failed to encode archive: %w", err)
+	}
+	return nil
+}
+
+// decodeArchive reads the candles of a Parquet archive
+func decodeArchive(data []byte) ([]models.MarketData, error) {
+	columns, err := parquet.Read(data)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string][]interface{}{}
+	for _, col := range columns {
+		values[col.Name] = col.Values
+	}
+	rows := 0
+	if len(columns) > 0 {
+		rows = len(columns[0].Values)
+	}
+	get := func(name string, i int) interface{} {
+		if v := values[name]; i < len(v) {
+			return v[i]
+		}
+		return nil
+	}
+
+	candles := make([]models.MarketData, rows)
+	for i := range candles {
+		md := &candles[i]
+		md.ID, _ = get("id", i).(int64)
+		md.Exchange, _ = get("exchange", i).(string)
+		md.Symbol, _ = get("symbol", i).(string)
+		md.Interval, _ = get("interval", i).(string)
+		md.Date, _ = get("date", i).(time.Time)
+		md.Timestamp, _ = get("ts", i).(time.Time)
+		md.High, _ = get("high", i).(float64)
+		md.Low, _ = get("low", i).(float64)
+		md.Close, _ = get("close", i).(float64)
+		md.Source, _ = get("source", i).(string)
+		md.CreatedAt, _ = get("created_at", i).(time.Time)
+		md.UpdatedAt, _ = get("updated_at", i).(time.Time)
+		if v, ok := get("open", i).(float64); ok {
+			md.Open = &v
+		}
+		if v, ok := get("adj_close", i).(float64); ok {
+			md.AdjClose = &v
+		}
+		if v, ok := get("volume", i).(int64); ok {
+			md.Volume = &v
+		}
+	}
+	return candles, nil
+}
+
+// readArchive loads the candles of an archived object. Objects written before the
+// archive moved to Parquet are gzip-compressed JSON Lines.
+func (s *MarketService) readArchive(ctx context.Context, key string) ([]models.MarketData, error) {
+	body, err := s.opts.Archive.Open(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive %s: %w", key, err)
+	}
+	defer body.Close()
+
+	if !strings.HasSuffix(key, ".jsonl.gz") {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive %s: %w", key, err)
+		}
+		candles, err := decodeArchive(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive %s: %w", key, err)
+		}
+		return candles, nil
+	}
+
+	zr, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive %s: %w", key, err)
+	}
+	dec := json.NewDecoder(zr)
+	var candles []models.MarketData
+	for {
+		var md models.MarketData
+		err := dec.Decode(&md)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive %s: %w", key, err)
+		}
+		candles = append(candles, md)
+	}
+	return candles, nil
+}
+
+// discardArchive removes an object no manifest entry points to
+func (s *MarketService) discardArchive(ctx context.Context, key string) {
+	if err := s.opts.Archive.Delete(context.WithoutCancel(ctx), key); err != nil {
+		s.logger.Warn("Failed to delete archive object", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// dateOf truncates t to its calendar date, as a DATE parameter does
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
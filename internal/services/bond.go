@@ -0,0 +1,604 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrBondNotFound     = errors.New("bond not found")
+	ErrInvalidBond      = errors.New("invalid bond terms")
+	ErrCouponNotFound   = errors.New("coupon period not found")
+	ErrInvalidBondQuote = errors.New("invalid bond quote")
+	ErrBondMatured      = errors.New("bond has matured")
+)
+
+// parPrice is the clean price of a bond without quotes, e.g. an SBR redeemable at par
+const parPrice = 100.0
+
+type BondService struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewBondService(db *database.DB) *BondService {
+	return &BondService{
+		db:     db,
+		logger: logger.With(zap.String("service", "bond")),
+	}
+}
+
+const bondColumns = `symbol, name, issuer, currency, coupon_type, coupon_rate, coupon_frequency, day_count,
+	issue_date, maturity_date, tradable, created_at, COALESCE(updated_at, created_at)`
+
+const couponColumns = `symbol, period_start, period_end, record_date, pay_date, rate, amount`
+
+// List returns every bond ordered by maturity
+func (s *BondService) List(ctx context.Context) ([]models.Bond, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+bondColumns+` FROM bonds ORDER BY maturity_date, symbol`)
+	if err != nil {
+		s.logger.Error("Failed to list bonds", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	bonds, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Bond])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return bonds, nil
+}
+
+// Get returns one bond's terms
+func (s *BondService) Get(ctx context.Context, symbol string) (*models.Bond, error) {
+	bonds, err := s.bondsBySymbol(ctx, []string{symbol})
+	if err != nil {
+		return nil, err
+	}
+	bond, ok := bonds[symbol]
+	if !ok {
+		return nil, ErrBondNotFound
+	}
+	return &bond, nil
+}
+
+// Upsert creates or replaces a bond, lists it on the BOND exchange and regenerates its
+// coupon schedule from the terms
+func (s *BondService) Upsert(ctx context.Context, req models.BondRequest) (*models.Bond, error) {
+	bond, err := bondFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	coupons := GenerateCoupons(bond)
+
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `
+			INSERT INTO bonds (symbol, name, issuer, currency, coupon_type, coupon_rate, coupon_frequency,
+				day_count, issue_date, maturity_date, tradable)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (symbol) DO UPDATE SET
+				name = EXCLUDED.name,
+				issuer = EXCLUDED.issuer,
+				currency = EXCLUDED.currency,
+				coupon_type = EXCLUDED.coupon_type,
+				coupon_rate = EXCLUDED.coupon_rate,
+				coupon_frequency = EXCLUDED.coupon_frequency,
+				day_count = EXCLUDED.day_count,
+				issue_date = EXCLUDED.issue_date,
+				maturity_date = EXCLUDED.maturity_date,
+				tradable = EXCLUDED.tradable
+			RETURNING `+bondColumns,
+			bond.Symbol, bond.Name, bond.Issuer, bond.Currency, bond.CouponType, bond.CouponRate,
+			bond.CouponFrequency, bond.DayCount, bond.IssueDate, bond.MaturityDate, bond.Tradable,
+		).Scan(
+			&bond.Symbol, &bond.Name, &bond.Issuer, &bond.Currency, &bond.CouponType, &bond.CouponRate,
+			&bond.CouponFrequency, &bond.DayCount, &bond.IssueDate, &bond.MaturityDate, &bond.Tradable,
+			&bond.CreatedAt, &bond.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store bond: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO symbols (exchange, symbol, name, currency) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (exchange, symbol) DO UPDATE SET name = EXCLUDED.name, currency = EXCLUDED.currency
+		`, models.ExchangeBond, bond.Symbol, bond.Name, bond.Currency); err != nil {
+			return fmt.Errorf("failed to list bond: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM bond_coupons WHERE symbol = $1`, bond.Symbol); err != nil {
+			return fmt.Errorf("failed to clear coupons: %w", err)
+		}
+		batch := &pgx.Batch{}
+		for _, c := range coupons {
+			batch.Queue(`
+				INSERT INTO bond_coupons (symbol, period_start, period_end, record_date, pay_date, rate, amount)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`, c.Symbol, c.PeriodStart, c.PeriodEnd, c.RecordDate, c.PayDate, c.Rate, c.Amount)
+		}
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if err != nil {
+		s.logger.Error("Failed to upsert bond", zap.String("symbol", bond.Symbol), zap.Error(err))
+		return nil, err
+	}
+
+	return &bond, nil
+}
+
+// Coupons returns a bond's schedule in payment order
+func (s *BondService) Coupons(ctx context.Context, symbol string) ([]models.Coupon, error) {
+	if _, err := s.Get(ctx, symbol); err != nil {
+		return nil, err
+	}
+	schedules, err := s.couponsBySymbol(ctx, []string{symbol})
+	if err != nil {
+		return nil, err
+	}
+	return schedules[symbol], nil
+}
+
+// UpdateCoupons sets the rate and dates of existing coupon periods, recomputing what
+// each pays, e.g. when a floating-rate bond's coupon is reset
+func (s *BondService) UpdateCoupons(ctx context.Context, symbol string, inputs []models.CouponInput) ([]models.Coupon, error) {
+	bond, err := s.Get(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		for i, in := range inputs {
+			periodEnd, err := time.Parse("2006-01-02", in.PeriodEnd)
+			if err != nil {
+				return fmt.Errorf("%w: coupon %d has period_end %q, use YYYY-MM-DD", ErrInvalidBond, i, in.PeriodEnd)
+			}
+			payDate := periodEnd
+			if in.PayDate != "" {
+				if payDate, err = time.Parse("2006-01-02", in.PayDate); err != nil {
+					return fmt.Errorf("%w: coupon %d has pay_date %q, use YYYY-MM-DD", ErrInvalidBond, i, in.PayDate)
+				}
+			}
+			var recordDate *time.Time
+			if in.RecordDate != "" {
+				d, err := time.Parse("2006-01-02", in.RecordDate)
+				if err != nil {
+					return fmt.Errorf("%w: coupon %d has record_date %q, use YYYY-MM-DD", ErrInvalidBond, i, in.RecordDate)
+				}
+				recordDate = &d
+			}
+
+			var periodStart time.Time
+			err = tx.QueryRow(ctx, `
+				SELECT period_start FROM bond_coupons WHERE symbol = $1 AND period_end = $2
+			`, symbol, periodEnd).Scan(&periodStart)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return fmt.Errorf("%w: %s ending %s", ErrCouponNotFound, symbol, in.PeriodEnd)
+				}
+				return err
+			}
+
+			amount := couponAmount(*bond, in.Rate, periodStart, periodEnd)
+			if _, err := tx.Exec(ctx, `
+				UPDATE bond_coupons SET rate = $3, amount = $4, record_date = $5, pay_date = $6
+				WHERE symbol = $1 AND period_end = $2
+			`, symbol, periodEnd, in.Rate, amount, recordDate, payDate); err != nil {
+				return fmt.Errorf("failed to update coupon %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidBond) && !errors.Is(err, ErrCouponNotFound) {
+			s.logger.Error("Failed to update coupons", zap.String("symbol", symbol), zap.Error(err))
+		}
+		return nil, err
+	}
+
+	return s.Coupons(ctx, symbol)
+}
+
+// RecordQuote stores a day's quote given either as a clean price or as a yield,
+// deriving the other from the coupon schedule
+func (s *BondService) RecordQuote(ctx context.Context, symbol string, req models.BondQuoteRequest) (*models.BondQuote, error) {
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: date %q, use YYYY-MM-DD", ErrInvalidBondQuote, req.Date)
+	}
+	if (req.CleanPrice == nil) == (req.Yield == nil) {
+		return nil, fmt.Errorf("%w: give exactly one of clean_price and yield", ErrInvalidBondQuote)
+	}
+
+	bond, err := s.Get(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.couponsBySymbol(ctx, []string{symbol})
+	if err != nil {
+		return nil, err
+	}
+	coupons := schedules[symbol]
+
+	quote := models.BondQuote{Symbol: symbol, Date: date, Source: req.Source}
+	if quote.Source == "" {
+		quote.Source = "manual"
+	}
+	if req.CleanPrice != nil {
+		quote.CleanPrice = *req.CleanPrice
+		quote.Yield, err = YieldFromPrice(*bond, coupons, date, quote.CleanPrice)
+	} else {
+		quote.Yield = *req.Yield
+		quote.CleanPrice, err = PriceFromYield(*bond, coupons, date, quote.Yield)
+	}
+	if err != nil {
+		return nil, err
+	}
+	quote.CleanPrice = math.Round(quote.CleanPrice*1e6) / 1e6
+	quote.Yield = math.Round(quote.Yield*1e6) / 1e6
+
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO bond_quotes (symbol, date, clean_price, yield, source)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (symbol, date, source) DO UPDATE SET clean_price = EXCLUDED.clean_price, yield = EXCLUDED.yield
+		RETURNING created_at, COALESCE(updated_at, created_at)
+	`, quote.Symbol, quote.Date, quote.CleanPrice, quote.Yield, quote.Source).Scan(&quote.CreatedAt, &quote.UpdatedAt)
+	if err != nil {
+		s.logger.Error("Failed to store bond quote", zap.String("symbol", symbol), zap.Error(err))
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+// Quotes returns a bond's quotes between two dates, oldest first
+func (s *BondService) Quotes(ctx context.Context, symbol string, startDate, endDate time.Time) ([]models.BondQuote, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT symbol, date, clean_price, yield, source, created_at, COALESCE(updated_at, created_at)
+		FROM bond_quotes
+		WHERE symbol = $1 AND date >= $2 AND date <= $3
+		ORDER BY date, source
+	`, symbol, startDate, endDate)
+	if err != nil {
+		s.logger.Error("Failed to get bond quotes", zap.String("symbol", symbol), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	quotes, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.BondQuote])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return quotes, nil
+}
+
+// Value prices the bonds among symbols on date at their latest quote on or before it,
+// or at par without one, plus accrued interest. Symbols that are not bonds are left out.
+func (s *BondService) Value(ctx context.Context, symbols []string, date time.Time) (map[string]models.BondValuation, error) {
+	bonds, err := s.bondsBySymbol(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+	valuations := make(map[string]models.BondValuation, len(bonds))
+	if len(bonds) == 0 {
+		return valuations, nil
+	}
+
+	held := make([]string, 0, len(bonds))
+	for symbol := range bonds {
+		held = append(held, symbol)
+	}
+	schedules, err := s.couponsBySymbol(ctx, held)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT DISTINCT ON (q.symbol) q.symbol, q.date, q.clean_price, q.yield, q.source, q.created_at,
+			COALESCE(q.updated_at, q.created_at)
+		FROM bond_quotes q
+		LEFT JOIN sources s ON s.name = q.source
+		WHERE q.symbol = ANY($1) AND q.date <= $2
+		ORDER BY q.symbol, q.date DESC, COALESCE(s.priority, 100)
+	`, held, date)
+	if err != nil {
+		s.logger.Error("Failed to get latest bond quotes", zap.Strings("symbols", held), zap.Error(err))
+		return nil, err
+	}
+	quotes, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.BondQuote])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	latest := make(map[string]models.BondQuote, len(quotes))
+	for _, q := range quotes {
+		latest[q.Symbol] = q
+	}
+
+	for symbol, bond := range bonds {
+		coupons := schedules[symbol]
+		v := models.BondValuation{
+			Symbol:      symbol,
+			Date:        date,
+			CleanPrice:  parPrice,
+			PriceSource: "par",
+		}
+		if q, ok := latest[symbol]; ok {
+			yield := q.Yield
+			v.CleanPrice = q.CleanPrice
+			v.Yield = &yield
+			v.PriceSource = q.Source
+		}
+		v.AccruedInterest = math.Round(AccruedInterest(bond, coupons, date)*1e6) / 1e6
+		v.DirtyPrice = v.CleanPrice + v.AccruedInterest
+		for i := range coupons {
+			if coupons[i].PayDate.After(date) {
+				next := coupons[i]
+				v.NextCoupon = &next
+				break
+			}
+		}
+		valuations[symbol] = v
+	}
+
+	return valuations, nil
+}
+
+func (s *BondService) bondsBySymbol(ctx context.Context, symbols []string) (map[string]models.Bond, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+bondColumns+` FROM bonds WHERE symbol = ANY($1)`, symbols)
+	if err != nil {
+		s.logger.Error("Failed to get bonds", zap.Strings("symbols", symbols), zap.Error(err))
+		return nil, err
+	}
+	list, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Bond])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	bonds := make(map[string]models.Bond, len(list))
+	for _, b := range list {
+		bonds[b.Symbol] = b
+	}
+	return bonds, nil
+}
+
+func (s *BondService) couponsBySymbol(ctx context.Context, symbols []string) (map[string][]models.Coupon, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+couponColumns+` FROM bond_coupons
+		WHERE symbol = ANY($1)
+		ORDER BY symbol, period_end
+	`, symbols)
+	if err != nil {
+		s.logger.Error("Failed to get coupons", zap.Strings("symbols", symbols), zap.Error(err))
+		return nil, err
+	}
+	list, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Coupon])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	schedules := make(map[string][]models.Coupon)
+	for _, c := range list {
+		schedules[c.Symbol] = append(schedules[c.Symbol], c)
+	}
+	return schedules, nil
+}
+
+func bondFromRequest(req models.BondRequest) (models.Bond, error) {
+	issue, err := time.Parse("2006-01-02", req.IssueDate)
+	if err != nil {
+		return models.Bond{}, fmt.Errorf("%w: issue_date %q, use YYYY-MM-DD", ErrInvalidBond, req.IssueDate)
+	}
+	maturity, err := time.Parse("2006-01-02", req.MaturityDate)
+	if err != nil {
+		return models.Bond{}, fmt.Errorf("%w: maturity_date %q, use YYYY-MM-DD", ErrInvalidBond, req.MaturityDate)
+	}
+	if !maturity.After(issue) {
+		return models.Bond{}, fmt.Errorf("%w: maturity_date must be after issue_date", ErrInvalidBond)
+	}
+
+	bond := models.Bond{
+		Symbol:          strings.ToUpper(strings.TrimSpace(req.Symbol)),
+		Name:            req.Name,
+		Issuer:          req.Issuer,
+		Currency:        strings.ToUpper(req.Currency),
+		CouponType:      req.CouponType,
+		CouponRate:      req.CouponRate,
+		CouponFrequency: req.CouponFrequency,
+		DayCount:        req.DayCount,
+		IssueDate:       issue,
+		MaturityDate:    maturity,
+		Tradable:        req.Tradable == nil || *req.Tradable,
+	}
+	if bond.Currency == "" {
+		bond.Currency = "IDR"
+	}
+	if bond.CouponType == "" {
+		bond.CouponType = models.CouponFixed
+	}
+	if bond.DayCount == "" {
+		bond.DayCount = models.DayCountActAct
+	}
+	return bond, nil
+}
+
+// GenerateCoupons builds a bond's schedule at its coupon rate, stepping back from
+// maturity so that any short (stub) period comes first
+func GenerateCoupons(bond models.Bond) []models.Coupon {
+	step := 12 / bond.CouponFrequency
+	var coupons []models.Coupon
+	for k := 0; ; k++ {
+		end := addMonths(bond.MaturityDate, -k*step)
+		if !end.After(bond.IssueDate) {
+			break
+		}
+		start := addMonths(bond.MaturityDate, -(k+1)*step)
+		if start.Before(bond.IssueDate) {
+			start = bond.IssueDate
+		}
+		coupons = append(coupons, models.Coupon{
+			Symbol:      bond.Symbol,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			PayDate:     end,
+			Rate:        bond.CouponRate,
+			Amount:      couponAmount(bond, bond.CouponRate, start, end),
+		})
+	}
+
+	for i, j := 0, len(coupons)-1; i < j; i, j = i+1, j-1 {
+		coupons[i], coupons[j] = coupons[j], coupons[i]
+	}
+	return coupons
+}
+
+// couponAmount is what a period pays per 100 of face value at an annual rate in percent.
+// Under ACT/ACT a full period pays rate/frequency and a stub its share of the days.
+func couponAmount(bond models.Bond, rate float64, start, end time.Time) float64 {
+	var amount float64
+	switch bond.DayCount {
+	case models.DayCountAct365:
+		amount = rate * days(start, end) / 365
+	case models.DayCount30360:
+		amount = rate * days360(start, end) / 360
+	default:
+		nominal := addMonths(end, -12/bond.CouponFrequency)
+		amount = rate / float64(bond.CouponFrequency) * days(start, end) / days(nominal, end)
+	}
+	return math.Round(amount*1e10) / 1e10
+}
+
+// AccruedInterest is the interest earned per 100 of face value since the start of the
+// coupon period containing date
+func AccruedInterest(bond models.Bond, coupons []models.Coupon, date time.Time) float64 {
+	for _, c := range coupons {
+		if date.Before(c.PeriodStart) || !date.Before(c.PeriodEnd) {
+			continue
+		}
+		switch bond.DayCount {
+		case models.DayCountAct365:
+			return c.Rate * days(c.PeriodStart, date) / 365
+		case models.DayCount30360:
+			return c.Rate * days360(c.PeriodStart, date) / 360
+		default:
+			return c.Amount * days(c.PeriodStart, date) / days(c.PeriodStart, c.PeriodEnd)
+		}
+	}
+	return 0
+}
+
+// dirtyPrice discounts the coupons still to be paid after date, and par at maturity,
+// at an annual yield in percent compounded at the coupon frequency
+func dirtyPrice(bond models.Bond, coupons []models.Coupon, date time.Time, yield float64) (float64, error) {
+	f := float64(bond.CouponFrequency)
+	first := -1
+	for i, c := range coupons {
+		if c.PeriodEnd.After(date) {
+			first = i
+			break
+		}
+	}
+	if first < 0 || !bond.MaturityDate.After(date) {
+		return 0, ErrBondMatured
+	}
+
+	current := coupons[first]
+	start := current.PeriodStart
+	if start.After(date) {
+		start = date
+	}
+	// Fraction of the current period left until its payment
+	w := days(date, current.PeriodEnd) / days(start, current.PeriodEnd)
+
+	discount := 1 + yield/100/f
+	var price float64
+	for i, c := range coupons[first:] {
+		cash := c.Amount
+		if first+i == len(coupons)-1 {
+			cash += parPrice
+		}
+		price += cash / math.Pow(discount, w+float64(i))
+	}
+	return price, nil
+}
+
+// PriceFromYield returns the clean price, in percent of par, at an annual yield in percent
+func PriceFromYield(bond models.Bond, coupons []models.Coupon, date time.Time, yield float64) (float64, error) {
+	if yield/100/float64(bond.CouponFrequency) <= -1 {
+		return 0, fmt.Errorf("%w: yield %.4f is too low", ErrInvalidBondQuote, yield)
+	}
+	dirty, err := dirtyPrice(bond, coupons, date, yield)
+	if err != nil {
+		return 0, err
+	}
+	return dirty - AccruedInterest(bond, coupons, date), nil
+}
+
+// YieldFromPrice solves for the annual yield in percent that discounts the remaining
+// payments to the clean price, by bisection since price falls as yield rises
+func YieldFromPrice(bond models.Bond, coupons []models.Coupon, date time.Time, cleanPrice float64) (float64, error) {
+	price := func(y float64) (float64, error) { return PriceFromYield(bond, coupons, date, y) }
+
+	lo, hi := -50.0, 200.0
+	pLo, err := price(lo)
+	if err != nil {
+		return 0, err
+	}
+	pHi, err := price(hi)
+	if err != nil {
+		return 0, err
+	}
+	if cleanPrice > pLo || cleanPrice < pHi {
+		return 0, fmt.Errorf("%w: no yield between %.0f%% and %.0f%% gives a clean price of %.4f", ErrInvalidBondQuote, lo, hi, cleanPrice)
+	}
+
+	for i := 0; i < 200 && hi-lo > 1e-9; i++ {
+		mid := (lo + hi) / 2
+		p, err := price(mid)
+		if err != nil {
+			return 0, err
+		}
+		if p > cleanPrice {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2, nil
+}
+
+// addMonths moves t by n months, clamping to the last day of the target month
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+func days(from, to time.Time) float64 {
+	return to.Sub(from).Hours() / 24
+}
+
+// days360 counts days under the 30/360 (bond basis) convention
+func days360(from, to time.Time) float64 {
+	d1, d2 := from.Day(), to.Day()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+	return float64(360*(to.Year()-from.Year()) + 30*(int(to.Month())-int(from.Month())) + d2 - d1)
+}
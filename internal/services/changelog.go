@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ErrReleaseNotFound is returned when marking a version the changelog does not list
+var ErrReleaseNotFound = errors.New("release not found")
+
+// ChangelogService serves the release notes and remembers, per user, the newest
+// release they have seen so clients can show what is new after a deploy
+type ChangelogService struct {
+	db       *database.DB
+	releases []models.Release // newest first
+	index    map[string]int   // version to its position in releases
+	logger   *zap.Logger
+}
+
+// NewChangelogService creates the service for release notes ordered newest first
+func NewChangelogService(db *database.DB, releases []models.Release) *ChangelogService {
+	index := make(map[string]int, len(releases))
+	for i, r := range releases {
+		index[r.Version] = i
+	}
+	return &ChangelogService{
+		db:       db,
+		releases: releases,
+		index:    index,
+		logger:   logger.With(zap.String("service", "changelog")),
+	}
+}
+
+// seenMark is the newest release a user has marked seen
+type seenMark struct {
+	version string
+	seenAt  time.Time
+}
+
+// Get returns the release notes, newest first, with which ones userID has seen.
+// An empty userID leaves seen out. With unseenOnly, only releases after the last one
+// seen are returned.
+func (s *ChangelogService) Get(ctx context.Context, userID string, unseenOnly bool) (models.Changelog, error) {
+	var mark *seenMark
+	if userID != "" {
+		var err error
+		if mark, err = s.seen(ctx, userID); err != nil {
+			return models.Changelog{}, err
+		}
+	}
+	return s.changelog(userID != "", mark, unseenOnly), nil
+}
+
+// MarkSeen records that userID has seen every release up to and including version,
+// the latest when empty. A newer release already marked seen is kept.
+func (s *ChangelogService) MarkSeen(ctx context.Context, userID, version string) (models.Changelog, error) {
+	if len(s.releases) == 0 {
+		return models.Changelog{}, ErrReleaseNotFound
+	}
+	if version == "" {
+		version = s.releases[0].Version
+	}
+	i, ok := s.index[version]
+	if !ok {
+		return models.Changelog{}, ErrReleaseNotFound
+	}
+
+	current, err := s.seen(ctx, userID)
+	if err != nil {
+		return models.Changelog{}, err
+	}
+	if current != nil {
+		if j, ok := s.index[current.version]; ok && j <= i {
+			return s.changelog(true, current, false), nil
+		}
+	}
+
+	mark := &seenMark{version: version, seenAt: time.Now().UTC()}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO changelog_seen (user_id, version, seen_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET version = EXCLUDED.version, seen_at = EXCLUDED.seen_at
+	`, userID, mark.version, mark.seenAt)
+	if err != nil {
+		s.logger.Error("Failed to mark changelog seen",
+			zap.String("user_id", userID),
+			zap.String("version", version),
+			zap.Error(err),
+		)
+		return models.Changelog{}, err
+	}
+	return s.changelog(true, mark, false), nil
+}
+
+// seen loads the newest release userID has marked seen, nil when none
+func (s *ChangelogService) seen(ctx context.Context, userID string) (*seenMark, error) {
+	var mark seenMark
+	err := s.db.QueryRow(ctx, `
+		SELECT version, seen_at FROM changelog_seen WHERE user_id = $1
+	`, userID).Scan(&mark.version, &mark.seenAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		s.logger.Error("Failed to load changelog seen mark", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return &mark, nil
+}
+
+// changelog builds the response for a user's seen mark. A mark naming a version no
+// longer listed counts releases dated after it was set as unseen.
+func (s *ChangelogService) changelog(withSeen bool, mark *seenMark, unseenOnly bool) models.Changelog {
+	result := models.Changelog{Releases: []models.ChangelogEntry{}}
+	if len(s.releases) > 0 {
+		result.LatestVersion = s.releases[0].Version
+	}
+	if mark != nil {
+		result.SeenVersion = mark.version
+		result.SeenAt = &mark.seenAt
+	}
+
+	for i, r := range s.releases {
+		entry := models.ChangelogEntry{Release: r}
+		if withSeen {
+			seen := false
+			if mark != nil {
+				if j, ok := s.index[mark.version]; ok {
+					seen = i >= j
+				} else {
+					seen = !r.ReleasedAt.After(mark.seenAt)
+				}
+			}
+			entry.Seen = &seen
+			if !seen {
+				result.Unseen++
+			} else if unseenOnly {
+				continue
+			}
+		}
+		result.Releases = append(result.Releases, entry)
+	}
+	return result
+}
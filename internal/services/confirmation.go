@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ErrConfirmationInvalid is returned when a confirmation token is unknown, expired,
+// or was issued for a different user, action or target
+var ErrConfirmationInvalid = errors.New("confirmation token is invalid or expired")
+
+const confirmationTTL = 2 * time.Minute
+
+// Destructive actions guarded by a confirmation token
+const (
+	ActionDeleteMarketData      = "delete_market_data"
+	ActionDeleteMarketDataRange = "delete_market_data_range"
+)
+
+type ConfirmationService struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewConfirmationService(db *database.DB) *ConfirmationService {
+	return &ConfirmationService{
+		db:     db,
+		logger: logger.With(zap.String("service", "confirmation")),
+	}
+}
+
+// Issue creates a single-use token binding userID to one action on one target
+func (s *ConfirmationService) Issue(ctx context.Context, userID, action, target string, rowsAffected int64) (*models.Confirmation, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+	expiresAt := time.Now().Add(confirmationTTL)
+
+	query := `
+		INSERT INTO confirmation_tokens (token_hash, user_id, action, target, rows_affected, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if _, err := s.db.Exec(ctx, query, hashToken(token), userID, action, target, rowsAffected, expiresAt); err != nil {
+		s.logger.Error("Failed to issue confirmation token",
+			zap.String("user_id", userID),
+			zap.String("action", action),
+			zap.String("target", target),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return &models.Confirmation{
+		Token:        token,
+		Action:       action,
+		Target:       target,
+		RowsAffected: rowsAffected,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// Consume redeems a token; it succeeds at most once and only for the same user, action and target
+func (s *ConfirmationService) Consume(ctx context.Context, token, userID, action, target string) error {
+	query := `
+		DELETE FROM confirmation_tokens
+		WHERE token_hash = $1 AND user_id = $2 AND action = $3 AND target = $4
+			AND expires_at > CURRENT_TIMESTAMP
+	`
+
+	cmdTag, err := s.db.Exec(ctx, query, hashToken(token), userID, action, target)
+	if err != nil {
+		s.logger.Error("Failed to consume confirmation token",
+			zap.String("user_id", userID),
+			zap.String("action", action),
+			zap.String("target", target),
+			zap.Error(err),
+		)
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrConfirmationInvalid
+	}
+
+	return nil
+}
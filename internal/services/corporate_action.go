@@ -0,0 +1,669 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrActionNotFound      = errors.New("corporate action not found")
+	ErrInvalidAction       = errors.New("invalid corporate action")
+	ErrActionNotReviewable = errors.New("corporate action is no longer awaiting review")
+)
+
+// cumPriceLookback is how far before the ex-date the last cum-rights close is searched for
+const cumPriceLookback = 14
+
+type CorporateActionService struct {
+	db     *database.DB
+	market *MarketService
+	outbox *OutboxService
+	logger *zap.Logger
+}
+
+func NewCorporateActionService(db *database.DB, market *MarketService, outbox *OutboxService) *CorporateActionService {
+	return &CorporateActionService{
+		db:     db,
+		market: market,
+		outbox: outbox,
+		logger: logger.With(zap.String("service", "corporate_action")),
+	}
+}
+
+const actionColumns = `id, symbol, action_type, ex_date, ratio_old, ratio_new, exercise_price, entitlement_symbol,
+	amount, cum_price, status, review_reasons, note, created_by, reviewed_by, created_at, COALESCE(updated_at, created_at),
+	reviewed_at, applied_at`
+
+// Create records an announced action. Actions that pass every check are scheduled
+// and applied once their ex-date arrives; the rest wait for review.
+func (s *CorporateActionService) Create(ctx context.Context, userID string, req models.CorporateActionRequest) (*models.CorporateAction, error) {
+	exDate, err := time.Parse("2006-01-02", req.ExDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ex_date %q, use YYYY-MM-DD", ErrInvalidAction, req.ExDate)
+	}
+	action := models.CorporateAction{
+		Symbol:            strings.ToUpper(strings.TrimSpace(req.Symbol)),
+		ActionType:        req.ActionType,
+		ExDate:            exDate,
+		RatioOld:          req.RatioOld,
+		RatioNew:          req.RatioNew,
+		ExercisePrice:     req.ExercisePrice,
+		EntitlementSymbol: strings.ToUpper(strings.TrimSpace(req.EntitlementSymbol)),
+		Amount:            req.Amount,
+		CumPrice:          req.CumPrice,
+		Note:              req.Note,
+		CreatedBy:         userID,
+	}
+	if err := checkAction(&action); err != nil {
+		return nil, err
+	}
+
+	if action.CumPrice == nil && exDateArrived(action.ExDate) {
+		if action.CumPrice, err = s.cumPrice(ctx, action.Symbol, action.ExDate); err != nil {
+			return nil, err
+		}
+	}
+	action.ReviewReasons, err = s.review(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+	action.Status = models.ActionScheduled
+	if len(action.ReviewReasons) > 0 {
+		action.Status = models.ActionPendingReview
+	}
+
+	created, err := scanAction(s.db.QueryRow(ctx, `
+		INSERT INTO corporate_actions (symbol, action_type, ex_date, ratio_old, ratio_new, exercise_price,
+			entitlement_symbol, amount, cum_price, status, review_reasons, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING `+actionColumns,
+		action.Symbol, action.ActionType, action.ExDate, action.RatioOld, action.RatioNew, action.ExercisePrice,
+		action.EntitlementSymbol, action.Amount, action.CumPrice, action.Status, action.ReviewReasons, action.Note,
+		action.CreatedBy,
+	))
+	if err != nil {
+		s.logger.Error("Failed to create corporate action", zap.String("symbol", action.Symbol), zap.Error(err))
+		return nil, err
+	}
+
+	return s.applyIfDue(ctx, created)
+}
+
+// checkAction rejects fields that do not fit the action's type. Dividends are
+// recorded with a 1:1 ratio.
+func checkAction(action *models.CorporateAction) error {
+	if action.ActionType == models.ActionDividend {
+		if action.Amount <= 0 {
+			return fmt.Errorf("%w: a dividend needs an amount per share", ErrInvalidAction)
+		}
+		action.RatioOld, action.RatioNew = 1, 1
+	} else {
+		if action.Amount != 0 {
+			return fmt.Errorf("%w: amount only applies to dividends", ErrInvalidAction)
+		}
+		if action.RatioOld <= 0 || action.RatioNew <= 0 {
+			return fmt.Errorf("%w: ratio_old and ratio_new are required", ErrInvalidAction)
+		}
+	}
+	if action.ActionType == models.ActionSplit && action.RatioOld == action.RatioNew {
+		return fmt.Errorf("%w: a split must change the number of shares", ErrInvalidAction)
+	}
+
+	if !entitles(action.ActionType) {
+		if action.EntitlementSymbol != "" {
+			return fmt.Errorf("%w: entitlement_symbol only applies to rights and warrants", ErrInvalidAction)
+		}
+		return nil
+	}
+	if action.EntitlementSymbol == action.Symbol {
+		return fmt.Errorf("%w: entitlement_symbol must differ from symbol", ErrInvalidAction)
+	}
+	return nil
+}
+
+// entitles reports whether an action type credits holders with a new symbol
+func entitles(actionType string) bool {
+	return actionType == models.ActionRights || actionType == models.ActionWarrant
+}
+
+// List returns actions newest ex-date first, optionally filtered by status and symbol
+func (s *CorporateActionService) List(ctx context.Context, status, symbol string) ([]models.CorporateAction, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+actionColumns+` FROM corporate_actions
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR symbol = $2)
+		ORDER BY ex_date DESC, id DESC
+		LIMIT 200
+	`, status, symbol)
+	if err != nil {
+		s.logger.Error("Failed to list corporate actions", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []models.CorporateAction{}
+	for rows.Next() {
+		action, err := scanAction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		actions = append(actions, *action)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return actions, nil
+}
+
+// Get returns one action
+func (s *CorporateActionService) Get(ctx context.Context, id int64) (*models.CorporateAction, error) {
+	action, err := scanAction(s.db.QueryRow(ctx, `SELECT `+actionColumns+` FROM corporate_actions WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrActionNotFound
+		}
+		s.logger.Error("Failed to get corporate action", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	return action, nil
+}
+
+// Adjustments returns what applying an action did to each holding
+func (s *CorporateActionService) Adjustments(ctx context.Context, id int64) ([]models.PortfolioAdjustment, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT a.id, a.action_id, ca.action_type, a.portfolio_id, a.symbol, a.quantity_before, a.quantity_after,
+			a.avg_price_before, a.avg_price_after, a.created_at
+		FROM portfolio_adjustments a
+		JOIN corporate_actions ca ON ca.id = a.action_id
+		WHERE a.action_id = $1
+		ORDER BY a.portfolio_id, a.symbol
+	`, id)
+	if err != nil {
+		s.logger.Error("Failed to get adjustments", zap.Int64("action_id", id), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.PortfolioAdjustment])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return adjustments, nil
+}
+
+// Approve schedules an action held for review, filling in the entitlement symbol or
+// cum price it lacked, and applies it straight away when its ex-date has passed
+func (s *CorporateActionService) Approve(ctx context.Context, reviewer string, id int64, req models.ReviewActionRequest) (*models.CorporateAction, error) {
+	action, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if action.Status != models.ActionPendingReview {
+		return nil, ErrActionNotReviewable
+	}
+
+	if symbol := strings.ToUpper(strings.TrimSpace(req.EntitlementSymbol)); symbol != "" {
+		action.EntitlementSymbol = symbol
+	}
+	if entitles(action.ActionType) && action.EntitlementSymbol == "" {
+		return nil, fmt.Errorf("%w: entitlement_symbol is required to approve", ErrInvalidAction)
+	}
+	if err := checkAction(action); err != nil {
+		return nil, err
+	}
+	if req.CumPrice != nil {
+		action.CumPrice = req.CumPrice
+	}
+	note := action.Note
+	if req.Note != "" {
+		note = req.Note
+	}
+
+	approved, err := scanAction(s.db.QueryRow(ctx, `
+		UPDATE corporate_actions
+		SET status = $2, entitlement_symbol = $3, cum_price = $4, note = $5, reviewed_by = $6,
+			reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = $7
+		RETURNING `+actionColumns,
+		id, models.ActionScheduled, action.EntitlementSymbol, action.CumPrice, note, reviewer, models.ActionPendingReview,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrActionNotReviewable
+		}
+		s.logger.Error("Failed to approve corporate action", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	return s.applyIfDue(ctx, approved)
+}
+
+// Reject discards an action that is awaiting review or not yet applied
+func (s *CorporateActionService) Reject(ctx context.Context, reviewer string, id int64, note string) (*models.CorporateAction, error) {
+	rejected, err := scanAction(s.db.QueryRow(ctx, `
+		UPDATE corporate_actions
+		SET status = $2, note = $3, reviewed_by = $4, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status IN ($5, $6)
+		RETURNING `+actionColumns,
+		id, models.ActionRejected, note, reviewer, models.ActionPendingReview, models.ActionScheduled,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, getErr := s.Get(ctx, id); getErr != nil {
+				return nil, getErr
+			}
+			return nil, ErrActionNotReviewable
+		}
+		s.logger.Error("Failed to reject corporate action", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	return rejected, nil
+}
+
+// Start applies scheduled actions as their ex-dates arrive until ctx is cancelled
+func (s *CorporateActionService) Start(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		s.applyDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *CorporateActionService) applyDue(ctx context.Context) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+actionColumns+` FROM corporate_actions
+		WHERE status = $1 AND ex_date <= CURRENT_DATE
+		ORDER BY ex_date, id
+	`, models.ActionScheduled)
+	if err != nil {
+		s.logger.Error("Failed to find due corporate actions", zap.Error(err))
+		return
+	}
+	var actions []models.CorporateAction
+	for rows.Next() {
+		action, err := scanAction(rows)
+		if err != nil {
+			rows.Close()
+			s.logger.Error("Failed to scan corporate action", zap.Error(err))
+			return
+		}
+		actions = append(actions, *action)
+	}
+	rows.Close()
+
+	for i := range actions {
+		if _, err := s.applyIfDue(ctx, &actions[i]); err != nil {
+			s.logger.Error("Failed to apply corporate action", zap.Int64("id", actions[i].ID), zap.Error(err))
+		}
+	}
+}
+
+// applyIfDue applies a scheduled action whose ex-date has arrived. A rights issue
+// whose cum price still cannot be found goes back to review instead.
+func (s *CorporateActionService) applyIfDue(ctx context.Context, action *models.CorporateAction) (*models.CorporateAction, error) {
+	if action.Status != models.ActionScheduled || !exDateArrived(action.ExDate) {
+		return action, nil
+	}
+
+	if action.ActionType == models.ActionRights && action.CumPrice == nil {
+		cum, err := s.cumPrice(ctx, action.Symbol, action.ExDate)
+		if err != nil {
+			return nil, err
+		}
+		if cum == nil {
+			if _, err := s.db.Exec(ctx, `
+				UPDATE corporate_actions SET status = $2, review_reasons = $3
+				WHERE id = $1 AND status = $4
+			`, action.ID, models.ActionPendingReview, []string{reasonNoCumPrice(action.Symbol)}, models.ActionScheduled); err != nil {
+				s.logger.Error("Failed to return corporate action to review", zap.Int64("id", action.ID), zap.Error(err))
+				return nil, err
+			}
+			return s.Get(ctx, action.ID)
+		}
+		action.CumPrice = cum
+	}
+
+	var adjusted int
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		// Lock the action so concurrent workers apply it once
+		var status string
+		if err := tx.QueryRow(ctx, `SELECT status FROM corporate_actions WHERE id = $1 FOR UPDATE`, action.ID).Scan(&status); err != nil {
+			return fmt.Errorf("failed to lock action: %w", err)
+		}
+		if status != models.ActionScheduled {
+			return nil
+		}
+
+		var err error
+		if adjusted, err = applyAction(ctx, tx, *action); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE corporate_actions SET status = $2, cum_price = $3, applied_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`, action.ID, models.ActionApplied, action.CumPrice)
+		if err != nil {
+			return err
+		}
+		return s.announceApplied(ctx, tx, *action, adjusted)
+	})
+	if err != nil {
+		s.logger.Error("Failed to apply corporate action", zap.Int64("id", action.ID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Corporate action applied",
+		zap.Int64("id", action.ID),
+		zap.String("symbol", action.Symbol),
+		zap.String("type", action.ActionType),
+		zap.Int("adjustments", adjusted),
+	)
+	return s.Get(ctx, action.ID)
+}
+
+// announceApplied enqueues the portfolio events an applied action recorded, then the
+// action itself
+func (s *CorporateActionService) announceApplied(ctx context.Context, tx pgx.Tx, action models.CorporateAction, adjusted int) error {
+	if !s.outbox.Enabled() {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, portfolio_id, event_type, symbol, side, quantity, price, amount, action_id, occurred_at, recorded_at
+		FROM portfolio_events
+		WHERE action_id = $1
+		ORDER BY id
+	`, action.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load action events: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.PortfolioEvent])
+	if err != nil {
+		return fmt.Errorf("failed to collect rows: %w", err)
+	}
+	for _, e := range events {
+		if err := s.outbox.Enqueue(ctx, tx, models.TopicPortfolioEvent, portfolioKey(e.PortfolioID), e); err != nil {
+			return err
+		}
+	}
+
+	action.Status = models.ActionApplied
+	return s.outbox.Enqueue(ctx, tx, models.TopicCorporateActionApplied, "corporate_action:"+action.Symbol,
+		models.CorporateActionApplied{Action: action, Adjustments: adjusted})
+}
+
+// heldPosition is one portfolio's holding of a symbol
+type heldPosition struct {
+	quantity, avgPrice float64
+}
+
+// applyAction changes the holdings an action affects, recording each change as an
+// adjustment and a portfolio event. Dividends leave holdings as they are.
+func applyAction(ctx context.Context, tx pgx.Tx, action models.CorporateAction) (int, error) {
+	switch action.ActionType {
+	case models.ActionDividend:
+		return 0, nil
+	case models.ActionSplit:
+		return applySplit(ctx, tx, action)
+	}
+	return applyEntitlement(ctx, tx, action)
+}
+
+// lockHolders locks the portfolios holding symbol before their holdings, as recording
+// a portfolio event does, so a replay cannot write back positions from before an action
+func lockHolders(ctx context.Context, tx pgx.Tx, symbol string) error {
+	if _, err := tx.Exec(ctx, `
+		SELECT id FROM portfolios
+		WHERE id IN (SELECT portfolio_id FROM portfolio_holdings WHERE symbol = $1)
+		ORDER BY id
+		FOR UPDATE
+	`, symbol); err != nil {
+		return fmt.Errorf("failed to lock portfolios: %w", err)
+	}
+	return nil
+}
+
+// holdingsOf locks and returns every portfolio's holding of symbol
+func holdingsOf(ctx context.Context, tx pgx.Tx, symbol string) (map[int64]heldPosition, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT portfolio_id, quantity, avg_price FROM portfolio_holdings
+		WHERE symbol = $1
+		ORDER BY portfolio_id
+		FOR UPDATE
+	`, symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := map[int64]heldPosition{}
+	for rows.Next() {
+		var id int64
+		var p heldPosition
+		if err := rows.Scan(&id, &p.quantity, &p.avgPrice); err != nil {
+			return nil, err
+		}
+		positions[id] = p
+	}
+	return positions, rows.Err()
+}
+
+const (
+	adjustmentInsert = `
+		INSERT INTO portfolio_adjustments (action_id, portfolio_id, symbol, quantity_before, quantity_after,
+			avg_price_before, avg_price_after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	actionEventInsert = `
+		INSERT INTO portfolio_events (portfolio_id, event_type, symbol, quantity, price, action_id, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+)
+
+// applySplit multiplies every holding of the symbol by ratio_new/ratio_old and divides
+// its average price by the same, keeping the cost basis
+func applySplit(ctx context.Context, tx pgx.Tx, action models.CorporateAction) (int, error) {
+	if err := lockHolders(ctx, tx, action.Symbol); err != nil {
+		return 0, err
+	}
+	holders, err := holdingsOf(ctx, tx, action.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load holdings: %w", err)
+	}
+
+	ratio := float64(action.RatioNew) / float64(action.RatioOld)
+	now := time.Now().UTC()
+	batch := &pgx.Batch{}
+	for portfolioID, held := range holders {
+		quantity := roundAmount(held.quantity * ratio)
+		avg := roundHoldingPrice(held.avgPrice / ratio)
+		batch.Queue(`UPDATE portfolio_holdings SET quantity = $3, avg_price = $4 WHERE portfolio_id = $1 AND symbol = $2`,
+			portfolioID, action.Symbol, quantity, avg)
+		batch.Queue(adjustmentInsert, action.ID, portfolioID, action.Symbol, held.quantity, quantity, held.avgPrice, avg)
+		batch.Queue(actionEventInsert, portfolioID, models.EventCorporateAction, action.Symbol, quantity, avg, action.ID, now)
+	}
+
+	if batch.Len() > 0 {
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return 0, fmt.Errorf("failed to adjust holdings: %w", err)
+		}
+	}
+	return len(holders), nil
+}
+
+// applyEntitlement credits every holder of the parent symbol with entitlements, moving
+// the rights' share of cost basis from the parent onto them
+func applyEntitlement(ctx context.Context, tx pgx.Tx, action models.CorporateAction) (int, error) {
+	if err := lockHolders(ctx, tx, action.Symbol); err != nil {
+		return 0, err
+	}
+
+	parents, err := holdingsOf(ctx, tx, action.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load holdings: %w", err)
+	}
+	existing, err := holdingsOf(ctx, tx, action.EntitlementSymbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load entitlement holdings: %w", err)
+	}
+
+	// The entitlement trades on the parent's exchange
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO symbols (exchange, symbol, name, currency, lot_size)
+		SELECT exchange, $2, TRIM(name || ' ' || $3), currency, lot_size FROM symbols WHERE symbol = $1
+		ON CONFLICT DO NOTHING
+	`, action.Symbol, action.EntitlementSymbol, action.ActionType); err != nil {
+		return 0, fmt.Errorf("failed to list entitlement: %w", err)
+	}
+
+	fraction := costFraction(action)
+	now := time.Now().UTC()
+	batch := &pgx.Batch{}
+	adjusted := 0
+	for portfolioID, parent := range parents {
+		entitled := math.Floor(parent.quantity * float64(action.RatioNew) / float64(action.RatioOld))
+		if entitled <= 0 {
+			continue
+		}
+
+		moved := parent.quantity * parent.avgPrice * fraction
+		if moved > 0 {
+			avg := roundHoldingPrice(parent.avgPrice * (1 - fraction))
+			batch.Queue(`UPDATE portfolio_holdings SET avg_price = $3 WHERE portfolio_id = $1 AND symbol = $2`,
+				portfolioID, action.Symbol, avg)
+			batch.Queue(adjustmentInsert, action.ID, portfolioID, action.Symbol, parent.quantity, parent.quantity, parent.avgPrice, avg)
+			batch.Queue(actionEventInsert, portfolioID, models.EventCorporateAction, action.Symbol, parent.quantity, avg, action.ID, now)
+			adjusted++
+		}
+
+		before := existing[portfolioID]
+		quantity := before.quantity + entitled
+		avg := roundHoldingPrice((before.quantity*before.avgPrice + moved) / quantity)
+		batch.Queue(`
+			INSERT INTO portfolio_holdings (portfolio_id, symbol, quantity, avg_price)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (portfolio_id, symbol) DO UPDATE SET
+				quantity = EXCLUDED.quantity,
+				avg_price = EXCLUDED.avg_price
+		`, portfolioID, action.EntitlementSymbol, quantity, avg)
+		batch.Queue(adjustmentInsert, action.ID, portfolioID, action.EntitlementSymbol, before.quantity, quantity, before.avgPrice, avg)
+		batch.Queue(actionEventInsert, portfolioID, models.EventCorporateAction, action.EntitlementSymbol, quantity, avg, action.ID, now)
+		adjusted++
+	}
+
+	if batch.Len() > 0 {
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return 0, fmt.Errorf("failed to adjust holdings: %w", err)
+		}
+	}
+	return adjusted, nil
+}
+
+// costFraction is the share of a parent holding's cost basis that moves to the rights:
+// their theoretical value relative to the cum-rights price. Warrants, rights without a
+// cum price and rights priced at or above it carry no cost.
+func costFraction(action models.CorporateAction) float64 {
+	if action.ActionType != models.ActionRights || action.CumPrice == nil {
+		return 0
+	}
+	cum, exercise := *action.CumPrice, action.ExercisePrice
+	if cum <= 0 || exercise >= cum {
+		return 0
+	}
+	ratio := float64(action.RatioNew) / float64(action.RatioOld)
+	terp := (cum + ratio*exercise) / (1 + ratio)
+	return ratio * (terp - exercise) / cum
+}
+
+// review lists what makes an action ambiguous enough to need an admin's approval
+func (s *CorporateActionService) review(ctx context.Context, action models.CorporateAction) ([]string, error) {
+	reasons := []string{}
+
+	var listed, clashes bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM symbols WHERE symbol = $1),
+			EXISTS (SELECT 1 FROM corporate_actions WHERE symbol = $1 AND ex_date = $2 AND status <> $3)
+	`, action.Symbol, action.ExDate, models.ActionRejected).Scan(&listed, &clashes)
+	if err != nil {
+		s.logger.Error("Failed to review corporate action", zap.String("symbol", action.Symbol), zap.Error(err))
+		return nil, err
+	}
+
+	if !listed {
+		reasons = append(reasons, fmt.Sprintf("%s is not a listed symbol", action.Symbol))
+	}
+	if clashes {
+		reasons = append(reasons, fmt.Sprintf("another corporate action on %s shares this ex-date, so the order they apply in is unclear", action.Symbol))
+	}
+	if entitles(action.ActionType) && action.EntitlementSymbol == "" {
+		reasons = append(reasons, "no entitlement_symbol to credit holders with")
+	}
+	if action.ActionType == models.ActionRights {
+		switch {
+		case action.CumPrice == nil && exDateArrived(action.ExDate):
+			reasons = append(reasons, reasonNoCumPrice(action.Symbol))
+		case action.CumPrice != nil && action.ExercisePrice >= *action.CumPrice:
+			reasons = append(reasons, "exercise price is not below the cum price, so the rights carry no value")
+		}
+	}
+	return reasons, nil
+}
+
+// cumPrice returns the parent's last daily close before the ex-date, or nil when none
+// is stored
+func (s *CorporateActionService) cumPrice(ctx context.Context, symbol string, exDate time.Time) (*float64, error) {
+	data, err := s.market.GetBySymbolsAndDateRange(ctx, []string{symbol}, SourceAny, models.IntervalDaily,
+		exDate.AddDate(0, 0, -cumPriceLookback), exDate.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	last := data[len(data)-1].Close
+	return &last, nil
+}
+
+func reasonNoCumPrice(symbol string) string {
+	return fmt.Sprintf("no %s close before the ex-date to allocate cost basis; approve with cum_price", symbol)
+}
+
+// exDateArrived reports whether an ex-date has arrived
+func exDateArrived(exDate time.Time) bool {
+	return !exDate.After(time.Now().UTC().Truncate(24 * time.Hour))
+}
+
+// roundHoldingPrice rounds to the four decimals holdings store
+func roundHoldingPrice(price float64) float64 {
+	return math.Round(price*1e4) / 1e4
+}
+
+func scanAction(row pgx.Row) (*models.CorporateAction, error) {
+	var a models.CorporateAction
+	err := row.Scan(
+		&a.ID, &a.Symbol, &a.ActionType, &a.ExDate, &a.RatioOld, &a.RatioNew, &a.ExercisePrice,
+		&a.EntitlementSymbol, &a.Amount, &a.CumPrice, &a.Status, &a.ReviewReasons, &a.Note, &a.CreatedBy, &a.ReviewedBy,
+		&a.CreatedAt, &a.UpdatedAt, &a.ReviewedAt, &a.AppliedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
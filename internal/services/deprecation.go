@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// deprecationFlushInterval is how often buffered call counts are written
+	deprecationFlushInterval = time.Minute
+	// maxUserAgentLength bounds the User-Agent kept per caller
+	maxUserAgentLength = 255
+)
+
+// DeprecationService holds the endpoints slated for removal and counts the clients
+// still calling them. Counts are buffered in memory and added to deprecated_calls
+// every minute, so recording a call never waits on the database.
+type DeprecationService struct {
+	db      *database.DB
+	routes  []models.Deprecation
+	index   map[string]int // method and route to the entry in routes
+	mu      sync.Mutex
+	pending map[deprecatedCall]*models.DeprecatedCaller
+	logger  *zap.Logger
+}
+
+type deprecatedCall struct {
+	method, route, userID, userAgent string
+}
+
+// NewDeprecationService creates the service for a fixed set of deprecated endpoints
+func NewDeprecationService(db *database.DB, deprecations []models.Deprecation) *DeprecationService {
+	index := make(map[string]int, len(deprecations))
+	for i, d := range deprecations {
+		index[d.Method+" "+d.Route] = i
+	}
+	return &DeprecationService{
+		db:      db,
+		routes:  deprecations,
+		index:   index,
+		pending: make(map[deprecatedCall]*models.DeprecatedCaller),
+		logger:  logger.With(zap.String("service", "deprecation")),
+	}
+}
+
+// List returns the deprecated endpoints
+func (s *DeprecationService) List() []models.Deprecation {
+	return s.routes
+}
+
+// Lookup returns the deprecation of an endpoint, and false when it is not deprecated
+func (s *DeprecationService) Lookup(method, route string) (models.Deprecation, bool) {
+	i, ok := s.index[method+" "+route]
+	if !ok {
+		return models.Deprecation{}, false
+	}
+	return s.routes[i], true
+}
+
+// Record counts a call to a deprecated endpoint. The first call from a client since
+// the last flush is also logged.
+func (s *DeprecationService) Record(method, route, userID, userAgent string) {
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+	now := time.Now().UTC()
+	key := deprecatedCall{method: method, route: route, userID: userID, userAgent: userAgent}
+
+	s.mu.Lock()
+	caller, seen := s.pending[key]
+	if !seen {
+		caller = &models.DeprecatedCaller{UserID: userID, UserAgent: userAgent, FirstSeenAt: now}
+		s.pending[key] = caller
+	}
+	caller.Calls++
+	caller.LastSeenAt = now
+	s.mu.Unlock()
+
+	if !seen {
+		s.logger.Warn("Deprecated endpoint called",
+			zap.String("method", method),
+			zap.String("route", route),
+			zap.String("user_id", userID),
+			zap.String("user_agent", userAgent),
+		)
+	}
+}
+
+// Start writes buffered counts every minute until ctx is cancelled, then once more
+func (s *DeprecationService) Start(ctx context.Context) {
+	if len(s.routes) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(deprecationFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := s.flush(flushCtx); err != nil {
+				s.logger.Error("Failed to record deprecated calls", zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			if err := s.flush(ctx); err != nil {
+				s.logger.Error("Failed to record deprecated calls", zap.Error(err))
+			}
+		}
+	}
+}
+
+// flush adds the buffered counts to deprecated_calls. On failure they are kept for
+// the next flush.
+func (s *DeprecationService) flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[deprecatedCall]*models.DeprecatedCaller)
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	var methods, routes, userIDs, userAgents []string
+	var calls []int64
+	var firstSeen, lastSeen []time.Time
+	for key, caller := range pending {
+		methods = append(methods, key.method)
+		routes = append(routes, key.route)
+		userIDs = append(userIDs, key.userID)
+		userAgents = append(userAgents, key.userAgent)
+		calls = append(calls, caller.Calls)
+		firstSeen = append(firstSeen, caller.FirstSeenAt)
+		lastSeen = append(lastSeen, caller.LastSeenAt)
+	}
+
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO deprecated_calls (method, route, user_id, user_agent, calls, first_seen_at, last_seen_at)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::bigint[], $6::timestamp[], $7::timestamp[])
+		ON CONFLICT (method, route, user_id, user_agent) DO UPDATE SET
+			calls = deprecated_calls.calls + EXCLUDED.calls,
+			first_seen_at = LEAST(deprecated_calls.first_seen_at, EXCLUDED.first_seen_at),
+			last_seen_at = GREATEST(deprecated_calls.last_seen_at, EXCLUDED.last_seen_at)
+	`, methods, routes, userIDs, userAgents, calls, firstSeen, lastSeen)
+	if err != nil {
+		s.restore(pending)
+		return err
+	}
+	return nil
+}
+
+// restore merges counts that could not be written back into the buffer
+func (s *DeprecationService) restore(pending map[deprecatedCall]*models.DeprecatedCaller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, caller := range pending {
+		current, ok := s.pending[key]
+		if !ok {
+			s.pending[key] = caller
+			continue
+		}
+		current.Calls += caller.Calls
+		if caller.FirstSeenAt.Before(current.FirstSeenAt) {
+			current.FirstSeenAt = caller.FirstSeenAt
+		}
+	}
+}
+
+// Report returns every deprecated endpoint with the clients that called it, most
+// recent first, including calls not yet flushed
+func (s *DeprecationService) Report(ctx context.Context) ([]models.DeprecationUsage, error) {
+	usage := make([]models.DeprecationUsage, len(s.routes))
+	for i, d := range s.routes {
+		usage[i] = models.DeprecationUsage{Deprecation: d, Callers: []models.DeprecatedCaller{}}
+	}
+	if len(s.routes) == 0 {
+		return usage, nil
+	}
+
+	if err := s.flush(ctx); err != nil {
+		s.logger.Error("Failed to record deprecated calls", zap.Error(err))
+		return nil, fmt.Errorf("failed to record deprecated calls: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT method, route, user_id, user_agent, calls, first_seen_at, last_seen_at
+		FROM deprecated_calls
+		ORDER BY last_seen_at DESC, calls DESC
+	`)
+	if err != nil {
+		s.logger.Error("Failed to query deprecated calls", zap.Error(err))
+		return nil, fmt.Errorf("failed to query deprecated calls: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var method, route string
+		var caller models.DeprecatedCaller
+		if err := rows.Scan(&method, &route, &caller.UserID, &caller.UserAgent, &caller.Calls, &caller.FirstSeenAt, &caller.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deprecated call: %w", err)
+		}
+		// Endpoints no longer deprecated keep their rows but are not reported
+		i, ok := s.index[method+" "+route]
+		if !ok {
+			continue
+		}
+		usage[i].Calls += caller.Calls
+		usage[i].Callers = append(usage[i].Callers, caller)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read deprecated calls: %w", err)
+	}
+	return usage, nil
+}
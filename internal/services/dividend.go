@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrDividendNotFound = errors.New("dividend not found")
+	ErrInvalidDividend  = errors.New("invalid dividend")
+)
+
+type DividendService struct {
+	db         *database.DB
+	portfolios *PortfolioService
+	logger     *zap.Logger
+}
+
+func NewDividendService(db *database.DB, portfolios *PortfolioService) *DividendService {
+	return &DividendService{
+		db:         db,
+		portfolios: portfolios,
+		logger:     logger.With(zap.String("service", "dividend")),
+	}
+}
+
+const dividendColumns = `id, symbol, ex_date, record_date, payment_date, amount, currency, note, created_by,
+	created_at, COALESCE(updated_at, created_at)`
+
+// dividendCurrency falls back to the symbol's quote currency, then IDR, when a
+// dividend is recorded without one
+const dividendCurrency = `COALESCE(NULLIF($6, ''),
+	(SELECT currency FROM symbols WHERE symbol = $1 AND currency <> '' ORDER BY exchange LIMIT 1), 'IDR')`
+
+// List returns dividends going ex between from and to, optionally for one symbol
+func (s *DividendService) List(ctx context.Context, symbol string, from, to time.Time) ([]models.Dividend, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+dividendColumns+` FROM dividends
+		WHERE ex_date BETWEEN $1 AND $2 AND ($3 = '' OR symbol = $3)
+		ORDER BY ex_date, symbol
+		LIMIT 500
+	`, from, to, symbol)
+	if err != nil {
+		s.logger.Error("Failed to list dividends", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	dividends, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Dividend])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return dividends, nil
+}
+
+// Get returns one dividend
+func (s *DividendService) Get(ctx context.Context, id int64) (*models.Dividend, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+dividendColumns+` FROM dividends WHERE id = $1`, id)
+	if err != nil {
+		s.logger.Error("Failed to get dividend", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	dividend, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.Dividend])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDividendNotFound
+		}
+		return nil, fmt.Errorf("failed to collect row: %w", err)
+	}
+	return &dividend, nil
+}
+
+// Upsert records a dividend, replacing the one already announced for the same
+// symbol and ex-date
+func (s *DividendService) Upsert(ctx context.Context, userID string, req models.DividendRequest) (*models.Dividend, error) {
+	d, err := dividendFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		INSERT INTO dividends (symbol, ex_date, record_date, payment_date, amount, currency, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, `+dividendCurrency+`, $7, $8)
+		ON CONFLICT (symbol, ex_date) DO UPDATE SET
+			record_date = EXCLUDED.record_date,
+			payment_date = EXCLUDED.payment_date,
+			amount = EXCLUDED.amount,
+			currency = EXCLUDED.currency,
+			note = EXCLUDED.note
+		RETURNING `+dividendColumns,
+		d.Symbol, d.ExDate, d.RecordDate, d.PaymentDate, d.Amount, d.Currency, d.Note, userID,
+	)
+	if err != nil {
+		s.logger.Error("Failed to store dividend", zap.String("symbol", d.Symbol), zap.Error(err))
+		return nil, err
+	}
+	stored, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.Dividend])
+	if err != nil {
+		s.logger.Error("Failed to store dividend", zap.String("symbol", d.Symbol), zap.Error(err))
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// Update replaces every field of an existing dividend
+func (s *DividendService) Update(ctx context.Context, id int64, req models.DividendRequest) (*models.Dividend, error) {
+	d, err := dividendFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		UPDATE dividends SET symbol = $1, ex_date = $2, record_date = $3, payment_date = $4, amount = $5,
+			currency = `+dividendCurrency+`, note = $7
+		WHERE id = $8
+		RETURNING `+dividendColumns,
+		d.Symbol, d.ExDate, d.RecordDate, d.PaymentDate, d.Amount, d.Currency, d.Note, id,
+	)
+	if err != nil {
+		s.logger.Error("Failed to update dividend", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	updated, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.Dividend])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrDividendNotFound
+		case isUniqueViolation(err):
+			return nil, fmt.Errorf("%w: %s already has a dividend going ex on %s", ErrInvalidDividend,
+				d.Symbol, d.ExDate.Format("2006-01-02"))
+		}
+		s.logger.Error("Failed to update dividend", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Delete removes a dividend, e.g. one announced in error
+func (s *DividendService) Delete(ctx context.Context, id int64) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM dividends WHERE id = $1`, id)
+	if err != nil {
+		s.logger.Error("Failed to delete dividend", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDividendNotFound
+	}
+	return nil
+}
+
+// Projected estimates a portfolio's income from dividends going ex between from and
+// to, paying each on the portfolio's current quantity of the symbol
+func (s *DividendService) Projected(ctx context.Context, userID string, portfolioID int64, from, to time.Time) (*models.DividendProjection, error) {
+	p, err := s.portfolios.Get(ctx, userID, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	projection := &models.DividendProjection{
+		PortfolioID: portfolioID,
+		From:        from,
+		To:          to,
+		Dividends:   []models.ProjectedDividend{},
+		Totals:      map[string]float64{},
+	}
+	if len(p.Holdings) == 0 {
+		return projection, nil
+	}
+
+	held := make(map[string]float64, len(p.Holdings))
+	symbols := make([]string, 0, len(p.Holdings))
+	for _, h := range p.Holdings {
+		if h.Quantity > 0 {
+			held[h.Symbol] = h.Quantity
+			symbols = append(symbols, h.Symbol)
+		}
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT `+dividendColumns+` FROM dividends
+		WHERE symbol = ANY($1) AND ex_date BETWEEN $2 AND $3
+		ORDER BY ex_date, symbol
+	`, symbols, from, to)
+	if err != nil {
+		s.logger.Error("Failed to project dividends", zap.Int64("portfolio_id", portfolioID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	dividends, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Dividend])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	for _, d := range dividends {
+		quantity := held[d.Symbol]
+		income := roundAmount(quantity * d.Amount)
+		projection.Dividends = append(projection.Dividends, models.ProjectedDividend{
+			DividendID:  d.ID,
+			Symbol:      d.Symbol,
+			ExDate:      d.ExDate,
+			PaymentDate: d.PaymentDate,
+			Amount:      d.Amount,
+			Currency:    d.Currency,
+			Quantity:    quantity,
+			Income:      income,
+		})
+		projection.Totals[d.Currency] = roundAmount(projection.Totals[d.Currency] + income)
+	}
+	sort.SliceStable(projection.Dividends, func(i, j int) bool {
+		return payDate(projection.Dividends[i]).Before(payDate(projection.Dividends[j]))
+	})
+	return projection, nil
+}
+
+// payDate orders projected income by when it arrives, using the ex-date for
+// dividends whose payment date is not announced yet
+func payDate(d models.ProjectedDividend) time.Time {
+	if d.PaymentDate != nil {
+		return *d.PaymentDate
+	}
+	return d.ExDate
+}
+
+func dividendFromRequest(req models.DividendRequest) (*models.Dividend, error) {
+	exDate, err := time.Parse("2006-01-02", req.ExDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ex_date %q, use YYYY-MM-DD", ErrInvalidDividend, req.ExDate)
+	}
+	d := &models.Dividend{
+		Symbol:   strings.ToUpper(strings.TrimSpace(req.Symbol)),
+		ExDate:   exDate,
+		Amount:   req.Amount,
+		Currency: strings.ToUpper(req.Currency),
+		Note:     req.Note,
+	}
+	if d.RecordDate, err = optionalDate("record_date", req.RecordDate); err != nil {
+		return nil, err
+	}
+	if d.PaymentDate, err = optionalDate("payment_date", req.PaymentDate); err != nil {
+		return nil, err
+	}
+	if d.PaymentDate != nil && d.PaymentDate.Before(exDate) {
+		return nil, fmt.Errorf("%w: payment_date is before ex_date", ErrInvalidDividend)
+	}
+	return d, nil
+}
+
+func optionalDate(field, s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %q, use YYYY-MM-DD", ErrInvalidDividend, field, s)
+	}
+	return &d, nil
+}
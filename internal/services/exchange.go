@@ -0,0 +1,489 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// MaxCalendarDays caps how many days one calendar request covers
+const MaxCalendarDays = 366
+
+// statusLookahead is how far ahead Status searches for the next session
+const statusLookahead = 14
+
+// sessionLookback is how far back LastSession searches, enough to span the longest
+// holiday closures
+const sessionLookback = 14
+
+var (
+	ErrExchangeNotFound = errors.New("exchange not found")
+	ErrHolidayNotFound  = errors.New("holiday not found")
+	// ErrCalendarTooLong is returned for calendar ranges longer than MaxCalendarDays
+	ErrCalendarTooLong = errors.New("calendar range is too long")
+	// ErrHolidayOutsideYear is returned when an uploaded calendar lists a date of another year
+	ErrHolidayOutsideYear = errors.New("holiday is outside the calendar year")
+	// ErrDuplicateHoliday is returned when an uploaded calendar lists a date twice
+	ErrDuplicateHoliday = errors.New("holiday is listed more than once")
+)
+
+type ExchangeService struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewExchangeService(db *database.DB) *ExchangeService {
+	return &ExchangeService{
+		db:     db,
+		logger: logger.With(zap.String("service", "exchange")),
+	}
+}
+
+const exchangeColumns = `code, name, timezone, to_char(opens_at, 'HH24:MI'), to_char(closes_at, 'HH24:MI'),
+		COALESCE(to_char(pre_opens_at, 'HH24:MI'), ''), trading_days, asset_class, COALESCE(currency, ''), lot_size, created_at`
+
+// List returns every configured exchange
+func (s *ExchangeService) List(ctx context.Context) ([]models.Exchange, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+exchangeColumns+` FROM exchanges ORDER BY code`)
+	if err != nil {
+		s.logger.Error("Failed to list exchanges", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Exchange])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// Get returns one exchange, or ErrExchangeNotFound
+func (s *ExchangeService) Get(ctx context.Context, code string) (*models.Exchange, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+exchangeColumns+` FROM exchanges WHERE code = $1`, code)
+	if err != nil {
+		s.logger.Error("Failed to get exchange", zap.String("exchange", code), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	exchange, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.Exchange])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExchangeNotFound
+		}
+		return nil, fmt.Errorf("failed to collect row: %w", err)
+	}
+
+	return &exchange, nil
+}
+
+// AddHoliday closes an exchange for a full day, replacing the name of an existing closure
+func (s *ExchangeService) AddHoliday(ctx context.Context, code string, date time.Time, name string) (*models.ExchangeHoliday, error) {
+	if _, err := s.Get(ctx, code); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO exchange_holidays (exchange, date, name) VALUES ($1, $2, $3)
+		ON CONFLICT (exchange, date) DO UPDATE SET name = EXCLUDED.name
+	`, code, date, name)
+	if err != nil {
+		s.logger.Error("Failed to add holiday",
+			zap.String("exchange", code),
+			zap.Time("date", date),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return &models.ExchangeHoliday{Exchange: code, Date: date, Name: name}, nil
+}
+
+// DeleteHoliday reopens a day previously marked as a holiday
+func (s *ExchangeService) DeleteHoliday(ctx context.Context, code string, date time.Time) error {
+	cmdTag, err := s.db.Exec(ctx, `DELETE FROM exchange_holidays WHERE exchange = $1 AND date = $2`, code, date)
+	if err != nil {
+		s.logger.Error("Failed to delete holiday",
+			zap.String("exchange", code),
+			zap.Time("date", date),
+			zap.Error(err),
+		)
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrHolidayNotFound
+	}
+	return nil
+}
+
+// Holidays lists an exchange's closures in a calendar year, in date order
+func (s *ExchangeService) Holidays(ctx context.Context, code string, year int) ([]models.ExchangeHoliday, error) {
+	if _, err := s.Get(ctx, code); err != nil {
+		return nil, err
+	}
+
+	startDate, endDate := yearBounds(year)
+	rows, err := s.db.Query(ctx, `
+		SELECT exchange, date, name FROM exchange_holidays
+		WHERE exchange = $1 AND date >= $2 AND date <= $3
+		ORDER BY date
+	`, code, startDate, endDate)
+	if err != nil {
+		s.logger.Error("Failed to list holidays", zap.String("exchange", code), zap.Int("year", year), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ExchangeHoliday])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// ReplaceHolidays makes holidays the exchange's full list of closures for a year, as
+// when a published calendar is uploaded: closures the list leaves out reopen and
+// closures it renames take the new name. Every date must fall in year and appear once.
+func (s *ExchangeService) ReplaceHolidays(ctx context.Context, code string, year int, holidays []models.ExchangeHoliday) (*models.HolidayUploadResult, error) {
+	if _, err := s.Get(ctx, code); err != nil {
+		return nil, err
+	}
+
+	startDate, endDate := yearBounds(year)
+	dates := make([]time.Time, len(holidays))
+	names := make([]string, len(holidays))
+	seen := make(map[time.Time]bool, len(holidays))
+	for i, holiday := range holidays {
+		if holiday.Date.Before(startDate) || holiday.Date.After(endDate) {
+			return nil, fmt.Errorf("%w: %s is not in %d", ErrHolidayOutsideYear, holiday.Date.Format("2006-01-02"), year)
+		}
+		if seen[holiday.Date] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateHoliday, holiday.Date.Format("2006-01-02"))
+		}
+		seen[holiday.Date] = true
+		dates[i], names[i] = holiday.Date, holiday.Name
+	}
+
+	result := &models.HolidayUploadResult{Exchange: code, Year: year, Holidays: len(holidays)}
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM exchange_holidays
+			WHERE exchange = $1 AND date >= $2 AND date <= $3 AND NOT (date = ANY($4))
+		`, code, startDate, endDate, dates)
+		if err != nil {
+			return fmt.Errorf("failed to remove holidays: %w", err)
+		}
+		result.Removed = int(tag.RowsAffected())
+
+		// Rows left alone by the WHERE are not returned, so what is not added or
+		// renamed is unchanged
+		rows, err := tx.Query(ctx, `
+			INSERT INTO exchange_holidays (exchange, date, name)
+			SELECT $1, d, n FROM unnest($2::date[], $3::text[]) AS u(d, n)
+			ON CONFLICT (exchange, date) DO UPDATE SET name = EXCLUDED.name
+			WHERE exchange_holidays.name <> EXCLUDED.name
+			RETURNING xmax = 0
+		`, code, dates, names)
+		if err != nil {
+			return fmt.Errorf("failed to store holidays: %w", err)
+		}
+		inserted, err := pgx.CollectRows(rows, pgx.RowTo[bool])
+		if err != nil {
+			return fmt.Errorf("failed to store holidays: %w", err)
+		}
+		for _, added := range inserted {
+			if added {
+				result.Added++
+			} else {
+				result.Renamed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to replace holidays", zap.String("exchange", code), zap.Int("year", year), zap.Error(err))
+		return nil, err
+	}
+
+	result.Unchanged = result.Holidays - result.Added - result.Renamed
+	return result, nil
+}
+
+// TradesOn reports whether the exchange has a session on its local date at the given
+// moment, so callers can tell a closed day from missing data
+func (s *ExchangeService) TradesOn(ctx context.Context, code string, at time.Time) (bool, error) {
+	exchange, err := s.Get(ctx, code)
+	if err != nil {
+		return false, err
+	}
+	loc, err := time.LoadLocation(exchange.Timezone)
+	if err != nil {
+		return false, fmt.Errorf("exchange %s: %w", code, err)
+	}
+
+	local := at.In(loc)
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	holidays, err := s.holidays(ctx, code, date, date)
+	if err != nil {
+		return false, err
+	}
+	days, err := tradingDays(exchange, holidays, date, date)
+	if err != nil {
+		return false, err
+	}
+	return days[0].Open, nil
+}
+
+// Calendar lists each day from startDate to endDate with whether the exchange trades
+// and, when it does, the session's open and close in UTC
+func (s *ExchangeService) Calendar(ctx context.Context, code string, startDate, endDate time.Time) ([]models.TradingDay, error) {
+	days := int(endDate.Sub(startDate).Hours()/24) + 1
+	if days > MaxCalendarDays {
+		return nil, fmt.Errorf("%w: %d days, limit is %d", ErrCalendarTooLong, days, MaxCalendarDays)
+	}
+
+	exchange, err := s.Get(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := s.holidays(ctx, code, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return tradingDays(exchange, holidays, startDate, endDate)
+}
+
+// OpenDays lists the days from startDate to endDate the exchange trades. Unlike
+// Calendar it is not capped, for checks spanning a listing's whole history.
+func (s *ExchangeService) OpenDays(ctx context.Context, code string, startDate, endDate time.Time) ([]string, error) {
+	exchange, err := s.Get(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := s.holidays(ctx, code, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	days, err := tradingDays(exchange, holidays, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var open []string
+	for _, day := range days {
+		if day.Open {
+			open = append(open, day.Date)
+		}
+	}
+	return open, nil
+}
+
+// Status reports whether the exchange is in session at the given moment and its market
+// state, with the next open and close within the next two weeks
+func (s *ExchangeService) Status(ctx context.Context, code string, at time.Time) (*models.ExchangeStatus, error) {
+	exchange, err := s.Get(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(exchange.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("exchange %s: %w", code, err)
+	}
+
+	// Start a day early so a session that began before local midnight is seen
+	local := at.In(loc)
+	startDate := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	endDate := startDate.AddDate(0, 0, statusLookahead)
+
+	holidays, err := s.holidays(ctx, code, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	days, err := tradingDays(exchange, holidays, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionStatus(code, days, at, local.Format("2006-01-02")), nil
+}
+
+// LastSession returns the session in progress at the given moment or, between
+// sessions, the latest one to have closed. ok is false when the exchange has not
+// traded in the last two weeks.
+func (s *ExchangeService) LastSession(ctx context.Context, code string, at time.Time) (models.TradingDay, bool, error) {
+	exchange, err := s.Get(ctx, code)
+	if err != nil {
+		return models.TradingDay{}, false, err
+	}
+	loc, err := time.LoadLocation(exchange.Timezone)
+	if err != nil {
+		return models.TradingDay{}, false, fmt.Errorf("exchange %s: %w", code, err)
+	}
+
+	local := at.In(loc)
+	endDate := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	startDate := endDate.AddDate(0, 0, -sessionLookback)
+
+	holidays, err := s.holidays(ctx, code, startDate, endDate)
+	if err != nil {
+		return models.TradingDay{}, false, err
+	}
+	days, err := tradingDays(exchange, holidays, startDate, endDate)
+	if err != nil {
+		return models.TradingDay{}, false, err
+	}
+
+	for i := len(days) - 1; i >= 0; i-- {
+		if days[i].Open && !days[i].OpensAt.After(at) {
+			return days[i], true, nil
+		}
+	}
+	return models.TradingDay{}, false, nil
+}
+
+// sessionStatus finds the session state at a moment from consecutive trading days
+// starting before it; today is the exchange's local date at that moment
+func sessionStatus(code string, days []models.TradingDay, at time.Time, today string) *models.ExchangeStatus {
+	status := &models.ExchangeStatus{Exchange: code, At: at.UTC(), MarketState: models.MarketClosed}
+	preOpen := false
+	for _, day := range days {
+		if day.Date == today && !day.Open && day.Holiday != "" {
+			status.Holiday = day.Holiday
+		}
+		if !day.Open || !day.ClosesAt.After(at) {
+			continue
+		}
+		if day.PreOpensAt != nil && !day.PreOpensAt.After(at) && day.OpensAt.After(at) {
+			preOpen = true
+		}
+		// A session starting as the previous one closes continues it
+		if status.NextClose != nil && day.OpensAt.Equal(*status.NextClose) {
+			status.NextClose = day.ClosesAt
+			continue
+		}
+		if !day.OpensAt.After(at) {
+			status.Open = true
+			status.NextClose = day.ClosesAt
+			continue
+		}
+		if status.NextOpen == nil {
+			status.NextOpen = day.OpensAt
+			if status.NextClose == nil {
+				status.NextClose = day.ClosesAt
+			}
+		}
+	}
+
+	// Sessions running to the end of the lookahead, as on a 24/7 exchange, have no
+	// close in sight
+	if last := days[len(days)-1]; last.Open && status.NextClose != nil && status.NextClose.Equal(*last.ClosesAt) {
+		status.NextClose = nil
+	}
+
+	switch {
+	case status.Open:
+		status.MarketState = models.MarketOpen
+	case preOpen:
+		status.MarketState = models.MarketPreOpen
+	case status.Holiday != "":
+		status.MarketState = models.MarketHoliday
+	}
+
+	return status
+}
+
+// yearBounds returns the first and last day of a calendar year
+func yearBounds(year int) (time.Time, time.Time) {
+	return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *ExchangeService) holidays(ctx context.Context, code string, startDate, endDate time.Time) (map[string]string, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT date, name FROM exchange_holidays
+		WHERE exchange = $1 AND date >= $2 AND date <= $3
+	`, code, startDate, endDate)
+	if err != nil {
+		s.logger.Error("Failed to load holidays", zap.String("exchange", code), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	holidays := map[string]string{}
+	for rows.Next() {
+		var date time.Time
+		var name string
+		if err := rows.Scan(&date, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		holidays[date.Format("2006-01-02")] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return holidays, nil
+}
+
+// tradingDays expands an exchange's trading week and holidays into one entry per day
+func tradingDays(exchange *models.Exchange, holidays map[string]string, startDate, endDate time.Time) ([]models.TradingDay, error) {
+	loc, err := time.LoadLocation(exchange.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("exchange %s: %w", exchange.Code, err)
+	}
+	opens, err := time.Parse("15:04", exchange.OpensAt)
+	if err != nil {
+		return nil, fmt.Errorf("exchange %s opens_at: %w", exchange.Code, err)
+	}
+	closes, err := time.Parse("15:04", exchange.ClosesAt)
+	if err != nil {
+		return nil, fmt.Errorf("exchange %s closes_at: %w", exchange.Code, err)
+	}
+	var preOpens time.Time
+	if exchange.PreOpensAt != "" {
+		if preOpens, err = time.Parse("15:04", exchange.PreOpensAt); err != nil {
+			return nil, fmt.Errorf("exchange %s pre_opens_at: %w", exchange.Code, err)
+		}
+	}
+
+	var days []models.TradingDay
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		day := models.TradingDay{Date: d.Format("2006-01-02")}
+
+		weekday := int(d.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		holiday, closed := holidays[day.Date]
+		day.Holiday = holiday
+		day.Open = slices.Contains(exchange.TradingDays, weekday) && !closed
+
+		if day.Open {
+			openAt := time.Date(d.Year(), d.Month(), d.Day(), opens.Hour(), opens.Minute(), 0, 0, loc).UTC()
+			closeAt := time.Date(d.Year(), d.Month(), d.Day(), closes.Hour(), closes.Minute(), 0, 0, loc).UTC()
+			if !closeAt.After(openAt) {
+				closeAt = time.Date(d.Year(), d.Month(), d.Day()+1, closes.Hour(), closes.Minute(), 0, 0, loc).UTC()
+			}
+			day.OpensAt, day.ClosesAt = &openAt, &closeAt
+			if exchange.PreOpensAt != "" {
+				preOpenAt := time.Date(d.Year(), d.Month(), d.Day(), preOpens.Hour(), preOpens.Minute(), 0, 0, loc).UTC()
+				day.PreOpensAt = &preOpenAt
+			}
+		}
+		days = append(days, day)
+	}
+
+	return days, nil
+}
@@ -0,0 +1,584 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/storage"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrExportNotFound         = errors.New("export not found")
+	ErrExportQuotaExceeded    = errors.New("daily export quota exceeded")
+	ErrExportTooLarge         = errors.New("export exceeds the row limit")
+	ErrExportNotReady         = errors.New("export is not ready")
+	ErrExportSignatureInvalid = errors.New("download link is invalid or expired")
+)
+
+// ExportOptions bounds what export jobs may produce and how long results are served
+type ExportOptions struct {
+	SigningKey []byte
+	URLTTL     time.Duration
+	MaxRows    int64
+	DailyQuota int
+	Outbox     *OutboxService  // announces quota warnings; nil disables them
+	Webhooks   *WebhookService // tells the user of quota warnings and finished jobs; nil disables them
+}
+
+const (
+	// exportPollInterval is how often the worker looks for jobs it was not woken for,
+	// such as those created on another instance
+	exportPollInterval = 10 * time.Second
+	// exportLease is how long a claimed job stays claimed without being renewed; the
+	// worker renews it three times as often while the job runs
+	exportLease = 2 * time.Minute
+)
+
+type ExportService struct {
+	db      *database.DB
+	market  *MarketService
+	sources *SourceService
+	store   storage.ObjectStore
+	opts    ExportOptions
+	wake    chan struct{}
+	worker  string // names this instance's claims
+	logger  *zap.Logger
+}
+
+func NewExportService(db *database.DB, market *MarketService, sources *SourceService, store storage.ObjectStore, opts ExportOptions) *ExportService {
+	return &ExportService{
+		db:      db,
+		market:  market,
+		sources: sources,
+		store:   store,
+		opts:    opts,
+		wake:    make(chan struct{}, 1),
+		worker:  workerID(),
+		logger:  logger.With(zap.String("service", "export")),
+	}
+}
+
+// workerID names this process among the instances sharing the database
+func workerID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%.50s-%x", host, b)
+}
+
+const exportJobColumns = `id, user_id, symbols, source, interval, exchange, start_date, end_date, format,
+	status, row_count, size_bytes, object_key, error, created_at, completed_at, expires_at`
+
+// Create validates quota and size, then queues an export job. It returns the user's
+// quota usage counting the new job, or before it when the quota is exceeded.
+func (s *ExportService) Create(ctx context.Context, userID string, symbols []string, source, interval, exchange string, startDate, endDate time.Time, format string) (*models.ExportJob, *models.QuotaUsage, error) {
+	usage, err := s.Usage(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if usage.Remaining == 0 {
+		return nil, usage, fmt.Errorf("%w: %d jobs in the last 24 hours", ErrExportQuotaExceeded, usage.Used)
+	}
+
+	rows, err := s.market.CountBySymbolsAndDateRange(ctx, symbols, source, interval, exchange, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rows > s.opts.MaxRows {
+		return nil, nil, fmt.Errorf("%w: %d rows requested, limit is %d", ErrExportTooLarge, rows, s.opts.MaxRows)
+	}
+
+	query := `
+		INSERT INTO export_jobs (user_id, symbols, source, interval, exchange, start_date, end_date, format)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + exportJobColumns
+
+	// The job that first reaches the warning threshold announces it
+	wasWarning := usage.Warning
+	usage.Used++
+	setQuotaRemaining(usage)
+	crossed := usage.Warning && !wasWarning
+
+	var job *models.ExportJob
+	err = s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		job, err = scanExportJob(tx.QueryRow(ctx, query, userID, pq.Array(symbols), source, interval, exchange, startDate, endDate, format))
+		if err != nil {
+			return err
+		}
+		if usage.ResetAt == nil {
+			reset := job.CreatedAt.Add(24 * time.Hour)
+			usage.ResetAt = &reset
+		}
+		if !crossed {
+			return nil
+		}
+		return s.opts.Outbox.Enqueue(ctx, tx, models.TopicQuotaWarning, "user:"+userID, models.QuotaWarning{
+			UserID:     userID,
+			QuotaUsage: *usage,
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to create export job",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, nil, err
+	}
+	if crossed {
+		s.logger.Info("Export quota warning",
+			zap.String("user_id", userID),
+			zap.Int("used", usage.Used),
+			zap.Int("limit", usage.Limit),
+		)
+		s.opts.Webhooks.Dispatch(ctx, userID, models.EventQuotaWarning, models.QuotaWarning{
+			UserID:     userID,
+			QuotaUsage: *usage,
+		})
+	}
+
+	s.enqueue()
+	return job, usage, nil
+}
+
+// quotaWarningPercent is the share of a quota whose use sets QuotaUsage.Warning
+const quotaWarningPercent = 80
+
+// Usage reports how many exports a user started in the last 24 hours against the
+// daily quota
+func (s *ExportService) Usage(ctx context.Context, userID string) (*models.QuotaUsage, error) {
+	usage := &models.QuotaUsage{Quota: "exports", Limit: s.opts.DailyQuota}
+	var oldest *time.Time
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*), MIN(created_at) FROM export_jobs
+		WHERE user_id = $1 AND created_at > CURRENT_TIMESTAMP - INTERVAL '24 hours'
+	`, userID).Scan(&usage.Used, &oldest)
+	if err != nil {
+		s.logger.Error("Failed to check export quota",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	if oldest != nil {
+		reset := oldest.Add(24 * time.Hour)
+		usage.ResetAt = &reset
+	}
+	setQuotaRemaining(usage)
+	return usage, nil
+}
+
+// setQuotaRemaining derives Remaining and Warning from Limit and Used
+func setQuotaRemaining(usage *models.QuotaUsage) {
+	usage.Remaining = max(usage.Limit-usage.Used, 0)
+	usage.Warning = usage.Limit > 0 && usage.Used*100 >= usage.Limit*quotaWarningPercent
+}
+
+// Get returns a job owned by userID with its download URL when ready
+func (s *ExportService) Get(ctx context.Context, userID string, id int64) (*models.ExportJob, error) {
+	query := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE id = $1 AND user_id = $2`
+
+	job, err := scanExportJob(s.db.QueryRow(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExportNotFound
+		}
+		s.logger.Error("Failed to get export job",
+			zap.Int64("id", id),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	s.attachDownloadURL(job)
+	return job, nil
+}
+
+// List returns a user's most recent export jobs
+func (s *ExportService) List(ctx context.Context, userID string) ([]models.ExportJob, error) {
+	query := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE user_id = $1 ORDER BY created_at DESC LIMIT 50`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		s.logger.Error("Failed to list export jobs",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.ExportJob{}
+	for rows.Next() {
+		job, err := scanExportJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		s.attachDownloadURL(job)
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// OpenSigned verifies a signed download link and opens the export file
+func (s *ExportService) OpenSigned(ctx context.Context, id, expires int64, signature string) (*models.ExportJob, io.ReadCloser, error) {
+	if time.Now().Unix() > expires || !hmac.Equal([]byte(signature), []byte(s.sign(id, expires))) {
+		return nil, nil, ErrExportSignatureInvalid
+	}
+
+	query := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE id = $1`
+	job, err := scanExportJob(s.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrExportNotFound
+		}
+		return nil, nil, err
+	}
+	if job.Status != models.ExportCompleted {
+		return nil, nil, ErrExportNotReady
+	}
+
+	body, err := s.store.Open(ctx, job.ObjectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrExportNotFound
+		}
+		return nil, nil, err
+	}
+
+	return job, body, nil
+}
+
+// Start runs the export worker until ctx is cancelled, expiring old files hourly.
+// Jobs are claimed from the database, so any instance may run any user's job and one
+// whose instance stopped mid-run is claimed again once its lease lapses.
+func (s *ExportService) Start(ctx context.Context) {
+	poll := time.NewTicker(exportPollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	s.drain(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			s.drain(ctx)
+		case <-poll.C:
+			s.drain(ctx)
+		case <-cleanup.C:
+			s.expire(ctx)
+		}
+	}
+}
+
+// enqueue wakes the worker for a new job; one wake-up already pending covers it
+func (s *ExportService) enqueue() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// drain runs claimable jobs one after another until none is left
+func (s *ExportService) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := s.claim(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to claim export job", zap.Error(err))
+			}
+			return
+		}
+		if job == nil {
+			return
+		}
+		s.process(ctx, job)
+	}
+}
+
+// claim takes the oldest pending job, or a running one whose lease has lapsed, for
+// this instance. Instances claiming at once skip each other's rows. It returns nil
+// when there is nothing to claim.
+func (s *ExportService) claim(ctx context.Context) (*models.ExportJob, error) {
+	job, err := scanExportJob(s.db.QueryRow(ctx, `
+		UPDATE export_jobs
+		SET status = 'running', claimed_by = $1, lease_expires_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE id = (
+			SELECT id FROM export_jobs
+			WHERE status = 'pending'
+				OR (status = 'running' AND (lease_expires_at IS NULL OR lease_expires_at < CURRENT_TIMESTAMP))
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+exportJobColumns, s.worker, exportLease.Seconds()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return job, err
+}
+
+// renew extends this instance's lease on a running job, reporting false once the
+// job is no longer ours
+func (s *ExportService) renew(ctx context.Context, id int64) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE export_jobs SET lease_expires_at = CURRENT_TIMESTAMP + make_interval(secs => $3)
+		WHERE id = $1 AND claimed_by = $2 AND status = 'running'
+	`, id, s.worker, exportLease.Seconds())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// heartbeat renews the lease on a job until ctx ends, cancelling the job when the
+// lease is lost to another instance
+func (s *ExportService) heartbeat(ctx context.Context, cancel context.CancelFunc, id int64) {
+	ticker := time.NewTicker(exportLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			held, err := s.renew(ctx, id)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.logger.Warn("Failed to renew export lease", zap.Int64("id", id), zap.Error(err))
+				}
+				continue
+			}
+			if !held {
+				s.logger.Warn("Export lease lost, stopping job", zap.Int64("id", id))
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (s *ExportService) process(ctx context.Context, job *models.ExportJob) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.heartbeat(jobCtx, cancel, job.ID)
+
+	rowCount, size, key, err := s.build(jobCtx, job)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down: the lease lapses and another instance runs the job again
+			return
+		}
+		s.logger.Error("Export job failed",
+			zap.Int64("id", job.ID),
+			zap.String("user_id", job.UserID),
+			zap.Error(err),
+		)
+		tag, dbErr := s.db.Exec(ctx, `
+			UPDATE export_jobs SET status = 'failed', error = $3, completed_at = CURRENT_TIMESTAMP, lease_expires_at = NULL
+			WHERE id = $1 AND claimed_by = $2 AND status = 'running'
+		`, job.ID, s.worker, err.Error())
+		if dbErr != nil {
+			s.logger.Error("Failed to mark export job failed", zap.Int64("id", job.ID), zap.Error(dbErr))
+			return
+		}
+		if tag.RowsAffected() == 1 {
+			job.Status, job.Error = models.ExportFailed, err.Error()
+			s.notify(ctx, job)
+		}
+		return
+	}
+
+	expiresAt := time.Now().Add(s.opts.URLTTL)
+	finished, err := scanExportJob(s.db.QueryRow(ctx, `
+		UPDATE export_jobs
+		SET status = 'completed', row_count = $3, size_bytes = $4, object_key = $5,
+			completed_at = CURRENT_TIMESTAMP, expires_at = $6, lease_expires_at = NULL
+		WHERE id = $1 AND claimed_by = $2 AND status = 'running'
+		RETURNING `+exportJobColumns, job.ID, s.worker, rowCount, size, key, expiresAt))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Warn("Export job was claimed by another instance before it finished", zap.Int64("id", job.ID))
+			return
+		}
+		s.logger.Error("Failed to mark export job completed", zap.Int64("id", job.ID), zap.Error(err))
+		return
+	}
+
+	s.logger.Info("Export ready",
+		zap.Int64("id", job.ID),
+		zap.String("user_id", job.UserID),
+		zap.Int64("rows", rowCount),
+		zap.Int64("size_bytes", size),
+	)
+	s.attachDownloadURL(finished)
+	s.notify(ctx, finished)
+}
+
+// notify tells the job's owner it finished, through their webhooks
+func (s *ExportService) notify(ctx context.Context, job *models.ExportJob) {
+	event := models.EventExportCompleted
+	if job.Status == models.ExportFailed {
+		event = models.EventExportFailed
+	}
+	s.opts.Webhooks.Dispatch(ctx, job.UserID, event, models.ExportFinished{
+		ID:          job.ID,
+		Status:      job.Status,
+		Format:      job.Format,
+		RowCount:    job.RowCount,
+		SizeBytes:   job.SizeBytes,
+		DownloadURL: job.DownloadURL,
+		ExpiresAt:   job.ExpiresAt,
+		Error:       job.Error,
+	})
+}
+
+// build writes the job's file to the store as its rows are read, so no more than a
+// row is held at a time
+func (s *ExportService) build(ctx context.Context, job *models.ExportJob) (int64, int64, string, error) {
+	key := fmt.Sprintf("exports/%s/%d.%s", job.UserID, job.ID, job.Format)
+
+	pr, pw := io.Pipe()
+	written := make(chan int64, 1)
+	go func() {
+		rows, err := s.write(ctx, pw, job)
+		pw.CloseWithError(err)
+		written <- rows
+	}()
+
+	size, err := s.store.Put(ctx, key, pr)
+	// Unblock the writer if the store stopped reading early
+	pr.CloseWithError(err)
+	rows := <-written
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("failed to store export: %w", err)
+	}
+
+	return rows, size, key, nil
+}
+
+// write encodes the job's candles to w a symbol at a time, in symbol then time order.
+// It stops past MaxRows, or at a source whose license forbids redistribution.
+func (s *ExportService) write(ctx context.Context, w io.Writer, job *models.ExportJob) (int64, error) {
+	mw, err := NewMarketDataWriter(w, job.Format)
+	if err != nil {
+		return 0, err
+	}
+
+	symbols := slices.Clone(job.Symbols)
+	slices.Sort(symbols)
+	symbols = slices.Compact(symbols)
+
+	allowed := map[string]bool{}
+	var rows int64
+	for _, symbol := range symbols {
+		_, err := s.market.StreamBySymbolAndDateRange(ctx, symbol, job.Source, job.Interval, job.Exchange, job.StartDate, job.EndDate, time.Time{},
+			func(md models.MarketData) error {
+				if rows == s.opts.MaxRows {
+					return fmt.Errorf("%w: more than %d rows", ErrExportTooLarge, s.opts.MaxRows)
+				}
+				if !allowed[md.Source] {
+					if err := s.sources.CheckSourcesExportAllowed(ctx, md.Source); err != nil {
+						return err
+					}
+					allowed[md.Source] = true
+				}
+				rows++
+				return mw.Write(md)
+			})
+		if err != nil {
+			return rows, err
+		}
+	}
+	return rows, mw.Close()
+}
+
+// expire removes files whose download window has closed
+func (s *ExportService) expire(ctx context.Context) {
+	rows, err := s.db.Query(ctx, `
+		UPDATE export_jobs SET status = 'expired'
+		WHERE status = 'completed' AND expires_at <= CURRENT_TIMESTAMP
+		RETURNING object_key
+	`)
+	if err != nil {
+		s.logger.Error("Failed to expire export jobs", zap.Error(err))
+		return
+	}
+	defer rows.Close()
+
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		s.logger.Error("Failed to collect expired exports", zap.Error(err))
+		return
+	}
+
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			s.logger.Warn("Failed to delete expired export", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+func (s *ExportService) attachDownloadURL(job *models.ExportJob) {
+	if job.Status != models.ExportCompleted || job.ExpiresAt == nil {
+		return
+	}
+	expires := job.ExpiresAt.Unix()
+	job.DownloadURL = fmt.Sprintf("/api/v1/exports/%d/download?expires=%d&signature=%s",
+		job.ID, expires, s.sign(job.ID, expires))
+}
+
+func (s *ExportService) sign(id, expires int64) string {
+	mac := hmac.New(sha256.New, s.opts.SigningKey)
+	fmt.Fprintf(mac, "%d:%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func scanExportJob(row pgx.Row) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := row.Scan(
+		&job.ID, &job.UserID, pq.Array(&job.Symbols), &job.Source, &job.Interval, &job.Exchange,
+		&job.StartDate, &job.EndDate, &job.Format, &job.Status, &job.RowCount, &job.SizeBytes,
+		&job.ObjectKey, &job.Error, &job.CreatedAt, &job.CompletedAt, &job.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// WriteMarketData encodes market data in an export format (csv, json or xlsx)
+func WriteMarketData(w io.Writer, format string, data []models.MarketData) error {
+	mw, err := NewMarketDataWriter(w, format)
+	if err != nil {
+		return err
+	}
+	for _, md := range data {
+		if err := mw.Write(md); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// Export formats
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+	ExportXLSX = "xlsx"
+)
+
+// ValidExportFormat reports whether format is one market data can be exported in
+func ValidExportFormat(format string) bool {
+	switch format {
+	case ExportCSV, ExportJSON, ExportXLSX:
+		return true
+	}
+	return false
+}
+
+// ExportContentType returns the media type of an export format
+func ExportContentType(format string) string {
+	switch format {
+	case ExportJSON:
+		return "application/json"
+	case ExportXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv"
+	}
+}
+
+// MarketDataWriter encodes market data one candle at a time. Close completes the
+// output and must be called even when nothing was written.
+type MarketDataWriter interface {
+	Write(md models.MarketData) error
+	Close() error
+}
+
+// NewMarketDataWriter returns a writer encoding to w in an export format
+func NewMarketDataWriter(w io.Writer, format string) (MarketDataWriter, error) {
+	switch format {
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportHeader); err != nil {
+			return nil, err
+		}
+		return &csvDataWriter{w: cw}, nil
+	case ExportJSON:
+		return &jsonDataWriter{w: w}, nil
+	case ExportXLSX:
+		return newXLSXDataWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+var exportHeader = []string{"Symbol", "Date", "Open", "High", "Low", "Close", "Volume", "Source"}
+
+// exportDate is the trading day of a daily candle and the opening time of an
+// intraday one
+func exportDate(md models.MarketData) string {
+	if md.Interval != "" && md.Interval != models.IntervalDaily {
+		return md.Timestamp.UTC().Format(time.RFC3339)
+	}
+	return md.Date.Format("2006-01-02")
+}
+
+// exportOpen formats the open, "" when it is missing
+func exportOpen(md models.MarketData) string {
+	if md.Open == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*md.Open, 'f', -1, 64)
+}
+
+// exportVolume formats the volume, "" when it is missing
+func exportVolume(md models.MarketData) string {
+	if md.Volume == nil {
+		return ""
+	}
+	return strconv.FormatInt(*md.Volume, 10)
+}
+
+type csvDataWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvDataWriter) Write(md models.MarketData) error {
+	return c.w.Write([]string{
+		md.Symbol,
+		exportDate(md),
+		exportOpen(md),
+		strconv.FormatFloat(md.High, 'f', -1, 64),
+		strconv.FormatFloat(md.Low, 'f', -1, 64),
+		strconv.FormatFloat(md.Close, 'f', -1, 64),
+		exportVolume(md),
+		md.Source,
+	})
+}
+
+func (c *csvDataWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonDataWriter writes a JSON array element by element
+type jsonDataWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonDataWriter) Write(md models.MarketData) error {
+	b, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	sep := ","
+	if j.count == 0 {
+		sep = "["
+	}
+	j.count++
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonDataWriter) Close() error {
+	end := "]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+// Parts of a single-sheet workbook; the sheet itself is streamed last
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Market Data" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`},
+	// Cell style 1 shows a date, 2 a date and time
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>
+</styleSheet>`},
+}
+
+// excelEpoch is day zero of spreadsheet date serials
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxDataWriter streams rows into the worksheet of a minimal workbook, keeping
+// nothing but the row count in memory
+type xlsxDataWriter struct {
+	zw  *zip.Writer
+	w   *bufio.Writer
+	row int
+}
+
+func newXLSXDataWriter(w io.Writer) (*xlsxDataWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	x := &xlsxDataWriter{zw: zw, w: bufio.NewWriter(sheet)}
+	x.w.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	x.startRow()
+	for _, name := range exportHeader {
+		x.text(name)
+	}
+	x.w.WriteString("</row>")
+	return x, nil
+}
+
+func (x *xlsxDataWriter) Write(md models.MarketData) error {
+	x.startRow()
+	x.text(md.Symbol)
+	if md.Interval != "" && md.Interval != models.IntervalDaily {
+		x.date(md.Timestamp.UTC(), 2)
+	} else {
+		x.date(md.Date, 1)
+	}
+	x.number(exportOpen(md))
+	x.number(strconv.FormatFloat(md.High, 'f', -1, 64))
+	x.number(strconv.FormatFloat(md.Low, 'f', -1, 64))
+	x.number(strconv.FormatFloat(md.Close, 'f', -1, 64))
+	x.number(exportVolume(md))
+	x.text(md.Source)
+	_, err := x.w.WriteString("</row>")
+	return err
+}
+
+func (x *xlsxDataWriter) Close() error {
+	x.w.WriteString("</sheetData></worksheet>")
+	if err := x.w.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+func (x *xlsxDataWriter) startRow() {
+	x.row++
+	fmt.Fprintf(x.w, `<row r="%d">`, x.row)
+}
+
+func (x *xlsxDataWriter) text(s string) {
+	x.w.WriteString(`<c t="inlineStr"><is><t>`)
+	xml.EscapeText(x.w, []byte(s))
+	x.w.WriteString(`</t></is></c>`)
+}
+
+// number writes a numeric cell, or an empty one when v is ""
+func (x *xlsxDataWriter) number(v string) {
+	if v == "" {
+		x.w.WriteString(`<c/>`)
+		return
+	}
+	x.w.WriteString(`<c><v>` + v + `</v></c>`)
+}
+
+// date writes t as a spreadsheet serial shown with cell style
+func (x *xlsxDataWriter) date(t time.Time, style int) {
+	serial := t.Sub(excelEpoch).Hours() / 24
+	fmt.Fprintf(x.w, `<c s="%d"><v>%s</v></c>`, style, strconv.FormatFloat(serial, 'f', -1, 64))
+}
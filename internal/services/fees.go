@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/fees"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+type FeeService struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewFeeService(db *database.DB) *FeeService {
+	return &FeeService{
+		db:     db,
+		logger: logger.With(zap.String("service", "fees")),
+	}
+}
+
+// GetModel returns the user's fee model, falling back to the IDX defaults
+func (s *FeeService) GetModel(ctx context.Context, userID string) (fees.Model, bool, error) {
+	query := `SELECT model FROM user_fee_settings WHERE user_id = $1`
+
+	var raw []byte
+	err := s.db.QueryRow(ctx, query, userID).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fees.Default(), false, nil
+		}
+		s.logger.Error("Failed to get fee settings",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return fees.Model{}, false, err
+	}
+
+	var model fees.Model
+	if err := json.Unmarshal(raw, &model); err != nil {
+		return fees.Model{}, false, fmt.Errorf("failed to decode fee model: %w", err)
+	}
+
+	return model, true, nil
+}
+
+// SaveModel stores the user's fee model
+func (s *FeeService) SaveModel(ctx context.Context, userID string, model fees.Model) error {
+	raw, err := json.Marshal(model)
+	if err != nil {
+		return fmt.Errorf("failed to encode fee model: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_fee_settings (user_id, model)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			model = EXCLUDED.model,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	if _, err := s.db.Exec(ctx, query, userID, raw); err != nil {
+		s.logger.Error("Failed to save fee settings",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// ResetModel removes the user's custom fee model so defaults apply again
+func (s *FeeService) ResetModel(ctx context.Context, userID string) error {
+	query := `DELETE FROM user_fee_settings WHERE user_id = $1`
+
+	if _, err := s.db.Exec(ctx, query, userID); err != nil {
+		s.logger.Error("Failed to reset fee settings",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
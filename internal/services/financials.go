@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidFinancials is returned for a report that cannot be stored
+var ErrInvalidFinancials = errors.New("invalid financial report")
+
+type FinancialsService struct {
+	db           *database.DB
+	market       *MarketService
+	fundamentals *FundamentalsService
+	logger       *zap.Logger
+}
+
+func NewFinancialsService(db *database.DB, market *MarketService, fundamentals *FundamentalsService) *FinancialsService {
+	return &FinancialsService{
+		db:           db,
+		market:       market,
+		fundamentals: fundamentals,
+		logger:       logger.With(zap.String("service", "financials")),
+	}
+}
+
+// mergedFinancials keeps one report per symbol and quarter, from the highest-priority
+// source, numbering each symbol's reports from the latest (rn = 1)
+const mergedFinancials = `(
+		SELECT m.*, ROW_NUMBER() OVER (PARTITION BY m.symbol ORDER BY m.period_end DESC) AS rn
+		FROM (
+			SELECT DISTINCT ON (f.symbol, f.fiscal_year, f.fiscal_quarter) f.*
+			FROM financial_reports f
+			LEFT JOIN sources src ON src.name = f.source
+			WHERE f.symbol = ANY($1)
+			ORDER BY f.symbol, f.fiscal_year, f.fiscal_quarter, COALESCE(src.priority, 100)
+		) m
+	) r`
+
+const financialColumns = `r.id, r.symbol, r.fiscal_year, r.fiscal_quarter, r.period_end, r.currency, r.revenue,
+		r.net_income, r.eps, r.book_value, r.source, r.created_at, COALESCE(r.updated_at, r.created_at)`
+
+// ParseFinancialInputs validates reports from a request, upper-casing symbols and
+// currencies and defaulting the source to manual and the currency to IDR
+func ParseFinancialInputs(inputs []models.FinancialReportInput) ([]models.FinancialReport, error) {
+	reports := make([]models.FinancialReport, len(inputs))
+	for i, in := range inputs {
+		periodEnd, err := time.Parse("2006-01-02", in.PeriodEnd)
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d has period_end %q, use YYYY-MM-DD", ErrInvalidFinancials, i, in.PeriodEnd)
+		}
+		source := in.Source
+		if source == "" {
+			source = "manual"
+		}
+		currency := strings.ToUpper(in.Currency)
+		if currency == "" {
+			currency = "IDR"
+		}
+		reports[i] = models.FinancialReport{
+			Symbol:        strings.ToUpper(strings.TrimSpace(in.Symbol)),
+			FiscalYear:    in.FiscalYear,
+			FiscalQuarter: in.FiscalQuarter,
+			PeriodEnd:     periodEnd,
+			Currency:      currency,
+			Revenue:       in.Revenue,
+			NetIncome:     in.NetIncome,
+			EPS:           in.EPS,
+			BookValue:     in.BookValue,
+			Source:        source,
+		}
+	}
+	return reports, nil
+}
+
+// Upsert stores reports in one transaction, replacing a source's earlier report for
+// the same quarter
+func (s *FinancialsService) Upsert(ctx context.Context, reports []models.FinancialReport) (int, int, error) {
+	batch := &pgx.Batch{}
+	for _, r := range reports {
+		batch.Queue(`
+			INSERT INTO financial_reports (symbol, fiscal_year, fiscal_quarter, period_end, currency,
+				revenue, net_income, eps, book_value, source)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (symbol, fiscal_year, fiscal_quarter, source) DO UPDATE SET
+				period_end = EXCLUDED.period_end,
+				currency = EXCLUDED.currency,
+				revenue = EXCLUDED.revenue,
+				net_income = EXCLUDED.net_income,
+				eps = EXCLUDED.eps,
+				book_value = EXCLUDED.book_value
+			RETURNING (xmax = 0)
+		`, r.Symbol, r.FiscalYear, r.FiscalQuarter, r.PeriodEnd, r.Currency,
+			r.Revenue, r.NetIncome, r.EPS, r.BookValue, r.Source)
+	}
+
+	var inserted, updated int
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		br := tx.SendBatch(ctx, batch)
+		defer br.Close()
+
+		for i := range reports {
+			var isInsert bool
+			if err := br.QueryRow().Scan(&isInsert); err != nil {
+				return fmt.Errorf("failed to store report %d: %w", i, err)
+			}
+			if isInsert {
+				inserted++
+			} else {
+				updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to store financial reports", zap.Int("count", len(reports)), zap.Error(err))
+		return 0, 0, err
+	}
+
+	return inserted, updated, nil
+}
+
+// List returns a symbol's latest quarters, newest first
+func (s *FinancialsService) List(ctx context.Context, symbol string, quarters int) ([]models.FinancialReport, error) {
+	return s.latest(ctx, []string{symbol}, quarters)
+}
+
+// Ratios computes PER and PBV for each symbol from its last four quarters, its latest
+// shares outstanding and its latest daily close. Symbols without reports are absent.
+func (s *FinancialsService) Ratios(ctx context.Context, symbols []string) (map[string]models.FinancialRatios, error) {
+	reports, err := s.latest(ctx, symbols, 4)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return map[string]models.FinancialRatios{}, nil
+	}
+	shares, err := s.fundamentals.Latest(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+	closes, err := s.market.GetLatestBySymbols(ctx, symbols, SourceAny, models.IntervalDaily)
+	if err != nil {
+		return nil, err
+	}
+
+	bySymbol := map[string][]models.FinancialReport{}
+	for _, r := range reports {
+		bySymbol[r.Symbol] = append(bySymbol[r.Symbol], r)
+	}
+
+	ratios := make(map[string]models.FinancialRatios, len(bySymbol))
+	for symbol, quarters := range bySymbol {
+		ratio := models.FinancialRatios{Symbol: symbol, PeriodEnd: &quarters[0].PeriodEnd}
+		var sharesOutstanding int64
+		if f, ok := shares[symbol]; ok {
+			sharesOutstanding = f.SharesOutstanding
+		}
+		if md, ok := closes[symbol]; ok {
+			ratio.Price = &md.Close
+			ratio.PriceDate = &md.Date
+		}
+
+		ratio.EPSTTM = trailingEPS(quarters, sharesOutstanding)
+		if bv := quarters[0].BookValue; bv != nil && sharesOutstanding > 0 {
+			bvps := *bv / float64(sharesOutstanding)
+			ratio.BookValuePerShare = &bvps
+		}
+		if ratio.Price != nil {
+			if ratio.EPSTTM != nil && *ratio.EPSTTM > 0 {
+				per := *ratio.Price / *ratio.EPSTTM
+				ratio.PER = &per
+			}
+			if ratio.BookValuePerShare != nil && *ratio.BookValuePerShare > 0 {
+				pbv := *ratio.Price / *ratio.BookValuePerShare
+				ratio.PBV = &pbv
+			}
+		}
+		ratios[symbol] = ratio
+	}
+	return ratios, nil
+}
+
+// latest returns up to quarters of each symbol's most recent reports, newest first
+func (s *FinancialsService) latest(ctx context.Context, symbols []string, quarters int) ([]models.FinancialReport, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE r.rn <= $2
+		ORDER BY r.symbol, r.period_end DESC
+	`, financialColumns, mergedFinancials)
+
+	rows, err := s.db.Query(ctx, query, symbols, quarters)
+	if err != nil {
+		s.logger.Error("Failed to get financial reports",
+			zap.Strings("symbols", symbols),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.FinancialReport])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return reports, nil
+}
+
+// trailingEPS sums the EPS of four consecutive quarters, newest first, deriving a
+// missing EPS from net income over shares outstanding. It is nil when fewer than four
+// consecutive quarters have earnings.
+func trailingEPS(quarters []models.FinancialReport, sharesOutstanding int64) *float64 {
+	if len(quarters) < 4 {
+		return nil
+	}
+	newest, oldest := quarters[0], quarters[3]
+	if (newest.FiscalYear*4+newest.FiscalQuarter)-(oldest.FiscalYear*4+oldest.FiscalQuarter) != 3 {
+		return nil
+	}
+
+	var sum float64
+	for _, q := range quarters[:4] {
+		switch {
+		case q.EPS != nil:
+			sum += *q.EPS
+		case q.NetIncome != nil && sharesOutstanding > 0:
+			sum += *q.NetIncome / float64(sharesOutstanding)
+		default:
+			return nil
+		}
+	}
+	return &sum
+}
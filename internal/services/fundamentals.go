@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrInvalidFundamentals is returned for a snapshot that cannot be stored
+	ErrInvalidFundamentals = errors.New("invalid fundamentals")
+	// ErrFundamentalsNotFound is returned for a symbol without any snapshot
+	ErrFundamentalsNotFound = errors.New("no fundamentals for symbol")
+)
+
+// UnclassifiedSector groups listings without a sector in sector aggregates
+const UnclassifiedSector = "Unclassified"
+
+type FundamentalsService struct {
+	db     *database.DB
+	market *MarketService
+	logger *zap.Logger
+}
+
+func NewFundamentalsService(db *database.DB, market *MarketService) *FundamentalsService {
+	return &FundamentalsService{
+		db:     db,
+		market: market,
+		logger: logger.With(zap.String("service", "fundamentals")),
+	}
+}
+
+const fundamentalsColumns = `f.id, f.symbol, f.date, f.shares_outstanding, f.market_cap, f.free_float_pct,
+		f.source, f.created_at, COALESCE(f.updated_at, f.created_at)`
+
+// ParseFundamentalsInputs validates snapshots from a request, upper-casing symbols
+// and defaulting the source to manual
+func ParseFundamentalsInputs(inputs []models.FundamentalsInput) ([]models.Fundamentals, error) {
+	snapshots := make([]models.Fundamentals, len(inputs))
+	for i, in := range inputs {
+		date, err := time.Parse("2006-01-02", in.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d has date %q, use YYYY-MM-DD", ErrInvalidFundamentals, i, in.Date)
+		}
+		source := in.Source
+		if source == "" {
+			source = "manual"
+		}
+		snapshots[i] = models.Fundamentals{
+			Symbol:            strings.ToUpper(strings.TrimSpace(in.Symbol)),
+			Date:              date,
+			SharesOutstanding: in.SharesOutstanding,
+			MarketCap:         in.MarketCap,
+			FreeFloatPct:      in.FreeFloatPct,
+			Source:            source,
+		}
+	}
+	return snapshots, nil
+}
+
+// Upsert stores snapshots in one transaction, replacing a source's earlier report
+// for the same symbol and date
+func (s *FundamentalsService) Upsert(ctx context.Context, snapshots []models.Fundamentals) (int, int, error) {
+	batch := &pgx.Batch{}
+	for _, f := range snapshots {
+		batch.Queue(`
+			INSERT INTO symbol_fundamentals (symbol, date, shares_outstanding, market_cap, free_float_pct, source)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (symbol, date, source) DO UPDATE SET
+				shares_outstanding = EXCLUDED.shares_outstanding,
+				market_cap = EXCLUDED.market_cap,
+				free_float_pct = EXCLUDED.free_float_pct
+			RETURNING (xmax = 0)
+		`, f.Symbol, f.Date, f.SharesOutstanding, f.MarketCap, f.FreeFloatPct, f.Source)
+	}
+
+	var inserted, updated int
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		br := tx.SendBatch(ctx, batch)
+		defer br.Close()
+
+		for i := range snapshots {
+			var isInsert bool
+			if err := br.QueryRow().Scan(&isInsert); err != nil {
+				return fmt.Errorf("failed to store fundamentals %d: %w", i, err)
+			}
+			if isInsert {
+				inserted++
+			} else {
+				updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to store fundamentals", zap.Int("count", len(snapshots)), zap.Error(err))
+		return 0, 0, err
+	}
+
+	return inserted, updated, nil
+}
+
+// Snapshots returns a symbol's snapshots dated start_date..end_date, led by the one in
+// force on startDate. Each date comes from the highest-priority source reporting it.
+func (s *FundamentalsService) Snapshots(ctx context.Context, symbol string, startDate, endDate time.Time) ([]models.Fundamentals, error) {
+	query := fmt.Sprintf(`
+		SELECT DISTINCT ON (f.date) %s
+		FROM symbol_fundamentals f
+		LEFT JOIN sources src ON src.name = f.source
+		WHERE f.symbol = $1 AND f.date <= $3
+			AND f.date >= COALESCE((
+				SELECT MAX(date) FROM symbol_fundamentals WHERE symbol = $1 AND date <= $2
+			), $2)
+		ORDER BY f.date, COALESCE(src.priority, 100)
+	`, fundamentalsColumns)
+
+	rows, err := s.db.Query(ctx, query, symbol, startDate, endDate)
+	if err != nil {
+		s.logger.Error("Failed to get fundamentals",
+			zap.String("symbol", symbol),
+			zap.Time("start_date", startDate),
+			zap.Time("end_date", endDate),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Fundamentals])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return snapshots, nil
+}
+
+// Latest returns each symbol's most recent snapshot, preferring higher-priority
+// sources on the same day; symbols without one are absent
+func (s *FundamentalsService) Latest(ctx context.Context, symbols []string) (map[string]models.Fundamentals, error) {
+	query := fmt.Sprintf(`
+		SELECT DISTINCT ON (f.symbol) %s
+		FROM symbol_fundamentals f
+		LEFT JOIN sources src ON src.name = f.source
+		WHERE f.symbol = ANY($1)
+		ORDER BY f.symbol, f.date DESC, COALESCE(src.priority, 100)
+	`, fundamentalsColumns)
+
+	rows, err := s.db.Query(ctx, query, symbols)
+	if err != nil {
+		s.logger.Error("Failed to get latest fundamentals",
+			zap.Strings("symbols", symbols),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Fundamentals])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	latest := make(map[string]models.Fundamentals, len(snapshots))
+	for _, f := range snapshots {
+		latest[f.Symbol] = f
+	}
+	return latest, nil
+}
+
+// LatestMarketCaps values each symbol's latest snapshot at its latest daily close;
+// symbols without a snapshot are absent
+func (s *FundamentalsService) LatestMarketCaps(ctx context.Context, symbols []string) (map[string]models.MarketCap, error) {
+	latest, err := s.Latest(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+	closes, err := s.market.GetLatestBySymbols(ctx, symbols, SourceAny, models.IntervalDaily)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := make(map[string]models.MarketCap, len(latest))
+	for symbol, f := range latest {
+		if md, ok := closes[symbol]; ok && !md.Date.Before(f.Date) {
+			caps[symbol] = valueAt(f, md.Date, &md.Close)
+		} else {
+			caps[symbol] = valueAt(f, f.Date, nil)
+		}
+	}
+	return caps, nil
+}
+
+// MarketCapHistory returns a symbol's daily market cap over startDate..endDate along
+// with the snapshots it was computed from. Days before the first snapshot are left out.
+func (s *FundamentalsService) MarketCapHistory(ctx context.Context, symbol string, startDate, endDate time.Time) ([]models.MarketCap, []models.Fundamentals, error) {
+	snapshots, err := s.Snapshots(ctx, symbol, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(snapshots) == 0 {
+		return []models.MarketCap{}, snapshots, nil
+	}
+	candles, err := s.market.GetBySymbolsAndDateRange(ctx, []string{symbol}, SourceAny, models.IntervalDaily, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	history := make([]models.MarketCap, 0, len(candles))
+	next := 0
+	for _, md := range candles {
+		for next < len(snapshots) && !snapshots[next].Date.After(md.Date) {
+			next++
+		}
+		if next == 0 {
+			continue
+		}
+		history = append(history, valueAt(snapshots[next-1], md.Date, &md.Close))
+	}
+	return history, snapshots, nil
+}
+
+// Sectors sums the latest market caps of the listings on exchange ("" for every
+// exchange) by sector, largest first
+func (s *FundamentalsService) Sectors(ctx context.Context, exchange string) ([]models.SectorAggregate, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT DISTINCT ON (f.symbol) f.symbol, COALESCE(NULLIF(sy.sector, ''), $2)
+		FROM symbol_fundamentals f
+		JOIN symbols sy ON sy.symbol = f.symbol
+		WHERE $1 = '' OR sy.exchange = $1
+		ORDER BY f.symbol, sy.exchange
+	`, exchange, UnclassifiedSector)
+	if err != nil {
+		s.logger.Error("Failed to get sectors", zap.String("exchange", exchange), zap.Error(err))
+		return nil, err
+	}
+	sectorOf := map[string]string{}
+	var symbols []string
+	for rows.Next() {
+		var symbol, sector string
+		if err := rows.Scan(&symbol, &sector); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan sector: %w", err)
+		}
+		sectorOf[symbol] = sector
+		symbols = append(symbols, symbol)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(symbols) == 0 {
+		return []models.SectorAggregate{}, nil
+	}
+
+	caps, err := s.LatestMarketCaps(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	bySector := map[string]*models.SectorAggregate{}
+	var total float64
+	for _, symbol := range symbols {
+		mc, ok := caps[symbol]
+		if !ok || mc.MarketCap == nil {
+			continue
+		}
+		agg, ok := bySector[sectorOf[symbol]]
+		if !ok {
+			agg = &models.SectorAggregate{Sector: sectorOf[symbol]}
+			bySector[agg.Sector] = agg
+		}
+		agg.Symbols++
+		agg.MarketCap += *mc.MarketCap
+		if mc.FreeFloatMarketCap != nil {
+			agg.FreeFloatMarketCap += *mc.FreeFloatMarketCap
+		}
+		total += *mc.MarketCap
+	}
+
+	sectors := make([]models.SectorAggregate, 0, len(bySector))
+	for _, agg := range bySector {
+		if total > 0 {
+			agg.WeightPct = math.Round(agg.MarketCap/total*1e4) / 100
+		}
+		sectors = append(sectors, *agg)
+	}
+	sort.Slice(sectors, func(i, j int) bool {
+		if sectors[i].MarketCap != sectors[j].MarketCap {
+			return sectors[i].MarketCap > sectors[j].MarketCap
+		}
+		return sectors[i].Sector < sectors[j].Sector
+	})
+	return sectors, nil
+}
+
+// valueAt values a snapshot on date at close, or at the market cap the source
+// reported when close is nil
+func valueAt(f models.Fundamentals, date time.Time, close *float64) models.MarketCap {
+	mc := models.MarketCap{
+		Symbol:            f.Symbol,
+		Date:              date,
+		Close:             close,
+		SharesOutstanding: f.SharesOutstanding,
+		FreeFloatPct:      f.FreeFloatPct,
+		MarketCap:         f.MarketCap,
+		AsOf:              f.Date,
+		Source:            f.Source,
+	}
+	if close != nil {
+		value := *close * float64(f.SharesOutstanding)
+		mc.MarketCap = &value
+	}
+	if mc.MarketCap != nil && f.FreeFloatPct != nil {
+		floating := *mc.MarketCap * *f.FreeFloatPct / 100
+		mc.FreeFloatMarketCap = &floating
+	}
+	return mc
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrFXRateNotFound = errors.New("fx rate not found")
+	ErrInvalidFXPair  = errors.New("invalid currency pair")
+	ErrInvalidFXRate  = errors.New("invalid fx rate")
+)
+
+// fxCrossCurrency is the currency rates are crossed through when a pair is not stored
+const fxCrossCurrency = "USD"
+
+type FXService struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewFXService(db *database.DB) *FXService {
+	return &FXService{
+		db:     db,
+		logger: logger.With(zap.String("service", "fx")),
+	}
+}
+
+// ParsePair splits a pair written USD-IDR, USD_IDR or USDIDR into upper-case codes
+func ParsePair(pair string) (string, string, error) {
+	pair = strings.ToUpper(strings.NewReplacer("-", "", "_", "", "/", "").Replace(pair))
+	if len(pair) != 6 || !ValidCurrency(pair[:3]) || !ValidCurrency(pair[3:]) {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidFXPair, pair)
+	}
+	return pair[:3], pair[3:], nil
+}
+
+// Rate returns the latest rate for base/quote on or before date. A pair that is not
+// stored is served as the inverse of the opposite pair, or crossed through USD.
+func (s *FXService) Rate(ctx context.Context, base, quote string, date time.Time) (*models.FXRate, error) {
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	if base == quote {
+		return &models.FXRate{Base: base, Quote: quote, Date: date, Rate: 1, Source: "identity"}, nil
+	}
+
+	rate, err := s.direct(ctx, base, quote, date)
+	if !errors.Is(err, ErrFXRateNotFound) || base == fxCrossCurrency || quote == fxCrossCurrency {
+		return rate, err
+	}
+
+	// Cross through USD, dated by the older of the two legs
+	first, err := s.direct(ctx, base, fxCrossCurrency, date)
+	if err != nil {
+		return nil, err
+	}
+	second, err := s.direct(ctx, fxCrossCurrency, quote, date)
+	if err != nil {
+		return nil, err
+	}
+
+	crossed := &models.FXRate{
+		Base:    base,
+		Quote:   quote,
+		Date:    first.Date,
+		Rate:    first.Rate * second.Rate,
+		Source:  first.Source,
+		Derived: "cross",
+	}
+	if second.Date.Before(first.Date) {
+		crossed.Date = second.Date
+	}
+	if second.Source != first.Source {
+		crossed.Source = first.Source + "+" + second.Source
+	}
+	return crossed, nil
+}
+
+// direct looks up base/quote or its inverse, preferring the stored direction on a tie
+func (s *FXService) direct(ctx context.Context, base, quote string, date time.Time) (*models.FXRate, error) {
+	query := `
+		SELECT base, date, rate::float8, source
+		FROM fx_rates
+		WHERE ((base = $1 AND quote = $2) OR (base = $2 AND quote = $1)) AND date <= $3
+		ORDER BY date DESC, (base = $1) DESC
+		LIMIT 1
+	`
+
+	rate := models.FXRate{Base: base, Quote: quote}
+	var storedBase string
+	err := s.db.QueryRow(ctx, query, base, quote, date).Scan(&storedBase, &rate.Date, &rate.Rate, &rate.Source)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s/%s on or before %s", ErrFXRateNotFound, base, quote, date.Format("2006-01-02"))
+		}
+		s.logger.Error("Failed to get fx rate",
+			zap.String("base", base),
+			zap.String("quote", quote),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	if storedBase != base {
+		rate.Rate = 1 / rate.Rate
+		rate.Derived = "inverse"
+	}
+	return &rate, nil
+}
+
+// Convert converts amount from one currency to another at the rate in force on date
+func (s *FXService) Convert(ctx context.Context, amount float64, from, to string, date time.Time) (*models.Conversion, error) {
+	rate, err := s.Rate(ctx, from, to, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Conversion{
+		Amount:    amount,
+		From:      rate.Base,
+		To:        rate.Quote,
+		Converted: amount * rate.Rate,
+		Rate:      *rate,
+	}, nil
+}
+
+// Upsert stores rates, replacing any already stored for the same pair and date
+func (s *FXService) Upsert(ctx context.Context, rates []models.FXRateInput) (int, error) {
+	batch := &pgx.Batch{}
+	query := `
+		INSERT INTO fx_rates (base, quote, date, rate, source)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (base, quote, date) DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source
+	`
+	for i, r := range rates {
+		date, err := time.Parse("2006-01-02", r.Date)
+		if err != nil {
+			return 0, fmt.Errorf("%w: rate %d has date %q, use YYYY-MM-DD", ErrInvalidFXRate, i, r.Date)
+		}
+		base, quote := strings.ToUpper(r.Base), strings.ToUpper(r.Quote)
+		if base == quote {
+			return 0, fmt.Errorf("%w: rate %d converts %s to itself", ErrInvalidFXRate, i, base)
+		}
+		source := r.Source
+		if source == "" {
+			source = "manual"
+		}
+		batch.Queue(query, base, quote, date, r.Rate, source)
+	}
+
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		br := tx.SendBatch(ctx, batch)
+		defer br.Close()
+
+		for i := 0; i < batch.Len(); i++ {
+			if _, err := br.Exec(); err != nil {
+				return fmt.Errorf("failed to store rate %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to store fx rates", zap.Error(err))
+		return 0, err
+	}
+
+	return batch.Len(), nil
+}
+
+// fxHomeCurrency is the currency of symbols with no listing or exchange currency
+// on record, and of portfolio cash
+const fxHomeCurrency = "IDR"
+
+// ValidCurrency reports whether code looks like an ISO 4217 currency code
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Currencies returns the currency each symbol is quoted in: its listing's, else its
+// exchange's, else IDR
+func (s *FXService) Currencies(ctx context.Context, symbols []string) (map[string]string, error) {
+	currencies := make(map[string]string, len(symbols))
+	for _, symbol := range symbols {
+		currencies[symbol] = fxHomeCurrency
+	}
+	if len(symbols) == 0 {
+		return currencies, nil
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT DISTINCT ON (s.symbol) s.symbol, COALESCE(NULLIF(s.currency, ''), e.currency, '')
+		FROM symbols s
+		LEFT JOIN exchanges e ON e.code = s.exchange
+		WHERE s.symbol = ANY($1)
+		ORDER BY s.symbol, s.exchange
+	`, symbols)
+	if err != nil {
+		s.logger.Error("Failed to get symbol currencies", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var symbol, currency string
+		if err := rows.Scan(&symbol, &currency); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if currency != "" {
+			currencies[symbol] = strings.ToUpper(strings.TrimSpace(currency))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return currencies, nil
+}
+
+// Converter converts amounts into one currency, looking each currency and date up
+// once
+type Converter struct {
+	fx    *FXService
+	to    string
+	rates map[string]*models.FXRate
+}
+
+// Converter returns a converter into currency to
+func (s *FXService) Converter(to string) *Converter {
+	return &Converter{fx: s, to: strings.ToUpper(to), rates: map[string]*models.FXRate{}}
+}
+
+// Rate returns the rate from currency from into the converter's currency in force
+// on date
+func (c *Converter) Rate(ctx context.Context, from string, date time.Time) (*models.FXRate, error) {
+	key := from + date.Format("2006-01-02")
+	if rate, ok := c.rates[key]; ok {
+		return rate, nil
+	}
+	rate, err := c.fx.Rate(ctx, from, c.to, date)
+	if err != nil {
+		return nil, err
+	}
+	c.rates[key] = rate
+	return rate, nil
+}
+
+// ConvertCandles returns a copy of data with prices converted into currency to at
+// the rate in force on each candle's date
+func (s *FXService) ConvertCandles(ctx context.Context, data []models.MarketData, to string) ([]models.MarketData, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	seen := map[string]bool{}
+	var symbols []string
+	for _, md := range data {
+		if !seen[md.Symbol] {
+			seen[md.Symbol] = true
+			symbols = append(symbols, md.Symbol)
+		}
+	}
+	currencies, err := s.Currencies(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	converter := s.Converter(to)
+	converted := make([]models.MarketData, len(data))
+	for i, md := range data {
+		rate, err := converter.Rate(ctx, currencies[md.Symbol], md.Date)
+		if err != nil {
+			return nil, err
+		}
+		if md.Open != nil {
+			open := roundFX(*md.Open * rate.Rate)
+			md.Open = &open
+		}
+		md.High = roundFX(md.High * rate.Rate)
+		md.Low = roundFX(md.Low * rate.Rate)
+		md.Close = roundFX(md.Close * rate.Rate)
+		if md.AdjClose != nil {
+			adj := roundFX(*md.AdjClose * rate.Rate)
+			md.AdjClose = &adj
+		}
+		converted[i] = md
+	}
+	return converted, nil
+}
+
+// roundFX rounds a converted price to 8 decimals, the precision prices are stored at
+func roundFX(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
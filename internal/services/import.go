@@ -0,0 +1,464 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/importers"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/storage"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrImportNotFound = errors.New("import not found")
+	ErrImportEmpty    = errors.New("CSV file is empty")
+)
+
+const (
+	// importBatchSize is how many parsed rows are screened and stored at a time
+	importBatchSize = 5000
+	// maxImportRowErrors caps the per-row errors kept on a job
+	maxImportRowErrors = 1000
+)
+
+type ImportService struct {
+	db      *database.DB
+	market  *MarketService
+	anomaly *AnomalyService
+	store   storage.ObjectStore
+	hooks   *WebhookService // tells users their imports finished; may be nil
+	queue   chan int64
+	logger  *zap.Logger
+}
+
+func NewImportService(db *database.DB, market *MarketService, anomaly *AnomalyService, store storage.ObjectStore, hooks *WebhookService) *ImportService {
+	return &ImportService{
+		db:      db,
+		market:  market,
+		anomaly: anomaly,
+		store:   store,
+		hooks:   hooks,
+		queue:   make(chan int64, 100),
+		logger:  logger.With(zap.String("service", "import")),
+	}
+}
+
+const importJobColumns = `id, user_id, filename, object_key, on_conflict, format, symbol, interval, status, size_bytes, bytes_read,
+	rows_processed, rows_inserted, rows_updated, rows_skipped, rows_rejected, errors, error,
+	created_at, started_at, completed_at`
+
+// Create stores an uploaded CSV and queues a job to import it. An empty format is
+// detected from the file's header when the job runs.
+func (s *ImportService) Create(ctx context.Context, userID, filename string, file io.Reader, onConflict, format string, opts importers.Options) (*models.ImportJob, error) {
+	query := `
+		INSERT INTO import_jobs (user_id, filename, on_conflict, format, symbol, interval)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + importJobColumns
+
+	job, err := scanImportJob(s.db.QueryRow(ctx, query, userID, filename, onConflict, format, opts.Symbol, opts.Interval))
+	if err != nil {
+		s.logger.Error("Failed to create import job",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	id := job.ID
+	key := fmt.Sprintf("imports/%s/%d.csv", userID, id)
+	size, err := s.store.Put(ctx, key, file)
+	if err != nil {
+		err = fmt.Errorf("failed to store upload: %w", err)
+		s.fail(ctx, id, err)
+		return nil, err
+	}
+
+	query = `
+		UPDATE import_jobs SET object_key = $2, size_bytes = $3
+		WHERE id = $1
+		RETURNING ` + importJobColumns
+	job, err = scanImportJob(s.db.QueryRow(ctx, query, id, key, size))
+	if err != nil {
+		s.logger.Error("Failed to record import upload", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	s.enqueue(job.ID)
+	return job, nil
+}
+
+// Get returns a job owned by userID
+func (s *ImportService) Get(ctx context.Context, userID string, id int64) (*models.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE id = $1 AND user_id = $2`
+
+	job, err := scanImportJob(s.db.QueryRow(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrImportNotFound
+		}
+		s.logger.Error("Failed to get import job",
+			zap.Int64("id", id),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// List returns a user's most recent import jobs without their row errors
+func (s *ImportService) List(ctx context.Context, userID string) ([]models.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE user_id = $1 ORDER BY created_at DESC LIMIT 50`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		s.logger.Error("Failed to list import jobs",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.ImportJob{}
+	for rows.Next() {
+		job, err := scanImportJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		job.Errors = nil
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// ImportFilter narrows the import history
+type ImportFilter struct {
+	UserID string
+	Status string
+	Limit  int
+}
+
+// History returns the most recent import jobs of every user, without their row errors
+func (s *ImportService) History(ctx context.Context, filter ImportFilter) ([]models.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs
+		WHERE ($1 = '' OR user_id = $1) AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3`
+
+	rows, err := s.db.Query(ctx, query, filter.UserID, filter.Status, filter.Limit)
+	if err != nil {
+		s.logger.Error("Failed to list import history", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.ImportJob{}
+	for rows.Next() {
+		job, err := scanImportJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		job.Errors = nil
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// Start runs the import worker until ctx is cancelled. Pending jobs left by a
+// previous process are queued again; running ones are failed, since some of
+// their batches may already be stored.
+func (s *ImportService) Start(ctx context.Context) {
+	if err := s.recoverUnfinished(ctx); err != nil {
+		s.logger.Error("Failed to recover unfinished imports", zap.Error(err))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.queue:
+			s.process(ctx, id)
+		}
+	}
+}
+
+func (s *ImportService) enqueue(id int64) {
+	select {
+	case s.queue <- id:
+	default:
+		// Queue full: the job stays pending and is picked up on the next restart
+		s.logger.Warn("Import queue full, job left pending", zap.Int64("id", id))
+	}
+}
+
+func (s *ImportService) recoverUnfinished(ctx context.Context) error {
+	rows, err := s.db.Query(ctx, `
+		UPDATE import_jobs
+		SET status = 'failed', error = 'interrupted by a restart', completed_at = CURRENT_TIMESTAMP
+		WHERE status = 'running'
+		RETURNING object_key
+	`)
+	if err != nil {
+		return err
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to collect rows: %w", err)
+	}
+	for _, key := range keys {
+		s.discard(ctx, key)
+	}
+
+	rows, err = s.db.Query(ctx, `SELECT id FROM import_jobs WHERE status = 'pending' ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	for _, id := range ids {
+		s.enqueue(id)
+	}
+	return nil
+}
+
+func (s *ImportService) process(ctx context.Context, id int64) {
+	query := `
+		UPDATE import_jobs SET status = 'running', started_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+		RETURNING ` + importJobColumns
+
+	job, err := scanImportJob(s.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Error("Failed to start import job", zap.Int64("id", id), zap.Error(err))
+		}
+		return
+	}
+	defer s.discard(ctx, job.ObjectKey)
+
+	if err := s.run(ctx, job); err != nil {
+		s.logger.Error("Import job failed",
+			zap.Int64("id", id),
+			zap.String("user_id", job.UserID),
+			zap.Error(err),
+		)
+		s.fail(ctx, id, err)
+		job.Status, job.Error = models.ImportFailed, err.Error()
+		s.notify(ctx, job)
+		return
+	}
+
+	if _, err := s.db.Exec(ctx, `
+		UPDATE import_jobs SET status = 'completed', completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id); err != nil {
+		s.logger.Error("Failed to mark import job completed", zap.Int64("id", id), zap.Error(err))
+		return
+	}
+
+	s.logger.Info("Import finished",
+		zap.Int64("id", id),
+		zap.String("user_id", job.UserID),
+		zap.Int64("rows", job.RowsProcessed),
+		zap.Int64("inserted", job.RowsInserted),
+		zap.Int64("updated", job.RowsUpdated),
+		zap.Int64("rejected", job.RowsRejected),
+	)
+	job.Status = models.ImportCompleted
+	s.notify(ctx, job)
+}
+
+// notify tells the job's owner it finished, through their webhooks
+func (s *ImportService) notify(ctx context.Context, job *models.ImportJob) {
+	event := models.EventImportCompleted
+	if job.Status == models.ImportFailed {
+		event = models.EventImportFailed
+	}
+	s.hooks.Dispatch(ctx, job.UserID, event, models.ImportFinished{
+		ID:            job.ID,
+		Filename:      job.Filename,
+		Status:        job.Status,
+		RowsProcessed: job.RowsProcessed,
+		RowsInserted:  job.RowsInserted,
+		RowsUpdated:   job.RowsUpdated,
+		RowsSkipped:   job.RowsSkipped,
+		RowsRejected:  job.RowsRejected,
+		Error:         job.Error,
+	})
+}
+
+// run streams the stored file, storing each batch of parsed rows and recording
+// progress after it, so a failure keeps every batch before it
+func (s *ImportService) run(ctx context.Context, job *models.ImportJob) error {
+	body, err := s.store.Open(ctx, job.ObjectKey)
+	if err != nil {
+		return fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer body.Close()
+
+	counter := &countingReader{r: body}
+	reader := csv.NewReader(counter)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return ErrImportEmpty
+		}
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	parse, format, err := importers.Open(job.Format, header, importers.Options{Symbol: job.Symbol, Interval: job.Interval})
+	if err != nil {
+		return err
+	}
+	job.Format = format
+
+	batch := make([]models.MarketData, 0, importBatchSize)
+	row := int64(1)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		row++
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return fmt.Errorf("failed to read upload: %w", err)
+			}
+			// A malformed row does not stop the import
+			job.RowsProcessed++
+			job.RowsRejected++
+			addRowError(job, row, err)
+			continue
+		}
+
+		job.RowsProcessed++
+		md, err := parse(record)
+		if err != nil {
+			job.RowsRejected++
+			addRowError(job, row, err)
+			continue
+		}
+		batch = append(batch, md)
+
+		if len(batch) == importBatchSize {
+			if err := s.storeBatch(ctx, job, batch, counter.n); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	return s.storeBatch(ctx, job, batch, counter.n)
+}
+
+func (s *ImportService) storeBatch(ctx context.Context, job *models.ImportJob, batch []models.MarketData, bytesRead int64) error {
+	if len(batch) > 0 {
+		accepted, _, err := s.anomaly.Screen(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to screen rows: %w", err)
+		}
+		job.RowsRejected += int64(len(batch) - len(accepted))
+
+		if len(accepted) > 0 {
+			result, err := s.market.BulkCreateWithConflict(ctx, accepted, BulkOptions{OnConflict: job.OnConflict})
+			if err != nil {
+				return err
+			}
+			job.RowsInserted += int64(result.Inserted)
+			job.RowsUpdated += int64(result.Updated)
+			job.RowsSkipped += int64(result.Skipped)
+		}
+	}
+	job.BytesRead = bytesRead
+
+	_, err := s.db.Exec(ctx, `
+		UPDATE import_jobs
+		SET format = $2, bytes_read = $3, rows_processed = $4, rows_inserted = $5, rows_updated = $6,
+			rows_skipped = $7, rows_rejected = $8, errors = $9
+		WHERE id = $1
+	`, job.ID, job.Format, job.BytesRead, job.RowsProcessed, job.RowsInserted, job.RowsUpdated,
+		job.RowsSkipped, job.RowsRejected, job.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to record progress: %w", err)
+	}
+	return nil
+}
+
+func addRowError(job *models.ImportJob, row int64, err error) {
+	if len(job.Errors) < maxImportRowErrors {
+		job.Errors = append(job.Errors, models.ImportRowError{Row: row, Error: err.Error()})
+	}
+}
+
+func (s *ImportService) fail(ctx context.Context, id int64, err error) {
+	if _, dbErr := s.db.Exec(ctx, `
+		UPDATE import_jobs SET status = 'failed', error = $2, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id, err.Error()); dbErr != nil {
+		s.logger.Error("Failed to mark import job failed", zap.Int64("id", id), zap.Error(dbErr))
+	}
+}
+
+// discard removes an uploaded file once its job can no longer run
+func (s *ImportService) discard(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := s.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.logger.Warn("Failed to delete import upload", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func scanImportJob(row pgx.Row) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := row.Scan(
+		&job.ID, &job.UserID, &job.Filename, &job.ObjectKey, &job.OnConflict, &job.Format,
+		&job.Symbol, &job.Interval, &job.Status,
+		&job.SizeBytes, &job.BytesRead, &job.RowsProcessed, &job.RowsInserted, &job.RowsUpdated,
+		&job.RowsSkipped, &job.RowsRejected, &job.Errors, &job.Error,
+		&job.CreatedAt, &job.StartedAt, &job.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if job.SizeBytes > 0 {
+		job.Progress = float64(job.BytesRead) / float64(job.SizeBytes) * 100
+	}
+	return &job, nil
+}
+
+// countingReader tracks how far into the upload the parser has read
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/wal"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ErrIngestBufferFull is returned when buffering an ingest would grow the
+// write-ahead log past its size cap
+var ErrIngestBufferFull = errors.New("ingest buffer is full")
+
+// defaultReplayInterval is used when no positive replay interval is configured
+const defaultReplayInterval = 5 * time.Second
+
+// IngestBuffer holds bulk ingests in a write-ahead log while the database is
+// unavailable and replays them, oldest first, once it is back. Ingests of a symbol
+// with buffered data queue behind it, so each symbol's writes land in the order
+// they were received.
+type IngestBuffer struct {
+	log      *wal.Log // nil when buffering is off
+	rejected *wal.Log // replayed ingests the database refused, kept for inspection
+	market   *MarketService
+	anomaly  *AnomalyService
+	interval time.Duration
+
+	mu           sync.Mutex
+	symbols      map[string]int // pending ingests per symbol
+	replaying    bool
+	replayed     int
+	rowsReplayed int
+	rejects      int
+	lastReplayAt *time.Time
+	lastError    string
+
+	logger *zap.Logger
+}
+
+// NewIngestBuffer buffers into log, which may be nil to turn buffering off.
+// Ingests refused on replay are appended to a log next to it.
+func NewIngestBuffer(log *wal.Log, market *MarketService, anomaly *AnomalyService, interval time.Duration) (*IngestBuffer, error) {
+	if interval <= 0 {
+		interval = defaultReplayInterval
+	}
+	b := &IngestBuffer{
+		log:      log,
+		market:   market,
+		anomaly:  anomaly,
+		interval: interval,
+		symbols:  map[string]int{},
+		logger:   logger.With(zap.String("service", "ingest_buffer")),
+	}
+	if log == nil {
+		return b, nil
+	}
+
+	var err error
+	if b.rejected, err = wal.Open(log.Path()+".rejected", 0); err != nil {
+		return nil, err
+	}
+
+	// Ingests left over from before a restart still hold their symbols
+	pending, err := log.Pending()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range pending {
+		var ingest models.BufferedIngest
+		if err := json.Unmarshal(e.Payload, &ingest); err != nil {
+			// Rejected when replay reaches it
+			b.logger.Warn("Undecodable buffered ingest", zap.Uint64("seq", e.Seq), zap.Error(err))
+			continue
+		}
+		b.hold(ingest.Data, 1)
+	}
+	return b, nil
+}
+
+// Enabled reports whether ingests are buffered
+func (b *IngestBuffer) Enabled() bool {
+	return b.log != nil
+}
+
+// Holds reports whether any of data's symbols has buffered ingests waiting; new
+// writes to those symbols must be buffered behind them
+func (b *IngestBuffer) Holds(data []models.MarketData) bool {
+	if !b.Enabled() {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, md := range data {
+		if b.symbols[md.Symbol] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Absorbs reports whether a write that failed with err can be buffered instead
+func (b *IngestBuffer) Absorbs(err error) bool {
+	return b.Enabled() && database.IsUnavailable(err)
+}
+
+// Buffer appends an ingest to the log, returning its sequence and how many
+// ingests are now waiting. screened is set when data already passed screening.
+func (b *IngestBuffer) Buffer(data []models.MarketData, onConflict string, screened bool) (uint64, int, error) {
+	payload, err := json.Marshal(models.BufferedIngest{
+		ReceivedAt: time.Now().UTC(),
+		OnConflict: onConflict,
+		Screened:   screened,
+		Data:       data,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to encode ingest: %w", err)
+	}
+
+	b.mu.Lock()
+	seq, err := b.log.Append(payload)
+	if err == nil {
+		b.hold(data, 1)
+	}
+	b.mu.Unlock()
+	if err != nil {
+		if errors.Is(err, wal.ErrFull) {
+			return 0, 0, ErrIngestBufferFull
+		}
+		b.logger.Error("Failed to buffer ingest", zap.Int("rows", len(data)), zap.Error(err))
+		return 0, 0, err
+	}
+
+	_, pending := b.log.Stats()
+	return seq, pending, nil
+}
+
+// Start replays buffered ingests now and then every interval until ctx is
+// cancelled. It returns at once when buffering is off.
+func (b *IngestBuffer) Start(ctx context.Context) {
+	if !b.Enabled() {
+		return
+	}
+	_, pending := b.log.Stats()
+	b.logger.Info("Ingest buffer enabled",
+		zap.String("path", b.log.Path()),
+		zap.Int64("max_bytes", b.log.MaxBytes()),
+		zap.Int("pending", pending),
+	)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		b.replay(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// replay writes buffered ingests in order until the log is empty or the database
+// is unavailable again
+func (b *IngestBuffer) replay(ctx context.Context) {
+	b.mu.Lock()
+	b.replaying = true
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.replaying = false
+		b.mu.Unlock()
+	}()
+
+	for ctx.Err() == nil {
+		entry, ok, err := b.log.Next()
+		if err != nil {
+			b.failed("Failed to read ingest buffer", err)
+			return
+		}
+		if !ok {
+			return
+		}
+
+		var ingest models.BufferedIngest
+		if err := json.Unmarshal(entry.Payload, &ingest); err != nil {
+			if !b.reject(entry, ingest, err) {
+				return
+			}
+			continue
+		}
+
+		rows, err := b.write(ctx, ingest)
+		if err != nil {
+			if ctx.Err() != nil || database.IsUnavailable(err) || errors.Is(err, ErrImportInProgress) {
+				// Stop here so nothing behind this ingest overtakes it
+				b.failed("Ingest replay deferred", err)
+				return
+			}
+			if !b.reject(entry, ingest, err) {
+				return
+			}
+			continue
+		}
+
+		if err := b.log.Ack(entry); err != nil {
+			b.failed("Failed to acknowledge replayed ingest", err)
+			return
+		}
+		now := time.Now().UTC()
+		b.mu.Lock()
+		b.hold(ingest.Data, -1)
+		b.replayed++
+		b.rowsReplayed += rows
+		b.lastReplayAt = &now
+		b.lastError = ""
+		b.mu.Unlock()
+		b.logger.Info("Replayed buffered ingest",
+			zap.Uint64("seq", entry.Seq),
+			zap.Int("rows", rows),
+			zap.Time("received_at", ingest.ReceivedAt),
+		)
+	}
+}
+
+// write stores one buffered ingest in a single transaction, screening it first
+// unless it was screened before it was buffered
+func (b *IngestBuffer) write(ctx context.Context, ingest models.BufferedIngest) (int, error) {
+	data := ingest.Data
+	if !ingest.Screened {
+		accepted, _, err := b.anomaly.Screen(ctx, data)
+		if err != nil {
+			return 0, err
+		}
+		data = accepted
+	}
+	result, err := b.market.BulkCreateWithConflict(ctx, data, BulkOptions{OnConflict: ingest.OnConflict})
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsCommitted, nil
+}
+
+// reject moves an ingest the database refused to the rejected log so replay can
+// carry on past it, reporting whether it could
+func (b *IngestBuffer) reject(entry wal.Entry, ingest models.BufferedIngest, cause error) bool {
+	b.logger.Error("Buffered ingest rejected on replay",
+		zap.Uint64("seq", entry.Seq),
+		zap.Int("rows", len(ingest.Data)),
+		zap.Error(cause),
+	)
+	if _, err := b.rejected.Append(entry.Payload); err != nil {
+		b.failed("Failed to keep rejected ingest", err)
+		return false
+	}
+	if err := b.log.Ack(entry); err != nil {
+		b.failed("Failed to acknowledge rejected ingest", err)
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.hold(ingest.Data, -1)
+	b.rejects++
+	b.lastError = cause.Error()
+	return true
+}
+
+func (b *IngestBuffer) failed(msg string, err error) {
+	b.logger.Warn(msg, zap.Error(err))
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastError = err.Error()
+}
+
+// hold adds delta to the pending count of each symbol in data; callers hold b.mu
+func (b *IngestBuffer) hold(data []models.MarketData, delta int) {
+	seen := map[string]bool{}
+	for _, md := range data {
+		if seen[md.Symbol] {
+			continue
+		}
+		seen[md.Symbol] = true
+		if b.symbols[md.Symbol] += delta; b.symbols[md.Symbol] <= 0 {
+			delete(b.symbols, md.Symbol)
+		}
+	}
+}
+
+// Status reports what is buffered and how replay is going
+func (b *IngestBuffer) Status() models.IngestBufferStatus {
+	status := models.IngestBufferStatus{Enabled: b.Enabled(), Symbols: map[string]int{}}
+	if !status.Enabled {
+		return status
+	}
+	status.Bytes, status.Pending = b.log.Stats()
+	status.MaxBytes = b.log.MaxBytes()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for symbol, n := range b.symbols {
+		status.Symbols[symbol] = n
+	}
+	status.Replaying = b.replaying
+	status.Replayed = b.replayed
+	status.RowsReplayed = b.rowsReplayed
+	status.Rejected = b.rejects
+	status.LastReplayAt = b.lastReplayAt
+	status.LastError = b.lastError
+	return status
+}
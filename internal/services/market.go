@@ -2,40 +2,297 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/ridhomain/proto-trading-service/internal/cache"
 	"github.com/ridhomain/proto-trading-service/internal/database"
 	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/storage"
+	"github.com/ridhomain/proto-trading-service/internal/stream"
 	"github.com/ridhomain/proto-trading-service/pkg/logger"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 )
 
+// MarketOptions tunes how the market service guards concurrent writes and large reads,
+// and where old candles are archived
+type MarketOptions struct {
+	ImportLockTimeout time.Duration       // how long bulk writes wait for other imports of the same symbols
+	MaxRangeRows      int64               // largest date-range read served synchronously
+	Outbox            *OutboxService      // announces written candles; nil to not announce them
+	Archive           storage.ObjectStore // holds candles moved out of the database; nil to keep them all
+}
+
 type MarketService struct {
 	db     *database.DB
+	hub    *stream.Hub
+	cache  cache.Cache
+	opts   MarketOptions
 	logger *zap.Logger
 }
 
-func NewMarketService(db *database.DB) *MarketService {
+// NewMarketService creates the service; rows it stores are published to hub, and
+// hot per-symbol reads are kept in readCache until the symbol is written
+func NewMarketService(db *database.DB, hub *stream.Hub, readCache cache.Cache, opts MarketOptions) *MarketService {
 	return &MarketService{
 		db:     db,
+		hub:    hub,
+		cache:  readCache,
+		opts:   opts,
 		logger: logger.With(zap.String("service", "market")),
 	}
 }
 
-// GetBySymbol retrieves market data for a symbol
-func (s *MarketService) GetBySymbol(ctx context.Context, symbol string, limit int) ([]models.MarketData, error) {
-	query := `
-		SELECT id, symbol, date, open, high, low, close, volume, source, created_at 
-		FROM market_data 
-		WHERE symbol = $1 
-		ORDER BY date DESC 
-		LIMIT $2
-	`
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page selects a slice of a symbol's history, newest first. A Cursor from a
+// previous page takes precedence over Offset.
+type Page struct {
+	Limit    int
+	Offset   int
+	Cursor   string
+	Source   string // "" for every source, SourceAny or a merged value to merge them
+	Interval string // candle interval; daily when ""
+	Exchange string // "" for every exchange listing the symbol
+}
+
+// SourceAny merges overlapping sources into one candle per symbol and timestamp,
+// preferring the source with the lowest sources.priority
+const SourceAny = "any"
+
+// SourceMerged merges sources like SourceAny. Built by MergedPreferring, it ranks one
+// source, usually the user's default, ahead of sources.priority.
+const SourceMerged = "merged"
+
+// preferableSource is the shape of a source name a merge may prefer. Names are
+// checked against it before they are written into a query.
+var preferableSource = regexp.MustCompile(`^[a-z0-9_-]{1,40}$`)
+
+// MergedPreferring returns the source value that merges every source, ranking
+// preferred first; without a usable preference it merges by sources.priority alone
+func MergedPreferring(preferred string) string {
+	if preferred == SourceAny || preferred == SourceMerged || !preferableSource.MatchString(preferred) {
+		return SourceMerged
+	}
+	return SourceMerged + ":" + preferred
+}
+
+// isMerged reports whether a source value merges every source
+func isMerged(source string) bool {
+	return source == SourceAny || source == SourceMerged || strings.HasPrefix(source, SourceMerged+":")
+}
+
+// mergedLabel names a merged series after the merged value, rebuilt from its
+// validated parts
+func mergedLabel(source string) string {
+	if source == SourceAny {
+		return SourceAny
+	}
+	return MergedPreferring(preferredSource(source))
+}
+
+// preferredSource returns the source a merged value ranks first, or ""
+func preferredSource(source string) string {
+	preferred, ok := strings.CutPrefix(source, SourceMerged+":")
+	if !ok || !preferableSource.MatchString(preferred) {
+		return ""
+	}
+	return preferred
+}
+
+// sourceRank is the ORDER BY that picks the row a merge keeps among alias's sources,
+// joined to sources as src: the preferred source, then the lowest priority
+func sourceRank(source, alias string) string {
+	rank := "COALESCE(src.priority, 1000), " + alias + ".source"
+	if preferred := preferredSource(source); preferred != "" {
+		rank = alias + ".source <> '" + preferred + "', " + rank
+	}
+	return rank
+}
+
+// mergedMarketData keeps the preferred source's row for each listing, interval and
+// timestamp. date follows from ts but is listed so filters on exchange, symbol,
+// interval and date are pushed through DISTINCT ON and reads stay indexed.
+func mergedMarketData(source string) string {
+	return `(
+		SELECT DISTINCT ON (m.exchange, m.symbol, m.interval, m.date, m.ts) m.*
+		FROM market_data m
+		LEFT JOIN sources src ON src.name = m.source
+		ORDER BY m.exchange, m.symbol, m.interval, m.date, m.ts, ` + sourceRank(source, "m") + `
+	) md`
+}
+
+// ingestedScope is sourceScope for reads limited to candles first stored before
+// the timestamp bound to $param, or to every candle when it is NULL. The limit applies
+// before merging, so a source's later candle does not hide one stored in time.
+func ingestedScope(source, param string) (string, string) {
+	if !isMerged(source) {
+		return "market_data md", source
+	}
+	return `(
+		SELECT DISTINCT ON (m.exchange, m.symbol, m.interval, m.date, m.ts) m.*
+		FROM market_data m
+		LEFT JOIN sources src ON src.name = m.source
+		WHERE ` + param + `::timestamp IS NULL OR m.created_at < ` + param + `
+		ORDER BY m.exchange, m.symbol, m.interval, m.date, m.ts, ` + sourceRank(source, "m") + `
+	) md`, ""
+}
+
+// nullableTime passes a zero time as NULL
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// intervalOrDaily returns interval, or daily when it is empty
+func intervalOrDaily(interval string) string {
+	if interval == "" {
+		return models.IntervalDaily
+	}
+	return interval
+}
+
+// sourceScope returns the relation a read selects from, aliased md, and the value
+// to bind to its source filter ("" when the relation is already merged)
+func sourceScope(source string) (string, string) {
+	if isMerged(source) {
+		return mergedMarketData(source), ""
+	}
+	return "market_data md", source
+}
+
+// cachedPage is a GetBySymbol result as stored in the read cache
+type cachedPage struct {
+	Data []models.MarketData `json:"data"`
+	Next string              `json:"next"`
+}
+
+// GetBySymbol retrieves a page of market data for a symbol, returning the cursor
+// for the next page or "" when this is the last one
+func (s *MarketService) GetBySymbol(ctx context.Context, symbol string, page Page) ([]models.MarketData, string, error) {
+	page.Interval = intervalOrDaily(page.Interval)
+	field := fmt.Sprintf("page:%s:%s:%s:%d:%d:%s", page.Source, page.Interval, page.Exchange, page.Limit, page.Offset, page.Cursor)
+	var cached cachedPage
+	if s.cached(ctx, symbol, field, &cached) {
+		return cached.Data, cached.Next, nil
+	}
+
+	data, next, err := s.getBySymbol(ctx, symbol, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.fill(ctx, symbol, field, cachedPage{Data: data, Next: next})
+	return data, next, nil
+}
+
+// getBySymbol reads a page newest first. Archived years are the oldest, so a page
+// starts with the live candles after them and continues into the archived span.
+func (s *MarketService) getBySymbol(ctx context.Context, symbol string, page Page) ([]models.MarketData, string, error) {
+	var cursorTS time.Time
+	var cursorID int64
+	if page.Cursor != "" {
+		var err error
+		if cursorTS, cursorID, err = decodeCursor(page.Cursor); err != nil {
+			return nil, "", err
+		}
+	}
+	objects, err := s.archiveObjects(ctx, symbol, page.Source, page.Interval, page.Exchange, time.Time{}, archiveHorizon)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var spanEnd time.Time
+	if len(objects) > 0 {
+		spanEnd = archiveSpanEnd(objects, archiveHorizon)
+	}
+	inSpan := len(objects) > 0 && page.Cursor != "" && !dateOf(cursorTS).After(spanEnd)
+
+	var results []models.MarketData
+	if !inSpan {
+		if results, err = s.liveBySymbol(ctx, symbol, page, cursorTS, cursorID, spanEnd); err != nil {
+			return nil, "", err
+		}
+	}
+	if len(objects) > 0 && len(results) <= page.Limit {
+		skip := 0
+		if page.Cursor == "" && page.Offset > 0 && len(results) == 0 {
+			// The offset may reach past every live candle after the span
+			later, err := s.countBySymbolAfter(ctx, symbol, page, spanEnd)
+			if err != nil {
+				return nil, "", err
+			}
+			skip = max(page.Offset-int(later), 0)
+		}
+		if !inSpan {
+			cursorTS, cursorID = time.Time{}, 0
+		}
+		more, err := s.archivedPage(ctx, symbol, page, objects, spanEnd, cursorTS, cursorID, skip, page.Limit+1-len(results))
+		if err != nil {
+			return nil, "", err
+		}
+		results = append(results, more...)
+	}
 
-	rows, err := s.db.Query(ctx, query, symbol, limit)
+	// One extra row was fetched to learn whether another page exists
+	var next string
+	if len(results) > page.Limit {
+		results = results[:page.Limit]
+		last := results[len(results)-1]
+		next = encodeCursor(last.Timestamp, last.ID)
+	}
+
+	return results, next, nil
+}
+
+// archiveHorizon is the end date of a read without one
+var archiveHorizon = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// liveBySymbol reads up to page.Limit+1 candles from market_data, newest first, from
+// the page's offset or after its cursor. A non-zero after keeps only candles dated
+// after it.
+func (s *MarketService) liveBySymbol(ctx context.Context, symbol string, page Page, cursorTS time.Time, cursorID int64, after time.Time) ([]models.MarketData, error) {
+	from, source := sourceScope(page.Source)
+	query := fmt.Sprintf(`
+		SELECT id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+			COALESCE(updated_at, created_at)
+		FROM %s
+		WHERE symbol = $1 AND interval = $5 AND ($4 = '' OR source = $4) AND ($6 = '' OR exchange = $6)
+			AND ($7::date IS NULL OR date > $7)
+		ORDER BY ts DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, from)
+	args := []interface{}{symbol, page.Limit + 1, page.Offset, source, page.Interval, page.Exchange, nullableTime(after)}
+
+	if page.Cursor != "" {
+		query = fmt.Sprintf(`
+			SELECT id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+				COALESCE(updated_at, created_at)
+			FROM %s
+			WHERE symbol = $1 AND interval = $6 AND ($5 = '' OR source = $5) AND ($7 = '' OR exchange = $7)
+				AND (ts, id) < ($3, $4) AND ($8::date IS NULL OR date > $8)
+			ORDER BY ts DESC, id DESC
+			LIMIT $2
+		`, from)
+		args = []interface{}{symbol, page.Limit + 1, cursorTS, cursorID, source, page.Interval, page.Exchange, nullableTime(after)}
+	}
+
+	rows, err := s.db.Query(ctx, query, args...)
 	if err != nil {
 		s.logger.Error("Failed to get market data by symbol",
 			zap.String("symbol", symbol),
@@ -49,8 +306,8 @@ func (s *MarketService) GetBySymbol(ctx context.Context, symbol string, limit in
 	for rows.Next() {
 		var md models.MarketData
 		err := rows.Scan(
-			&md.ID, &md.Symbol, &md.Date, &md.Open, &md.High,
-			&md.Low, &md.Close, &md.Volume, &md.Source, &md.CreatedAt,
+			&md.ID, &md.Exchange, &md.Symbol, &md.Interval, &md.Date, &md.Timestamp, &md.Open, &md.High,
+			&md.Low, &md.Close, &md.AdjClose, &md.Volume, &md.Source, &md.CreatedAt, &md.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
@@ -65,16 +322,78 @@ func (s *MarketService) GetBySymbol(ctx context.Context, symbol string, limit in
 	return results, nil
 }
 
-// GetBySymbolAndDateRange retrieves market data within a date range
-func (s *MarketService) GetBySymbolAndDateRange(ctx context.Context, symbol string, startDate, endDate time.Time) ([]models.MarketData, error) {
-	query := `
-		SELECT id, symbol, date, open, high, low, close, volume, source, created_at 
-		FROM market_data 
-		WHERE symbol = $1 AND date >= $2 AND date <= $3
-		ORDER BY date ASC
-	`
+// countBySymbolAfter counts the live candles a page would list that are dated after
+// a date
+func (s *MarketService) countBySymbolAfter(ctx context.Context, symbol string, page Page, after time.Time) (int64, error) {
+	from, source := sourceScope(page.Source)
+	var count int64
+	err := s.db.QueryRow(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FROM %s
+		WHERE symbol = $1 AND interval = $2 AND ($3 = '' OR source = $3) AND ($4 = '' OR exchange = $4) AND date > $5
+	`, from), symbol, page.Interval, source, page.Exchange, after).Scan(&count)
+	if err != nil {
+		s.logger.Error("Failed to count market data by symbol", zap.String("symbol", symbol), zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
+
+// encodeCursor packs the keyset position of the last row served into an opaque token
+func encodeCursor(ts time.Time, id int64) string {
+	raw := fmt.Sprintf("%s|%d", ts.Format(time.RFC3339), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	tsStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	ts, err := time.Parse(time.RFC3339, tsStr)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	return ts, id, nil
+}
+
+// GetBySymbolAndDateRange retrieves market data of one interval within a date range;
+// source "" means every source and SourceAny merges them, exchange "" every listing.
+// A non-zero ingestedBefore keeps only candles first stored before it, with their
+// current values. Archived years are read back from the archive store.
+func (s *MarketService) GetBySymbolAndDateRange(ctx context.Context, symbol, source, interval, exchange string, startDate, endDate, ingestedBefore time.Time) ([]models.MarketData, error) {
+	live, err := s.getBySymbolAndDateRange(ctx, symbol, source, interval, exchange, startDate, endDate, ingestedBefore)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := s.archiveObjects(ctx, symbol, source, interval, exchange, startDate, endDate)
+	if err != nil || len(objects) == 0 {
+		return live, err
+	}
+	return s.withArchived(ctx, live, objects, symbol, source, interval, exchange, startDate, endDate, ingestedBefore)
+}
 
-	rows, err := s.db.Query(ctx, query, symbol, startDate, endDate)
+func (s *MarketService) getBySymbolAndDateRange(ctx context.Context, symbol, source, interval, exchange string, startDate, endDate, ingestedBefore time.Time) ([]models.MarketData, error) {
+	from, filter := ingestedScope(source, "$7")
+	query := fmt.Sprintf(`
+		SELECT id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+			COALESCE(updated_at, created_at)
+		FROM %s
+		WHERE symbol = $1 AND interval = $5 AND date >= $2 AND date <= $3 AND ($4 = '' OR source = $4)
+			AND ($6 = '' OR exchange = $6) AND ($7::timestamp IS NULL OR created_at < $7)
+		ORDER BY ts ASC
+	`, from)
+
+	rows, err := s.db.Query(ctx, query, symbol, startDate, endDate, filter, intervalOrDaily(interval), exchange, nullableTime(ingestedBefore))
 	if err != nil {
 		s.logger.Error("Failed to get market data by date range",
 			zap.String("symbol", symbol),
@@ -95,199 +414,1349 @@ func (s *MarketService) GetBySymbolAndDateRange(ctx context.Context, symbol stri
 	return results, nil
 }
 
-// Create inserts new market data
-func (s *MarketService) Create(ctx context.Context, data models.MarketData) (*models.MarketData, error) {
-	query := `
-		INSERT INTO market_data (symbol, date, open, high, low, close, volume, source) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
-		RETURNING id, created_at
-	`
+// StreamBySymbolAndDateRange passes the market data GetBySymbolAndDateRange would
+// return to fn one candle at a time, without holding the range in memory. It stops at
+// the first error fn returns and reports how many candles fn accepted. Archived years
+// are stitched in memory, one range of them at a time, before the rest streams.
+func (s *MarketService) StreamBySymbolAndDateRange(ctx context.Context, symbol, source, interval, exchange string, startDate, endDate, ingestedBefore time.Time, fn func(models.MarketData) error) (int64, error) {
+	objects, err := s.archiveObjects(ctx, symbol, source, interval, exchange, startDate, endDate)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if len(objects) > 0 {
+		spanEnd := archiveSpanEnd(objects, endDate)
+		data, err := s.GetBySymbolAndDateRange(ctx, symbol, source, interval, exchange, startDate, spanEnd, ingestedBefore)
+		if err != nil {
+			return 0, err
+		}
+		for _, md := range data {
+			if err := fn(md); err != nil {
+				return count, err
+			}
+			count++
+		}
+		startDate = spanEnd.AddDate(0, 0, 1)
+		if startDate.After(endDate) {
+			return count, nil
+		}
+	}
+
+	n, err := s.streamBySymbolAndDateRange(ctx, symbol, source, interval, exchange, startDate, endDate, ingestedBefore, fn)
+	return count + n, err
+}
 
-	err := s.db.QueryRow(ctx, query,
-		data.Symbol, data.Date, data.Open, data.High,
-		data.Low, data.Close, data.Volume, data.Source,
-	).Scan(&data.ID, &data.CreatedAt)
+func (s *MarketService) streamBySymbolAndDateRange(ctx context.Context, symbol, source, interval, exchange string, startDate, endDate, ingestedBefore time.Time, fn func(models.MarketData) error) (int64, error) {
+	from, filter := ingestedScope(source, "$7")
+	query := fmt.Sprintf(`
+		SELECT id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+			COALESCE(updated_at, created_at)
+		FROM %s
+		WHERE symbol = $1 AND interval = $5 AND date >= $2 AND date <= $3 AND ($4 = '' OR source = $4)
+			AND ($6 = '' OR exchange = $6) AND ($7::timestamp IS NULL OR created_at < $7)
+		ORDER BY ts ASC
+	`, from)
 
+	rows, err := s.db.Query(ctx, query, symbol, startDate, endDate, filter, intervalOrDaily(interval), exchange, nullableTime(ingestedBefore))
 	if err != nil {
-		s.logger.Error("Failed to create market data",
-			zap.String("symbol", data.Symbol),
+		s.logger.Error("Failed to stream market data by date range",
+			zap.String("symbol", symbol),
+			zap.Time("start_date", startDate),
+			zap.Time("end_date", endDate),
 			zap.Error(err),
 		)
-		return nil, err
+		return 0, err
 	}
+	defer rows.Close()
 
-	return &data, nil
+	var count int64
+	for rows.Next() {
+		md, err := pgx.RowToStructByPos[models.MarketData](rows)
+		if err != nil {
+			return count, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := fn(md); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
 }
 
-// BulkCreate efficiently inserts multiple market data records using COPY
-func (s *MarketService) BulkCreate(ctx context.Context, dataList []models.MarketData) error {
-	if len(dataList) == 0 {
-		return nil
+// GetBySymbolAsOf reconstructs market data within a date range as it was stored at asOf,
+// combining current rows with superseded versions from market_data_history. Archived
+// candles count from when their archived version became current.
+func (s *MarketService) GetBySymbolAsOf(ctx context.Context, symbol, source, interval, exchange string, startDate, endDate, asOf time.Time) ([]models.MarketData, error) {
+	objects, err := s.archiveObjects(ctx, symbol, source, interval, exchange, startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
-
-	// Prepare data for COPY
-	rows := make([][]interface{}, len(dataList))
-	for i, data := range dataList {
-		rows[i] = []interface{}{
-			data.Symbol,
-			data.Date,
-			data.Open,
-			data.High,
-			data.Low,
-			data.Close,
-			data.Volume,
-			data.Source,
-		}
+	if len(objects) == 0 {
+		return s.getBySymbolAsOf(ctx, symbol, source, interval, exchange, startDate, endDate, asOf)
 	}
 
-	// Use COPY for bulk insert - much faster than individual INSERTs
-	copyCount, err := s.db.CopyFrom(
-		ctx,
-		pgx.Identifier{"market_data"},
-		[]string{"symbol", "date", "open", "high", "low", "close", "volume", "source"},
-		pgx.CopyFromRows(rows),
-	)
-
+	// Merged sources are merged again once the archived versions are in
+	filter := source
+	if isMerged(source) {
+		filter = ""
+	}
+	live, err := s.getBySymbolAsOf(ctx, symbol, filter, interval, exchange, startDate, endDate, asOf)
 	if err != nil {
-		s.logger.Error("Failed to bulk create market data",
-			zap.Int("count", len(dataList)),
-			zap.Error(err),
-		)
-		return err
+		return nil, err
 	}
-
-	s.logger.Info("Bulk created market data",
-		zap.Int64("inserted", copyCount),
-		zap.Int("requested", len(dataList)),
-	)
-
-	return nil
+	return s.withArchivedAsOf(ctx, live, objects, source, startDate, endDate, asOf)
 }
 
-// BulkCreateWithConflict inserts with conflict handling
-func (s *MarketService) BulkCreateWithConflict(ctx context.Context, dataList []models.MarketData) error {
-	if len(dataList) == 0 {
-		return nil
-	}
+func (s *MarketService) getBySymbolAsOf(ctx context.Context, symbol, source, interval, exchange string, startDate, endDate, asOf time.Time) ([]models.MarketData, error) {
+	query := `		SELECT id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+			COALESCE(updated_at, created_at)
+		FROM market_data
+		WHERE symbol = $1 AND interval = $6 AND date >= $2 AND date <= $3 AND ($5 = '' OR source = $5)
+			AND ($7 = '' OR exchange = $7) AND COALESCE(updated_at, created_at) <= $4
+		UNION ALL
+		SELECT market_data_id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+			valid_from
+		FROM market_data_history
+		WHERE symbol = $1 AND interval = $6 AND date >= $2 AND date <= $3 AND ($5 = '' OR source = $5)
+			AND ($7 = '' OR exchange = $7) AND valid_from <= $4 AND valid_to > $4
+	`
 
-	// Use transaction with batch for conflict handling
-	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
-		batch := &pgx.Batch{}
-
-		query := `
-			INSERT INTO market_data (symbol, date, open, high, low, close, volume, source) 
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
-			ON CONFLICT (symbol, date, source) DO UPDATE SET
-				open = EXCLUDED.open,
-				high = EXCLUDED.high,
-				low = EXCLUDED.low,
-				close = EXCLUDED.close,
-				volume = EXCLUDED.volume
+	filter := source
+	if isMerged(source) {
+		// Merge after reconstructing each source's versions so priority applies to the past state
+		filter = ""
+		query = `
+			SELECT DISTINCT ON (v.ts, v.exchange) v.*
+			FROM (` + query + `) v
+			LEFT JOIN sources src ON src.name = v.source
+			ORDER BY v.ts, v.exchange, ` + sourceRank(source, "v") + `
 		`
+	} else {
+		query += ` ORDER BY ts ASC`
+	}
 
-		for _, data := range dataList {
-			batch.Queue(query,
-				data.Symbol, data.Date, data.Open, data.High,
-				data.Low, data.Close, data.Volume, data.Source,
-			)
-		}
+	rows, err := s.db.Query(ctx, query, symbol, startDate, endDate, asOf, filter, intervalOrDaily(interval), exchange)
+	if err != nil {
+		s.logger.Error("Failed to get market data as of timestamp",
+			zap.String("symbol", symbol),
+			zap.Time("start_date", startDate),
+			zap.Time("end_date", endDate),
+			zap.Time("as_of", asOf),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
 
-		br := tx.SendBatch(ctx, batch)
-		defer br.Close()
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.MarketData])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
 
-		// Execute all queries
-		for i := 0; i < batch.Len(); i++ {
-			if _, err := br.Exec(); err != nil {
-				return fmt.Errorf("failed to execute batch item %d: %w", i, err)
-			}
-		}
+	return results, nil
+}
 
-		return nil
-	})
+// GetBySymbolsAndDateRange retrieves market data of one interval for several symbols,
+// ordered by symbol then time
+func (s *MarketService) GetBySymbolsAndDateRange(ctx context.Context, symbols []string, source, interval string, startDate, endDate time.Time) ([]models.MarketData, error) {
+	from, filter := sourceScope(source)
+	query := fmt.Sprintf(`
+		SELECT id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+			COALESCE(updated_at, created_at)
+		FROM %s
+		WHERE symbol = ANY($1) AND interval = $5 AND date >= $2 AND date <= $3 AND ($4 = '' OR source = $4)
+		ORDER BY symbol ASC, ts ASC
+	`, from)
 
+	rows, err := s.db.Query(ctx, query, symbols, startDate, endDate, filter, intervalOrDaily(interval))
 	if err != nil {
-		s.logger.Error("Failed to bulk create with conflict handling",
-			zap.Int("count", len(dataList)),
+		s.logger.Error("Failed to get market data for symbols",
+			zap.Strings("symbols", symbols),
+			zap.Time("start_date", startDate),
+			zap.Time("end_date", endDate),
 			zap.Error(err),
 		)
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.MarketData])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return results, nil
 }
 
-// Delete removes market data by symbol
-func (s *MarketService) Delete(ctx context.Context, symbol string) error {
-	query := `DELETE FROM market_data WHERE symbol = $1`
+// GetBySymbolsChangedSince is GetBySymbolsAndDateRange limited to candles stored or
+// updated after since
+func (s *MarketService) GetBySymbolsChangedSince(ctx context.Context, symbols []string, source, interval string, startDate, endDate, since time.Time) ([]models.MarketData, error) {
+	from, filter := sourceScope(source)
+	query := fmt.Sprintf(`
+		SELECT id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+			COALESCE(updated_at, created_at)
+		FROM %s
+		WHERE symbol = ANY($1) AND interval = $5 AND date >= $2 AND date <= $3 AND ($4 = '' OR source = $4)
+			AND COALESCE(updated_at, created_at) > $6
+		ORDER BY symbol ASC, ts ASC
+	`, from)
 
-	cmdTag, err := s.db.Exec(ctx, query, symbol)
+	rows, err := s.db.Query(ctx, query, symbols, startDate, endDate, filter, intervalOrDaily(interval), since)
 	if err != nil {
-		s.logger.Error("Failed to delete market data",
-			zap.String("symbol", symbol),
+		s.logger.Error("Failed to get changed market data for symbols",
+			zap.Strings("symbols", symbols),
+			zap.Time("since", since),
 			zap.Error(err),
 		)
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	s.logger.Info("Deleted market data",
-		zap.String("symbol", symbol),
-		zap.Int64("rows_affected", cmdTag.RowsAffected()),
-	)
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.MarketData])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
 
-	return nil
+	return results, nil
 }
 
-// GetLatestBySymbol gets the most recent data point for a symbol
-func (s *MarketService) GetLatestBySymbol(ctx context.Context, symbol string) (*models.MarketData, error) {
-	query := `
-		SELECT id, symbol, date, open, high, low, close, volume, source, created_at 
-		FROM market_data 
-		WHERE symbol = $1 
-		ORDER BY date DESC 
-		LIMIT 1
-	`
+// GroupBySymbol splits rows into one series per symbol, keeping their order
+func GroupBySymbol(data []models.MarketData) map[string][]models.MarketData {
+	groups := make(map[string][]models.MarketData)
+	for _, md := range data {
+		groups[md.Symbol] = append(groups[md.Symbol], md)
+	}
+	return groups
+}
 
-	var result models.MarketData
-	err := s.db.QueryRow(ctx, query, symbol).Scan(
-		&result.ID, &result.Symbol, &result.Date, &result.Open, &result.High,
-		&result.Low, &result.Close, &result.Volume, &result.Source, &result.CreatedAt,
-	)
+// Performance summarizes each symbol's daily move over a date window from a single
+// query. Symbols without data in the window are returned with zero points.
+func (s *MarketService) Performance(ctx context.Context, symbols []string, source string, startDate, endDate time.Time) ([]models.SymbolPerformance, error) {
+	if len(symbols) == 0 {
+		return []models.SymbolPerformance{}, nil
+	}
 
+	data, err := s.GetBySymbolsAndDateRange(ctx, symbols, source, models.IntervalDaily, startDate, endDate)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
-		}
-		s.logger.Error("Failed to get latest market data",
-			zap.String("symbol", symbol),
-			zap.Error(err),
-		)
 		return nil, err
 	}
+	groups := GroupBySymbol(data)
 
-	return &result, nil
+	results := make([]models.SymbolPerformance, 0, len(symbols))
+	for _, symbol := range symbols {
+		series := groups[symbol]
+		perf := models.SymbolPerformance{
+			Symbol:     symbol,
+			AssetClass: models.AssetClassForExchange(models.ExchangeForSymbol(symbol)),
+			Points:     len(series),
+		}
+		if len(series) > 0 {
+			first, last := series[0], series[len(series)-1]
+			perf.StartDate = &first.Date
+			perf.EndDate = &last.Date
+			perf.StartClose = first.Close
+			perf.EndClose = last.Close
+			perf.Change = last.Close - first.Close
+			if first.Close != 0 {
+				perf.ChangePct = perf.Change / first.Close * 100
+			}
+			perf.High, perf.Low = first.High, first.Low
+			for _, md := range series[1:] {
+				perf.High = math.Max(perf.High, md.High)
+				perf.Low = math.Min(perf.Low, md.Low)
+			}
+		}
+		results = append(results, perf)
+	}
+
+	return results, nil
 }
 
-// GetSymbols returns all unique symbols in the database
-func (s *MarketService) GetSymbols(ctx context.Context) ([]string, error) {
-	query := `SELECT DISTINCT symbol FROM market_data ORDER BY symbol`
+// LatestQuotes builds a quote for each symbol from its latest daily candle, with
+// change against the previous close, in one query. Quotes follow the order of
+// symbols; symbols without candles are skipped.
+func (s *MarketService) LatestQuotes(ctx context.Context, symbols []string, source string) ([]models.Quote, error) {
+	if len(symbols) == 0 {
+		return []models.Quote{}, nil
+	}
+
+	from, filter := sourceScope(source)
+	query := fmt.Sprintf(`
+		SELECT symbol, open, high, low, close, volume, source, as_of, prev_close
+		FROM (
+			SELECT symbol, open, high, low, close, volume, source, COALESCE(updated_at, created_at) AS as_of,
+				LEAD(close) OVER w AS prev_close,
+				ROW_NUMBER() OVER w AS rn
+			FROM %s
+			WHERE symbol = ANY($1) AND interval = '1d' AND ($2 = '' OR source = $2)
+			WINDOW w AS (PARTITION BY symbol ORDER BY date DESC, COALESCE(updated_at, created_at) DESC)
+		) latest
+		WHERE rn = 1
+	`, from)
 
-	rows, err := s.db.Query(ctx, query)
+	rows, err := s.db.Query(ctx, query, symbols, filter)
 	if err != nil {
-		s.logger.Error("Failed to get symbols", zap.Error(err))
+		s.logger.Error("Failed to get latest quotes",
+			zap.Strings("symbols", symbols),
+			zap.Error(err),
+		)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var symbols []string
+	bySymbol := make(map[string]models.Quote, len(symbols))
 	for rows.Next() {
-		var symbol string
-		if err := rows.Scan(&symbol); err != nil {
-			return nil, err
+		var quote models.Quote
+		var prevClose *float64
+		if err := rows.Scan(&quote.Symbol, &quote.Open, &quote.High, &quote.Low, &quote.Price,
+			&quote.Volume, &quote.Source, &quote.AsOf, &prevClose); err != nil {
+			return nil, fmt.Errorf("failed to scan quote: %w", err)
 		}
-		symbols = append(symbols, symbol)
+		if prevClose != nil && *prevClose != 0 {
+			quote.PreviousClose = *prevClose
+			quote.Change = quote.Price - *prevClose
+			quote.ChangePct = quote.Change / *prevClose * 100
+		}
+		bySymbol[quote.Symbol] = quote
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	quotes := make([]models.Quote, 0, len(bySymbol))
+	for _, symbol := range symbols {
+		if quote, ok := bySymbol[symbol]; ok {
+			quotes = append(quotes, quote)
+		}
+	}
+	return quotes, nil
+}
+
+// CountBySymbolsAndDateRange counts the rows a multi-symbol read would return
+func (s *MarketService) CountBySymbolsAndDateRange(ctx context.Context, symbols []string, source, interval, exchange string, startDate, endDate time.Time) (int64, error) {
+	from, filter := sourceScope(source)
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) FROM %s
+		WHERE symbol = ANY($1) AND interval = $5 AND date >= $2 AND date <= $3 AND ($4 = '' OR source = $4)
+			AND ($6 = '' OR exchange = $6)
+	`, from)
+
+	var count int64
+	if err := s.db.QueryRow(ctx, query, symbols, startDate, endDate, filter, intervalOrDaily(interval), exchange).Scan(&count); err != nil {
+		s.logger.Error("Failed to count market data for symbols",
+			zap.Strings("symbols", symbols),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// EstimateBySymbolsAndDateRange asks the planner how many rows a multi-symbol read
+// would return, which costs no more than planning the query however large the range
+func (s *MarketService) EstimateBySymbolsAndDateRange(ctx context.Context, symbols []string, source, interval, exchange string, startDate, endDate time.Time) (int64, error) {
+	from, filter := sourceScope(source)
+	query := fmt.Sprintf(`
+		EXPLAIN (FORMAT JSON) SELECT 1 FROM %s
+		WHERE symbol = ANY($1) AND interval = $5 AND date >= $2 AND date <= $3 AND ($4 = '' OR source = $4)
+			AND ($6 = '' OR exchange = $6)
+	`, from)
+
+	var raw []byte
+	if err := s.db.QueryRow(ctx, query, symbols, startDate, endDate, filter, intervalOrDaily(interval), exchange).Scan(&raw); err != nil {
+		s.logger.Error("Failed to estimate market data for symbols",
+			zap.Strings("symbols", symbols),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(raw, &plans); err != nil || len(plans) == 0 {
+		return 0, fmt.Errorf("failed to read query plan: %v", err)
+	}
+	return int64(math.Ceil(plans[0].Plan.Rows)), nil
+}
+
+// ErrRangeTooLarge is returned when a date-range read would return more rows than
+// are served synchronously
+var ErrRangeTooLarge = errors.New("date range is too large")
+
+// CheckRange estimates the rows a date-range read would return. Above the limit it
+// returns ErrRangeTooLarge along with how many days each smaller read may span.
+func (s *MarketService) CheckRange(ctx context.Context, symbols []string, source, interval, exchange string, startDate, endDate time.Time) (*models.RangeEstimate, error) {
+	rows, err := s.EstimateBySymbolsAndDateRange(ctx, symbols, source, interval, exchange, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &models.RangeEstimate{
+		EstimatedRows: rows,
+		MaxRows:       s.opts.MaxRangeRows,
+	}
+	if s.opts.MaxRangeRows <= 0 || rows <= s.opts.MaxRangeRows {
+		return estimate, nil
+	}
+
+	// Assume rows are spread evenly over the range and split it into equal parts
+	days := int(endDate.Sub(startDate).Hours()/24) + 1
+	parts := int((rows + s.opts.MaxRangeRows - 1) / s.opts.MaxRangeRows)
+	estimate.ChunkDays = days / parts
+	if estimate.ChunkDays < 1 {
+		estimate.ChunkDays = 1
+	}
+	estimate.Chunks = (days + estimate.ChunkDays - 1) / estimate.ChunkDays
+
+	return estimate, fmt.Errorf("%w: about %d rows, limit is %d", ErrRangeTooLarge, rows, s.opts.MaxRangeRows)
+}
+
+// Create inserts new market data
+func (s *MarketService) Create(ctx context.Context, data models.MarketData) (*models.MarketData, error) {
+	if err := data.Normalize(); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO market_data (exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) 
+		RETURNING id, created_at, COALESCE(updated_at, created_at)
+	`
+
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			data.Exchange, data.Symbol, data.Interval, data.Date, data.Timestamp, data.Open, data.High,
+			data.Low, data.Close, data.AdjClose, data.Volume, data.Source,
+		).Scan(&data.ID, &data.CreatedAt, &data.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return s.announceWritten(ctx, tx, []models.MarketData{data})
+	})
+
+	if err != nil {
+		s.logger.Error("Failed to create market data",
+			zap.String("symbol", data.Symbol),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	s.stored(ctx, []models.MarketData{data})
+	return &data, nil
+}
+
+// BulkCreate efficiently inserts multiple market data records using COPY
+func (s *MarketService) BulkCreate(ctx context.Context, dataList []models.MarketData) error {
+	if len(dataList) == 0 {
+		return nil
+	}
+	if err := models.NormalizeAll(dataList); err != nil {
+		return err
+	}
+
+	// Prepare data for COPY
+	rows := make([][]interface{}, len(dataList))
+	for i, data := range dataList {
+		rows[i] = []interface{}{
+			data.Exchange,
+			data.Symbol,
+			data.Interval,
+			data.Date,
+			data.Timestamp,
+			data.Open,
+			data.High,
+			data.Low,
+			data.Close,
+			data.AdjClose,
+			data.Volume,
+			data.Source,
+		}
+	}
+
+	// Use COPY for bulk insert - much faster than individual INSERTs
+	copyCount, err := s.db.CopyFrom(
+		ctx,
+		pgx.Identifier{"market_data"},
+		[]string{"exchange", "symbol", "interval", "date", "ts", "open", "high", "low", "close", "adj_close", "volume", "source"},
+		pgx.CopyFromRows(rows),
+	)
+
+	if err != nil {
+		s.logger.Error("Failed to bulk create market data",
+			zap.Int("count", len(dataList)),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	s.logger.Info("Bulk created market data",
+		zap.Int64("inserted", copyCount),
+		zap.Int("requested", len(dataList)),
+	)
+
+	s.stored(ctx, dataList)
+	return nil
+}
+
+// DefaultBulkChunkSize is how many rows each bulk upsert batch carries
+const DefaultBulkChunkSize = 500
+
+// What a bulk upsert does with a row whose exchange, symbol, interval, timestamp and
+// source already exist
+const (
+	ConflictUpdate = "update" // overwrite the stored prices
+	ConflictSkip   = "skip"   // keep the stored row
+	ConflictError  = "error"  // fail the write
+)
+
+var (
+	// ErrDuplicateRow is returned under ConflictError when a row already exists
+	ErrDuplicateRow = errors.New("row already exists")
+	// ErrImportInProgress is returned when another write holds a symbol past the lock timeout
+	ErrImportInProgress = errors.New("another import for this symbol is in progress")
+)
+
+// ValidConflictPolicy reports whether policy is a known on_conflict value
+func ValidConflictPolicy(policy string) bool {
+	return policy == ConflictUpdate || policy == ConflictSkip || policy == ConflictError
+}
+
+// BulkOptions controls how BulkCreateWithConflict splits its work
+type BulkOptions struct {
+	ChunkSize      int    // rows per batch; DefaultBulkChunkSize when 0
+	CommitPerChunk bool   // commit each chunk on its own so finished chunks survive a cancellation
+	OnConflict     string // ConflictUpdate (default), ConflictSkip or ConflictError
+}
+
+// BulkCreateWithConflict upserts rows in chunks, checking for cancellation between
+// them. By default every chunk shares one transaction and nothing is kept unless all
+// succeed; with CommitPerChunk the result reports how many chunks were committed
+// before a failure or cancellation.
+func (s *MarketService) BulkCreateWithConflict(ctx context.Context, dataList []models.MarketData, opts BulkOptions) (models.BulkResult, error) {
+	size := opts.ChunkSize
+	if size <= 0 {
+		size = DefaultBulkChunkSize
+	}
+	if err := models.NormalizeAll(dataList); err != nil {
+		return models.BulkResult{}, err
+	}
+
+	var chunks [][]models.MarketData
+	for start := 0; start < len(dataList); start += size {
+		end := start + size
+		if end > len(dataList) {
+			end = len(dataList)
+		}
+		chunks = append(chunks, dataList[start:end])
+	}
+
+	onConflict := opts.OnConflict
+	if onConflict == "" {
+		onConflict = ConflictUpdate
+	}
+
+	result := models.BulkResult{Chunks: len(chunks)}
+	if len(chunks) == 0 {
+		return result, nil
+	}
+
+	symbols := distinctSymbols(dataList)
+
+	if opts.CommitPerChunk {
+		err := s.commitChunks(ctx, symbols, chunks, onConflict, &result)
+		return result, s.bulkFailed(ctx, result, err)
+	}
+
+	var written []models.MarketData
+	var outcome models.BulkResult
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		written, outcome = nil, models.BulkResult{}
+		if err := s.lockSymbols(ctx, tx, symbols, xactLockQuery); err != nil {
+			return err
+		}
+
+		for i, chunk := range chunks {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			rows, chunkOutcome, err := upsertChunk(ctx, tx, chunk, onConflict)
+			if err != nil {
+				return fmt.Errorf("chunk %d: %w", i, err)
+			}
+			written = append(written, rows...)
+			outcome.Add(chunkOutcome)
+		}
+		return s.announceWritten(ctx, tx, written)
+	})
+	if err != nil {
+		return result, s.bulkFailed(ctx, result, err)
+	}
+
+	result.ChunksCommitted = len(chunks)
+	result.RowsCommitted = len(dataList)
+	result.Add(outcome)
+	s.stored(ctx, written)
+	return result, nil
+}
+
+// commitChunks writes each chunk in a transaction of its own. The chunks commit
+// separately, so the symbol locks are session locks on one connection that every
+// chunk's transaction runs on; a second connection per chunk could starve the pool.
+func (s *MarketService) commitChunks(ctx context.Context, symbols []string, chunks [][]models.MarketData, onConflict string, result *models.BulkResult) error {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer func() {
+		// The request may be cancelled by now, so unlocking gets its own deadline. A
+		// connection that may still hold locks must not go back to the pool.
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.Exec(unlockCtx, "SELECT pg_advisory_unlock_all()"); err != nil {
+			s.logger.Warn("Failed to release import locks, closing connection", zap.Error(err))
+			_ = conn.Conn().Close(unlockCtx)
+		}
+		conn.Release()
+	}()
+
+	err = pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		return s.lockSymbols(ctx, tx, symbols, sessionLockQuery)
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var written []models.MarketData
+		var outcome models.BulkResult
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			var err error
+			written, outcome, err = upsertChunk(ctx, tx, chunk, onConflict)
+			if err != nil {
+				return err
+			}
+			return s.announceWritten(ctx, tx, written)
+		})
+		if err != nil {
+			return fmt.Errorf("chunk %d: %w", i, err)
+		}
+
+		result.ChunksCommitted++
+		result.RowsCommitted += len(chunk)
+		result.Add(outcome)
+		s.stored(ctx, written)
+	}
+	return nil
+}
+
+// announceWritten enqueues a market_data.written message in tx for each listing,
+// interval and source among the rows it wrote
+func (s *MarketService) announceWritten(ctx context.Context, tx pgx.Tx, rows []models.MarketData) error {
+	if !s.opts.Outbox.Enabled() || len(rows) == 0 {
+		return nil
+	}
+
+	type series struct{ exchange, symbol, interval, source string }
+	written := map[series]*models.MarketDataWritten{}
+	var order []series
+	for _, md := range rows {
+		key := series{md.Exchange, md.Symbol, md.Interval, md.Source}
+		w, ok := written[key]
+		if !ok {
+			w = &models.MarketDataWritten{Exchange: md.Exchange, Symbol: md.Symbol, Interval: md.Interval,
+				Source: md.Source, From: md.Timestamp, To: md.Timestamp}
+			written[key] = w
+			order = append(order, key)
+		}
+		w.Rows++
+		if md.Timestamp.Before(w.From) {
+			w.From = md.Timestamp
+		}
+		if md.Timestamp.After(w.To) {
+			w.To = md.Timestamp
+		}
+	}
+
+	for _, key := range order {
+		if err := s.opts.Outbox.Enqueue(ctx, tx, models.TopicMarketDataWritten, key.exchange+":"+key.symbol, written[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bulkFailed classifies a bulk write error, logging only unexpected failures
+func (s *MarketService) bulkFailed(ctx context.Context, result models.BulkResult, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return s.bulkCancelled(result, ctx.Err())
+	case errors.Is(err, ErrDuplicateRow), errors.Is(err, ErrImportInProgress):
+		return err
+	}
+
+	s.logger.Error("Failed to bulk create with conflict handling",
+		zap.Int("chunks", result.Chunks),
+		zap.Int("chunks_committed", result.ChunksCommitted),
+		zap.Error(err),
+	)
+	return err
+}
+
+// Advisory lock queries per symbol: one released when the transaction ends, and one
+// held by the connection until pg_advisory_unlock_all
+const (
+	xactLockQuery    = `SELECT pg_advisory_xact_lock(hashtextextended('market_data:' || $1, 0))`
+	sessionLockQuery = `SELECT pg_advisory_lock(hashtextextended('market_data:' || $1, 0))`
+)
+
+// lockSymbols takes an advisory lock per symbol with lockQuery so imports of the same
+// symbol run one after another. Symbols are locked in sorted order so two imports
+// cannot deadlock, and waiting gives up after the import lock timeout.
+func (s *MarketService) lockSymbols(ctx context.Context, tx pgx.Tx, symbols []string, lockQuery string) error {
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL lock_timeout = %d", s.opts.ImportLockTimeout.Milliseconds())); err != nil {
+		return err
+	}
+
+	for _, symbol := range symbols {
+		_, err := tx.Exec(ctx, lockQuery, symbol)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "55P03" {
+				return fmt.Errorf("%w: %s", ErrImportInProgress, symbol)
+			}
+			return err
+		}
+	}
+
+	// The writes' own row locks keep the server's timeout
+	_, err := tx.Exec(ctx, "SET LOCAL lock_timeout TO DEFAULT")
+	return err
+}
+
+// distinctSymbols returns the symbols present in data, sorted
+func distinctSymbols(data []models.MarketData) []string {
+	seen := map[string]bool{}
+	var symbols []string
+	for _, md := range data {
+		if !seen[md.Symbol] {
+			seen[md.Symbol] = true
+			symbols = append(symbols, md.Symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// upsertQueries maps each conflict policy to its insert. Every query returns
+// whether the row was newly inserted; skipped rows return nothing.
+var upsertQueries = map[string]string{
+	ConflictUpdate: `
+		INSERT INTO market_data (exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (exchange, symbol, interval, ts, source) DO UPDATE SET
+			open = EXCLUDED.open,
+			high = EXCLUDED.high,
+			low = EXCLUDED.low,
+			close = EXCLUDED.close,
+			adj_close = COALESCE(EXCLUDED.adj_close, market_data.adj_close),
+			volume = EXCLUDED.volume
+		RETURNING (xmax = 0)
+	`,
+	ConflictSkip: `
+		INSERT INTO market_data (exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (exchange, symbol, interval, ts, source) DO NOTHING
+		RETURNING TRUE
+	`,
+	ConflictError: `
+		INSERT INTO market_data (exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING TRUE
+	`,
+}
+
+// upsertChunk sends one chunk as a single batch, returning the rows written and
+// how many were inserted, updated or skipped
+func upsertChunk(ctx context.Context, tx pgx.Tx, chunk []models.MarketData, onConflict string) ([]models.MarketData, models.BulkResult, error) {
+	var outcome models.BulkResult
+	query, ok := upsertQueries[onConflict]
+	if !ok {
+		return nil, outcome, fmt.Errorf("unknown conflict policy %q", onConflict)
+	}
+
+	batch := &pgx.Batch{}
+	for _, data := range chunk {
+		batch.Queue(query,
+			data.Exchange, data.Symbol, data.Interval, data.Date, data.Timestamp, data.Open, data.High,
+			data.Low, data.Close, data.AdjClose, data.Volume, data.Source,
+		)
+	}
+
+	br := tx.SendBatch(ctx, batch)
+	defer br.Close()
+
+	written := make([]models.MarketData, 0, len(chunk))
+	for i, data := range chunk {
+		var inserted bool
+		err := br.QueryRow().Scan(&inserted)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			outcome.Skipped++
+			continue
+		case isUniqueViolation(err):
+			return nil, outcome, fmt.Errorf("%w: %s:%s %s %s from %s (batch item %d)",
+				ErrDuplicateRow, data.Exchange, data.Symbol, data.Interval, data.Timestamp.Format(time.RFC3339), data.Source, i)
+		case err != nil:
+			return nil, outcome, fmt.Errorf("failed to execute batch item %d: %w", i, err)
+		}
+
+		if inserted {
+			outcome.Inserted++
+		} else {
+			outcome.Updated++
+		}
+		written = append(written, data)
+	}
+
+	return written, outcome, nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (s *MarketService) bulkCancelled(result models.BulkResult, cause error) error {
+	s.logger.Warn("Bulk create cancelled",
+		zap.Int("chunks", result.Chunks),
+		zap.Int("chunks_committed", result.ChunksCommitted),
+		zap.Error(cause),
+	)
+	return fmt.Errorf("bulk create cancelled after %d of %d chunks: %w", result.ChunksCommitted, result.Chunks, cause)
+}
+
+// CountBySymbol returns how many rows a read of symbol covers; source "" counts every
+// source and SourceAny counts merged candles, while interval "" and exchange "" count
+// every interval and listing
+func (s *MarketService) CountBySymbol(ctx context.Context, symbol, source, interval, exchange string) (int64, error) {
+	from, filter := sourceScope(source)
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) FROM %s
+		WHERE symbol = $1 AND ($2 = '' OR source = $2) AND ($3 = '' OR interval = $3)
+			AND ($4 = '' OR exchange = $4)
+	`, from)
+
+	var count int64
+	if err := s.db.QueryRow(ctx, query, symbol, filter, interval, exchange).Scan(&count); err != nil {
+		s.logger.Error("Failed to count market data",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Delete removes market data by symbol and returns the number of rows deleted
+func (s *MarketService) Delete(ctx context.Context, symbol string) (int64, error) {
+	query := `DELETE FROM market_data WHERE symbol = $1`
+
+	cmdTag, err := s.db.Exec(ctx, query, symbol)
+	if err != nil {
+		s.logger.Error("Failed to delete market data",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	s.invalidate(ctx, []string{symbol})
+
+	s.logger.Info("Deleted market data",
+		zap.String("symbol", symbol),
+		zap.Int64("rows_affected", cmdTag.RowsAffected()),
+	)
+
+	return cmdTag.RowsAffected(), nil
+}
+
+// StoredDates lists the distinct dates a listing has daily candles for, oldest
+// first, optionally within startDate..endDate. Any source counts in a merge.
+func (s *MarketService) StoredDates(ctx context.Context, symbol, exchange, source string, startDate, endDate *time.Time) ([]time.Time, error) {
+	if isMerged(source) {
+		source = ""
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT DISTINCT date FROM market_data
+		WHERE symbol = $1 AND exchange = $2 AND interval = $3 AND ($4 = '' OR source = $4)
+			AND ($5::date IS NULL OR date >= $5) AND ($6::date IS NULL OR date <= $6)
+		ORDER BY date
+	`, symbol, exchange, models.IntervalDaily, source, startDate, endDate)
+	if err != nil {
+		s.logger.Error("Failed to list stored dates", zap.String("symbol", symbol), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	dates, err := pgx.CollectRows(rows, pgx.RowTo[time.Time])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return dates, nil
+}
+
+// FindGaps groups the trading days (YYYY-MM-DD, ascending) with no stored date into
+// runs of consecutive trading days, returning the runs and how many days they cover
+func FindGaps(tradingDays []string, stored []time.Time) ([]models.DataGap, int) {
+	have := make(map[string]bool, len(stored))
+	for _, d := range stored {
+		have[d.Format("2006-01-02")] = true
+	}
+
+	gaps := []models.DataGap{}
+	missing := 0
+	var current *models.DataGap
+	for _, day := range tradingDays {
+		if have[day] {
+			current = nil
+			continue
+		}
+		missing++
+		if current == nil {
+			gaps = append(gaps, models.DataGap{StartDate: day})
+			current = &gaps[len(gaps)-1]
+		}
+		current.EndDate = day
+		current.Days++
+	}
+	return gaps, missing
+}
+
+// DataRange selects stored candles of one source between two dates, optionally
+// narrowed to an exchange, symbol or interval
+type DataRange struct {
+	Source    string
+	Exchange  string
+	Symbol    string
+	Interval  string
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// Target identifies the range in a confirmation token
+func (r DataRange) Target() string {
+	return fmt.Sprintf("source=%s;exchange=%s;symbol=%s;interval=%s;%s..%s", r.Source, r.Exchange, r.Symbol, r.Interval,
+		r.StartDate.Format("2006-01-02"), r.EndDate.Format("2006-01-02"))
+}
+
+const dataRangeFilter = `
+	WHERE source = $1 AND date >= $2 AND date <= $3
+		AND ($4 = '' OR exchange = $4) AND ($5 = '' OR symbol = $5) AND ($6 = '' OR interval = $6)
+`
+
+// CountRange counts the candles in a range, to report a delete's impact before it runs
+func (s *MarketService) CountRange(ctx context.Context, r DataRange) (int64, error) {
+	var count int64
+	err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM market_data`+dataRangeFilter,
+		r.Source, r.StartDate, r.EndDate, r.Exchange, r.Symbol, r.Interval).Scan(&count)
+	if err != nil {
+		s.logger.Error("Failed to count market data range", zap.String("range", r.Target()), zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteRange deletes the candles in a range. Deleted versions are archived in
+// market_data_history like any other delete.
+func (s *MarketService) DeleteRange(ctx context.Context, r DataRange) (int64, error) {
+	rows, err := s.db.Query(ctx, `
+		WITH deleted AS (DELETE FROM market_data`+dataRangeFilter+` RETURNING symbol)
+		SELECT symbol, COUNT(*) FROM deleted GROUP BY symbol
+	`, r.Source, r.StartDate, r.EndDate, r.Exchange, r.Symbol, r.Interval)
+	if err != nil {
+		s.logger.Error("Failed to delete market data range", zap.String("range", r.Target()), zap.Error(err))
+		return 0, err
+	}
+	defer rows.Close()
+
+	var symbols []string
+	var deleted int64
+	for rows.Next() {
+		var symbol string
+		var count int64
+		if err := rows.Scan(&symbol, &count); err != nil {
+			return 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+		symbols = append(symbols, symbol)
+		deleted += count
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to delete market data range", zap.String("range", r.Target()), zap.Error(err))
+		return 0, err
+	}
+
+	s.invalidate(ctx, symbols)
+	s.logger.Info("Deleted market data range",
+		zap.String("range", r.Target()),
+		zap.Int("symbols", len(symbols)),
+		zap.Int64("rows_affected", deleted),
+	)
+	return deleted, nil
+}
+
+// Coverage lists every known listing with how many candles are stored for it, the
+// dates they span and the intervals and sources they come from. Listings without
+// candles are included with zero rows.
+func (s *MarketService) Coverage(ctx context.Context, exchange, source string) ([]models.SymbolCoverage, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT sym.exchange, sym.symbol, COUNT(md.id), MIN(md.date), MAX(md.date),
+			COALESCE(ARRAY_AGG(DISTINCT md.interval ORDER BY md.interval) FILTER (WHERE md.id IS NOT NULL), '{}'),
+			COALESCE(ARRAY_AGG(DISTINCT md.source ORDER BY md.source) FILTER (WHERE md.id IS NOT NULL), '{}')
+		FROM symbols sym
+		LEFT JOIN market_data md ON md.exchange = sym.exchange AND md.symbol = sym.symbol AND ($2 = '' OR md.source = $2)
+		WHERE ($1 = '' OR sym.exchange = $1)
+		GROUP BY sym.exchange, sym.symbol
+		ORDER BY sym.exchange, sym.symbol
+	`, exchange, source)
+	if err != nil {
+		s.logger.Error("Failed to get data coverage", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	coverage, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SymbolCoverage])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return coverage, nil
+}
+
+// GetLatestBySymbol gets the most recent candle of an interval for a symbol from source
+func (s *MarketService) GetLatestBySymbol(ctx context.Context, symbol, source, interval string) (*models.MarketData, error) {
+	interval = intervalOrDaily(interval)
+	field := "latest:" + source + ":" + interval
+	var cached *models.MarketData
+	if s.cached(ctx, symbol, field, &cached) {
+		return cached, nil
+	}
+
+	latest, err := s.getLatestBySymbol(ctx, symbol, source, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	// A symbol without data is cached as null until its first row arrives
+	s.fill(ctx, symbol, field, latest)
+	return latest, nil
+}
+
+func (s *MarketService) getLatestBySymbol(ctx context.Context, symbol, source, interval string) (*models.MarketData, error) {
+	from, filter := sourceScope(source)
+	query := fmt.Sprintf(`
+		SELECT id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+			COALESCE(updated_at, created_at)
+		FROM %s
+		WHERE symbol = $1 AND interval = $3 AND ($2 = '' OR source = $2)
+		ORDER BY ts DESC, COALESCE(updated_at, created_at) DESC
+		LIMIT 1
+	`, from)
+
+	var result models.MarketData
+	err := s.db.QueryRow(ctx, query, symbol, filter, interval).Scan(
+		&result.ID, &result.Exchange, &result.Symbol, &result.Interval, &result.Date, &result.Timestamp, &result.Open, &result.High,
+		&result.Low, &result.Close, &result.AdjClose, &result.Volume, &result.Source, &result.CreatedAt, &result.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		s.logger.Error("Failed to get latest market data",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// CacheTrace counts how the cached reads of one request were served
+type CacheTrace struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type cacheTraceKey struct{}
+
+// WithCacheTrace returns a context whose cached reads are counted in the trace
+func WithCacheTrace(ctx context.Context) (context.Context, *CacheTrace) {
+	trace := &CacheTrace{}
+	return context.WithValue(ctx, cacheTraceKey{}, trace), trace
+}
+
+// Status is hit when every cached read was served from the cache, miss when any
+// went to the database, and bypass when the request made no cacheable reads
+func (t *CacheTrace) Status() string {
+	switch {
+	case t.misses.Load() > 0:
+		return "miss"
+	case t.hits.Load() > 0:
+		return "hit"
+	default:
+		return "bypass"
+	}
+}
+
+func traceCache(ctx context.Context, hit bool) {
+	trace, ok := ctx.Value(cacheTraceKey{}).(*CacheTrace)
+	if !ok {
+		return
+	}
+	if hit {
+		trace.hits.Add(1)
+	} else {
+		trace.misses.Add(1)
+	}
+}
+
+// cacheKey groups every cached read of a symbol so one write drops them all
+func cacheKey(symbol string) string {
+	return "market:" + symbol
+}
+
+// cached decodes a cached read of symbol into dest. Cache failures are logged and
+// treated as misses so reads fall back to the database.
+func (s *MarketService) cached(ctx context.Context, symbol, field string, dest interface{}) (hit bool) {
+	defer func() { traceCache(ctx, hit) }()
+
+	value, err := s.cache.Get(ctx, cacheKey(symbol), field)
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			s.logger.Warn("Failed to read cache",
+				zap.String("symbol", symbol),
+				zap.Error(err),
+			)
+		}
+		return false
+	}
+
+	if err := json.Unmarshal(value, dest); err != nil {
+		s.logger.Warn("Discarding unreadable cache entry",
+			zap.String("symbol", symbol),
+			zap.String("field", field),
+			zap.Error(err),
+		)
+		return false
+	}
+	return true
+}
+
+// fill stores a read of symbol in the cache; failures only cost a later miss
+func (s *MarketService) fill(ctx context.Context, symbol, field string, value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err == nil {
+		err = s.cache.Set(ctx, cacheKey(symbol), field, encoded)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to fill cache",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+	}
+}
+
+// invalidate drops every cached read of symbols
+func (s *MarketService) invalidate(ctx context.Context, symbols []string) {
+	if len(symbols) == 0 {
+		return
+	}
+
+	keys := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		keys[i] = cacheKey(symbol)
+	}
+
+	// The write has already committed, so a client that went away must not skip this
+	if err := s.cache.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+		s.logger.Error("Failed to invalidate cache",
+			zap.Strings("symbols", symbols),
+			zap.Error(err),
+		)
+	}
+}
+
+// stored runs after rows are committed: it drops stale cached reads of their
+// symbols and pushes the rows to stream subscribers
+func (s *MarketService) stored(ctx context.Context, data []models.MarketData) {
+	s.invalidate(ctx, distinctSymbols(data))
+	s.hub.Publish(data)
+	s.recordIngest(ctx, data)
+}
+
+// recordIngest notes that the sources of committed rows have just delivered, for
+// the ingest monitor. It runs after commit so a long import does not hold the
+// source's row locked.
+func (s *MarketService) recordIngest(ctx context.Context, data []models.MarketData) {
+	sources := distinctSources(data)
+	if len(sources) == 0 {
+		return
+	}
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO source_ingests (source, last_ingest_at)
+		SELECT unnest($1::text[]), CURRENT_TIMESTAMP
+		ON CONFLICT (source) DO UPDATE SET last_ingest_at = EXCLUDED.last_ingest_at
+	`, sources)
+	if err != nil {
+		s.logger.Warn("Failed to record source ingest", zap.Strings("sources", sources), zap.Error(err))
+	}
+}
+
+// GetLatestBySymbols returns the most recent candle of an interval for each symbol in
+// one query, keyed by symbol; symbols without data are absent
+func (s *MarketService) GetLatestBySymbols(ctx context.Context, symbols []string, source, interval string) (map[string]models.MarketData, error) {
+	return s.GetLatestBySymbolsAt(ctx, symbols, source, interval, nil)
+}
+
+// GetLatestBySymbolsAt is GetLatestBySymbols limited to candles dated on or before
+// asOf; a nil asOf takes the most recent
+func (s *MarketService) GetLatestBySymbolsAt(ctx context.Context, symbols []string, source, interval string, asOf *time.Time) (map[string]models.MarketData, error) {
+	from, filter := sourceScope(source)
+	query := fmt.Sprintf(`
+		SELECT DISTINCT ON (symbol) id, exchange, symbol, interval, date, ts, open, high, low, close, adj_close, volume, source, created_at,
+			COALESCE(updated_at, created_at)
+		FROM %s
+		WHERE symbol = ANY($1) AND interval = $3 AND ($2 = '' OR source = $2)
+			AND ($4::date IS NULL OR date <= $4)
+		ORDER BY symbol, ts DESC, COALESCE(updated_at, created_at) DESC
+	`, from)
+
+	rows, err := s.db.Query(ctx, query, symbols, filter, intervalOrDaily(interval), asOf)
+	if err != nil {
+		s.logger.Error("Failed to get latest market data for symbols",
+			zap.Strings("symbols", symbols),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	data, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.MarketData])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	latest := make(map[string]models.MarketData, len(data))
+	for _, md := range data {
+		latest[md.Symbol] = md
+	}
+	return latest, nil
+}
+
+// LatestQuote builds a quote from the latest merged daily candle, with change against
+// the previous close
+func (s *MarketService) LatestQuote(ctx context.Context, symbol string) (*models.Quote, error) {
+	query := `
+		SELECT symbol, open, high, low, close, volume, source,
+			COALESCE(updated_at, created_at),
+			LEAD(close) OVER (ORDER BY date DESC)
+		FROM ` + mergedMarketData(SourceAny) + `
+		WHERE symbol = $1 AND interval = '1d'
+		ORDER BY date DESC
+		LIMIT 1
+	`
+
+	var quote models.Quote
+	var prevClose *float64
+	err := s.db.QueryRow(ctx, query, symbol).Scan(
+		&quote.Symbol, &quote.Open, &quote.High, &quote.Low, &quote.Price,
+		&quote.Volume, &quote.Source, &quote.AsOf, &prevClose,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		s.logger.Error("Failed to get latest quote",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	if prevClose != nil && *prevClose != 0 {
+		quote.PreviousClose = *prevClose
+		quote.Change = quote.Price - *prevClose
+		quote.ChangePct = quote.Change / *prevClose * 100
+	}
+
+	return &quote, nil
+}
+
+// LatestIntradayQuote builds a quote from the day of the most recent intraday candle:
+// its open, range and volume so far, the latest close as the price and change against
+// the daily close before that day. Candles of that day's interval and source are used.
+func (s *MarketService) LatestIntradayQuote(ctx context.Context, symbol string) (*models.Quote, error) {
+	query := `
+		WITH last AS (
+			SELECT exchange, symbol, interval, date, source
+			FROM market_data
+			WHERE symbol = $1 AND interval <> '1d'
+			ORDER BY ts DESC, COALESCE(updated_at, created_at) DESC
+			LIMIT 1
+		)
+		SELECT last.symbol,
+			(array_agg(m.open ORDER BY m.ts))[1], max(m.high), min(m.low),
+			(array_agg(m.close ORDER BY m.ts DESC))[1], sum(m.volume)::bigint, last.source,
+			max(COALESCE(m.updated_at, m.created_at)),
+			(
+				SELECT d.close FROM market_data d
+				WHERE d.exchange = last.exchange AND d.symbol = last.symbol AND d.interval = '1d' AND d.date < last.date
+				ORDER BY d.date DESC, COALESCE(d.updated_at, d.created_at) DESC
+				LIMIT 1
+			)
+		FROM last
+		JOIN market_data m ON m.exchange = last.exchange AND m.symbol = last.symbol
+			AND m.interval = last.interval AND m.date = last.date AND m.source = last.source
+		GROUP BY last.exchange, last.symbol, last.date, last.source
+	`
+
+	var quote models.Quote
+	var prevClose *float64
+	err := s.db.QueryRow(ctx, query, symbol).Scan(
+		&quote.Symbol, &quote.Open, &quote.High, &quote.Low, &quote.Price,
+		&quote.Volume, &quote.Source, &quote.AsOf, &prevClose,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		s.logger.Error("Failed to get latest intraday quote",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	if prevClose != nil && *prevClose != 0 {
+		quote.PreviousClose = *prevClose
+		quote.Change = quote.Price - *prevClose
+		quote.ChangePct = quote.Change / *prevClose * 100
 	}
 
-	return symbols, nil
+	return &quote, nil
 }
 
 // HealthCheck verifies the service is working
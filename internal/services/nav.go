@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidNAV is returned for a NAV row that cannot be stored
+var ErrInvalidNAV = errors.New("invalid NAV")
+
+type NAVService struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewNAVService(db *database.DB) *NAVService {
+	return &NAVService{
+		db:     db,
+		logger: logger.With(zap.String("service", "nav")),
+	}
+}
+
+// ParseNAVInputs validates NAV rows from a request, upper-casing symbols and
+// defaulting the source to manual
+func ParseNAVInputs(inputs []models.NAVInput) ([]models.NAV, error) {
+	navs := make([]models.NAV, len(inputs))
+	for i, in := range inputs {
+		date, err := time.Parse("2006-01-02", in.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d has date %q, use YYYY-MM-DD", ErrInvalidNAV, i, in.Date)
+		}
+		source := in.Source
+		if source == "" {
+			source = "manual"
+		}
+		navs[i] = models.NAV{
+			Symbol: strings.ToUpper(strings.TrimSpace(in.Symbol)),
+			Date:   date,
+			NAV:    in.NAV,
+			Source: source,
+		}
+	}
+	return navs, nil
+}
+
+// Upsert stores NAVs in one transaction, listing each fund on the FUND exchange so it
+// can be looked up, watched and held like any other symbol
+func (s *NAVService) Upsert(ctx context.Context, navs []models.NAV) (int, int, error) {
+	var funds []string
+	listed := map[string]bool{}
+	for _, n := range navs {
+		if !listed[n.Symbol] {
+			listed[n.Symbol] = true
+			funds = append(funds, n.Symbol)
+		}
+	}
+
+	batch := &pgx.Batch{}
+	for _, symbol := range funds {
+		batch.Queue(`
+			INSERT INTO symbols (exchange, symbol) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, models.ExchangeFund, symbol)
+	}
+	for _, n := range navs {
+		batch.Queue(`
+			INSERT INTO nav_data (symbol, date, nav, source)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (symbol, date, source) DO UPDATE SET nav = EXCLUDED.nav
+			RETURNING (xmax = 0)
+		`, n.Symbol, n.Date, n.NAV, n.Source)
+	}
+
+	var inserted, updated int
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		br := tx.SendBatch(ctx, batch)
+		defer br.Close()
+
+		for _, symbol := range funds {
+			if _, err := br.Exec(); err != nil {
+				return fmt.Errorf("failed to list fund %s: %w", symbol, err)
+			}
+		}
+		for i := range navs {
+			var isInsert bool
+			if err := br.QueryRow().Scan(&isInsert); err != nil {
+				return fmt.Errorf("failed to store NAV %d: %w", i, err)
+			}
+			if isInsert {
+				inserted++
+			} else {
+				updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to store NAVs", zap.Int("count", len(navs)), zap.Error(err))
+		return 0, 0, err
+	}
+
+	return inserted, updated, nil
+}
+
+// GetBySymbolsAndDateRange returns NAVs ordered by symbol and date. With source "" or
+// a merge, each day's NAV comes from the merge's preferred source, if any, or else the
+// highest-priority source that has one.
+func (s *NAVService) GetBySymbolsAndDateRange(ctx context.Context, symbols []string, source string, startDate, endDate time.Time) ([]models.NAV, error) {
+	preferred := preferredSource(source)
+	if isMerged(source) {
+		source = ""
+	}
+	query := `
+		SELECT DISTINCT ON (n.symbol, n.date) n.id, n.symbol, n.date, n.nav, n.source, n.created_at,
+			COALESCE(n.updated_at, n.created_at)
+		FROM nav_data n
+		LEFT JOIN sources s ON s.name = n.source
+		WHERE n.symbol = ANY($1) AND n.date >= $2 AND n.date <= $3 AND ($4 = '' OR n.source = $4)
+		ORDER BY n.symbol, n.date, n.source <> $5, COALESCE(s.priority, 100)
+	`
+
+	rows, err := s.db.Query(ctx, query, symbols, startDate, endDate, source, preferred)
+	if err != nil {
+		s.logger.Error("Failed to get NAVs",
+			zap.Strings("symbols", symbols),
+			zap.Time("start_date", startDate),
+			zap.Time("end_date", endDate),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	navs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.NAV])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return navs, nil
+}
+
+// GetLatestBySymbols returns each fund's most recent NAV, preferring higher-priority
+// sources on the same day
+func (s *NAVService) GetLatestBySymbols(ctx context.Context, symbols []string) (map[string]models.NAV, error) {
+	return s.GetLatestBySymbolsAt(ctx, symbols, nil)
+}
+
+// GetLatestBySymbolsAt is GetLatestBySymbols limited to NAVs dated on or before asOf;
+// a nil asOf takes the most recent
+func (s *NAVService) GetLatestBySymbolsAt(ctx context.Context, symbols []string, asOf *time.Time) (map[string]models.NAV, error) {
+	query := `
+		SELECT DISTINCT ON (n.symbol) n.id, n.symbol, n.date, n.nav, n.source, n.created_at,
+			COALESCE(n.updated_at, n.created_at)
+		FROM nav_data n
+		LEFT JOIN sources s ON s.name = n.source
+		WHERE n.symbol = ANY($1) AND ($2::date IS NULL OR n.date <= $2)
+		ORDER BY n.symbol, n.date DESC, COALESCE(s.priority, 100)
+	`
+
+	rows, err := s.db.Query(ctx, query, symbols, asOf)
+	if err != nil {
+		s.logger.Error("Failed to get latest NAVs",
+			zap.Strings("symbols", symbols),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	navs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.NAV])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	latest := make(map[string]models.NAV, len(navs))
+	for _, n := range navs {
+		latest[n.Symbol] = n
+	}
+	return latest, nil
+}
+
+// FillPerformance completes a watchlist performance summary with NAV series for the
+// symbols that have no candles in the window. A NAV stands in for open, high, low and
+// close alike.
+func (s *NAVService) FillPerformance(ctx context.Context, performance []models.SymbolPerformance, startDate, endDate time.Time) error {
+	var missing []string
+	for _, perf := range performance {
+		if perf.Points == 0 {
+			missing = append(missing, perf.Symbol)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	navs, err := s.GetBySymbolsAndDateRange(ctx, missing, "", startDate, endDate)
+	if err != nil {
+		return err
+	}
+	series := map[string][]models.NAV{}
+	for _, n := range navs {
+		series[n.Symbol] = append(series[n.Symbol], n)
+	}
+
+	for i, perf := range performance {
+		points := series[perf.Symbol]
+		if perf.Points != 0 || len(points) == 0 {
+			continue
+		}
+		first, last := points[0], points[len(points)-1]
+		perf.AssetClass = models.AssetClassFund
+		perf.Points = len(points)
+		perf.StartDate = &first.Date
+		perf.EndDate = &last.Date
+		perf.StartClose = first.NAV
+		perf.EndClose = last.NAV
+		perf.Change = last.NAV - first.NAV
+		if first.NAV != 0 {
+			perf.ChangePct = perf.Change / first.NAV * 100
+		}
+		perf.High, perf.Low = first.NAV, first.NAV
+		for _, n := range points[1:] {
+			perf.High = math.Max(perf.High, n.NAV)
+			perf.Low = math.Min(perf.Low, n.NAV)
+		}
+		performance[i] = perf
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/storage"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoteNotFound       = errors.New("note not found")
+	ErrNoteReadOnly       = errors.New("only the author can change a note")
+	ErrNoteNotShareable   = errors.New("notes can only be shared by a user in an organization")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+)
+
+type NoteService struct {
+	db     *database.DB
+	store  storage.ObjectStore
+	logger *zap.Logger
+}
+
+func NewNoteService(db *database.DB, store storage.ObjectStore) *NoteService {
+	return &NoteService{
+		db:     db,
+		store:  store,
+		logger: logger.With(zap.String("service", "note")),
+	}
+}
+
+const noteColumns = `n.id, n.user_id, n.organization, n.symbol, n.title, n.body, n.shared, n.created_at, n.updated_at`
+
+// noteVisible matches notes a user ($1) wrote, or that were shared within their
+// organization ($2)
+const noteVisible = `(n.user_id = $1 OR (n.shared AND n.organization <> '' AND n.organization = $2))`
+
+// NoteFilter narrows a note listing. Query is a full-text search over titles and
+// bodies, ranking matches first.
+type NoteFilter struct {
+	Symbol string
+	Query  string
+	Limit  int
+}
+
+// Create stores a note on a symbol, recording the author's organization for sharing
+func (s *NoteService) Create(ctx context.Context, userID, organization, symbol string, req models.CreateNoteRequest) (*models.SymbolNote, error) {
+	if req.Shared && organization == "" {
+		return nil, ErrNoteNotShareable
+	}
+
+	query := `
+		INSERT INTO symbol_notes AS n (user_id, organization, symbol, title, body, shared)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + noteColumns
+
+	note, err := scanNote(s.db.QueryRow(ctx, query, userID, organization, symbol, strings.TrimSpace(req.Title), req.Body, req.Shared))
+	if err != nil {
+		s.logger.Error("Failed to create note",
+			zap.String("user_id", userID),
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	note.Attachments = []models.NoteAttachment{}
+	return note, nil
+}
+
+// List returns the notes a user can read, newest first or best match first when
+// searching, with their attachments
+func (s *NoteService) List(ctx context.Context, userID, organization string, filter NoteFilter) ([]models.SymbolNote, error) {
+	args := []interface{}{userID, organization}
+	where := []string{noteVisible}
+	order := "n.created_at DESC, n.id DESC"
+
+	if filter.Symbol != "" {
+		args = append(args, filter.Symbol)
+		where = append(where, fmt.Sprintf("n.symbol = $%d", len(args)))
+	}
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+		where = append(where, fmt.Sprintf("n.search @@ websearch_to_tsquery('simple', $%d)", len(args)))
+		order = fmt.Sprintf("ts_rank(n.search, websearch_to_tsquery('simple', $%d)) DESC, ", len(args)) + order
+	}
+	args = append(args, filter.Limit)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM symbol_notes n
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d
+	`, noteColumns, strings.Join(where, " AND "), order, len(args))
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		s.logger.Error("Failed to list notes",
+			zap.String("user_id", userID),
+			zap.String("symbol", filter.Symbol),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []models.SymbolNote{}
+	var ids []int64
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes = append(notes, *note)
+		ids = append(ids, note.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := s.attach(ctx, notes, ids); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// Get returns a note the user can read, with its attachments
+func (s *NoteService) Get(ctx context.Context, userID, organization string, id int64) (*models.SymbolNote, error) {
+	query := `SELECT ` + noteColumns + ` FROM symbol_notes n WHERE n.id = $3 AND ` + noteVisible
+
+	note, err := scanNote(s.db.QueryRow(ctx, query, userID, organization, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoteNotFound
+		}
+		s.logger.Error("Failed to get note", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	notes := []models.SymbolNote{*note}
+	if err := s.attach(ctx, notes, []int64{id}); err != nil {
+		return nil, err
+	}
+	return &notes[0], nil
+}
+
+// Update changes the given fields of a note the user wrote
+func (s *NoteService) Update(ctx context.Context, userID, organization string, id int64, req models.UpdateNoteRequest) (*models.SymbolNote, error) {
+	note, err := s.owned(ctx, userID, organization, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Title != nil {
+		note.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Body != nil {
+		note.Body = *req.Body
+	}
+	if req.Shared != nil {
+		if *req.Shared && note.Organization == "" {
+			return nil, ErrNoteNotShareable
+		}
+		note.Shared = *req.Shared
+	}
+
+	_, err = s.db.Exec(ctx, `
+		UPDATE symbol_notes SET title = $2, body = $3, shared = $4
+		WHERE id = $1
+	`, id, note.Title, note.Body, note.Shared)
+	if err != nil {
+		s.logger.Error("Failed to update note", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	return s.Get(ctx, userID, organization, id)
+}
+
+// Delete removes a note the user wrote, with its attachments
+func (s *NoteService) Delete(ctx context.Context, userID, organization string, id int64) error {
+	if _, err := s.owned(ctx, userID, organization, id); err != nil {
+		return err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT object_key FROM symbol_note_attachments WHERE note_id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	if _, err := s.db.Exec(ctx, `DELETE FROM symbol_notes WHERE id = $1`, id); err != nil {
+		s.logger.Error("Failed to delete note", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	for _, key := range keys {
+		s.discard(ctx, key)
+	}
+	return nil
+}
+
+// AddAttachment stores a file on a note the user wrote
+func (s *NoteService) AddAttachment(ctx context.Context, userID, organization string, noteID int64, filename, contentType string, file io.Reader) (*models.NoteAttachment, error) {
+	if _, err := s.owned(ctx, userID, organization, noteID); err != nil {
+		return nil, err
+	}
+	filename = path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if filename == "." || filename == "/" {
+		filename = "attachment"
+	}
+	if len(filename) > 255 {
+		filename = filename[len(filename)-255:]
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	var a models.NoteAttachment
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO symbol_note_attachments (note_id, filename, content_type)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, noteID, filename, contentType).Scan(&a.ID)
+	if err != nil {
+		s.logger.Error("Failed to create attachment", zap.Int64("note_id", noteID), zap.Error(err))
+		return nil, err
+	}
+
+	key := fmt.Sprintf("notes/%d/%d", noteID, a.ID)
+	size, err := s.store.Put(ctx, key, file)
+	if err != nil {
+		if _, delErr := s.db.Exec(context.WithoutCancel(ctx), `DELETE FROM symbol_note_attachments WHERE id = $1`, a.ID); delErr != nil {
+			s.logger.Error("Failed to remove attachment", zap.Int64("id", a.ID), zap.Error(delErr))
+		}
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	err = s.db.QueryRow(ctx, `
+		UPDATE symbol_note_attachments SET object_key = $2, size_bytes = $3
+		WHERE id = $1
+		RETURNING id, note_id, filename, content_type, object_key, size_bytes, created_at
+	`, a.ID, key, size).Scan(&a.ID, &a.NoteID, &a.Filename, &a.ContentType, &a.ObjectKey, &a.SizeBytes, &a.CreatedAt)
+	if err != nil {
+		s.logger.Error("Failed to record attachment", zap.Int64("id", a.ID), zap.Error(err))
+		return nil, err
+	}
+	return &a, nil
+}
+
+// OpenAttachment returns an attachment of a note the user can read, and its content.
+// The caller closes the reader.
+func (s *NoteService) OpenAttachment(ctx context.Context, userID, organization string, noteID, id int64) (*models.NoteAttachment, io.ReadCloser, error) {
+	if _, err := s.Get(ctx, userID, organization, noteID); err != nil {
+		return nil, nil, err
+	}
+	a, err := s.attachment(ctx, noteID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := s.store.Open(ctx, a.ObjectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	return a, body, nil
+}
+
+// DeleteAttachment removes an attachment from a note the user wrote
+func (s *NoteService) DeleteAttachment(ctx context.Context, userID, organization string, noteID, id int64) error {
+	if _, err := s.owned(ctx, userID, organization, noteID); err != nil {
+		return err
+	}
+	a, err := s.attachment(ctx, noteID, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.Exec(ctx, `DELETE FROM symbol_note_attachments WHERE id = $1`, id); err != nil {
+		s.logger.Error("Failed to delete attachment", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	s.discard(ctx, a.ObjectKey)
+	return nil
+}
+
+// owned returns a note the user can read, refusing with ErrNoteReadOnly when
+// someone else wrote it
+func (s *NoteService) owned(ctx context.Context, userID, organization string, id int64) (*models.SymbolNote, error) {
+	note, err := s.Get(ctx, userID, organization, id)
+	if err != nil {
+		return nil, err
+	}
+	if note.UserID != userID {
+		return nil, ErrNoteReadOnly
+	}
+	return note, nil
+}
+
+func (s *NoteService) attachment(ctx context.Context, noteID, id int64) (*models.NoteAttachment, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, note_id, filename, content_type, object_key, size_bytes, created_at
+		FROM symbol_note_attachments
+		WHERE id = $1 AND note_id = $2
+	`, id, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.NoteAttachment])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// attach loads the attachments of notes, whose IDs are ids
+func (s *NoteService) attach(ctx context.Context, notes []models.SymbolNote, ids []int64) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, note_id, filename, content_type, object_key, size_bytes, created_at
+		FROM symbol_note_attachments
+		WHERE note_id = ANY($1)
+		ORDER BY id
+	`, ids)
+	if err != nil {
+		s.logger.Error("Failed to get note attachments", zap.Error(err))
+		return err
+	}
+	defer rows.Close()
+
+	attachments, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.NoteAttachment])
+	if err != nil {
+		return fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	byNote := map[int64][]models.NoteAttachment{}
+	for _, a := range attachments {
+		byNote[a.NoteID] = append(byNote[a.NoteID], a)
+	}
+	for i := range notes {
+		notes[i].Attachments = byNote[notes[i].ID]
+		if notes[i].Attachments == nil {
+			notes[i].Attachments = []models.NoteAttachment{}
+		}
+	}
+	return nil
+}
+
+func (s *NoteService) discard(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := s.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.logger.Warn("Failed to delete note attachment", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func scanNote(row pgx.Row) (*models.SymbolNote, error) {
+	var n models.SymbolNote
+	err := row.Scan(&n.ID, &n.UserID, &n.Organization, &n.Symbol, &n.Title, &n.Body, &n.Shared, &n.CreatedAt, &n.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	// offlineWindowDays is how many days of candles a bundle covers
+	offlineWindowDays = 90
+	// offlineRetention is how long a bundle can serve as the base of a delta
+	offlineRetention = 30 * 24 * time.Hour
+	// deltaOverlap widens a delta to candles changed shortly before its base was
+	// generated, catching writes that committed while the base was being read
+	deltaOverlap = time.Minute
+)
+
+// OfflineService builds the signed bundles the mobile app caches for offline use and
+// the deltas that bring a cached bundle up to date
+type OfflineService struct {
+	db     *database.DB
+	market *MarketService
+	users  *UserService
+	key    ed25519.PrivateKey
+	keyID  string
+	logger *zap.Logger
+}
+
+// NewOfflineService signs bundles with key
+func NewOfflineService(db *database.DB, market *MarketService, users *UserService, key ed25519.PrivateKey) *OfflineService {
+	sum := sha256.Sum256(key.Public().(ed25519.PublicKey))
+	return &OfflineService{
+		db:     db,
+		market: market,
+		users:  users,
+		key:    key,
+		keyID:  hex.EncodeToString(sum[:8]),
+		logger: logger.With(zap.String("service", "offline")),
+	}
+}
+
+// ParseOfflineKey decodes a base64 Ed25519 seed
+func ParseOfflineKey(seed string) (ed25519.PrivateKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(seed))
+	if err != nil {
+		return nil, fmt.Errorf("offline signing key is not base64: %w", err)
+	}
+	if len(data) != ed25519.SeedSize {
+		return nil, fmt.Errorf("offline signing key must be a %d-byte seed, got %d bytes", ed25519.SeedSize, len(data))
+	}
+	return ed25519.NewKeyFromSeed(data), nil
+}
+
+// Key returns the public key clients verify bundles with
+func (s *OfflineService) Key() models.OfflineKey {
+	return models.OfflineKey{
+		KeyID:     s.keyID,
+		Algorithm: "ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey)),
+	}
+}
+
+// Sign returns the base64 signature of a bundle's encoded body and the ID of the
+// key that made it
+func (s *OfflineService) Sign(body []byte) (string, string) {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, body)), s.keyID
+}
+
+// offlineBase is what an earlier bundle covered
+type offlineBase struct {
+	version         int64
+	symbols         []string
+	source          string
+	preferencesHash string
+	generatedAt     time.Time
+}
+
+// Bundle builds userID's bundle. With since, the version of a bundle the client holds,
+// only what changed after it is included; an unknown or expired since, or a change
+// of default source, gets a full bundle. It reports false, with no bundle, when
+// nothing changed since.
+func (s *OfflineService) Bundle(ctx context.Context, userID string, since int64) (*models.OfflineBundle, bool, error) {
+	prefs, err := s.preferences(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	prefsHash, err := hashPreferences(prefs)
+	if err != nil {
+		return nil, false, err
+	}
+	symbols := offlineSymbols(prefs.Watchlist)
+	source := MergedPreferring(prefs.DefaultSource)
+
+	var base *offlineBase
+	if since > 0 {
+		if base, err = s.base(ctx, userID, since); err != nil {
+			return nil, false, err
+		}
+		if base != nil && base.source != source {
+			base = nil
+		}
+	}
+
+	generatedAt := time.Now().UTC()
+	end := generatedAt.Truncate(24 * time.Hour)
+	start := end.AddDate(0, 0, -offlineWindowDays)
+
+	bundle := &models.OfflineBundle{
+		Full:        base == nil,
+		GeneratedAt: generatedAt,
+		Source:      source,
+		WindowStart: start.Format("2006-01-02"),
+		Preferences: prefs,
+		Series:      []models.OfflineSeries{},
+	}
+
+	fresh, kept := symbols, []string(nil)
+	if base != nil {
+		bundle.BaseVersion = base.version
+		fresh, kept, bundle.Removed = diffSymbols(base.symbols, symbols)
+	}
+
+	var data []models.MarketData
+	if len(fresh) > 0 {
+		rows, err := s.market.GetBySymbolsAndDateRange(ctx, fresh, source, models.IntervalDaily, start, end)
+		if err != nil {
+			return nil, false, err
+		}
+		data = append(data, rows...)
+	}
+	if len(kept) > 0 {
+		rows, err := s.market.GetBySymbolsChangedSince(ctx, kept, source, models.IntervalDaily, start, end, base.generatedAt.Add(-deltaOverlap))
+		if err != nil {
+			return nil, false, err
+		}
+		data = append(data, rows...)
+	}
+	bundle.Series = offlineSeries(symbols, data, base == nil)
+
+	if base != nil && len(fresh) == 0 && len(bundle.Removed) == 0 && len(bundle.Series) == 0 && base.preferencesHash == prefsHash {
+		return nil, false, nil
+	}
+
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO offline_bundles (user_id, symbols, source, window_start, preferences_hash, generated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING version
+	`, userID, pq.Array(symbols), source, start, prefsHash, generatedAt).Scan(&bundle.Version)
+	if err != nil {
+		s.logger.Error("Failed to record offline bundle", zap.String("user_id", userID), zap.Error(err))
+		return nil, false, err
+	}
+
+	// Bundles past retention can no longer be a delta's base
+	if _, err := s.db.Exec(ctx, `
+		DELETE FROM offline_bundles WHERE user_id = $1 AND generated_at < $2
+	`, userID, generatedAt.Add(-offlineRetention)); err != nil {
+		s.logger.Warn("Failed to prune offline bundles", zap.String("user_id", userID), zap.Error(err))
+	}
+
+	return bundle, true, nil
+}
+
+// preferences returns the user's preferences as bundled; a user who never saved any
+// gets empty ones
+func (s *OfflineService) preferences(ctx context.Context, userID string) (models.OfflinePreferences, error) {
+	prefs, err := s.users.GetPreferences(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.OfflinePreferences{DefaultSource: SourceAny, Watchlist: []string{}, SelectedSymbols: []string{}}, nil
+	}
+	if err != nil {
+		return models.OfflinePreferences{}, err
+	}
+	result := models.OfflinePreferences{
+		DefaultSource:   prefs.DefaultSource,
+		Watchlist:       prefs.Watchlist,
+		SelectedSymbols: prefs.SelectedSymbols,
+		DefaultLimit:    prefs.DefaultLimit,
+		DefaultWindow:   prefs.DefaultWindow,
+	}
+	if result.Watchlist == nil {
+		result.Watchlist = []string{}
+	}
+	if result.SelectedSymbols == nil {
+		result.SelectedSymbols = []string{}
+	}
+	return result, nil
+}
+
+// base loads an earlier bundle of userID still within retention, nil when there is none
+func (s *OfflineService) base(ctx context.Context, userID string, version int64) (*offlineBase, error) {
+	base := offlineBase{version: version}
+	err := s.db.QueryRow(ctx, `
+		SELECT symbols, source, preferences_hash, generated_at
+		FROM offline_bundles
+		WHERE version = $1 AND user_id = $2 AND generated_at >= $3
+	`, version, userID, time.Now().UTC().Add(-offlineRetention)).Scan(
+		pq.Array(&base.symbols), &base.source, &base.preferencesHash, &base.generatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		s.logger.Error("Failed to load offline bundle", zap.Int64("version", version), zap.Error(err))
+		return nil, err
+	}
+	return &base, nil
+}
+
+// hashPreferences fingerprints preferences so a delta can tell whether they changed
+func hashPreferences(prefs models.OfflinePreferences) (string, error) {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// offlineSymbols returns the watchlist upper-cased, without duplicates, sorted
+func offlineSymbols(watchlist []string) []string {
+	seen := map[string]bool{}
+	symbols := []string{}
+	for _, symbol := range watchlist {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// diffSymbols splits the current symbols into those the base lacked and those it
+// had, and lists the base's symbols that are gone
+func diffSymbols(before, now []string) (fresh, kept, removed []string) {
+	had := map[string]bool{}
+	for _, symbol := range before {
+		had[symbol] = true
+	}
+	for _, symbol := range now {
+		if had[symbol] {
+			kept = append(kept, symbol)
+			delete(had, symbol)
+		} else {
+			fresh = append(fresh, symbol)
+		}
+	}
+	for symbol := range had {
+		removed = append(removed, symbol)
+	}
+	sort.Strings(removed)
+	return fresh, kept, removed
+}
+
+// offlineSeries groups candles by symbol in the order of symbols. A full bundle lists
+// every symbol, a delta only those with candles.
+func offlineSeries(symbols []string, data []models.MarketData, full bool) []models.OfflineSeries {
+	groups := GroupBySymbol(data)
+	series := []models.OfflineSeries{}
+	for _, symbol := range symbols {
+		rows := groups[symbol]
+		if len(rows) == 0 && !full {
+			continue
+		}
+		candles := make([]models.OfflineCandle, len(rows))
+		for i, md := range rows {
+			candles[i] = models.OfflineCandle{
+				Date:   md.Date.Format("2006-01-02"),
+				Open:   md.Open,
+				High:   md.High,
+				Low:    md.Low,
+				Close:  md.Close,
+				Volume: md.Volume,
+			}
+		}
+		series = append(series, models.OfflineSeries{Symbol: symbol, Candles: candles})
+	}
+	return series
+}
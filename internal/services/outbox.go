@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ErrOutboxMessageNotFound is returned when retrying a message that is not dead
+var ErrOutboxMessageNotFound = errors.New("outbox message not found or not dead")
+
+// Publisher delivers outbox messages to a broker or endpoint. Publish must return
+// nil only once the message has been accepted; it may be called again for the same
+// message after a failure or crash.
+type Publisher interface {
+	Name() string
+	Publish(ctx context.Context, msg models.OutboxMessage) error
+}
+
+// OutboxOptions tunes the relay
+type OutboxOptions struct {
+	PollInterval time.Duration // how often pending messages are looked for
+	BatchSize    int           // messages relayed per transaction
+	MaxAttempts  int           // failed deliveries before a message is given up on
+	Retention    time.Duration // how long delivered messages are kept
+}
+
+// Relay defaults for options left zero
+const (
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxBatchSize    = 100
+	defaultOutboxMaxAttempts  = 20
+	defaultOutboxRetention    = 7 * 24 * time.Hour
+	maxOutboxBackoff          = 15 * time.Minute
+)
+
+// OutboxService writes messages alongside the changes they describe and relays them
+// to a Publisher after commit. Without a publisher it is disabled: nothing is
+// written and the relay does not run.
+type OutboxService struct {
+	db        *database.DB
+	publisher Publisher
+	opts      OutboxOptions
+	logger    *zap.Logger
+}
+
+func NewOutboxService(db *database.DB, publisher Publisher, opts OutboxOptions) *OutboxService {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultOutboxPollInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultOutboxBatchSize
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultOutboxMaxAttempts
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = defaultOutboxRetention
+	}
+	return &OutboxService{
+		db:        db,
+		publisher: publisher,
+		opts:      opts,
+		logger:    logger.With(zap.String("service", "outbox")),
+	}
+}
+
+// Enabled reports whether messages are written and relayed. A nil service is disabled.
+func (s *OutboxService) Enabled() bool {
+	return s != nil && s.publisher != nil
+}
+
+// Enqueue writes a message in tx, so it is published if and only if tx commits
+func (s *OutboxService) Enqueue(ctx context.Context, tx pgx.Tx, topic, key string, payload interface{}) error {
+	if !s.Enabled() {
+		return nil
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", topic, err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO outbox_messages (topic, key, payload) VALUES ($1, $2, $3)`, topic, key, raw); err != nil {
+		return fmt.Errorf("failed to enqueue %s message: %w", topic, err)
+	}
+	return nil
+}
+
+// Start relays pending messages until ctx is cancelled, and purges delivered ones
+// past retention hourly
+func (s *OutboxService) Start(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+	s.logger.Info("Outbox relay started", zap.String("publisher", s.publisher.Name()))
+
+	poll := time.NewTicker(s.opts.PollInterval)
+	defer poll.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			s.drain(ctx)
+		case <-purge.C:
+			s.purge(ctx)
+		}
+	}
+}
+
+// drain relays batches until one comes back short
+func (s *OutboxService) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		relayed, err := s.relay(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to relay outbox messages", zap.Error(err))
+			}
+			return
+		}
+		if relayed < s.opts.BatchSize {
+			return
+		}
+	}
+}
+
+// relay delivers one batch of due messages oldest first, returning how many it
+// attempted. A transaction-scoped advisory lock lets one instance relay at a time,
+// which with per-key blocking keeps each key's messages in order. A message is
+// marked delivered only after the publisher accepts it, so a crash in between
+// delivers it again.
+func (s *OutboxService) relay(ctx context.Context) (int, error) {
+	attempted := 0
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock(hashtextextended('outbox_relay', 0))`).Scan(&locked); err != nil {
+			return fmt.Errorf("failed to take relay lock: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		// Messages held back by an earlier failure of their key are not due either
+		rows, err := tx.Query(ctx, `
+			SELECT m.id, m.topic, m.key, m.payload, m.created_at, m.attempts
+			FROM outbox_messages m
+			WHERE m.delivered_at IS NULL AND m.dead_at IS NULL AND m.available_at <= CURRENT_TIMESTAMP
+				AND NOT EXISTS (
+					SELECT 1 FROM outbox_messages b
+					WHERE b.key = m.key AND b.key <> '' AND b.id < m.id
+						AND b.delivered_at IS NULL AND b.dead_at IS NULL AND b.available_at > CURRENT_TIMESTAMP
+				)
+			ORDER BY m.id
+			LIMIT $1
+		`, s.opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to load messages: %w", err)
+		}
+		type pending struct {
+			msg      models.OutboxMessage
+			attempts int
+		}
+		var batch []pending
+		for rows.Next() {
+			var p pending
+			if err := rows.Scan(&p.msg.ID, &p.msg.Topic, &p.msg.Key, &p.msg.Payload, &p.msg.CreatedAt, &p.attempts); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan message: %w", err)
+			}
+			batch = append(batch, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("row iteration error: %w", err)
+		}
+
+		updates := &pgx.Batch{}
+		blocked := map[string]bool{}
+		for _, p := range batch {
+			if err := ctx.Err(); err != nil {
+				break
+			}
+			// A failed message holds back the rest of its key
+			if p.msg.Key != "" && blocked[p.msg.Key] {
+				continue
+			}
+			attempted++
+
+			err := s.publisher.Publish(ctx, p.msg)
+			if err == nil {
+				updates.Queue(`UPDATE outbox_messages SET delivered_at = CURRENT_TIMESTAMP, attempts = attempts + 1 WHERE id = $1`, p.msg.ID)
+				continue
+			}
+
+			blocked[p.msg.Key] = true
+			attempts := p.attempts + 1
+			if attempts >= s.opts.MaxAttempts {
+				s.logger.Error("Giving up on outbox message",
+					zap.Int64("id", p.msg.ID),
+					zap.String("topic", p.msg.Topic),
+					zap.Int("attempts", attempts),
+					zap.Error(err),
+				)
+				updates.Queue(`
+					UPDATE outbox_messages SET attempts = $2, last_error = $3, dead_at = CURRENT_TIMESTAMP WHERE id = $1
+				`, p.msg.ID, attempts, err.Error())
+				continue
+			}
+			s.logger.Warn("Outbox delivery failed",
+				zap.Int64("id", p.msg.ID),
+				zap.String("topic", p.msg.Topic),
+				zap.Int("attempts", attempts),
+				zap.Error(err),
+			)
+			updates.Queue(`
+				UPDATE outbox_messages
+				SET attempts = $2, last_error = $3, available_at = CURRENT_TIMESTAMP + make_interval(secs => $4)
+				WHERE id = $1
+			`, p.msg.ID, attempts, err.Error(), outboxBackoff(attempts).Seconds())
+		}
+
+		if updates.Len() == 0 {
+			return nil
+		}
+		// Record outcomes even if the relay is stopping; deliveries already happened
+		if err := tx.SendBatch(context.WithoutCancel(ctx), updates).Close(); err != nil {
+			return fmt.Errorf("failed to record deliveries: %w", err)
+		}
+		return nil
+	})
+	return attempted, err
+}
+
+// outboxBackoff doubles the wait after each failed delivery, from one second up to
+// maxOutboxBackoff
+func outboxBackoff(attempts int) time.Duration {
+	wait := time.Second
+	for i := 1; i < attempts && wait < maxOutboxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxOutboxBackoff {
+		wait = maxOutboxBackoff
+	}
+	return wait
+}
+
+func (s *OutboxService) purge(ctx context.Context) {
+	tag, err := s.db.Exec(ctx, `DELETE FROM outbox_messages WHERE delivered_at < CURRENT_TIMESTAMP - make_interval(secs => $1)`,
+		s.opts.Retention.Seconds())
+	if err != nil {
+		s.logger.Error("Failed to purge delivered outbox messages", zap.Error(err))
+		return
+	}
+	if tag.RowsAffected() > 0 {
+		s.logger.Info("Purged delivered outbox messages", zap.Int64("count", tag.RowsAffected()))
+	}
+}
+
+// Status reports the backlog and the most recent failures
+func (s *OutboxService) Status(ctx context.Context) (*models.OutboxStatus, error) {
+	status := &models.OutboxStatus{Enabled: s.Enabled(), Failures: []models.OutboxFailure{}}
+	if status.Enabled {
+		status.Publisher = s.publisher.Name()
+	}
+
+	err := s.db.QueryRow(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE delivered_at IS NULL AND dead_at IS NULL),
+			COUNT(*) FILTER (WHERE delivered_at IS NULL AND dead_at IS NULL AND attempts > 0),
+			COUNT(*) FILTER (WHERE dead_at IS NOT NULL),
+			MIN(created_at) FILTER (WHERE delivered_at IS NULL AND dead_at IS NULL)
+		FROM outbox_messages
+		WHERE delivered_at IS NULL
+	`).Scan(&status.Pending, &status.Retrying, &status.Dead, &status.OldestPendingAt)
+	if err != nil {
+		s.logger.Error("Failed to get outbox status", zap.Error(err))
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, topic, key, attempts, last_error,
+			CASE WHEN dead_at IS NULL THEN available_at END, dead_at, created_at
+		FROM outbox_messages
+		WHERE delivered_at IS NULL AND attempts > 0
+		ORDER BY COALESCE(dead_at, available_at) DESC, id DESC
+		LIMIT 50
+	`)
+	if err != nil {
+		s.logger.Error("Failed to list outbox failures", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	status.Failures, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.OutboxFailure])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return status, nil
+}
+
+// Retry returns a dead message to the queue with its attempts reset
+func (s *OutboxService) Retry(ctx context.Context, id int64) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE outbox_messages SET dead_at = NULL, attempts = 0, available_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND dead_at IS NOT NULL AND delivered_at IS NULL
+	`, id)
+	if err != nil {
+		s.logger.Error("Failed to retry outbox message", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOutboxMessageNotFound
+	}
+	return nil
+}
+
+// RetryDead returns every dead message created in [from, to) to the queue with its
+// attempts reset, only those of topic when it is not empty, and returns how many
+func (s *OutboxService) RetryDead(ctx context.Context, from, to time.Time, topic string) (int64, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE outbox_messages SET dead_at = NULL, attempts = 0, available_at = CURRENT_TIMESTAMP
+		WHERE dead_at IS NOT NULL AND delivered_at IS NULL
+			AND created_at >= $1 AND created_at < $2
+			AND ($3 = '' OR topic = $3)
+	`, from, to, topic)
+	if err != nil {
+		s.logger.Error("Failed to retry outbox messages",
+			zap.Time("from", from),
+			zap.Time("to", to),
+			zap.String("topic", topic),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/stream"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrPortfolioNotFound = errors.New("portfolio not found")
+	ErrPortfolioExists   = errors.New("a portfolio with this name already exists")
+	ErrHoldingNotFound   = errors.New("holding not found")
+)
+
+type PortfolioService struct {
+	db     *database.DB
+	market *MarketService
+	nav    *NAVService
+	bonds  *BondService
+	fx     *FXService
+	outbox *OutboxService
+	hub    *stream.Hub
+	logger *zap.Logger
+}
+
+// NewPortfolioService creates the service; trades and cash movements it records are
+// published to hub
+func NewPortfolioService(db *database.DB, market *MarketService, nav *NAVService, bonds *BondService, fx *FXService, outbox *OutboxService, hub *stream.Hub) *PortfolioService {
+	return &PortfolioService{
+		db:     db,
+		market: market,
+		nav:    nav,
+		bonds:  bonds,
+		fx:     fx,
+		outbox: outbox,
+		hub:    hub,
+		logger: logger.With(zap.String("service", "portfolio")),
+	}
+}
+
+// List returns the user's portfolios with their holdings
+func (s *PortfolioService) List(ctx context.Context, userID string) ([]models.Portfolio, error) {
+	query := `
+		SELECT id, user_id, name, cash, created_at, updated_at
+		FROM portfolios
+		WHERE user_id = $1
+		ORDER BY name
+	`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		s.logger.Error("Failed to list portfolios",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	portfolios := []models.Portfolio{}
+	index := map[int64]int{}
+	for rows.Next() {
+		var p models.Portfolio
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Name, &p.Cash, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		p.Holdings = []models.Holding{}
+		index[p.ID] = len(portfolios)
+		portfolios = append(portfolios, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	if len(portfolios) == 0 {
+		return portfolios, nil
+	}
+
+	// Load every portfolio's holdings in one query
+	holdingRows, err := s.db.Query(ctx, `
+		SELECT h.portfolio_id, h.symbol, h.quantity, h.avg_price, h.created_at, h.updated_at
+		FROM portfolio_holdings h
+		JOIN portfolios p ON p.id = h.portfolio_id
+		WHERE p.user_id = $1
+		ORDER BY h.symbol
+	`, userID)
+	if err != nil {
+		s.logger.Error("Failed to list holdings",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer holdingRows.Close()
+
+	for holdingRows.Next() {
+		var portfolioID int64
+		var h models.Holding
+		if err := holdingRows.Scan(&portfolioID, &h.Symbol, &h.Quantity, &h.AvgPrice, &h.CreatedAt, &h.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if i, ok := index[portfolioID]; ok {
+			portfolios[i].Holdings = append(portfolios[i].Holdings, h)
+		}
+	}
+	if err := holdingRows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return portfolios, nil
+}
+
+// Get returns one of the user's portfolios with its holdings
+func (s *PortfolioService) Get(ctx context.Context, userID string, id int64) (*models.Portfolio, error) {
+	query := `SELECT id, user_id, name, cash, created_at, updated_at FROM portfolios WHERE id = $1 AND user_id = $2`
+
+	var p models.Portfolio
+	err := s.db.QueryRow(ctx, query, id, userID).Scan(&p.ID, &p.UserID, &p.Name, &p.Cash, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPortfolioNotFound
+		}
+		s.logger.Error("Failed to get portfolio",
+			zap.Int64("id", id),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT symbol, quantity, avg_price, created_at, updated_at
+		FROM portfolio_holdings
+		WHERE portfolio_id = $1
+		ORDER BY symbol
+	`, id)
+	if err != nil {
+		s.logger.Error("Failed to get holdings",
+			zap.Int64("id", id),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	p.Holdings, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.Holding])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return &p, nil
+}
+
+// Create adds an empty portfolio
+func (s *PortfolioService) Create(ctx context.Context, userID, name string) (*models.Portfolio, error) {
+	query := `
+		INSERT INTO portfolios (user_id, name)
+		VALUES ($1, $2)
+		RETURNING id, user_id, name, cash, created_at, updated_at
+	`
+
+	p := models.Portfolio{Holdings: []models.Holding{}}
+	err := s.db.QueryRow(ctx, query, userID, name).Scan(&p.ID, &p.UserID, &p.Name, &p.Cash, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrPortfolioExists
+		}
+		s.logger.Error("Failed to create portfolio",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// Rename changes a portfolio's name
+func (s *PortfolioService) Rename(ctx context.Context, userID string, id int64, name string) error {
+	tag, err := s.db.Exec(ctx, `UPDATE portfolios SET name = $3 WHERE id = $1 AND user_id = $2`, id, userID, name)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrPortfolioExists
+		}
+		s.logger.Error("Failed to rename portfolio",
+			zap.Int64("id", id),
+			zap.Error(err),
+		)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPortfolioNotFound
+	}
+	return nil
+}
+
+// Delete removes a portfolio and its holdings
+func (s *PortfolioService) Delete(ctx context.Context, userID string, id int64) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM portfolios WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		s.logger.Error("Failed to delete portfolio",
+			zap.Int64("id", id),
+			zap.Error(err),
+		)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPortfolioNotFound
+	}
+	return nil
+}
+
+// SetHolding records the position held in a symbol from at, replacing any previous
+// one; a zero at is now
+func (s *PortfolioService) SetHolding(ctx context.Context, userID string, id int64, symbol string, quantity, avgPrice float64, at time.Time) (*models.Holding, error) {
+	state, _, err := s.record(ctx, userID, models.PortfolioEvent{
+		PortfolioID: id,
+		EventType:   models.EventHoldingSet,
+		Symbol:      symbol,
+		Quantity:    &quantity,
+		Price:       &avgPrice,
+		OccurredAt:  at,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if h, ok := state.Holdings[symbol]; ok {
+		return &h, nil
+	}
+	// A backdated position sold out of since is answered as it was set
+	return &models.Holding{Symbol: symbol, Quantity: quantity, AvgPrice: avgPrice, CreatedAt: at, UpdatedAt: at}, nil
+}
+
+// RemoveHolding deletes the position in a symbol
+func (s *PortfolioService) RemoveHolding(ctx context.Context, userID string, id int64, symbol string) error {
+	_, _, err := s.record(ctx, userID, models.PortfolioEvent{
+		PortfolioID: id,
+		EventType:   models.EventHoldingRemoved,
+		Symbol:      symbol,
+	})
+	return err
+}
+
+// Adjustments returns the changes corporate actions made to a portfolio's holdings,
+// newest first
+func (s *PortfolioService) Adjustments(ctx context.Context, userID string, id int64) ([]models.PortfolioAdjustment, error) {
+	if err := s.ensureOwned(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT a.id, a.action_id, ca.action_type, a.portfolio_id, a.symbol, a.quantity_before, a.quantity_after,
+			a.avg_price_before, a.avg_price_after, a.created_at
+		FROM portfolio_adjustments a
+		JOIN corporate_actions ca ON ca.id = a.action_id
+		WHERE a.portfolio_id = $1
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT 200
+	`, id)
+	if err != nil {
+		s.logger.Error("Failed to get adjustments", zap.Int64("portfolio_id", id), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.PortfolioAdjustment])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return adjustments, nil
+}
+
+// Value prices a portfolio at each holding's latest close from source, fetched in one
+// query. Holdings without candles, such as mutual funds, are priced at their latest NAV.
+// Bond holdings are face value priced in percent of par, with interest accrued to today.
+// With asOf, the holdings then are priced at the closes, NAVs and accrual of that day,
+// so corrected historical prices are picked up. With currency, holdings quoted in
+// other currencies are converted at the rates of the valuation date.
+func (s *PortfolioService) Value(ctx context.Context, userID string, id int64, source, currency string, asOf *time.Time) (*models.PortfolioValuation, error) {
+	var p *models.Portfolio
+	var err error
+	priceDate := time.Now().UTC().Truncate(24 * time.Hour)
+	if asOf != nil {
+		p, err = s.GetAt(ctx, userID, id, *asOf)
+		priceDate = asOf.UTC().Truncate(24 * time.Hour)
+	} else {
+		p, err = s.Get(ctx, userID, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]string, len(p.Holdings))
+	for i, h := range p.Holdings {
+		all[i] = h.Symbol
+	}
+
+	bonds := map[string]models.BondValuation{}
+	if len(all) > 0 {
+		bonds, err = s.bonds.Value(ctx, all, priceDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var symbols []string
+	for _, symbol := range all {
+		if _, ok := bonds[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	latest := map[string]models.MarketData{}
+	if len(symbols) > 0 {
+		latest, err = s.market.GetLatestBySymbolsAt(ctx, symbols, source, models.IntervalDaily, p.AsOf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var unquoted []string
+	for _, symbol := range symbols {
+		if _, ok := latest[symbol]; !ok {
+			unquoted = append(unquoted, symbol)
+		}
+	}
+	navs := map[string]models.NAV{}
+	if len(unquoted) > 0 {
+		navs, err = s.nav.GetLatestBySymbolsAt(ctx, unquoted, p.AsOf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	valuation := &models.PortfolioValuation{
+		PortfolioID: p.ID,
+		Name:        p.Name,
+		AsOf:        p.AsOf,
+		Cash:        p.Cash,
+		Holdings:    make([]models.HoldingValuation, 0, len(p.Holdings)),
+	}
+
+	// With a currency, every amount is converted at the rate in force on the
+	// valuation date; cash is held in IDR
+	var currencies map[string]string
+	var converter *Converter
+	if currency != "" {
+		if currencies, err = s.fx.Currencies(ctx, all); err != nil {
+			return nil, err
+		}
+		converter = s.fx.Converter(currency)
+		rate, err := converter.Rate(ctx, fxHomeCurrency, priceDate)
+		if err != nil {
+			return nil, err
+		}
+		valuation.Currency = converter.to
+		valuation.Cash = roundAmount(p.Cash * rate.Rate)
+	}
+
+	for _, h := range p.Holdings {
+		hv := models.HoldingValuation{
+			Symbol:    h.Symbol,
+			Quantity:  h.Quantity,
+			AvgPrice:  h.AvgPrice,
+			CostBasis: h.Quantity * h.AvgPrice,
+		}
+		fxRate := 1.0
+		if converter != nil {
+			rate, err := converter.Rate(ctx, currencies[h.Symbol], priceDate)
+			if err != nil {
+				return nil, err
+			}
+			fxRate = rate.Rate
+			hv.Currency = currencies[h.Symbol]
+			hv.FXRate = &fxRate
+			hv.CostBasis *= fxRate
+		}
+
+		var price, value float64
+		if bond, ok := bonds[h.Symbol]; ok {
+			// Bond prices stay in percent of par; only amounts are converted
+			accrued := h.Quantity * bond.AccruedInterest / 100 * fxRate
+			hv.CostBasis = h.Quantity * h.AvgPrice / 100 * fxRate
+			hv.AccruedInterest = &accrued
+			price = bond.CleanPrice
+			value = h.Quantity * bond.DirtyPrice / 100 * fxRate
+			hv.PriceDate = &bond.Date
+			hv.PriceSource = bond.PriceSource
+		} else {
+			hv.AvgPrice *= fxRate
+			if md, ok := latest[h.Symbol]; ok {
+				price = md.Close * fxRate
+				value = h.Quantity * price
+				hv.PriceDate = &md.Date
+				hv.PriceSource = md.Source
+			} else if nav, ok := navs[h.Symbol]; ok {
+				price = nav.NAV * fxRate
+				value = h.Quantity * price
+				hv.PriceDate = &nav.Date
+				hv.PriceSource = nav.Source
+			} else {
+				valuation.Unpriced = append(valuation.Unpriced, h.Symbol)
+				valuation.Holdings = append(valuation.Holdings, hv)
+				continue
+			}
+		}
+
+		pnl := value - hv.CostBasis
+		hv.LastPrice = &price
+		hv.MarketValue = &value
+		hv.UnrealizedPnL = &pnl
+		if hv.CostBasis != 0 {
+			pct := pnl / hv.CostBasis * 100
+			hv.PnLPct = &pct
+		}
+
+		valuation.CostBasis += hv.CostBasis
+		valuation.MarketValue += value
+		valuation.UnrealizedPnL += pnl
+		valuation.Holdings = append(valuation.Holdings, hv)
+	}
+	if valuation.CostBasis != 0 {
+		valuation.PnLPct = valuation.UnrealizedPnL / valuation.CostBasis * 100
+	}
+
+	return valuation, nil
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInsufficientHoldings  = errors.New("sell quantity exceeds the holding")
+	ErrFutureEvent           = errors.New("portfolio changes cannot be dated in the future")
+	ErrBeforeCorporateAction = errors.New("a corporate action adjusted this holding later; record the change after it")
+)
+
+// portfolioEventRejections are the errors recording an event answers for a change
+// the portfolio's history does not allow
+var portfolioEventRejections = []error{
+	ErrPortfolioNotFound, ErrHoldingNotFound, ErrInsufficientHoldings, ErrBeforeCorporateAction,
+}
+
+// portfolioSnapshotEvery is how many events a replay goes through before the state
+// it reached is stored as a snapshot
+const portfolioSnapshotEvery = 100
+
+// futureEventSkew tolerates clients whose clock runs slightly ahead
+const futureEventSkew = time.Minute
+
+// portfolioQuerier is the pool or a transaction
+type portfolioQuerier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// portfolioState is what replaying a portfolio's events up to some point gives.
+// It is also the JSON of a snapshot.
+type portfolioState struct {
+	Cash     float64                   `json:"cash"`
+	Holdings map[string]models.Holding `json:"holdings"`
+}
+
+func newPortfolioState() *portfolioState {
+	return &portfolioState{Holdings: map[string]models.Holding{}}
+}
+
+// apply replays one event. A buy averages its price into the holding and spends
+// cash; a sell keeps the average price and returns cash.
+func (st *portfolioState) apply(e models.PortfolioEvent) error {
+	switch e.EventType {
+	case models.EventHoldingSet, models.EventCorporateAction:
+		st.set(e.Symbol, eventValue(e.Quantity), eventValue(e.Price), e.OccurredAt)
+	case models.EventHoldingRemoved:
+		if _, ok := st.Holdings[e.Symbol]; !ok {
+			return fmt.Errorf("%w: no %s held on %s", ErrHoldingNotFound, e.Symbol, e.OccurredAt.Format(time.RFC3339))
+		}
+		delete(st.Holdings, e.Symbol)
+	case models.EventTrade:
+		h := st.Holdings[e.Symbol]
+		quantity, price := eventValue(e.Quantity), eventValue(e.Price)
+		if e.Side == models.SideBuy {
+			total := h.Quantity + quantity
+			st.set(e.Symbol, total, (h.Quantity*h.AvgPrice+quantity*price)/total, e.OccurredAt)
+			st.Cash = roundAmount(st.Cash - quantity*price)
+			break
+		}
+		remaining := roundAmount(h.Quantity - quantity)
+		if remaining < 0 {
+			return fmt.Errorf("%w: selling %g %s on %s with %g held", ErrInsufficientHoldings,
+				quantity, e.Symbol, e.OccurredAt.Format(time.RFC3339), h.Quantity)
+		}
+		if remaining == 0 {
+			delete(st.Holdings, e.Symbol)
+		} else {
+			st.set(e.Symbol, remaining, h.AvgPrice, e.OccurredAt)
+		}
+		st.Cash = roundAmount(st.Cash + quantity*price)
+	case models.EventDeposit:
+		st.Cash = roundAmount(st.Cash + eventValue(e.Amount))
+	case models.EventWithdrawal:
+		st.Cash = roundAmount(st.Cash - eventValue(e.Amount))
+	default:
+		return fmt.Errorf("unknown portfolio event type %q", e.EventType)
+	}
+	return nil
+}
+
+func (st *portfolioState) set(symbol string, quantity, avgPrice float64, at time.Time) {
+	h, ok := st.Holdings[symbol]
+	if !ok {
+		h = models.Holding{Symbol: symbol, CreatedAt: at}
+	}
+	h.Quantity = roundAmount(quantity)
+	h.AvgPrice = roundHoldingPrice(avgPrice)
+	h.UpdatedAt = at
+	st.Holdings[symbol] = h
+}
+
+// list returns the holdings ordered by symbol
+func (st *portfolioState) list() []models.Holding {
+	holdings := make([]models.Holding, 0, len(st.Holdings))
+	for _, h := range st.Holdings {
+		holdings = append(holdings, h)
+	}
+	sort.Slice(holdings, func(i, j int) bool { return holdings[i].Symbol < holdings[j].Symbol })
+	return holdings
+}
+
+// replayPortfolio rebuilds a portfolio's state from its latest snapshot at or before
+// asOf and the events after it, or from every event when asOf is nil. It also
+// returns the last event applied, nil when there was none, and how many events
+// were replayed past the snapshot.
+func replayPortfolio(ctx context.Context, q portfolioQuerier, portfolioID int64, asOf *time.Time) (*portfolioState, *models.PortfolioEvent, int, error) {
+	state := newPortfolioState()
+	var last *models.PortfolioEvent
+
+	var snapshotID int64
+	var snapshotAt time.Time
+	var raw []byte
+	err := q.QueryRow(ctx, `
+		SELECT event_id, at, state FROM portfolio_snapshots
+		WHERE portfolio_id = $1 AND ($2::timestamp IS NULL OR at <= $2)
+		ORDER BY at DESC, event_id DESC
+		LIMIT 1
+	`, portfolioID, asOf).Scan(&snapshotID, &snapshotAt, &raw)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return nil, nil, 0, fmt.Errorf("failed to load snapshot: %w", err)
+	default:
+		if err := json.Unmarshal(raw, state); err != nil {
+			return nil, nil, 0, fmt.Errorf("failed to decode snapshot %d: %w", snapshotID, err)
+		}
+		if state.Holdings == nil {
+			state.Holdings = map[string]models.Holding{}
+		}
+		last = &models.PortfolioEvent{ID: snapshotID, PortfolioID: portfolioID, OccurredAt: snapshotAt}
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT id, portfolio_id, event_type, symbol, side, quantity, price, amount, action_id, occurred_at, recorded_at
+		FROM portfolio_events
+		WHERE portfolio_id = $1 AND (occurred_at, id) > ($2, $3) AND ($4::timestamp IS NULL OR occurred_at <= $4)
+		ORDER BY occurred_at, id
+	`, portfolioID, snapshotAt, snapshotID, asOf)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to load events: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.PortfolioEvent])
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	for i := range events {
+		if err := state.apply(events[i]); err != nil {
+			return nil, nil, 0, err
+		}
+		last = &events[i]
+	}
+	return state, last, len(events), nil
+}
+
+// record appends an event to a portfolio the user owns and brings the holdings and
+// cash projection up to date by replaying it, all in one transaction. An event
+// the history cannot absorb, such as a backdated sell that leaves a later sell
+// short, is rejected and nothing is stored.
+func (s *PortfolioService) record(ctx context.Context, userID string, e models.PortfolioEvent) (*portfolioState, *models.PortfolioEvent, error) {
+	now := time.Now().UTC()
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = now
+	}
+	e.OccurredAt = e.OccurredAt.UTC()
+	if e.OccurredAt.After(now.Add(futureEventSkew)) {
+		return nil, nil, ErrFutureEvent
+	}
+
+	var state *portfolioState
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		// Serialize a portfolio's writers, so each replays the others' events
+		err := tx.QueryRow(ctx, `SELECT id FROM portfolios WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+			e.PortfolioID, userID).Scan(&e.PortfolioID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPortfolioNotFound
+			}
+			return fmt.Errorf("failed to lock portfolio: %w", err)
+		}
+
+		// Corporate action events record the position they left, so a change
+		// before one would not be carried through it
+		if e.Symbol != "" {
+			var adjusted bool
+			err := tx.QueryRow(ctx, `
+				SELECT EXISTS (
+					SELECT 1 FROM portfolio_events
+					WHERE portfolio_id = $1 AND symbol = $2 AND event_type = $3 AND occurred_at > $4
+				)
+			`, e.PortfolioID, e.Symbol, models.EventCorporateAction, e.OccurredAt).Scan(&adjusted)
+			if err != nil {
+				return fmt.Errorf("failed to check corporate actions: %w", err)
+			}
+			if adjusted {
+				return ErrBeforeCorporateAction
+			}
+		}
+
+		err = tx.QueryRow(ctx, `
+			INSERT INTO portfolio_events (portfolio_id, event_type, symbol, side, quantity, price, amount, occurred_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, recorded_at
+		`, e.PortfolioID, e.EventType, e.Symbol, e.Side, e.Quantity, e.Price, e.Amount, e.OccurredAt).Scan(&e.ID, &e.RecordedAt)
+		if err != nil {
+			return fmt.Errorf("failed to append event: %w", err)
+		}
+		if err := s.outbox.Enqueue(ctx, tx, models.TopicPortfolioEvent, portfolioKey(e.PortfolioID), e); err != nil {
+			return err
+		}
+
+		// A backdated event invalidates the snapshots taken after it
+		if _, err := tx.Exec(ctx, `DELETE FROM portfolio_snapshots WHERE portfolio_id = $1 AND at >= $2`,
+			e.PortfolioID, e.OccurredAt); err != nil {
+			return fmt.Errorf("failed to drop snapshots: %w", err)
+		}
+
+		var last *models.PortfolioEvent
+		var replayed int
+		state, last, replayed, err = replayPortfolio(ctx, tx, e.PortfolioID, nil)
+		if err != nil {
+			return err
+		}
+		if err := writeProjection(ctx, tx, e.PortfolioID, state); err != nil {
+			return err
+		}
+
+		if replayed >= portfolioSnapshotEvery {
+			raw, err := json.Marshal(state)
+			if err != nil {
+				return fmt.Errorf("failed to encode snapshot: %w", err)
+			}
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO portfolio_snapshots (portfolio_id, event_id, at, state)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT DO NOTHING
+			`, e.PortfolioID, last.ID, last.OccurredAt, raw); err != nil {
+				return fmt.Errorf("failed to store snapshot: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, rejection := range portfolioEventRejections {
+			if errors.Is(err, rejection) {
+				return nil, nil, err
+			}
+		}
+		s.logger.Error("Failed to record portfolio event",
+			zap.Int64("portfolio_id", e.PortfolioID),
+			zap.String("event_type", e.EventType),
+			zap.String("symbol", e.Symbol),
+			zap.Error(err),
+		)
+		return nil, nil, err
+	}
+	s.hub.PublishPortfolio(userID, e)
+	return state, &e, nil
+}
+
+// writeProjection makes portfolio_holdings and portfolios.cash match a replayed
+// state, leaving unchanged holdings and their timestamps alone
+func writeProjection(ctx context.Context, tx pgx.Tx, portfolioID int64, state *portfolioState) error {
+	holdings := state.list()
+	symbols := make([]string, len(holdings))
+	quantities := make([]float64, len(holdings))
+	prices := make([]float64, len(holdings))
+	for i, h := range holdings {
+		symbols[i], quantities[i], prices[i] = h.Symbol, h.Quantity, h.AvgPrice
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM portfolio_holdings WHERE portfolio_id = $1 AND symbol <> ALL($2)`, portfolioID, symbols)
+	batch.Queue(`
+		INSERT INTO portfolio_holdings (portfolio_id, symbol, quantity, avg_price)
+		SELECT $1, symbol, quantity, avg_price
+		FROM unnest($2::text[], $3::numeric[], $4::numeric[]) AS h (symbol, quantity, avg_price)
+		ON CONFLICT (portfolio_id, symbol) DO UPDATE SET
+			quantity = EXCLUDED.quantity,
+			avg_price = EXCLUDED.avg_price
+		WHERE (portfolio_holdings.quantity, portfolio_holdings.avg_price)
+			IS DISTINCT FROM (EXCLUDED.quantity, EXCLUDED.avg_price)
+	`, portfolioID, symbols, quantities, prices)
+	batch.Queue(`UPDATE portfolios SET cash = $2 WHERE id = $1 AND cash <> $2`, portfolioID, state.Cash)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to update holdings: %w", err)
+	}
+	return nil
+}
+
+// RecordTrade buys or sells a symbol, adjusting the holding and cash
+func (s *PortfolioService) RecordTrade(ctx context.Context, userID string, id int64, req models.TradeRequest) (*models.PortfolioEvent, *models.Holding, error) {
+	quantity, price := req.Quantity, req.Price
+	e := models.PortfolioEvent{
+		PortfolioID: id,
+		EventType:   models.EventTrade,
+		Symbol:      req.Symbol,
+		Side:        req.Side,
+		Quantity:    &quantity,
+		Price:       &price,
+	}
+	if req.ExecutedAt != nil {
+		e.OccurredAt = *req.ExecutedAt
+	}
+
+	state, event, err := s.record(ctx, userID, e)
+	if err != nil {
+		return nil, nil, err
+	}
+	// nil once a sell closes the position
+	if h, ok := state.Holdings[req.Symbol]; ok {
+		return event, &h, nil
+	}
+	return event, nil, nil
+}
+
+// RecordCash deposits or withdraws cash, returning the balance after it
+func (s *PortfolioService) RecordCash(ctx context.Context, userID string, id int64, req models.CashRequest) (*models.PortfolioEvent, float64, error) {
+	amount := req.Amount
+	e := models.PortfolioEvent{PortfolioID: id, EventType: req.Type, Amount: &amount}
+	if req.At != nil {
+		e.OccurredAt = *req.At
+	}
+
+	state, event, err := s.record(ctx, userID, e)
+	if err != nil {
+		return nil, 0, err
+	}
+	return event, state.Cash, nil
+}
+
+// Events returns the portfolio's events, most recently effective first
+func (s *PortfolioService) Events(ctx context.Context, userID string, id int64) ([]models.PortfolioEvent, error) {
+	if err := s.ensureOwned(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, portfolio_id, event_type, symbol, side, quantity, price, amount, action_id, occurred_at, recorded_at
+		FROM portfolio_events
+		WHERE portfolio_id = $1
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT 200
+	`, id)
+	if err != nil {
+		s.logger.Error("Failed to get portfolio events", zap.Int64("portfolio_id", id), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.PortfolioEvent])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return events, nil
+}
+
+// portfolioKey orders a portfolio's outbox messages
+func portfolioKey(id int64) string {
+	return fmt.Sprintf("portfolio:%d", id)
+}
+
+// GetAt reconstructs a portfolio's holdings and cash as they stood at asOf by
+// replaying its events. Holding timestamps are those of the events that opened
+// and last changed them.
+func (s *PortfolioService) GetAt(ctx context.Context, userID string, id int64, asOf time.Time) (*models.Portfolio, error) {
+	query := `SELECT id, user_id, name, cash, created_at, updated_at FROM portfolios WHERE id = $1 AND user_id = $2`
+
+	var p models.Portfolio
+	err := s.db.QueryRow(ctx, query, id, userID).Scan(&p.ID, &p.UserID, &p.Name, &p.Cash, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPortfolioNotFound
+		}
+		s.logger.Error("Failed to get portfolio",
+			zap.Int64("id", id),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	asOf = asOf.UTC()
+	state, _, _, err := replayPortfolio(ctx, s.db, id, &asOf)
+	if err != nil {
+		s.logger.Error("Failed to replay portfolio",
+			zap.Int64("id", id),
+			zap.Time("as_of", asOf),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	p.Holdings = state.list()
+	p.Cash = state.Cash
+	p.AsOf = &asOf
+	return &p, nil
+}
+
+// ensureOwned returns ErrPortfolioNotFound unless the user owns the portfolio
+func (s *PortfolioService) ensureOwned(ctx context.Context, userID string, id int64) error {
+	var exists bool
+	err := s.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM portfolios WHERE id = $1 AND user_id = $2)`, id, userID).Scan(&exists)
+	if err != nil {
+		s.logger.Error("Failed to get portfolio", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	if !exists {
+		return ErrPortfolioNotFound
+	}
+	return nil
+}
+
+// eventValue dereferences an event's optional column, which its type guarantees is set
+func eventValue(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// roundAmount rounds quantities and cash to the four decimals they are stored with
+func roundAmount(v float64) float64 {
+	return roundHoldingPrice(v)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// profileQuantiles are the quantiles reported for returns and volume
+var profileQuantiles = []float64{0.01, 0.05, 0.25, 0.5, 0.75, 0.95, 0.99}
+
+// Profile computes per-column statistics for one interval of a symbol in a single
+// SQL pass. startDate, endDate, source and exchange are optional filters (nil / empty
+// for all); SourceAny or SourceMerged profiles the merged series.
+func (s *MarketService) Profile(ctx context.Context, symbol string, startDate, endDate *time.Time, source, interval, exchange string) (*models.DataProfile, error) {
+	from, filter := sourceScope(source)
+
+	// Returns follow each source's own series, except in a merge where there is one series
+	series := "exchange, source"
+	if isMerged(source) {
+		series = "exchange"
+	}
+
+	query := fmt.Sprintf(`
+		WITH d AS (
+			SELECT date, open, high, low, close, volume,
+				(close / NULLIF(LAG(close) OVER (PARTITION BY %s ORDER BY ts), 0) - 1)::float8 AS ret
+			FROM %s
+			WHERE symbol = $1 AND interval = $6
+				AND ($2::date IS NULL OR date >= $2)
+				AND ($3::date IS NULL OR date <= $3)
+				AND ($4 = '' OR source = $4)
+				AND ($7 = '' OR exchange = $7)
+		)
+		SELECT
+			COUNT(*), MIN(date), MAX(date),
+			COUNT(*) - COUNT(open), MIN(open)::float8, MAX(open)::float8, AVG(open)::float8,
+			COUNT(*) - COUNT(high), MIN(high)::float8, MAX(high)::float8, AVG(high)::float8,
+			COUNT(*) - COUNT(low), MIN(low)::float8, MAX(low)::float8, AVG(low)::float8,
+			COUNT(*) - COUNT(close), MIN(close)::float8, MAX(close)::float8, AVG(close)::float8,
+			COUNT(*) - COUNT(volume), MIN(volume)::float8, MAX(volume)::float8, AVG(volume)::float8,
+			COUNT(*) FILTER (WHERE volume = 0),
+			COUNT(*) FILTER (WHERE high < low OR high < GREATEST(open, close) OR low > LEAST(open, close)),
+			COUNT(ret), AVG(ret), STDDEV_SAMP(ret), MIN(ret), MAX(ret),
+			percentile_cont($5::float8[]) WITHIN GROUP (ORDER BY ret),
+			COUNT(volume), STDDEV_SAMP(volume)::float8,
+			percentile_cont($5::float8[]) WITHIN GROUP (ORDER BY volume::float8)
+		FROM d
+	`, series, from)
+
+	profile := models.DataProfile{Symbol: symbol, Source: source}
+	var open, high, low, closeCol, volume models.ColumnStats
+	var retQuantiles, volQuantiles []float64
+
+	err := s.db.QueryRow(ctx, query, symbol, startDate, endDate, filter, profileQuantiles, intervalOrDaily(interval), exchange).Scan(
+		&profile.Rows, &profile.FirstDate, &profile.LastDate,
+		&open.Nulls, &open.Min, &open.Max, &open.Mean,
+		&high.Nulls, &high.Min, &high.Max, &high.Mean,
+		&low.Nulls, &low.Min, &low.Max, &low.Mean,
+		&closeCol.Nulls, &closeCol.Min, &closeCol.Max, &closeCol.Mean,
+		&volume.Nulls, &volume.Min, &volume.Max, &volume.Mean,
+		&profile.ZeroVolumeRows, &profile.InconsistentRows,
+		&profile.Returns.Count, &profile.Returns.Mean, &profile.Returns.StdDev,
+		&profile.Returns.Min, &profile.Returns.Max, &retQuantiles,
+		&profile.Volume.Count, &profile.Volume.StdDev, &volQuantiles,
+	)
+	if err != nil {
+		s.logger.Error("Failed to profile market data",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	profile.Columns = map[string]models.ColumnStats{
+		"open":   open,
+		"high":   high,
+		"low":    low,
+		"close":  closeCol,
+		"volume": volume,
+	}
+	profile.Volume.Mean = volume.Mean
+	profile.Volume.Min = volume.Min
+	profile.Volume.Max = volume.Max
+	profile.Returns.Quantiles = quantileMap(retQuantiles)
+	profile.Volume.Quantiles = quantileMap(volQuantiles)
+
+	return &profile, nil
+}
+
+func quantileMap(values []float64) map[string]float64 {
+	result := make(map[string]float64, len(values))
+	for i, v := range values {
+		if i >= len(profileQuantiles) {
+			break
+		}
+		result[fmt.Sprintf("p%02.0f", profileQuantiles[i]*100)] = v
+	}
+	return result
+}
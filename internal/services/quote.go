@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/datasource"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ErrQuoteNotFound is returned when no step of the chain produced a quote
+var ErrQuoteNotFound = errors.New("quote not found")
+
+// quoteFallbackAge is how old a quote may be when its exchange's calendar is unknown
+const quoteFallbackAge = 24 * time.Hour
+
+// liveQuoteDays is how many days of candles the live step asks a provider for, enough
+// to find the close before the latest session over a long weekend
+const liveQuoteDays = 10
+
+// Reasons a quote step failed, reported in place of the underlying error
+const (
+	QuoteReasonRateLimited   = "rate_limited"
+	QuoteReasonNotConfigured = "not_configured"
+	QuoteReasonUnavailable   = "unavailable"
+)
+
+// QuoteStep is one stage of the quote fallback chain, tried in priority order. A quote
+// from before the exchange's latest session is stale; it is kept as a fallback while
+// later steps are tried.
+type QuoteStep struct {
+	Name    string
+	Timeout time.Duration // budget for this step alone
+	MaxAge  time.Duration // while the exchange is in session, older quotes are stale too; 0 for no limit
+	Fetch   func(ctx context.Context, symbol string) (*models.Quote, error)
+}
+
+type QuoteService struct {
+	steps     []QuoteStep
+	exchanges *ExchangeService
+	logger    *zap.Logger
+}
+
+func NewQuoteService(exchanges *ExchangeService, steps ...QuoteStep) *QuoteService {
+	return &QuoteService{
+		steps:     steps,
+		exchanges: exchanges,
+		logger:    logger.With(zap.String("service", "quote")),
+	}
+}
+
+// quoteFreshness is what a quote must be newer than to be current
+type quoteFreshness struct {
+	since     time.Time // open of the session in progress, else close of the last one
+	inSession bool
+}
+
+// GetQuote walks the chain until a fresh quote is found, returning the freshest value seen
+func (s *QuoteService) GetQuote(ctx context.Context, symbol string) (*models.QuoteResult, error) {
+	result := &models.QuoteResult{Attempts: []models.QuoteAttempt{}}
+	fresh := s.freshness(ctx, symbol, time.Now())
+
+	for _, step := range s.steps {
+		if ctx.Err() != nil {
+			break
+		}
+
+		quote, attempt := s.try(ctx, step, symbol, fresh)
+		result.Attempts = append(result.Attempts, attempt)
+		if quote == nil {
+			continue
+		}
+
+		if result.Quote == nil || quote.AsOf.After(result.Quote.AsOf) {
+			result.Quote = quote
+			result.Path = step.Name
+		}
+
+		if attempt.Outcome == "ok" {
+			break
+		}
+	}
+
+	if result.Quote == nil {
+		return result, ErrQuoteNotFound
+	}
+
+	return result, nil
+}
+
+// freshness finds the latest session of the symbol's exchange, so a Friday close is
+// current over the weekend and a holiday. Without a calendar a day-old quote is current.
+func (s *QuoteService) freshness(ctx context.Context, symbol string, now time.Time) quoteFreshness {
+	fallback := quoteFreshness{since: now.Add(-quoteFallbackAge)}
+	exchange := models.ExchangeForSymbol(strings.ToUpper(symbol))
+
+	session, ok, err := s.exchanges.LastSession(ctx, exchange, now)
+	if err != nil {
+		if !errors.Is(err, ErrExchangeNotFound) && ctx.Err() == nil {
+			s.logger.Warn("Failed to get last session",
+				zap.String("exchange", exchange),
+				zap.Error(err),
+			)
+		}
+		return fallback
+	}
+	if !ok {
+		return fallback
+	}
+	if session.ClosesAt.After(now) {
+		return quoteFreshness{since: *session.OpensAt, inSession: true}
+	}
+	return quoteFreshness{since: *session.ClosesAt}
+}
+
+func (s *QuoteService) try(ctx context.Context, step QuoteStep, symbol string, fresh quoteFreshness) (*models.Quote, models.QuoteAttempt) {
+	stepCtx, cancel := context.WithTimeout(ctx, step.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	quote, err := step.Fetch(stepCtx, symbol)
+	attempt := models.QuoteAttempt{
+		Step:   step.Name,
+		TookMS: time.Since(start).Milliseconds(),
+	}
+
+	switch {
+	case err != nil && (errors.Is(err, context.DeadlineExceeded) || stepCtx.Err() == context.DeadlineExceeded):
+		attempt.Outcome = "timeout"
+		return nil, attempt
+	case errors.Is(err, datasource.ErrSymbolNotFound):
+		attempt.Outcome = "not_found"
+		return nil, attempt
+	case err != nil:
+		s.logger.Warn("Quote step failed",
+			zap.String("step", step.Name),
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		attempt.Outcome = "error"
+		attempt.Error = quoteReason(err)
+		return nil, attempt
+	case quote == nil:
+		attempt.Outcome = "not_found"
+		return nil, attempt
+	case quote.AsOf.Before(fresh.since),
+		fresh.inSession && step.MaxAge > 0 && time.Since(quote.AsOf) > step.MaxAge:
+		attempt.Outcome = "stale"
+		return quote, attempt
+	default:
+		attempt.Outcome = "ok"
+		return quote, attempt
+	}
+}
+
+// quoteReason names why a step failed without passing on the error itself, which may
+// carry database or provider details
+func quoteReason(err error) string {
+	switch {
+	case errors.Is(err, datasource.ErrRateLimited):
+		return QuoteReasonRateLimited
+	case errors.Is(err, datasource.ErrNotConfigured):
+		return QuoteReasonNotConfigured
+	default:
+		return QuoteReasonUnavailable
+	}
+}
+
+// LiveQuote returns a quote step's Fetch that asks a provider for the symbol: Binance
+// for crypto pairs, Yahoo Finance for everything else. The provider's latest daily
+// candle, the session in progress included, gives the price, and the one before it the
+// previous close. The quote is as of the fetch.
+func LiveQuote(sources *datasource.Registry) func(ctx context.Context, symbol string) (*models.Quote, error) {
+	return func(ctx context.Context, symbol string) (*models.Quote, error) {
+		name := "yahoo"
+		if _, _, ok := models.CryptoPair(symbol); ok {
+			name = "binance"
+		}
+
+		now := time.Now().UTC()
+		data, fetched, err := sources.Fetch(ctx, name, symbol, models.IntervalDaily, now.AddDate(0, 0, -liveQuoteDays), now)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			return nil, nil
+		}
+
+		latest := data[len(data)-1]
+		quote := &models.Quote{
+			Symbol: fetched,
+			Price:  latest.Close,
+			Open:   latest.Open,
+			High:   latest.High,
+			Low:    latest.Low,
+			Volume: latest.Volume,
+			Source: latest.Source,
+			AsOf:   now,
+		}
+		if len(data) > 1 {
+			if prevClose := data[len(data)-2].Close; prevClose != 0 {
+				quote.PreviousClose = prevClose
+				quote.Change = quote.Price - prevClose
+				quote.ChangePct = quote.Change / prevClose * 100
+			}
+		}
+		return quote, nil
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidPermission = errors.New("invalid permission")
+	ErrInvalidRole       = errors.New("invalid role")
+)
+
+var (
+	permissionPattern = regexp.MustCompile(`^(\*|[a-z][a-z_]*:(\*|[a-z][a-z_]*))$`)
+	rolePattern       = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+)
+
+// RBACService maps roles to permissions. Configured grants cannot be revoked at
+// runtime; grants stored in the database are added to them.
+type RBACService struct {
+	db         *database.DB
+	configured map[string][]string
+	logger     *zap.Logger
+}
+
+func NewRBACService(db *database.DB, configured map[string][]string) *RBACService {
+	return &RBACService{
+		db:         db,
+		configured: configured,
+		logger:     logger.With(zap.String("service", "rbac")),
+	}
+}
+
+// ParseRolePermissions reads a role mapping such as "admin=*;analyst=market_data:*,symbols:write"
+func ParseRolePermissions(spec string) (map[string][]string, error) {
+	roles := map[string][]string{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, list, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q has no =", ErrInvalidRole, entry)
+		}
+		role = strings.TrimSpace(role)
+		if !rolePattern.MatchString(role) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+		}
+		var permissions []string
+		for _, p := range strings.Split(list, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				permissions = append(permissions, p)
+			}
+		}
+		if err := ValidatePermissions(permissions); err != nil {
+			return nil, err
+		}
+		roles[role] = append(roles[role], permissions...)
+	}
+	return roles, nil
+}
+
+// ValidatePermissions checks each permission is resource:action, resource:* or *
+func ValidatePermissions(permissions []string) error {
+	for _, p := range permissions {
+		if !permissionPattern.MatchString(p) {
+			return fmt.Errorf("%w: %q, use resource:action", ErrInvalidPermission, p)
+		}
+	}
+	return nil
+}
+
+// Permissions returns the sorted union of what roles grant
+func (s *RBACService) Permissions(ctx context.Context, roles []string) ([]string, error) {
+	stored, err := s.stored(ctx, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	set := map[string]bool{}
+	for _, role := range roles {
+		for _, p := range s.configured[role] {
+			set[p] = true
+		}
+		for _, p := range stored[role] {
+			set[p] = true
+		}
+	}
+	return sortedKeys(set), nil
+}
+
+// Roles returns every role with configured or stored permissions, by name
+func (s *RBACService) Roles(ctx context.Context) ([]models.Role, error) {
+	stored, err := s.stored(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for role := range s.configured {
+		names[role] = true
+	}
+	for role := range stored {
+		names[role] = true
+	}
+
+	roles := make([]models.Role, 0, len(names))
+	for _, name := range sortedKeys(names) {
+		set := map[string]bool{}
+		for _, p := range s.configured[name] {
+			set[p] = true
+		}
+		for _, p := range stored[name] {
+			set[p] = true
+		}
+		configured := append([]string{}, s.configured[name]...)
+		sort.Strings(configured)
+		roles = append(roles, models.Role{
+			Name:        name,
+			Permissions: sortedKeys(set),
+			Configured:  configured,
+			Stored:      append([]string{}, stored[name]...),
+		})
+	}
+	return roles, nil
+}
+
+// SetRolePermissions replaces the permissions stored for a role; configured
+// permissions are unaffected. An empty list removes the stored grants.
+func (s *RBACService) SetRolePermissions(ctx context.Context, role string, permissions []string) error {
+	if !rolePattern.MatchString(role) {
+		return fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+	if err := ValidatePermissions(permissions); err != nil {
+		return err
+	}
+
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM role_permissions WHERE role = $1`, role); err != nil {
+			return err
+		}
+		if len(permissions) == 0 {
+			return nil
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO role_permissions (role, permission)
+			SELECT $1, UNNEST($2::text[])
+			ON CONFLICT DO NOTHING
+		`, role, permissions)
+		return err
+	})
+	if err != nil {
+		s.logger.Error("Failed to set role permissions", zap.String("role", role), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Role permissions updated",
+		zap.String("role", role),
+		zap.Strings("permissions", permissions),
+	)
+	return nil
+}
+
+// stored returns the database grants of roles, or of every role when roles is nil
+func (s *RBACService) stored(ctx context.Context, roles []string) (map[string][]string, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT role, permission
+		FROM role_permissions
+		WHERE $1::text[] IS NULL OR role = ANY($1)
+		ORDER BY role, permission
+	`, roles)
+	if err != nil {
+		s.logger.Error("Failed to get role permissions", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored := map[string][]string{}
+	for rows.Next() {
+		var role, permission string
+		if err := rows.Scan(&role, &permission); err != nil {
+			return nil, fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		stored[role] = append(stored[role], permission)
+	}
+	return stored, rows.Err()
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
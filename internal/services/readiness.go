@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/cache"
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var ErrUnknownGate = errors.New("unknown readiness gate")
+
+// gateTimeout bounds each gate's check so a hung subsystem cannot stall /ready
+const gateTimeout = 2 * time.Second
+
+// readinessGates lists every gate in the order they are checked
+var readinessGates = []string{models.GateDB, models.GateCache, models.GateIngest, models.GateQueue}
+
+// ReadinessOptions configures which gates fail readiness and when the lag gates fail
+type ReadinessOptions struct {
+	Blocking         []string      // gates whose failure fails readiness; the rest are only reported
+	MaxImportBacklog time.Duration // the ingest gate fails once an import has waited longer
+	MaxOutboxLag     time.Duration // the queue gate fails once a message has waited longer
+}
+
+type ReadinessService struct {
+	db       *database.DB
+	cache    cache.Cache
+	outbox   *OutboxService
+	blocking map[string]bool
+	opts     ReadinessOptions
+	logger   *zap.Logger
+}
+
+func NewReadinessService(db *database.DB, readCache cache.Cache, outbox *OutboxService, opts ReadinessOptions) *ReadinessService {
+	blocking := make(map[string]bool, len(opts.Blocking))
+	for _, gate := range opts.Blocking {
+		blocking[gate] = true
+	}
+	return &ReadinessService{
+		db:       db,
+		cache:    readCache,
+		outbox:   outbox,
+		blocking: blocking,
+		opts:     opts,
+		logger:   logger.With(zap.String("service", "readiness")),
+	}
+}
+
+// ParseReadinessGates reads a comma-separated list of gate names, such as
+// READY_GATES; an empty list leaves every gate informational
+func ParseReadinessGates(spec string) ([]string, error) {
+	var gates []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, gate := range readinessGates {
+			known = known || gate == name
+		}
+		if !known {
+			return nil, fmt.Errorf("%w %q, use %s", ErrUnknownGate, name, strings.Join(readinessGates, ", "))
+		}
+		gates = append(gates, name)
+	}
+	return gates, nil
+}
+
+// Check runs every gate. Readiness only fails on a blocking gate, so a degraded
+// ingestion pipeline can be reported without taking reads out of rotation.
+func (s *ReadinessService) Check(ctx context.Context) models.Readiness {
+	checks := map[string]func(context.Context) (string, string){
+		models.GateDB:     s.checkDB,
+		models.GateCache:  s.checkCache,
+		models.GateIngest: s.checkIngest,
+		models.GateQueue:  s.checkQueue,
+	}
+
+	readiness := models.Readiness{Ready: true, Gates: make(map[string]models.GateStatus, len(readinessGates))}
+	for _, gate := range readinessGates {
+		gateCtx, cancel := context.WithTimeout(ctx, gateTimeout)
+		status, detail := checks[gate](gateCtx)
+		cancel()
+
+		blocking := s.blocking[gate]
+		if status == models.GateFailing {
+			s.logger.Warn("Readiness gate failing",
+				zap.String("gate", gate),
+				zap.Bool("blocking", blocking),
+				zap.String("detail", detail),
+			)
+			if blocking {
+				readiness.Ready = false
+			}
+		}
+		readiness.Gates[gate] = models.GateStatus{Status: status, Blocking: blocking, Detail: detail}
+	}
+	return readiness
+}
+
+func (s *ReadinessService) checkDB(ctx context.Context) (string, string) {
+	if err := s.db.HealthCheck(ctx); err != nil {
+		return models.GateFailing, "database unreachable"
+	}
+	return models.GateOK, ""
+}
+
+func (s *ReadinessService) checkCache(ctx context.Context) (string, string) {
+	pinger, ok := s.cache.(cache.Pinger)
+	if !ok {
+		return models.GateDisabled, ""
+	}
+	if err := pinger.Ping(ctx); err != nil {
+		return models.GateFailing, "cache unreachable"
+	}
+	return models.GateOK, ""
+}
+
+// checkIngest fails when the oldest queued import has waited longer than allowed
+func (s *ReadinessService) checkIngest(ctx context.Context) (string, string) {
+	var pending int64
+	var oldest *time.Time
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*), MIN(created_at) FROM import_jobs WHERE status = $1
+	`, models.ImportPending).Scan(&pending, &oldest)
+	if err != nil {
+		return models.GateFailing, "import backlog unavailable"
+	}
+	return lagStatus(pending, oldest, s.opts.MaxImportBacklog, "imports")
+}
+
+// checkQueue fails when the oldest undelivered outbox message has waited longer than allowed
+func (s *ReadinessService) checkQueue(ctx context.Context) (string, string) {
+	if !s.outbox.Enabled() {
+		return models.GateDisabled, ""
+	}
+
+	var pending int64
+	var oldest *time.Time
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*), MIN(created_at) FROM outbox_messages
+		WHERE delivered_at IS NULL AND dead_at IS NULL
+	`).Scan(&pending, &oldest)
+	if err != nil {
+		return models.GateFailing, "outbox backlog unavailable"
+	}
+	return lagStatus(pending, oldest, s.opts.MaxOutboxLag, "messages")
+}
+
+// lagStatus fails a backlog whose oldest item has waited longer than maxLag; a
+// non-positive maxLag only reports it
+func lagStatus(pending int64, oldest *time.Time, maxLag time.Duration, noun string) (string, string) {
+	if pending == 0 || oldest == nil {
+		return models.GateOK, ""
+	}
+	lag := time.Since(*oldest).Truncate(time.Second)
+	detail := fmt.Sprintf("%d %s waiting, oldest for %s", pending, noun, lag)
+	if maxLag > 0 && lag > maxLag {
+		return models.GateFailing, detail
+	}
+	return models.GateOK, detail
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// ReconcileOptions sets when two sources' candles count as discrepant
+type ReconcileOptions struct {
+	TolerancePct       float64 // allowed open/high/low/close difference
+	VolumeTolerancePct float64 // allowed volume difference; feeds count volume differently
+	All                bool    // include pairs within tolerance in the rows
+}
+
+// Reconcile compares a symbol's candles for the same date across every source that has it
+func (s *MarketService) Reconcile(ctx context.Context, symbol string, startDate, endDate time.Time, opts ReconcileOptions) (*models.ReconcileReport, error) {
+	data, err := s.GetBySymbolAndDateRange(ctx, symbol, "", models.IntervalDaily, "", startDate, endDate, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ReconcileReport{
+		Symbol:             symbol,
+		StartDate:          startDate,
+		EndDate:            endDate,
+		TolerancePct:       opts.TolerancePct,
+		VolumeTolerancePct: opts.VolumeTolerancePct,
+		Sources:            distinctSources(data),
+		Rows:               []models.ReconcileRow{},
+		Missing:            []models.MissingCandle{},
+	}
+
+	// Group candles by date; data is ordered by date already
+	byDate := map[time.Time][]models.MarketData{}
+	var dates []time.Time
+	for _, md := range data {
+		if _, ok := byDate[md.Date]; !ok {
+			dates = append(dates, md.Date)
+		}
+		byDate[md.Date] = append(byDate[md.Date], md)
+	}
+
+	for _, date := range dates {
+		candles := byDate[date]
+		sort.Slice(candles, func(i, j int) bool { return candles[i].Source < candles[j].Source })
+
+		if len(candles) < len(report.Sources) {
+			report.Missing = append(report.Missing, missingCandle(date, candles, report.Sources))
+		}
+		if len(candles) < 2 {
+			continue
+		}
+		report.DatesCompared++
+
+		for i := 0; i < len(candles); i++ {
+			for j := i + 1; j < len(candles); j++ {
+				row := compareCandles(candles[i], candles[j], opts)
+				if row.Discrepant {
+					report.Discrepancies++
+				}
+				if row.Discrepant || opts.All {
+					report.Rows = append(report.Rows, row)
+				}
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// Compare sets a symbol's candles from sources side by side per date, diffing each
+// source against the first. Without opts.All only dates with a discrepancy or a
+// missing candle are listed; the counts cover every date.
+func (s *MarketService) Compare(ctx context.Context, symbol, interval, exchange string, sources []string, startDate, endDate time.Time, opts ReconcileOptions) (*models.CompareReport, error) {
+	data, err := s.GetBySymbolAndDateRange(ctx, symbol, "", interval, exchange, startDate, endDate, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.CompareReport{
+		Symbol:             symbol,
+		Interval:           interval,
+		StartDate:          startDate,
+		EndDate:            endDate,
+		Sources:            sources,
+		Reference:          sources[0],
+		TolerancePct:       opts.TolerancePct,
+		VolumeTolerancePct: opts.VolumeTolerancePct,
+		Rows:               []models.CompareRow{},
+	}
+
+	// Group the requested sources' candles by date; data is ordered by date already.
+	// A symbol listed on several exchanges keeps the first listing's candle.
+	wanted := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		wanted[source] = true
+	}
+	byDate := map[time.Time]map[string]models.MarketData{}
+	var dates []time.Time
+	for _, md := range data {
+		if !wanted[md.Source] {
+			continue
+		}
+		candles, ok := byDate[md.Date]
+		if !ok {
+			candles = make(map[string]models.MarketData, len(sources))
+			byDate[md.Date] = candles
+			dates = append(dates, md.Date)
+		}
+		if _, ok := candles[md.Source]; !ok {
+			candles[md.Source] = md
+		}
+	}
+
+	for _, date := range dates {
+		candles := byDate[date]
+		row := models.CompareRow{Date: date, Candles: make(map[string]*models.CompareCandle, len(sources))}
+		for _, source := range sources {
+			md, ok := candles[source]
+			if !ok {
+				row.Candles[source] = nil
+				row.Missing = append(row.Missing, source)
+				continue
+			}
+			row.Candles[source] = &models.CompareCandle{Open: md.Open, High: md.High, Low: md.Low, Close: md.Close, Volume: md.Volume}
+		}
+
+		if ref, ok := candles[report.Reference]; ok {
+			for _, source := range sources[1:] {
+				md, ok := candles[source]
+				if !ok {
+					continue
+				}
+				pair := compareCandles(md, ref, opts)
+				if row.Diffs == nil {
+					row.Diffs = make(map[string]models.CompareDiff, len(sources)-1)
+				}
+				row.Diffs[source] = models.CompareDiff{
+					OpenDiffPct:   pair.OpenDiffPct,
+					HighDiffPct:   pair.HighDiffPct,
+					LowDiffPct:    pair.LowDiffPct,
+					CloseDiffPct:  pair.CloseDiffPct,
+					VolumeDiffPct: pair.VolumeDiffPct,
+					Discrepant:    pair.Discrepant,
+					Fields:        pair.Fields,
+				}
+				row.Discrepant = row.Discrepant || pair.Discrepant
+			}
+		}
+
+		report.Dates++
+		if row.Discrepant {
+			report.DiscrepantDates++
+		}
+		if len(row.Missing) > 0 {
+			report.IncompleteDates++
+		}
+		if opts.All || row.Discrepant || len(row.Missing) > 0 {
+			report.Rows = append(report.Rows, row)
+		}
+	}
+
+	return report, nil
+}
+
+func compareCandles(a, b models.MarketData, opts ReconcileOptions) models.ReconcileRow {
+	row := models.ReconcileRow{
+		Date:          a.Date,
+		SourceA:       a.Source,
+		SourceB:       b.Source,
+		CloseA:        a.Close,
+		CloseB:        b.Close,
+		OpenDiffPct:   openDiffPct(a.Open, b.Open),
+		HighDiffPct:   diffPct(a.High, b.High),
+		LowDiffPct:    diffPct(a.Low, b.Low),
+		CloseDiffPct:  diffPct(a.Close, b.Close),
+		VolumeDiffPct: volumeDiffPct(a.Volume, b.Volume),
+	}
+
+	fields := []struct {
+		name      string
+		diff      float64
+		tolerance float64
+	}{
+		{"open", row.OpenDiffPct, opts.TolerancePct},
+		{"high", row.HighDiffPct, opts.TolerancePct},
+		{"low", row.LowDiffPct, opts.TolerancePct},
+		{"close", row.CloseDiffPct, opts.TolerancePct},
+		{"volume", row.VolumeDiffPct, opts.VolumeTolerancePct},
+	}
+	for _, f := range fields {
+		if math.Abs(f.diff) > f.tolerance {
+			row.Fields = append(row.Fields, f.name)
+		}
+	}
+	row.Discrepant = len(row.Fields) > 0
+
+	return row
+}
+
+func missingCandle(date time.Time, candles []models.MarketData, sources []string) models.MissingCandle {
+	present := map[string]bool{}
+	m := models.MissingCandle{Date: date, Present: []string{}, Missing: []string{}}
+	for _, md := range candles {
+		present[md.Source] = true
+		m.Present = append(m.Present, md.Source)
+	}
+	for _, source := range sources {
+		if !present[source] {
+			m.Missing = append(m.Missing, source)
+		}
+	}
+	return m
+}
+
+// diffPct is the difference of a from b as a percentage of b, rounded to 4 places
+// openDiffPct compares opens only when both sources have one
+func openDiffPct(a, b *float64) float64 {
+	if a == nil || b == nil {
+		return 0
+	}
+	return diffPct(*a, *b)
+}
+
+// volumeDiffPct compares volumes only when both sources have one
+func volumeDiffPct(a, b *int64) float64 {
+	if a == nil || b == nil {
+		return 0
+	}
+	return diffPct(float64(*a), float64(*b))
+}
+
+func diffPct(a, b float64) float64 {
+	if b == 0 {
+		if a == 0 {
+			return 0
+		}
+		return 100
+	}
+	return math.Round((a-b)/b*100*10000) / 10000
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ErrEmptySearch is returned for a query without any searchable word
+var ErrEmptySearch = errors.New("search query has no words")
+
+// SearchTypes are the result types GET /api/v1/search covers
+var SearchTypes = []string{models.SearchSymbol, models.SearchNote}
+
+type SearchService struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewSearchService(db *database.DB) *SearchService {
+	return &SearchService{
+		db:     db,
+		logger: logger.With(zap.String("service", "search")),
+	}
+}
+
+// SearchQuery is a global search. Every word must match, the last as a prefix so
+// results follow a search box as it is typed.
+type SearchQuery struct {
+	Text         string
+	Types        []string // empty for all of SearchTypes
+	UserID       string   // notes are limited to those the user can read
+	Organization string
+	Limit        int
+}
+
+// Search matches q against listings and readable notes, best first. A ticker
+// starting with the query ranks above any other match.
+func (s *SearchService) Search(ctx context.Context, q SearchQuery) ([]models.SearchResult, error) {
+	tsquery := prefixQuery(q.Text)
+	if tsquery == "" {
+		return nil, ErrEmptySearch
+	}
+	types := q.Types
+	if len(types) == 0 {
+		types = SearchTypes
+	}
+
+	var parts []string
+	for _, t := range types {
+		switch t {
+		case models.SearchSymbol:
+			parts = append(parts, `
+				SELECT 'symbol', s.exchange || ':' || s.symbol, s.symbol, s.exchange, s.name, s.sector,
+					ts_rank(s.search, q.query) + CASE WHEN lower(s.symbol) LIKE q.ticker || '%' THEN 1 ELSE 0 END,
+					'/api/v1/symbols/' || s.exchange || '/' || s.symbol
+				FROM symbols s, q
+				WHERE s.search @@ q.query OR lower(s.symbol) LIKE q.ticker || '%'`)
+		case models.SearchNote:
+			parts = append(parts, `
+				SELECT 'note', n.id::text, n.symbol, '', n.title,
+					ts_headline('simple', n.body, q.query, 'StartSel=**, StopSel=**, MaxWords=25, MinWords=10'),
+					ts_rank(n.search, q.query),
+					'/api/v1/notes/' || n.id
+				FROM symbol_notes n, q
+				WHERE n.search @@ q.query AND `+noteVisible)
+		}
+	}
+	if len(parts) == 0 {
+		return []models.SearchResult{}, nil
+	}
+
+	// Every parameter is typed in q, since the parts left out by Types would leave
+	// some unreferenced
+	query := fmt.Sprintf(`
+		WITH q AS (SELECT to_tsquery('simple', $3) AS query, $5::text AS ticker, $1::text AS user_id, $2::text AS organization)
+		SELECT * FROM (%s) r (type, id, symbol, exchange, title, snippet, rank, path)
+		ORDER BY rank DESC, type, id
+		LIMIT $4
+	`, strings.Join(parts, "\n\t\t\tUNION ALL"))
+
+	ticker := escapeLike(strings.ToLower(strings.TrimSpace(q.Text)))
+	rows, err := s.db.Query(ctx, query, q.UserID, q.Organization, tsquery, q.Limit, ticker)
+	if err != nil {
+		s.logger.Error("Failed to search",
+			zap.String("query", q.Text),
+			zap.Strings("types", types),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(&r.Type, &r.ID, &r.Symbol, &r.Exchange, &r.Title, &r.Snippet, &r.Rank, &r.Path); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// prefixQuery turns free text into a tsquery requiring every word, the last as a
+// prefix, e.g. "bank cent" becomes "bank & cent:*". Punctuation separates words, so
+// the result is always valid tsquery syntax.
+func prefixQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] += ":*"
+	return strings.Join(words, " & ")
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrRedistributionRestricted is returned when an export includes data whose license forbids it
+	ErrRedistributionRestricted = errors.New("source does not allow redistribution")
+	ErrSourceNotFound           = errors.New("source not found")
+)
+
+type SourceService struct {
+	db                     *database.DB
+	blockRestrictedExports bool
+	logger                 *zap.Logger
+}
+
+func NewSourceService(db *database.DB, blockRestrictedExports bool) *SourceService {
+	return &SourceService{
+		db:                     db,
+		blockRestrictedExports: blockRestrictedExports,
+		logger:                 logger.With(zap.String("service", "source")),
+	}
+}
+
+const sourceColumns = `s.name, s.display_name, s.attribution, s.license, s.license_url, s.redistribution_allowed,
+		s.anomaly_policy, s.created_at, s.updated_at, s.ingest_window_minutes, i.last_ingest_at, i.stale_since,
+		s.transform_rules`
+
+// List returns all configured sources
+func (s *SourceService) List(ctx context.Context) ([]models.Source, error) {
+	query := `
+		SELECT ` + sourceColumns + `
+		FROM sources s
+		LEFT JOIN source_ingests i ON i.source = s.name
+		ORDER BY s.name
+	`
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		s.logger.Error("Failed to list sources", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Source])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// Get returns a single source by name, or nil if it is not configured
+func (s *SourceService) Get(ctx context.Context, name string) (*models.Source, error) {
+	query := `
+		SELECT ` + sourceColumns + `
+		FROM sources s
+		LEFT JOIN source_ingests i ON i.source = s.name
+		WHERE s.name = $1
+	`
+
+	rows, err := s.db.Query(ctx, query, name)
+	if err != nil {
+		s.logger.Error("Failed to get source", zap.String("source", name), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	source, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.Source])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to collect row: %w", err)
+	}
+
+	return &source, nil
+}
+
+// Attributions returns attribution metadata for the distinct sources present in data
+func (s *SourceService) Attributions(ctx context.Context, data []models.MarketData) ([]models.SourceAttribution, error) {
+	return s.attributions(ctx, distinctSources(data))
+}
+
+func (s *SourceService) attributions(ctx context.Context, names []string) ([]models.SourceAttribution, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT name, attribution, license, license_url, redistribution_allowed
+		FROM sources
+		WHERE name = ANY($1)
+		ORDER BY name
+	`
+
+	rows, err := s.db.Query(ctx, query, names)
+	if err != nil {
+		s.logger.Error("Failed to get source attributions",
+			zap.Strings("sources", names),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SourceAttribution])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// CheckExportAllowed rejects exports containing redistribution-restricted sources when blocking is enabled
+func (s *SourceService) CheckExportAllowed(ctx context.Context, data []models.MarketData) error {
+	return s.CheckSourcesExportAllowed(ctx, distinctSources(data)...)
+}
+
+// CheckSourcesExportAllowed is CheckExportAllowed for data from the named sources, for
+// exports checked as they are written
+func (s *SourceService) CheckSourcesExportAllowed(ctx context.Context, names ...string) error {
+	if !s.blockRestrictedExports {
+		return nil
+	}
+
+	attributions, err := s.attributions(ctx, names)
+	if err != nil {
+		return err
+	}
+
+	var restricted []string
+	for _, a := range attributions {
+		if !a.RedistributionAllowed {
+			restricted = append(restricted, a.Source)
+		}
+	}
+	if len(restricted) > 0 {
+		return fmt.Errorf("%w: %s", ErrRedistributionRestricted, strings.Join(restricted, ", "))
+	}
+
+	return nil
+}
+
+// AnomalyPolicies returns the anomaly policy configured for each source
+func (s *SourceService) AnomalyPolicies(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.Query(ctx, `SELECT name, anomaly_policy FROM sources`)
+	if err != nil {
+		s.logger.Error("Failed to load anomaly policies", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := map[string]string{}
+	for rows.Next() {
+		var name, policy string
+		if err := rows.Scan(&name, &policy); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		policies[name] = policy
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return policies, nil
+}
+
+// SetAnomalyPolicy changes how ingestion anomalies from a source are handled
+func (s *SourceService) SetAnomalyPolicy(ctx context.Context, name, policy string) error {
+	cmdTag, err := s.db.Exec(ctx, `UPDATE sources SET anomaly_policy = $1 WHERE name = $2`, policy, name)
+	if err != nil {
+		s.logger.Error("Failed to set anomaly policy",
+			zap.String("source", name),
+			zap.String("policy", policy),
+			zap.Error(err),
+		)
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrSourceNotFound
+	}
+
+	s.logger.Info("Updated anomaly policy",
+		zap.String("source", name),
+		zap.String("policy", policy),
+	)
+
+	return nil
+}
+
+// SetIngestWindow sets how long a source may go without delivering candles before
+// the ingest monitor reports it; nil stops monitoring it
+func (s *SourceService) SetIngestWindow(ctx context.Context, name string, minutes *int) error {
+	cmdTag, err := s.db.Exec(ctx, `UPDATE sources SET ingest_window_minutes = $1 WHERE name = $2`, minutes, name)
+	if err != nil {
+		s.logger.Error("Failed to set ingest window", zap.String("source", name), zap.Error(err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrSourceNotFound
+	}
+
+	s.logger.Info("Updated ingest window", zap.String("source", name), zap.Any("window_minutes", minutes))
+	return nil
+}
+
+func distinctSources(data []models.MarketData) []string {
+	seen := map[string]bool{}
+	for _, md := range data {
+		seen[md.Source] = true
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/strategy"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrStrategyNotFound = errors.New("strategy not found")
+	ErrStrategyReadOnly = errors.New("only the owner can change a strategy")
+)
+
+type StrategyService struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewStrategyService(db *database.DB) *StrategyService {
+	return &StrategyService{
+		db:     db,
+		logger: logger.With(zap.String("service", "strategy")),
+	}
+}
+
+const strategyColumns = `s.id, s.user_id, s.name, s.definition, s.public, s.cloned_from, s.created_at, s.updated_at`
+
+// StrategyFilter narrows a strategy listing: the user's own strategies, or with
+// Public every user's public ones
+type StrategyFilter struct {
+	Public bool
+	Limit  int
+}
+
+// Create saves a validated definition for a user
+func (s *StrategyService) Create(ctx context.Context, userID string, def *strategy.Definition, public bool) (*models.Strategy, error) {
+	return s.insert(ctx, userID, def, public, nil)
+}
+
+// List returns strategies most recently changed first
+func (s *StrategyService) List(ctx context.Context, userID string, filter StrategyFilter) ([]models.Strategy, error) {
+	where, args := "s.user_id = $1", []interface{}{userID}
+	if filter.Public {
+		where, args = "s.public", nil
+	}
+	args = append(args, filter.Limit)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM strategies s
+		WHERE %s
+		ORDER BY s.updated_at DESC, s.id DESC
+		LIMIT $%d
+	`, strategyColumns, where, len(args))
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		s.logger.Error("Failed to list strategies", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Strategy])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return results, nil
+}
+
+// Get returns a strategy the user owns or that is public
+func (s *StrategyService) Get(ctx context.Context, userID string, id int64) (*models.Strategy, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+strategyColumns+`
+		FROM strategies s
+		WHERE s.id = $2 AND (s.user_id = $1 OR s.public)
+	`, userID, id)
+	if err != nil {
+		s.logger.Error("Failed to get strategy", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	result, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.Strategy])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStrategyNotFound
+		}
+		return nil, fmt.Errorf("failed to collect row: %w", err)
+	}
+	return &result, nil
+}
+
+// Update replaces the definition of a strategy the user owns, and its visibility
+// when public is not nil
+func (s *StrategyService) Update(ctx context.Context, userID string, id int64, def *strategy.Definition, public *bool) (*models.Strategy, error) {
+	current, err := s.owned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if public == nil {
+		public = &current.Public
+	}
+
+	_, err = s.db.Exec(ctx, `
+		UPDATE strategies SET name = $2, definition = $3, public = $4
+		WHERE id = $1
+	`, id, def.Name, def, *public)
+	if err != nil {
+		s.logger.Error("Failed to update strategy", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	return s.Get(ctx, userID, id)
+}
+
+// Delete removes a strategy the user owns; its clones are kept
+func (s *StrategyService) Delete(ctx context.Context, userID string, id int64) error {
+	if _, err := s.owned(ctx, userID, id); err != nil {
+		return err
+	}
+
+	if _, err := s.db.Exec(ctx, `DELETE FROM strategies WHERE id = $1`, id); err != nil {
+		s.logger.Error("Failed to delete strategy", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Clone copies a strategy the user can read into a private strategy of their own
+func (s *StrategyService) Clone(ctx context.Context, userID string, id int64) (*models.Strategy, error) {
+	source, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.insert(ctx, userID, &source.Definition, false, &source.ID)
+}
+
+func (s *StrategyService) insert(ctx context.Context, userID string, def *strategy.Definition, public bool, clonedFrom *int64) (*models.Strategy, error) {
+	rows, err := s.db.Query(ctx, `
+		INSERT INTO strategies AS s (user_id, name, definition, public, cloned_from)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+strategyColumns, userID, def.Name, def, public, clonedFrom)
+	if err != nil {
+		s.logger.Error("Failed to create strategy", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	result, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.Strategy])
+	if err != nil {
+		s.logger.Error("Failed to create strategy", zap.String("user_id", userID), zap.Error(err))
+		return nil, fmt.Errorf("failed to collect row: %w", err)
+	}
+	return &result, nil
+}
+
+// owned returns a strategy the user can read, refusing with ErrStrategyReadOnly when
+// someone else owns it
+func (s *StrategyService) owned(ctx context.Context, userID string, id int64) (*models.Strategy, error) {
+	result, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if result.UserID != userID {
+		return nil, ErrStrategyReadOnly
+	}
+	return result, nil
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSymbolNotFound = errors.New("symbol not found")
+	// ErrSymbolHasData is returned when deleting a listing that still has market data
+	ErrSymbolHasData = errors.New("symbol still has market data")
+)
+
+// SymbolQuery filters the reference data listing
+type SymbolQuery struct {
+	Exchange string // "" for every exchange
+	Search   string // prefix of the ticker, or of any word of the name
+	Limit    int
+}
+
+type SymbolService struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+func NewSymbolService(db *database.DB) *SymbolService {
+	return &SymbolService{
+		db:     db,
+		logger: logger.With(zap.String("service", "symbol")),
+	}
+}
+
+const symbolColumns = `s.exchange, s.symbol, s.name, s.sector, s.currency, s.lot_size,
+		COALESCE(e.asset_class, 'equity'), s.created_at, COALESCE(s.updated_at, s.created_at)`
+
+// List returns listings matching q; ticker prefix matches rank before name matches
+func (s *SymbolService) List(ctx context.Context, q SymbolQuery) ([]models.Symbol, error) {
+	search := strings.ToLower(strings.TrimSpace(q.Search))
+	query := `
+		SELECT ` + symbolColumns + `
+		FROM symbols s
+		LEFT JOIN exchanges e ON e.code = s.exchange
+		WHERE ($1 = '' OR s.exchange = $1)
+			AND ($2 = '' OR lower(s.symbol) LIKE $2 || '%' OR lower(s.name) LIKE $2 || '%'
+				OR lower(s.name) LIKE '% ' || $2 || '%')
+		ORDER BY ($2 <> '' AND lower(s.symbol) LIKE $2 || '%') DESC, s.symbol, s.exchange
+		LIMIT $3
+	`
+
+	rows, err := s.db.Query(ctx, query, q.Exchange, escapeLike(search), q.Limit)
+	if err != nil {
+		s.logger.Error("Failed to list symbols",
+			zap.String("exchange", q.Exchange),
+			zap.String("search", q.Search),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Symbol])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// Get returns one listing, or ErrSymbolNotFound
+func (s *SymbolService) Get(ctx context.Context, exchange, symbol string) (*models.Symbol, error) {
+	query := `
+		SELECT ` + symbolColumns + `
+		FROM symbols s
+		LEFT JOIN exchanges e ON e.code = s.exchange
+		WHERE s.exchange = $1 AND s.symbol = $2
+	`
+
+	rows, err := s.db.Query(ctx, query, exchange, symbol)
+	if err != nil {
+		s.logger.Error("Failed to get symbol", zap.String("exchange", exchange), zap.String("symbol", symbol), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	result, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.Symbol])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSymbolNotFound
+		}
+		return nil, fmt.Errorf("failed to collect row: %w", err)
+	}
+
+	return &result, nil
+}
+
+// Exists reports whether symbol is listed, on exchange when it is not ""
+func (s *SymbolService) Exists(ctx context.Context, symbol, exchange string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM symbols WHERE symbol = $1 AND ($2 = '' OR exchange = $2))
+	`, symbol, exchange).Scan(&exists)
+	if err != nil {
+		s.logger.Error("Failed to check symbol", zap.String("symbol", symbol), zap.Error(err))
+		return false, err
+	}
+	return exists, nil
+}
+
+// Upsert creates or replaces listings in one transaction. ErrExchangeNotFound names
+// the first request whose exchange is not configured.
+func (s *SymbolService) Upsert(ctx context.Context, reqs []models.SymbolRequest) error {
+	query := `
+		INSERT INTO symbols (exchange, symbol, name, sector, currency, lot_size)
+		SELECT e.code, $2, $3, $4,
+			COALESCE(NULLIF($5, ''), e.currency, split_part($2, '-', 2), ''),
+			COALESCE(NULLIF($6, 0), e.lot_size)
+		FROM exchanges e
+		WHERE e.code = $1
+		ON CONFLICT (exchange, symbol) DO UPDATE SET
+			name = EXCLUDED.name,
+			sector = EXCLUDED.sector,
+			currency = EXCLUDED.currency,
+			lot_size = EXCLUDED.lot_size
+	`
+
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		for i, req := range reqs {
+			req = normalizeSymbolRequest(req)
+			tag, err := tx.Exec(ctx, query, req.Exchange, req.Symbol, req.Name, req.Sector, req.Currency, req.LotSize)
+			if err != nil {
+				return fmt.Errorf("symbol %d: %w", i, err)
+			}
+			if tag.RowsAffected() == 0 {
+				return fmt.Errorf("symbol %d (%s): %w: %s", i, req.Symbol, ErrExchangeNotFound, req.Exchange)
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrExchangeNotFound) {
+		s.logger.Error("Failed to upsert symbols", zap.Int("count", len(reqs)), zap.Error(err))
+	}
+	return err
+}
+
+// Update changes the given fields of a listing
+func (s *SymbolService) Update(ctx context.Context, exchange, symbol string, req models.UpdateSymbolRequest) (*models.Symbol, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE symbols SET
+			name = COALESCE($3, name),
+			sector = COALESCE($4, sector),
+			currency = COALESCE(upper($5), currency),
+			lot_size = COALESCE($6, lot_size)
+		WHERE exchange = $1 AND symbol = $2
+	`, exchange, symbol, req.Name, req.Sector, req.Currency, req.LotSize)
+	if err != nil {
+		s.logger.Error("Failed to update symbol", zap.String("exchange", exchange), zap.String("symbol", symbol), zap.Error(err))
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrSymbolNotFound
+	}
+
+	return s.Get(ctx, exchange, symbol)
+}
+
+// Delete removes a listing that has no market data left
+func (s *SymbolService) Delete(ctx context.Context, exchange, symbol string) error {
+	tag, err := s.db.Exec(ctx, `
+		DELETE FROM symbols
+		WHERE exchange = $1 AND symbol = $2
+			AND NOT EXISTS (SELECT 1 FROM market_data WHERE exchange = $1 AND symbol = $2)
+	`, exchange, symbol)
+	if err != nil {
+		s.logger.Error("Failed to delete symbol", zap.String("exchange", exchange), zap.String("symbol", symbol), zap.Error(err))
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	if _, err := s.Get(ctx, exchange, symbol); err != nil {
+		return err
+	}
+	return ErrSymbolHasData
+}
+
+func normalizeSymbolRequest(req models.SymbolRequest) models.SymbolRequest {
+	req.Symbol = models.CanonicalSymbol(strings.ToUpper(strings.TrimSpace(req.Symbol)))
+	req.Exchange = strings.ToUpper(strings.TrimSpace(req.Exchange))
+	if req.Exchange == "" {
+		req.Exchange = models.ExchangeForSymbol(req.Symbol)
+	}
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	req.Name = strings.TrimSpace(req.Name)
+	req.Sector = strings.TrimSpace(req.Sector)
+	return req
+}
+
+// escapeLike escapes LIKE wildcards so a search matches them literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/internal/transform"
+
+	"go.uber.org/zap"
+)
+
+// TransformRules returns the transform rules of every source that has any
+func (s *SourceService) TransformRules(ctx context.Context) (map[string][]models.TransformRule, error) {
+	rows, err := s.db.Query(ctx, `SELECT name, transform_rules FROM sources WHERE transform_rules <> '[]'::jsonb`)
+	if err != nil {
+		s.logger.Error("Failed to load transform rules", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := map[string][]models.TransformRule{}
+	for rows.Next() {
+		var name string
+		var sourceRules []models.TransformRule
+		if err := rows.Scan(&name, &sourceRules); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		rules[name] = sourceRules
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return rules, nil
+}
+
+// SetTransformRules replaces the rules a source's candles are rewritten with on
+// ingest. Rules are checked with transform.Validate by the caller.
+func (s *SourceService) SetTransformRules(ctx context.Context, name string, rules []models.TransformRule) error {
+	encoded, err := json.Marshal(rules)
+	if err != nil {
+		return fmt.Errorf("failed to encode rules: %w", err)
+	}
+	cmdTag, err := s.db.Exec(ctx, `UPDATE sources SET transform_rules = $1 WHERE name = $2`, encoded, name)
+	if err != nil {
+		s.logger.Error("Failed to set transform rules", zap.String("source", name), zap.Error(err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrSourceNotFound
+	}
+
+	s.logger.Info("Updated transform rules", zap.String("source", name), zap.Int("rules", len(rules)))
+	return nil
+}
+
+// Transform rewrites an ingest batch in place with each source's rules and reports
+// how many candles changed
+func (s *SourceService) Transform(ctx context.Context, data []models.MarketData) (int, error) {
+	rules, err := s.TransformRules(ctx)
+	if err != nil || len(rules) == 0 {
+		return 0, err
+	}
+	return s.transform(ctx, data, func(source string) []models.TransformRule { return rules[source] })
+}
+
+// PreviewTransform applies rules, or the source's stored rules when rules is nil, to
+// a sample batch as if the source had delivered it. Nothing is stored.
+func (s *SourceService) PreviewTransform(ctx context.Context, name string, rules []models.TransformRule, data []models.MarketData) (*models.TransformPreview, error) {
+	if rules == nil {
+		source, err := s.Get(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if source == nil {
+			return nil, ErrSourceNotFound
+		}
+		rules = source.TransformRules
+	}
+
+	preview := &models.TransformPreview{Source: name, Rules: rules, Rows: make([]models.TransformedCandle, len(data))}
+	after := make([]models.MarketData, len(data))
+	for i, md := range data {
+		md.Source = name
+		preview.Rows[i].Before = md
+		after[i] = md
+	}
+	changed, err := s.transform(ctx, after, func(string) []models.TransformRule { return rules })
+	if err != nil {
+		return nil, err
+	}
+	preview.Changed = changed
+	for i := range after {
+		preview.Rows[i].After = after[i]
+		preview.Rows[i].Changed = after[i] != preview.Rows[i].Before
+	}
+	return preview, nil
+}
+
+func (s *SourceService) transform(ctx context.Context, data []models.MarketData, rulesOf func(source string) []models.TransformRule) (int, error) {
+	var lotSizes map[string]int
+	for _, md := range data {
+		if transform.NeedsLotSize(rulesOf(md.Source)) {
+			var err error
+			if lotSizes, err = s.lotSizes(ctx, data); err != nil {
+				return 0, err
+			}
+			break
+		}
+	}
+
+	changed := 0
+	for i := range data {
+		md := &data[i]
+		rules := rulesOf(md.Source)
+		if len(rules) == 0 {
+			continue
+		}
+		if transform.Apply(md, rules, lotSizes[md.Exchange+"|"+md.Symbol]) {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// lotSizes returns the lot size of each listing in data, by exchange|symbol. Listings
+// without a symbol record use their exchange's lot size.
+func (s *SourceService) lotSizes(ctx context.Context, data []models.MarketData) (map[string]int, error) {
+	exchanges := make([]string, 0, len(data))
+	symbols := make([]string, 0, len(data))
+	seen := map[string]bool{}
+	for _, md := range data {
+		key := md.Exchange + "|" + md.Symbol
+		if !seen[key] {
+			seen[key] = true
+			exchanges = append(exchanges, md.Exchange)
+			symbols = append(symbols, md.Symbol)
+		}
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT l.exchange, l.symbol, COALESCE(sym.lot_size, e.lot_size, 0)
+		FROM unnest($1::text[], $2::text[]) AS l(exchange, symbol)
+		LEFT JOIN symbols sym ON sym.exchange = l.exchange AND sym.symbol = l.symbol
+		LEFT JOIN exchanges e ON e.code = l.exchange
+	`, exchanges, symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lot sizes: %w", err)
+	}
+	defer rows.Close()
+
+	lotSizes := make(map[string]int, len(seen))
+	for rows.Next() {
+		var exchange, symbol string
+		var lotSize int
+		if err := rows.Scan(&exchange, &symbol, &lotSize); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		lotSizes[exchange+"|"+symbol] = lotSize
+	}
+	return lotSizes, rows.Err()
+}
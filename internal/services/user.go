@@ -2,9 +2,13 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
 	"github.com/ridhomain/proto-trading-service/pkg/logger"
 
 	"github.com/jackc/pgx/v5"
@@ -12,12 +16,19 @@ import (
 	"go.uber.org/zap"
 )
 
+// ErrNoOrganization is returned when an organization-wide operation is requested by a
+// user outside any organization
+var ErrNoOrganization = errors.New("user is not in an organization")
+
 type UserPreferences struct {
 	UserID          string   `json:"user_id" db:"user_id"`
 	Email           string   `json:"email" db:"email"`
+	Organization    string   `json:"organization,omitempty" db:"organization"` // as of the last sign-in
 	DefaultSource   string   `json:"default_source" db:"default_source"`
 	SelectedSymbols []string `json:"selected_symbols" db:"selected_symbols"`
 	Watchlist       []string `json:"watchlist" db:"watchlist"`
+	DefaultLimit    int      `json:"default_limit" db:"default_limit"`             // rows per page when none is requested
+	DefaultWindow   int      `json:"default_window_days" db:"default_window_days"` // days shown when no range is requested
 	CreatedAt       string   `json:"created_at" db:"created_at"`
 	UpdatedAt       string   `json:"updated_at" db:"updated_at"`
 }
@@ -34,11 +45,18 @@ func NewUserService(db *database.DB) *UserService {
 	}
 }
 
-// GetOrCreatePreferences gets user preferences or creates default ones
-func (s *UserService) GetOrCreatePreferences(ctx context.Context, userID, email string) (*UserPreferences, error) {
+// GetOrCreatePreferences gets user preferences or creates default ones, recording
+// the user's current organization
+func (s *UserService) GetOrCreatePreferences(ctx context.Context, userID, email, organization string) (*UserPreferences, error) {
 	// Try to get existing preferences
 	prefs, err := s.GetPreferences(ctx, userID)
 	if err == nil && prefs != nil {
+		if prefs.Organization != organization {
+			if err := s.UpdatePreferences(ctx, userID, map[string]interface{}{"organization": organization}); err != nil {
+				return nil, err
+			}
+			prefs.Organization = organization
+		}
 		return prefs, nil
 	}
 
@@ -47,7 +65,8 @@ func (s *UserService) GetOrCreatePreferences(ctx context.Context, userID, email
 		defaultPrefs := &UserPreferences{
 			UserID:          userID,
 			Email:           email,
-			DefaultSource:   "yahoo",
+			Organization:    organization,
+			DefaultSource:   SourceAny,
 			SelectedSymbols: []string{"BBCA.JK", "BBRI.JK", "TLKM.JK"},
 			Watchlist:       []string{"BBCA.JK", "BBRI.JK", "TLKM.JK", "ASII.JK"},
 		}
@@ -66,7 +85,8 @@ func (s *UserService) GetOrCreatePreferences(ctx context.Context, userID, email
 // GetPreferences retrieves user preferences
 func (s *UserService) GetPreferences(ctx context.Context, userID string) (*UserPreferences, error) {
 	query := `
-		SELECT user_id, email, default_source, selected_symbols, watchlist, created_at, updated_at
+		SELECT user_id, email, organization, default_source, selected_symbols, watchlist, default_limit,
+			default_window_days, created_at, updated_at
 		FROM user_preferences
 		WHERE user_id = $1
 	`
@@ -75,9 +95,12 @@ func (s *UserService) GetPreferences(ctx context.Context, userID string) (*UserP
 	err := s.db.QueryRow(ctx, query, userID).Scan(
 		&prefs.UserID,
 		&prefs.Email,
+		&prefs.Organization,
 		&prefs.DefaultSource,
 		pq.Array(&prefs.SelectedSymbols),
 		pq.Array(&prefs.Watchlist),
+		&prefs.DefaultLimit,
+		&prefs.DefaultWindow,
 		&prefs.CreatedAt,
 		&prefs.UpdatedAt,
 	)
@@ -99,12 +122,13 @@ func (s *UserService) GetPreferences(ctx context.Context, userID string) (*UserP
 // CreatePreferences creates new user preferences
 func (s *UserService) CreatePreferences(ctx context.Context, prefs *UserPreferences) error {
 	query := `
-		INSERT INTO user_preferences (user_id, email, default_source, selected_symbols, watchlist)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO user_preferences (user_id, email, default_source, selected_symbols, watchlist, organization)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (user_id) DO UPDATE SET
 			email = EXCLUDED.email,
+			organization = EXCLUDED.organization,
 			updated_at = CURRENT_TIMESTAMP
-		RETURNING created_at, updated_at
+		RETURNING default_limit, default_window_days, created_at, updated_at
 	`
 
 	err := s.db.QueryRow(ctx, query,
@@ -113,7 +137,8 @@ func (s *UserService) CreatePreferences(ctx context.Context, prefs *UserPreferen
 		prefs.DefaultSource,
 		pq.Array(prefs.SelectedSymbols),
 		pq.Array(prefs.Watchlist),
-	).Scan(&prefs.CreatedAt, &prefs.UpdatedAt)
+		prefs.Organization,
+	).Scan(&prefs.DefaultLimit, &prefs.DefaultWindow, &prefs.CreatedAt, &prefs.UpdatedAt)
 
 	if err != nil {
 		s.logger.Error("Failed to create user preferences",
@@ -198,3 +223,118 @@ func (s *UserService) RemoveFromWatchlist(ctx context.Context, userID, symbol st
 
 	return nil
 }
+
+// WatchedSymbols returns every symbol on any user's watchlist that is listed on an
+// exchange of assetClass, in ticker order
+func (s *UserService) WatchedSymbols(ctx context.Context, assetClass string) ([]string, error) {
+	query := `
+		SELECT DISTINCT w.symbol
+		FROM user_preferences p
+		CROSS JOIN LATERAL unnest(p.watchlist) AS w(symbol)
+		JOIN symbols sy ON sy.symbol = w.symbol
+		JOIN exchanges e ON e.code = sy.exchange
+		WHERE e.asset_class = $1
+		ORDER BY w.symbol
+	`
+
+	rows, err := s.db.Query(ctx, query, assetClass)
+	if err != nil {
+		s.logger.Error("Failed to get watched symbols",
+			zap.String("asset_class", assetClass),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	symbols, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return symbols, nil
+}
+
+// ExportOrganization returns the settings of every member of organization, by email
+func (s *UserService) ExportOrganization(ctx context.Context, organization string) (*models.OrganizationExport, error) {
+	if organization == "" {
+		return nil, ErrNoOrganization
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT email, default_source, default_limit, default_window_days, watchlist, selected_symbols
+		FROM user_preferences
+		WHERE organization = $1
+		ORDER BY email, user_id
+	`, organization)
+	if err != nil {
+		s.logger.Error("Failed to export organization", zap.String("organization", organization), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	export := &models.OrganizationExport{
+		Organization: organization,
+		ExportedAt:   time.Now().UTC(),
+		Members:      []models.MemberSettings{},
+	}
+	for rows.Next() {
+		var m models.MemberSettings
+		if err := rows.Scan(&m.Email, &m.DefaultSource, &m.DefaultLimit, &m.DefaultWindow,
+			pq.Array(&m.Watchlist), pq.Array(&m.SelectedSymbols)); err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		export.Members = append(export.Members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return export, nil
+}
+
+// ImportOrganization applies exported settings to the members of organization with
+// the same email, all or none. Zero and empty fields keep the member's current value.
+func (s *UserService) ImportOrganization(ctx context.Context, organization string, members []models.MemberSettings) (*models.OrganizationImportResult, error) {
+	if organization == "" {
+		return nil, ErrNoOrganization
+	}
+
+	result := &models.OrganizationImportResult{Organization: organization, Unmatched: []string{}}
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		result.Updated = 0
+		result.Unmatched = result.Unmatched[:0]
+		for _, m := range members {
+			tag, err := tx.Exec(ctx, `
+				UPDATE user_preferences SET
+					default_source = COALESCE(NULLIF($3, ''), default_source),
+					default_limit = COALESCE(NULLIF($4, 0), default_limit),
+					default_window_days = COALESCE(NULLIF($5, 0), default_window_days),
+					watchlist = COALESCE($6, watchlist),
+					selected_symbols = COALESCE($7, selected_symbols)
+				WHERE organization = $1 AND LOWER(email) = LOWER($2)
+			`, organization, strings.TrimSpace(m.Email), m.DefaultSource, m.DefaultLimit, m.DefaultWindow,
+				nullableArray(m.Watchlist), nullableArray(m.SelectedSymbols))
+			if err != nil {
+				return fmt.Errorf("failed to import %s: %w", m.Email, err)
+			}
+			if tag.RowsAffected() == 0 {
+				result.Unmatched = append(result.Unmatched, m.Email)
+				continue
+			}
+			result.Updated++
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to import organization", zap.String("organization", organization), zap.Error(err))
+		return nil, err
+	}
+	return result, nil
+}
+
+// nullableArray passes a nil slice as NULL, so COALESCE keeps the stored array
+func nullableArray(values []string) interface{} {
+	if values == nil {
+		return nil
+	}
+	return pq.Array(values)
+}
@@ -0,0 +1,486 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/database"
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookLimit     = errors.New("webhook limit reached")
+)
+
+// WebhookSender POSTs a body to a user's endpoint, signed with secret, returning the
+// response status and an error for anything but a 2xx
+type WebhookSender interface {
+	Send(ctx context.Context, url string, secret []byte, headers map[string]string, body []byte) (int, error)
+}
+
+const (
+	// maxWebhooksPerUser caps the webhooks one user can register
+	maxWebhooksPerUser = 10
+	// webhookPollInterval is how often due deliveries are looked for
+	webhookPollInterval = 5 * time.Second
+	// webhookBatchSize is how many deliveries are attempted per transaction; each may
+	// take the whole send timeout
+	webhookBatchSize          = 20
+	defaultWebhookMaxAttempts = 10
+	// webhookRetention is how long finished deliveries stay in the log
+	webhookRetention = 30 * 24 * time.Hour
+)
+
+// WebhookService keeps the webhooks users register and delivers their events to them.
+// Events are written to a delivery log and sent by a background relay, which retries
+// failures with the outbox's backoff up to a maximum number of attempts.
+type WebhookService struct {
+	db          *database.DB
+	sender      WebhookSender
+	maxAttempts int
+	logger      *zap.Logger
+}
+
+func NewWebhookService(db *database.DB, sender WebhookSender, maxAttempts int) *WebhookService {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookMaxAttempts
+	}
+	return &WebhookService{
+		db:          db,
+		sender:      sender,
+		maxAttempts: maxAttempts,
+		logger:      logger.With(zap.String("service", "webhook")),
+	}
+}
+
+const webhookColumns = `id, user_id, url, secret, events, active, created_at, updated_at`
+
+const webhookDeliveryColumns = `d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.response_status, d.last_error,
+	CASE WHEN d.status = 'pending' THEN d.available_at END, d.created_at, d.completed_at`
+
+// Create registers a webhook with a new secret, which is only returned here
+func (s *WebhookService) Create(ctx context.Context, userID string, req models.WebhookRequest) (*models.Webhook, error) {
+	var count int
+	if err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM webhooks WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		s.logger.Error("Failed to count webhooks", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	if count >= maxWebhooksPerUser {
+		return nil, fmt.Errorf("%w: at most %d per user", ErrWebhookLimit, maxWebhooksPerUser)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		INSERT INTO webhooks (user_id, url, secret, events, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+webhookColumns,
+		userID, req.URL, hex.EncodeToString(secret), req.Events, req.Active == nil || *req.Active)
+	if err != nil {
+		s.logger.Error("Failed to create webhook", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	webhook, err := collectWebhook(rows)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// List returns a user's webhooks, oldest first, without their secrets
+func (s *WebhookService) List(ctx context.Context, userID string) ([]models.Webhook, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		s.logger.Error("Failed to list webhooks", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Webhook])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+// Get returns a webhook the user owns, without its secret
+func (s *WebhookService) Get(ctx context.Context, userID string, id int64) (*models.Webhook, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		s.logger.Error("Failed to get webhook", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	webhook, err := collectWebhook(rows)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// Update replaces the URL, events and active flag of a webhook the user owns; its
+// secret is kept
+func (s *WebhookService) Update(ctx context.Context, userID string, id int64, req models.WebhookRequest) (*models.Webhook, error) {
+	rows, err := s.db.Query(ctx, `
+		UPDATE webhooks SET url = $3, events = $4, active = $5
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+webhookColumns,
+		id, userID, req.URL, req.Events, req.Active == nil || *req.Active)
+	if err != nil {
+		s.logger.Error("Failed to update webhook", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	webhook, err := collectWebhook(rows)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// Delete removes a webhook the user owns along with its delivery log
+func (s *WebhookService) Delete(ctx context.Context, userID string, id int64) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		s.logger.Error("Failed to delete webhook", zap.Int64("id", id), zap.Error(err))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func collectWebhook(rows pgx.Rows) (*models.Webhook, error) {
+	webhook, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.Webhook])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to collect row: %w", err)
+	}
+	return &webhook, nil
+}
+
+// Deliveries returns a webhook's most recent deliveries, only those with status
+// when it is not empty
+func (s *WebhookService) Deliveries(ctx context.Context, userID string, id int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries d
+		WHERE d.webhook_id = $1 AND ($2 = '' OR d.status = $2)
+		ORDER BY d.id DESC
+		LIMIT $3
+	`, id, status, limit)
+	if err != nil {
+		s.logger.Error("Failed to list webhook deliveries", zap.Int64("webhook_id", id), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.WebhookDelivery])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues a new delivery of the event and payload of an earlier one
+func (s *WebhookService) Redeliver(ctx context.Context, userID string, id, deliveryID int64) (*models.WebhookDelivery, error) {
+	rows, err := s.db.Query(ctx, `
+		WITH d AS (
+			INSERT INTO webhook_deliveries (webhook_id, event, payload)
+			SELECT o.webhook_id, o.event, o.payload
+			FROM webhook_deliveries o
+			JOIN webhooks w ON w.id = o.webhook_id
+			WHERE o.id = $1 AND o.webhook_id = $2 AND w.user_id = $3
+			RETURNING *
+		)
+		SELECT `+webhookDeliveryColumns+` FROM d
+	`, deliveryID, id, userID)
+	if err != nil {
+		s.logger.Error("Failed to redeliver webhook delivery", zap.Int64("id", deliveryID), zap.Error(err))
+		return nil, err
+	}
+	delivery, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.WebhookDelivery])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to collect row: %w", err)
+	}
+	return &delivery, nil
+}
+
+// Ping queues a ping delivery to a webhook the user owns, whatever its events and
+// whether or not it is active
+func (s *WebhookService) Ping(ctx context.Context, userID string, id int64) (*models.WebhookDelivery, error) {
+	payload, err := json.Marshal(map[string]int64{"webhook_id": id})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(ctx, `
+		WITH d AS (
+			INSERT INTO webhook_deliveries (webhook_id, event, payload)
+			SELECT id, $3::text, $4::jsonb FROM webhooks WHERE id = $1 AND user_id = $2
+			RETURNING *
+		)
+		SELECT `+webhookDeliveryColumns+` FROM d
+	`, id, userID, models.EventPing, payload)
+	if err != nil {
+		s.logger.Error("Failed to ping webhook", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	delivery, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.WebhookDelivery])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to collect row: %w", err)
+	}
+	return &delivery, nil
+}
+
+// Dispatch queues event with data to each of the user's active webhooks subscribed to
+// it. A nil service dispatches nothing. Failures are logged: a lost notification
+// must not fail the work it reports on.
+func (s *WebhookService) Dispatch(ctx context.Context, userID, event string, data interface{}) {
+	if s == nil {
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		s.logger.Error("Failed to encode webhook event", zap.String("event", event), zap.Error(err))
+		return
+	}
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT id, $2::text, $3::jsonb FROM webhooks
+		WHERE user_id = $1 AND active AND $2::text = ANY(events)
+	`, userID, event, payload)
+	if err != nil {
+		s.logger.Error("Failed to dispatch webhook event",
+			zap.String("user_id", userID),
+			zap.String("event", event),
+			zap.Error(err),
+		)
+		return
+	}
+	if tag.RowsAffected() > 0 {
+		s.logger.Debug("Webhook event dispatched",
+			zap.String("user_id", userID),
+			zap.String("event", event),
+			zap.Int64("webhooks", tag.RowsAffected()),
+		)
+	}
+}
+
+// Start delivers due events until ctx is cancelled, and purges finished deliveries
+// past retention hourly
+func (s *WebhookService) Start(ctx context.Context) {
+	s.logger.Info("Webhook relay started")
+
+	poll := time.NewTicker(webhookPollInterval)
+	defer poll.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			s.drain(ctx)
+		case <-purge.C:
+			s.purge(ctx)
+		}
+	}
+}
+
+// drain delivers batches until one comes back short
+func (s *WebhookService) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		attempted, err := s.relay(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to relay webhook deliveries", zap.Error(err))
+			}
+			return
+		}
+		if attempted < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// relay attempts one batch of due deliveries to active webhooks, oldest first,
+// returning how many it attempted. As with the outbox, a transaction-scoped advisory
+// lock lets one instance relay at a time, and an outcome is recorded only after the
+// attempt, so a crash in between sends the delivery again.
+func (s *WebhookService) relay(ctx context.Context) (int, error) {
+	attempted := 0
+	err := s.db.Transaction(ctx, func(tx pgx.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock(hashtextextended('webhook_relay', 0))`).Scan(&locked); err != nil {
+			return fmt.Errorf("failed to take relay lock: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		rows, err := tx.Query(ctx, `
+			SELECT d.id, d.webhook_id, d.event, d.payload, d.created_at, d.attempts, w.url, w.secret
+			FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.available_at <= CURRENT_TIMESTAMP AND w.active
+			ORDER BY d.id
+			LIMIT $1
+		`, webhookBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to load deliveries: %w", err)
+		}
+		type pending struct {
+			envelope  models.WebhookEnvelope
+			webhookID int64
+			attempts  int
+			url       string
+			secret    string
+		}
+		var batch []pending
+		for rows.Next() {
+			var p pending
+			if err := rows.Scan(&p.envelope.ID, &p.webhookID, &p.envelope.Event, &p.envelope.Data, &p.envelope.CreatedAt,
+				&p.attempts, &p.url, &p.secret); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan delivery: %w", err)
+			}
+			batch = append(batch, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("row iteration error: %w", err)
+		}
+
+		updates := &pgx.Batch{}
+		for _, p := range batch {
+			if err := ctx.Err(); err != nil {
+				break
+			}
+			attempted++
+
+			body, err := json.Marshal(p.envelope)
+			if err != nil {
+				return fmt.Errorf("failed to encode delivery %d: %w", p.envelope.ID, err)
+			}
+			status, err := s.sender.Send(ctx, p.url, []byte(p.secret), map[string]string{
+				"X-Webhook-Id":       strconv.FormatInt(p.webhookID, 10),
+				"X-Webhook-Event":    p.envelope.Event,
+				"X-Webhook-Delivery": strconv.FormatInt(p.envelope.ID, 10),
+			}, body)
+			var responseStatus *int
+			if status != 0 {
+				responseStatus = &status
+			}
+			attempts := p.attempts + 1
+
+			if err == nil {
+				updates.Queue(`
+					UPDATE webhook_deliveries
+					SET status = 'delivered', attempts = $2, response_status = $3, last_error = '', completed_at = CURRENT_TIMESTAMP
+					WHERE id = $1
+				`, p.envelope.ID, attempts, responseStatus)
+				continue
+			}
+
+			if attempts >= s.maxAttempts {
+				s.logger.Warn("Giving up on webhook delivery",
+					zap.Int64("id", p.envelope.ID),
+					zap.Int64("webhook_id", p.webhookID),
+					zap.String("event", p.envelope.Event),
+					zap.Int("attempts", attempts),
+					zap.Error(err),
+				)
+				updates.Queue(`
+					UPDATE webhook_deliveries
+					SET status = 'failed', attempts = $2, response_status = $3, last_error = $4, completed_at = CURRENT_TIMESTAMP
+					WHERE id = $1
+				`, p.envelope.ID, attempts, responseStatus, deliveryError(status, err))
+				continue
+			}
+			s.logger.Debug("Webhook delivery failed",
+				zap.Int64("id", p.envelope.ID),
+				zap.Int64("webhook_id", p.webhookID),
+				zap.Int("attempts", attempts),
+				zap.Error(err),
+			)
+			updates.Queue(`
+				UPDATE webhook_deliveries
+				SET attempts = $2, response_status = $3, last_error = $4,
+					available_at = CURRENT_TIMESTAMP + make_interval(secs => $5)
+				WHERE id = $1
+			`, p.envelope.ID, attempts, responseStatus, deliveryError(status, err), outboxBackoff(attempts).Seconds())
+		}
+
+		if updates.Len() == 0 {
+			return nil
+		}
+		// Record outcomes even if the relay is stopping; the attempts already happened
+		if err := tx.SendBatch(context.WithoutCancel(ctx), updates).Close(); err != nil {
+			return fmt.Errorf("failed to record deliveries: %w", err)
+		}
+		return nil
+	})
+	return attempted, err
+}
+
+// deliveryError is what the delivery log shows of a failed attempt. Users can read
+// the log, so it says no more than the status code: what the endpoint or the network
+// said stays in the server's logs.
+func deliveryError(status int, err error) string {
+	var netErr net.Error
+	switch {
+	case status != 0:
+		return fmt.Sprintf("webhook returned status %d", status)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "request timed out"
+	default:
+		return "request failed"
+	}
+}
+
+func (s *WebhookService) purge(ctx context.Context) {
+	tag, err := s.db.Exec(ctx, `
+		DELETE FROM webhook_deliveries
+		WHERE status <> 'pending' AND completed_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+	`, webhookRetention.Seconds())
+	if err != nil {
+		s.logger.Error("Failed to purge webhook deliveries", zap.Error(err))
+		return
+	}
+	if tag.RowsAffected() > 0 {
+		s.logger.Info("Purged webhook deliveries", zap.Int64("count", tag.RowsAffected()))
+	}
+}
@@ -0,0 +1,96 @@
+package strategy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported definition formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Definition is a declarative trading strategy shared by the backtester and live signal engine
+type Definition struct {
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Symbols     []string       `json:"symbols" yaml:"symbols"`
+	Interval    string         `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Entry       RuleSet        `json:"entry" yaml:"entry"`
+	Exit        ExitRules      `json:"exit" yaml:"exit"`
+	Sizing      PositionSizing `json:"sizing" yaml:"sizing"`
+}
+
+// RuleSet groups conditions: all of All must hold, and at least one of Any if present
+type RuleSet struct {
+	All []Condition `json:"all,omitempty" yaml:"all,omitempty"`
+	Any []Condition `json:"any,omitempty" yaml:"any,omitempty"`
+}
+
+// ExitRules are exit conditions plus optional protective stops
+type ExitRules struct {
+	RuleSet       `yaml:",inline"`
+	StopLossPct   float64 `json:"stop_loss_pct,omitempty" yaml:"stop_loss_pct,omitempty"`
+	TakeProfitPct float64 `json:"take_profit_pct,omitempty" yaml:"take_profit_pct,omitempty"`
+}
+
+// Condition compares two operands, e.g. sma(20) crosses_above sma(50)
+type Condition struct {
+	Left     Operand `json:"left" yaml:"left"`
+	Operator string  `json:"op" yaml:"op"`
+	Right    Operand `json:"right" yaml:"right"`
+}
+
+// Operand is either an indicator reference or a constant value
+type Operand struct {
+	Indicator string   `json:"indicator,omitempty" yaml:"indicator,omitempty"`
+	Period    int      `json:"period,omitempty" yaml:"period,omitempty"`
+	Value     *float64 `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// PositionSizing controls how much is bought on each entry signal
+type PositionSizing struct {
+	Method       string  `json:"method" yaml:"method"`
+	Value        float64 `json:"value" yaml:"value"`
+	MaxPositions int     `json:"max_positions,omitempty" yaml:"max_positions,omitempty"`
+}
+
+// String renders the operand the way it appears in error messages and requirements
+func (o Operand) String() string {
+	if o.Value != nil {
+		return fmt.Sprintf("%g", *o.Value)
+	}
+	if o.Period > 0 {
+		return fmt.Sprintf("%s(%d)", o.Indicator, o.Period)
+	}
+	return o.Indicator
+}
+
+// Parse decodes a strategy definition, rejecting unknown fields
+func Parse(data []byte, format string) (*Definition, error) {
+	var def Definition
+
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&def); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case FormatYAML, "yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&def); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+
+	return &def, nil
+}
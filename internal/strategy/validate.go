@@ -0,0 +1,245 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+)
+
+// indicatorSpec describes an indicator the engine can compute
+type indicatorSpec struct {
+	needsPeriod bool
+	// lookback returns how many bars of history are needed before the value is usable
+	lookback func(period int) int
+}
+
+var indicators = map[string]indicatorSpec{
+	"open":    {lookback: func(int) int { return 1 }},
+	"high":    {lookback: func(int) int { return 1 }},
+	"low":     {lookback: func(int) int { return 1 }},
+	"close":   {lookback: func(int) int { return 1 }},
+	"volume":  {lookback: func(int) int { return 1 }},
+	"sma":     {needsPeriod: true, lookback: func(p int) int { return p }},
+	"ema":     {needsPeriod: true, lookback: func(p int) int { return p * 3 }}, // warm-up for convergence
+	"rsi":     {needsPeriod: true, lookback: func(p int) int { return p + 1 }},
+	"roc":     {needsPeriod: true, lookback: func(p int) int { return p + 1 }},
+	"highest": {needsPeriod: true, lookback: func(p int) int { return p }},
+	"lowest":  {needsPeriod: true, lookback: func(p int) int { return p }},
+}
+
+var operators = map[string]bool{
+	"gt":            true,
+	"gte":           true,
+	"lt":            true,
+	"lte":           true,
+	"crosses_above": true,
+	"crosses_below": true,
+}
+
+var sizingMethods = map[string]bool{
+	"fixed_amount":   true, // Value is an amount in account currency
+	"fixed_lots":     true, // Value is a number of lots
+	"percent_equity": true, // Value is a percentage of current equity
+}
+
+var intervals = map[string]bool{
+	"1d": true,
+}
+
+const maxPeriod = 500
+
+// maxNameLength is the longest name a saved strategy can have
+const maxNameLength = 200
+
+// ValidationError points at the offending field of a definition
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Requirements lists the data a strategy needs before it can be evaluated
+type Requirements struct {
+	Symbols      []string `json:"symbols"`
+	Interval     string   `json:"interval"`
+	Fields       []string `json:"fields"`
+	Indicators   []string `json:"indicators"`
+	LookbackBars int      `json:"lookback_bars"`
+}
+
+// Validate checks a definition and reports every problem found along with its data requirements
+func Validate(def *Definition) ([]ValidationError, *Requirements) {
+	v := &validator{
+		fields:     map[string]bool{},
+		indicators: map[string]bool{},
+	}
+
+	if def.Name == "" {
+		v.add("name", "is required")
+	} else if len(def.Name) > maxNameLength {
+		v.add("name", fmt.Sprintf("must be at most %d characters", maxNameLength))
+	}
+
+	if len(def.Symbols) == 0 {
+		v.add("symbols", "at least one symbol is required")
+	}
+	seen := map[string]bool{}
+	for i, symbol := range def.Symbols {
+		if symbol == "" {
+			v.add(fmt.Sprintf("symbols[%d]", i), "must not be empty")
+			continue
+		}
+		if seen[symbol] {
+			v.add(fmt.Sprintf("symbols[%d]", i), fmt.Sprintf("duplicate symbol %s", symbol))
+		}
+		seen[symbol] = true
+	}
+
+	interval := def.Interval
+	if interval == "" {
+		interval = "1d"
+	}
+	if !intervals[interval] {
+		v.add("interval", fmt.Sprintf("unsupported interval %q", def.Interval))
+	}
+
+	if len(def.Entry.All) == 0 && len(def.Entry.Any) == 0 {
+		v.add("entry", "at least one entry condition is required")
+	}
+	v.ruleSet("entry", def.Entry)
+
+	hasStops := def.Exit.StopLossPct > 0 || def.Exit.TakeProfitPct > 0
+	if len(def.Exit.All) == 0 && len(def.Exit.Any) == 0 && !hasStops {
+		v.add("exit", "at least one exit condition, stop_loss_pct or take_profit_pct is required")
+	}
+	v.ruleSet("exit", def.Exit.RuleSet)
+	if def.Exit.StopLossPct < 0 || def.Exit.StopLossPct >= 100 {
+		v.add("exit.stop_loss_pct", "must be between 0 and 100")
+	}
+	if def.Exit.TakeProfitPct < 0 {
+		v.add("exit.take_profit_pct", "must not be negative")
+	}
+
+	switch {
+	case def.Sizing.Method == "":
+		v.add("sizing.method", "is required")
+	case !sizingMethods[def.Sizing.Method]:
+		v.add("sizing.method", fmt.Sprintf("unknown sizing method %q", def.Sizing.Method))
+	}
+	if def.Sizing.Value <= 0 {
+		v.add("sizing.value", "must be greater than 0")
+	}
+	if def.Sizing.Method == "percent_equity" && def.Sizing.Value > 100 {
+		v.add("sizing.value", "percent_equity cannot exceed 100")
+	}
+	if def.Sizing.MaxPositions < 0 {
+		v.add("sizing.max_positions", "must not be negative")
+	}
+
+	req := &Requirements{
+		Symbols:      def.Symbols,
+		Interval:     interval,
+		Fields:       sortedKeys(v.fields),
+		Indicators:   sortedKeys(v.indicators),
+		LookbackBars: v.lookback,
+	}
+	if req.Symbols == nil {
+		req.Symbols = []string{}
+	}
+
+	return v.errors, req
+}
+
+type validator struct {
+	errors     []ValidationError
+	fields     map[string]bool
+	indicators map[string]bool
+	lookback   int
+}
+
+func (v *validator) add(field, message string) {
+	v.errors = append(v.errors, ValidationError{Field: field, Message: message})
+}
+
+func (v *validator) ruleSet(path string, rs RuleSet) {
+	for i, cond := range rs.All {
+		v.condition(fmt.Sprintf("%s.all[%d]", path, i), cond)
+	}
+	for i, cond := range rs.Any {
+		v.condition(fmt.Sprintf("%s.any[%d]", path, i), cond)
+	}
+}
+
+func (v *validator) condition(path string, cond Condition) {
+	if !operators[cond.Operator] {
+		v.add(path+".op", fmt.Sprintf("unknown operator %q", cond.Operator))
+	}
+
+	v.operand(path+".left", cond.Left)
+	v.operand(path+".right", cond.Right)
+
+	if cond.Left.Value != nil && cond.Right.Value != nil {
+		v.add(path, "comparing two constants is always true or always false")
+	}
+
+	// Crossovers compare the current and previous bar, so they need one extra bar
+	if cond.Operator == "crosses_above" || cond.Operator == "crosses_below" {
+		v.lookback = max(v.lookback, v.operandLookback(cond.Left)+1, v.operandLookback(cond.Right)+1)
+	}
+}
+
+func (v *validator) operand(path string, op Operand) {
+	if op.Value != nil {
+		if op.Indicator != "" || op.Period != 0 {
+			v.add(path, "an operand is either a value or an indicator, not both")
+		}
+		return
+	}
+
+	if op.Indicator == "" {
+		v.add(path, "indicator or value is required")
+		return
+	}
+
+	spec, ok := indicators[op.Indicator]
+	if !ok {
+		v.add(path+".indicator", fmt.Sprintf("unknown indicator %q", op.Indicator))
+		return
+	}
+
+	switch {
+	case spec.needsPeriod && op.Period <= 0:
+		v.add(path+".period", fmt.Sprintf("%s requires a positive period", op.Indicator))
+		return
+	case spec.needsPeriod && op.Period > maxPeriod:
+		v.add(path+".period", fmt.Sprintf("must not exceed %d", maxPeriod))
+		return
+	case !spec.needsPeriod && op.Period != 0:
+		v.add(path+".period", fmt.Sprintf("%s does not take a period", op.Indicator))
+		return
+	}
+
+	if spec.needsPeriod {
+		v.indicators[op.String()] = true
+		// Derived indicators are computed from closing prices
+		v.fields["close"] = true
+	} else {
+		v.fields[op.Indicator] = true
+	}
+	v.lookback = max(v.lookback, v.operandLookback(op))
+}
+
+func (v *validator) operandLookback(op Operand) int {
+	spec, ok := indicators[op.Indicator]
+	if !ok || op.Value != nil {
+		return 0
+	}
+	return spec.lookback(op.Period)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
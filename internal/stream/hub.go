@@ -0,0 +1,273 @@
+package stream
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+	"github.com/ridhomain/proto-trading-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// MaxSymbols bounds how many symbols one subscriber may follow
+const MaxSymbols = 50
+
+// sendBuffer is how many pending updates a subscriber may queue before it is
+// considered too slow and dropped
+const sendBuffer = 64
+
+// replayBuffer is how many recent events are kept for clients resuming after a
+// disconnect
+const replayBuffer = 1024
+
+// ChannelPortfolio is the channel of a user's own portfolio events, which a
+// subscriber can follow besides symbols
+const ChannelPortfolio = "portfolio"
+
+// Event is one published batch of rows or portfolio events. IDs increase with every
+// batch and, since they start from the hub's creation time, do not repeat across
+// restarts.
+type Event struct {
+	ID        uint64
+	Data      []models.MarketData
+	UserID    string                  // whose portfolio the events belong to
+	Portfolio []models.PortfolioEvent // recorded trades and cash movements
+}
+
+// Hub fans newly stored market data out to subscribers of each symbol
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscriber]struct{}
+	lastID      uint64
+	recent      []Event // the latest events, oldest first, at most replayBuffer
+	logger      *zap.Logger
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[*Subscriber]struct{}),
+		lastID:      uint64(time.Now().UnixMilli()) << 16,
+		logger:      logger.With(zap.String("component", "stream")),
+	}
+}
+
+// Subscriber receives the rows stored for the symbols it follows, and its user's
+// portfolio events while it follows ChannelPortfolio
+type Subscriber struct {
+	mu        sync.Mutex
+	userID    string
+	symbols   map[string]struct{}
+	portfolio bool
+	updates   chan Event
+	done      chan struct{}
+	once      sync.Once
+}
+
+// Register adds a subscriber for userID that follows no symbols yet
+func (h *Hub) Register(userID string) *Subscriber {
+	sub := &Subscriber{
+		userID:  userID,
+		symbols: make(map[string]struct{}),
+		updates: make(chan Event, sendBuffer),
+		done:    make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	count := len(h.subscribers)
+	h.mu.Unlock()
+
+	h.logger.Debug("Stream subscriber registered", zap.Int("subscribers", count))
+	return sub
+}
+
+// Unregister removes a subscriber and closes its Done channel
+func (h *Hub) Unregister(sub *Subscriber) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+
+	sub.close()
+}
+
+// Publish delivers rows to every subscriber following their symbols. It never
+// blocks: a subscriber whose buffer is full is dropped.
+func (h *Hub) Publish(data []models.MarketData) {
+	if len(data) == 0 {
+		return
+	}
+	h.publish(Event{Data: data})
+}
+
+// PublishPortfolio delivers a user's portfolio events to their subscribers following
+// ChannelPortfolio, like Publish
+func (h *Hub) PublishPortfolio(userID string, events ...models.PortfolioEvent) {
+	if len(events) == 0 {
+		return
+	}
+	h.publish(Event{UserID: userID, Portfolio: events})
+}
+
+func (h *Hub) publish(event Event) {
+	h.mu.Lock()
+	h.lastID++
+	event.ID = h.lastID
+	if len(h.recent) == replayBuffer {
+		h.recent = append(h.recent[:0], h.recent[1:]...)
+	}
+	h.recent = append(h.recent, event)
+
+	var slow []*Subscriber
+	for sub := range h.subscribers {
+		filtered, ok := sub.filter(event)
+		if !ok {
+			continue
+		}
+		select {
+		case sub.updates <- filtered:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, sub := range slow {
+		h.logger.Warn("Dropping slow stream subscriber")
+		h.Unregister(sub)
+	}
+}
+
+// Since returns the events after id for what sub follows, and false when
+// some of them are no longer buffered or id was not issued by this hub, so the
+// client may have missed rows
+func (h *Hub) Since(sub *Subscriber, id uint64) ([]Event, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if id > h.lastID || (len(h.recent) > 0 && id+1 < h.recent[0].ID) || (len(h.recent) == 0 && id != h.lastID) {
+		return nil, false
+	}
+
+	var events []Event
+	for _, event := range h.recent {
+		if event.ID <= id {
+			continue
+		}
+		if filtered, ok := sub.filter(event); ok {
+			events = append(events, filtered)
+		}
+	}
+	return events, true
+}
+
+// LastID is the ID of the latest event, from which a new client can resume
+func (h *Hub) LastID() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastID
+}
+
+// Subscribe follows symbols and returns the full set followed, or false if it
+// would exceed MaxSymbols
+func (s *Subscriber) Subscribe(symbols []string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+	for _, symbol := range symbols {
+		if _, ok := s.symbols[symbol]; !ok {
+			added++
+		}
+	}
+	if len(s.symbols)+added > MaxSymbols {
+		return s.list(), false
+	}
+
+	for _, symbol := range symbols {
+		s.symbols[symbol] = struct{}{}
+	}
+	return s.list(), true
+}
+
+// Unsubscribe stops following symbols and returns the symbols still followed
+func (s *Subscriber) Unsubscribe(symbols []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, symbol := range symbols {
+		delete(s.symbols, symbol)
+	}
+	return s.list()
+}
+
+// Follow starts or stops following a channel, returning false for an unknown one
+func (s *Subscriber) Follow(channel string, follow bool) bool {
+	if channel != ChannelPortfolio {
+		return false
+	}
+	s.mu.Lock()
+	s.portfolio = follow
+	s.mu.Unlock()
+	return true
+}
+
+// Channels lists the channels followed
+func (s *Subscriber) Channels() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.portfolio {
+		return []string{ChannelPortfolio}
+	}
+	return []string{}
+}
+
+// Symbols lists the symbols followed
+func (s *Subscriber) Symbols() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list()
+}
+
+// Updates delivers batches of rows for followed symbols and followed channels' events
+func (s *Subscriber) Updates() <-chan Event {
+	return s.updates
+}
+
+// Done is closed once the subscriber has been unregistered or dropped
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// filter narrows event to what the subscriber follows, returning false when that
+// leaves nothing
+func (s *Subscriber) filter(event Event) (Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(event.Portfolio) > 0 {
+		return event, s.portfolio && event.UserID == s.userID
+	}
+	var rows []models.MarketData
+	for _, md := range event.Data {
+		if _, ok := s.symbols[md.Symbol]; ok {
+			rows = append(rows, md)
+		}
+	}
+	return Event{ID: event.ID, Data: rows}, len(rows) > 0
+}
+
+// list returns the followed symbols; callers hold s.mu
+func (s *Subscriber) list() []string {
+	symbols := make([]string, 0, len(s.symbols))
+	for symbol := range s.symbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+func (s *Subscriber) close() {
+	s.once.Do(func() { close(s.done) })
+}
@@ -0,0 +1,107 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ridhomain/proto-trading-service/internal/models"
+)
+
+// Kinds of rule
+const (
+	KindScalePrices  = "scale_prices"   // multiply open, high, low and close by factor, e.g. prices quoted in thousands
+	KindScaleVolume  = "scale_volume"   // multiply volume by factor
+	KindVolumeToLots = "volume_to_lots" // divide volume by the listing's lot size
+	KindStripSuffix  = "strip_suffix"   // remove suffix from the end of the symbol
+)
+
+// Kinds lists the valid rule kinds
+var Kinds = []string{KindScalePrices, KindScaleVolume, KindVolumeToLots, KindStripSuffix}
+
+// pricePrecision is the number of decimals scaled prices are rounded to, so a factor
+// does not leave floating point noise behind
+const pricePrecision = 1e8
+
+// Validate reports the first problem with a rule
+func Validate(r models.TransformRule) error {
+	switch r.Kind {
+	case KindScalePrices, KindScaleVolume:
+		if r.Factor <= 0 || math.IsInf(r.Factor, 0) {
+			return fmt.Errorf("%s needs a positive factor", r.Kind)
+		}
+	case KindVolumeToLots:
+	case KindStripSuffix:
+		if r.Suffix == "" {
+			return errors.New("strip_suffix needs a suffix")
+		}
+	default:
+		return fmt.Errorf("kind must be one of: %s", strings.Join(Kinds, ", "))
+	}
+	if r.Interval != "" && !models.ValidInterval(r.Interval) {
+		return errors.New("interval must be 1m, 5m, 1h or 1d")
+	}
+	return nil
+}
+
+// NeedsLotSize reports whether any rule divides by the listing's lot size
+func NeedsLotSize(rules []models.TransformRule) bool {
+	for _, r := range rules {
+		if r.Kind == KindVolumeToLots {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a rule applies to a candle as rewritten so far
+func matches(r models.TransformRule, md *models.MarketData) bool {
+	return (r.SymbolSuffix == "" || strings.HasSuffix(md.Symbol, strings.ToUpper(r.SymbolSuffix))) &&
+		(r.Exchange == "" || strings.EqualFold(r.Exchange, md.Exchange)) &&
+		(r.Interval == "" || r.Interval == md.Interval)
+}
+
+// Apply runs rules on md in order, each seeing what the earlier ones left, and
+// reports whether any changed it. lotSize is the listing's lot size; volume_to_lots
+// leaves the volume alone when it is not positive. A missing open or volume stays
+// missing.
+func Apply(md *models.MarketData, rules []models.TransformRule, lotSize int) bool {
+	before := *md
+	for _, r := range rules {
+		if !matches(r, md) {
+			continue
+		}
+		switch r.Kind {
+		case KindScalePrices:
+			if md.Open != nil {
+				open := scale(*md.Open, r.Factor)
+				md.Open = &open
+			}
+			md.High = scale(md.High, r.Factor)
+			md.Low = scale(md.Low, r.Factor)
+			md.Close = scale(md.Close, r.Factor)
+			if md.AdjClose != nil {
+				adj := scale(*md.AdjClose, r.Factor)
+				md.AdjClose = &adj
+			}
+		case KindScaleVolume:
+			if md.Volume != nil {
+				volume := int64(math.Round(float64(*md.Volume) * r.Factor))
+				md.Volume = &volume
+			}
+		case KindVolumeToLots:
+			if lotSize > 0 && md.Volume != nil {
+				volume := int64(math.Round(float64(*md.Volume) / float64(lotSize)))
+				md.Volume = &volume
+			}
+		case KindStripSuffix:
+			md.Symbol = strings.TrimSuffix(md.Symbol, strings.ToUpper(r.Suffix))
+		}
+	}
+	return *md != before
+}
+
+func scale(price, factor float64) float64 {
+	return math.Round(price*factor*pricePrecision) / pricePrecision
+}
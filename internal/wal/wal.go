@@ -0,0 +1,262 @@
+// Package wal is an append-only log of JSON records kept in a local file. Records
+// are read back in the order they were appended and acknowledged once handled; the
+// file is truncated when every record has been acknowledged.
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrFull is returned when appending a record would grow the log past its size cap
+var ErrFull = errors.New("write-ahead log is full")
+
+// Entry is one record in the log
+type Entry struct {
+	Seq     uint64
+	Payload json.RawMessage
+
+	end int64 // offset just past the record's line
+}
+
+// line is how a record is stored: one JSON object per line, with a checksum of the
+// payload so a record torn by a crash is recognised
+type line struct {
+	Seq     uint64          `json:"seq"`
+	CRC     uint32          `json:"crc"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Log is a write-ahead log backed by a file and a position file holding the
+// sequence of the last acknowledged record. Sequences keep increasing when the file
+// is emptied, so a stale position never skips newer records. It is safe for
+// concurrent use.
+type Log struct {
+	path     string
+	maxBytes int64
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64  // bytes in the file
+	acked   int64  // offset of the first unacknowledged record
+	lastSeq uint64 // sequence of the newest record appended
+	pending int    // records appended but not acknowledged
+}
+
+// Open opens the log at path, creating it when missing. A record left half-written
+// by a crash is cut off. maxBytes caps the file size; 0 leaves it uncapped.
+func Open(path string, maxBytes int64) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open write-ahead log: %w", err)
+	}
+	l := &Log{path: path, maxBytes: maxBytes, file: file}
+
+	ackedSeq, err := readPosition(l.positionPath())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// Find where the last whole record ends and what is still pending
+	entries, valid, err := l.read(0)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Truncate(valid); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to cut off torn record: %w", err)
+	}
+	l.size = valid
+	l.lastSeq = ackedSeq
+	for _, e := range entries {
+		if e.Seq <= ackedSeq {
+			l.acked = e.end
+			continue
+		}
+		l.lastSeq = e.Seq
+		l.pending++
+	}
+	return l, nil
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Append writes a JSON record and syncs it to disk before returning its sequence
+func (l *Log) Append(payload []byte) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Records are one line each, so the payload is compacted before it is checksummed
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, payload); err != nil {
+		return 0, fmt.Errorf("failed to encode record: %w", err)
+	}
+	seq := l.lastSeq + 1
+	data, err := json.Marshal(line{Seq: seq, CRC: crc32.ChecksumIEEE(compact.Bytes()), Payload: compact.Bytes()})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode record: %w", err)
+	}
+	data = append(data, '\n')
+	if l.maxBytes > 0 && l.size+int64(len(data)) > l.maxBytes {
+		return 0, ErrFull
+	}
+
+	if _, err := l.file.WriteAt(data, l.size); err != nil {
+		// Leave no partial record behind for the next append to follow
+		_ = l.file.Truncate(l.size)
+		return 0, fmt.Errorf("failed to write record: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		_ = l.file.Truncate(l.size)
+		return 0, fmt.Errorf("failed to sync write-ahead log: %w", err)
+	}
+	l.size += int64(len(data))
+	l.lastSeq = seq
+	l.pending++
+	return seq, nil
+}
+
+// Pending returns every unacknowledged record, oldest first
+func (l *Log) Pending() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries, _, err := l.read(l.acked)
+	return entries, err
+}
+
+// Next returns the oldest unacknowledged record, or false when there is none
+func (l *Log) Next() (Entry, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.acked >= l.size {
+		return Entry{}, false, nil
+	}
+	entries, _, err := l.readN(l.acked, 1)
+	if err != nil || len(entries) == 0 {
+		return Entry{}, false, err
+	}
+	return entries[0], true, nil
+}
+
+// Ack marks e, which must be the record Next returned, as handled. The file is
+// emptied once nothing is left unacknowledged.
+func (l *Log) Ack(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e.end <= l.acked {
+		return nil
+	}
+
+	l.acked = e.end
+	l.pending--
+	if l.acked >= l.size {
+		if err := l.file.Truncate(0); err != nil {
+			return fmt.Errorf("failed to empty write-ahead log: %w", err)
+		}
+		l.size, l.acked, l.pending = 0, 0, 0
+	}
+	return writePosition(l.positionPath(), e.Seq)
+}
+
+// Stats reports the file size and how many records are waiting
+func (l *Log) Stats() (bytes int64, pending int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size, l.pending
+}
+
+// MaxBytes returns the size cap, 0 when uncapped
+func (l *Log) MaxBytes() int64 {
+	return l.maxBytes
+}
+
+// Path returns the log file's path
+func (l *Log) Path() string {
+	return l.path
+}
+
+func (l *Log) positionPath() string {
+	return l.path + ".pos"
+}
+
+// read decodes every whole record from offset on, returning them and the offset
+// just past the last one
+func (l *Log) read(offset int64) ([]Entry, int64, error) {
+	return l.readN(offset, -1)
+}
+
+// readN decodes up to n records from offset on, or all of them when n is negative
+func (l *Log) readN(offset int64, n int) ([]Entry, int64, error) {
+	end, err := l.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to read write-ahead log: %w", err)
+	}
+	reader := bufio.NewReader(io.NewSectionReader(l.file, offset, end-offset))
+
+	var entries []Entry
+	valid := offset
+	for n < 0 || len(entries) < n {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A line without its newline was torn by a crash
+			break
+		}
+		if err != nil {
+			return nil, valid, fmt.Errorf("failed to read write-ahead log: %w", err)
+		}
+
+		var rec line
+		if err := json.Unmarshal(bytes.TrimSpace(data), &rec); err != nil || crc32.ChecksumIEEE(rec.Payload) != rec.CRC {
+			break
+		}
+		valid += int64(len(data))
+		entries = append(entries, Entry{Seq: rec.Seq, Payload: rec.Payload, end: valid})
+	}
+	return entries, valid, nil
+}
+
+// readPosition loads the sequence of the last acknowledged record; a missing file
+// means nothing has been acknowledged
+func readPosition(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read write-ahead log position: %w", err)
+	}
+	seq, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed write-ahead log position %q", data)
+	}
+	return seq, nil
+}
+
+// writePosition replaces the position file atomically so a crash leaves either the
+// old or the new position
+func writePosition(path string, seq uint64) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(seq, 10)+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write write-ahead log position: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write write-ahead log position: %w", err)
+	}
+	return nil
+}